@@ -0,0 +1,11 @@
+CREATE TABLE address(tenant_id UUID NOT NULL, application_id UUID NOT NULL, address_id UUID NOT NULL, address_key TEXT NOT NULL, address_value TEXT NOT NULL, PRIMARY KEY(tenant_id, application_id, address_id, address_key));
+CREATE TABLE address_indexed_by_address_key(tenant_id UUID NOT NULL, application_id UUID NOT NULL, address_id UUID NOT NULL, address_key TEXT NOT NULL, address_value TEXT NOT NULL, PRIMARY KEY(tenant_id, application_id, address_key, address_id));
+CREATE TABLE address_metadata(tenant_id UUID NOT NULL, application_id UUID NOT NULL, address_id UUID NOT NULL, version BIGINT NOT NULL, deleted_at TIMESTAMP, expires_at TIMESTAMP, fingerprint TEXT, PRIMARY KEY(tenant_id, application_id, address_id));
+CREATE INDEX address_metadata_by_fingerprint ON address_metadata(tenant_id, application_id, fingerprint);
+CREATE TABLE address_owner(tenant_id UUID NOT NULL, application_id UUID NOT NULL, address_id UUID NOT NULL, owner_type TEXT NOT NULL, owner_id TEXT NOT NULL, PRIMARY KEY(tenant_id, application_id, address_id, owner_type, owner_id));
+CREATE INDEX address_owner_by_owner ON address_owner(tenant_id, application_id, owner_type, owner_id);
+CREATE TABLE address_history(tenant_id UUID NOT NULL, application_id UUID NOT NULL, address_id UUID NOT NULL, version BIGINT NOT NULL, changed_at TIMESTAMP NOT NULL, changed_by TEXT NOT NULL, deleted BOOLEAN NOT NULL, PRIMARY KEY(tenant_id, application_id, address_id, version));
+CREATE TABLE address_history_detail(tenant_id UUID NOT NULL, application_id UUID NOT NULL, address_id UUID NOT NULL, version BIGINT NOT NULL, address_key TEXT NOT NULL, address_value TEXT NOT NULL, PRIMARY KEY(tenant_id, application_id, address_id, version, address_key));
+CREATE TABLE erasure_certificate(certificate_id UUID NOT NULL, tenant_id UUID NOT NULL, application_id UUID NOT NULL, requested_by TEXT NOT NULL, started_at TIMESTAMP NOT NULL, completed_at TIMESTAMP, PRIMARY KEY(certificate_id));
+CREATE TABLE erasure_certificate_row(certificate_id UUID NOT NULL, table_name TEXT NOT NULL, erased_rows BIGINT NOT NULL, PRIMARY KEY(certificate_id, table_name));
+CREATE TABLE address_event_outbox(event_id UUID NOT NULL, event_type TEXT NOT NULL, tenant_id UUID NOT NULL, application_id UUID NOT NULL, address_id UUID NOT NULL, version BIGINT NOT NULL, old_address_details TEXT, new_address_details TEXT, changed_at TIMESTAMP NOT NULL, changed_by TEXT NOT NULL, PRIMARY KEY(event_id));
@@ -2,46 +2,298 @@
 package contract
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/micro-business/AddressService/business/domain"
 	"github.com/micro-business/Micro-Business-Core/system"
+	"golang.org/x/net/context"
 )
 
+// AnyVersion can be provided as the expected version of an address to skip the version check. It is negative, so it
+// never matches the version of an address, including 0 reported for the addresses created before versioning.
+const AnyVersion int64 = -1
+
+// MaxFoundAddresses is the maximum number of addresses FindByDetail returns, matching the limit of the data layer.
+const MaxFoundAddresses = 100
+
+// ConflictError is returned when the expected version of an address does not match its current version.
+type ConflictError struct {
+	AddressID       system.UUID
+	ExpectedVersion int64
+	ActualVersion   int64
+}
+
+// Error returns the error message.
+func (conflictError ConflictError) Error() string {
+	return fmt.Sprintf(
+		"Address version conflict. Address ID: %s, expected version: %d, actual version: %d",
+		conflictError.AddressID.String(),
+		conflictError.ExpectedVersion,
+		conflictError.ActualVersion)
+}
+
+// DuplicateAddressError is returned when an address with the same fingerprint already exists and creating duplicate
+// addresses is rejected.
+type DuplicateAddressError struct {
+	// AddressID is the unique identifier of the existing address.
+	AddressID system.UUID
+}
+
+// Error returns the error message.
+func (duplicateAddressError DuplicateAddressError) Error() string {
+	return fmt.Sprintf("Duplicate address. Existing address ID: %s", duplicateAddressError.AddressID.String())
+}
+
+// NotFoundError is returned when the address does not exist, e.g. it was never created, it is deleted or it has expired.
+type NotFoundError struct {
+	AddressID system.UUID
+
+	// Deleted is true if a deleted address was looked for, e.g. to restore or purge it.
+	Deleted bool
+}
+
+// Error returns the error message.
+func (notFoundError NotFoundError) Error() string {
+	if notFoundError.Deleted {
+		return fmt.Sprintf("Deleted address not found. Address ID: %s", notFoundError.AddressID.String())
+	}
+
+	return fmt.Sprintf("Address not found. Address ID: %s", notFoundError.AddressID.String())
+}
+
+// InvalidArgumentError is returned when an argument provided to the call is invalid, so the call fails the same way no
+// matter how many times it is retried.
+type InvalidArgumentError struct {
+	Message string
+}
+
+// Error returns the error message.
+func (invalidArgumentError InvalidArgumentError) Error() string {
+	return invalidArgumentError.Message
+}
+
+// UnavailableError is returned when the address storage cannot be reached or does not respond in time, so the call may
+// succeed if it is retried later.
+type UnavailableError struct {
+	Err error
+}
+
+// Error returns the error message.
+func (unavailableError UnavailableError) Error() string {
+	return fmt.Sprintf("Address storage is unavailable. Error: %s", unavailableError.Err.Error())
+}
+
 // AddressService contract, it can add new address and update/retrieve/remove an existing address.
+// The methods do not panic on invalid input parameters, they return InvalidArgumentError instead. NotFoundError is returned
+// when the address does not exist and UnavailableError when the address storage cannot be reached.
 type AddressService interface {
 	// Create creates a new address.
+	// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
 	// tenantID: Mandatory. The unique identifier of the tenant owning the address.
 	// applicationID: Mandatory. The unique identifier of the tenant's application will be owning the address.
 	// address: Mandatory. The reference to the new address information.
-	// Returns either the unique identifier of the new address or error if something goes wrong.
-	Create(tenantID, applicationID system.UUID, address domain.Address) (system.UUID, error)
+	// changedBy: Optional. Identifies who makes the change, recorded in the address history.
+	// Returns either the unique identifier of the new address along with the existing address with the same fingerprint,
+	// or DuplicateAddressError if such an address exists and duplicates are rejected, or error if something goes wrong.
+	Create(ctx context.Context, tenantID, applicationID system.UUID, address domain.Address, changedBy string) (domain.CreatedAddress, error)
+
+	// CreateMany creates several new addresses. Every address is validated and created on its own, so an invalid address
+	// or failing to create an address does not stop creating the rest of them.
+	// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+	// tenantID: Mandatory. The unique identifier of the tenant owning the addresses.
+	// applicationID: Mandatory. The unique identifier of the tenant's application will be owning the addresses.
+	// addresses: Mandatory. The new addresses information.
+	// changedBy: Optional. Identifies who makes the change, recorded in the address history.
+	// Returns either the result of creating every address in the same order as addresses or InvalidArgumentError if the
+	// owner is invalid or no address or too many addresses are provided.
+	CreateMany(ctx context.Context, tenantID, applicationID system.UUID, addresses []domain.Address, changedBy string) ([]domain.AddressResult, error)
 
 	// Update updates an existing address.
+	// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
 	// tenantID: Mandatory. The unique identifier of the tenant owning the address.
 	// applicationID: Mandatory. The unique identifier of the tenant's application will be owning the address.
 	// addressID: Mandatory. The unique identifier of the existing address.
 	// address: Mandatory. The reeference to the updated address information.
-	// Returns error if something goes wrong.
-	Update(tenantID, applicationID, addressID system.UUID, address domain.Address) error
+	// expectedVersion: Optional. The version the address is expected to have, or AnyVersion to skip the version check.
+	// changedBy: Optional. Identifies who makes the change, recorded in the address history.
+	// Returns ConflictError if the address version does not match the expected version, or error if something goes wrong.
+	Update(ctx context.Context, tenantID, applicationID, addressID system.UUID, address domain.Address, expectedVersion int64, changedBy string) error
+
+	// Patch changes only the provided details of an existing address and leaves the rest of them unchanged.
+	// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+	// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+	// applicationID: Mandatory. The unique identifier of the tenant's application will be owning the address.
+	// addressID: Mandatory. The unique identifier of the existing address.
+	// set: Optional. The address details keys to add or change along with their new values.
+	// remove: Optional. The address details keys to remove.
+	// expectedVersion: Optional. The version the address is expected to have, or AnyVersion to skip the version check.
+	// changedBy: Optional. Identifies who makes the change, recorded in the address history.
+	// Returns ConflictError if the address version does not match the expected version, or error if something goes wrong.
+	Patch(ctx context.Context, tenantID, applicationID, addressID system.UUID, set map[string]string, remove []string, expectedVersion int64, changedBy string) error
 
 	// Read retrieves an existing address information and returns only the detail which the keys provided by the keys.
+	// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
 	// tenantID: Mandatory. The unique identifier of the tenant owning the address.
 	// applicationID: Mandatory. The unique identifier of the tenant's application will be owning the address.
 	// addressID: Mandatory. The unique identifier of the existing address.
 	// keys: Mandatory. The interested address details keys to return.
 	// Returns either the address information or error if something goes wrong.
-	Read(tenantID, applicationID, addressID system.UUID, keys []string) (domain.Address, error)
+	Read(ctx context.Context, tenantID, applicationID, addressID system.UUID, keys []string) (domain.Address, error)
 
 	// ReadAll retrieves an existing address information and returns all the detail of it.
+	// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
 	// tenantID: Mandatory. The unique identifier of the tenant owning the address.
 	// applicationID: Mandatory. The unique identifier of the tenant's application will be owning the address.
 	// addressID: Mandatory. The unique identifier of the existing address.
 	// Returns either the address information or error if something goes wrong.
-	ReadAll(tenantID, applicationID, addressID system.UUID) (domain.Address, error)
+	ReadAll(ctx context.Context, tenantID, applicationID, addressID system.UUID) (domain.Address, error)
+
+	// ReadMany retrieves several existing addresses information. Every address is read on its own, so an invalid address
+	// unique identifier or failing to read an address does not stop reading the rest of them.
+	// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+	// tenantID: Mandatory. The unique identifier of the tenant owning the addresses.
+	// applicationID: Mandatory. The unique identifier of the tenant's application owning the addresses.
+	// addressIDs: Mandatory. The unique identifiers of the existing addresses.
+	// keys: Optional. The interested address details keys to return, or empty to return all the details.
+	// Returns either the result of reading every address in the same order as addressIDs or InvalidArgumentError if the
+	// owner or keys are invalid or no address or too many addresses are provided.
+	ReadMany(ctx context.Context, tenantID, applicationID system.UUID, addressIDs []system.UUID, keys []string) ([]domain.AddressResult, error)
+
+	// List retrieves a page of the addresses owned by a tenant's application. The deleted addresses are not returned.
+	// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+	// tenantID: Mandatory. The unique identifier of the tenant owning the addresses.
+	// applicationID: Mandatory. The unique identifier of the tenant's application owning the addresses.
+	// pageSize: Mandatory. The maximum number of addresses to return.
+	// cursor: Optional. The cursor returned with the previous page, or empty to return the first page.
+	// Returns either the page of addresses or error if the cursor is invalid or something goes wrong.
+	List(ctx context.Context, tenantID, applicationID system.UUID, pageSize int, cursor string) (domain.AddressPage, error)
+
+	// FindByDetail retrieves the addresses owned by a tenant's application whose details match all the provided criteria.
+	// The deleted addresses are not returned, and no more than MaxFoundAddresses addresses are returned.
+	// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+	// tenantID: Mandatory. The unique identifier of the tenant owning the addresses.
+	// applicationID: Mandatory. The unique identifier of the tenant's application owning the addresses.
+	// criteria: Mandatory. The address details keys along with the values the returned addresses must have.
+	// Returns either the matching addresses or error if something goes wrong.
+	FindByDetail(ctx context.Context, tenantID, applicationID system.UUID, criteria map[string]string) ([]domain.ListedAddress, error)
+
+	// AddOwners links an existing address to the provided owners. The owners already linked to the address are kept.
+	// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+	// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+	// applicationID: Mandatory. The unique identifier of the tenant's application owning the address.
+	// addressID: Mandatory. The unique identifier of the existing address.
+	// owners: Mandatory. The owners to link the address to.
+	// Returns error if the address does not exist, or if something goes wrong.
+	AddOwners(ctx context.Context, tenantID, applicationID, addressID system.UUID, owners []domain.AddressOwner) error
+
+	// RemoveOwners unlinks an existing address from the provided owners. The owners not linked to the address are skipped.
+	// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+	// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+	// applicationID: Mandatory. The unique identifier of the tenant's application owning the address.
+	// addressID: Mandatory. The unique identifier of the existing address.
+	// owners: Mandatory. The owners to unlink the address from.
+	// Returns error if the address does not exist, or if something goes wrong.
+	RemoveOwners(ctx context.Context, tenantID, applicationID, addressID system.UUID, owners []domain.AddressOwner) error
+
+	// ReadOwners retrieves the owners an existing address is linked to.
+	// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+	// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+	// applicationID: Mandatory. The unique identifier of the tenant's application owning the address.
+	// addressID: Mandatory. The unique identifier of the existing address.
+	// Returns either the owners ordered by their type and identifier or error if the address does not exist or something
+	// goes wrong.
+	ReadOwners(ctx context.Context, tenantID, applicationID, addressID system.UUID) ([]domain.AddressOwner, error)
+
+	// FindByOwner retrieves the addresses owned by a tenant's application which are linked to the provided owner. The
+	// deleted addresses are not returned.
+	// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+	// tenantID: Mandatory. The unique identifier of the tenant owning the addresses.
+	// applicationID: Mandatory. The unique identifier of the tenant's application owning the addresses.
+	// owner: Mandatory. The owner the returned addresses are linked to.
+	// Returns either the linked addresses ordered by their unique identifier or error if something goes wrong.
+	FindByOwner(ctx context.Context, tenantID, applicationID system.UUID, owner domain.AddressOwner) ([]domain.ListedAddress, error)
+
+	// History retrieves all the recorded versions of an address, including the versions recorded when it was deleted and
+	// restored.
+	// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+	// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+	// applicationID: Mandatory. The unique identifier of the tenant's application will be owning the address.
+	// addressID: Mandatory. The unique identifier of the address.
+	// Returns either the recorded versions ordered from the oldest to the newest or error if no version is recorded or
+	// something goes wrong.
+	History(ctx context.Context, tenantID, applicationID, addressID system.UUID) ([]domain.AddressHistoryEntry, error)
+
+	// ReadAt retrieves the address information as it was at the provided time.
+	// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+	// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+	// applicationID: Mandatory. The unique identifier of the tenant's application will be owning the address.
+	// addressID: Mandatory. The unique identifier of the address.
+	// timestamp: Mandatory. The time to return the address information at.
+	// Returns either the address information or error if the address did not exist or was deleted at the provided time, or
+	// if something goes wrong.
+	ReadAt(ctx context.Context, tenantID, applicationID, addressID system.UUID, timestamp time.Time) (domain.Address, error)
 
-	// Delete deletes an existing address information.
+	// Delete marks an existing address as deleted. The deleted address is not returned anymore but it can be restored
+	// within the deleted address grace period.
+	// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
 	// tenantID: Mandatory. The unique identifier of the tenant owning the address.
 	// applicationID: Mandatory. The unique identifier of the tenant's application will be owning the address.
 	// addressID: Mandatory. The unique identifier of the existing address to remove.
+	// expectedVersion: Optional. The version the address is expected to have, or AnyVersion to skip the version check.
+	// changedBy: Optional. Identifies who makes the change, recorded in the address history.
+	// Returns ConflictError if the address version does not match the expected version, or error if something goes wrong.
+	Delete(ctx context.Context, tenantID, applicationID, addressID system.UUID, expectedVersion int64, changedBy string) error
+
+	// DeleteMany marks several existing addresses as deleted, skipping the version check. Every address is deleted on its
+	// own, so an invalid address unique identifier or failing to delete an address does not stop deleting the rest of them.
+	// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+	// tenantID: Mandatory. The unique identifier of the tenant owning the addresses.
+	// applicationID: Mandatory. The unique identifier of the tenant's application owning the addresses.
+	// addressIDs: Mandatory. The unique identifiers of the existing addresses to remove.
+	// changedBy: Optional. Identifies who makes the change, recorded in the address history.
+	// Returns either the result of deleting every address in the same order as addressIDs or InvalidArgumentError if the
+	// owner is invalid or no address or too many addresses are provided.
+	DeleteMany(ctx context.Context, tenantID, applicationID system.UUID, addressIDs []system.UUID, changedBy string) ([]domain.AddressResult, error)
+
+	// Restore restores an address deleted within the deleted address grace period.
+	// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+	// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+	// applicationID: Mandatory. The unique identifier of the tenant's application will be owning the address.
+	// addressID: Mandatory. The unique identifier of the deleted address to restore.
+	// changedBy: Optional. Identifies who makes the change, recorded in the address history.
+	// Returns error if the deleted address does not exist or its grace period is over, or if something goes wrong.
+	Restore(ctx context.Context, tenantID, applicationID, addressID system.UUID, changedBy string) error
+
+	// Purge removes a deleted address for good without waiting for its grace period to be over.
+	// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+	// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+	// applicationID: Mandatory. The unique identifier of the tenant's application will be owning the address.
+	// addressID: Mandatory. The unique identifier of the deleted address to purge.
+	// Returns error if the deleted address does not exist, or if something goes wrong.
+	Purge(ctx context.Context, tenantID, applicationID, addressID system.UUID) error
+
+	// PurgeExpired removes for good all the deleted addresses whose grace period is over.
+	// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
 	// Returns error if something goes wrong.
-	Delete(tenantID, applicationID, addressID system.UUID) error
+	PurgeExpired(ctx context.Context) error
+
+	// PurgeTenant removes for good all the addresses of all the applications of a tenant along with their history, whether
+	// they are deleted or not, and records an erasure certificate. An interrupted erasure continues where it stopped when
+	// it is requested again.
+	// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+	// tenantID: Mandatory. The unique identifier of the tenant to erase.
+	// requestedBy: Optional. Identifies who requests the erasure, recorded in the erasure certificate.
+	// Returns either the erasure certificate or error if something goes wrong.
+	PurgeTenant(ctx context.Context, tenantID system.UUID, requestedBy string) (domain.ErasureCertificate, error)
+
+	// PurgeApplication removes for good all the addresses of a tenant's application along with their history, whether they
+	// are deleted or not, and records an erasure certificate. An interrupted erasure continues where it stopped when it is
+	// requested again.
+	// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+	// tenantID: Mandatory. The unique identifier of the tenant owning the application.
+	// applicationID: Mandatory. The unique identifier of the tenant's application to erase.
+	// requestedBy: Optional. Identifies who requests the erasure, recorded in the erasure certificate.
+	// Returns either the erasure certificate or error if something goes wrong.
+	PurgeApplication(ctx context.Context, tenantID, applicationID system.UUID, requestedBy string) (domain.ErasureCertificate, error)
 }
@@ -0,0 +1,16 @@
+package contract
+
+import (
+	"github.com/micro-business/AddressService/business/domain"
+	"golang.org/x/net/context"
+)
+
+// EventPublisher publishes the address events to the downstream services.
+type EventPublisher interface {
+	// Publish publishes the provided events in order. The events are removed from the outbox only if Publish succeeds,
+	// so they are published again if it fails, even if some of them were already published.
+	// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+	// events: Mandatory. The events to publish.
+	// Returns error if something goes wrong.
+	Publish(ctx context.Context, events []domain.AddressEvent) error
+}
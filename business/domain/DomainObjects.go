@@ -1,7 +1,150 @@
 // Package domain defines domain object used in Address service
 package domain
 
+import (
+	"time"
+
+	"github.com/micro-business/Micro-Business-Core/system"
+)
+
 // Address defines how an address should look like
 type Address struct {
 	AddressDetails map[string]string
+
+	// Version is the current version of the address returned when reading the address. The version of a new address is 1
+	// and it is increased every time the address changes.
+	Version int64
+
+	// TTL is the time to live of the address. When provided on create or update, the address expires and disappears on its
+	// own after it; zero means the address never expires. When reading the address, it is the remaining time before the
+	// address expires, or zero if it never expires.
+	TTL time.Duration
+}
+
+// AddressHistoryEntry defines a version of an address recorded in the address history.
+type AddressHistoryEntry struct {
+	// Address holds the address details and the version of the address recorded by this entry.
+	Address Address
+
+	// ChangedAt is the time the address was changed at.
+	ChangedAt time.Time
+
+	// ChangedBy identifies who changed the address, or is empty if it was not provided.
+	ChangedBy string
+
+	// Deleted is true if the address was deleted by this change.
+	Deleted bool
+}
+
+// ListedAddress defines an address returned when listing addresses along with its unique identifier.
+type ListedAddress struct {
+	AddressID system.UUID
+	Address   Address
+}
+
+// AddressOwner defines a reference to an entity owning an address, e.g. the customer the address belongs to.
+type AddressOwner struct {
+	// Type is the type of the owning entity, e.g. customer.
+	Type string
+
+	// ID is the identifier of the owning entity within its type.
+	ID string
+}
+
+// AddressPage defines a page of addresses returned when listing addresses.
+type AddressPage struct {
+	// Addresses holds the addresses in the page.
+	Addresses []ListedAddress
+
+	// NextCursor is the cursor to provide to return the next page, or empty if there is no more address to return.
+	// A page returned for a non-empty cursor can be empty.
+	NextCursor string
+}
+
+// AddressResult defines the result of creating, reading or deleting one of the addresses in a bulk operation.
+type AddressResult struct {
+	// AddressID is the unique identifier of the address, or empty if the address could not be created.
+	AddressID system.UUID
+
+	// Address holds the address information returned when reading addresses.
+	Address Address
+
+	// Err is the error happened when the address was created, read or deleted, or nil if it succeeded.
+	Err error
+}
+
+// CreatedAddress defines the result of creating an address.
+type CreatedAddress struct {
+	// AddressID is the unique identifier of the new address, or of the existing address with the same fingerprint if it is
+	// returned instead of creating a new one.
+	AddressID system.UUID
+
+	// DuplicateOf is the unique identifier of an existing address with the same fingerprint, or empty if there is none or
+	// duplicate detection is disabled.
+	DuplicateOf system.UUID
+}
+
+// ErasureCertificate records the erasure of all the addresses of a tenant or a tenant's application.
+type ErasureCertificate struct {
+	// CertificateID is the unique identifier of the erasure. It is kept when an interrupted erasure is resumed.
+	CertificateID system.UUID
+
+	TenantID system.UUID
+
+	// ApplicationID is the unique identifier of the erased application, or empty if all the tenant's applications were
+	// erased.
+	ApplicationID system.UUID
+
+	// RequestedBy identifies who requested the erasure, or is empty if it was not provided.
+	RequestedBy string
+
+	// StartedAt is the time the erasure was first started at.
+	StartedAt time.Time
+
+	// CompletedAt is the time the last row was erased at.
+	CompletedAt time.Time
+
+	// ErasedRows holds the number of rows erased from every table keyed by the table name.
+	ErasedRows map[string]int64
+}
+
+// AddressEventType identifies the change of an address recorded by an address event.
+type AddressEventType string
+
+const (
+	// AddressCreated is published when an address is created.
+	AddressCreated AddressEventType = "AddressCreated"
+
+	// AddressUpdated is published when an address is updated or patched.
+	AddressUpdated AddressEventType = "AddressUpdated"
+
+	// AddressDeleted is published when an address is deleted.
+	AddressDeleted AddressEventType = "AddressDeleted"
+)
+
+// AddressEvent records a change of an address published to the downstream services.
+type AddressEvent struct {
+	// EventID is the unique identifier of the event. An event can be published more than once, so the consumers use it
+	// to skip the events they have already handled.
+	EventID system.UUID
+
+	EventType     AddressEventType
+	TenantID      system.UUID
+	ApplicationID system.UUID
+	AddressID     system.UUID
+
+	// Version is the version of the address made by the change.
+	Version int64
+
+	// OldAddressDetails holds the address details before the change, or is nil for AddressCreated.
+	OldAddressDetails map[string]string
+
+	// NewAddressDetails holds the address details after the change, or is nil for AddressDeleted.
+	NewAddressDetails map[string]string
+
+	// ChangedAt is the time the address was changed at.
+	ChangedAt time.Time
+
+	// ChangedBy identifies who changed the address, or is empty if it was not provided.
+	ChangedBy string
 }
@@ -0,0 +1,89 @@
+package service
+
+import (
+	businessContract "github.com/micro-business/AddressService/business/contract"
+	"github.com/micro-business/AddressService/business/domain"
+	"github.com/micro-business/AddressService/data/contract"
+	"github.com/micro-business/Micro-Business-Core/common/diagnostics"
+	"golang.org/x/net/context"
+)
+
+// defaultEventRelayBatchSize is the number of events published at a time when EventRelay.BatchSize is not provided.
+const defaultEventRelayBatchSize = 100
+
+// EventRelay publishes the address events written to the outbox by the address data service. An event is removed from
+// the outbox only once it is published, so every event is published at least once, and more than once if the relay
+// stops between publishing the event and removing it.
+type EventRelay struct {
+	AddressDataService contract.AddressDataService
+	EventPublisher     businessContract.EventPublisher
+
+	// BatchSize is the number of events read from the outbox and published at a time. 100 events are published at a time
+	// if it is not provided.
+	BatchSize int
+}
+
+// Relay publishes the events waiting in the outbox batch by batch until the outbox is empty, removing every batch from the
+// outbox once it is published.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// Returns the number of published events, along with error if something goes wrong.
+func (eventRelay EventRelay) Relay(ctx context.Context) (int, error) {
+	diagnostics.IsNotNil(eventRelay.AddressDataService, "eventRelay.AddressDataService", "AddressDataService must be provided.")
+	diagnostics.IsNotNil(eventRelay.EventPublisher, "eventRelay.EventPublisher", "EventPublisher must be provided.")
+
+	batchSize := eventRelay.BatchSize
+
+	if batchSize <= 0 {
+		batchSize = defaultEventRelayBatchSize
+	}
+
+	published := 0
+
+	for {
+		events, err := eventRelay.AddressDataService.ReadEvents(ctx, batchSize)
+
+		if err != nil {
+			return published, mapFromDataError(err)
+		}
+
+		if len(events) == 0 {
+			return published, nil
+		}
+
+		if err = eventRelay.EventPublisher.Publish(ctx, mapFromDataAddressEvents(events)); err != nil {
+			return published, err
+		}
+
+		if err = eventRelay.AddressDataService.RemoveEvents(ctx, events); err != nil {
+			return published, mapFromDataError(err)
+		}
+
+		published += len(events)
+
+		if len(events) < batchSize {
+			return published, nil
+		}
+	}
+}
+
+// mapFromDataAddressEvents maps the address events returned by the address data service to domain objects.
+func mapFromDataAddressEvents(events []contract.AddressEvent) []domain.AddressEvent {
+	mappedEvents := make([]domain.AddressEvent, 0, len(events))
+
+	for _, event := range events {
+		mappedEvents = append(mappedEvents, domain.AddressEvent{
+			EventID:           event.EventID,
+			EventType:         domain.AddressEventType(event.EventType),
+			TenantID:          event.TenantID,
+			ApplicationID:     event.ApplicationID,
+			AddressID:         event.AddressID,
+			Version:           event.Version,
+			OldAddressDetails: event.OldAddressDetails,
+			NewAddressDetails: event.NewAddressDetails,
+			ChangedAt:         event.ChangedAt,
+			ChangedBy:         event.ChangedBy,
+		})
+	}
+
+	return mappedEvents
+}
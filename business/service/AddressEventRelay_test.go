@@ -0,0 +1,230 @@
+package service_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	businessContract "github.com/micro-business/AddressService/business/contract"
+	"github.com/micro-business/AddressService/business/domain"
+	"github.com/micro-business/AddressService/business/service"
+	"github.com/micro-business/AddressService/data/contract"
+	"github.com/micro-business/Micro-Business-Core/system"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+// failingEventPublisher is an event publisher which fails to publish any event.
+type failingEventPublisher struct {
+	err error
+}
+
+func (publisher failingEventPublisher) Publish(ctx context.Context, events []domain.AddressEvent) error {
+	return publisher.err
+}
+
+var _ = Describe("Relay method input parameters and dependency test", func() {
+	var (
+		mockCtrl               *gomock.Controller
+		mockAddressDataService *MockAddressDataService
+	)
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockAddressDataService = NewMockAddressDataService(mockCtrl)
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	Context("when address data service not provided", func() {
+		It("should panic", func() {
+			eventRelay := service.EventRelay{EventPublisher: &service.InMemoryEventPublisher{}}
+
+			Ω(func() { eventRelay.Relay(context.Background()) }).Should(Panic())
+		})
+	})
+
+	Context("when event publisher not provided", func() {
+		It("should panic", func() {
+			eventRelay := service.EventRelay{AddressDataService: mockAddressDataService}
+
+			Ω(func() { eventRelay.Relay(context.Background()) }).Should(Panic())
+		})
+	})
+})
+
+var _ = Describe("Relay method behaviour", func() {
+	var (
+		ctx                    context.Context
+		mockCtrl               *gomock.Controller
+		eventRelay             service.EventRelay
+		mockAddressDataService *MockAddressDataService
+		publisher              *service.InMemoryEventPublisher
+		events                 []contract.AddressEvent
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockAddressDataService = NewMockAddressDataService(mockCtrl)
+		publisher = &service.InMemoryEventPublisher{}
+
+		eventRelay = service.EventRelay{AddressDataService: mockAddressDataService, EventPublisher: publisher, BatchSize: 2}
+
+		tenantID, _ := system.RandomUUID()
+		applicationID, _ := system.RandomUUID()
+		addressID, _ := system.RandomUUID()
+		events = []contract.AddressEvent{}
+
+		for idx, eventType := range []contract.AddressEventType{contract.AddressCreated, contract.AddressUpdated, contract.AddressDeleted} {
+			eventID, _ := system.RandomUUID()
+			events = append(events, contract.AddressEvent{
+				EventID:       eventID,
+				EventType:     eventType,
+				TenantID:      tenantID,
+				ApplicationID: applicationID,
+				AddressID:     addressID,
+				Version:       int64(idx + 1),
+			})
+		}
+
+		events[0].NewAddressDetails = map[string]string{"City": "Christchurch"}
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	Context("when there are events waiting in the outbox", func() {
+		It("should publish the events batch by batch and remove every batch once it is published", func() {
+			gomock.InOrder(
+				mockAddressDataService.EXPECT().ReadEvents(ctx, 2).Return(events[:2], nil),
+				mockAddressDataService.EXPECT().RemoveEvents(ctx, events[:2]).Return(nil),
+				mockAddressDataService.EXPECT().ReadEvents(ctx, 2).Return(events[2:], nil),
+				mockAddressDataService.EXPECT().RemoveEvents(ctx, events[2:]).Return(nil))
+
+			published, err := eventRelay.Relay(ctx)
+
+			Expect(err).To(BeNil())
+			Expect(published).To(Equal(3))
+
+			publishedEvents := publisher.Events()
+
+			Expect(publishedEvents).To(HaveLen(3))
+			Expect(publishedEvents[0].EventID).To(Equal(events[0].EventID))
+			Expect(publishedEvents[0].EventType).To(Equal(domain.AddressCreated))
+			Expect(publishedEvents[0].NewAddressDetails).To(Equal(map[string]string{"City": "Christchurch"}))
+			Expect(publishedEvents[2].EventType).To(Equal(domain.AddressDeleted))
+			Expect(publishedEvents[2].Version).To(Equal(int64(3)))
+		})
+
+		It("should read the outbox again until it is empty if the batch is full", func() {
+			gomock.InOrder(
+				mockAddressDataService.EXPECT().ReadEvents(ctx, 2).Return(events[:2], nil),
+				mockAddressDataService.EXPECT().RemoveEvents(ctx, events[:2]).Return(nil),
+				mockAddressDataService.EXPECT().ReadEvents(ctx, 2).Return([]contract.AddressEvent{}, nil))
+
+			published, err := eventRelay.Relay(ctx)
+
+			Expect(err).To(BeNil())
+			Expect(published).To(Equal(2))
+		})
+
+		It("should keep the events in the outbox if publishing them fails", func() {
+			expectedError := errors.New("broker unavailable")
+			eventRelay.EventPublisher = failingEventPublisher{err: expectedError}
+
+			mockAddressDataService.
+				EXPECT().
+				ReadEvents(ctx, 2).
+				Return(events[:2], nil)
+
+			published, err := eventRelay.Relay(ctx)
+
+			Expect(err).To(Equal(expectedError))
+			Expect(published).To(Equal(0))
+		})
+
+		It("should return the typed error returned by address data service as the business layer error", func() {
+			gomock.InOrder(
+				mockAddressDataService.EXPECT().ReadEvents(ctx, 2).Return(events[:2], nil),
+				mockAddressDataService.EXPECT().RemoveEvents(ctx, events[:2]).Return(contract.UnavailableError{Err: errors.New("no hosts")}))
+
+			published, err := eventRelay.Relay(ctx)
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.UnavailableError{}))
+			Expect(published).To(Equal(0))
+		})
+	})
+
+	Context("when the outbox is empty", func() {
+		It("should publish nothing", func() {
+			mockAddressDataService.
+				EXPECT().
+				ReadEvents(ctx, 2).
+				Return([]contract.AddressEvent{}, nil)
+
+			published, err := eventRelay.Relay(ctx)
+
+			Expect(err).To(BeNil())
+			Expect(published).To(Equal(0))
+			Expect(publisher.Events()).To(BeEmpty())
+		})
+	})
+})
+
+var _ = Describe("FileEventPublisher behaviour", func() {
+	Context("when publishing events", func() {
+		It("should append every event as a JSON object on its own line", func() {
+			directory, err := ioutil.TempDir("", "events")
+
+			Expect(err).To(BeNil())
+
+			defer os.RemoveAll(directory)
+
+			eventID, _ := system.RandomUUID()
+			publisher := service.FileEventPublisher{Path: filepath.Join(directory, "events.jsonl")}
+			event := domain.AddressEvent{
+				EventID:           eventID,
+				EventType:         domain.AddressUpdated,
+				Version:           2,
+				OldAddressDetails: map[string]string{"City": "Christchurch"},
+				NewAddressDetails: map[string]string{"City": "Wellington"},
+			}
+
+			Expect(publisher.Publish(context.Background(), []domain.AddressEvent{event})).To(BeNil())
+			Expect(publisher.Publish(context.Background(), []domain.AddressEvent{event})).To(BeNil())
+
+			content, err := ioutil.ReadFile(publisher.Path)
+
+			Expect(err).To(BeNil())
+
+			lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+
+			Expect(lines).To(HaveLen(2))
+
+			var publishedEvent map[string]interface{}
+
+			Expect(json.Unmarshal([]byte(lines[1]), &publishedEvent)).To(BeNil())
+			Expect(publishedEvent["eventId"]).To(Equal(eventID.String()))
+			Expect(publishedEvent["eventType"]).To(Equal("AddressUpdated"))
+			Expect(publishedEvent["oldAddressDetails"]).To(Equal(map[string]interface{}{"City": "Christchurch"}))
+			Expect(publishedEvent["newAddressDetails"]).To(Equal(map[string]interface{}{"City": "Wellington"}))
+		})
+	})
+})
+
+func TestEventRelay(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Relay method input parameters and dependency test")
+	RunSpecs(t, "Relay method behaviour")
+	RunSpecs(t, "FileEventPublisher behaviour")
+}
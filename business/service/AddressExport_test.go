@@ -0,0 +1,200 @@
+package service_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	businessContract "github.com/micro-business/AddressService/business/contract"
+	"github.com/micro-business/AddressService/business/service"
+	"github.com/micro-business/AddressService/data/contract"
+	"github.com/micro-business/Micro-Business-Core/system"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("Export method input parameters and dependency test", func() {
+	var (
+		ctx                    context.Context
+		mockCtrl               *gomock.Controller
+		addressExporter        *service.AddressExporter
+		mockAddressDataService *MockAddressDataService
+		tenantID               system.UUID
+		applicationID          system.UUID
+		buffer                 *bytes.Buffer
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockAddressDataService = NewMockAddressDataService(mockCtrl)
+
+		addressExporter = &service.AddressExporter{AddressDataService: mockAddressDataService}
+
+		tenantID, _ = system.RandomUUID()
+		applicationID, _ = system.RandomUUID()
+		buffer = &bytes.Buffer{}
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	Context("when address data service not provided", func() {
+		It("should panic", func() {
+			addressExporter.AddressDataService = nil
+
+			Ω(func() { addressExporter.Export(ctx, tenantID, applicationID, service.JSONLinesFormat, "", buffer, nil) }).Should(Panic())
+		})
+	})
+
+	Describe("Input Parameters", func() {
+		It("should return InvalidArgumentError when empty tenant unique identifier provided", func() {
+			err := addressExporter.Export(ctx, system.EmptyUUID, applicationID, service.JSONLinesFormat, "", buffer, nil)
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+		})
+
+		It("should return InvalidArgumentError when empty application unique identifier provided", func() {
+			err := addressExporter.Export(ctx, tenantID, system.EmptyUUID, service.JSONLinesFormat, "", buffer, nil)
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+		})
+
+		It("should return InvalidArgumentError when unknown format provided", func() {
+			err := addressExporter.Export(ctx, tenantID, applicationID, "xml", "", buffer, nil)
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+		})
+	})
+})
+
+var _ = Describe("Export method behaviour", func() {
+	var (
+		ctx                    context.Context
+		mockCtrl               *gomock.Controller
+		addressExporter        *service.AddressExporter
+		mockAddressDataService *MockAddressDataService
+		tenantID               system.UUID
+		applicationID          system.UUID
+		firstAddressID         system.UUID
+		secondAddressID        system.UUID
+		buffer                 *bytes.Buffer
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockAddressDataService = NewMockAddressDataService(mockCtrl)
+
+		addressExporter = &service.AddressExporter{AddressDataService: mockAddressDataService, PageSize: 1}
+
+		tenantID, _ = system.RandomUUID()
+		applicationID, _ = system.RandomUUID()
+		firstAddressID, _ = system.ParseUUID("11111111-1111-1111-1111-111111111111")
+		secondAddressID, _ = system.ParseUUID("22222222-2222-2222-2222-222222222222")
+		buffer = &bytes.Buffer{}
+
+		mockAddressDataService.
+			EXPECT().
+			List(ctx, tenantID, applicationID, 1, "").
+			Return(
+				contract.AddressPage{
+					Addresses: []contract.ListedAddress{
+						{AddressID: firstAddressID, Address: contract.Address{AddressDetails: map[string]string{"Postcode": "8011", "City": "Christchurch"}, Version: 2}},
+					},
+					NextCursor: "next",
+				},
+				nil).
+			AnyTimes()
+
+		mockAddressDataService.
+			EXPECT().
+			List(ctx, tenantID, applicationID, 1, "next").
+			Return(
+				contract.AddressPage{
+					Addresses: []contract.ListedAddress{
+						{AddressID: secondAddressID, Address: contract.Address{AddressDetails: map[string]string{"City": "Wellington"}, TTL: time.Hour}},
+					},
+				},
+				nil).
+			AnyTimes()
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	It("should write every address on its own line in JSON Lines format", func() {
+		err := addressExporter.Export(ctx, tenantID, applicationID, service.JSONLinesFormat, "", buffer, nil)
+
+		Expect(err).To(BeNil())
+		Expect(buffer.String()).To(Equal(
+			`{"addressId":"11111111-1111-1111-1111-111111111111","addressDetails":{"City":"Christchurch","Postcode":"8011"}}` + "\n" +
+				`{"addressId":"22222222-2222-2222-2222-222222222222","addressDetails":{"City":"Wellington"},"ttl":3600}` + "\n"))
+	})
+
+	It("should write every address detail on its own row in CSV format", func() {
+		err := addressExporter.Export(ctx, tenantID, applicationID, service.CSVFormat, "", buffer, nil)
+
+		Expect(err).To(BeNil())
+		Expect(buffer.String()).To(Equal(
+			"address_id,ttl,address_key,address_value\n" +
+				"11111111-1111-1111-1111-111111111111,0,City,Christchurch\n" +
+				"11111111-1111-1111-1111-111111111111,0,Postcode,8011\n" +
+				"22222222-2222-2222-2222-222222222222,3600,City,Wellington\n"))
+	})
+
+	It("should call checkpoint with the cursor to resume from after every page", func() {
+		cursors := []string{}
+
+		err := addressExporter.Export(ctx, tenantID, applicationID, service.CSVFormat, "", buffer, func(cursor string) error {
+			cursors = append(cursors, cursor)
+
+			return nil
+		})
+
+		Expect(err).To(BeNil())
+		Expect(cursors).To(Equal([]string{"next", ""}))
+	})
+
+	It("should resume from the provided cursor without writing the CSV header", func() {
+		err := addressExporter.Export(ctx, tenantID, applicationID, service.CSVFormat, "next", buffer, nil)
+
+		Expect(err).To(BeNil())
+		Expect(buffer.String()).To(Equal("22222222-2222-2222-2222-222222222222,3600,City,Wellington\n"))
+	})
+
+	It("should stop and return the error returned by checkpoint", func() {
+		expectedError := errors.New("Checkpoint cannot be saved")
+
+		err := addressExporter.Export(ctx, tenantID, applicationID, service.JSONLinesFormat, "", buffer, func(cursor string) error {
+			return expectedError
+		})
+
+		Expect(err).To(Equal(expectedError))
+	})
+
+	It("should return the error returned by address data service", func() {
+		mockAddressDataService = NewMockAddressDataService(mockCtrl)
+		addressExporter.AddressDataService = mockAddressDataService
+
+		mockAddressDataService.
+			EXPECT().
+			List(ctx, tenantID, applicationID, 1, "").
+			Return(contract.AddressPage{}, contract.UnavailableError{Err: errors.New("Cluster is down")})
+
+		err := addressExporter.Export(ctx, tenantID, applicationID, service.JSONLinesFormat, "", buffer, nil)
+
+		Expect(err).To(BeAssignableToTypeOf(businessContract.UnavailableError{}))
+	})
+})
+
+func TestExport(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Export method input parameters and dependency test")
+	RunSpecs(t, "Export method behaviour")
+}
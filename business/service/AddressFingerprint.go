@@ -0,0 +1,124 @@
+package service
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/micro-business/AddressService/business/domain"
+	"github.com/micro-business/AddressService/data/contract"
+	"github.com/micro-business/Micro-Business-Core/system"
+	"golang.org/x/net/context"
+)
+
+// DuplicateAddressPolicy defines what Create does when an address with the same fingerprint already exists in the same
+// tenant's application.
+type DuplicateAddressPolicy string
+
+const (
+	// IgnoreDuplicateAddress disables duplicate detection, so the addresses are neither fingerprinted nor looked for.
+	IgnoreDuplicateAddress DuplicateAddressPolicy = ""
+
+	// WarnOnDuplicateAddress creates the address and returns the existing address as the address it is a duplicate of.
+	WarnOnDuplicateAddress DuplicateAddressPolicy = "warn"
+
+	// RejectDuplicateAddress does not create the address and returns DuplicateAddressError instead.
+	RejectDuplicateAddress DuplicateAddressPolicy = "reject"
+
+	// ReturnExistingAddress does not create the address and returns the unique identifier of the existing address instead.
+	ReturnExistingAddress DuplicateAddressPolicy = "return-existing"
+)
+
+// addressAbbreviations maps the common address abbreviations to the words they are expanded to when the address details
+// are fingerprinted.
+var addressAbbreviations = map[string]string{
+	"apt":  "apartment",
+	"ave":  "avenue",
+	"blvd": "boulevard",
+	"cres": "crescent",
+	"ct":   "court",
+	"dr":   "drive",
+	"e":    "east",
+	"fl":   "floor",
+	"hwy":  "highway",
+	"ln":   "lane",
+	"n":    "north",
+	"ne":   "northeast",
+	"nw":   "northwest",
+	"pde":  "parade",
+	"pl":   "place",
+	"rd":   "road",
+	"s":    "south",
+	"se":   "southeast",
+	"st":   "street",
+	"ste":  "suite",
+	"sw":   "southwest",
+	"tce":  "terrace",
+	"w":    "west",
+}
+
+// mapToFingerprintedDataAddress maps the domain address object to the Address object used in data layer along with the
+// fingerprint of its details, or without a fingerprint if duplicate detection is disabled.
+func (addressService AddressService) mapToFingerprintedDataAddress(address domain.Address) contract.Address {
+	dataAddress := mapToDataAddress(address)
+
+	if addressService.DuplicatePolicy != IgnoreDuplicateAddress {
+		dataAddress.Fingerprint = fingerprintAddressDetails(address.AddressDetails)
+	}
+
+	return dataAddress
+}
+
+// findDuplicateAddress returns the unique identifier of an existing address of the tenant's application whose details
+// have the provided fingerprint, or empty UUID if there is none. An address patched since it was created or updated keeps
+// its former fingerprint in the data layer, so the current details of every found address are fingerprinted again.
+func (addressService AddressService) findDuplicateAddress(ctx context.Context, tenantID, applicationID system.UUID, fingerprint string) (system.UUID, error) {
+	listedAddresses, err := addressService.AddressDataService.FindByFingerprint(ctx, tenantID, applicationID, fingerprint)
+
+	if err != nil {
+		return system.EmptyUUID, mapFromDataError(err)
+	}
+
+	for _, listedAddress := range listedAddresses {
+		if fingerprintAddressDetails(listedAddress.Address.AddressDetails) == fingerprint {
+			return listedAddress.AddressID, nil
+		}
+	}
+
+	return system.EmptyUUID, nil
+}
+
+// fingerprintAddressDetails returns the fingerprint of the provided address details. The keys are case-folded and the
+// values are case-folded, split into words on whitespace, full stops and commas, and joined back by single spaces after
+// expanding the common abbreviations, so the details differing only by them have the same fingerprint. The details left
+// empty are skipped. The fingerprint is the sorted normalized details, which the data layer hashes with the tenant's key
+// before storing it, so it is never stored in plaintext.
+func fingerprintAddressDetails(addressDetails map[string]string) string {
+	normalizedDetails := make([]string, 0, len(addressDetails))
+
+	for key, value := range addressDetails {
+		if normalizedValue := normalizeAddressValue(value); normalizedValue != "" {
+			normalizedDetails = append(normalizedDetails, strings.ToLower(key)+"\x00"+normalizedValue)
+		}
+	}
+
+	sort.Strings(normalizedDetails)
+
+	return strings.Join(normalizedDetails, "\n")
+}
+
+// normalizeAddressValue case-folds the provided address value, collapses its whitespace and expands the common
+// abbreviations in it.
+func normalizeAddressValue(value string) string {
+	words := strings.FieldsFunc(strings.ToLower(value), func(r rune) bool {
+		return unicode.IsSpace(r) || r == '.' || r == ','
+	})
+
+	for index, word := range words {
+		if expandedWord, ok := addressAbbreviations[word]; ok {
+			words[index] = expandedWord
+		}
+	}
+
+	return strings.Join(words, " ")
+}
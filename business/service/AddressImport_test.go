@@ -0,0 +1,280 @@
+package service_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	businessContract "github.com/micro-business/AddressService/business/contract"
+	"github.com/micro-business/AddressService/business/service"
+	"github.com/micro-business/AddressService/data/contract"
+	"github.com/micro-business/Micro-Business-Core/system"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("Import method input parameters and dependency test", func() {
+	var (
+		ctx                    context.Context
+		mockCtrl               *gomock.Controller
+		addressImporter        *service.AddressImporter
+		mockAddressDataService *MockAddressDataService
+		tenantID               system.UUID
+		applicationID          system.UUID
+		report                 func(result service.ImportResult) error
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockAddressDataService = NewMockAddressDataService(mockCtrl)
+
+		addressImporter = &service.AddressImporter{AddressDataService: mockAddressDataService}
+
+		tenantID, _ = system.RandomUUID()
+		applicationID, _ = system.RandomUUID()
+		report = func(result service.ImportResult) error { return nil }
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	Context("when address data service not provided", func() {
+		It("should panic", func() {
+			addressImporter.AddressDataService = nil
+
+			Ω(func() {
+				addressImporter.Import(ctx, tenantID, applicationID, service.JSONLinesFormat, strings.NewReader(""), 0, "", report)
+			}).Should(Panic())
+		})
+	})
+
+	Describe("Input Parameters", func() {
+		It("should return InvalidArgumentError when empty tenant unique identifier provided", func() {
+			err := addressImporter.Import(ctx, system.EmptyUUID, applicationID, service.JSONLinesFormat, strings.NewReader(""), 0, "", report)
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+		})
+
+		It("should return InvalidArgumentError when empty application unique identifier provided", func() {
+			err := addressImporter.Import(ctx, tenantID, system.EmptyUUID, service.JSONLinesFormat, strings.NewReader(""), 0, "", report)
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+		})
+
+		It("should return InvalidArgumentError when unknown format provided", func() {
+			err := addressImporter.Import(ctx, tenantID, applicationID, "xml", strings.NewReader(""), 0, "", report)
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+		})
+
+		It("should return InvalidArgumentError when the CSV header is not valid", func() {
+			err := addressImporter.Import(ctx, tenantID, applicationID, service.CSVFormat, strings.NewReader("id,ttl,key,value\n"), 0, "", report)
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+		})
+
+		It("should return InvalidArgumentError when an address cannot be read", func() {
+			err := addressImporter.Import(ctx, tenantID, applicationID, service.JSONLinesFormat, strings.NewReader("{\n"), 0, "", report)
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+		})
+	})
+})
+
+var _ = Describe("Import method behaviour", func() {
+	var (
+		ctx                    context.Context
+		mockCtrl               *gomock.Controller
+		addressImporter        *service.AddressImporter
+		mockAddressDataService *MockAddressDataService
+		tenantID               system.UUID
+		applicationID          system.UUID
+		firstAddressID         system.UUID
+		secondAddressID        system.UUID
+		results                []service.ImportResult
+		report                 func(result service.ImportResult) error
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockAddressDataService = NewMockAddressDataService(mockCtrl)
+
+		addressImporter = &service.AddressImporter{AddressDataService: mockAddressDataService}
+
+		tenantID, _ = system.RandomUUID()
+		applicationID, _ = system.RandomUUID()
+		firstAddressID, _ = system.RandomUUID()
+		secondAddressID, _ = system.RandomUUID()
+		results = []service.ImportResult{}
+		report = func(result service.ImportResult) error {
+			results = append(results, result)
+
+			return nil
+		}
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	It("should create every address read in JSON Lines format", func() {
+		mockAddressDataService.
+			EXPECT().
+			Create(ctx, tenantID, applicationID, contract.Address{AddressDetails: map[string]string{"City": "Christchurch"}}, "importer").
+			Return(firstAddressID, nil)
+
+		mockAddressDataService.
+			EXPECT().
+			Create(ctx, tenantID, applicationID, contract.Address{AddressDetails: map[string]string{"City": "Wellington"}, TTL: time.Hour}, "importer").
+			Return(secondAddressID, nil)
+
+		input := `{"addressId":"a","addressDetails":{"City":"Christchurch"}}` + "\n" +
+			`{"addressId":"b","addressDetails":{"City":"Wellington"},"ttl":3600}` + "\n"
+
+		err := addressImporter.Import(ctx, tenantID, applicationID, service.JSONLinesFormat, strings.NewReader(input), 0, "importer", report)
+
+		Expect(err).To(BeNil())
+		Expect(results).To(Equal([]service.ImportResult{
+			{Record: 1, SourceAddressID: "a", AddressID: firstAddressID.String()},
+			{Record: 2, SourceAddressID: "b", AddressID: secondAddressID.String()},
+		}))
+	})
+
+	It("should create an address from the consecutive rows of the same address in CSV format", func() {
+		mockAddressDataService.
+			EXPECT().
+			Create(ctx, tenantID, applicationID, contract.Address{AddressDetails: map[string]string{"City": "Christchurch", "Postcode": "8011"}}, "").
+			Return(firstAddressID, nil)
+
+		mockAddressDataService.
+			EXPECT().
+			Create(ctx, tenantID, applicationID, contract.Address{AddressDetails: map[string]string{"City": "Wellington"}, TTL: time.Hour}, "").
+			Return(secondAddressID, nil)
+
+		input := "address_id,ttl,address_key,address_value\n" +
+			"a,0,City,Christchurch\n" +
+			"a,0,Postcode,8011\n" +
+			"b,3600,City,Wellington\n"
+
+		err := addressImporter.Import(ctx, tenantID, applicationID, service.CSVFormat, strings.NewReader(input), 0, "", report)
+
+		Expect(err).To(BeNil())
+		Expect(results).To(HaveLen(2))
+		Expect(results[0].AddressID).To(Equal(firstAddressID.String()))
+		Expect(results[1].AddressID).To(Equal(secondAddressID.String()))
+	})
+
+	It("should skip the addresses already imported", func() {
+		mockAddressDataService.
+			EXPECT().
+			Create(ctx, tenantID, applicationID, contract.Address{AddressDetails: map[string]string{"City": "Wellington"}}, "").
+			Return(secondAddressID, nil)
+
+		input := `{"addressDetails":{"City":"Christchurch"}}` + "\n" + `{"addressDetails":{"City":"Wellington"}}` + "\n"
+
+		err := addressImporter.Import(ctx, tenantID, applicationID, service.JSONLinesFormat, strings.NewReader(input), 1, "", report)
+
+		Expect(err).To(BeNil())
+		Expect(results).To(Equal([]service.ImportResult{{Record: 2, AddressID: secondAddressID.String()}}))
+	})
+
+	It("should report and skip the addresses that are not valid", func() {
+		mockAddressDataService.
+			EXPECT().
+			Create(ctx, tenantID, applicationID, contract.Address{AddressDetails: map[string]string{"City": "Wellington"}}, "").
+			Return(secondAddressID, nil)
+
+		input := `{"addressDetails":{"City name":"Christchurch"}}` + "\n" +
+			`{"addressDetails":{}}` + "\n" +
+			`{"addressDetails":{"City":"Wellington"}}` + "\n"
+
+		err := addressImporter.Import(ctx, tenantID, applicationID, service.JSONLinesFormat, strings.NewReader(input), 0, "", report)
+
+		Expect(err).To(BeNil())
+		Expect(results).To(HaveLen(3))
+		Expect(results[0].Error).NotTo(BeEmpty())
+		Expect(results[0].AddressID).To(BeEmpty())
+		Expect(results[1].Error).NotTo(BeEmpty())
+		Expect(results[2].AddressID).To(Equal(secondAddressID.String()))
+	})
+
+	It("should fingerprint the addresses and report the rejected duplicates", func() {
+		addressImporter.DuplicatePolicy = service.RejectDuplicateAddress
+
+		mockAddressDataService.
+			EXPECT().
+			FindByFingerprint(ctx, tenantID, applicationID, gomock.Any()).
+			Return([]contract.ListedAddress{{AddressID: firstAddressID, Address: contract.Address{AddressDetails: map[string]string{"City": "christchurch"}}}}, nil)
+
+		mockAddressDataService.
+			EXPECT().
+			FindByFingerprint(ctx, tenantID, applicationID, gomock.Any()).
+			Return(nil, nil)
+
+		mockAddressDataService.
+			EXPECT().
+			Create(ctx, tenantID, applicationID, gomock.Any(), "").
+			Do(func(ctx context.Context, tenantID, applicationID system.UUID, address contract.Address, changedBy string) {
+				Expect(address.Fingerprint).NotTo(BeEmpty())
+			}).
+			Return(secondAddressID, nil)
+
+		input := `{"addressDetails":{"City":"Christchurch"}}` + "\n" + `{"addressDetails":{"City":"Wellington"}}` + "\n"
+
+		err := addressImporter.Import(ctx, tenantID, applicationID, service.JSONLinesFormat, strings.NewReader(input), 0, "", report)
+
+		Expect(err).To(BeNil())
+		Expect(results).To(HaveLen(2))
+		Expect(results[0].AddressID).To(BeEmpty())
+		Expect(results[0].DuplicateOf).To(Equal(firstAddressID.String()))
+		Expect(results[0].Error).NotTo(BeEmpty())
+		Expect(results[1]).To(Equal(service.ImportResult{Record: 2, AddressID: secondAddressID.String()}))
+	})
+
+	It("should create and report the duplicates if they are only warned about", func() {
+		addressImporter.DuplicatePolicy = service.WarnOnDuplicateAddress
+
+		mockAddressDataService.
+			EXPECT().
+			FindByFingerprint(ctx, tenantID, applicationID, gomock.Any()).
+			Return([]contract.ListedAddress{{AddressID: firstAddressID, Address: contract.Address{AddressDetails: map[string]string{"City": "Christchurch"}}}}, nil)
+
+		mockAddressDataService.
+			EXPECT().
+			Create(ctx, tenantID, applicationID, gomock.Any(), "").
+			Return(secondAddressID, nil)
+
+		input := `{"addressDetails":{"City":"Christchurch"}}` + "\n"
+
+		err := addressImporter.Import(ctx, tenantID, applicationID, service.JSONLinesFormat, strings.NewReader(input), 0, "", report)
+
+		Expect(err).To(BeNil())
+		Expect(results).To(Equal([]service.ImportResult{{Record: 1, AddressID: secondAddressID.String(), DuplicateOf: firstAddressID.String()}}))
+	})
+
+	It("should stop and return the error returned by address data service", func() {
+		mockAddressDataService.
+			EXPECT().
+			Create(ctx, tenantID, applicationID, gomock.Any(), "").
+			Return(system.EmptyUUID, contract.UnavailableError{Err: errors.New("Cluster is down")})
+
+		input := `{"addressDetails":{"City":"Christchurch"}}` + "\n" + `{"addressDetails":{"City":"Wellington"}}` + "\n"
+
+		err := addressImporter.Import(ctx, tenantID, applicationID, service.JSONLinesFormat, strings.NewReader(input), 0, "", report)
+
+		Expect(err).To(BeAssignableToTypeOf(businessContract.UnavailableError{}))
+		Expect(results).To(BeEmpty())
+	})
+})
+
+func TestImport(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Import method input parameters and dependency test")
+	RunSpecs(t, "Import method behaviour")
+}
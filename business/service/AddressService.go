@@ -1,136 +1,978 @@
 package service
 
 import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	businessContract "github.com/micro-business/AddressService/business/contract"
 	"github.com/micro-business/AddressService/business/domain"
 	"github.com/micro-business/AddressService/data/contract"
 	"github.com/micro-business/Micro-Business-Core/common/diagnostics"
 	"github.com/micro-business/Micro-Business-Core/system"
+	"golang.org/x/net/context"
 )
 
+// addressKeyPattern defines the characters allowed in address details keys.
+var addressKeyPattern = regexp.MustCompile("^[A-Za-z0-9_.-]+$")
+
+// addressOwnerTypePattern defines the characters allowed in address owner types.
+var addressOwnerTypePattern = regexp.MustCompile("^[A-Za-z0-9_.-]+$")
+
+// DefaultDeletedAddressGracePeriod is how long a deleted address can be restored before it is purged when
+// DeletedAddressGracePeriod is not provided.
+const DefaultDeletedAddressGracePeriod = 72 * time.Hour
+
+// MaxBatchSize is the maximum number of addresses a bulk operation accepts.
+const MaxBatchSize = 1000
+
 // AddressService provides access to add new address and update/retrieve/remove an existing address.
+// DeletedAddressGracePeriod is how long a deleted address can be restored before it is purged. If it is not provided,
+// DefaultDeletedAddressGracePeriod is used.
+// DuplicatePolicy is what Create does when an address with the same fingerprint already exists. Duplicate detection is
+// disabled if it is not provided.
 type AddressService struct {
-	AddressDataService contract.AddressDataService
+	AddressDataService        contract.AddressDataService
+	DeletedAddressGracePeriod time.Duration
+	DuplicatePolicy           DuplicateAddressPolicy
 }
 
-// Create creates a new address.
+// Create creates a new address. Unless duplicate detection is disabled, the existing addresses of the tenant's
+// application with the same fingerprint are looked for first and DuplicatePolicy decides whether the address is created.
+// Looking for the duplicates and creating the address are not atomic, so concurrent calls creating the same address can
+// all succeed even if duplicates are rejected.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
 // tenantID: Mandatory. The unique identifier of the tenant owning the address.
 // applicationID: Mandatory. The unique identifier of the tenant's application will be owning the address.
 // address: Mandatory. The reference to the new address information.
-// Returns either the unique identifier of the new address or error if something goes wrong.
-func (addressService AddressService) Create(tenantID, applicationID system.UUID, address domain.Address) (system.UUID, error) {
+// changedBy: Optional. Identifies who makes the change, recorded in the address history.
+// Returns either the unique identifier of the new address along with the existing address with the same fingerprint,
+// or DuplicateAddressError if such an address exists and duplicates are rejected, or error if something goes wrong.
+func (addressService AddressService) Create(
+	ctx context.Context,
+	tenantID, applicationID system.UUID,
+	address domain.Address,
+	changedBy string) (domain.CreatedAddress, error) {
+	diagnostics.IsNotNil(addressService.AddressDataService, "addressService.AddressDataService", "AddressDataService must be provided.")
+
+	if err := firstError(validateOwner(tenantID, applicationID), validateAddress(address)); err != nil {
+		return domain.CreatedAddress{}, err
+	}
+
+	dataAddress := addressService.mapToFingerprintedDataAddress(address)
+	duplicateOf := system.EmptyUUID
+
+	if dataAddress.Fingerprint != "" {
+		var err error
+
+		if duplicateOf, err = addressService.findDuplicateAddress(ctx, tenantID, applicationID, dataAddress.Fingerprint); err != nil {
+			return domain.CreatedAddress{}, err
+		}
+
+		if duplicateOf != system.EmptyUUID {
+			switch addressService.DuplicatePolicy {
+			case RejectDuplicateAddress:
+				return domain.CreatedAddress{}, businessContract.DuplicateAddressError{AddressID: duplicateOf}
+			case ReturnExistingAddress:
+				return domain.CreatedAddress{AddressID: duplicateOf, DuplicateOf: duplicateOf}, nil
+			}
+		}
+	}
+
+	addressID, err := addressService.AddressDataService.Create(ctx, tenantID, applicationID, dataAddress, changedBy)
+
+	if err != nil {
+		return domain.CreatedAddress{}, mapFromDataError(err)
+	}
+
+	return domain.CreatedAddress{AddressID: addressID, DuplicateOf: duplicateOf}, nil
+}
+
+// CreateMany creates several new addresses. Every address is validated and created on its own, so an invalid address or
+// failing to create an address does not stop creating the rest of them.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the addresses.
+// applicationID: Mandatory. The unique identifier of the tenant's application will be owning the addresses.
+// addresses: Mandatory. The new addresses information.
+// changedBy: Optional. Identifies who makes the change, recorded in the address history.
+// Returns either the result of creating every address in the same order as addresses or InvalidArgumentError if the owner
+// is invalid or no address or more than MaxBatchSize addresses are provided.
+func (addressService AddressService) CreateMany(
+	ctx context.Context,
+	tenantID, applicationID system.UUID,
+	addresses []domain.Address,
+	changedBy string) ([]domain.AddressResult, error) {
 	diagnostics.IsNotNil(addressService.AddressDataService, "addressService.AddressDataService", "AddressDataService must be provided.")
-	diagnostics.IsNotNilOrEmpty(tenantID, "tenantID", "tenantID must be provided.")
-	diagnostics.IsNotNilOrEmpty(applicationID, "applicationID", "applicationID must be provided.")
 
-	validateAddress(address)
+	if err := firstError(validateOwner(tenantID, applicationID), validateBatchSize(len(addresses), "addresses")); err != nil {
+		return nil, err
+	}
+
+	results := make([]domain.AddressResult, len(addresses))
+	validIndexes := make([]int, 0, len(addresses))
+	dataAddresses := make([]contract.Address, 0, len(addresses))
+
+	for index, address := range addresses {
+		if err := validateAddress(address); err != nil {
+			results[index] = domain.AddressResult{Err: err}
+
+			continue
+		}
+
+		validIndexes = append(validIndexes, index)
+		dataAddresses = append(dataAddresses, addressService.mapToFingerprintedDataAddress(address))
+	}
+
+	if len(dataAddresses) == 0 {
+		return results, nil
+	}
 
-	return addressService.AddressDataService.Create(tenantID, applicationID, mapToDataAddress(address))
+	return mergeAddressResults(
+		results,
+		validIndexes,
+		addressService.AddressDataService.CreateMany(ctx, tenantID, applicationID, dataAddresses, changedBy)), nil
 }
 
 // Update updates an existing address.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
 // tenantID: Mandatory. The unique identifier of the tenant owning the address.
 // applicationID: Mandatory. The unique identifier of the tenant's application will be owning the address.
 // addressID: Mandatory. The unique identifier of the existing address.
 // address: Mandatory. The reeference to the updated address information.
-// Returns error if something goes wrong.
-func (addressService AddressService) Update(tenantID, applicationID, addressID system.UUID, address domain.Address) error {
+// expectedVersion: Optional. The version the address is expected to have, or AnyVersion to skip the version check.
+// changedBy: Optional. Identifies who makes the change, recorded in the address history.
+// Returns ConflictError if the address version does not match the expected version, or error if something goes wrong.
+func (addressService AddressService) Update(
+	ctx context.Context,
+	tenantID, applicationID, addressID system.UUID,
+	address domain.Address,
+	expectedVersion int64,
+	changedBy string) error {
+	diagnostics.IsNotNil(addressService.AddressDataService, "addressService.AddressDataService", "AddressDataService must be provided.")
+
+	err := firstError(
+		validateOwner(tenantID, applicationID),
+		validateAddressID(addressID),
+		validateAddress(address),
+		validateExpectedVersion(expectedVersion))
+
+	if err != nil {
+		return err
+	}
+
+	return mapFromDataError(addressService.AddressDataService.Update(
+		ctx,
+		tenantID,
+		applicationID,
+		addressID,
+		addressService.mapToFingerprintedDataAddress(address),
+		expectedVersion,
+		changedBy))
+}
+
+// Patch changes only the provided details of an existing address and leaves the rest of them unchanged.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+// applicationID: Mandatory. The unique identifier of the tenant's application will be owning the address.
+// addressID: Mandatory. The unique identifier of the existing address.
+// set: Optional. The address details keys to add or change along with their new values.
+// remove: Optional. The address details keys to remove.
+// expectedVersion: Optional. The version the address is expected to have, or AnyVersion to skip the version check.
+// changedBy: Optional. Identifies who makes the change, recorded in the address history.
+// Returns ConflictError if the address version does not match the expected version, or error if something goes wrong.
+func (addressService AddressService) Patch(
+	ctx context.Context,
+	tenantID, applicationID, addressID system.UUID,
+	set map[string]string,
+	remove []string,
+	expectedVersion int64,
+	changedBy string) error {
 	diagnostics.IsNotNil(addressService.AddressDataService, "addressService.AddressDataService", "AddressDataService must be provided.")
-	diagnostics.IsNotNilOrEmpty(tenantID, "tenantID", "tenantID must be provided.")
-	diagnostics.IsNotNilOrEmpty(applicationID, "applicationID", "applicationID must be provided.")
-	diagnostics.IsNotNilOrEmpty(addressID, "addressID", "addressID must be provided.")
 
-	validateAddress(address)
+	err := firstError(
+		validateOwner(tenantID, applicationID),
+		validateAddressID(addressID),
+		validatePatch(set, remove),
+		validateExpectedVersion(expectedVersion))
 
-	return addressService.AddressDataService.Update(tenantID, applicationID, addressID, mapToDataAddress(address))
+	if err != nil {
+		return err
+	}
+
+	return mapFromDataError(addressService.AddressDataService.Patch(ctx, tenantID, applicationID, addressID, set, remove, expectedVersion, changedBy))
 }
 
 // Read retrieves an existing address information and returns only the detail which the keys provided by the keys.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
 // tenantID: Mandatory. The unique identifier of the tenant owning the address.
 // applicationID: Mandatory. The unique identifier of the tenant's application will be owning the address.
 // addressID: Mandatory. The unique identifier of the existing address.
 // keys: Mandatory. The interested address details keys to return.
 // Returns either the address information or error if something goes wrong.
-func (addressService AddressService) Read(tenantID, applicationID, addressID system.UUID, keys []string) (domain.Address, error) {
+func (addressService AddressService) Read(ctx context.Context, tenantID, applicationID, addressID system.UUID, keys []string) (domain.Address, error) {
 	diagnostics.IsNotNil(addressService.AddressDataService, "addressService.AddressDataService", "AddressDataService must be provided.")
-	diagnostics.IsNotNilOrEmpty(tenantID, "tenantID", "tenantID must be provided.")
-	diagnostics.IsNotNilOrEmpty(applicationID, "applicationID", "applicationID must be provided.")
-	diagnostics.IsNotNilOrEmpty(addressID, "addressID", "addressID must be provided.")
 
-	keysCount := len(keys)
+	if err := firstError(validateOwner(tenantID, applicationID), validateAddressID(addressID)); err != nil {
+		return domain.Address{}, err
+	}
 
-	if keysCount == 0 {
-		panic("No address details key provided.")
+	if len(keys) == 0 {
+		return domain.Address{}, businessContract.InvalidArgumentError{Message: "No address details key provided."}
 	}
 
-	for _, key := range keys {
-		diagnostics.IsNotNilOrEmptyOrWhitespace(key, "key", "key cannot be empty or contains whitespace only.")
+	if err := validateAddressKeys(keys); err != nil {
+		return domain.Address{}, err
 	}
 
-	address, err := addressService.AddressDataService.Read(tenantID, applicationID, addressID, keys)
+	address, err := addressService.AddressDataService.Read(ctx, tenantID, applicationID, addressID, keys)
 
 	if err != nil {
-		return domain.Address{}, err
+		return domain.Address{}, mapFromDataError(err)
 	}
 
 	return mapFromDataAddress(address), nil
 }
 
 // ReadAll retrieves an existing address information and returns all the detail of it.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
 // tenantID: Mandatory. The unique identifier of the tenant owning the address.
 // applicationID: Mandatory. The unique identifier of the tenant's application will be owning the address.
 // addressID: Mandatory. The unique identifier of the existing address.
 // Returns either the address information or error if something goes wrong.
-func (addressService AddressService) ReadAll(tenantID, applicationID, addressID system.UUID) (domain.Address, error) {
+func (addressService AddressService) ReadAll(ctx context.Context, tenantID, applicationID, addressID system.UUID) (domain.Address, error) {
+	diagnostics.IsNotNil(addressService.AddressDataService, "addressService.AddressDataService", "AddressDataService must be provided.")
+
+	if err := firstError(validateOwner(tenantID, applicationID), validateAddressID(addressID)); err != nil {
+		return domain.Address{}, err
+	}
+
+	address, err := addressService.AddressDataService.ReadAll(ctx, tenantID, applicationID, addressID)
+
+	if err != nil {
+		return domain.Address{}, mapFromDataError(err)
+	}
+
+	return mapFromDataAddress(address), nil
+}
+
+// ReadMany retrieves several existing addresses information. Every address is read on its own, so an invalid address
+// unique identifier or failing to read an address does not stop reading the rest of them.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the addresses.
+// applicationID: Mandatory. The unique identifier of the tenant's application owning the addresses.
+// addressIDs: Mandatory. The unique identifiers of the existing addresses.
+// keys: Optional. The interested address details keys to return, or empty to return all the details.
+// Returns either the result of reading every address in the same order as addressIDs or InvalidArgumentError if the owner
+// or keys are invalid or no address or more than MaxBatchSize addresses are provided.
+func (addressService AddressService) ReadMany(
+	ctx context.Context,
+	tenantID, applicationID system.UUID,
+	addressIDs []system.UUID,
+	keys []string) ([]domain.AddressResult, error) {
+	diagnostics.IsNotNil(addressService.AddressDataService, "addressService.AddressDataService", "AddressDataService must be provided.")
+
+	if err := firstError(validateOwner(tenantID, applicationID), validateBatchSize(len(addressIDs), "address unique identifiers"), validateAddressKeys(keys)); err != nil {
+		return nil, err
+	}
+
+	results, validIndexes, validAddressIDs := validateAddressIDs(addressIDs)
+
+	if len(validAddressIDs) == 0 {
+		return results, nil
+	}
+
+	return mergeAddressResults(
+		results,
+		validIndexes,
+		addressService.AddressDataService.ReadMany(ctx, tenantID, applicationID, validAddressIDs, keys)), nil
+}
+
+// List retrieves a page of the addresses owned by a tenant's application. The deleted addresses are not returned.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the addresses.
+// applicationID: Mandatory. The unique identifier of the tenant's application owning the addresses.
+// pageSize: Mandatory. The maximum number of addresses to return.
+// cursor: Optional. The cursor returned with the previous page, or empty to return the first page.
+// Returns either the page of addresses or error if the cursor is invalid or something goes wrong.
+func (addressService AddressService) List(ctx context.Context, tenantID, applicationID system.UUID, pageSize int, cursor string) (domain.AddressPage, error) {
+	diagnostics.IsNotNil(addressService.AddressDataService, "addressService.AddressDataService", "AddressDataService must be provided.")
+
+	if err := validateOwner(tenantID, applicationID); err != nil {
+		return domain.AddressPage{}, err
+	}
+
+	if pageSize <= 0 {
+		return domain.AddressPage{}, businessContract.InvalidArgumentError{Message: "pageSize must be greater than zero."}
+	}
+
+	page, err := addressService.AddressDataService.List(ctx, tenantID, applicationID, pageSize, cursor)
+
+	if err != nil {
+		return domain.AddressPage{}, mapFromDataError(err)
+	}
+
+	return domain.AddressPage{Addresses: mapFromDataListedAddresses(page.Addresses), NextCursor: page.NextCursor}, nil
+}
+
+// FindByDetail retrieves the addresses owned by a tenant's application whose details match all the provided criteria.
+// The deleted addresses are not returned, and no more than contract.MaxFoundAddresses addresses are returned.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the addresses.
+// applicationID: Mandatory. The unique identifier of the tenant's application owning the addresses.
+// criteria: Mandatory. The address details keys along with the values the returned addresses must have.
+// Returns either the matching addresses or error if something goes wrong.
+func (addressService AddressService) FindByDetail(ctx context.Context, tenantID, applicationID system.UUID, criteria map[string]string) ([]domain.ListedAddress, error) {
+	diagnostics.IsNotNil(addressService.AddressDataService, "addressService.AddressDataService", "AddressDataService must be provided.")
+
+	if err := firstError(validateOwner(tenantID, applicationID), validateCriteria(criteria)); err != nil {
+		return nil, err
+	}
+
+	listedAddresses, err := addressService.AddressDataService.FindByDetail(ctx, tenantID, applicationID, criteria)
+
+	if err != nil {
+		return nil, mapFromDataError(err)
+	}
+
+	return mapFromDataListedAddresses(listedAddresses), nil
+}
+
+// AddOwners links an existing address to the provided owners. The owners already linked to the address are kept.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+// applicationID: Mandatory. The unique identifier of the tenant's application owning the address.
+// addressID: Mandatory. The unique identifier of the existing address.
+// owners: Mandatory. The owners to link the address to.
+// Returns error if the address does not exist, or if something goes wrong.
+func (addressService AddressService) AddOwners(ctx context.Context, tenantID, applicationID, addressID system.UUID, owners []domain.AddressOwner) error {
+	diagnostics.IsNotNil(addressService.AddressDataService, "addressService.AddressDataService", "AddressDataService must be provided.")
+
+	if err := firstError(validateOwner(tenantID, applicationID), validateAddressID(addressID), validateAddressOwners(owners)); err != nil {
+		return err
+	}
+
+	return mapFromDataError(addressService.AddressDataService.AddOwners(ctx, tenantID, applicationID, addressID, mapToDataAddressOwners(owners)))
+}
+
+// RemoveOwners unlinks an existing address from the provided owners. The owners not linked to the address are skipped.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+// applicationID: Mandatory. The unique identifier of the tenant's application owning the address.
+// addressID: Mandatory. The unique identifier of the existing address.
+// owners: Mandatory. The owners to unlink the address from.
+// Returns error if the address does not exist, or if something goes wrong.
+func (addressService AddressService) RemoveOwners(ctx context.Context, tenantID, applicationID, addressID system.UUID, owners []domain.AddressOwner) error {
+	diagnostics.IsNotNil(addressService.AddressDataService, "addressService.AddressDataService", "AddressDataService must be provided.")
+
+	if err := firstError(validateOwner(tenantID, applicationID), validateAddressID(addressID), validateAddressOwners(owners)); err != nil {
+		return err
+	}
+
+	return mapFromDataError(addressService.AddressDataService.RemoveOwners(ctx, tenantID, applicationID, addressID, mapToDataAddressOwners(owners)))
+}
+
+// ReadOwners retrieves the owners an existing address is linked to.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+// applicationID: Mandatory. The unique identifier of the tenant's application owning the address.
+// addressID: Mandatory. The unique identifier of the existing address.
+// Returns either the owners ordered by their type and identifier or error if the address does not exist or something
+// goes wrong.
+func (addressService AddressService) ReadOwners(ctx context.Context, tenantID, applicationID, addressID system.UUID) ([]domain.AddressOwner, error) {
+	diagnostics.IsNotNil(addressService.AddressDataService, "addressService.AddressDataService", "AddressDataService must be provided.")
+
+	if err := firstError(validateOwner(tenantID, applicationID), validateAddressID(addressID)); err != nil {
+		return nil, err
+	}
+
+	owners, err := addressService.AddressDataService.ReadOwners(ctx, tenantID, applicationID, addressID)
+
+	if err != nil {
+		return nil, mapFromDataError(err)
+	}
+
+	return mapFromDataAddressOwners(owners), nil
+}
+
+// FindByOwner retrieves the addresses owned by a tenant's application which are linked to the provided owner. The
+// deleted addresses are not returned.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the addresses.
+// applicationID: Mandatory. The unique identifier of the tenant's application owning the addresses.
+// owner: Mandatory. The owner the returned addresses are linked to.
+// Returns either the linked addresses ordered by their unique identifier or error if something goes wrong.
+func (addressService AddressService) FindByOwner(ctx context.Context, tenantID, applicationID system.UUID, owner domain.AddressOwner) ([]domain.ListedAddress, error) {
+	diagnostics.IsNotNil(addressService.AddressDataService, "addressService.AddressDataService", "AddressDataService must be provided.")
+
+	if err := firstError(validateOwner(tenantID, applicationID), validateAddressOwner(owner)); err != nil {
+		return nil, err
+	}
+
+	listedAddresses, err := addressService.AddressDataService.FindByOwner(ctx, tenantID, applicationID, contract.AddressOwner(owner))
+
+	if err != nil {
+		return nil, mapFromDataError(err)
+	}
+
+	return mapFromDataListedAddresses(listedAddresses), nil
+}
+
+// History retrieves all the recorded versions of an address, including the versions recorded when it was deleted and
+// restored.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+// applicationID: Mandatory. The unique identifier of the tenant's application will be owning the address.
+// addressID: Mandatory. The unique identifier of the address.
+// Returns either the recorded versions ordered from the oldest to the newest or error if no version is recorded or
+// something goes wrong.
+func (addressService AddressService) History(ctx context.Context, tenantID, applicationID, addressID system.UUID) ([]domain.AddressHistoryEntry, error) {
 	diagnostics.IsNotNil(addressService.AddressDataService, "addressService.AddressDataService", "AddressDataService must be provided.")
-	diagnostics.IsNotNilOrEmpty(tenantID, "tenantID", "tenantID must be provided.")
-	diagnostics.IsNotNilOrEmpty(applicationID, "applicationID", "applicationID must be provided.")
-	diagnostics.IsNotNilOrEmpty(addressID, "addressID", "addressID must be provided.")
 
-	address, err := addressService.AddressDataService.ReadAll(tenantID, applicationID, addressID)
+	if err := firstError(validateOwner(tenantID, applicationID), validateAddressID(addressID)); err != nil {
+		return nil, err
+	}
+
+	history, err := addressService.AddressDataService.History(ctx, tenantID, applicationID, addressID)
 
 	if err != nil {
+		return nil, mapFromDataError(err)
+	}
+
+	mappedHistory := make([]domain.AddressHistoryEntry, 0, len(history))
+
+	for _, historyEntry := range history {
+		mappedHistory = append(mappedHistory, mapFromDataAddressHistoryEntry(historyEntry))
+	}
+
+	return mappedHistory, nil
+}
+
+// ReadAt retrieves the address information as it was at the provided time.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+// applicationID: Mandatory. The unique identifier of the tenant's application will be owning the address.
+// addressID: Mandatory. The unique identifier of the address.
+// timestamp: Mandatory. The time to return the address information at.
+// Returns either the address information or error if the address did not exist or was deleted at the provided time, or
+// if something goes wrong.
+func (addressService AddressService) ReadAt(ctx context.Context, tenantID, applicationID, addressID system.UUID, timestamp time.Time) (domain.Address, error) {
+	diagnostics.IsNotNil(addressService.AddressDataService, "addressService.AddressDataService", "AddressDataService must be provided.")
+
+	if err := firstError(validateOwner(tenantID, applicationID), validateAddressID(addressID)); err != nil {
 		return domain.Address{}, err
 	}
 
+	if timestamp.IsZero() {
+		return domain.Address{}, businessContract.InvalidArgumentError{Message: "timestamp must be provided."}
+	}
+
+	address, err := addressService.AddressDataService.ReadAt(ctx, tenantID, applicationID, addressID, timestamp)
+
+	if err != nil {
+		return domain.Address{}, mapFromDataError(err)
+	}
+
 	return mapFromDataAddress(address), nil
 }
 
-// Delete deletes an existing address information.
+// Delete marks an existing address as deleted. The deleted address is not returned anymore but it can be restored
+// within the deleted address grace period.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
 // tenantID: Mandatory. The unique identifier of the tenant owning the address.
 // applicationID: Mandatory. The unique identifier of the tenant's application will be owning the address.
 // addressID: Mandatory. The unique identifier of the existing address to remove.
+// expectedVersion: Optional. The version the address is expected to have, or AnyVersion to skip the version check.
+// changedBy: Optional. Identifies who makes the change, recorded in the address history.
+// Returns ConflictError if the address version does not match the expected version, or error if something goes wrong.
+func (addressService AddressService) Delete(ctx context.Context, tenantID, applicationID, addressID system.UUID, expectedVersion int64, changedBy string) error {
+	diagnostics.IsNotNil(addressService.AddressDataService, "addressService.AddressDataService", "AddressDataService must be provided.")
+
+	err := firstError(validateOwner(tenantID, applicationID), validateAddressID(addressID), validateExpectedVersion(expectedVersion))
+
+	if err != nil {
+		return err
+	}
+
+	return mapFromDataError(addressService.AddressDataService.Delete(ctx, tenantID, applicationID, addressID, expectedVersion, changedBy))
+}
+
+// DeleteMany marks several existing addresses as deleted, skipping the version check. Every address is deleted on its
+// own, so an invalid address unique identifier or failing to delete an address does not stop deleting the rest of them.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the addresses.
+// applicationID: Mandatory. The unique identifier of the tenant's application owning the addresses.
+// addressIDs: Mandatory. The unique identifiers of the existing addresses to remove.
+// changedBy: Optional. Identifies who makes the change, recorded in the address history.
+// Returns either the result of deleting every address in the same order as addressIDs or InvalidArgumentError if the
+// owner is invalid or no address or more than MaxBatchSize addresses are provided.
+func (addressService AddressService) DeleteMany(
+	ctx context.Context,
+	tenantID, applicationID system.UUID,
+	addressIDs []system.UUID,
+	changedBy string) ([]domain.AddressResult, error) {
+	diagnostics.IsNotNil(addressService.AddressDataService, "addressService.AddressDataService", "AddressDataService must be provided.")
+
+	if err := firstError(validateOwner(tenantID, applicationID), validateBatchSize(len(addressIDs), "address unique identifiers")); err != nil {
+		return nil, err
+	}
+
+	results, validIndexes, validAddressIDs := validateAddressIDs(addressIDs)
+
+	if len(validAddressIDs) == 0 {
+		return results, nil
+	}
+
+	return mergeAddressResults(
+		results,
+		validIndexes,
+		addressService.AddressDataService.DeleteMany(ctx, tenantID, applicationID, validAddressIDs, changedBy)), nil
+}
+
+// Restore restores an address deleted within the deleted address grace period.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+// applicationID: Mandatory. The unique identifier of the tenant's application will be owning the address.
+// addressID: Mandatory. The unique identifier of the deleted address to restore.
+// changedBy: Optional. Identifies who makes the change, recorded in the address history.
+// Returns error if the deleted address does not exist or its grace period is over, or if something goes wrong.
+func (addressService AddressService) Restore(ctx context.Context, tenantID, applicationID, addressID system.UUID, changedBy string) error {
+	diagnostics.IsNotNil(addressService.AddressDataService, "addressService.AddressDataService", "AddressDataService must be provided.")
+
+	if err := firstError(validateOwner(tenantID, applicationID), validateAddressID(addressID)); err != nil {
+		return err
+	}
+
+	return mapFromDataError(addressService.AddressDataService.Restore(
+		ctx,
+		tenantID,
+		applicationID,
+		addressID,
+		time.Now().Add(-addressService.getDeletedAddressGracePeriod()),
+		changedBy))
+}
+
+// Purge removes a deleted address for good without waiting for its grace period to be over.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+// applicationID: Mandatory. The unique identifier of the tenant's application will be owning the address.
+// addressID: Mandatory. The unique identifier of the deleted address to purge.
+// Returns error if the deleted address does not exist, or if something goes wrong.
+func (addressService AddressService) Purge(ctx context.Context, tenantID, applicationID, addressID system.UUID) error {
+	diagnostics.IsNotNil(addressService.AddressDataService, "addressService.AddressDataService", "AddressDataService must be provided.")
+
+	if err := firstError(validateOwner(tenantID, applicationID), validateAddressID(addressID)); err != nil {
+		return err
+	}
+
+	return mapFromDataError(addressService.AddressDataService.Purge(ctx, tenantID, applicationID, addressID))
+}
+
+// PurgeExpired removes for good all the deleted addresses whose grace period is over.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
 // Returns error if something goes wrong.
-func (addressService AddressService) Delete(tenantID, applicationID, addressID system.UUID) error {
+func (addressService AddressService) PurgeExpired(ctx context.Context) error {
+	diagnostics.IsNotNil(addressService.AddressDataService, "addressService.AddressDataService", "AddressDataService must be provided.")
+
+	return mapFromDataError(addressService.AddressDataService.PurgeDeleted(ctx, time.Now().Add(-addressService.getDeletedAddressGracePeriod())))
+}
+
+// getDeletedAddressGracePeriod returns DeletedAddressGracePeriod, or DefaultDeletedAddressGracePeriod if it is not
+// provided, so a deleted address can always be restored for a while before it is purged.
+func (addressService AddressService) getDeletedAddressGracePeriod() time.Duration {
+	if addressService.DeletedAddressGracePeriod <= 0 {
+		return DefaultDeletedAddressGracePeriod
+	}
+
+	return addressService.DeletedAddressGracePeriod
+}
+
+// PurgeTenant removes for good all the addresses of all the applications of a tenant along with their history, whether
+// they are deleted or not, and records an erasure certificate. An interrupted erasure continues where it stopped when it
+// is requested again.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant to erase.
+// requestedBy: Optional. Identifies who requests the erasure, recorded in the erasure certificate.
+// Returns either the erasure certificate or error if something goes wrong.
+func (addressService AddressService) PurgeTenant(ctx context.Context, tenantID system.UUID, requestedBy string) (domain.ErasureCertificate, error) {
+	diagnostics.IsNotNil(addressService.AddressDataService, "addressService.AddressDataService", "AddressDataService must be provided.")
+
+	if err := validateTenantID(tenantID); err != nil {
+		return domain.ErasureCertificate{}, err
+	}
+
+	certificate, err := addressService.AddressDataService.PurgeTenant(ctx, tenantID, requestedBy)
+
+	if err != nil {
+		return domain.ErasureCertificate{}, mapFromDataError(err)
+	}
+
+	return mapFromDataErasureCertificate(certificate), nil
+}
+
+// PurgeApplication removes for good all the addresses of a tenant's application along with their history, whether they
+// are deleted or not, and records an erasure certificate. An interrupted erasure continues where it stopped when it is
+// requested again.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the application.
+// applicationID: Mandatory. The unique identifier of the tenant's application to erase.
+// requestedBy: Optional. Identifies who requests the erasure, recorded in the erasure certificate.
+// Returns either the erasure certificate or error if something goes wrong.
+func (addressService AddressService) PurgeApplication(
+	ctx context.Context,
+	tenantID, applicationID system.UUID,
+	requestedBy string) (domain.ErasureCertificate, error) {
 	diagnostics.IsNotNil(addressService.AddressDataService, "addressService.AddressDataService", "AddressDataService must be provided.")
-	diagnostics.IsNotNilOrEmpty(tenantID, "tenantID", "tenantID must be provided.")
-	diagnostics.IsNotNilOrEmpty(applicationID, "applicationID", "applicationID must be provided.")
-	diagnostics.IsNotNilOrEmpty(addressID, "addressID", "addressID  must be provided.")
 
-	return addressService.AddressDataService.Delete(tenantID, applicationID, addressID)
+	if err := validateOwner(tenantID, applicationID); err != nil {
+		return domain.ErasureCertificate{}, err
+	}
+
+	certificate, err := addressService.AddressDataService.PurgeApplication(ctx, tenantID, applicationID, requestedBy)
+
+	if err != nil {
+		return domain.ErasureCertificate{}, mapFromDataError(err)
+	}
+
+	return mapFromDataErasureCertificate(certificate), nil
+}
+
+// validateOwner validates the unique identifiers of the tenant and the tenant's application owning the addresses and make
+// sure both of them are provided.
+func validateOwner(tenantID, applicationID system.UUID) error {
+	if err := validateTenantID(tenantID); err != nil {
+		return err
+	}
+
+	if applicationID == system.EmptyUUID {
+		return businessContract.InvalidArgumentError{Message: "applicationID must be provided."}
+	}
+
+	return nil
+}
+
+// validateTenantID validates the unique identifier of a tenant and make sure it is provided.
+func validateTenantID(tenantID system.UUID) error {
+	if tenantID == system.EmptyUUID {
+		return businessContract.InvalidArgumentError{Message: "tenantID must be provided."}
+	}
+
+	return nil
+}
+
+// validateAddressID validates the unique identifier of an address and make sure it is provided.
+func validateAddressID(addressID system.UUID) error {
+	if addressID == system.EmptyUUID {
+		return businessContract.InvalidArgumentError{Message: "addressID must be provided."}
+	}
+
+	return nil
 }
 
 // validateAddress validates the tenant domain object and make sure the data is consistent and valid.
-func validateAddress(address domain.Address) {
+func validateAddress(address domain.Address) error {
 	if len(address.AddressDetails) == 0 {
-		panic("Address does not contain any address key.")
+		return businessContract.InvalidArgumentError{Message: "Address does not contain any address key."}
+	}
+
+	if err := validateAddressDetails(address.AddressDetails); err != nil {
+		return err
+	}
+
+	if address.TTL < 0 {
+		return businessContract.InvalidArgumentError{Message: "TTL cannot be negative."}
+	}
+
+	return nil
+}
+
+// validatePatch validates the address details keys to set and remove and make sure at least one change is requested and
+// no key is both set and removed.
+func validatePatch(set map[string]string, remove []string) error {
+	if len(set) == 0 && len(remove) == 0 {
+		return businessContract.InvalidArgumentError{Message: "Patch does not contain any address key to set or remove."}
+	}
+
+	if err := firstError(validateAddressDetails(set), validateAddressKeys(remove)); err != nil {
+		return err
+	}
+
+	for _, key := range remove {
+		if _, ok := set[key]; ok {
+			return businessContract.InvalidArgumentError{Message: "key cannot be both set and removed."}
+		}
+	}
+
+	return nil
+}
+
+// validateAddressDetails validates the address details keys and values and make sure no value is empty or contains
+// whitespace only, or starts with the prefix of the encrypted values, which would be read back as ciphertext.
+func validateAddressDetails(addressDetails map[string]string) error {
+	for key, value := range addressDetails {
+		if err := validateAddressKey(key); err != nil {
+			return err
+		}
+
+		if len(strings.TrimSpace(value)) == 0 {
+			return businessContract.InvalidArgumentError{Message: "value cannot be empty or contains whitespace only."}
+		}
+
+		if strings.HasPrefix(value, contract.EncryptedValuePrefix) {
+			return businessContract.InvalidArgumentError{Message: "value cannot start with '" + contract.EncryptedValuePrefix + "'."}
+		}
+	}
+
+	return nil
+}
+
+// validateAddressKeys validates the provided address details keys.
+func validateAddressKeys(keys []string) error {
+	for _, key := range keys {
+		if err := validateAddressKey(key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateAddressKey validates the address details key and make sure it only contains letters, digits, '_', '.' and '-'.
+func validateAddressKey(key string) error {
+	if len(strings.TrimSpace(key)) == 0 {
+		return businessContract.InvalidArgumentError{Message: "key cannot be empty or contains whitespace only."}
+	}
+
+	if !addressKeyPattern.MatchString(key) {
+		return businessContract.InvalidArgumentError{Message: "key can only contain letters, digits, '_', '.' and '-'."}
+	}
+
+	return nil
+}
+
+// validateCriteria validates the address details keys and values to find the addresses by and make sure at least one
+// criterion is provided.
+func validateCriteria(criteria map[string]string) error {
+	if len(criteria) == 0 {
+		return businessContract.InvalidArgumentError{Message: "Criteria does not contain any address key."}
+	}
+
+	return validateAddressDetails(criteria)
+}
+
+// validateBatchSize validates the number of the items provided to bulk operations and make sure at least one and at most
+// MaxBatchSize of them are provided.
+func validateBatchSize(count int, items string) error {
+	if count == 0 {
+		return businessContract.InvalidArgumentError{Message: "No " + items + " provided."}
+	}
+
+	if count > MaxBatchSize {
+		return businessContract.InvalidArgumentError{Message: fmt.Sprintf("No more than %d %s can be provided at once.", MaxBatchSize, items)}
+	}
+
+	return nil
+}
+
+// validateAddressIDs validates the unique identifiers of the addresses provided to bulk operations one by one. Returns
+// the results with the errors of the invalid ones set, the indexes of the valid ones in addressIDs and the valid ones.
+func validateAddressIDs(addressIDs []system.UUID) ([]domain.AddressResult, []int, []system.UUID) {
+	results := make([]domain.AddressResult, len(addressIDs))
+	validIndexes := make([]int, 0, len(addressIDs))
+	validAddressIDs := make([]system.UUID, 0, len(addressIDs))
+
+	for index, addressID := range addressIDs {
+		if err := validateAddressID(addressID); err != nil {
+			results[index] = domain.AddressResult{AddressID: addressID, Err: err}
+
+			continue
+		}
+
+		validIndexes = append(validIndexes, index)
+		validAddressIDs = append(validAddressIDs, addressID)
+	}
+
+	return results, validIndexes, validAddressIDs
+}
+
+// validateAddressOwners validates the owners provided to link or unlink an address and make sure at least one of them
+// is provided.
+func validateAddressOwners(owners []domain.AddressOwner) error {
+	if len(owners) == 0 {
+		return businessContract.InvalidArgumentError{Message: "No address owner provided."}
+	}
+
+	for _, owner := range owners {
+		if err := validateAddressOwner(owner); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateAddressOwner validates the address owner and make sure its type only contains letters, digits, '_', '.' and '-'
+// and its identifier is provided.
+func validateAddressOwner(owner domain.AddressOwner) error {
+	if !addressOwnerTypePattern.MatchString(owner.Type) {
+		return businessContract.InvalidArgumentError{Message: "owner type can only contain letters, digits, '_', '.' and '-'."}
+	}
+
+	if len(strings.TrimSpace(owner.ID)) == 0 {
+		return businessContract.InvalidArgumentError{Message: "owner ID cannot be empty or contains whitespace only."}
+	}
+
+	return nil
+}
+
+// validateExpectedVersion validates the expected version of an address provided for optimistic concurrency check.
+func validateExpectedVersion(expectedVersion int64) error {
+	if expectedVersion < 0 && expectedVersion != businessContract.AnyVersion {
+		return businessContract.InvalidArgumentError{Message: "expectedVersion cannot be negative unless it is AnyVersion."}
 	}
 
-	for key, value := range address.AddressDetails {
-		diagnostics.IsNotNilOrEmptyOrWhitespace(key, "key", "key cannot be empty or contains whitespace only.")
-		diagnostics.IsNotNilOrEmptyOrWhitespace(value, "value", "value cannot be empty or contains whitespace only.")
+	return nil
+}
+
+// firstError returns the first provided error which is not nil, so the validations of the input parameters can be
+// combined.
+func firstError(errs ...error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
 	}
+
+	return nil
 }
 
 // mapToDataAddress Maps the domain address object to the Address object used in data layer.
 // address: Mandatory. The address domain object
 // Returns the converted address object used in data layer
 func mapToDataAddress(address domain.Address) contract.Address {
-	return contract.Address{AddressDetails: address.AddressDetails}
+	return contract.Address{AddressDetails: address.AddressDetails, TTL: address.TTL}
 }
 
 // mapFromDataAddress Maps the address object used in data layer to the Address domain object.
 // address: Mandatory. The address object used in data layer
 // Returns the converted address domain object
 func mapFromDataAddress(address contract.Address) domain.Address {
-	return domain.Address{AddressDetails: address.AddressDetails}
+	return domain.Address{AddressDetails: address.AddressDetails, Version: address.Version, TTL: address.TTL}
+}
+
+// mapFromDataListedAddresses Maps the listed addresses used in data layer to the ListedAddress domain objects.
+// listedAddresses: Mandatory. The listed addresses used in data layer
+// Returns the converted listed address domain objects
+func mapFromDataListedAddresses(listedAddresses []contract.ListedAddress) []domain.ListedAddress {
+	mappedListedAddresses := make([]domain.ListedAddress, 0, len(listedAddresses))
+
+	for _, listedAddress := range listedAddresses {
+		mappedListedAddresses = append(
+			mappedListedAddresses,
+			domain.ListedAddress{AddressID: listedAddress.AddressID, Address: mapFromDataAddress(listedAddress.Address)})
+	}
+
+	return mappedListedAddresses
+}
+
+// mapToDataAddressOwners maps the domain address owners to the AddressOwner objects used in data layer.
+func mapToDataAddressOwners(owners []domain.AddressOwner) []contract.AddressOwner {
+	mappedOwners := make([]contract.AddressOwner, 0, len(owners))
+
+	for _, owner := range owners {
+		mappedOwners = append(mappedOwners, contract.AddressOwner(owner))
+	}
+
+	return mappedOwners
+}
+
+// mapFromDataAddressOwners maps the AddressOwner objects used in data layer to the domain address owners.
+func mapFromDataAddressOwners(owners []contract.AddressOwner) []domain.AddressOwner {
+	mappedOwners := make([]domain.AddressOwner, 0, len(owners))
+
+	for _, owner := range owners {
+		mappedOwners = append(mappedOwners, domain.AddressOwner(owner))
+	}
+
+	return mappedOwners
+}
+
+// mapFromDataAddressHistoryEntry Maps the address history entry used in data layer to the AddressHistoryEntry domain object.
+// historyEntry: Mandatory. The address history entry used in data layer
+// Returns the converted address history entry domain object
+func mapFromDataAddressHistoryEntry(historyEntry contract.AddressHistoryEntry) domain.AddressHistoryEntry {
+	return domain.AddressHistoryEntry{
+		Address:   mapFromDataAddress(historyEntry.Address),
+		ChangedAt: historyEntry.ChangedAt,
+		ChangedBy: historyEntry.ChangedBy,
+		Deleted:   historyEntry.Deleted,
+	}
+}
+
+// mapFromDataAddressResults Maps the address results used in data layer to the AddressResult domain objects.
+// results: Mandatory. The address results used in data layer
+// Returns the converted address result domain objects
+func mapFromDataAddressResults(results []contract.AddressResult) []domain.AddressResult {
+	mappedResults := make([]domain.AddressResult, 0, len(results))
+
+	for _, result := range results {
+		mappedResults = append(mappedResults, domain.AddressResult{
+			AddressID: result.AddressID,
+			Address:   mapFromDataAddress(result.Address),
+			Err:       mapFromDataError(result.Err),
+		})
+	}
+
+	return mappedResults
+}
+
+// mergeAddressResults sets the results of the valid items of a bulk operation returned by data layer among the results of
+// the invalid ones.
+// results: Mandatory. The results of all the items, with the errors of the invalid ones set
+// validIndexes: Mandatory. The indexes of the valid items in results, in the same order as dataResults
+// dataResults: Mandatory. The results of the valid items returned by data layer
+// Returns the results of all the items in their original order
+func mergeAddressResults(results []domain.AddressResult, validIndexes []int, dataResults []contract.AddressResult) []domain.AddressResult {
+	for index, result := range mapFromDataAddressResults(dataResults) {
+		results[validIndexes[index]] = result
+	}
+
+	return results
+}
+
+// mapFromDataErasureCertificate Maps the erasure certificate used in data layer to the ErasureCertificate domain object.
+// certificate: Mandatory. The erasure certificate used in data layer
+// Returns the converted erasure certificate domain object
+func mapFromDataErasureCertificate(certificate contract.ErasureCertificate) domain.ErasureCertificate {
+	return domain.ErasureCertificate{
+		CertificateID: certificate.CertificateID,
+		TenantID:      certificate.TenantID,
+		ApplicationID: certificate.ApplicationID,
+		RequestedBy:   certificate.RequestedBy,
+		StartedAt:     certificate.StartedAt,
+		CompletedAt:   certificate.CompletedAt,
+		ErasedRows:    certificate.ErasedRows,
+	}
+}
+
+// mapFromDataError Maps the typed errors returned by data layer to the business layer errors.
+// err: Optional. The error returned by data layer
+// Returns the converted error, or the provided error if it does not have a business layer equivalent
+func mapFromDataError(err error) error {
+	switch dataError := err.(type) {
+	case contract.NotFoundError:
+		return businessContract.NotFoundError{AddressID: dataError.AddressID, Deleted: dataError.Deleted}
+	case contract.InvalidArgumentError:
+		return businessContract.InvalidArgumentError{Message: dataError.Message}
+	case contract.ConflictError:
+		return businessContract.ConflictError{
+			AddressID:       dataError.AddressID,
+			ExpectedVersion: dataError.ExpectedVersion,
+			ActualVersion:   dataError.ActualVersion,
+		}
+	case contract.UnavailableError:
+		return businessContract.UnavailableError{Err: dataError.Err}
+	}
+
+	return err
 }
@@ -0,0 +1,165 @@
+package service_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	businessContract "github.com/micro-business/AddressService/business/contract"
+	"github.com/micro-business/AddressService/business/domain"
+	"github.com/micro-business/AddressService/business/service"
+	"github.com/micro-business/AddressService/data/contract"
+	"github.com/micro-business/Micro-Business-Core/system"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("CreateMany method input parameters and dependency test", func() {
+	var (
+		ctx                    context.Context
+		mockCtrl               *gomock.Controller
+		addressService         *service.AddressService
+		mockAddressDataService *MockAddressDataService
+		tenantID               system.UUID
+		applicationID          system.UUID
+		validAddresses         []domain.Address
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockAddressDataService = NewMockAddressDataService(mockCtrl)
+
+		addressService = &service.AddressService{AddressDataService: mockAddressDataService}
+
+		tenantID, _ = system.RandomUUID()
+		applicationID, _ = system.RandomUUID()
+		validAddresses = []domain.Address{{AddressDetails: map[string]string{"City": "Christchurch"}}}
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	Context("when address data service not provided", func() {
+		It("should panic", func() {
+			addressService.AddressDataService = nil
+
+			Ω(func() { addressService.CreateMany(ctx, tenantID, applicationID, validAddresses, "") }).Should(Panic())
+		})
+	})
+
+	Describe("Input Parameters", func() {
+		It("should return InvalidArgumentError when empty tenant unique identifier provided", func() {
+			_, err := addressService.CreateMany(ctx, system.EmptyUUID, applicationID, validAddresses, "")
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+		})
+
+		It("should return InvalidArgumentError when empty application unique identifier provided", func() {
+			_, err := addressService.CreateMany(ctx, tenantID, system.EmptyUUID, validAddresses, "")
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+		})
+
+		It("should return InvalidArgumentError when no address provided", func() {
+			_, err := addressService.CreateMany(ctx, tenantID, applicationID, []domain.Address{}, "")
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+		})
+
+		It("should return InvalidArgumentError when more than the maximum batch size of addresses provided", func() {
+			addresses := make([]domain.Address, service.MaxBatchSize+1)
+
+			_, err := addressService.CreateMany(ctx, tenantID, applicationID, addresses, "")
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+		})
+
+		It("should return InvalidArgumentError as the result of the invalid address without creating any address when all of the addresses are invalid", func() {
+			results, err := addressService.CreateMany(ctx, tenantID, applicationID, []domain.Address{{AddressDetails: map[string]string{}}}, "")
+
+			Expect(err).To(BeNil())
+			Expect(results).To(HaveLen(1))
+			Expect(results[0].Err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+		})
+	})
+})
+
+var _ = Describe("CreateMany method behaviour", func() {
+	var (
+		ctx                    context.Context
+		mockCtrl               *gomock.Controller
+		addressService         *service.AddressService
+		mockAddressDataService *MockAddressDataService
+		tenantID               system.UUID
+		applicationID          system.UUID
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockAddressDataService = NewMockAddressDataService(mockCtrl)
+
+		addressService = &service.AddressService{AddressDataService: mockAddressDataService}
+
+		tenantID, _ = system.RandomUUID()
+		applicationID, _ = system.RandomUUID()
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	Context("when address data service creates some of the addresses", func() {
+		It("should return the result of creating every address", func() {
+			addressID, _ := system.RandomUUID()
+			expectedError := errors.New("failed to create address")
+			addressDetails := map[string]string{"City": "Christchurch"}
+			mockAddressDataService.
+				EXPECT().
+				CreateMany(ctx, tenantID, applicationID, []contract.Address{{AddressDetails: addressDetails}, {AddressDetails: addressDetails}}, "importer").
+				Return([]contract.AddressResult{{AddressID: addressID}, {Err: expectedError}})
+
+			results, err := addressService.CreateMany(
+				ctx,
+				tenantID,
+				applicationID,
+				[]domain.Address{{AddressDetails: addressDetails}, {AddressDetails: addressDetails}},
+				"importer")
+
+			Expect(err).To(BeNil())
+			Expect(results).To(Equal([]domain.AddressResult{{AddressID: addressID}, {Err: expectedError}}))
+		})
+	})
+
+	Context("when some of the addresses are invalid", func() {
+		It("should create the valid addresses and return InvalidArgumentError as the result of the invalid ones", func() {
+			addressID, _ := system.RandomUUID()
+			addressDetails := map[string]string{"City": "Christchurch"}
+			mockAddressDataService.
+				EXPECT().
+				CreateMany(ctx, tenantID, applicationID, []contract.Address{{AddressDetails: addressDetails}}, "importer").
+				Return([]contract.AddressResult{{AddressID: addressID}})
+
+			results, err := addressService.CreateMany(
+				ctx,
+				tenantID,
+				applicationID,
+				[]domain.Address{{AddressDetails: map[string]string{}}, {AddressDetails: addressDetails}},
+				"importer")
+
+			Expect(err).To(BeNil())
+			Expect(results).To(HaveLen(2))
+			Expect(results[0].Err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+			Expect(results[1]).To(Equal(domain.AddressResult{AddressID: addressID}))
+		})
+	})
+})
+
+func TestCreateMany(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "CreateMany method input parameters and dependency test")
+	RunSpecs(t, "CreateMany method behaviour")
+}
@@ -4,18 +4,22 @@ import (
 	"errors"
 	"math/rand"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
+	businessContract "github.com/micro-business/AddressService/business/contract"
 	"github.com/micro-business/AddressService/business/domain"
 	"github.com/micro-business/AddressService/business/service"
 	"github.com/micro-business/AddressService/data/contract"
 	"github.com/micro-business/Micro-Business-Core/system"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
 )
 
 var _ = Describe("Create method input parameters and dependency test", func() {
 	var (
+		ctx                        context.Context
 		mockCtrl                   *gomock.Controller
 		addressService             *service.AddressService
 		mockAddressDataService     *MockAddressDataService
@@ -25,11 +29,13 @@ var _ = Describe("Create method input parameters and dependency test", func() {
 		emptyAddress               domain.Address
 		addressWithEmptyKey        domain.Address
 		addressWithWhitespaceKey   domain.Address
+		addressWithHostileKey      domain.Address
 		addressWithEmptyValue      domain.Address
 		addressWithWhitespaceValue domain.Address
 	)
 
 	BeforeEach(func() {
+		ctx = context.Background()
 		mockCtrl = gomock.NewController(GinkgoT())
 		mockAddressDataService = NewMockAddressDataService(mockCtrl)
 
@@ -41,6 +47,7 @@ var _ = Describe("Create method input parameters and dependency test", func() {
 		emptyAddress = domain.Address{}
 		addressWithEmptyKey = domain.Address{AddressDetails: map[string]string{"": "Christchurch"}}
 		addressWithWhitespaceKey = domain.Address{AddressDetails: map[string]string{"    ": "Christchurch"}}
+		addressWithHostileKey = domain.Address{AddressDetails: map[string]string{"City') OR address_key = ('": "Christchurch"}}
 		addressWithEmptyValue = domain.Address{AddressDetails: map[string]string{"City": ""}}
 		addressWithWhitespaceValue = domain.Address{AddressDetails: map[string]string{"City": "    "}}
 	})
@@ -53,43 +60,76 @@ var _ = Describe("Create method input parameters and dependency test", func() {
 		It("should panic", func() {
 			addressService.AddressDataService = nil
 
-			Ω(func() { addressService.Create(tenantID, applicationID, validAddress) }).Should(Panic())
+			Ω(func() { addressService.Create(ctx, tenantID, applicationID, validAddress, "") }).Should(Panic())
 		})
 	})
 
 	Describe("Input Parameters", func() {
-		It("should panic when empty tenant unique identifier provided", func() {
-			Ω(func() { addressService.Create(system.EmptyUUID, applicationID, validAddress) }).Should(Panic())
+		It("should return InvalidArgumentError when empty tenant unique identifier provided", func() {
+			_, err := addressService.Create(ctx, system.EmptyUUID, applicationID, validAddress, "")
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+		})
+
+		It("should return InvalidArgumentError when empty application unique identifier provided", func() {
+			_, err := addressService.Create(ctx, tenantID, system.EmptyUUID, validAddress, "")
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
 		})
 
-		It("should panic when empty application unique identifier provided", func() {
-			Ω(func() { addressService.Create(tenantID, system.EmptyUUID, validAddress) }).Should(Panic())
+		It("should return InvalidArgumentError when address without address key provided", func() {
+			_, err := addressService.Create(ctx, tenantID, applicationID, emptyAddress, "")
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
 		})
 
-		It("should panic when address without address key provided", func() {
-			Ω(func() { addressService.Create(tenantID, applicationID, emptyAddress) }).Should(Panic())
+		It("should return InvalidArgumentError when address with empty key provided", func() {
+			_, err := addressService.Create(ctx, tenantID, applicationID, addressWithEmptyKey, "")
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
 		})
 
-		It("should panic when address with empty key provided", func() {
-			Ω(func() { addressService.Create(tenantID, applicationID, addressWithEmptyKey) }).Should(Panic())
+		It("should return InvalidArgumentError when address with key contains whitespace only provided", func() {
+			_, err := addressService.Create(ctx, tenantID, applicationID, addressWithWhitespaceKey, "")
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
 		})
 
-		It("should panic when address with key contains whitespace only provided", func() {
-			Ω(func() { addressService.Create(tenantID, applicationID, addressWithWhitespaceKey) }).Should(Panic())
+		It("should return InvalidArgumentError when address with key contains not allowed characters provided", func() {
+			_, err := addressService.Create(ctx, tenantID, applicationID, addressWithHostileKey, "")
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
 		})
 
-		It("should panic when address with empty value provided", func() {
-			Ω(func() { addressService.Create(tenantID, applicationID, addressWithEmptyValue) }).Should(Panic())
+		It("should return InvalidArgumentError when address with empty value provided", func() {
+			_, err := addressService.Create(ctx, tenantID, applicationID, addressWithEmptyValue, "")
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+		})
+
+		It("should return InvalidArgumentError when address with value contains whitespace only provided", func() {
+			_, err := addressService.Create(ctx, tenantID, applicationID, addressWithWhitespaceValue, "")
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+		})
+
+		It("should return InvalidArgumentError when address with value looking like an encrypted value provided", func() {
+			_, err := addressService.Create(ctx, tenantID, applicationID, domain.Address{AddressDetails: map[string]string{"City": "enc:1:AAAA"}}, "")
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
 		})
 
-		It("should panic when address with value contains whitespace only provided", func() {
-			Ω(func() { addressService.Create(tenantID, applicationID, addressWithWhitespaceValue) }).Should(Panic())
+		It("should return InvalidArgumentError when address with negative TTL provided", func() {
+			_, err := addressService.Create(ctx, tenantID, applicationID, domain.Address{AddressDetails: validAddress.AddressDetails, TTL: -time.Second}, "")
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
 		})
 	})
 })
 
 var _ = Describe("Create method behaviour", func() {
 	var (
+		ctx                    context.Context
 		mockCtrl               *gomock.Controller
 		addressService         *service.AddressService
 		mockAddressDataService *MockAddressDataService
@@ -99,6 +139,7 @@ var _ = Describe("Create method behaviour", func() {
 	)
 
 	BeforeEach(func() {
+		ctx = context.Background()
 		mockCtrl = gomock.NewController(GinkgoT())
 		mockAddressDataService = NewMockAddressDataService(mockCtrl)
 
@@ -115,10 +156,19 @@ var _ = Describe("Create method behaviour", func() {
 
 	It("should call address data service Create function", func() {
 		mappedAddress := contract.Address{AddressDetails: validAddress.AddressDetails}
+		changedBy := "support-agent"
+
+		mockAddressDataService.EXPECT().Create(ctx, tenantID, applicationID, mappedAddress, changedBy)
+
+		addressService.Create(ctx, tenantID, applicationID, validAddress, changedBy)
+	})
 
-		mockAddressDataService.EXPECT().Create(tenantID, applicationID, mappedAddress)
+	It("should pass the address TTL to address data service Create function", func() {
+		addressWithTTL := domain.Address{AddressDetails: validAddress.AddressDetails, TTL: time.Hour}
 
-		addressService.Create(tenantID, applicationID, validAddress)
+		mockAddressDataService.EXPECT().Create(ctx, tenantID, applicationID, contract.Address{AddressDetails: validAddress.AddressDetails, TTL: time.Hour}, "")
+
+		addressService.Create(ctx, tenantID, applicationID, addressWithTTL, "")
 	})
 
 	Context("when address data service succeeds to create the new address", func() {
@@ -137,12 +187,12 @@ var _ = Describe("Create method behaviour", func() {
 			expectedAddressID, _ := system.RandomUUID()
 			mockAddressDataService.
 				EXPECT().
-				Create(tenantID, applicationID, mappedAddress).
+				Create(ctx, tenantID, applicationID, mappedAddress, "").
 				Return(expectedAddressID, nil)
 
-			newAddressID, err := addressService.Create(tenantID, applicationID, domain.Address{AddressDetails: addressDetails})
+			createdAddress, err := addressService.Create(ctx, tenantID, applicationID, domain.Address{AddressDetails: addressDetails}, "")
 
-			Expect(expectedAddressID).To(Equal(newAddressID))
+			Expect(createdAddress).To(Equal(domain.CreatedAddress{AddressID: expectedAddressID}))
 			Expect(err).To(BeNil())
 		})
 	})
@@ -155,19 +205,222 @@ var _ = Describe("Create method behaviour", func() {
 			expectedError := errors.New(expectedErrorID.String())
 			mockAddressDataService.
 				EXPECT().
-				Create(tenantID, applicationID, mappedAddress).
+				Create(ctx, tenantID, applicationID, mappedAddress, "").
 				Return(system.EmptyUUID, expectedError)
 
-			newAddressID, err := addressService.Create(tenantID, applicationID, validAddress)
+			createdAddress, err := addressService.Create(ctx, tenantID, applicationID, validAddress, "")
 
-			Expect(newAddressID).To(Equal(system.EmptyUUID))
+			Expect(createdAddress.AddressID).To(Equal(system.EmptyUUID))
 			Expect(err).To(Equal(expectedError))
 		})
 	})
 })
 
+var _ = Describe("Create method duplicate detection", func() {
+	var (
+		ctx                    context.Context
+		mockCtrl               *gomock.Controller
+		addressService         *service.AddressService
+		mockAddressDataService *MockAddressDataService
+		tenantID               system.UUID
+		applicationID          system.UUID
+		newAddressID           system.UUID
+		existingAddressID      system.UUID
+		address                domain.Address
+		existingAddress        contract.ListedAddress
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockAddressDataService = NewMockAddressDataService(mockCtrl)
+
+		addressService = &service.AddressService{AddressDataService: mockAddressDataService, DuplicatePolicy: service.WarnOnDuplicateAddress}
+
+		tenantID, _ = system.RandomUUID()
+		applicationID, _ = system.RandomUUID()
+		newAddressID, _ = system.RandomUUID()
+		existingAddressID, _ = system.RandomUUID()
+		address = domain.Address{AddressDetails: map[string]string{"Street": "12 Main St.", "City": "Christchurch"}}
+		existingAddress = contract.ListedAddress{
+			AddressID: existingAddressID,
+			Address:   contract.Address{AddressDetails: map[string]string{"street": " 12  MAIN street ", "CITY": "christchurch"}, Version: 3},
+		}
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	It("should give the same fingerprint to the addresses differing only by case, whitespace and abbreviations", func() {
+		fingerprints := []string{}
+
+		for _, addressDetails := range []map[string]string{address.AddressDetails, existingAddress.Address.AddressDetails} {
+			mockAddressDataService.
+				EXPECT().
+				FindByFingerprint(ctx, tenantID, applicationID, gomock.Any()).
+				Do(func(ctx context.Context, tenantID, applicationID system.UUID, fingerprint string) {
+					fingerprints = append(fingerprints, fingerprint)
+				})
+			mockAddressDataService.
+				EXPECT().
+				Create(ctx, tenantID, applicationID, gomock.Any(), "").
+				Return(newAddressID, nil)
+
+			_, err := addressService.Create(ctx, tenantID, applicationID, domain.Address{AddressDetails: addressDetails}, "")
+
+			Expect(err).To(BeNil())
+		}
+
+		Expect(fingerprints).To(HaveLen(2))
+		Expect(fingerprints[0]).NotTo(BeEmpty())
+		Expect(fingerprints[0]).To(Equal(fingerprints[1]))
+	})
+
+	It("should give different fingerprints to different addresses", func() {
+		fingerprints := []string{}
+
+		for _, addressDetails := range []map[string]string{address.AddressDetails, {"Street": "14 Main St.", "City": "Christchurch"}} {
+			mockAddressDataService.
+				EXPECT().
+				FindByFingerprint(ctx, tenantID, applicationID, gomock.Any()).
+				Do(func(ctx context.Context, tenantID, applicationID system.UUID, fingerprint string) {
+					fingerprints = append(fingerprints, fingerprint)
+				})
+			mockAddressDataService.
+				EXPECT().
+				Create(ctx, tenantID, applicationID, gomock.Any(), "").
+				Return(newAddressID, nil)
+
+			addressService.Create(ctx, tenantID, applicationID, domain.Address{AddressDetails: addressDetails}, "")
+		}
+
+		Expect(fingerprints).To(HaveLen(2))
+		Expect(fingerprints[0]).NotTo(Equal(fingerprints[1]))
+	})
+
+	Context("when no address with the same fingerprint exists", func() {
+		It("should create the address along with its fingerprint and return no duplicate", func() {
+			var fingerprint string
+
+			mockAddressDataService.
+				EXPECT().
+				FindByFingerprint(ctx, tenantID, applicationID, gomock.Any()).
+				Do(func(ctx context.Context, tenantID, applicationID system.UUID, foundFingerprint string) {
+					fingerprint = foundFingerprint
+				}).
+				Return([]contract.ListedAddress{}, nil)
+			mockAddressDataService.
+				EXPECT().
+				Create(ctx, tenantID, applicationID, gomock.Any(), "").
+				Do(func(ctx context.Context, tenantID, applicationID system.UUID, dataAddress contract.Address, changedBy string) {
+					Expect(dataAddress.AddressDetails).To(Equal(address.AddressDetails))
+					Expect(dataAddress.Fingerprint).To(Equal(fingerprint))
+				}).
+				Return(newAddressID, nil)
+
+			createdAddress, err := addressService.Create(ctx, tenantID, applicationID, address, "")
+
+			Expect(err).To(BeNil())
+			Expect(createdAddress).To(Equal(domain.CreatedAddress{AddressID: newAddressID}))
+		})
+	})
+
+	Context("when an address with the same fingerprint exists", func() {
+		BeforeEach(func() {
+			mockAddressDataService.
+				EXPECT().
+				FindByFingerprint(ctx, tenantID, applicationID, gomock.Any()).
+				Return([]contract.ListedAddress{existingAddress}, nil)
+		})
+
+		It("should create the address and return the existing address as duplicate when warning on duplicates", func() {
+			mockAddressDataService.
+				EXPECT().
+				Create(ctx, tenantID, applicationID, gomock.Any(), "").
+				Return(newAddressID, nil)
+
+			createdAddress, err := addressService.Create(ctx, tenantID, applicationID, address, "")
+
+			Expect(err).To(BeNil())
+			Expect(createdAddress).To(Equal(domain.CreatedAddress{AddressID: newAddressID, DuplicateOf: existingAddressID}))
+		})
+
+		It("should not create the address and return DuplicateAddressError when rejecting duplicates", func() {
+			addressService.DuplicatePolicy = service.RejectDuplicateAddress
+
+			createdAddress, err := addressService.Create(ctx, tenantID, applicationID, address, "")
+
+			Expect(err).To(Equal(businessContract.DuplicateAddressError{AddressID: existingAddressID}))
+			Expect(createdAddress).To(Equal(domain.CreatedAddress{}))
+		})
+
+		It("should not create the address and return the existing address when returning existing addresses", func() {
+			addressService.DuplicatePolicy = service.ReturnExistingAddress
+
+			createdAddress, err := addressService.Create(ctx, tenantID, applicationID, address, "")
+
+			Expect(err).To(BeNil())
+			Expect(createdAddress).To(Equal(domain.CreatedAddress{AddressID: existingAddressID, DuplicateOf: existingAddressID}))
+		})
+	})
+
+	Context("when the address found by the fingerprint has been patched since", func() {
+		It("should create the address and return no duplicate", func() {
+			addressService.DuplicatePolicy = service.RejectDuplicateAddress
+			existingAddress.Address.AddressDetails = map[string]string{"Street": "14 Main St.", "City": "Christchurch"}
+
+			mockAddressDataService.
+				EXPECT().
+				FindByFingerprint(ctx, tenantID, applicationID, gomock.Any()).
+				Return([]contract.ListedAddress{existingAddress}, nil)
+			mockAddressDataService.
+				EXPECT().
+				Create(ctx, tenantID, applicationID, gomock.Any(), "").
+				Return(newAddressID, nil)
+
+			createdAddress, err := addressService.Create(ctx, tenantID, applicationID, address, "")
+
+			Expect(err).To(BeNil())
+			Expect(createdAddress).To(Equal(domain.CreatedAddress{AddressID: newAddressID}))
+		})
+	})
+
+	Context("when address data service fails to find the addresses by fingerprint", func() {
+		It("should not create the address and return the mapped error", func() {
+			mockAddressDataService.
+				EXPECT().
+				FindByFingerprint(ctx, tenantID, applicationID, gomock.Any()).
+				Return(nil, contract.UnavailableError{Err: errors.New("timeout")})
+
+			_, err := addressService.Create(ctx, tenantID, applicationID, address, "")
+
+			Expect(err).To(Equal(businessContract.UnavailableError{Err: errors.New("timeout")}))
+		})
+	})
+
+	Context("when address is updated", func() {
+		It("should pass the fingerprint of the new address details to address data service Update function", func() {
+			mockAddressDataService.
+				EXPECT().
+				Update(ctx, tenantID, applicationID, existingAddressID, gomock.Any(), contract.AnyVersion, "").
+				Do(func(
+					ctx context.Context,
+					tenantID, applicationID, addressID system.UUID,
+					dataAddress contract.Address,
+					expectedVersion int64,
+					changedBy string) {
+					Expect(dataAddress.Fingerprint).NotTo(BeEmpty())
+				})
+
+			Expect(addressService.Update(ctx, tenantID, applicationID, existingAddressID, address, contract.AnyVersion, "")).To(BeNil())
+		})
+	})
+})
+
 func TestCreate(t *testing.T) {
 	RegisterFailHandler(Fail)
 	RunSpecs(t, "Create method input parameters and dependency test")
 	RunSpecs(t, "Create method behaviour")
+	RunSpecs(t, "Create method duplicate detection")
 }
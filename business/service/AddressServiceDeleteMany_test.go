@@ -0,0 +1,157 @@
+package service_test
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	businessContract "github.com/micro-business/AddressService/business/contract"
+	"github.com/micro-business/AddressService/business/domain"
+	"github.com/micro-business/AddressService/business/service"
+	"github.com/micro-business/AddressService/data/contract"
+	"github.com/micro-business/Micro-Business-Core/system"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("DeleteMany method input parameters and dependency test", func() {
+	var (
+		ctx                    context.Context
+		mockCtrl               *gomock.Controller
+		addressService         *service.AddressService
+		mockAddressDataService *MockAddressDataService
+		tenantID               system.UUID
+		applicationID          system.UUID
+		addressIDs             []system.UUID
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockAddressDataService = NewMockAddressDataService(mockCtrl)
+
+		addressService = &service.AddressService{AddressDataService: mockAddressDataService}
+
+		tenantID, _ = system.RandomUUID()
+		applicationID, _ = system.RandomUUID()
+		addressID, _ := system.RandomUUID()
+		addressIDs = []system.UUID{addressID}
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	Context("when address data service not provided", func() {
+		It("should panic", func() {
+			addressService.AddressDataService = nil
+
+			Ω(func() { addressService.DeleteMany(ctx, tenantID, applicationID, addressIDs, "") }).Should(Panic())
+		})
+	})
+
+	Describe("Input Parameters", func() {
+		It("should return InvalidArgumentError when empty tenant unique identifier provided", func() {
+			_, err := addressService.DeleteMany(ctx, system.EmptyUUID, applicationID, addressIDs, "")
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+		})
+
+		It("should return InvalidArgumentError when empty application unique identifier provided", func() {
+			_, err := addressService.DeleteMany(ctx, tenantID, system.EmptyUUID, addressIDs, "")
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+		})
+
+		It("should return InvalidArgumentError when no address unique identifier provided", func() {
+			_, err := addressService.DeleteMany(ctx, tenantID, applicationID, []system.UUID{}, "")
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+		})
+
+		It("should return InvalidArgumentError when more than the maximum batch size of address unique identifiers provided", func() {
+			_, err := addressService.DeleteMany(ctx, tenantID, applicationID, make([]system.UUID, service.MaxBatchSize+1), "")
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+		})
+
+		It("should return InvalidArgumentError as the result of the empty address unique identifier", func() {
+			results, err := addressService.DeleteMany(ctx, tenantID, applicationID, []system.UUID{system.EmptyUUID}, "")
+
+			Expect(err).To(BeNil())
+			Expect(results).To(HaveLen(1))
+			Expect(results[0].Err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+		})
+	})
+})
+
+var _ = Describe("DeleteMany method behaviour", func() {
+	var (
+		ctx                    context.Context
+		mockCtrl               *gomock.Controller
+		addressService         *service.AddressService
+		mockAddressDataService *MockAddressDataService
+		tenantID               system.UUID
+		applicationID          system.UUID
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockAddressDataService = NewMockAddressDataService(mockCtrl)
+
+		addressService = &service.AddressService{AddressDataService: mockAddressDataService}
+
+		tenantID, _ = system.RandomUUID()
+		applicationID, _ = system.RandomUUID()
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	Context("when address data service deletes some of the addresses", func() {
+		It("should return the result of deleting every address with conflict errors mapped to business layer errors", func() {
+			deletedAddressID, _ := system.RandomUUID()
+			conflictedAddressID, _ := system.RandomUUID()
+			mockAddressDataService.
+				EXPECT().
+				DeleteMany(ctx, tenantID, applicationID, []system.UUID{deletedAddressID, conflictedAddressID}, "support-agent").
+				Return([]contract.AddressResult{
+					{AddressID: deletedAddressID},
+					{AddressID: conflictedAddressID, Err: contract.ConflictError{AddressID: conflictedAddressID, ExpectedVersion: 1, ActualVersion: 2}},
+				})
+
+			results, err := addressService.DeleteMany(ctx, tenantID, applicationID, []system.UUID{deletedAddressID, conflictedAddressID}, "support-agent")
+
+			Expect(err).To(BeNil())
+			Expect(results).To(Equal([]domain.AddressResult{
+				{AddressID: deletedAddressID},
+				{AddressID: conflictedAddressID, Err: businessContract.ConflictError{AddressID: conflictedAddressID, ExpectedVersion: 1, ActualVersion: 2}},
+			}))
+		})
+	})
+
+	Context("when some of the address unique identifiers are empty", func() {
+		It("should delete the other addresses and return InvalidArgumentError as the result of the empty ones", func() {
+			deletedAddressID, _ := system.RandomUUID()
+			mockAddressDataService.
+				EXPECT().
+				DeleteMany(ctx, tenantID, applicationID, []system.UUID{deletedAddressID}, "support-agent").
+				Return([]contract.AddressResult{{AddressID: deletedAddressID}})
+
+			results, err := addressService.DeleteMany(ctx, tenantID, applicationID, []system.UUID{system.EmptyUUID, deletedAddressID}, "support-agent")
+
+			Expect(err).To(BeNil())
+			Expect(results).To(HaveLen(2))
+			Expect(results[0].Err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+			Expect(results[1]).To(Equal(domain.AddressResult{AddressID: deletedAddressID}))
+		})
+	})
+})
+
+func TestDeleteMany(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "DeleteMany method input parameters and dependency test")
+	RunSpecs(t, "DeleteMany method behaviour")
+}
@@ -2,17 +2,22 @@ package service_test
 
 import (
 	"errors"
+	"math/rand"
 	"testing"
 
 	"github.com/golang/mock/gomock"
+	businessContract "github.com/micro-business/AddressService/business/contract"
 	"github.com/micro-business/AddressService/business/service"
+	"github.com/micro-business/AddressService/data/contract"
 	"github.com/micro-business/Micro-Business-Core/system"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
 )
 
 var _ = Describe("Delete method input parameters and dependency test", func() {
 	var (
+		ctx                    context.Context
 		mockCtrl               *gomock.Controller
 		addressService         *service.AddressService
 		mockAddressDataService *MockAddressDataService
@@ -22,6 +27,7 @@ var _ = Describe("Delete method input parameters and dependency test", func() {
 	)
 
 	BeforeEach(func() {
+		ctx = context.Background()
 		mockCtrl = gomock.NewController(GinkgoT())
 		mockAddressDataService = NewMockAddressDataService(mockCtrl)
 
@@ -40,36 +46,51 @@ var _ = Describe("Delete method input parameters and dependency test", func() {
 		It("should panic", func() {
 			addressService.AddressDataService = nil
 
-			Ω(func() { addressService.Delete(tenantID, applicationID, addressID) }).Should(Panic())
+			Ω(func() { addressService.Delete(ctx, tenantID, applicationID, addressID, contract.AnyVersion, "") }).Should(Panic())
 		})
 	})
 
 	Describe("Input Parameters", func() {
-		It("should panic when empty tenant unique identifier provided", func() {
-			Ω(func() { addressService.Delete(system.EmptyUUID, applicationID, addressID) }).Should(Panic())
+		It("should return InvalidArgumentError when empty tenant unique identifier provided", func() {
+			err := addressService.Delete(ctx, system.EmptyUUID, applicationID, addressID, contract.AnyVersion, "")
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
 		})
 
-		It("should panic when empty application unique identifier provided", func() {
-			Ω(func() { addressService.Delete(tenantID, system.EmptyUUID, addressID) }).Should(Panic())
+		It("should return InvalidArgumentError when empty application unique identifier provided", func() {
+			err := addressService.Delete(ctx, tenantID, system.EmptyUUID, addressID, contract.AnyVersion, "")
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
 		})
 
-		It("should panic when empty address unique identifier provided", func() {
-			Ω(func() { addressService.Delete(tenantID, applicationID, system.EmptyUUID) }).Should(Panic())
+		It("should return InvalidArgumentError when empty address unique identifier provided", func() {
+			err := addressService.Delete(ctx, tenantID, applicationID, system.EmptyUUID, contract.AnyVersion, "")
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+		})
+
+		It("should return InvalidArgumentError when negative expected version provided", func() {
+			err := addressService.Delete(ctx, tenantID, applicationID, addressID, -2, "")
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
 		})
 	})
 })
 
 var _ = Describe("Delete method behaviour", func() {
 	var (
+		ctx                    context.Context
 		mockCtrl               *gomock.Controller
 		addressService         *service.AddressService
 		mockAddressDataService *MockAddressDataService
 		tenantID               system.UUID
 		applicationID          system.UUID
 		addressID              system.UUID
+		expectedVersion        int64
 	)
 
 	BeforeEach(func() {
+		ctx = context.Background()
 		mockCtrl = gomock.NewController(GinkgoT())
 		mockAddressDataService = NewMockAddressDataService(mockCtrl)
 
@@ -78,6 +99,7 @@ var _ = Describe("Delete method behaviour", func() {
 		tenantID, _ = system.RandomUUID()
 		applicationID, _ = system.RandomUUID()
 		addressID, _ = system.RandomUUID()
+		expectedVersion = int64(rand.Intn(100) + 1)
 	})
 
 	AfterEach(func() {
@@ -85,19 +107,21 @@ var _ = Describe("Delete method behaviour", func() {
 	})
 
 	It("should call address data service Delete function", func() {
-		mockAddressDataService.EXPECT().Delete(tenantID, applicationID, addressID)
+		changedBy := "support-agent"
+
+		mockAddressDataService.EXPECT().Delete(ctx, tenantID, applicationID, addressID, expectedVersion, changedBy)
 
-		addressService.Delete(tenantID, applicationID, addressID)
+		addressService.Delete(ctx, tenantID, applicationID, addressID, expectedVersion, changedBy)
 	})
 
 	Context("when address data service succeeds to delete the requested address", func() {
 		It("should return no error", func() {
 			mockAddressDataService.
 				EXPECT().
-				Delete(tenantID, applicationID, addressID).
+				Delete(ctx, tenantID, applicationID, addressID, expectedVersion, "").
 				Return(nil)
 
-			err := addressService.Delete(tenantID, applicationID, addressID)
+			err := addressService.Delete(ctx, tenantID, applicationID, addressID, expectedVersion, "")
 
 			Expect(err).To(BeNil())
 		})
@@ -109,14 +133,27 @@ var _ = Describe("Delete method behaviour", func() {
 			expectedError := errors.New(expectedErrorID.String())
 			mockAddressDataService.
 				EXPECT().
-				Delete(tenantID, applicationID, addressID).
+				Delete(ctx, tenantID, applicationID, addressID, expectedVersion, "").
 				Return(expectedError)
 
-			err := addressService.Delete(tenantID, applicationID, addressID)
+			err := addressService.Delete(ctx, tenantID, applicationID, addressID, expectedVersion, "")
 
 			Expect(err).To(Equal(expectedError))
 		})
 	})
+	Context("when address data service returns version conflict error", func() {
+		It("should return the conflict error as business layer conflict error", func() {
+			actualVersion := expectedVersion + 1
+			mockAddressDataService.
+				EXPECT().
+				Delete(ctx, tenantID, applicationID, addressID, expectedVersion, "").
+				Return(contract.ConflictError{AddressID: addressID, ExpectedVersion: expectedVersion, ActualVersion: actualVersion})
+
+			err := addressService.Delete(ctx, tenantID, applicationID, addressID, expectedVersion, "")
+
+			Expect(err).To(Equal(businessContract.ConflictError{AddressID: addressID, ExpectedVersion: expectedVersion, ActualVersion: actualVersion}))
+		})
+	})
 })
 
 func TestDelete(t *testing.T) {
@@ -0,0 +1,175 @@
+package service_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	businessContract "github.com/micro-business/AddressService/business/contract"
+	"github.com/micro-business/AddressService/business/domain"
+	"github.com/micro-business/AddressService/business/service"
+	"github.com/micro-business/AddressService/data/contract"
+	"github.com/micro-business/Micro-Business-Core/system"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("PurgeTenant and PurgeApplication methods input parameters and dependency test", func() {
+	var (
+		ctx                    context.Context
+		mockCtrl               *gomock.Controller
+		addressService         *service.AddressService
+		mockAddressDataService *MockAddressDataService
+		tenantID               system.UUID
+		applicationID          system.UUID
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockAddressDataService = NewMockAddressDataService(mockCtrl)
+
+		addressService = &service.AddressService{AddressDataService: mockAddressDataService}
+
+		tenantID, _ = system.RandomUUID()
+		applicationID, _ = system.RandomUUID()
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	Context("when address data service not provided", func() {
+		It("should panic", func() {
+			addressService.AddressDataService = nil
+
+			Ω(func() { addressService.PurgeTenant(ctx, tenantID, "") }).Should(Panic())
+			Ω(func() { addressService.PurgeApplication(ctx, tenantID, applicationID, "") }).Should(Panic())
+		})
+	})
+
+	Describe("Input Parameters", func() {
+		It("should return InvalidArgumentError when empty tenant unique identifier provided", func() {
+			_, err := addressService.PurgeTenant(ctx, system.EmptyUUID, "")
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+
+			_, err = addressService.PurgeApplication(ctx, system.EmptyUUID, applicationID, "")
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+		})
+
+		It("should return InvalidArgumentError when empty application unique identifier provided", func() {
+			_, err := addressService.PurgeApplication(ctx, tenantID, system.EmptyUUID, "")
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+		})
+	})
+})
+
+var _ = Describe("PurgeTenant and PurgeApplication methods behaviour", func() {
+	var (
+		ctx                    context.Context
+		mockCtrl               *gomock.Controller
+		addressService         *service.AddressService
+		mockAddressDataService *MockAddressDataService
+		tenantID               system.UUID
+		applicationID          system.UUID
+		certificate            contract.ErasureCertificate
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockAddressDataService = NewMockAddressDataService(mockCtrl)
+
+		addressService = &service.AddressService{AddressDataService: mockAddressDataService}
+
+		tenantID, _ = system.RandomUUID()
+		applicationID, _ = system.RandomUUID()
+		certificateID, _ := system.RandomUUID()
+
+		certificate = contract.ErasureCertificate{
+			CertificateID: certificateID,
+			TenantID:      tenantID,
+			RequestedBy:   "privacy-officer",
+			StartedAt:     time.Now().Add(-time.Minute),
+			CompletedAt:   time.Now(),
+			ErasedRows:    map[string]int64{"address": 4, "address_history": 2},
+		}
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	Context("when purging a tenant", func() {
+		It("should return the erasure certificate returned by address data service PurgeTenant function", func() {
+			mockAddressDataService.
+				EXPECT().
+				PurgeTenant(ctx, tenantID, "privacy-officer").
+				Return(certificate, nil)
+
+			returnedCertificate, err := addressService.PurgeTenant(ctx, tenantID, "privacy-officer")
+
+			Expect(err).To(BeNil())
+			Expect(returnedCertificate).To(Equal(domain.ErasureCertificate{
+				CertificateID: certificate.CertificateID,
+				TenantID:      tenantID,
+				RequestedBy:   "privacy-officer",
+				StartedAt:     certificate.StartedAt,
+				CompletedAt:   certificate.CompletedAt,
+				ErasedRows:    certificate.ErasedRows,
+			}))
+		})
+
+		It("should return the error returned by address data service", func() {
+			expectedErrorID, _ := system.RandomUUID()
+			expectedError := errors.New(expectedErrorID.String())
+			mockAddressDataService.
+				EXPECT().
+				PurgeTenant(ctx, tenantID, "").
+				Return(contract.ErasureCertificate{}, expectedError)
+
+			_, err := addressService.PurgeTenant(ctx, tenantID, "")
+
+			Expect(err).To(Equal(expectedError))
+		})
+	})
+
+	Context("when purging an application", func() {
+		It("should return the erasure certificate returned by address data service PurgeApplication function", func() {
+			certificate.ApplicationID = applicationID
+			mockAddressDataService.
+				EXPECT().
+				PurgeApplication(ctx, tenantID, applicationID, "privacy-officer").
+				Return(certificate, nil)
+
+			returnedCertificate, err := addressService.PurgeApplication(ctx, tenantID, applicationID, "privacy-officer")
+
+			Expect(err).To(BeNil())
+			Expect(returnedCertificate.CertificateID).To(Equal(certificate.CertificateID))
+			Expect(returnedCertificate.ApplicationID).To(Equal(applicationID))
+			Expect(returnedCertificate.ErasedRows).To(Equal(certificate.ErasedRows))
+		})
+
+		It("should return the typed error returned by address data service as the business layer error", func() {
+			mockAddressDataService.
+				EXPECT().
+				PurgeApplication(ctx, tenantID, applicationID, "").
+				Return(contract.ErasureCertificate{}, contract.UnavailableError{Err: errors.New("no hosts")})
+
+			_, err := addressService.PurgeApplication(ctx, tenantID, applicationID, "")
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.UnavailableError{}))
+		})
+	})
+})
+
+func TestErasure(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "PurgeTenant and PurgeApplication methods input parameters and dependency test")
+	RunSpecs(t, "PurgeTenant and PurgeApplication methods behaviour")
+}
@@ -0,0 +1,152 @@
+package service_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	businessContract "github.com/micro-business/AddressService/business/contract"
+	"github.com/micro-business/AddressService/business/domain"
+	"github.com/micro-business/AddressService/business/service"
+	"github.com/micro-business/AddressService/data/contract"
+	"github.com/micro-business/Micro-Business-Core/system"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("FindByDetail method input parameters and dependency test", func() {
+	var (
+		ctx                    context.Context
+		mockCtrl               *gomock.Controller
+		addressService         *service.AddressService
+		mockAddressDataService *MockAddressDataService
+		tenantID               system.UUID
+		applicationID          system.UUID
+		validCriteria          map[string]string
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockAddressDataService = NewMockAddressDataService(mockCtrl)
+
+		addressService = &service.AddressService{AddressDataService: mockAddressDataService}
+
+		tenantID, _ = system.RandomUUID()
+		applicationID, _ = system.RandomUUID()
+		validCriteria = map[string]string{"Postcode": "6011"}
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	Context("when address data service not provided", func() {
+		It("should panic", func() {
+			addressService.AddressDataService = nil
+
+			Ω(func() { addressService.FindByDetail(ctx, tenantID, applicationID, validCriteria) }).Should(Panic())
+		})
+	})
+
+	Describe("Input Parameters", func() {
+		It("should return InvalidArgumentError when empty tenant unique identifier provided", func() {
+			_, err := addressService.FindByDetail(ctx, system.EmptyUUID, applicationID, validCriteria)
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+		})
+
+		It("should return InvalidArgumentError when empty application unique identifier provided", func() {
+			_, err := addressService.FindByDetail(ctx, tenantID, system.EmptyUUID, validCriteria)
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+		})
+
+		It("should return InvalidArgumentError when no criteria provided", func() {
+			_, err := addressService.FindByDetail(ctx, tenantID, applicationID, map[string]string{})
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+		})
+
+		It("should return InvalidArgumentError when criteria contains invalid key", func() {
+			_, err := addressService.FindByDetail(ctx, tenantID, applicationID, map[string]string{"Post code": "6011"})
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+		})
+
+		It("should return InvalidArgumentError when criteria contains empty value", func() {
+			_, err := addressService.FindByDetail(ctx, tenantID, applicationID, map[string]string{"Postcode": " "})
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+		})
+	})
+})
+
+var _ = Describe("FindByDetail method behaviour", func() {
+	var (
+		ctx                    context.Context
+		mockCtrl               *gomock.Controller
+		addressService         *service.AddressService
+		mockAddressDataService *MockAddressDataService
+		tenantID               system.UUID
+		applicationID          system.UUID
+		criteria               map[string]string
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockAddressDataService = NewMockAddressDataService(mockCtrl)
+
+		addressService = &service.AddressService{AddressDataService: mockAddressDataService}
+
+		tenantID, _ = system.RandomUUID()
+		applicationID, _ = system.RandomUUID()
+		criteria = map[string]string{"City": "Wellington", "Postcode": "6011"}
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	Context("when address data service returns the matching addresses", func() {
+		It("should return the addresses as listed address domain objects", func() {
+			addressID, _ := system.RandomUUID()
+			mockAddressDataService.
+				EXPECT().
+				FindByDetail(ctx, tenantID, applicationID, criteria).
+				Return([]contract.ListedAddress{{AddressID: addressID, Address: contract.Address{AddressDetails: criteria, Version: 3}}}, nil)
+
+			listedAddresses, err := addressService.FindByDetail(ctx, tenantID, applicationID, criteria)
+
+			Expect(err).To(BeNil())
+			Expect(listedAddresses).To(Equal([]domain.ListedAddress{{AddressID: addressID, Address: domain.Address{AddressDetails: criteria, Version: 3}}}))
+		})
+	})
+
+	Context("when address data service fails to find the addresses", func() {
+		It("should return the error returned by address data service", func() {
+			expectedErrorID, _ := system.RandomUUID()
+			expectedError := errors.New(expectedErrorID.String())
+			mockAddressDataService.
+				EXPECT().
+				FindByDetail(ctx, tenantID, applicationID, criteria).
+				Return(nil, expectedError)
+
+			_, err := addressService.FindByDetail(ctx, tenantID, applicationID, criteria)
+
+			Expect(err).To(Equal(expectedError))
+		})
+	})
+
+	It("should document the same maximum number of found addresses as address data service returns", func() {
+		Expect(businessContract.MaxFoundAddresses).To(Equal(contract.MaxFoundAddresses))
+	})
+})
+
+func TestFindByDetail(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "FindByDetail method input parameters and dependency test")
+	RunSpecs(t, "FindByDetail method behaviour")
+}
@@ -0,0 +1,194 @@
+package service_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	businessContract "github.com/micro-business/AddressService/business/contract"
+	"github.com/micro-business/AddressService/business/domain"
+	"github.com/micro-business/AddressService/business/service"
+	"github.com/micro-business/AddressService/data/contract"
+	"github.com/micro-business/Micro-Business-Core/system"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("History and ReadAt methods input parameters and dependency test", func() {
+	var (
+		ctx                    context.Context
+		mockCtrl               *gomock.Controller
+		addressService         *service.AddressService
+		mockAddressDataService *MockAddressDataService
+		tenantID               system.UUID
+		applicationID          system.UUID
+		addressID              system.UUID
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockAddressDataService = NewMockAddressDataService(mockCtrl)
+
+		addressService = &service.AddressService{AddressDataService: mockAddressDataService}
+
+		tenantID, _ = system.RandomUUID()
+		applicationID, _ = system.RandomUUID()
+		addressID, _ = system.RandomUUID()
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	Context("when address data service not provided", func() {
+		It("should panic", func() {
+			addressService.AddressDataService = nil
+
+			Ω(func() { addressService.History(ctx, tenantID, applicationID, addressID) }).Should(Panic())
+			Ω(func() { addressService.ReadAt(ctx, tenantID, applicationID, addressID, time.Now()) }).Should(Panic())
+		})
+	})
+
+	Describe("Input Parameters", func() {
+		It("should return InvalidArgumentError when empty tenant unique identifier provided", func() {
+			_, err := addressService.History(ctx, system.EmptyUUID, applicationID, addressID)
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+
+			_, err = addressService.ReadAt(ctx, system.EmptyUUID, applicationID, addressID, time.Now())
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+		})
+
+		It("should return InvalidArgumentError when empty application unique identifier provided", func() {
+			_, err := addressService.History(ctx, tenantID, system.EmptyUUID, addressID)
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+
+			_, err = addressService.ReadAt(ctx, tenantID, system.EmptyUUID, addressID, time.Now())
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+		})
+
+		It("should return InvalidArgumentError when empty address unique identifier provided", func() {
+			_, err := addressService.History(ctx, tenantID, applicationID, system.EmptyUUID)
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+
+			_, err = addressService.ReadAt(ctx, tenantID, applicationID, system.EmptyUUID, time.Now())
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+		})
+
+		It("should return InvalidArgumentError when zero timestamp provided", func() {
+			_, err := addressService.ReadAt(ctx, tenantID, applicationID, addressID, time.Time{})
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+		})
+	})
+})
+
+var _ = Describe("History and ReadAt methods behaviour", func() {
+	var (
+		ctx                    context.Context
+		mockCtrl               *gomock.Controller
+		addressService         *service.AddressService
+		mockAddressDataService *MockAddressDataService
+		tenantID               system.UUID
+		applicationID          system.UUID
+		addressID              system.UUID
+		timestamp              time.Time
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockAddressDataService = NewMockAddressDataService(mockCtrl)
+
+		addressService = &service.AddressService{AddressDataService: mockAddressDataService}
+
+		tenantID, _ = system.RandomUUID()
+		applicationID, _ = system.RandomUUID()
+		addressID, _ = system.RandomUUID()
+		timestamp = time.Now().Add(-time.Hour)
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	Context("when address data service returns the address history", func() {
+		It("should return the history as address history domain objects", func() {
+			addressDetails := map[string]string{"City": "Christchurch"}
+			mockAddressDataService.
+				EXPECT().
+				History(ctx, tenantID, applicationID, addressID).
+				Return([]contract.AddressHistoryEntry{
+					{Address: contract.Address{AddressDetails: addressDetails, Version: 1}, ChangedAt: timestamp, ChangedBy: "creator"},
+					{Address: contract.Address{AddressDetails: addressDetails, Version: 2}, ChangedAt: timestamp, Deleted: true},
+				}, nil)
+
+			history, err := addressService.History(ctx, tenantID, applicationID, addressID)
+
+			Expect(err).To(BeNil())
+			Expect(history).To(Equal([]domain.AddressHistoryEntry{
+				{Address: domain.Address{AddressDetails: addressDetails, Version: 1}, ChangedAt: timestamp, ChangedBy: "creator"},
+				{Address: domain.Address{AddressDetails: addressDetails, Version: 2}, ChangedAt: timestamp, Deleted: true},
+			}))
+		})
+	})
+
+	Context("when address data service fails to return the address history", func() {
+		It("should return the error returned by address data service", func() {
+			expectedErrorID, _ := system.RandomUUID()
+			expectedError := errors.New(expectedErrorID.String())
+			mockAddressDataService.
+				EXPECT().
+				History(ctx, tenantID, applicationID, addressID).
+				Return(nil, expectedError)
+
+			_, err := addressService.History(ctx, tenantID, applicationID, addressID)
+
+			Expect(err).To(Equal(expectedError))
+		})
+	})
+
+	Context("when address data service returns the address at the provided time", func() {
+		It("should return the address as address domain object", func() {
+			addressDetails := map[string]string{"City": "Christchurch"}
+			mockAddressDataService.
+				EXPECT().
+				ReadAt(ctx, tenantID, applicationID, addressID, timestamp).
+				Return(contract.Address{AddressDetails: addressDetails, Version: 2}, nil)
+
+			address, err := addressService.ReadAt(ctx, tenantID, applicationID, addressID, timestamp)
+
+			Expect(err).To(BeNil())
+			Expect(address).To(Equal(domain.Address{AddressDetails: addressDetails, Version: 2}))
+		})
+	})
+
+	Context("when address data service fails to return the address at the provided time", func() {
+		It("should return the error returned by address data service", func() {
+			expectedErrorID, _ := system.RandomUUID()
+			expectedError := errors.New(expectedErrorID.String())
+			mockAddressDataService.
+				EXPECT().
+				ReadAt(ctx, tenantID, applicationID, addressID, timestamp).
+				Return(contract.Address{}, expectedError)
+
+			_, err := addressService.ReadAt(ctx, tenantID, applicationID, addressID, timestamp)
+
+			Expect(err).To(Equal(expectedError))
+		})
+	})
+})
+
+func TestHistory(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "History and ReadAt methods input parameters and dependency test")
+	RunSpecs(t, "History and ReadAt methods behaviour")
+}
@@ -0,0 +1,145 @@
+package service_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	businessContract "github.com/micro-business/AddressService/business/contract"
+	"github.com/micro-business/AddressService/business/domain"
+	"github.com/micro-business/AddressService/business/service"
+	"github.com/micro-business/AddressService/data/contract"
+	"github.com/micro-business/Micro-Business-Core/system"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("List method input parameters and dependency test", func() {
+	var (
+		ctx                    context.Context
+		mockCtrl               *gomock.Controller
+		addressService         *service.AddressService
+		mockAddressDataService *MockAddressDataService
+		tenantID               system.UUID
+		applicationID          system.UUID
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockAddressDataService = NewMockAddressDataService(mockCtrl)
+
+		addressService = &service.AddressService{AddressDataService: mockAddressDataService}
+
+		tenantID, _ = system.RandomUUID()
+		applicationID, _ = system.RandomUUID()
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	Context("when address data service not provided", func() {
+		It("should panic", func() {
+			addressService.AddressDataService = nil
+
+			Ω(func() { addressService.List(ctx, tenantID, applicationID, 10, "") }).Should(Panic())
+		})
+	})
+
+	Describe("Input Parameters", func() {
+		It("should return InvalidArgumentError when empty tenant unique identifier provided", func() {
+			_, err := addressService.List(ctx, system.EmptyUUID, applicationID, 10, "")
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+		})
+
+		It("should return InvalidArgumentError when empty application unique identifier provided", func() {
+			_, err := addressService.List(ctx, tenantID, system.EmptyUUID, 10, "")
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+		})
+
+		It("should return InvalidArgumentError when page size is not greater than zero", func() {
+			_, err := addressService.List(ctx, tenantID, applicationID, 0, "")
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+
+			_, err = addressService.List(ctx, tenantID, applicationID, -1, "")
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+		})
+	})
+})
+
+var _ = Describe("List method behaviour", func() {
+	var (
+		ctx                    context.Context
+		mockCtrl               *gomock.Controller
+		addressService         *service.AddressService
+		mockAddressDataService *MockAddressDataService
+		tenantID               system.UUID
+		applicationID          system.UUID
+		cursor                 string
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockAddressDataService = NewMockAddressDataService(mockCtrl)
+
+		addressService = &service.AddressService{AddressDataService: mockAddressDataService}
+
+		tenantID, _ = system.RandomUUID()
+		applicationID, _ = system.RandomUUID()
+		cursor = "cursor"
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	Context("when address data service returns a page of addresses", func() {
+		It("should return the page as address page domain object", func() {
+			addressID, _ := system.RandomUUID()
+			addressDetails := map[string]string{"City": "Christchurch"}
+			mockAddressDataService.
+				EXPECT().
+				List(ctx, tenantID, applicationID, 10, cursor).
+				Return(contract.AddressPage{
+					Addresses:  []contract.ListedAddress{{AddressID: addressID, Address: contract.Address{AddressDetails: addressDetails, Version: 2}}},
+					NextCursor: "next-cursor",
+				}, nil)
+
+			page, err := addressService.List(ctx, tenantID, applicationID, 10, cursor)
+
+			Expect(err).To(BeNil())
+			Expect(page).To(Equal(domain.AddressPage{
+				Addresses:  []domain.ListedAddress{{AddressID: addressID, Address: domain.Address{AddressDetails: addressDetails, Version: 2}}},
+				NextCursor: "next-cursor",
+			}))
+		})
+	})
+
+	Context("when address data service fails to return a page of addresses", func() {
+		It("should return the error returned by address data service", func() {
+			expectedErrorID, _ := system.RandomUUID()
+			expectedError := errors.New(expectedErrorID.String())
+			mockAddressDataService.
+				EXPECT().
+				List(ctx, tenantID, applicationID, 10, cursor).
+				Return(contract.AddressPage{}, expectedError)
+
+			_, err := addressService.List(ctx, tenantID, applicationID, 10, cursor)
+
+			Expect(err).To(Equal(expectedError))
+		})
+	})
+})
+
+func TestList(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "List method input parameters and dependency test")
+	RunSpecs(t, "List method behaviour")
+}
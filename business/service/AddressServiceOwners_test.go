@@ -0,0 +1,237 @@
+package service_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	businessContract "github.com/micro-business/AddressService/business/contract"
+	"github.com/micro-business/AddressService/business/domain"
+	"github.com/micro-business/AddressService/business/service"
+	"github.com/micro-business/AddressService/data/contract"
+	"github.com/micro-business/Micro-Business-Core/system"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("Owners methods input parameters and dependency test", func() {
+	var (
+		ctx                    context.Context
+		mockCtrl               *gomock.Controller
+		addressService         *service.AddressService
+		mockAddressDataService *MockAddressDataService
+		tenantID               system.UUID
+		applicationID          system.UUID
+		addressID              system.UUID
+		validOwner             domain.AddressOwner
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockAddressDataService = NewMockAddressDataService(mockCtrl)
+
+		addressService = &service.AddressService{AddressDataService: mockAddressDataService}
+
+		tenantID, _ = system.RandomUUID()
+		applicationID, _ = system.RandomUUID()
+		addressID, _ = system.RandomUUID()
+		validOwner = domain.AddressOwner{Type: "customer", ID: "123"}
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	Context("when address data service not provided", func() {
+		It("should panic", func() {
+			addressService.AddressDataService = nil
+
+			Ω(func() {
+				addressService.AddOwners(ctx, tenantID, applicationID, addressID, []domain.AddressOwner{validOwner})
+			}).Should(Panic())
+			Ω(func() {
+				addressService.RemoveOwners(ctx, tenantID, applicationID, addressID, []domain.AddressOwner{validOwner})
+			}).Should(Panic())
+			Ω(func() { addressService.ReadOwners(ctx, tenantID, applicationID, addressID) }).Should(Panic())
+			Ω(func() { addressService.FindByOwner(ctx, tenantID, applicationID, validOwner) }).Should(Panic())
+		})
+	})
+
+	Describe("Input Parameters", func() {
+		It("should return InvalidArgumentError when empty tenant unique identifier provided", func() {
+			err := addressService.AddOwners(ctx, system.EmptyUUID, applicationID, addressID, []domain.AddressOwner{validOwner})
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+
+			_, err = addressService.FindByOwner(ctx, system.EmptyUUID, applicationID, validOwner)
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+		})
+
+		It("should return InvalidArgumentError when empty application unique identifier provided", func() {
+			err := addressService.RemoveOwners(ctx, tenantID, system.EmptyUUID, addressID, []domain.AddressOwner{validOwner})
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+
+			_, err = addressService.ReadOwners(ctx, tenantID, system.EmptyUUID, addressID)
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+		})
+
+		It("should return InvalidArgumentError when empty address unique identifier provided", func() {
+			err := addressService.AddOwners(ctx, tenantID, applicationID, system.EmptyUUID, []domain.AddressOwner{validOwner})
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+
+			_, err = addressService.ReadOwners(ctx, tenantID, applicationID, system.EmptyUUID)
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+		})
+
+		It("should return InvalidArgumentError when no owner provided", func() {
+			err := addressService.AddOwners(ctx, tenantID, applicationID, addressID, []domain.AddressOwner{})
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+
+			err = addressService.RemoveOwners(ctx, tenantID, applicationID, addressID, nil)
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+		})
+
+		It("should return InvalidArgumentError when owner type is empty or contains invalid characters", func() {
+			err := addressService.AddOwners(ctx, tenantID, applicationID, addressID, []domain.AddressOwner{validOwner, {Type: "", ID: "123"}})
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+
+			_, err = addressService.FindByOwner(ctx, tenantID, applicationID, domain.AddressOwner{Type: "customer/account", ID: "123"})
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+		})
+
+		It("should return InvalidArgumentError when owner ID is empty or contains whitespace only", func() {
+			err := addressService.AddOwners(ctx, tenantID, applicationID, addressID, []domain.AddressOwner{{Type: "customer", ID: " "}})
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+
+			_, err = addressService.FindByOwner(ctx, tenantID, applicationID, domain.AddressOwner{Type: "customer"})
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+		})
+	})
+})
+
+var _ = Describe("Owners methods behaviour", func() {
+	var (
+		ctx                    context.Context
+		mockCtrl               *gomock.Controller
+		addressService         *service.AddressService
+		mockAddressDataService *MockAddressDataService
+		tenantID               system.UUID
+		applicationID          system.UUID
+		addressID              system.UUID
+		owners                 []domain.AddressOwner
+		dataOwners             []contract.AddressOwner
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockAddressDataService = NewMockAddressDataService(mockCtrl)
+
+		addressService = &service.AddressService{AddressDataService: mockAddressDataService}
+
+		tenantID, _ = system.RandomUUID()
+		applicationID, _ = system.RandomUUID()
+		addressID, _ = system.RandomUUID()
+		owners = []domain.AddressOwner{{Type: "customer", ID: "123"}, {Type: "supplier", ID: "S-42"}}
+		dataOwners = []contract.AddressOwner{{Type: "customer", ID: "123"}, {Type: "supplier", ID: "S-42"}}
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	Context("when linking an address to owners", func() {
+		It("should call address data service AddOwners with the same owners", func() {
+			mockAddressDataService.
+				EXPECT().
+				AddOwners(ctx, tenantID, applicationID, addressID, dataOwners).
+				Return(nil)
+
+			Expect(addressService.AddOwners(ctx, tenantID, applicationID, addressID, owners)).To(BeNil())
+		})
+
+		It("should return NotFoundError when address data service cannot find the address", func() {
+			mockAddressDataService.
+				EXPECT().
+				AddOwners(ctx, tenantID, applicationID, addressID, dataOwners).
+				Return(contract.NotFoundError{AddressID: addressID})
+
+			err := addressService.AddOwners(ctx, tenantID, applicationID, addressID, owners)
+
+			Expect(err).To(Equal(businessContract.NotFoundError{AddressID: addressID}))
+		})
+	})
+
+	Context("when unlinking an address from owners", func() {
+		It("should call address data service RemoveOwners with the same owners", func() {
+			mockAddressDataService.
+				EXPECT().
+				RemoveOwners(ctx, tenantID, applicationID, addressID, dataOwners).
+				Return(nil)
+
+			Expect(addressService.RemoveOwners(ctx, tenantID, applicationID, addressID, owners)).To(BeNil())
+		})
+	})
+
+	Context("when reading the owners of an address", func() {
+		It("should return the owners returned by address data service", func() {
+			mockAddressDataService.
+				EXPECT().
+				ReadOwners(ctx, tenantID, applicationID, addressID).
+				Return(dataOwners, nil)
+
+			returnedOwners, err := addressService.ReadOwners(ctx, tenantID, applicationID, addressID)
+
+			Expect(err).To(BeNil())
+			Expect(returnedOwners).To(Equal(owners))
+		})
+
+		It("should return the error returned by address data service", func() {
+			expectedErrorID, _ := system.RandomUUID()
+			expectedError := errors.New(expectedErrorID.String())
+			mockAddressDataService.
+				EXPECT().
+				ReadOwners(ctx, tenantID, applicationID, addressID).
+				Return(nil, expectedError)
+
+			_, err := addressService.ReadOwners(ctx, tenantID, applicationID, addressID)
+
+			Expect(err).To(Equal(expectedError))
+		})
+	})
+
+	Context("when finding the addresses of an owner", func() {
+		It("should return the addresses as listed address domain objects", func() {
+			addressDetails := map[string]string{"City": "Wellington"}
+			mockAddressDataService.
+				EXPECT().
+				FindByOwner(ctx, tenantID, applicationID, dataOwners[0]).
+				Return([]contract.ListedAddress{{AddressID: addressID, Address: contract.Address{AddressDetails: addressDetails, Version: 2}}}, nil)
+
+			listedAddresses, err := addressService.FindByOwner(ctx, tenantID, applicationID, owners[0])
+
+			Expect(err).To(BeNil())
+			Expect(listedAddresses).To(Equal([]domain.ListedAddress{{AddressID: addressID, Address: domain.Address{AddressDetails: addressDetails, Version: 2}}}))
+		})
+
+		It("should return the error returned by address data service", func() {
+			expectedErrorID, _ := system.RandomUUID()
+			expectedError := errors.New(expectedErrorID.String())
+			mockAddressDataService.
+				EXPECT().
+				FindByOwner(ctx, tenantID, applicationID, dataOwners[0]).
+				Return(nil, expectedError)
+
+			_, err := addressService.FindByOwner(ctx, tenantID, applicationID, owners[0])
+
+			Expect(err).To(Equal(expectedError))
+		})
+	})
+})
+
+func TestOwners(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Owners methods input parameters and dependency test")
+	RunSpecs(t, "Owners methods behaviour")
+}
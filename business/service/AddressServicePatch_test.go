@@ -0,0 +1,210 @@
+package service_test
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	businessContract "github.com/micro-business/AddressService/business/contract"
+	"github.com/micro-business/AddressService/business/service"
+	"github.com/micro-business/AddressService/data/contract"
+	"github.com/micro-business/Micro-Business-Core/system"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("Patch method input parameters and dependency test", func() {
+	var (
+		ctx                    context.Context
+		mockCtrl               *gomock.Controller
+		addressService         *service.AddressService
+		mockAddressDataService *MockAddressDataService
+		tenantID               system.UUID
+		applicationID          system.UUID
+		addressID              system.UUID
+		validSet               map[string]string
+		validRemove            []string
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockAddressDataService = NewMockAddressDataService(mockCtrl)
+
+		addressService = &service.AddressService{AddressDataService: mockAddressDataService}
+
+		tenantID, _ = system.RandomUUID()
+		applicationID, _ = system.RandomUUID()
+		addressID, _ = system.RandomUUID()
+		validSet = map[string]string{"Postcode": "8011"}
+		validRemove = []string{"Line2"}
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	Context("when address data service not provided", func() {
+		It("should panic", func() {
+			addressService.AddressDataService = nil
+
+			Ω(func() {
+				addressService.Patch(ctx, tenantID, applicationID, addressID, validSet, validRemove, contract.AnyVersion, "")
+			}).Should(Panic())
+		})
+	})
+
+	Describe("Input Parameters", func() {
+		It("should return InvalidArgumentError when empty tenant unique identifier provided", func() {
+			err := addressService.Patch(ctx, system.EmptyUUID, applicationID, addressID, validSet, validRemove, contract.AnyVersion, "")
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+		})
+
+		It("should return InvalidArgumentError when empty application unique identifier provided", func() {
+			err := addressService.Patch(ctx, tenantID, system.EmptyUUID, addressID, validSet, validRemove, contract.AnyVersion, "")
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+		})
+
+		It("should return InvalidArgumentError when empty address unique identifier provided", func() {
+			err := addressService.Patch(ctx, tenantID, applicationID, system.EmptyUUID, validSet, validRemove, contract.AnyVersion, "")
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+		})
+
+		It("should return InvalidArgumentError when negative expected version provided", func() {
+			err := addressService.Patch(ctx, tenantID, applicationID, addressID, validSet, validRemove, -2, "")
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+		})
+
+		It("should return InvalidArgumentError when no key to set or remove provided", func() {
+			err := addressService.Patch(ctx, tenantID, applicationID, addressID, nil, nil, contract.AnyVersion, "")
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+		})
+
+		It("should return InvalidArgumentError when key to set contains not allowed characters provided", func() {
+			err := addressService.Patch(ctx, tenantID, applicationID, addressID, map[string]string{"City') OR address_key = ('": "Christchurch"}, nil, contract.AnyVersion, "")
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+		})
+
+		It("should return InvalidArgumentError when value to set contains whitespace only provided", func() {
+			err := addressService.Patch(ctx, tenantID, applicationID, addressID, map[string]string{"City": "    "}, nil, contract.AnyVersion, "")
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+		})
+
+		It("should return InvalidArgumentError when value to set looks like an encrypted value", func() {
+			err := addressService.Patch(ctx, tenantID, applicationID, addressID, map[string]string{"City": "enc:1:AAAA"}, nil, contract.AnyVersion, "")
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+		})
+
+		It("should return InvalidArgumentError when empty key to remove provided", func() {
+			err := addressService.Patch(ctx, tenantID, applicationID, addressID, nil, []string{""}, contract.AnyVersion, "")
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+		})
+
+		It("should return InvalidArgumentError when the same key provided to both set and remove", func() {
+			err := addressService.Patch(ctx, tenantID, applicationID, addressID, validSet, []string{"Postcode"}, contract.AnyVersion, "")
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+		})
+	})
+})
+
+var _ = Describe("Patch method behaviour", func() {
+	var (
+		ctx                    context.Context
+		mockCtrl               *gomock.Controller
+		addressService         *service.AddressService
+		mockAddressDataService *MockAddressDataService
+		tenantID               system.UUID
+		applicationID          system.UUID
+		addressID              system.UUID
+		expectedVersion        int64
+		validSet               map[string]string
+		validRemove            []string
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockAddressDataService = NewMockAddressDataService(mockCtrl)
+
+		addressService = &service.AddressService{AddressDataService: mockAddressDataService}
+
+		tenantID, _ = system.RandomUUID()
+		applicationID, _ = system.RandomUUID()
+		addressID, _ = system.RandomUUID()
+		expectedVersion = int64(rand.Intn(100) + 1)
+		validSet = map[string]string{"Postcode": "8011"}
+		validRemove = []string{"Line2"}
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	It("should call address data service Patch function", func() {
+		changedBy := "support-agent"
+
+		mockAddressDataService.EXPECT().Patch(ctx, tenantID, applicationID, addressID, validSet, validRemove, expectedVersion, changedBy)
+
+		addressService.Patch(ctx, tenantID, applicationID, addressID, validSet, validRemove, expectedVersion, changedBy)
+	})
+
+	Context("when address data service succeeds to patch the requested address", func() {
+		It("should return no error", func() {
+			mockAddressDataService.
+				EXPECT().
+				Patch(ctx, tenantID, applicationID, addressID, validSet, validRemove, expectedVersion, "").
+				Return(nil)
+
+			err := addressService.Patch(ctx, tenantID, applicationID, addressID, validSet, validRemove, expectedVersion, "")
+
+			Expect(err).To(BeNil())
+		})
+	})
+
+	Context("when address data service fails to patch the requested address", func() {
+		It("should return the error returned by address data service", func() {
+			expectedErrorID, _ := system.RandomUUID()
+			expectedError := errors.New(expectedErrorID.String())
+			mockAddressDataService.
+				EXPECT().
+				Patch(ctx, tenantID, applicationID, addressID, validSet, validRemove, expectedVersion, "").
+				Return(expectedError)
+
+			err := addressService.Patch(ctx, tenantID, applicationID, addressID, validSet, validRemove, expectedVersion, "")
+
+			Expect(err).To(Equal(expectedError))
+		})
+	})
+
+	Context("when address data service returns version conflict error", func() {
+		It("should return the conflict error as business layer conflict error", func() {
+			actualVersion := expectedVersion + 1
+			mockAddressDataService.
+				EXPECT().
+				Patch(ctx, tenantID, applicationID, addressID, validSet, validRemove, expectedVersion, "").
+				Return(contract.ConflictError{AddressID: addressID, ExpectedVersion: expectedVersion, ActualVersion: actualVersion})
+
+			err := addressService.Patch(ctx, tenantID, applicationID, addressID, validSet, validRemove, expectedVersion, "")
+
+			Expect(err).To(Equal(businessContract.ConflictError{AddressID: addressID, ExpectedVersion: expectedVersion, ActualVersion: actualVersion}))
+		})
+	})
+})
+
+func TestPatch(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Patch method input parameters and dependency test")
+	RunSpecs(t, "Patch method behaviour")
+}
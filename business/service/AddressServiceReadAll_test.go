@@ -4,18 +4,22 @@ import (
 	"errors"
 	"math/rand"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
+	businessContract "github.com/micro-business/AddressService/business/contract"
 	"github.com/micro-business/AddressService/business/domain"
 	"github.com/micro-business/AddressService/business/service"
 	"github.com/micro-business/AddressService/data/contract"
 	"github.com/micro-business/Micro-Business-Core/system"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
 )
 
 var _ = Describe("ReadAll method input parameters and dependency test", func() {
 	var (
+		ctx                    context.Context
 		mockCtrl               *gomock.Controller
 		addressService         *service.AddressService
 		mockAddressDataService *MockAddressDataService
@@ -25,6 +29,7 @@ var _ = Describe("ReadAll method input parameters and dependency test", func() {
 	)
 
 	BeforeEach(func() {
+		ctx = context.Background()
 		mockCtrl = gomock.NewController(GinkgoT())
 		mockAddressDataService = NewMockAddressDataService(mockCtrl)
 
@@ -43,27 +48,34 @@ var _ = Describe("ReadAll method input parameters and dependency test", func() {
 		It("should panic", func() {
 			addressService.AddressDataService = nil
 
-			Ω(func() { addressService.ReadAll(tenantID, applicationID, addressID) }).Should(Panic())
+			Ω(func() { addressService.ReadAll(ctx, tenantID, applicationID, addressID) }).Should(Panic())
 		})
 	})
 
 	Describe("Input Parameters", func() {
-		It("should panic when empty tenant unique identifier provided", func() {
-			Ω(func() { addressService.ReadAll(system.EmptyUUID, applicationID, addressID) }).Should(Panic())
+		It("should return InvalidArgumentError when empty tenant unique identifier provided", func() {
+			_, err := addressService.ReadAll(ctx, system.EmptyUUID, applicationID, addressID)
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
 		})
 
-		It("should panic when empty application unique identifier provided", func() {
-			Ω(func() { addressService.ReadAll(tenantID, system.EmptyUUID, addressID) }).Should(Panic())
+		It("should return InvalidArgumentError when empty application unique identifier provided", func() {
+			_, err := addressService.ReadAll(ctx, tenantID, system.EmptyUUID, addressID)
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
 		})
 
-		It("should panic when empty address unique identifier provided", func() {
-			Ω(func() { addressService.ReadAll(tenantID, applicationID, system.EmptyUUID) }).Should(Panic())
+		It("should return InvalidArgumentError when empty address unique identifier provided", func() {
+			_, err := addressService.ReadAll(ctx, tenantID, applicationID, system.EmptyUUID)
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
 		})
 	})
 })
 
 var _ = Describe("ReadAll method behaviour", func() {
 	var (
+		ctx                    context.Context
 		mockCtrl               *gomock.Controller
 		addressService         *service.AddressService
 		mockAddressDataService *MockAddressDataService
@@ -73,6 +85,7 @@ var _ = Describe("ReadAll method behaviour", func() {
 	)
 
 	BeforeEach(func() {
+		ctx = context.Background()
 		mockCtrl = gomock.NewController(GinkgoT())
 		mockAddressDataService = NewMockAddressDataService(mockCtrl)
 
@@ -88,9 +101,9 @@ var _ = Describe("ReadAll method behaviour", func() {
 	})
 
 	It("should call address data service ReadAll function", func() {
-		mockAddressDataService.EXPECT().ReadAll(tenantID, applicationID, addressID)
+		mockAddressDataService.EXPECT().ReadAll(ctx, tenantID, applicationID, addressID)
 
-		addressService.ReadAll(tenantID, applicationID, addressID)
+		addressService.ReadAll(ctx, tenantID, applicationID, addressID)
 	})
 
 	Context("when address data service succeeds to read the requested address", func() {
@@ -107,31 +120,68 @@ var _ = Describe("ReadAll method behaviour", func() {
 			expectedAddress := domain.Address{AddressDetails: addressDetails}
 			mockAddressDataService.
 				EXPECT().
-				ReadAll(tenantID, applicationID, addressID).
+				ReadAll(ctx, tenantID, applicationID, addressID).
 				Return(contract.Address{AddressDetails: expectedAddress.AddressDetails}, nil)
 
-			address, err := addressService.ReadAll(tenantID, applicationID, addressID)
+			address, err := addressService.ReadAll(ctx, tenantID, applicationID, addressID)
 
 			Expect(address).To(Equal(expectedAddress))
 			Expect(err).To(BeNil())
 		})
 	})
 
+	Context("when address data service returns an address that expires", func() {
+		It("should return the remaining TTL of the address", func() {
+			addressDetails := map[string]string{"City": "Christchurch"}
+			mockAddressDataService.
+				EXPECT().
+				ReadAll(ctx, tenantID, applicationID, addressID).
+				Return(contract.Address{AddressDetails: addressDetails, Version: 1, TTL: time.Minute}, nil)
+
+			address, err := addressService.ReadAll(ctx, tenantID, applicationID, addressID)
+
+			Expect(address).To(Equal(domain.Address{AddressDetails: addressDetails, Version: 1, TTL: time.Minute}))
+			Expect(err).To(BeNil())
+		})
+	})
+
 	Context("when address data service fails to read the requested address", func() {
 		It("should return the error returned by address data service", func() {
 			expectedErrorID, _ := system.RandomUUID()
 			expectedError := errors.New(expectedErrorID.String())
 			mockAddressDataService.
 				EXPECT().
-				ReadAll(tenantID, applicationID, addressID).
+				ReadAll(ctx, tenantID, applicationID, addressID).
 				Return(contract.Address{}, expectedError)
 
-			expectedAddress, err := addressService.ReadAll(tenantID, applicationID, addressID)
+			expectedAddress, err := addressService.ReadAll(ctx, tenantID, applicationID, addressID)
 
 			Expect(expectedAddress).To(Equal(domain.Address{}))
 			Expect(err).To(Equal(expectedError))
 		})
 	})
+
+	Context("when address data service returns a typed error", func() {
+		It("should return the business layer equivalent of the error", func() {
+			storageError := errors.New("no hosts available")
+			mockAddressDataService.
+				EXPECT().
+				ReadAll(ctx, tenantID, applicationID, addressID).
+				Return(contract.Address{}, contract.NotFoundError{AddressID: addressID})
+			mockAddressDataService.
+				EXPECT().
+				ReadAll(ctx, tenantID, applicationID, addressID).
+				Return(contract.Address{}, contract.UnavailableError{Err: storageError})
+
+			_, err := addressService.ReadAll(ctx, tenantID, applicationID, addressID)
+
+			Expect(err).To(Equal(businessContract.NotFoundError{AddressID: addressID}))
+
+			_, err = addressService.ReadAll(ctx, tenantID, applicationID, addressID)
+
+			Expect(err).To(Equal(businessContract.UnavailableError{Err: storageError}))
+		})
+	})
 })
 
 func TestReadAll(t *testing.T) {
@@ -0,0 +1,151 @@
+package service_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	businessContract "github.com/micro-business/AddressService/business/contract"
+	"github.com/micro-business/AddressService/business/domain"
+	"github.com/micro-business/AddressService/business/service"
+	"github.com/micro-business/AddressService/data/contract"
+	"github.com/micro-business/Micro-Business-Core/system"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("ReadMany method input parameters and dependency test", func() {
+	var (
+		ctx                    context.Context
+		mockCtrl               *gomock.Controller
+		addressService         *service.AddressService
+		mockAddressDataService *MockAddressDataService
+		tenantID               system.UUID
+		applicationID          system.UUID
+		addressIDs             []system.UUID
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockAddressDataService = NewMockAddressDataService(mockCtrl)
+
+		addressService = &service.AddressService{AddressDataService: mockAddressDataService}
+
+		tenantID, _ = system.RandomUUID()
+		applicationID, _ = system.RandomUUID()
+		addressID, _ := system.RandomUUID()
+		addressIDs = []system.UUID{addressID}
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	Context("when address data service not provided", func() {
+		It("should panic", func() {
+			addressService.AddressDataService = nil
+
+			Ω(func() { addressService.ReadMany(ctx, tenantID, applicationID, addressIDs, nil) }).Should(Panic())
+		})
+	})
+
+	Describe("Input Parameters", func() {
+		It("should return InvalidArgumentError when empty tenant unique identifier provided", func() {
+			_, err := addressService.ReadMany(ctx, system.EmptyUUID, applicationID, addressIDs, nil)
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+		})
+
+		It("should return InvalidArgumentError when empty application unique identifier provided", func() {
+			_, err := addressService.ReadMany(ctx, tenantID, system.EmptyUUID, addressIDs, nil)
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+		})
+
+		It("should return InvalidArgumentError when no address unique identifier provided", func() {
+			_, err := addressService.ReadMany(ctx, tenantID, applicationID, []system.UUID{}, nil)
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+		})
+
+		It("should return InvalidArgumentError when more than the maximum batch size of address unique identifiers provided", func() {
+			_, err := addressService.ReadMany(ctx, tenantID, applicationID, make([]system.UUID, service.MaxBatchSize+1), nil)
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+			Expect(err.Error()).To(ContainSubstring("address unique identifiers"))
+		})
+
+		It("should return InvalidArgumentError as the result of the empty address unique identifier", func() {
+			results, err := addressService.ReadMany(ctx, tenantID, applicationID, []system.UUID{system.EmptyUUID}, nil)
+
+			Expect(err).To(BeNil())
+			Expect(results).To(HaveLen(1))
+			Expect(results[0].Err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+		})
+
+		It("should return InvalidArgumentError when invalid key provided", func() {
+			_, err := addressService.ReadMany(ctx, tenantID, applicationID, addressIDs, []string{"Post code"})
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+		})
+	})
+})
+
+var _ = Describe("ReadMany method behaviour", func() {
+	var (
+		ctx                    context.Context
+		mockCtrl               *gomock.Controller
+		addressService         *service.AddressService
+		mockAddressDataService *MockAddressDataService
+		tenantID               system.UUID
+		applicationID          system.UUID
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockAddressDataService = NewMockAddressDataService(mockCtrl)
+
+		addressService = &service.AddressService{AddressDataService: mockAddressDataService}
+
+		tenantID, _ = system.RandomUUID()
+		applicationID, _ = system.RandomUUID()
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	Context("when address data service reads some of the addresses", func() {
+		It("should return the result of reading every address", func() {
+			existingAddressID, _ := system.RandomUUID()
+			missingAddressID, _ := system.RandomUUID()
+			expectedError := errors.New("address not found")
+			addressDetails := map[string]string{"City": "Christchurch"}
+			keys := []string{"City"}
+			mockAddressDataService.
+				EXPECT().
+				ReadMany(ctx, tenantID, applicationID, []system.UUID{existingAddressID, missingAddressID}, keys).
+				Return([]contract.AddressResult{
+					{AddressID: existingAddressID, Address: contract.Address{AddressDetails: addressDetails, Version: 2}},
+					{AddressID: missingAddressID, Err: expectedError},
+				})
+
+			results, err := addressService.ReadMany(ctx, tenantID, applicationID, []system.UUID{existingAddressID, missingAddressID}, keys)
+
+			Expect(err).To(BeNil())
+			Expect(results).To(Equal([]domain.AddressResult{
+				{AddressID: existingAddressID, Address: domain.Address{AddressDetails: addressDetails, Version: 2}},
+				{AddressID: missingAddressID, Err: expectedError},
+			}))
+		})
+	})
+})
+
+func TestReadMany(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "ReadMany method input parameters and dependency test")
+	RunSpecs(t, "ReadMany method behaviour")
+}
@@ -6,16 +6,19 @@ import (
 	"testing"
 
 	"github.com/golang/mock/gomock"
+	businessContract "github.com/micro-business/AddressService/business/contract"
 	"github.com/micro-business/AddressService/business/domain"
 	"github.com/micro-business/AddressService/business/service"
 	"github.com/micro-business/AddressService/data/contract"
 	"github.com/micro-business/Micro-Business-Core/system"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
 )
 
 var _ = Describe("Read method input parameters and dependency test", func() {
 	var (
+		ctx                     context.Context
 		mockCtrl                *gomock.Controller
 		addressService          *service.AddressService
 		mockAddressDataService  *MockAddressDataService
@@ -26,9 +29,11 @@ var _ = Describe("Read method input parameters and dependency test", func() {
 		emptyKeys               []string
 		keysWithEmptyValue      []string
 		keysWithWhitespaceValue []string
+		keysWithHostileValue    []string
 	)
 
 	BeforeEach(func() {
+		ctx = context.Background()
 		mockCtrl = gomock.NewController(GinkgoT())
 		mockAddressDataService = NewMockAddressDataService(mockCtrl)
 
@@ -49,6 +54,8 @@ var _ = Describe("Read method input parameters and dependency test", func() {
 
 		keysWithWhitespaceValue = make([]string, 1)
 		keysWithWhitespaceValue[0] = "    "
+
+		keysWithHostileValue = []string{"City", "City') OR address_key IN ('Line1"}
 	})
 
 	AfterEach(func() {
@@ -59,33 +66,51 @@ var _ = Describe("Read method input parameters and dependency test", func() {
 		It("should panic", func() {
 			addressService.AddressDataService = nil
 
-			Ω(func() { addressService.Read(tenantID, applicationID, addressID, validKeys) }).Should(Panic())
+			Ω(func() { addressService.Read(ctx, tenantID, applicationID, addressID, validKeys) }).Should(Panic())
 		})
 	})
 
 	Describe("Input Parameters", func() {
-		It("should panic when empty tenant unique identifier provided", func() {
-			Ω(func() { addressService.Read(system.EmptyUUID, applicationID, addressID, validKeys) }).Should(Panic())
+		It("should return InvalidArgumentError when empty tenant unique identifier provided", func() {
+			_, err := addressService.Read(ctx, system.EmptyUUID, applicationID, addressID, validKeys)
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+		})
+
+		It("should return InvalidArgumentError when empty application unique identifier provided", func() {
+			_, err := addressService.Read(ctx, tenantID, system.EmptyUUID, addressID, validKeys)
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
 		})
 
-		It("should panic when empty application unique identifier provided", func() {
-			Ω(func() { addressService.Read(tenantID, system.EmptyUUID, addressID, validKeys) }).Should(Panic())
+		It("should return InvalidArgumentError when empty address unique identifier provided", func() {
+			_, err := addressService.Read(ctx, tenantID, applicationID, system.EmptyUUID, validKeys)
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
 		})
 
-		It("should panic when empty address unique identifier provided", func() {
-			Ω(func() { addressService.Read(tenantID, applicationID, system.EmptyUUID, validKeys) }).Should(Panic())
+		It("should return InvalidArgumentError when empty keys provided", func() {
+			_, err := addressService.Read(ctx, tenantID, applicationID, addressID, emptyKeys)
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
 		})
 
-		It("should panic when empty keys provided", func() {
-			Ω(func() { addressService.Read(tenantID, applicationID, addressID, emptyKeys) }).Should(Panic())
+		It("should return InvalidArgumentError when keys with empty value provided", func() {
+			_, err := addressService.Read(ctx, tenantID, applicationID, addressID, keysWithEmptyValue)
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
 		})
 
-		It("should panic when keys with empty value provided", func() {
-			Ω(func() { addressService.Read(tenantID, applicationID, addressID, keysWithEmptyValue) }).Should(Panic())
+		It("should return InvalidArgumentError when keys with whitespace only value provided", func() {
+			_, err := addressService.Read(ctx, tenantID, applicationID, addressID, keysWithWhitespaceValue)
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
 		})
 
-		It("should panic when keys with whitespace only value provided", func() {
-			Ω(func() { addressService.Read(tenantID, applicationID, addressID, keysWithWhitespaceValue) }).Should(Panic())
+		It("should return InvalidArgumentError when keys with not allowed characters provided", func() {
+			_, err := addressService.Read(ctx, tenantID, applicationID, addressID, keysWithHostileValue)
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
 		})
 
 	})
@@ -93,6 +118,7 @@ var _ = Describe("Read method input parameters and dependency test", func() {
 
 var _ = Describe("Read method behaviour", func() {
 	var (
+		ctx                    context.Context
 		mockCtrl               *gomock.Controller
 		addressService         *service.AddressService
 		mockAddressDataService *MockAddressDataService
@@ -103,6 +129,7 @@ var _ = Describe("Read method behaviour", func() {
 	)
 
 	BeforeEach(func() {
+		ctx = context.Background()
 		mockCtrl = gomock.NewController(GinkgoT())
 		mockAddressDataService = NewMockAddressDataService(mockCtrl)
 
@@ -122,9 +149,9 @@ var _ = Describe("Read method behaviour", func() {
 	})
 
 	It("should call address data service Read function", func() {
-		mockAddressDataService.EXPECT().Read(tenantID, applicationID, addressID, validKeys)
+		mockAddressDataService.EXPECT().Read(ctx, tenantID, applicationID, addressID, validKeys)
 
-		addressService.Read(tenantID, applicationID, addressID, validKeys)
+		addressService.Read(ctx, tenantID, applicationID, addressID, validKeys)
 	})
 
 	Context("when address data service succeeds to read the requested address", func() {
@@ -147,10 +174,10 @@ var _ = Describe("Read method behaviour", func() {
 			expectedAddress := domain.Address{AddressDetails: addressDetails}
 			mockAddressDataService.
 				EXPECT().
-				Read(tenantID, applicationID, addressID, keys).
+				Read(ctx, tenantID, applicationID, addressID, keys).
 				Return(contract.Address{AddressDetails: expectedAddress.AddressDetails}, nil)
 
-			address, err := addressService.Read(tenantID, applicationID, addressID, keys)
+			address, err := addressService.Read(ctx, tenantID, applicationID, addressID, keys)
 
 			Expect(address).To(Equal(expectedAddress))
 			Expect(err).To(BeNil())
@@ -163,10 +190,10 @@ var _ = Describe("Read method behaviour", func() {
 			expectedError := errors.New(expectedErrorID.String())
 			mockAddressDataService.
 				EXPECT().
-				Read(tenantID, applicationID, addressID, validKeys).
+				Read(ctx, tenantID, applicationID, addressID, validKeys).
 				Return(contract.Address{}, expectedError)
 
-			expectedAddress, err := addressService.Read(tenantID, applicationID, addressID, validKeys)
+			expectedAddress, err := addressService.Read(ctx, tenantID, applicationID, addressID, validKeys)
 
 			Expect(expectedAddress).To(Equal(domain.Address{}))
 			Expect(err).To(Equal(expectedError))
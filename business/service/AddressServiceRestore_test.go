@@ -0,0 +1,279 @@
+package service_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	businessContract "github.com/micro-business/AddressService/business/contract"
+	"github.com/micro-business/AddressService/business/service"
+	"github.com/micro-business/Micro-Business-Core/system"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("Restore method input parameters and dependency test", func() {
+	var (
+		ctx                    context.Context
+		mockCtrl               *gomock.Controller
+		addressService         *service.AddressService
+		mockAddressDataService *MockAddressDataService
+		tenantID               system.UUID
+		applicationID          system.UUID
+		addressID              system.UUID
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockAddressDataService = NewMockAddressDataService(mockCtrl)
+
+		addressService = &service.AddressService{AddressDataService: mockAddressDataService}
+
+		tenantID, _ = system.RandomUUID()
+		applicationID, _ = system.RandomUUID()
+		addressID, _ = system.RandomUUID()
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	Context("when address data service not provided", func() {
+		It("should panic", func() {
+			addressService.AddressDataService = nil
+
+			Ω(func() { addressService.Restore(ctx, tenantID, applicationID, addressID, "") }).Should(Panic())
+		})
+	})
+
+	Describe("Input Parameters", func() {
+		It("should return InvalidArgumentError when empty tenant unique identifier provided", func() {
+			err := addressService.Restore(ctx, system.EmptyUUID, applicationID, addressID, "")
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+		})
+
+		It("should return InvalidArgumentError when empty application unique identifier provided", func() {
+			err := addressService.Restore(ctx, tenantID, system.EmptyUUID, addressID, "")
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+		})
+
+		It("should return InvalidArgumentError when empty address unique identifier provided", func() {
+			err := addressService.Restore(ctx, tenantID, applicationID, system.EmptyUUID, "")
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+		})
+	})
+})
+
+var _ = Describe("Restore method behaviour", func() {
+	var (
+		ctx                    context.Context
+		mockCtrl               *gomock.Controller
+		addressService         *service.AddressService
+		mockAddressDataService *MockAddressDataService
+		tenantID               system.UUID
+		applicationID          system.UUID
+		addressID              system.UUID
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockAddressDataService = NewMockAddressDataService(mockCtrl)
+
+		addressService = &service.AddressService{AddressDataService: mockAddressDataService, DeletedAddressGracePeriod: time.Hour}
+
+		tenantID, _ = system.RandomUUID()
+		applicationID, _ = system.RandomUUID()
+		addressID, _ = system.RandomUUID()
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	It("should call address data service Restore function with the start of the grace period", func() {
+		var deletedSince time.Time
+
+		changedBy := "support-agent"
+
+		mockAddressDataService.
+			EXPECT().
+			Restore(ctx, tenantID, applicationID, addressID, gomock.Any(), changedBy).
+			Do(func(ctx context.Context, tenantID, applicationID, addressID system.UUID, since time.Time, changedBy string) {
+				deletedSince = since
+			})
+
+		before := time.Now().Add(-time.Hour)
+		addressService.Restore(ctx, tenantID, applicationID, addressID, changedBy)
+		after := time.Now().Add(-time.Hour)
+
+		Expect(deletedSince).To(BeTemporally(">=", before))
+		Expect(deletedSince).To(BeTemporally("<=", after))
+	})
+
+	Context("when address data service fails to restore the requested address", func() {
+		It("should return the error returned by address data service", func() {
+			expectedErrorID, _ := system.RandomUUID()
+			expectedError := errors.New(expectedErrorID.String())
+			mockAddressDataService.
+				EXPECT().
+				Restore(ctx, tenantID, applicationID, addressID, gomock.Any(), "").
+				Return(expectedError)
+
+			err := addressService.Restore(ctx, tenantID, applicationID, addressID, "")
+
+			Expect(err).To(Equal(expectedError))
+		})
+	})
+})
+
+var _ = Describe("Purge method input parameters and dependency test", func() {
+	var (
+		ctx                    context.Context
+		mockCtrl               *gomock.Controller
+		addressService         *service.AddressService
+		mockAddressDataService *MockAddressDataService
+		tenantID               system.UUID
+		applicationID          system.UUID
+		addressID              system.UUID
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockAddressDataService = NewMockAddressDataService(mockCtrl)
+
+		addressService = &service.AddressService{AddressDataService: mockAddressDataService}
+
+		tenantID, _ = system.RandomUUID()
+		applicationID, _ = system.RandomUUID()
+		addressID, _ = system.RandomUUID()
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	Context("when address data service not provided", func() {
+		It("should panic", func() {
+			addressService.AddressDataService = nil
+
+			Ω(func() { addressService.Purge(ctx, tenantID, applicationID, addressID) }).Should(Panic())
+			Ω(func() { addressService.PurgeExpired(ctx) }).Should(Panic())
+		})
+	})
+
+	Describe("Input Parameters", func() {
+		It("should return InvalidArgumentError when empty tenant unique identifier provided", func() {
+			err := addressService.Purge(ctx, system.EmptyUUID, applicationID, addressID)
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+		})
+
+		It("should return InvalidArgumentError when empty application unique identifier provided", func() {
+			err := addressService.Purge(ctx, tenantID, system.EmptyUUID, addressID)
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+		})
+
+		It("should return InvalidArgumentError when empty address unique identifier provided", func() {
+			err := addressService.Purge(ctx, tenantID, applicationID, system.EmptyUUID)
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+		})
+	})
+})
+
+var _ = Describe("Purge method behaviour", func() {
+	var (
+		ctx                    context.Context
+		mockCtrl               *gomock.Controller
+		addressService         *service.AddressService
+		mockAddressDataService *MockAddressDataService
+		tenantID               system.UUID
+		applicationID          system.UUID
+		addressID              system.UUID
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockAddressDataService = NewMockAddressDataService(mockCtrl)
+
+		addressService = &service.AddressService{AddressDataService: mockAddressDataService, DeletedAddressGracePeriod: time.Hour}
+
+		tenantID, _ = system.RandomUUID()
+		applicationID, _ = system.RandomUUID()
+		addressID, _ = system.RandomUUID()
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	Context("when purging deleted address", func() {
+		It("should return the result returned by address data service Purge function", func() {
+			expectedErrorID, _ := system.RandomUUID()
+			expectedError := errors.New(expectedErrorID.String())
+			mockAddressDataService.
+				EXPECT().
+				Purge(ctx, tenantID, applicationID, addressID).
+				Return(expectedError)
+
+			err := addressService.Purge(ctx, tenantID, applicationID, addressID)
+
+			Expect(err).To(Equal(expectedError))
+		})
+	})
+
+	Context("when purging expired addresses", func() {
+		It("should call address data service PurgeDeleted function with the start of the grace period", func() {
+			var deletedBefore time.Time
+
+			mockAddressDataService.
+				EXPECT().
+				PurgeDeleted(ctx, gomock.Any()).
+				Do(func(ctx context.Context, before time.Time) { deletedBefore = before })
+
+			before := time.Now().Add(-time.Hour)
+			err := addressService.PurgeExpired(ctx)
+			after := time.Now().Add(-time.Hour)
+
+			Expect(err).To(BeNil())
+			Expect(deletedBefore).To(BeTemporally(">=", before))
+			Expect(deletedBefore).To(BeTemporally("<=", after))
+		})
+
+		It("should use the default grace period when no grace period is provided", func() {
+			var deletedBefore time.Time
+
+			addressService.DeletedAddressGracePeriod = 0
+			mockAddressDataService.
+				EXPECT().
+				PurgeDeleted(ctx, gomock.Any()).
+				Do(func(ctx context.Context, before time.Time) { deletedBefore = before })
+
+			before := time.Now().Add(-service.DefaultDeletedAddressGracePeriod)
+			err := addressService.PurgeExpired(ctx)
+			after := time.Now().Add(-service.DefaultDeletedAddressGracePeriod)
+
+			Expect(err).To(BeNil())
+			Expect(deletedBefore).To(BeTemporally(">=", before))
+			Expect(deletedBefore).To(BeTemporally("<=", after))
+		})
+	})
+})
+
+func TestRestore(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Restore method input parameters and dependency test")
+	RunSpecs(t, "Restore method behaviour")
+	RunSpecs(t, "Purge method input parameters and dependency test")
+	RunSpecs(t, "Purge method behaviour")
+}
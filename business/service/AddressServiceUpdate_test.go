@@ -2,19 +2,24 @@ package service_test
 
 import (
 	"errors"
+	"math/rand"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
+	businessContract "github.com/micro-business/AddressService/business/contract"
 	"github.com/micro-business/AddressService/business/domain"
 	"github.com/micro-business/AddressService/business/service"
 	"github.com/micro-business/AddressService/data/contract"
 	"github.com/micro-business/Micro-Business-Core/system"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
 )
 
 var _ = Describe("Update method input parameters and dependency test", func() {
 	var (
+		ctx                        context.Context
 		mockCtrl                   *gomock.Controller
 		addressService             *service.AddressService
 		mockAddressDataService     *MockAddressDataService
@@ -25,11 +30,13 @@ var _ = Describe("Update method input parameters and dependency test", func() {
 		emptyAddress               domain.Address
 		addressWithEmptyKey        domain.Address
 		addressWithWhitespaceKey   domain.Address
+		addressWithHostileKey      domain.Address
 		addressWithEmptyValue      domain.Address
 		addressWithWhitespaceValue domain.Address
 	)
 
 	BeforeEach(func() {
+		ctx = context.Background()
 		mockCtrl = gomock.NewController(GinkgoT())
 		mockAddressDataService = NewMockAddressDataService(mockCtrl)
 
@@ -42,6 +49,7 @@ var _ = Describe("Update method input parameters and dependency test", func() {
 		emptyAddress = domain.Address{}
 		addressWithEmptyKey = domain.Address{AddressDetails: map[string]string{"": "Christchurch"}}
 		addressWithWhitespaceKey = domain.Address{AddressDetails: map[string]string{"    ": "Christchurch"}}
+		addressWithHostileKey = domain.Address{AddressDetails: map[string]string{"City') OR address_key = ('": "Christchurch"}}
 		addressWithEmptyValue = domain.Address{AddressDetails: map[string]string{"City": ""}}
 		addressWithWhitespaceValue = domain.Address{AddressDetails: map[string]string{"City": "    "}}
 	})
@@ -54,57 +62,103 @@ var _ = Describe("Update method input parameters and dependency test", func() {
 		It("should panic", func() {
 			addressService.AddressDataService = nil
 
-			Ω(func() { addressService.Update(tenantID, applicationID, addressID, validAddress) }).Should(Panic())
+			Ω(func() {
+				addressService.Update(ctx, tenantID, applicationID, addressID, validAddress, contract.AnyVersion, "")
+			}).Should(Panic())
 		})
 	})
 
 	Describe("Input Parameters", func() {
-		It("should panic when empty tenant unique identifier provided", func() {
-			Ω(func() { addressService.Update(system.EmptyUUID, applicationID, addressID, validAddress) }).Should(Panic())
+		It("should return InvalidArgumentError when empty tenant unique identifier provided", func() {
+			err := addressService.Update(ctx, system.EmptyUUID, applicationID, addressID, validAddress, contract.AnyVersion, "")
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+		})
+
+		It("should return InvalidArgumentError when empty application unique identifier provided", func() {
+			err := addressService.Update(ctx, tenantID, system.EmptyUUID, addressID, validAddress, contract.AnyVersion, "")
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+		})
+
+		It("should return InvalidArgumentError when empty address unique identifier provided", func() {
+			err := addressService.Update(ctx, tenantID, applicationID, system.EmptyUUID, validAddress, contract.AnyVersion, "")
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
+		})
+
+		It("should return InvalidArgumentError when negative expected version provided", func() {
+			err := addressService.Update(ctx, tenantID, applicationID, addressID, validAddress, -2, "")
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
 		})
 
-		It("should panic when empty application unique identifier provided", func() {
-			Ω(func() { addressService.Update(tenantID, system.EmptyUUID, addressID, validAddress) }).Should(Panic())
+		It("should return InvalidArgumentError when address without address key provided", func() {
+			err := addressService.Update(ctx, tenantID, applicationID, addressID, emptyAddress, contract.AnyVersion, "")
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
 		})
 
-		It("should panic when empty address unique identifier provided", func() {
-			Ω(func() { addressService.Update(tenantID, applicationID, system.EmptyUUID, validAddress) }).Should(Panic())
+		It("should return InvalidArgumentError when address with empty key provided", func() {
+			err := addressService.Update(ctx, tenantID, applicationID, addressID, addressWithEmptyKey, contract.AnyVersion, "")
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
 		})
 
-		It("should panic when address without address key provided", func() {
-			Ω(func() { addressService.Update(tenantID, applicationID, addressID, emptyAddress) }).Should(Panic())
+		It("should return InvalidArgumentError when address with key contains whitespace only provided", func() {
+			err := addressService.Update(ctx, tenantID, applicationID, addressID, addressWithWhitespaceKey, contract.AnyVersion, "")
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
 		})
 
-		It("should panic when address with empty key provided", func() {
-			Ω(func() { addressService.Update(tenantID, applicationID, addressID, addressWithEmptyKey) }).Should(Panic())
+		It("should return InvalidArgumentError when address with key contains not allowed characters provided", func() {
+			err := addressService.Update(ctx, tenantID, applicationID, addressID, addressWithHostileKey, contract.AnyVersion, "")
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
 		})
 
-		It("should panic when address with key contains whitespace only provided", func() {
-			Ω(func() { addressService.Update(tenantID, applicationID, addressID, addressWithWhitespaceKey) }).Should(Panic())
+		It("should return InvalidArgumentError when address with empty value provided", func() {
+			err := addressService.Update(ctx, tenantID, applicationID, addressID, addressWithEmptyValue, contract.AnyVersion, "")
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
 		})
 
-		It("should panic when address with empty value provided", func() {
-			Ω(func() { addressService.Update(tenantID, applicationID, addressID, addressWithEmptyValue) }).Should(Panic())
+		It("should return InvalidArgumentError when address with value contains whitespace only provided", func() {
+			err := addressService.Update(ctx, tenantID, applicationID, addressID, addressWithWhitespaceValue, contract.AnyVersion, "")
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
 		})
 
-		It("should panic when address with value contains whitespace only provided", func() {
-			Ω(func() { addressService.Update(tenantID, applicationID, addressID, addressWithWhitespaceValue) }).Should(Panic())
+		It("should return InvalidArgumentError when address with negative TTL provided", func() {
+			err := addressService.Update(
+					ctx,
+					tenantID,
+					applicationID,
+					addressID,
+					domain.Address{AddressDetails: map[string]string{"City": "Christchurch"}, TTL: -time.Second},
+					contract.AnyVersion,
+					"")
+
+			Expect(err).To(BeAssignableToTypeOf(businessContract.InvalidArgumentError{}))
 		})
 	})
 })
 
 var _ = Describe("Update method behaviour", func() {
 	var (
+		ctx                    context.Context
 		mockCtrl               *gomock.Controller
 		addressService         *service.AddressService
 		mockAddressDataService *MockAddressDataService
 		tenantID               system.UUID
 		applicationID          system.UUID
 		addressID              system.UUID
+		expectedVersion        int64
 		validAddress           domain.Address
 	)
 
 	BeforeEach(func() {
+		ctx = context.Background()
 		mockCtrl = gomock.NewController(GinkgoT())
 		mockAddressDataService = NewMockAddressDataService(mockCtrl)
 
@@ -113,6 +167,7 @@ var _ = Describe("Update method behaviour", func() {
 		tenantID, _ = system.RandomUUID()
 		applicationID, _ = system.RandomUUID()
 		addressID, _ = system.RandomUUID()
+		expectedVersion = int64(rand.Intn(100) + 1)
 		validAddress = domain.Address{AddressDetails: map[string]string{"City": "Christchurch"}}
 	})
 
@@ -122,10 +177,11 @@ var _ = Describe("Update method behaviour", func() {
 
 	It("should call address data service Update function", func() {
 		mappedAddress := contract.Address{AddressDetails: validAddress.AddressDetails}
+		changedBy := "support-agent"
 
-		mockAddressDataService.EXPECT().Update(tenantID, applicationID, addressID, mappedAddress)
+		mockAddressDataService.EXPECT().Update(ctx, tenantID, applicationID, addressID, mappedAddress, expectedVersion, changedBy)
 
-		addressService.Update(tenantID, applicationID, addressID, validAddress)
+		addressService.Update(ctx, tenantID, applicationID, addressID, validAddress, expectedVersion, changedBy)
 	})
 
 	Context("when address data service succeeds to update the requested address", func() {
@@ -134,10 +190,10 @@ var _ = Describe("Update method behaviour", func() {
 
 			mockAddressDataService.
 				EXPECT().
-				Update(tenantID, applicationID, addressID, mappedAddress).
+				Update(ctx, tenantID, applicationID, addressID, mappedAddress, expectedVersion, "").
 				Return(nil)
 
-			err := addressService.Update(tenantID, applicationID, addressID, validAddress)
+			err := addressService.Update(ctx, tenantID, applicationID, addressID, validAddress, expectedVersion, "")
 
 			Expect(err).To(BeNil())
 		})
@@ -151,14 +207,29 @@ var _ = Describe("Update method behaviour", func() {
 			expectedError := errors.New(expectedErrorID.String())
 			mockAddressDataService.
 				EXPECT().
-				Update(tenantID, applicationID, addressID, mappedAddress).
+				Update(ctx, tenantID, applicationID, addressID, mappedAddress, expectedVersion, "").
 				Return(expectedError)
 
-			err := addressService.Update(tenantID, applicationID, addressID, validAddress)
+			err := addressService.Update(ctx, tenantID, applicationID, addressID, validAddress, expectedVersion, "")
 
 			Expect(err).To(Equal(expectedError))
 		})
 	})
+	Context("when address data service returns version conflict error", func() {
+		It("should return the conflict error as business layer conflict error", func() {
+			mappedAddress := contract.Address{AddressDetails: validAddress.AddressDetails}
+
+			actualVersion := expectedVersion + 1
+			mockAddressDataService.
+				EXPECT().
+				Update(ctx, tenantID, applicationID, addressID, mappedAddress, expectedVersion, "").
+				Return(contract.ConflictError{AddressID: addressID, ExpectedVersion: expectedVersion, ActualVersion: actualVersion})
+
+			err := addressService.Update(ctx, tenantID, applicationID, addressID, validAddress, expectedVersion, "")
+
+			Expect(err).To(Equal(businessContract.ConflictError{AddressID: addressID, ExpectedVersion: expectedVersion, ActualVersion: actualVersion}))
+		})
+	})
 })
 
 func TestUpdate(t *testing.T) {
@@ -0,0 +1,418 @@
+package service
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	businessContract "github.com/micro-business/AddressService/business/contract"
+	"github.com/micro-business/AddressService/business/domain"
+	"github.com/micro-business/AddressService/data/contract"
+	"github.com/micro-business/Micro-Business-Core/common/diagnostics"
+	"github.com/micro-business/Micro-Business-Core/system"
+	"golang.org/x/net/context"
+)
+
+// The formats the addresses are exported and imported in.
+const (
+	// JSONLinesFormat writes every address as a JSON object on its own line.
+	JSONLinesFormat = "jsonl"
+
+	// CSVFormat writes every address detail as a row of address_id, ttl, address_key and address_value columns following
+	// a header row. The rows of an address are written one after another.
+	CSVFormat = "csv"
+)
+
+// defaultExportPageSize is the number of addresses read at a time when AddressExporter.PageSize is not provided.
+const defaultExportPageSize = 100
+
+// csvHeader holds the columns of CSVFormat.
+var csvHeader = []string{"address_id", "ttl", "address_key", "address_value"}
+
+// ExportedAddress defines an address as it is exported and imported.
+type ExportedAddress struct {
+	// AddressID is the unique identifier the address had when it was exported. Imported addresses are given new unique
+	// identifiers.
+	AddressID string `json:"addressId,omitempty"`
+
+	AddressDetails map[string]string `json:"addressDetails"`
+
+	// TTL is the remaining number of seconds before the address expires, or 0 if it never expires.
+	TTL int64 `json:"ttl,omitempty"`
+}
+
+// ImportResult defines the result of importing one of the addresses.
+type ImportResult struct {
+	// Record is the number of the address in the imported addresses, starting from 1.
+	Record int `json:"record"`
+
+	// SourceAddressID is the unique identifier the address had when it was exported, or empty if it is not provided.
+	SourceAddressID string `json:"sourceAddressId,omitempty"`
+
+	// AddressID is the unique identifier of the new address, or of the existing address if duplicates return the existing
+	// address, or empty if the address could not be imported.
+	AddressID string `json:"addressId,omitempty"`
+
+	// DuplicateOf is the unique identifier of the existing address with the same fingerprint, or empty if none is found.
+	DuplicateOf string `json:"duplicateOf,omitempty"`
+
+	// Error is the reason the address could not be imported, or empty if it is imported.
+	Error string `json:"error,omitempty"`
+}
+
+// AddressExporter writes all the addresses of a tenant's application to a stream page by page, so an interrupted export
+// can be resumed from the last written page.
+type AddressExporter struct {
+	AddressDataService contract.AddressDataService
+
+	// PageSize is the number of addresses read at a time. 100 addresses are read at a time if it is not provided.
+	PageSize int
+}
+
+// AddressImporter reads addresses from a stream and creates them for a tenant's application one by one, so an
+// interrupted import can be resumed from the last imported address.
+type AddressImporter struct {
+	AddressDataService contract.AddressDataService
+
+	// DuplicatePolicy is what is done when an address with the same fingerprint already exists, the same as
+	// AddressService.DuplicatePolicy. Duplicate detection is disabled if it is not provided.
+	DuplicatePolicy DuplicateAddressPolicy
+}
+
+// addressWriter writes addresses in one of the export formats.
+type addressWriter interface {
+	write(address ExportedAddress) error
+	flush() error
+}
+
+// addressReader reads addresses in one of the export formats. read returns io.EOF when there is no more address.
+type addressReader interface {
+	read() (ExportedAddress, error)
+}
+
+// Export writes the addresses of a tenant's application to the provided writer, starting from the provided cursor. The
+// deleted and expired addresses are not exported.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the addresses.
+// applicationID: Mandatory. The unique identifier of the tenant's application owning the addresses.
+// format: Mandatory. Either JSONLinesFormat or CSVFormat.
+// cursor: Optional. The cursor provided to the last checkpoint call to resume an export, or empty to start from the first
+// address. The CSV header row is only written when the cursor is empty.
+// writer: Mandatory. The writer the addresses are written to.
+// checkpoint: Optional. Called with the cursor to resume from once every page is written to the writer. The cursor is
+// empty once all the addresses are written.
+// Returns InvalidArgumentError if the format or the cursor is not valid, or error if something goes wrong.
+func (addressExporter AddressExporter) Export(
+	ctx context.Context,
+	tenantID, applicationID system.UUID,
+	format string,
+	cursor string,
+	writer io.Writer,
+	checkpoint func(cursor string) error) error {
+	diagnostics.IsNotNil(addressExporter.AddressDataService, "addressExporter.AddressDataService", "AddressDataService must be provided.")
+	diagnostics.IsNotNil(writer, "writer", "writer must be provided.")
+
+	if err := validateOwner(tenantID, applicationID); err != nil {
+		return err
+	}
+
+	addressWriter, err := newAddressWriter(format, writer, len(cursor) == 0)
+
+	if err != nil {
+		return err
+	}
+
+	pageSize := addressExporter.PageSize
+
+	if pageSize <= 0 {
+		pageSize = defaultExportPageSize
+	}
+
+	for {
+		page, err := addressExporter.AddressDataService.List(ctx, tenantID, applicationID, pageSize, cursor)
+
+		if err != nil {
+			return mapFromDataError(err)
+		}
+
+		for _, listedAddress := range page.Addresses {
+			exportedAddress := ExportedAddress{
+				AddressID:      listedAddress.AddressID.String(),
+				AddressDetails: listedAddress.Address.AddressDetails,
+				TTL:            int64(listedAddress.Address.TTL / time.Second),
+			}
+
+			if err = addressWriter.write(exportedAddress); err != nil {
+				return err
+			}
+		}
+
+		if err = addressWriter.flush(); err != nil {
+			return err
+		}
+
+		cursor = page.NextCursor
+
+		if checkpoint != nil {
+			if err = checkpoint(cursor); err != nil {
+				return err
+			}
+		}
+
+		if len(cursor) == 0 {
+			return nil
+		}
+	}
+}
+
+// Import reads the addresses from the provided reader and creates them for a tenant's application with new unique
+// identifiers. Every address is created the same way as AddressService.Create, so it is validated and, unless duplicate
+// detection is disabled, looked for among the existing addresses with DuplicatePolicy deciding whether it is created. An
+// address that is not valid or is a rejected duplicate is reported and skipped without stopping the import. Looking for
+// the duplicates and creating the address are not atomic, so the same address imported or created concurrently can be
+// created more than once even if duplicates are rejected.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant will be owning the addresses.
+// applicationID: Mandatory. The unique identifier of the tenant's application will be owning the addresses.
+// format: Mandatory. Either JSONLinesFormat or CSVFormat.
+// reader: Mandatory. The reader the addresses are read from.
+// skip: Optional. The number of addresses already imported, which are read but not created again to resume an import.
+// changedBy: Optional. Identifies who imports the addresses, recorded in the address history.
+// report: Mandatory. Called with the result of importing every address once it is imported or skipped, in the same order
+// as the addresses are read.
+// Returns InvalidArgumentError if the format is not valid or the addresses cannot be read, or error if something goes
+// wrong. The addresses reported before the error stay imported.
+func (addressImporter AddressImporter) Import(
+	ctx context.Context,
+	tenantID, applicationID system.UUID,
+	format string,
+	reader io.Reader,
+	skip int,
+	changedBy string,
+	report func(result ImportResult) error) error {
+	diagnostics.IsNotNil(addressImporter.AddressDataService, "addressImporter.AddressDataService", "AddressDataService must be provided.")
+	diagnostics.IsNotNil(reader, "reader", "reader must be provided.")
+	diagnostics.IsNotNil(report, "report", "report must be provided.")
+
+	if err := validateOwner(tenantID, applicationID); err != nil {
+		return err
+	}
+
+	addressReader, err := newAddressReader(format, reader)
+
+	if err != nil {
+		return err
+	}
+
+	addressService := AddressService{AddressDataService: addressImporter.AddressDataService, DuplicatePolicy: addressImporter.DuplicatePolicy}
+
+	for record := 1; ; record++ {
+		exportedAddress, err := addressReader.read()
+
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return businessContract.InvalidArgumentError{Message: fmt.Sprintf("Address cannot be read. Record: %d, error: %s", record, err.Error())}
+		}
+
+		if record <= skip {
+			continue
+		}
+
+		result := ImportResult{Record: record, SourceAddressID: exportedAddress.AddressID}
+		address := domain.Address{AddressDetails: exportedAddress.AddressDetails, TTL: time.Duration(exportedAddress.TTL) * time.Second}
+		createdAddress, err := addressService.Create(ctx, tenantID, applicationID, address, changedBy)
+
+		switch err := err.(type) {
+		case nil:
+			result.AddressID = createdAddress.AddressID.String()
+
+			if createdAddress.DuplicateOf != system.EmptyUUID {
+				result.DuplicateOf = createdAddress.DuplicateOf.String()
+			}
+		case businessContract.InvalidArgumentError:
+			result.Error = err.Error()
+		case businessContract.DuplicateAddressError:
+			result.DuplicateOf = err.AddressID.String()
+			result.Error = err.Error()
+		default:
+			return err
+		}
+
+		if err = report(result); err != nil {
+			return err
+		}
+	}
+}
+
+// newAddressWriter returns the writer of the provided format, writing the CSV header row if writeHeader is true.
+func newAddressWriter(format string, writer io.Writer, writeHeader bool) (addressWriter, error) {
+	switch format {
+	case JSONLinesFormat:
+		return jsonLinesAddressWriter{encoder: json.NewEncoder(writer)}, nil
+	case CSVFormat:
+		csvWriter := csv.NewWriter(writer)
+
+		if writeHeader {
+			if err := csvWriter.Write(csvHeader); err != nil {
+				return nil, err
+			}
+		}
+
+		return csvAddressWriter{writer: csvWriter}, nil
+	}
+
+	return nil, newUnknownFormatError(format)
+}
+
+// newAddressReader returns the reader of the provided format, reading the CSV header row.
+func newAddressReader(format string, reader io.Reader) (addressReader, error) {
+	switch format {
+	case JSONLinesFormat:
+		return jsonLinesAddressReader{decoder: json.NewDecoder(reader)}, nil
+	case CSVFormat:
+		csvReader := csv.NewReader(reader)
+		csvReader.FieldsPerRecord = len(csvHeader)
+
+		header, err := csvReader.Read()
+
+		if err != nil && err != io.EOF {
+			return nil, businessContract.InvalidArgumentError{Message: fmt.Sprintf("CSV header cannot be read. Error: %s", err.Error())}
+		}
+
+		if err == nil {
+			for index, column := range csvHeader {
+				if header[index] != column {
+					return nil, businessContract.InvalidArgumentError{Message: fmt.Sprintf("Unexpected CSV column. Column: %s", header[index])}
+				}
+			}
+		}
+
+		return &csvAddressReader{reader: csvReader}, nil
+	}
+
+	return nil, newUnknownFormatError(format)
+}
+
+// newUnknownFormatError returns the error returned when the export format is not known.
+func newUnknownFormatError(format string) error {
+	return businessContract.InvalidArgumentError{
+		Message: fmt.Sprintf("Unknown format %s. Supported formats are %s and %s.", format, JSONLinesFormat, CSVFormat),
+	}
+}
+
+// jsonLinesAddressWriter writes addresses in JSONLinesFormat.
+type jsonLinesAddressWriter struct {
+	encoder *json.Encoder
+}
+
+func (writer jsonLinesAddressWriter) write(address ExportedAddress) error {
+	return writer.encoder.Encode(address)
+}
+
+func (writer jsonLinesAddressWriter) flush() error {
+	return nil
+}
+
+// jsonLinesAddressReader reads addresses in JSONLinesFormat.
+type jsonLinesAddressReader struct {
+	decoder *json.Decoder
+}
+
+func (reader jsonLinesAddressReader) read() (ExportedAddress, error) {
+	var address ExportedAddress
+
+	if err := reader.decoder.Decode(&address); err != nil {
+		return ExportedAddress{}, err
+	}
+
+	return address, nil
+}
+
+// csvAddressWriter writes addresses in CSVFormat. The address details are written ordered by key.
+type csvAddressWriter struct {
+	writer *csv.Writer
+}
+
+func (writer csvAddressWriter) write(address ExportedAddress) error {
+	keys := make([]string, 0, len(address.AddressDetails))
+
+	for key := range address.AddressDetails {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	ttl := strconv.FormatInt(address.TTL, 10)
+
+	for _, key := range keys {
+		if err := writer.writer.Write([]string{address.AddressID, ttl, key, address.AddressDetails[key]}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (writer csvAddressWriter) flush() error {
+	writer.writer.Flush()
+
+	return writer.writer.Error()
+}
+
+// csvAddressReader reads addresses in CSVFormat. The consecutive rows having the same address_id are read as the details
+// of the same address.
+type csvAddressReader struct {
+	reader *csv.Reader
+
+	// nextRow is the first row of the next address, read while reading the previous address.
+	nextRow []string
+}
+
+func (reader *csvAddressReader) read() (ExportedAddress, error) {
+	row := reader.nextRow
+	reader.nextRow = nil
+
+	if row == nil {
+		var err error
+
+		if row, err = reader.reader.Read(); err != nil {
+			return ExportedAddress{}, err
+		}
+	}
+
+	address := ExportedAddress{AddressID: row[0], AddressDetails: make(map[string]string)}
+
+	for {
+		ttl, err := strconv.ParseInt(row[1], 10, 64)
+
+		if err != nil {
+			return ExportedAddress{}, err
+		}
+
+		address.TTL = ttl
+		address.AddressDetails[row[2]] = row[3]
+
+		row, err = reader.reader.Read()
+
+		if err == io.EOF {
+			return address, nil
+		}
+
+		if err != nil {
+			return ExportedAddress{}, err
+		}
+
+		if row[0] != address.AddressID {
+			reader.nextRow = row
+
+			return address, nil
+		}
+	}
+}
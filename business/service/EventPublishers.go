@@ -0,0 +1,123 @@
+package service
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/micro-business/AddressService/business/domain"
+	"golang.org/x/net/context"
+)
+
+// publishedAddressEvent defines an address event as it is written by StdoutEventPublisher and FileEventPublisher.
+type publishedAddressEvent struct {
+	EventID           string            `json:"eventId"`
+	EventType         string            `json:"eventType"`
+	TenantID          string            `json:"tenantId"`
+	ApplicationID     string            `json:"applicationId"`
+	AddressID         string            `json:"addressId"`
+	Version           int64             `json:"version"`
+	OldAddressDetails map[string]string `json:"oldAddressDetails,omitempty"`
+	NewAddressDetails map[string]string `json:"newAddressDetails,omitempty"`
+	ChangedAt         time.Time         `json:"changedAt"`
+	ChangedBy         string            `json:"changedBy,omitempty"`
+}
+
+// StdoutEventPublisher writes every address event as a JSON object on its own line to the standard output.
+type StdoutEventPublisher struct {
+}
+
+// FileEventPublisher appends every address event as a JSON object on its own line to a file.
+type FileEventPublisher struct {
+	// Path is the path of the file the events are appended to. The file is created if it does not exist.
+	Path string
+}
+
+// InMemoryEventPublisher keeps the published address events in the process memory. It is meant to be used for tests.
+type InMemoryEventPublisher struct {
+	lock   sync.Mutex
+	events []domain.AddressEvent
+}
+
+// Publish writes the provided events to the standard output in order.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// events: Mandatory. The events to publish.
+// Returns error if something goes wrong.
+func (publisher StdoutEventPublisher) Publish(ctx context.Context, events []domain.AddressEvent) error {
+	return writeAddressEvents(os.Stdout, events)
+}
+
+// Publish appends the provided events to the file in order and flushes the file to the disk.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// events: Mandatory. The events to publish.
+// Returns error if something goes wrong.
+func (publisher FileEventPublisher) Publish(ctx context.Context, events []domain.AddressEvent) error {
+	file, err := os.OpenFile(publisher.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+
+	if err != nil {
+		return err
+	}
+
+	if err = writeAddressEvents(file, events); err != nil {
+		file.Close()
+
+		return err
+	}
+
+	if err = file.Sync(); err != nil {
+		file.Close()
+
+		return err
+	}
+
+	return file.Close()
+}
+
+// Publish keeps the provided events in order.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// events: Mandatory. The events to publish.
+// Returns error if something goes wrong.
+func (publisher *InMemoryEventPublisher) Publish(ctx context.Context, events []domain.AddressEvent) error {
+	publisher.lock.Lock()
+	defer publisher.lock.Unlock()
+
+	publisher.events = append(publisher.events, events...)
+
+	return nil
+}
+
+// Events returns all the published events ordered from the first published to the last published.
+func (publisher *InMemoryEventPublisher) Events() []domain.AddressEvent {
+	publisher.lock.Lock()
+	defer publisher.lock.Unlock()
+
+	return append([]domain.AddressEvent{}, publisher.events...)
+}
+
+// writeAddressEvents writes every event as a JSON object on its own line.
+func writeAddressEvents(writer io.Writer, events []domain.AddressEvent) error {
+	encoder := json.NewEncoder(writer)
+
+	for _, event := range events {
+		err := encoder.Encode(publishedAddressEvent{
+			EventID:           event.EventID.String(),
+			EventType:         string(event.EventType),
+			TenantID:          event.TenantID.String(),
+			ApplicationID:     event.ApplicationID.String(),
+			AddressID:         event.AddressID.String(),
+			Version:           event.Version,
+			OldAddressDetails: event.OldAddressDetails,
+			NewAddressDetails: event.NewAddressDetails,
+			ChangedAt:         event.ChangedAt,
+			ChangedBy:         event.ChangedBy,
+		})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -7,6 +7,8 @@ import (
 	gomock "github.com/golang/mock/gomock"
 	. "github.com/micro-business/AddressService/data/contract"
 	system "github.com/micro-business/Micro-Business-Core/system"
+	context "golang.org/x/net/context"
+	time "time"
 )
 
 // Mock of AddressDataService interface
@@ -30,55 +32,265 @@ func (_m *MockAddressDataService) EXPECT() *_MockAddressDataServiceRecorder {
 	return _m.recorder
 }
 
-func (_m *MockAddressDataService) Create(tenantID system.UUID, applicationID system.UUID, address Address) (system.UUID, error) {
-	ret := _m.ctrl.Call(_m, "Create", tenantID, applicationID, address)
+func (_m *MockAddressDataService) Create(ctx context.Context, tenantID system.UUID, applicationID system.UUID, address Address, changedBy string) (system.UUID, error) {
+	ret := _m.ctrl.Call(_m, "Create", ctx, tenantID, applicationID, address, changedBy)
 	ret0, _ := ret[0].(system.UUID)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-func (_mr *_MockAddressDataServiceRecorder) Create(arg0, arg1, arg2 interface{}) *gomock.Call {
-	return _mr.mock.ctrl.RecordCall(_mr.mock, "Create", arg0, arg1, arg2)
+func (_mr *_MockAddressDataServiceRecorder) Create(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "Create", arg0, arg1, arg2, arg3, arg4)
 }
 
-func (_m *MockAddressDataService) Update(tenantID system.UUID, applicationID system.UUID, addressID system.UUID, address Address) error {
-	ret := _m.ctrl.Call(_m, "Update", tenantID, applicationID, addressID, address)
+func (_m *MockAddressDataService) CreateMany(ctx context.Context, tenantID system.UUID, applicationID system.UUID, addresses []Address, changedBy string) []AddressResult {
+	ret := _m.ctrl.Call(_m, "CreateMany", ctx, tenantID, applicationID, addresses, changedBy)
+	ret0, _ := ret[0].([]AddressResult)
+	return ret0
+}
+
+func (_mr *_MockAddressDataServiceRecorder) CreateMany(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "CreateMany", arg0, arg1, arg2, arg3, arg4)
+}
+
+func (_m *MockAddressDataService) Update(ctx context.Context, tenantID system.UUID, applicationID system.UUID, addressID system.UUID, address Address, expectedVersion int64, changedBy string) error {
+	ret := _m.ctrl.Call(_m, "Update", ctx, tenantID, applicationID, addressID, address, expectedVersion, changedBy)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-func (_mr *_MockAddressDataServiceRecorder) Update(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
-	return _mr.mock.ctrl.RecordCall(_mr.mock, "Update", arg0, arg1, arg2, arg3)
+func (_mr *_MockAddressDataServiceRecorder) Update(arg0, arg1, arg2, arg3, arg4, arg5, arg6 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "Update", arg0, arg1, arg2, arg3, arg4, arg5, arg6)
+}
+
+func (_m *MockAddressDataService) Patch(ctx context.Context, tenantID system.UUID, applicationID system.UUID, addressID system.UUID, set map[string]string, remove []string, expectedVersion int64, changedBy string) error {
+	ret := _m.ctrl.Call(_m, "Patch", ctx, tenantID, applicationID, addressID, set, remove, expectedVersion, changedBy)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockAddressDataServiceRecorder) Patch(arg0, arg1, arg2, arg3, arg4, arg5, arg6, arg7 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "Patch", arg0, arg1, arg2, arg3, arg4, arg5, arg6, arg7)
+}
+
+func (_m *MockAddressDataService) Read(ctx context.Context, tenantID system.UUID, applicationID system.UUID, addressID system.UUID, keys []string) (Address, error) {
+	ret := _m.ctrl.Call(_m, "Read", ctx, tenantID, applicationID, addressID, keys)
+	ret0, _ := ret[0].(Address)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockAddressDataServiceRecorder) Read(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "Read", arg0, arg1, arg2, arg3, arg4)
 }
 
-func (_m *MockAddressDataService) Read(tenantID system.UUID, applicationID system.UUID, addressID system.UUID, keys []string) (Address, error) {
-	ret := _m.ctrl.Call(_m, "Read", tenantID, applicationID, addressID, keys)
+func (_m *MockAddressDataService) ReadAll(ctx context.Context, tenantID system.UUID, applicationID system.UUID, addressID system.UUID) (Address, error) {
+	ret := _m.ctrl.Call(_m, "ReadAll", ctx, tenantID, applicationID, addressID)
 	ret0, _ := ret[0].(Address)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-func (_mr *_MockAddressDataServiceRecorder) Read(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
-	return _mr.mock.ctrl.RecordCall(_mr.mock, "Read", arg0, arg1, arg2, arg3)
+func (_mr *_MockAddressDataServiceRecorder) ReadAll(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "ReadAll", arg0, arg1, arg2, arg3)
+}
+
+func (_m *MockAddressDataService) ReadMany(ctx context.Context, tenantID system.UUID, applicationID system.UUID, addressIDs []system.UUID, keys []string) []AddressResult {
+	ret := _m.ctrl.Call(_m, "ReadMany", ctx, tenantID, applicationID, addressIDs, keys)
+	ret0, _ := ret[0].([]AddressResult)
+	return ret0
+}
+
+func (_mr *_MockAddressDataServiceRecorder) ReadMany(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "ReadMany", arg0, arg1, arg2, arg3, arg4)
+}
+
+func (_m *MockAddressDataService) List(ctx context.Context, tenantID system.UUID, applicationID system.UUID, pageSize int, cursor string) (AddressPage, error) {
+	ret := _m.ctrl.Call(_m, "List", ctx, tenantID, applicationID, pageSize, cursor)
+	ret0, _ := ret[0].(AddressPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockAddressDataServiceRecorder) List(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "List", arg0, arg1, arg2, arg3, arg4)
+}
+
+func (_m *MockAddressDataService) FindByDetail(ctx context.Context, tenantID system.UUID, applicationID system.UUID, criteria map[string]string) ([]ListedAddress, error) {
+	ret := _m.ctrl.Call(_m, "FindByDetail", ctx, tenantID, applicationID, criteria)
+	ret0, _ := ret[0].([]ListedAddress)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockAddressDataServiceRecorder) FindByDetail(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "FindByDetail", arg0, arg1, arg2, arg3)
+}
+
+func (_m *MockAddressDataService) FindByFingerprint(ctx context.Context, tenantID system.UUID, applicationID system.UUID, fingerprint string) ([]ListedAddress, error) {
+	ret := _m.ctrl.Call(_m, "FindByFingerprint", ctx, tenantID, applicationID, fingerprint)
+	ret0, _ := ret[0].([]ListedAddress)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockAddressDataServiceRecorder) FindByFingerprint(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "FindByFingerprint", arg0, arg1, arg2, arg3)
+}
+
+func (_m *MockAddressDataService) AddOwners(ctx context.Context, tenantID system.UUID, applicationID system.UUID, addressID system.UUID, owners []AddressOwner) error {
+	ret := _m.ctrl.Call(_m, "AddOwners", ctx, tenantID, applicationID, addressID, owners)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockAddressDataServiceRecorder) AddOwners(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "AddOwners", arg0, arg1, arg2, arg3, arg4)
+}
+
+func (_m *MockAddressDataService) RemoveOwners(ctx context.Context, tenantID system.UUID, applicationID system.UUID, addressID system.UUID, owners []AddressOwner) error {
+	ret := _m.ctrl.Call(_m, "RemoveOwners", ctx, tenantID, applicationID, addressID, owners)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockAddressDataServiceRecorder) RemoveOwners(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "RemoveOwners", arg0, arg1, arg2, arg3, arg4)
+}
+
+func (_m *MockAddressDataService) ReadOwners(ctx context.Context, tenantID system.UUID, applicationID system.UUID, addressID system.UUID) ([]AddressOwner, error) {
+	ret := _m.ctrl.Call(_m, "ReadOwners", ctx, tenantID, applicationID, addressID)
+	ret0, _ := ret[0].([]AddressOwner)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockAddressDataServiceRecorder) ReadOwners(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "ReadOwners", arg0, arg1, arg2, arg3)
 }
 
-func (_m *MockAddressDataService) ReadAll(tenantID system.UUID, applicationID system.UUID, addressID system.UUID) (Address, error) {
-	ret := _m.ctrl.Call(_m, "ReadAll", tenantID, applicationID, addressID)
+func (_m *MockAddressDataService) FindByOwner(ctx context.Context, tenantID system.UUID, applicationID system.UUID, owner AddressOwner) ([]ListedAddress, error) {
+	ret := _m.ctrl.Call(_m, "FindByOwner", ctx, tenantID, applicationID, owner)
+	ret0, _ := ret[0].([]ListedAddress)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockAddressDataServiceRecorder) FindByOwner(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "FindByOwner", arg0, arg1, arg2, arg3)
+}
+
+func (_m *MockAddressDataService) History(ctx context.Context, tenantID system.UUID, applicationID system.UUID, addressID system.UUID) ([]AddressHistoryEntry, error) {
+	ret := _m.ctrl.Call(_m, "History", ctx, tenantID, applicationID, addressID)
+	ret0, _ := ret[0].([]AddressHistoryEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockAddressDataServiceRecorder) History(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "History", arg0, arg1, arg2, arg3)
+}
+
+func (_m *MockAddressDataService) ReadAt(ctx context.Context, tenantID system.UUID, applicationID system.UUID, addressID system.UUID, timestamp time.Time) (Address, error) {
+	ret := _m.ctrl.Call(_m, "ReadAt", ctx, tenantID, applicationID, addressID, timestamp)
 	ret0, _ := ret[0].(Address)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-func (_mr *_MockAddressDataServiceRecorder) ReadAll(arg0, arg1, arg2 interface{}) *gomock.Call {
-	return _mr.mock.ctrl.RecordCall(_mr.mock, "ReadAll", arg0, arg1, arg2)
+func (_mr *_MockAddressDataServiceRecorder) ReadAt(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "ReadAt", arg0, arg1, arg2, arg3, arg4)
+}
+
+func (_m *MockAddressDataService) Delete(ctx context.Context, tenantID system.UUID, applicationID system.UUID, addressID system.UUID, expectedVersion int64, changedBy string) error {
+	ret := _m.ctrl.Call(_m, "Delete", ctx, tenantID, applicationID, addressID, expectedVersion, changedBy)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockAddressDataServiceRecorder) Delete(arg0, arg1, arg2, arg3, arg4, arg5 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "Delete", arg0, arg1, arg2, arg3, arg4, arg5)
+}
+
+func (_m *MockAddressDataService) DeleteMany(ctx context.Context, tenantID system.UUID, applicationID system.UUID, addressIDs []system.UUID, changedBy string) []AddressResult {
+	ret := _m.ctrl.Call(_m, "DeleteMany", ctx, tenantID, applicationID, addressIDs, changedBy)
+	ret0, _ := ret[0].([]AddressResult)
+	return ret0
+}
+
+func (_mr *_MockAddressDataServiceRecorder) DeleteMany(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "DeleteMany", arg0, arg1, arg2, arg3, arg4)
+}
+
+func (_m *MockAddressDataService) Restore(ctx context.Context, tenantID system.UUID, applicationID system.UUID, addressID system.UUID, deletedSince time.Time, changedBy string) error {
+	ret := _m.ctrl.Call(_m, "Restore", ctx, tenantID, applicationID, addressID, deletedSince, changedBy)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockAddressDataServiceRecorder) Restore(arg0, arg1, arg2, arg3, arg4, arg5 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "Restore", arg0, arg1, arg2, arg3, arg4, arg5)
+}
+
+func (_m *MockAddressDataService) Purge(ctx context.Context, tenantID system.UUID, applicationID system.UUID, addressID system.UUID) error {
+	ret := _m.ctrl.Call(_m, "Purge", ctx, tenantID, applicationID, addressID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockAddressDataServiceRecorder) Purge(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "Purge", arg0, arg1, arg2, arg3)
+}
+
+func (_m *MockAddressDataService) PurgeDeleted(ctx context.Context, deletedBefore time.Time) error {
+	ret := _m.ctrl.Call(_m, "PurgeDeleted", ctx, deletedBefore)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockAddressDataServiceRecorder) PurgeDeleted(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "PurgeDeleted", arg0, arg1)
+}
+
+func (_m *MockAddressDataService) PurgeTenant(ctx context.Context, tenantID system.UUID, requestedBy string) (ErasureCertificate, error) {
+	ret := _m.ctrl.Call(_m, "PurgeTenant", ctx, tenantID, requestedBy)
+	ret0, _ := ret[0].(ErasureCertificate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockAddressDataServiceRecorder) PurgeTenant(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "PurgeTenant", arg0, arg1, arg2)
+}
+
+func (_m *MockAddressDataService) PurgeApplication(ctx context.Context, tenantID system.UUID, applicationID system.UUID, requestedBy string) (ErasureCertificate, error) {
+	ret := _m.ctrl.Call(_m, "PurgeApplication", ctx, tenantID, applicationID, requestedBy)
+	ret0, _ := ret[0].(ErasureCertificate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockAddressDataServiceRecorder) PurgeApplication(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "PurgeApplication", arg0, arg1, arg2, arg3)
+}
+
+func (_m *MockAddressDataService) ReadEvents(ctx context.Context, limit int) ([]AddressEvent, error) {
+	ret := _m.ctrl.Call(_m, "ReadEvents", ctx, limit)
+	ret0, _ := ret[0].([]AddressEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockAddressDataServiceRecorder) ReadEvents(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "ReadEvents", arg0, arg1)
 }
 
-func (_m *MockAddressDataService) Delete(tenantID system.UUID, applicationID system.UUID, addressID system.UUID) error {
-	ret := _m.ctrl.Call(_m, "Delete", tenantID, applicationID, addressID)
+func (_m *MockAddressDataService) RemoveEvents(ctx context.Context, events []AddressEvent) error {
+	ret := _m.ctrl.Call(_m, "RemoveEvents", ctx, events)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-func (_mr *_MockAddressDataServiceRecorder) Delete(arg0, arg1, arg2 interface{}) *gomock.Call {
-	return _mr.mock.ctrl.RecordCall(_mr.mock, "Delete", arg0, arg1, arg2)
+func (_mr *_MockAddressDataServiceRecorder) RemoveEvents(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "RemoveEvents", arg0, arg1)
 }
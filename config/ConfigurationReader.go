@@ -1,10 +1,15 @@
 package config
 
+import "time"
+
 // ConfigurationReader defines the interface that provides access to all configurations parameters required by the service.
 type ConfigurationReader interface {
 	// GetListeningPort returns the port the application should start listening on.
 	GetListeningPort() (int, error)
 
+	// GetRequestTimeout returns how long a request may take before it is canceled. Zero means no timeout.
+	GetRequestTimeout() (time.Duration, error)
+
 	// GetCassandraHosts returns the list of Cassandra host addresses.
 	GetCassandraHosts() ([]string, error)
 
@@ -13,4 +18,16 @@ type ConfigurationReader interface {
 
 	// GetCassandraProtocolVersion returns the cassandra procotol version.
 	GetCassandraProtocolVersion() (int, error)
+
+	// GetSQLDriverName returns the name of database/sql driver used to connect to the SQL database.
+	GetSQLDriverName() (string, error)
+
+	// GetSQLDataSourceName returns the driver specific data source name used to connect to the SQL database.
+	GetSQLDataSourceName() (string, error)
+
+	// GetDeletedAddressGracePeriod returns how long a deleted address can be restored before it is purged.
+	GetDeletedAddressGracePeriod() (time.Duration, error)
+
+	// GetDeletedAddressPurgeInterval returns how often the deleted addresses whose grace period is over are purged.
+	GetDeletedAddressPurgeInterval() (time.Duration, error)
 }
@@ -3,24 +3,35 @@ package config
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/micro-business/Micro-Business-Core/common/config"
 )
 
 // ConsulConfigurationReader implements ConfigurationReader using Consul to provide access to all configurations parameters required by the service.
 type ConsulConfigurationReader struct {
-	ConsulAddress                      string
-	ConsulScheme                       string
-	ListeningPortToOverride            int
-	CassandraHostsToOverride           []string
-	CassandraKeyspaceToOverride        string
-	CassandraProtocolVersionToOverride int
+	ConsulAddress                         string
+	ConsulScheme                          string
+	ListeningPortToOverride               int
+	RequestTimeoutToOverride              time.Duration
+	CassandraHostsToOverride              []string
+	CassandraKeyspaceToOverride           string
+	CassandraProtocolVersionToOverride    int
+	SQLDriverNameToOverride               string
+	SQLDataSourceNameToOverride           string
+	DeletedAddressGracePeriodToOverride   time.Duration
+	DeletedAddressPurgeIntervalToOverride time.Duration
 }
 
 const serviceListeningPortKey = "services/address-service/endpoint/listening-port"
+const requestTimeoutKey = "services/address-service/endpoint/request-timeout"
 const cassandraHostsKey = "services/address-service/data/cassandra/hosts"
 const cassandraKeyspaceKey = "services/address-service/data/cassandra/keyspace"
 const cassandraProtocolVersionKey = "services/address-service/data/cassandra/protocol-version"
+const sqlDriverNameKey = "services/address-service/data/sql/driver-name"
+const sqlDataSourceNameKey = "services/address-service/data/sql/data-source-name"
+const deletedAddressGracePeriodKey = "services/address-service/data/deleted-address/grace-period"
+const deletedAddressPurgeIntervalKey = "services/address-service/data/deleted-address/purge-interval"
 
 // GetListeningPort returns the port the service should listen on to serve the HTTP request
 func (consul ConsulConfigurationReader) GetListeningPort() (int, error) {
@@ -33,6 +44,16 @@ func (consul ConsulConfigurationReader) GetListeningPort() (int, error) {
 	return consulHelper.GetInt(serviceListeningPortKey)
 }
 
+// GetRequestTimeout returns how long a request may take before it is canceled. Zero means no timeout, which is also
+// returned when the Consul key does not exist or is empty.
+func (consul ConsulConfigurationReader) GetRequestTimeout() (time.Duration, error) {
+	if consul.RequestTimeoutToOverride != 0 {
+		return consul.RequestTimeoutToOverride, nil
+	}
+
+	return consul.getDuration(requestTimeoutKey)
+}
+
 // GetCassandraHosts returns the list of Cassandra host addresses.
 func (consul ConsulConfigurationReader) GetCassandraHosts() ([]string, error) {
 	if len(consul.CassandraHostsToOverride) != 0 {
@@ -82,3 +103,68 @@ func (consul ConsulConfigurationReader) GetCassandraProtocolVersion() (int, erro
 
 	return consulHelper.GetInt(cassandraProtocolVersionKey)
 }
+
+// GetSQLDriverName returns the name of database/sql driver used to connect to the SQL database.
+func (consul ConsulConfigurationReader) GetSQLDriverName() (string, error) {
+	if len(consul.SQLDriverNameToOverride) != 0 {
+		return consul.SQLDriverNameToOverride, nil
+	}
+
+	consulHelper := config.ConsulHelper{ConsulAddress: consul.ConsulAddress, ConsulScheme: consul.ConsulScheme}
+
+	return consulHelper.GetString(sqlDriverNameKey)
+}
+
+// GetSQLDataSourceName returns the driver specific data source name used to connect to the SQL database.
+func (consul ConsulConfigurationReader) GetSQLDataSourceName() (string, error) {
+	if len(consul.SQLDataSourceNameToOverride) != 0 {
+		return consul.SQLDataSourceNameToOverride, nil
+	}
+
+	consulHelper := config.ConsulHelper{ConsulAddress: consul.ConsulAddress, ConsulScheme: consul.ConsulScheme}
+
+	return consulHelper.GetString(sqlDataSourceNameKey)
+}
+
+// GetDeletedAddressGracePeriod returns how long a deleted address can be restored before it is purged. Returns zero if
+// the Consul key does not exist or is empty, so the default grace period is used.
+func (consul ConsulConfigurationReader) GetDeletedAddressGracePeriod() (time.Duration, error) {
+	if consul.DeletedAddressGracePeriodToOverride != 0 {
+		return consul.DeletedAddressGracePeriodToOverride, nil
+	}
+
+	return consul.getDuration(deletedAddressGracePeriodKey)
+}
+
+// GetDeletedAddressPurgeInterval returns how often the deleted addresses whose grace period is over are purged. Returns
+// zero if the Consul key does not exist or is empty, so the deleted addresses are not purged.
+func (consul ConsulConfigurationReader) GetDeletedAddressPurgeInterval() (time.Duration, error) {
+	if consul.DeletedAddressPurgeIntervalToOverride != 0 {
+		return consul.DeletedAddressPurgeIntervalToOverride, nil
+	}
+
+	return consul.getDuration(deletedAddressPurgeIntervalKey)
+}
+
+// getDuration reads the value of the provided Consul key and parses it as a duration, e.g. 72h. Returns zero if the key
+// does not exist or is empty, so the optional durations do not have to be set in Consul.
+func (consul ConsulConfigurationReader) getDuration(key string) (time.Duration, error) {
+	consulHelper := config.ConsulHelper{ConsulAddress: consul.ConsulAddress, ConsulScheme: consul.ConsulScheme}
+	keyPair, err := consulHelper.GetKeyPair(key)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if keyPair == nil || len(keyPair.Value) == 0 {
+		return 0, nil
+	}
+
+	duration, err := time.ParseDuration(string(keyPair.Value))
+
+	if err != nil {
+		return 0, fmt.Errorf("Consul key %s is not a valid duration. %s", key, err.Error())
+	}
+
+	return duration, nil
+}
@@ -2,50 +2,450 @@
 package contract
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/micro-business/Micro-Business-Core/system"
+	"golang.org/x/net/context"
 )
 
+// AnyVersion can be provided as the expected version of an address to skip the version check. It is negative, so it
+// never matches the version of an address, including 0 reported for the addresses created before versioning.
+const AnyVersion int64 = -1
+
+// MaxFoundAddresses is the maximum number of addresses FindByDetail returns.
+const MaxFoundAddresses = 100
+
+// EncryptedValuePrefix starts every address value encrypted before it is stored, so the values starting with it are read
+// as ciphertext and cannot be stored in plaintext.
+const EncryptedValuePrefix = "enc:"
+
 // Address defines how an address should look like
 type Address struct {
 	AddressDetails map[string]string
+
+	// Version is the current version of the address returned when reading the address. The version of a new address is 1
+	// and it is increased every time the address changes.
+	Version int64
+
+	// TTL is the time to live of the address. When provided on create or update, the address expires and disappears on its
+	// own after it; zero means the address never expires. When reading the address, it is the remaining time before the
+	// address expires, or zero if it never expires.
+	TTL time.Duration
+
+	// Fingerprint is the normalized fingerprint of the address details computed by the business layer. When provided on
+	// create or update, the address can be found by it using FindByFingerprint; patching the address keeps it unchanged.
+	// Only its hash is stored, keyed with the tenant's data key when the address values are encrypted, as the fingerprint
+	// holds the address details. It is not returned when reading the address.
+	Fingerprint string
+}
+
+// AddressHistoryEntry defines a version of an address recorded in the address history.
+type AddressHistoryEntry struct {
+	// Address holds the address details and the version of the address recorded by this entry.
+	Address Address
+
+	// ChangedAt is the time the address was changed at.
+	ChangedAt time.Time
+
+	// ChangedBy identifies who changed the address, or is empty if it was not provided.
+	ChangedBy string
+
+	// Deleted is true if the address was deleted by this change.
+	Deleted bool
+}
+
+// ListedAddress defines an address returned by List along with its unique identifier.
+type ListedAddress struct {
+	AddressID system.UUID
+	Address   Address
+}
+
+// AddressOwner defines a reference to an entity owning an address, e.g. the customer the address belongs to.
+type AddressOwner struct {
+	// Type is the type of the owning entity, e.g. customer.
+	Type string
+
+	// ID is the identifier of the owning entity within its type.
+	ID string
+}
+
+// AddressPage defines a page of addresses returned by List.
+type AddressPage struct {
+	// Addresses holds the addresses in the page.
+	Addresses []ListedAddress
+
+	// NextCursor is the cursor to provide to List to return the next page, or empty if there is no more address to return.
+	// A page returned for a non-empty cursor can be empty.
+	NextCursor string
+}
+
+// AddressResult defines the result of creating, reading or deleting one of the addresses provided to CreateMany, ReadMany
+// and DeleteMany.
+type AddressResult struct {
+	// AddressID is the unique identifier of the address, or empty if the address could not be created.
+	AddressID system.UUID
+
+	// Address holds the address information returned by ReadMany.
+	Address Address
+
+	// Err is the error happened when the address was created, read or deleted, or nil if it succeeded.
+	Err error
+}
+
+// ErasureCertificate records the erasure of all the addresses of a tenant or a tenant's application.
+type ErasureCertificate struct {
+	// CertificateID is the unique identifier of the erasure. It is kept when an interrupted erasure is resumed.
+	CertificateID system.UUID
+
+	TenantID system.UUID
+
+	// ApplicationID is the unique identifier of the erased application, or empty if all the tenant's applications were
+	// erased.
+	ApplicationID system.UUID
+
+	// RequestedBy identifies who requested the erasure, or is empty if it was not provided.
+	RequestedBy string
+
+	// StartedAt is the time the erasure was first started at.
+	StartedAt time.Time
+
+	// CompletedAt is the time the last row was erased at.
+	CompletedAt time.Time
+
+	// ErasedRows holds the number of rows erased from every table keyed by the table name.
+	ErasedRows map[string]int64
+}
+
+// AddressEventType identifies the change of an address recorded by an address event.
+type AddressEventType string
+
+const (
+	// AddressCreated is recorded when an address is created.
+	AddressCreated AddressEventType = "AddressCreated"
+
+	// AddressUpdated is recorded when an address is updated or patched.
+	AddressUpdated AddressEventType = "AddressUpdated"
+
+	// AddressDeleted is recorded when an address is deleted.
+	AddressDeleted AddressEventType = "AddressDeleted"
+)
+
+// AddressEvent records a change of an address. The event is written to the outbox along with the change, so it is
+// published only if the change is made.
+type AddressEvent struct {
+	// EventID is the unique identifier of the event. An event can be published more than once, so the consumers use it
+	// to skip the events they have already handled.
+	EventID system.UUID
+
+	EventType     AddressEventType
+	TenantID      system.UUID
+	ApplicationID system.UUID
+	AddressID     system.UUID
+
+	// Version is the version of the address made by the change.
+	Version int64
+
+	// OldAddressDetails holds the address details before the change, or is nil for AddressCreated.
+	OldAddressDetails map[string]string
+
+	// NewAddressDetails holds the address details after the change, or is nil for AddressDeleted.
+	NewAddressDetails map[string]string
+
+	// ChangedAt is the time the address was changed at.
+	ChangedAt time.Time
+
+	// ChangedBy identifies who changed the address, or is empty if it was not provided.
+	ChangedBy string
+}
+
+// ConflictError is returned when the expected version of an address does not match its current version.
+type ConflictError struct {
+	AddressID       system.UUID
+	ExpectedVersion int64
+	ActualVersion   int64
+}
+
+// Error returns the error message.
+func (conflictError ConflictError) Error() string {
+	return fmt.Sprintf(
+		"Address version conflict. Address ID: %s, expected version: %d, actual version: %d",
+		conflictError.AddressID.String(),
+		conflictError.ExpectedVersion,
+		conflictError.ActualVersion)
+}
+
+// NotFoundError is returned when the address does not exist, e.g. it was never created, it is deleted or it has expired.
+type NotFoundError struct {
+	AddressID system.UUID
+
+	// Deleted is true if a deleted address was looked for, e.g. to restore or purge it.
+	Deleted bool
+}
+
+// Error returns the error message.
+func (notFoundError NotFoundError) Error() string {
+	if notFoundError.Deleted {
+		return fmt.Sprintf("Deleted address not found. Address ID: %s", notFoundError.AddressID.String())
+	}
+
+	return fmt.Sprintf("Address not found. Address ID: %s", notFoundError.AddressID.String())
+}
+
+// InvalidArgumentError is returned when an argument provided to the call is invalid, so the call fails the same way no
+// matter how many times it is retried.
+type InvalidArgumentError struct {
+	Message string
+}
+
+// Error returns the error message.
+func (invalidArgumentError InvalidArgumentError) Error() string {
+	return invalidArgumentError.Message
+}
+
+// UnavailableError is returned when the address storage cannot be reached or does not respond in time, so the call may
+// succeed if it is retried later.
+type UnavailableError struct {
+	Err error
+}
+
+// Error returns the error message.
+func (unavailableError UnavailableError) Error() string {
+	return fmt.Sprintf("Address storage is unavailable. Error: %s", unavailableError.Err.Error())
 }
 
 // AddressDataService service can add new address and update/retrieve/remove an existing address.
+// NotFoundError is returned when the address does not exist and UnavailableError when the address storage cannot be
+// reached.
 type AddressDataService interface {
 	// Create creates a new address.
+	// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
 	// tenantID: Mandatory. The unique identifier of the tenant owning the address.
 	// applicationID: Mandatory. The unique identifier of the tenant's application will be owning the address.
 	// address: Mandatory. The reference to the new address information.
+	// changedBy: Optional. Identifies who makes the change, recorded in the address history.
 	// Returns either the unique identifier of the new address or error if something goes wrong.
-	Create(tenantID, applicationID system.UUID, address Address) (system.UUID, error)
+	Create(ctx context.Context, tenantID, applicationID system.UUID, address Address, changedBy string) (system.UUID, error)
+
+	// CreateMany creates several new addresses. Every address is created on its own, so failing to create an address does
+	// not stop creating the rest of them.
+	// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+	// tenantID: Mandatory. The unique identifier of the tenant owning the addresses.
+	// applicationID: Mandatory. The unique identifier of the tenant's application will be owning the addresses.
+	// addresses: Mandatory. The new addresses information.
+	// changedBy: Optional. Identifies who makes the change, recorded in the address history.
+	// Returns the result of creating every address in the same order as addresses.
+	CreateMany(ctx context.Context, tenantID, applicationID system.UUID, addresses []Address, changedBy string) []AddressResult
 
 	// Update updates an existing address.
+	// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
 	// tenantID: Mandatory. The unique identifier of the tenant owning the address.
 	// applicationID: Mandatory. The unique identifier of the tenant's application will be owning the address.
 	// addressID: Mandatory. The unique identifier of the existing address.
 	// address: Mandatory. The reeference to the updated address information.
-	// Returns error if something goes wrong.
-	Update(tenantID, applicationID, addressID system.UUID, address Address) error
+	// expectedVersion: Optional. The version the address is expected to have, or AnyVersion to skip the version check.
+	// changedBy: Optional. Identifies who makes the change, recorded in the address history.
+	// Returns ConflictError if the address version does not match the expected version, or error if something goes wrong.
+	Update(ctx context.Context, tenantID, applicationID, addressID system.UUID, address Address, expectedVersion int64, changedBy string) error
+
+	// Patch changes only the provided details of an existing address and leaves the rest of them unchanged.
+	// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+	// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+	// applicationID: Mandatory. The unique identifier of the tenant's application will be owning the address.
+	// addressID: Mandatory. The unique identifier of the existing address.
+	// set: Optional. The address details keys to add or change along with their new values.
+	// remove: Optional. The address details keys to remove.
+	// expectedVersion: Optional. The version the address is expected to have, or AnyVersion to skip the version check.
+	// changedBy: Optional. Identifies who makes the change, recorded in the address history.
+	// Returns ConflictError if the address version does not match the expected version, or error if something goes wrong.
+	Patch(ctx context.Context, tenantID, applicationID, addressID system.UUID, set map[string]string, remove []string, expectedVersion int64, changedBy string) error
 
 	// Read retrieves an existing address information and returns only the detail which the keys provided by the keys.
+	// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
 	// tenantID: Mandatory. The unique identifier of the tenant owning the address.
 	// applicationID: Mandatory. The unique identifier of the tenant's application will be owning the address.
 	// addressID: Mandatory. The unique identifier of the existing address.
 	// keys: Mandatory. The interested address details keys to return.
 	// Returns either the address information or error if something goes wrong.
-	Read(tenantID, applicationID, addressID system.UUID, keys []string) (Address, error)
+	Read(ctx context.Context, tenantID, applicationID, addressID system.UUID, keys []string) (Address, error)
 
 	// ReadAll retrieves an existing address information and returns all the detail of it.
+	// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
 	// tenantID: Mandatory. The unique identifier of the tenant owning the address.
 	// applicationID: Mandatory. The unique identifier of the tenant's application will be owning the address.
 	// addressID: Mandatory. The unique identifier of the existing address.
 	// Returns either the address information or error if something goes wrong.
-	ReadAll(tenantID, applicationID, addressID system.UUID) (Address, error)
+	ReadAll(ctx context.Context, tenantID, applicationID, addressID system.UUID) (Address, error)
+
+	// ReadMany retrieves several existing addresses information. Every address is read on its own, so failing to read an
+	// address does not stop reading the rest of them.
+	// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+	// tenantID: Mandatory. The unique identifier of the tenant owning the addresses.
+	// applicationID: Mandatory. The unique identifier of the tenant's application owning the addresses.
+	// addressIDs: Mandatory. The unique identifiers of the existing addresses.
+	// keys: Optional. The interested address details keys to return, or empty to return all the details.
+	// Returns the result of reading every address in the same order as addressIDs.
+	ReadMany(ctx context.Context, tenantID, applicationID system.UUID, addressIDs []system.UUID, keys []string) []AddressResult
+
+	// List retrieves a page of the addresses owned by a tenant's application. The deleted addresses are not returned.
+	// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+	// tenantID: Mandatory. The unique identifier of the tenant owning the addresses.
+	// applicationID: Mandatory. The unique identifier of the tenant's application owning the addresses.
+	// pageSize: Mandatory. The maximum number of addresses to return.
+	// cursor: Optional. The cursor returned with the previous page, or empty to return the first page.
+	// Returns either the page of addresses or error if the cursor is invalid or something goes wrong.
+	List(ctx context.Context, tenantID, applicationID system.UUID, pageSize int, cursor string) (AddressPage, error)
 
-	// Delete deletes an existing address information.
+	// FindByDetail retrieves the addresses owned by a tenant's application whose details match all the provided criteria.
+	// The deleted addresses are not returned, and no more than MaxFoundAddresses addresses are returned.
+	// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+	// tenantID: Mandatory. The unique identifier of the tenant owning the addresses.
+	// applicationID: Mandatory. The unique identifier of the tenant's application owning the addresses.
+	// criteria: Mandatory. The address details keys along with the values the returned addresses must have.
+	// Returns either the matching addresses or error if something goes wrong.
+	FindByDetail(ctx context.Context, tenantID, applicationID system.UUID, criteria map[string]string) ([]ListedAddress, error)
+
+	// FindByFingerprint retrieves the addresses owned by a tenant's application which were created or last updated with
+	// the provided fingerprint. The deleted addresses are not returned. An address patched since keeps its fingerprint, so
+	// the caller must compare the returned details if it needs them to still match the fingerprint.
+	// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+	// tenantID: Mandatory. The unique identifier of the tenant owning the addresses.
+	// applicationID: Mandatory. The unique identifier of the tenant's application owning the addresses.
+	// fingerprint: Mandatory. The fingerprint the returned addresses were created or last updated with, not its hash.
+	// Returns either the matching addresses or error if something goes wrong.
+	FindByFingerprint(ctx context.Context, tenantID, applicationID system.UUID, fingerprint string) ([]ListedAddress, error)
+
+	// AddOwners links an existing address to the provided owners. The owners already linked to the address are kept.
+	// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+	// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+	// applicationID: Mandatory. The unique identifier of the tenant's application owning the address.
+	// addressID: Mandatory. The unique identifier of the existing address.
+	// owners: Mandatory. The owners to link the address to.
+	// Returns error if the address does not exist, or if something goes wrong.
+	AddOwners(ctx context.Context, tenantID, applicationID, addressID system.UUID, owners []AddressOwner) error
+
+	// RemoveOwners unlinks an existing address from the provided owners. The owners not linked to the address are skipped.
+	// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+	// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+	// applicationID: Mandatory. The unique identifier of the tenant's application owning the address.
+	// addressID: Mandatory. The unique identifier of the existing address.
+	// owners: Mandatory. The owners to unlink the address from.
+	// Returns error if the address does not exist, or if something goes wrong.
+	RemoveOwners(ctx context.Context, tenantID, applicationID, addressID system.UUID, owners []AddressOwner) error
+
+	// ReadOwners retrieves the owners an existing address is linked to.
+	// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+	// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+	// applicationID: Mandatory. The unique identifier of the tenant's application owning the address.
+	// addressID: Mandatory. The unique identifier of the existing address.
+	// Returns either the owners ordered by their type and identifier or error if the address does not exist or something
+	// goes wrong.
+	ReadOwners(ctx context.Context, tenantID, applicationID, addressID system.UUID) ([]AddressOwner, error)
+
+	// FindByOwner retrieves the addresses owned by a tenant's application which are linked to the provided owner. The
+	// deleted addresses are not returned.
+	// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+	// tenantID: Mandatory. The unique identifier of the tenant owning the addresses.
+	// applicationID: Mandatory. The unique identifier of the tenant's application owning the addresses.
+	// owner: Mandatory. The owner the returned addresses are linked to.
+	// Returns either the linked addresses ordered by their unique identifier or error if something goes wrong.
+	FindByOwner(ctx context.Context, tenantID, applicationID system.UUID, owner AddressOwner) ([]ListedAddress, error)
+
+	// History retrieves all the recorded versions of an address, including the versions recorded when it was deleted and
+	// restored. The history is kept after the address is purged.
+	// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+	// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+	// applicationID: Mandatory. The unique identifier of the tenant's application will be owning the address.
+	// addressID: Mandatory. The unique identifier of the address.
+	// Returns either the recorded versions ordered from the oldest to the newest or error if no version is recorded or
+	// something goes wrong.
+	History(ctx context.Context, tenantID, applicationID, addressID system.UUID) ([]AddressHistoryEntry, error)
+
+	// ReadAt retrieves the address information as it was at the provided time.
+	// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+	// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+	// applicationID: Mandatory. The unique identifier of the tenant's application will be owning the address.
+	// addressID: Mandatory. The unique identifier of the address.
+	// timestamp: Mandatory. The time to return the address information at.
+	// Returns either the address information or error if the address did not exist or was deleted at the provided time, or
+	// if something goes wrong.
+	ReadAt(ctx context.Context, tenantID, applicationID, addressID system.UUID, timestamp time.Time) (Address, error)
+
+	// Delete marks an existing address as deleted. The deleted address is not returned anymore but it can be restored
+	// until it is purged.
+	// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
 	// tenantID: Mandatory. The unique identifier of the tenant owning the address.
 	// applicationID: Mandatory. The unique identifier of the tenant's application will be owning the address.
 	// addressID: Mandatory. The unique identifier of the existing address to remove.
+	// expectedVersion: Optional. The version the address is expected to have, or AnyVersion to skip the version check.
+	// changedBy: Optional. Identifies who makes the change, recorded in the address history.
+	// Returns ConflictError if the address version does not match the expected version, or error if something goes wrong.
+	Delete(ctx context.Context, tenantID, applicationID, addressID system.UUID, expectedVersion int64, changedBy string) error
+
+	// DeleteMany marks several existing addresses as deleted, skipping the version check. Every address is deleted on its
+	// own, so failing to delete an address does not stop deleting the rest of them.
+	// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+	// tenantID: Mandatory. The unique identifier of the tenant owning the addresses.
+	// applicationID: Mandatory. The unique identifier of the tenant's application owning the addresses.
+	// addressIDs: Mandatory. The unique identifiers of the existing addresses to remove.
+	// changedBy: Optional. Identifies who makes the change, recorded in the address history.
+	// Returns the result of deleting every address in the same order as addressIDs.
+	DeleteMany(ctx context.Context, tenantID, applicationID system.UUID, addressIDs []system.UUID, changedBy string) []AddressResult
+
+	// Restore restores a deleted address.
+	// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+	// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+	// applicationID: Mandatory. The unique identifier of the tenant's application will be owning the address.
+	// addressID: Mandatory. The unique identifier of the deleted address to restore.
+	// deletedSince: Mandatory. The address is restored only if it was deleted at or after this time.
+	// changedBy: Optional. Identifies who makes the change, recorded in the address history.
+	// Returns error if the deleted address does not exist or was deleted before deletedSince, or if something goes wrong.
+	Restore(ctx context.Context, tenantID, applicationID, addressID system.UUID, deletedSince time.Time, changedBy string) error
+
+	// Purge removes a deleted address for good.
+	// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+	// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+	// applicationID: Mandatory. The unique identifier of the tenant's application will be owning the address.
+	// addressID: Mandatory. The unique identifier of the deleted address to purge.
+	// Returns error if the deleted address does not exist, or if something goes wrong.
+	Purge(ctx context.Context, tenantID, applicationID, addressID system.UUID) error
+
+	// PurgeDeleted removes for good all the addresses of all tenants deleted before the provided time.
+	// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+	// deletedBefore: Mandatory. The addresses deleted before this time are purged.
+	// Returns error if something goes wrong.
+	PurgeDeleted(ctx context.Context, deletedBefore time.Time) error
+
+	// PurgeTenant removes for good all the addresses of all the applications of a tenant along with their history, whether
+	// they are deleted or not, and records an erasure certificate. The rows are removed in chunks and the progress is
+	// recorded after every chunk, so an interrupted erasure continues where it stopped when it is run again.
+	// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+	// tenantID: Mandatory. The unique identifier of the tenant to erase.
+	// requestedBy: Optional. Identifies who requests the erasure, recorded in the erasure certificate.
+	// Returns either the erasure certificate or error if something goes wrong.
+	PurgeTenant(ctx context.Context, tenantID system.UUID, requestedBy string) (ErasureCertificate, error)
+
+	// PurgeApplication removes for good all the addresses of a tenant's application along with their history, whether they
+	// are deleted or not, and records an erasure certificate. The rows are removed in chunks and the progress is recorded
+	// after every chunk, so an interrupted erasure continues where it stopped when it is run again.
+	// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+	// tenantID: Mandatory. The unique identifier of the tenant owning the application.
+	// applicationID: Mandatory. The unique identifier of the tenant's application to erase.
+	// requestedBy: Optional. Identifies who requests the erasure, recorded in the erasure certificate.
+	// Returns either the erasure certificate or error if something goes wrong.
+	PurgeApplication(ctx context.Context, tenantID, applicationID system.UUID, requestedBy string) (ErasureCertificate, error)
+
+	// ReadEvents retrieves the events waiting in the outbox to be published. The events of an address are returned in the
+	// order the address was changed in.
+	// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+	// limit: Mandatory. The maximum number of events to return.
+	// Returns either the events or error if something goes wrong.
+	ReadEvents(ctx context.Context, limit int) ([]AddressEvent, error)
+
+	// RemoveEvents removes the published events from the outbox. The outbox may be read past the latest of the provided
+	// events, so all the events returned by ReadEvents must be published before they are removed.
+	// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+	// events: Mandatory. The events returned by ReadEvents which are published.
 	// Returns error if something goes wrong.
-	Delete(tenantID, applicationID, addressID system.UUID) error
+	RemoveEvents(ctx context.Context, events []AddressEvent) error
 }
@@ -0,0 +1,136 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/micro-business/Micro-Business-Core/common/diagnostics"
+)
+
+// KeyProvider wraps and unwraps the tenants' data keys with master keys kept outside of the data store, e.g. in a key
+// management service.
+type KeyProvider interface {
+	// WrapKey encrypts a data key with the current master key.
+	// dataKey: Mandatory. The data key to encrypt.
+	// Returns either the identifier of the master key used along with the wrapped data key, or error if something goes
+	// wrong.
+	WrapKey(dataKey []byte) (string, []byte, error)
+
+	// UnwrapKey decrypts a data key wrapped by one of the master keys.
+	// masterKeyID: Mandatory. The identifier of the master key returned when the data key was wrapped.
+	// wrappedKey: Mandatory. The wrapped data key.
+	// Returns either the data key or error if the master key is unknown or something goes wrong.
+	UnwrapKey(masterKeyID string, wrappedKey []byte) ([]byte, error)
+}
+
+// LocalFileKeyProvider keeps the master keys in a local JSON file, e.g.
+// {"currentKeyId": "2024-01", "keys": {"2024-01": "<base64 encoded 32 bytes key>"}}. New data keys are wrapped with the
+// current key, and the former keys must be kept in the file as long as data keys wrapped by them exist. The file is read
+// on every call, so the master key can be rotated without restarting the service.
+type LocalFileKeyProvider struct {
+	Path string
+}
+
+// localMasterKeys is the content of the file read by LocalFileKeyProvider.
+type localMasterKeys struct {
+	CurrentKeyID string            `json:"currentKeyId"`
+	Keys         map[string]string `json:"keys"`
+}
+
+// WrapKey encrypts a data key with the current master key.
+// dataKey: Mandatory. The data key to encrypt.
+// Returns either the identifier of the master key used along with the wrapped data key, or error if something goes
+// wrong.
+func (keyProvider LocalFileKeyProvider) WrapKey(dataKey []byte) (string, []byte, error) {
+	masterKeys, err := keyProvider.readMasterKeys()
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	aead, err := masterKeys.getAEAD(masterKeys.CurrentKeyID)
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", nil, err
+	}
+
+	return masterKeys.CurrentKeyID, aead.Seal(nonce, nonce, dataKey, []byte(masterKeys.CurrentKeyID)), nil
+}
+
+// UnwrapKey decrypts a data key wrapped by one of the master keys.
+// masterKeyID: Mandatory. The identifier of the master key returned when the data key was wrapped.
+// wrappedKey: Mandatory. The wrapped data key.
+// Returns either the data key or error if the master key is unknown or something goes wrong.
+func (keyProvider LocalFileKeyProvider) UnwrapKey(masterKeyID string, wrappedKey []byte) ([]byte, error) {
+	masterKeys, err := keyProvider.readMasterKeys()
+
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := masterKeys.getAEAD(masterKeyID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(wrappedKey) < aead.NonceSize() {
+		return nil, fmt.Errorf("Wrapped data key is too short. Master key ID: %s", masterKeyID)
+	}
+
+	return aead.Open(nil, wrappedKey[:aead.NonceSize()], wrappedKey[aead.NonceSize():], []byte(masterKeyID))
+}
+
+// readMasterKeys reads the master keys from the file.
+func (keyProvider LocalFileKeyProvider) readMasterKeys() (localMasterKeys, error) {
+	diagnostics.IsNotNilOrEmpty(keyProvider.Path, "keyProvider.Path", "Path must be provided.")
+
+	content, err := ioutil.ReadFile(keyProvider.Path)
+
+	if err != nil {
+		return localMasterKeys{}, err
+	}
+
+	var masterKeys localMasterKeys
+
+	if err = json.Unmarshal(content, &masterKeys); err != nil {
+		return localMasterKeys{}, fmt.Errorf("Master keys file cannot be read. Error: %s", err.Error())
+	}
+
+	return masterKeys, nil
+}
+
+// getAEAD returns the cipher encrypting with the master key with the provided identifier.
+func (masterKeys localMasterKeys) getAEAD(masterKeyID string) (cipher.AEAD, error) {
+	encodedKey, ok := masterKeys.Keys[masterKeyID]
+
+	if !ok {
+		return nil, fmt.Errorf("Unknown master key. Master key ID: %s", masterKeyID)
+	}
+
+	key, err := base64.StdEncoding.DecodeString(encodedKey)
+
+	if err != nil {
+		return nil, fmt.Errorf("Invalid master key. Master key ID: %s, error: %s", masterKeyID, err.Error())
+	}
+
+	block, err := aes.NewCipher(key)
+
+	if err != nil {
+		return nil, fmt.Errorf("Invalid master key. Master key ID: %s, error: %s", masterKeyID, err.Error())
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,135 @@
+package encryption_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/micro-business/AddressService/data/encryption"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LocalFileKeyProvider input parameters and dependency test", func() {
+	Context("when path not provided", func() {
+		It("should panic", func() {
+			keyProvider := encryption.LocalFileKeyProvider{}
+
+			Ω(func() { keyProvider.WrapKey([]byte("data key")) }).Should(Panic())
+		})
+	})
+})
+
+var _ = Describe("LocalFileKeyProvider behaviour", func() {
+	var (
+		directory   string
+		keyProvider encryption.LocalFileKeyProvider
+		dataKey     encryption.DataKey
+	)
+
+	writeMasterKeys := func(currentKeyID string, keyIDs ...string) {
+		keys := make(map[string]string)
+
+		for _, keyID := range keyIDs {
+			key := make([]byte, 32)
+			copy(key, keyID)
+			keys[keyID] = base64.StdEncoding.EncodeToString(key)
+		}
+
+		content, _ := json.Marshal(map[string]interface{}{"currentKeyId": currentKeyID, "keys": keys})
+
+		Expect(ioutil.WriteFile(keyProvider.Path, content, 0600)).To(BeNil())
+	}
+
+	BeforeEach(func() {
+		var err error
+
+		directory, err = ioutil.TempDir("", "master-keys")
+
+		Expect(err).To(BeNil())
+
+		keyProvider = encryption.LocalFileKeyProvider{Path: filepath.Join(directory, "master-keys.json")}
+		dataKey, _ = encryption.NewDataKey(1)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(directory)
+	})
+
+	Context("when wrapping a data key", func() {
+		It("should return the current master key ID and a wrapped key that unwraps to the data key", func() {
+			writeMasterKeys("2024-01", "2024-01")
+
+			masterKeyID, wrappedKey, err := keyProvider.WrapKey(dataKey.Key)
+
+			Expect(err).To(BeNil())
+			Expect(masterKeyID).To(Equal("2024-01"))
+			Expect(wrappedKey).NotTo(Equal(dataKey.Key))
+
+			unwrappedKey, err := keyProvider.UnwrapKey(masterKeyID, wrappedKey)
+
+			Expect(err).To(BeNil())
+			Expect(unwrappedKey).To(Equal(dataKey.Key))
+		})
+	})
+
+	Context("when the master key is rotated", func() {
+		It("should wrap with the new master key and still unwrap the keys wrapped with the former one", func() {
+			writeMasterKeys("2024-01", "2024-01")
+
+			formerMasterKeyID, formerWrappedKey, _ := keyProvider.WrapKey(dataKey.Key)
+
+			writeMasterKeys("2024-02", "2024-01", "2024-02")
+
+			masterKeyID, _, err := keyProvider.WrapKey(dataKey.Key)
+
+			Expect(err).To(BeNil())
+			Expect(masterKeyID).To(Equal("2024-02"))
+
+			unwrappedKey, err := keyProvider.UnwrapKey(formerMasterKeyID, formerWrappedKey)
+
+			Expect(err).To(BeNil())
+			Expect(unwrappedKey).To(Equal(dataKey.Key))
+		})
+
+		It("should return error unwrapping a key wrapped with a removed master key", func() {
+			writeMasterKeys("2024-01", "2024-01")
+
+			formerMasterKeyID, formerWrappedKey, _ := keyProvider.WrapKey(dataKey.Key)
+
+			writeMasterKeys("2024-02", "2024-02")
+
+			_, err := keyProvider.UnwrapKey(formerMasterKeyID, formerWrappedKey)
+
+			Expect(err).NotTo(BeNil())
+		})
+	})
+
+	Context("when the wrapped key is unwrapped with another master key ID", func() {
+		It("should return error", func() {
+			writeMasterKeys("2024-01", "2024-01", "2024-02")
+
+			_, wrappedKey, _ := keyProvider.WrapKey(dataKey.Key)
+
+			_, err := keyProvider.UnwrapKey("2024-02", wrappedKey)
+
+			Expect(err).NotTo(BeNil())
+		})
+	})
+
+	Context("when the master keys file does not exist", func() {
+		It("should return error", func() {
+			_, _, err := keyProvider.WrapKey(dataKey.Key)
+
+			Expect(err).NotTo(BeNil())
+		})
+	})
+})
+
+func TestLocalFileKeyProvider(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "LocalFileKeyProvider behaviour")
+}
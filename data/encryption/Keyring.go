@@ -0,0 +1,320 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/micro-business/AddressService/data/contract"
+	"github.com/micro-business/Micro-Business-Core/system"
+)
+
+// encryptedValuePrefix starts every encrypted value, followed by the data key version, ':' and the base64 encoded nonce
+// and ciphertext. Values not starting with it are stored in plaintext, e.g. they were written before encryption was
+// enabled.
+const encryptedValuePrefix = contract.EncryptedValuePrefix
+
+// dataKeySize is the size of the data keys in bytes, used as AES-256 keys.
+const dataKeySize = 32
+
+// hashKeyLabel is used to derive the key hashing the values from a data key, so the same key is not used for both
+// encrypting and hashing.
+const hashKeyLabel = "address_value_hash"
+
+// DataKey is a version of a tenant's key used to encrypt and hash the address values.
+type DataKey struct {
+	Version int
+	Key     []byte
+}
+
+// Keyring holds all the data key versions of a tenant. The values are encrypted and hashed with the current version and
+// decrypted with the version they were encrypted with. A nil keyring leaves the values in plaintext.
+type Keyring struct {
+	TenantID       system.UUID
+	DataKeys       map[int]DataKey
+	CurrentVersion int
+}
+
+// WrappedDataKey is a data key version of a tenant as it is stored, wrapped with a master key.
+type WrappedDataKey struct {
+	Version     int
+	MasterKeyID string
+	WrappedKey  []byte
+}
+
+// UnknownDataKeyError is returned when a value is encrypted or hashed with a data key version the keyring does not hold,
+// e.g. the version was added after the keyring was read.
+type UnknownDataKeyError struct {
+	Version int
+}
+
+// Error returns the error message.
+func (err UnknownDataKeyError) Error() string {
+	return fmt.Sprintf("Unknown data key. Data key version: %d", err.Version)
+}
+
+// DecryptionError is returned when an encrypted value cannot be decrypted with the data key it was encrypted with, e.g. the
+// value is corrupted or no keyring is available.
+type DecryptionError struct {
+	Err error
+}
+
+// Error returns the error message.
+func (err DecryptionError) Error() string {
+	return fmt.Sprintf("Value cannot be decrypted. %s", err.Err.Error())
+}
+
+// NewDataKey generates a new random data key.
+// version: Mandatory. The version of the new data key.
+// Returns either the new data key or error if something goes wrong.
+func NewDataKey(version int) (DataKey, error) {
+	key := make([]byte, dataKeySize)
+
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return DataKey{}, err
+	}
+
+	return DataKey{Version: version, Key: key}, nil
+}
+
+// UnwrapKeyring unwraps the provided data key versions of a tenant with the key provider. The latest version is the
+// current one.
+// tenantID: Mandatory. The unique identifier of the tenant owning the data keys.
+// wrappedDataKeys: Mandatory. The data key versions of the tenant as they are stored.
+// keyProvider: Mandatory. The key provider holding the master keys the data keys are wrapped with.
+// Returns either the keyring, holding no data key if none is provided, or error if a data key cannot be unwrapped.
+func UnwrapKeyring(tenantID system.UUID, wrappedDataKeys []WrappedDataKey, keyProvider KeyProvider) (*Keyring, error) {
+	keyring := &Keyring{TenantID: tenantID, DataKeys: make(map[int]DataKey, len(wrappedDataKeys))}
+
+	for _, wrappedDataKey := range wrappedDataKeys {
+		key, err := keyProvider.UnwrapKey(wrappedDataKey.MasterKeyID, wrappedDataKey.WrappedKey)
+
+		if err != nil {
+			return nil, err
+		}
+
+		keyring.DataKeys[wrappedDataKey.Version] = DataKey{Version: wrappedDataKey.Version, Key: key}
+
+		if wrappedDataKey.Version > keyring.CurrentVersion {
+			keyring.CurrentVersion = wrappedDataKey.Version
+		}
+	}
+
+	return keyring, nil
+}
+
+// IsEncrypted returns true if the provided value is encrypted.
+func IsEncrypted(value string) bool {
+	_, _, ok := parseEncryptedValue(value)
+
+	return ok
+}
+
+// Encrypt encrypts the provided value with the current data key. Encrypting the same value twice returns different
+// results.
+// value: Mandatory. The value to encrypt.
+// Returns either the encrypted value or error if something goes wrong.
+func (keyring *Keyring) Encrypt(value string) (string, error) {
+	if keyring == nil {
+		return value, nil
+	}
+
+	aead, err := keyring.getAEAD(keyring.CurrentVersion)
+
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+
+	sealed := aead.Seal(nonce, nonce, []byte(value), keyring.TenantID.Bytes())
+
+	return encryptedValuePrefix + strconv.Itoa(keyring.CurrentVersion) + ":" + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt decrypts the provided value with the data key it was encrypted with. A plaintext value is returned as it is.
+// value: Mandatory. The value to decrypt.
+// Returns either the decrypted value, or UnknownDataKeyError if the data key is unknown, or DecryptionError if the value
+// cannot be decrypted.
+func (keyring *Keyring) Decrypt(value string) (string, error) {
+	version, sealed, ok := parseEncryptedValue(value)
+
+	if !ok {
+		return value, nil
+	}
+
+	if keyring == nil {
+		return "", DecryptionError{Err: fmt.Errorf("Value is encrypted but no data key is available. Data key version: %d", version)}
+	}
+
+	aead, err := keyring.getAEAD(version)
+
+	if _, ok := err.(UnknownDataKeyError); ok {
+		return "", err
+	}
+
+	if err != nil {
+		return "", DecryptionError{Err: err}
+	}
+
+	if len(sealed) < aead.NonceSize() {
+		return "", DecryptionError{Err: fmt.Errorf("Encrypted value is too short. Data key version: %d", version)}
+	}
+
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], keyring.TenantID.Bytes())
+
+	if err != nil {
+		return "", DecryptionError{Err: err}
+	}
+
+	return string(plaintext), nil
+}
+
+// Hash returns the keyed hash of an address detail computed with the current data key, so equal values of the same key
+// have equal hashes while the values cannot be recovered from the hashes. Empty is returned by a nil keyring.
+// addressKey: Mandatory. The address detail key.
+// value: Mandatory. The address detail value.
+// Returns either the hash or error if something goes wrong.
+func (keyring *Keyring) Hash(addressKey, value string) (string, error) {
+	if keyring == nil {
+		return "", nil
+	}
+
+	return keyring.hash(keyring.CurrentVersion, addressKey, value)
+}
+
+// Hashes returns the keyed hashes of an address detail computed with every data key version, ordered by version, so the
+// hashes stored before the current data key version was added can be looked for too. Nil is returned by a nil keyring.
+// addressKey: Mandatory. The address detail key.
+// value: Mandatory. The address detail value.
+// Returns either the hashes or error if something goes wrong.
+func (keyring *Keyring) Hashes(addressKey, value string) ([]string, error) {
+	if keyring == nil {
+		return nil, nil
+	}
+
+	versions := make([]int, 0, len(keyring.DataKeys))
+
+	for version := range keyring.DataKeys {
+		versions = append(versions, version)
+	}
+
+	sort.Ints(versions)
+
+	hashes := make([]string, 0, len(versions))
+
+	for _, version := range versions {
+		hash, err := keyring.hash(version, addressKey, value)
+
+		if err != nil {
+			return nil, err
+		}
+
+		hashes = append(hashes, hash)
+	}
+
+	return hashes, nil
+}
+
+// Matches returns true if the stored address detail has the provided value. The stored hash is compared when it is
+// available, otherwise the stored value is decrypted and compared.
+// addressKey: Mandatory. The address detail key.
+// value: Mandatory. The value to look for.
+// storedValue: Mandatory. The stored address detail value, either encrypted or in plaintext.
+// storedHash: Optional. The hash stored along with the value, or empty if no hash is stored.
+// Returns either whether the values match or error if the stored value cannot be decrypted.
+func (keyring *Keyring) Matches(addressKey, value, storedValue, storedHash string) (bool, error) {
+	if version, _, ok := parseEncryptedValue(storedValue); ok && keyring != nil && len(storedHash) != 0 {
+		hash, err := keyring.hash(version, addressKey, value)
+
+		if err != nil {
+			return false, err
+		}
+
+		return hmac.Equal([]byte(hash), []byte(storedHash)), nil
+	}
+
+	decryptedValue, err := keyring.Decrypt(storedValue)
+
+	if err != nil {
+		return false, err
+	}
+
+	return decryptedValue == value, nil
+}
+
+// hash returns the keyed hash of an address detail computed with the provided data key version.
+func (keyring *Keyring) hash(version int, addressKey, value string) (string, error) {
+	dataKey, ok := keyring.DataKeys[version]
+
+	if !ok {
+		return "", UnknownDataKeyError{Version: version}
+	}
+
+	hashKeyMAC := hmac.New(sha256.New, dataKey.Key)
+	hashKeyMAC.Write([]byte(hashKeyLabel))
+
+	mac := hmac.New(sha256.New, hashKeyMAC.Sum(nil))
+	mac.Write([]byte(addressKey))
+	mac.Write([]byte{0})
+	mac.Write([]byte(value))
+
+	return base64.RawStdEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// getAEAD returns the cipher encrypting with the provided data key version.
+func (keyring *Keyring) getAEAD(version int) (cipher.AEAD, error) {
+	dataKey, ok := keyring.DataKeys[version]
+
+	if !ok {
+		return nil, UnknownDataKeyError{Version: version}
+	}
+
+	block, err := aes.NewCipher(dataKey.Key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// parseEncryptedValue returns the data key version and the nonce and ciphertext of an encrypted value.
+// Returns false if the value is not encrypted.
+func parseEncryptedValue(value string) (int, []byte, bool) {
+	if !strings.HasPrefix(value, encryptedValuePrefix) {
+		return 0, nil, false
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(value, encryptedValuePrefix), ":", 2)
+
+	if len(parts) != 2 {
+		return 0, nil, false
+	}
+
+	version, err := strconv.Atoi(parts[0])
+
+	if err != nil {
+		return 0, nil, false
+	}
+
+	sealed, err := base64.RawStdEncoding.DecodeString(parts[1])
+
+	if err != nil {
+		return 0, nil, false
+	}
+
+	return version, sealed, true
+}
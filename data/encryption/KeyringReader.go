@@ -0,0 +1,39 @@
+package encryption
+
+import (
+	"github.com/gocql/gocql"
+	"github.com/micro-business/Micro-Business-Core/system"
+	"golang.org/x/net/context"
+)
+
+// ReadKeyring reads the data key versions of a tenant from tenant_data_key table of the keyspace the session is connected
+// to and unwraps them with the key provider. The latest version is the current one.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// session: Mandatory. The session to the Cassandra cluster.
+// tenantID: Mandatory. The unique identifier of the tenant whose keyring to read.
+// keyProvider: Mandatory. The key provider holding the master keys the data keys are wrapped with.
+// Returns either the keyring, holding no data key if the tenant has none, or error if something goes wrong.
+func ReadKeyring(ctx context.Context, session *gocql.Session, tenantID system.UUID, keyProvider KeyProvider) (*Keyring, error) {
+	mappedTenantID, _ := gocql.UUIDFromBytes(tenantID.Bytes())
+
+	iter := session.Query(
+		"SELECT version, master_key_id, wrapped_key"+
+			" FROM tenant_data_key"+
+			" WHERE tenant_id = ?",
+		mappedTenantID).WithContext(ctx).Iter()
+
+	wrappedDataKeys := []WrappedDataKey{}
+
+	var wrappedDataKey WrappedDataKey
+
+	for iter.Scan(&wrappedDataKey.Version, &wrappedDataKey.MasterKeyID, &wrappedDataKey.WrappedKey) {
+		wrappedDataKeys = append(wrappedDataKeys, wrappedDataKey)
+		wrappedDataKey = WrappedDataKey{}
+	}
+
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+
+	return UnwrapKeyring(tenantID, wrappedDataKeys, keyProvider)
+}
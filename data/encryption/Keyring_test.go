@@ -0,0 +1,248 @@
+package encryption_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/micro-business/AddressService/data/encryption"
+	"github.com/micro-business/Micro-Business-Core/system"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Keyring behaviour", func() {
+	var (
+		tenantID system.UUID
+		keyring  *encryption.Keyring
+	)
+
+	BeforeEach(func() {
+		tenantID, _ = system.RandomUUID()
+
+		dataKey, err := encryption.NewDataKey(1)
+
+		Expect(err).To(BeNil())
+
+		keyring = &encryption.Keyring{TenantID: tenantID, DataKeys: map[int]encryption.DataKey{1: dataKey}, CurrentVersion: 1}
+	})
+
+	Context("when encrypting a value", func() {
+		It("should return an encrypted value that decrypts to the original value", func() {
+			encryptedValue, err := keyring.Encrypt("Christchurch")
+
+			Expect(err).To(BeNil())
+			Expect(encryptedValue).NotTo(ContainSubstring("Christchurch"))
+			Expect(encryption.IsEncrypted(encryptedValue)).To(BeTrue())
+
+			decryptedValue, err := keyring.Decrypt(encryptedValue)
+
+			Expect(err).To(BeNil())
+			Expect(decryptedValue).To(Equal("Christchurch"))
+		})
+
+		It("should return a different encrypted value every time", func() {
+			firstEncryptedValue, _ := keyring.Encrypt("Christchurch")
+			secondEncryptedValue, _ := keyring.Encrypt("Christchurch")
+
+			Expect(firstEncryptedValue).NotTo(Equal(secondEncryptedValue))
+		})
+
+		It("should not decrypt the value with another tenant's keyring using the same data key", func() {
+			encryptedValue, _ := keyring.Encrypt("Christchurch")
+
+			otherTenantID, _ := system.RandomUUID()
+			otherKeyring := &encryption.Keyring{TenantID: otherTenantID, DataKeys: keyring.DataKeys, CurrentVersion: 1}
+
+			_, err := otherKeyring.Decrypt(encryptedValue)
+
+			Expect(err).NotTo(BeNil())
+		})
+
+		It("should not decrypt a tampered value", func() {
+			encryptedValue, _ := keyring.Encrypt("Christchurch")
+			tamperedValue := encryptedValue[:len(encryptedValue)-2] + strings.Repeat("A", 2)
+
+			if tamperedValue == encryptedValue {
+				tamperedValue = encryptedValue[:len(encryptedValue)-2] + strings.Repeat("B", 2)
+			}
+
+			_, err := keyring.Decrypt(tamperedValue)
+
+			Expect(err).To(BeAssignableToTypeOf(encryption.DecryptionError{}))
+		})
+	})
+
+	Context("when decrypting a plaintext value", func() {
+		It("should return the value as it is", func() {
+			decryptedValue, err := keyring.Decrypt("Christchurch")
+
+			Expect(err).To(BeNil())
+			Expect(decryptedValue).To(Equal("Christchurch"))
+			Expect(encryption.IsEncrypted("Christchurch")).To(BeFalse())
+		})
+	})
+
+	Context("when the data key is rotated", func() {
+		It("should encrypt with the new version and still decrypt the values encrypted with the former version", func() {
+			formerEncryptedValue, _ := keyring.Encrypt("Christchurch")
+
+			dataKey, _ := encryption.NewDataKey(2)
+			keyring.DataKeys[2] = dataKey
+			keyring.CurrentVersion = 2
+
+			encryptedValue, err := keyring.Encrypt("Christchurch")
+
+			Expect(err).To(BeNil())
+			Expect(encryptedValue).To(HavePrefix("enc:2:"))
+
+			decryptedValue, err := keyring.Decrypt(formerEncryptedValue)
+
+			Expect(err).To(BeNil())
+			Expect(decryptedValue).To(Equal("Christchurch"))
+		})
+
+		It("should return error decrypting a value encrypted with an unknown version", func() {
+			encryptedValue, _ := keyring.Encrypt("Christchurch")
+			delete(keyring.DataKeys, 1)
+
+			_, err := keyring.Decrypt(encryptedValue)
+
+			Expect(err).To(Equal(encryption.UnknownDataKeyError{Version: 1}))
+		})
+	})
+
+	Context("when hashing a value", func() {
+		It("should return the same hash for the same key and value", func() {
+			firstHash, err := keyring.Hash("City", "Christchurch")
+
+			Expect(err).To(BeNil())
+
+			secondHash, _ := keyring.Hash("City", "Christchurch")
+
+			Expect(firstHash).To(Equal(secondHash))
+			Expect(firstHash).NotTo(ContainSubstring("Christchurch"))
+		})
+
+		It("should return different hashes for different keys or values", func() {
+			hash, _ := keyring.Hash("City", "Christchurch")
+			otherKeyHash, _ := keyring.Hash("Suburb", "Christchurch")
+			otherValueHash, _ := keyring.Hash("City", "Wellington")
+
+			Expect(hash).NotTo(Equal(otherKeyHash))
+			Expect(hash).NotTo(Equal(otherValueHash))
+		})
+	})
+
+	Context("when matching a stored value", func() {
+		It("should compare the hashes if the hash is stored", func() {
+			encryptedValue, _ := keyring.Encrypt("Christchurch")
+			hash, _ := keyring.Hash("City", "Christchurch")
+
+			Expect(keyring.Matches("City", "Christchurch", encryptedValue, hash)).To(BeTrue())
+			Expect(keyring.Matches("City", "Wellington", encryptedValue, hash)).To(BeFalse())
+		})
+
+		It("should compare the hashes with the data key version the value is encrypted with", func() {
+			encryptedValue, _ := keyring.Encrypt("Christchurch")
+			hash, _ := keyring.Hash("City", "Christchurch")
+
+			dataKey, _ := encryption.NewDataKey(2)
+			keyring.DataKeys[2] = dataKey
+			keyring.CurrentVersion = 2
+
+			Expect(keyring.Matches("City", "Christchurch", encryptedValue, hash)).To(BeTrue())
+		})
+
+		It("should decrypt and compare the value if no hash is stored", func() {
+			encryptedValue, _ := keyring.Encrypt("Christchurch")
+
+			Expect(keyring.Matches("City", "Christchurch", encryptedValue, "")).To(BeTrue())
+			Expect(keyring.Matches("City", "Wellington", encryptedValue, "")).To(BeFalse())
+		})
+
+		It("should compare the plaintext value", func() {
+			Expect(keyring.Matches("City", "Christchurch", "Christchurch", "")).To(BeTrue())
+			Expect(keyring.Matches("City", "Wellington", "Christchurch", "")).To(BeFalse())
+		})
+	})
+
+	Context("when unwrapping the data keys of a tenant", func() {
+		It("should return the keyring holding all the versions with the latest one as the current one", func() {
+			firstDataKey, _ := encryption.NewDataKey(1)
+			secondDataKey, _ := encryption.NewDataKey(2)
+
+			unwrappedKeyring, err := encryption.UnwrapKeyring(
+				tenantID,
+				[]encryption.WrappedDataKey{
+					{Version: 2, MasterKeyID: "plain", WrappedKey: secondDataKey.Key},
+					{Version: 1, MasterKeyID: "plain", WrappedKey: firstDataKey.Key},
+				},
+				plainKeyProvider{})
+
+			Expect(err).To(BeNil())
+			Expect(unwrappedKeyring).To(Equal(&encryption.Keyring{
+				TenantID:       tenantID,
+				DataKeys:       map[int]encryption.DataKey{1: firstDataKey, 2: secondDataKey},
+				CurrentVersion: 2,
+			}))
+		})
+
+		It("should return error if a data key cannot be unwrapped", func() {
+			_, err := encryption.UnwrapKeyring(
+				tenantID,
+				[]encryption.WrappedDataKey{{Version: 1, MasterKeyID: "unknown", WrappedKey: []byte("data key")}},
+				plainKeyProvider{})
+
+			Expect(err).NotTo(BeNil())
+		})
+	})
+
+	Context("when keyring is not provided", func() {
+		It("should leave the values in plaintext", func() {
+			var noKeyring *encryption.Keyring
+
+			encryptedValue, err := noKeyring.Encrypt("Christchurch")
+
+			Expect(err).To(BeNil())
+			Expect(encryptedValue).To(Equal("Christchurch"))
+
+			hash, err := noKeyring.Hash("City", "Christchurch")
+
+			Expect(err).To(BeNil())
+			Expect(hash).To(BeEmpty())
+
+			Expect(noKeyring.Matches("City", "Christchurch", "Christchurch", "")).To(BeTrue())
+		})
+
+		It("should return error decrypting an encrypted value", func() {
+			var noKeyring *encryption.Keyring
+
+			encryptedValue, _ := keyring.Encrypt("Christchurch")
+
+			_, err := noKeyring.Decrypt(encryptedValue)
+
+			Expect(err).NotTo(BeNil())
+		})
+	})
+})
+
+// plainKeyProvider keeps the data keys unwrapped under "plain" master key.
+type plainKeyProvider struct{}
+
+func (plainKeyProvider) WrapKey(dataKey []byte) (string, []byte, error) {
+	return "plain", dataKey, nil
+}
+
+func (plainKeyProvider) UnwrapKey(masterKeyID string, wrappedKey []byte) ([]byte, error) {
+	if masterKeyID != "plain" {
+		return nil, errors.New("Unknown master key. Master key ID: " + masterKeyID)
+	}
+
+	return wrappedKey, nil
+}
+
+func TestKeyring(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Keyring behaviour")
+}
@@ -0,0 +1,210 @@
+// Code generated by cqlgen from the CQL files of cql directory. DO NOT EDIT.
+
+package migration
+
+// migrationFiles holds the contents of the CQL files of cql directory keyed by file name.
+var migrationFiles = map[string]string{
+	"0001_create_address_tables.down.cql": `DROP TABLE IF EXISTS address_indexed_by_address_key;
+
+DROP TABLE IF EXISTS address;
+`,
+	"0001_create_address_tables.up.cql": `CREATE TABLE IF NOT EXISTS address(
+    tenant_id UUID,
+    application_id UUID,
+    address_id UUID,
+    address_key text,
+    address_value text,
+    PRIMARY KEY(tenant_id, application_id, address_id, address_key)
+);
+
+CREATE TABLE IF NOT EXISTS address_indexed_by_address_key(
+    tenant_id UUID,
+    application_id UUID,
+    address_id UUID,
+    address_key text,
+    address_value text,
+    PRIMARY KEY(tenant_id, application_id, address_key, address_id)
+);
+`,
+	"0002_add_address_versions_and_soft_delete.down.cql": `DROP TABLE IF EXISTS address_metadata;
+`,
+	"0002_add_address_versions_and_soft_delete.up.cql": `CREATE TABLE IF NOT EXISTS address_metadata(
+    tenant_id UUID,
+    application_id UUID,
+    address_id UUID,
+    version bigint,
+    deleted_at timestamp,
+    PRIMARY KEY(tenant_id, application_id, address_id)
+);
+`,
+	"0003_add_address_history.down.cql": `DROP TABLE IF EXISTS address_history;
+`,
+	"0003_add_address_history.up.cql": `CREATE TABLE IF NOT EXISTS address_history(
+    tenant_id UUID,
+    application_id UUID,
+    address_id UUID,
+    version bigint,
+    address_details map<text, text>,
+    changed_at timestamp,
+    changed_by text,
+    deleted boolean,
+    PRIMARY KEY(tenant_id, application_id, address_id, version)
+);
+`,
+	"0004_add_address_value_encryption.down.cql": `DROP TABLE IF EXISTS tenant_data_key;
+
+ALTER TABLE address_indexed_by_address_key DROP address_value_hash;
+`,
+	"0004_add_address_value_encryption.up.cql": `ALTER TABLE address_indexed_by_address_key ADD address_value_hash text;
+
+CREATE TABLE IF NOT EXISTS tenant_data_key(
+    tenant_id UUID,
+    version int,
+    master_key_id text,
+    wrapped_key blob,
+    created_at timestamp,
+    PRIMARY KEY(tenant_id, version)
+);
+`,
+	"0005_add_erasure_certificates.down.cql": `DROP TABLE IF EXISTS erasure_certificate;
+`,
+	"0005_add_erasure_certificates.up.cql": `CREATE TABLE IF NOT EXISTS erasure_certificate(
+    tenant_id UUID,
+    application_id UUID,
+    certificate_id UUID,
+    requested_by text,
+    started_at timestamp,
+    completed_at timestamp,
+    erased_rows map<text, bigint>,
+    current_table text,
+    page_state blob,
+    PRIMARY KEY(tenant_id, application_id, certificate_id)
+);
+`,
+	"0006_add_address_event_outbox.down.cql": `DROP TABLE IF EXISTS address_event_outbox;
+`,
+	"0006_add_address_event_outbox.up.cql": `CREATE TABLE IF NOT EXISTS address_event_outbox(
+    shard int,
+    event_id timeuuid,
+    event_type text,
+    tenant_id UUID,
+    application_id UUID,
+    address_id UUID,
+    version bigint,
+    old_address_details map<text, text>,
+    new_address_details map<text, text>,
+    changed_by text,
+    PRIMARY KEY(shard, event_id)
+);
+`,
+	"0007_add_address_fingerprints.down.cql": `DROP TABLE IF EXISTS address_indexed_by_fingerprint;
+
+ALTER TABLE address_metadata DROP fingerprint;
+`,
+	"0007_add_address_fingerprints.up.cql": `ALTER TABLE address_metadata ADD fingerprint text;
+
+CREATE TABLE IF NOT EXISTS address_indexed_by_fingerprint(
+    tenant_id UUID,
+    application_id UUID,
+    fingerprint text,
+    address_id UUID,
+    PRIMARY KEY(tenant_id, application_id, fingerprint, address_id)
+);
+`,
+	"0008_add_address_owners.down.cql": `DROP TABLE IF EXISTS address_indexed_by_owner;
+
+DROP TABLE IF EXISTS address_owner;
+`,
+	"0008_add_address_owners.up.cql": `CREATE TABLE IF NOT EXISTS address_owner(
+    tenant_id UUID,
+    application_id UUID,
+    address_id UUID,
+    owner_type text,
+    owner_id text,
+    PRIMARY KEY(tenant_id, application_id, address_id, owner_type, owner_id)
+);
+
+CREATE TABLE IF NOT EXISTS address_indexed_by_owner(
+    tenant_id UUID,
+    application_id UUID,
+    owner_type text,
+    owner_id text,
+    address_id UUID,
+    PRIMARY KEY(tenant_id, application_id, owner_type, owner_id, address_id)
+);
+`,
+	"0009_add_address_event_dead_letters.down.cql": `DROP TABLE IF EXISTS address_event_dead_letter;
+`,
+	"0009_add_address_event_dead_letters.up.cql": `CREATE TABLE IF NOT EXISTS address_event_dead_letter(
+    tenant_id UUID,
+    application_id UUID,
+    event_id timeuuid,
+    event_type text,
+    address_id UUID,
+    version bigint,
+    old_address_details map<text, text>,
+    new_address_details map<text, text>,
+    changed_by text,
+    reason text,
+    PRIMARY KEY(tenant_id, application_id, event_id)
+);
+`,
+	"0010_backfill_address_metadata.up.cql": `-- The address metadata is backfilled by backfillAddressMetadata in Migrations.go.
+`,
+	"0011_index_addresses_by_value_hash.down.cql": `DROP TABLE IF EXISTS address_indexed_by_address_value;
+`,
+	"0011_index_addresses_by_value_hash.up.cql": `CREATE TABLE IF NOT EXISTS address_indexed_by_address_value(
+    tenant_id UUID,
+    application_id UUID,
+    address_key text,
+    address_value_hash text,
+    address_id UUID,
+    PRIMARY KEY(tenant_id, application_id, address_key, address_value_hash, address_id)
+);
+`,
+	"0012_bucket_deleted_addresses_by_hour.down.cql": `DROP TABLE IF EXISTS deleted_address_purge;
+
+DROP TABLE IF EXISTS deleted_address;
+`,
+	"0012_bucket_deleted_addresses_by_hour.up.cql": `CREATE TABLE IF NOT EXISTS deleted_address(
+    deleted_hour timestamp,
+    deleted_at timestamp,
+    tenant_id UUID,
+    application_id UUID,
+    address_id UUID,
+    PRIMARY KEY(deleted_hour, deleted_at, tenant_id, application_id, address_id)
+);
+
+CREATE TABLE IF NOT EXISTS deleted_address_purge(
+    name text,
+    next_hour timestamp,
+    PRIMARY KEY(name)
+);
+`,
+	"0013_bucket_address_event_outbox_by_hour.down.cql": `DROP TABLE IF EXISTS address_event_outbox_cursor;
+
+DROP TABLE IF EXISTS address_event_outbox_by_hour;
+`,
+	"0013_bucket_address_event_outbox_by_hour.up.cql": `CREATE TABLE IF NOT EXISTS address_event_outbox_by_hour(
+    shard int,
+    event_hour timestamp,
+    event_id timeuuid,
+    event_type text,
+    tenant_id UUID,
+    application_id UUID,
+    address_id UUID,
+    version bigint,
+    old_address_details map<text, text>,
+    new_address_details map<text, text>,
+    changed_by text,
+    PRIMARY KEY((shard, event_hour), event_id)
+);
+
+CREATE TABLE IF NOT EXISTS address_event_outbox_cursor(
+    shard int,
+    event_hour timestamp,
+    last_event_id timeuuid,
+    PRIMARY KEY(shard)
+);
+`,
+}
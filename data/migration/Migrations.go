@@ -0,0 +1,398 @@
+package migration
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gocql/gocql"
+	"golang.org/x/net/context"
+)
+
+// Migration is a versioned change to the Cassandra schema along with the statements reverting it.
+type Migration struct {
+	// Version orders the migrations. Migrations are applied in ascending and reverted in descending version order.
+	Version int
+
+	// Description is a short summary of the schema change, recorded in schema_migrations table.
+	Description string
+
+	// Up holds the CQL statements applying the schema change, run in order.
+	Up []string
+
+	// Down holds the CQL statements reverting the schema change, run in order. It is empty if the migration only changes
+	// the data, which is left as it is when the migration is reverted.
+	Down []string
+
+	// Backfill is run after the Up statements to change the data the CQL statements cannot change, e.g. writing rows read
+	// from another table. It must be safe to run again, as a migration failing after its backfill is run is run again. It
+	// must stop once the context is canceled, e.g. the lock is lost to another run.
+	Backfill func(ctx context.Context, session *gocql.Session) error
+}
+
+//go:generate go run cqlgen/main.go
+
+// migrationFileNamePattern matches the names of the CQL files of cql directory, e.g. 0001_create_address_tables.up.cql,
+// capturing the version, the description and the direction of the migration.
+var migrationFileNamePattern = regexp.MustCompile(`^([0-9]+)_([a-z0-9_]+)\.(up|down)\.cql$`)
+
+// Migrations holds all the address service schema migrations ordered by version, read from the CQL files of cql
+// directory built into the binary by go generate. A migration must never be changed once it is released, new schema
+// changes must be added as new files instead. The tables are created only if they do not exist, so a keyspace created by
+// the former hand-run script can adopt the migrations by running them.
+var Migrations = withBackfills(mustParseMigrations(migrationFiles), map[int]func(ctx context.Context, session *gocql.Session) error{
+	10: backfillAddressMetadata,
+	11: backfillAddressValueIndex,
+	12: backfillDeletedAddresses,
+	13: backfillAddressEventOutbox,
+})
+
+// addressEventOutboxShards is the number of shards the address event outbox is split into, the same as the address data
+// service writes the events to.
+const addressEventOutboxShards = 16
+
+// ParseMigrations reads the migrations from the contents of their CQL files. Every migration has an up file and, unless
+// it only changes the data, a down file named after its version and description, e.g.
+// 0001_create_address_tables.up.cql and 0001_create_address_tables.down.cql. The statements of a file are separated by
+// semicolons, and the lines starting with -- are comments.
+// files: Mandatory. The contents of the CQL files keyed by file name.
+// Returns either the migrations ordered by version or error if a file name is invalid or a down file has no up file.
+func ParseMigrations(files map[string]string) ([]Migration, error) {
+	migrationsByVersion := make(map[int]*Migration)
+	hasUpFile := make(map[int]bool)
+
+	for name, content := range files {
+		match := migrationFileNamePattern.FindStringSubmatch(name)
+
+		if match == nil {
+			return nil, fmt.Errorf("Invalid migration file name. Name: %s", name)
+		}
+
+		version, _ := strconv.Atoi(match[1])
+		migration, ok := migrationsByVersion[version]
+
+		if !ok {
+			description := strings.Replace(match[2], "_", " ", -1)
+			migration = &Migration{Version: version, Description: strings.ToUpper(description[:1]) + description[1:]}
+			migrationsByVersion[version] = migration
+		}
+
+		if match[3] == "up" {
+			migration.Up = parseStatements(content)
+			hasUpFile[version] = true
+		} else {
+			migration.Down = parseStatements(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(migrationsByVersion))
+
+	for version, migration := range migrationsByVersion {
+		if !hasUpFile[version] {
+			return nil, fmt.Errorf("Migration has no up file. Version: %d", version)
+		}
+
+		migrations = append(migrations, *migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// mustParseMigrations reads the migrations from the contents of their CQL files, panicking if they are invalid, as the
+// files are built into the binary.
+func mustParseMigrations(files map[string]string) []Migration {
+	migrations, err := ParseMigrations(files)
+
+	if err != nil {
+		panic(err)
+	}
+
+	return migrations
+}
+
+// withBackfills sets the backfill of the provided migrations keyed by version.
+// Returns the provided migrations.
+func withBackfills(migrations []Migration, backfills map[int]func(ctx context.Context, session *gocql.Session) error) []Migration {
+	for index := range migrations {
+		migrations[index].Backfill = backfills[migrations[index].Version]
+	}
+
+	return migrations
+}
+
+// parseStatements splits the content of a CQL file into its statements, leaving out the comments and empty statements.
+func parseStatements(content string) []string {
+	lines := []string{}
+
+	for _, line := range strings.Split(content, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "--") {
+			lines = append(lines, line)
+		}
+	}
+
+	statements := []string{}
+
+	for _, statement := range strings.Split(strings.Join(lines, "\n"), ";") {
+		if statement = strings.TrimSpace(statement); statement != "" {
+			statements = append(statements, statement)
+		}
+	}
+
+	return statements
+}
+
+// backfillAddressMetadata writes the first version to address_metadata table for every address created before the
+// versions were introduced, so they are listed and versioned like the rest of the addresses. The metadata rows are
+// written by lightweight transactions, so the rows written by the service in the meantime are kept, and they expire
+// along with the address.
+func backfillAddressMetadata(ctx context.Context, session *gocql.Session) error {
+	iter := session.Query("SELECT tenant_id, application_id, address_id, TTL(address_value) FROM address").WithContext(ctx).Iter()
+
+	var tenantID, applicationID, addressID gocql.UUID
+	var ttl int
+
+	backfill := func(tenantID, applicationID, addressID gocql.UUID, ttl int) error {
+		_, err := session.Query(
+			"INSERT INTO address_metadata"+
+				" (tenant_id, application_id, address_id, version)"+
+				" VALUES(?, ?, ?, 1)"+
+				" IF NOT EXISTS"+
+				" USING TTL ?",
+			tenantID,
+			applicationID,
+			addressID,
+			ttl).WithContext(ctx).MapScanCAS(make(map[string]interface{}))
+
+		return err
+	}
+
+	var lastTenantID, lastApplicationID, lastAddressID gocql.UUID
+	var lastTTL int
+
+	found := false
+
+	// The rows of an address are next to each other, as they are ordered by the address unique identifier and key.
+	for iter.Scan(&tenantID, &applicationID, &addressID, &ttl) {
+		if found && (tenantID != lastTenantID || applicationID != lastApplicationID || addressID != lastAddressID) {
+			if err := backfill(lastTenantID, lastApplicationID, lastAddressID, lastTTL); err != nil {
+				iter.Close()
+
+				return err
+			}
+
+			found = false
+		}
+
+		if !found || (ttl > 0 && (lastTTL == 0 || ttl < lastTTL)) {
+			lastTTL = ttl
+		}
+
+		lastTenantID, lastApplicationID, lastAddressID = tenantID, applicationID, addressID
+		found = true
+		ttl = 0
+	}
+
+	if err := iter.Close(); err != nil {
+		return err
+	}
+
+	if found {
+		return backfill(lastTenantID, lastApplicationID, lastAddressID, lastTTL)
+	}
+
+	return nil
+}
+
+// backfillAddressValueIndex writes every row of address_indexed_by_address_key table to
+// address_indexed_by_address_value table, keyed by the value hash, or by the value itself if it is stored in plaintext
+// and has no hash. The rows expire along with the address.
+func backfillAddressValueIndex(ctx context.Context, session *gocql.Session) error {
+	iter := session.Query(
+		"SELECT tenant_id, application_id, address_id, address_key, address_value, address_value_hash, TTL(address_value)" +
+			" FROM address_indexed_by_address_key").WithContext(ctx).Iter()
+
+	var tenantID, applicationID, addressID gocql.UUID
+	var key, value, hash string
+	var ttl int
+
+	for iter.Scan(&tenantID, &applicationID, &addressID, &key, &value, &hash, &ttl) {
+		if hash == "" {
+			hash = value
+		}
+
+		err := session.Query(
+			"INSERT INTO address_indexed_by_address_value"+
+				" (tenant_id, application_id, address_key, address_value_hash, address_id)"+
+				" VALUES(?, ?, ?, ?, ?)"+
+				" USING TTL ?",
+			tenantID,
+			applicationID,
+			key,
+			hash,
+			addressID,
+			ttl).WithContext(ctx).Exec()
+
+		if err != nil {
+			iter.Close()
+
+			return err
+		}
+
+		hash = ""
+		ttl = 0
+	}
+
+	return iter.Close()
+}
+
+// backfillDeletedAddresses writes every address deleted before the deleted addresses were bucketed to deleted_address
+// table, in the bucket of the hour it was deleted in, and records the first bucket the deleted addresses are purged
+// from, i.e. the earliest bucket written, or the current hour if no address is deleted. The rows expire along with the
+// address.
+func backfillDeletedAddresses(ctx context.Context, session *gocql.Session) error {
+	iter := session.Query("SELECT tenant_id, application_id, address_id, deleted_at, TTL(deleted_at) FROM address_metadata").
+		WithContext(ctx).
+		Iter()
+
+	var tenantID, applicationID, addressID gocql.UUID
+	var deletedAt time.Time
+	var ttl int
+
+	nextHour := time.Now().Truncate(time.Hour)
+
+	for iter.Scan(&tenantID, &applicationID, &addressID, &deletedAt, &ttl) {
+		if deletedAt.IsZero() {
+			ttl = 0
+
+			continue
+		}
+
+		deletedHour := deletedAt.Truncate(time.Hour)
+
+		err := session.Query(
+			"INSERT INTO deleted_address"+
+				" (deleted_hour, deleted_at, tenant_id, application_id, address_id)"+
+				" VALUES(?, ?, ?, ?, ?)"+
+				" USING TTL ?",
+			deletedHour,
+			deletedAt,
+			tenantID,
+			applicationID,
+			addressID,
+			ttl).WithContext(ctx).Exec()
+
+		if err != nil {
+			iter.Close()
+
+			return err
+		}
+
+		if deletedHour.Before(nextHour) {
+			nextHour = deletedHour
+		}
+
+		deletedAt = time.Time{}
+		ttl = 0
+	}
+
+	if err := iter.Close(); err != nil {
+		return err
+	}
+
+	return session.Query("INSERT INTO deleted_address_purge (name, next_hour) VALUES('deleted_address', ?)", nextHour).
+		WithContext(ctx).
+		Exec()
+}
+
+// backfillAddressEventOutbox moves every event waiting in address_event_outbox table to the partition of its shard and
+// the hour of its unique identifier in address_event_outbox_by_hour table. The cursor of every shard is recorded first,
+// at the earliest hour an event of the shard is moved to, or at the current hour if the shard has no event, so the
+// events moved by a failed run are not skipped when it is run again.
+func backfillAddressEventOutbox(ctx context.Context, session *gocql.Session) error {
+	currentHour := time.Now().Truncate(time.Hour)
+	cursorHours := make(map[int]time.Time)
+
+	for shard := 0; shard < addressEventOutboxShards; shard++ {
+		cursorHours[shard] = currentHour
+	}
+
+	iter := session.Query("SELECT shard, event_id FROM address_event_outbox").WithContext(ctx).Iter()
+
+	var shard int
+	var eventID gocql.UUID
+
+	for iter.Scan(&shard, &eventID) {
+		if eventHour := eventID.Time().Truncate(time.Hour); eventHour.Before(cursorHours[shard]) {
+			cursorHours[shard] = eventHour
+		}
+	}
+
+	if err := iter.Close(); err != nil {
+		return err
+	}
+
+	for shard, cursorHour := range cursorHours {
+		_, err := session.Query(
+			"INSERT INTO address_event_outbox_cursor"+
+				" (shard, event_hour)"+
+				" VALUES(?, ?)"+
+				" IF NOT EXISTS",
+			shard,
+			cursorHour).WithContext(ctx).MapScanCAS(make(map[string]interface{}))
+
+		if err != nil {
+			return err
+		}
+	}
+
+	iter = session.Query(
+		"SELECT shard, event_id, event_type, tenant_id, application_id, address_id, version, old_address_details," +
+			" new_address_details, changed_by" +
+			" FROM address_event_outbox").WithContext(ctx).Iter()
+
+	var tenantID, applicationID, addressID gocql.UUID
+	var eventType, changedBy string
+	var version int64
+	var oldAddressDetails, newAddressDetails map[string]string
+
+	for iter.Scan(&shard, &eventID, &eventType, &tenantID, &applicationID, &addressID, &version, &oldAddressDetails, &newAddressDetails, &changedBy) {
+		batch := session.NewBatch(gocql.LoggedBatch)
+
+		batch.Query(
+			"INSERT INTO address_event_outbox_by_hour"+
+				" (shard, event_hour, event_id, event_type, tenant_id, application_id, address_id, version,"+
+				" old_address_details, new_address_details, changed_by)"+
+				" VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+			shard,
+			eventID.Time().Truncate(time.Hour),
+			eventID,
+			eventType,
+			tenantID,
+			applicationID,
+			addressID,
+			version,
+			oldAddressDetails,
+			newAddressDetails,
+			changedBy)
+
+		batch.Query("DELETE FROM address_event_outbox WHERE shard = ? AND event_id = ?", shard, eventID)
+
+		if err := session.ExecuteBatch(batch.WithContext(ctx)); err != nil {
+			iter.Close()
+
+			return err
+		}
+
+		oldAddressDetails = nil
+		newAddressDetails = nil
+	}
+
+	return iter.Close()
+}
@@ -0,0 +1,105 @@
+package migration_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/micro-business/AddressService/data/migration"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Migrations", func() {
+	It("should be ordered by unique ascending versions", func() {
+		for index := 1; index < len(migration.Migrations); index++ {
+			Expect(migration.Migrations[index].Version).To(BeNumerically(">", migration.Migrations[index-1].Version))
+		}
+	})
+
+	It("should have a description and both up and down statements unless it only backfills the data", func() {
+		for _, knownMigration := range migration.Migrations {
+			Expect(knownMigration.Description).NotTo(BeEmpty())
+
+			if knownMigration.Backfill != nil && len(knownMigration.Up) == 0 {
+				Expect(knownMigration.Down).To(BeEmpty())
+
+				continue
+			}
+
+			Expect(knownMigration.Up).NotTo(BeEmpty())
+			Expect(knownMigration.Down).NotTo(BeEmpty())
+		}
+	})
+
+	It("should be generated from the CQL files of cql directory", func() {
+		fileInfos, err := ioutil.ReadDir("cql")
+
+		Expect(err).To(BeNil())
+
+		files := make(map[string]string, len(fileInfos))
+
+		for _, fileInfo := range fileInfos {
+			content, err := ioutil.ReadFile(filepath.Join("cql", fileInfo.Name()))
+
+			Expect(err).To(BeNil())
+
+			files[fileInfo.Name()] = string(content)
+		}
+
+		parsedMigrations, err := migration.ParseMigrations(files)
+
+		Expect(err).To(BeNil())
+		Expect(parsedMigrations).To(HaveLen(len(migration.Migrations)))
+
+		for index, parsedMigration := range parsedMigrations {
+			Expect(parsedMigration.Version).To(Equal(migration.Migrations[index].Version))
+			Expect(parsedMigration.Description).To(Equal(migration.Migrations[index].Description))
+			Expect(parsedMigration.Up).To(Equal(migration.Migrations[index].Up))
+			Expect(parsedMigration.Down).To(Equal(migration.Migrations[index].Down))
+		}
+	})
+})
+
+var _ = Describe("ParseMigrations", func() {
+	It("should read the version, the description and the statements of the migrations ordered by version", func() {
+		migrations, err := migration.ParseMigrations(map[string]string{
+			"0002_add_address_history.up.cql":     "-- The history of the addresses.\nCREATE TABLE address_history(version bigint);\n\n",
+			"0001_create_address_tables.up.cql":   "CREATE TABLE address(address_id UUID);\nCREATE TABLE address_metadata(address_id UUID);",
+			"0001_create_address_tables.down.cql": "DROP TABLE address_metadata;\nDROP TABLE address;\n",
+		})
+
+		Expect(err).To(BeNil())
+		Expect(migrations).To(Equal([]migration.Migration{
+			{
+				Version:     1,
+				Description: "Create address tables",
+				Up:          []string{"CREATE TABLE address(address_id UUID)", "CREATE TABLE address_metadata(address_id UUID)"},
+				Down:        []string{"DROP TABLE address_metadata", "DROP TABLE address"},
+			},
+			{
+				Version:     2,
+				Description: "Add address history",
+				Up:          []string{"CREATE TABLE address_history(version bigint)"},
+			},
+		}))
+	})
+
+	It("should return error when a file name is invalid", func() {
+		_, err := migration.ParseMigrations(map[string]string{"create_address_tables.cql": "CREATE TABLE address(address_id UUID);"})
+
+		Expect(err).NotTo(BeNil())
+	})
+
+	It("should return error when a migration has no up file", func() {
+		_, err := migration.ParseMigrations(map[string]string{"0001_create_address_tables.down.cql": "DROP TABLE address;"})
+
+		Expect(err).NotTo(BeNil())
+	})
+})
+
+func TestMigrations(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Migrations")
+	RunSpecs(t, "ParseMigrations")
+}
@@ -0,0 +1,383 @@
+package migration
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/micro-business/Micro-Business-Core/common/diagnostics"
+	"golang.org/x/net/context"
+)
+
+// lockName is the name of the only row of schema_migrations_lock table, held while migrations are applied or reverted.
+const lockName = "migrate"
+
+// lockTTL is how long the lock is held at most unless it is renewed, so a run that crashes while holding the lock does
+// not block the following runs for good.
+const lockTTL = 10 * time.Minute
+
+// defaultLockRenewInterval is how often the lock is renewed while it is held when Migrator.LockRenewInterval is not
+// provided.
+const defaultLockRenewInterval = lockTTL / 5
+
+// lockRetryInterval is how long to wait before trying to acquire the lock again when it is held by another run.
+const lockRetryInterval = time.Second
+
+// createSchemaMigrationsTableStatement creates the table recording the applied migrations.
+const createSchemaMigrationsTableStatement = "CREATE TABLE IF NOT EXISTS schema_migrations(" +
+	"version int, description text, applied_at timestamp, PRIMARY KEY(version))"
+
+// createSchemaMigrationsLockTableStatement creates the table used to stop concurrent runs from changing the schema.
+const createSchemaMigrationsLockTableStatement = "CREATE TABLE IF NOT EXISTS schema_migrations_lock(" +
+	"name text, owner timeuuid, locked_at timestamp, PRIMARY KEY(name))"
+
+// MigrationStatus is a known migration along with the time it was applied at.
+type MigrationStatus struct {
+	Migration
+
+	// AppliedAt is the time the migration was applied at, or zero time if it is pending.
+	AppliedAt time.Time
+}
+
+// Migrator applies and reverts the schema migrations of the keyspace the cluster configuration points to. The applied
+// migrations are recorded in schema_migrations table, and schema_migrations_lock table is used to make sure only one run
+// changes the schema at a time.
+type Migrator struct {
+	ClusterConfig *gocql.ClusterConfig
+
+	// Migrations holds the migrations to apply ordered by version. Migrations are used if it is not provided.
+	Migrations []Migration
+
+	// LockWaitTimeout is how long to wait for another run holding the lock to finish. Zero means not to wait.
+	LockWaitTimeout time.Duration
+
+	// LockRenewInterval is how often the lock is renewed while the migrations are applied or reverted, so a long backfill
+	// does not outlive the lock. It must be shorter than 10 minutes, the time the lock expires in, and is 2 minutes if it
+	// is not provided.
+	LockRenewInterval time.Duration
+}
+
+// Up applies all the pending migrations in version order.
+// ctx: Mandatory. The context the migrations are applied in, used to cancel applying them.
+// Returns error if the lock cannot be acquired or is lost, a migration fails or the context is canceled. The migrations
+// applied before the failing one stay applied.
+func (migrator Migrator) Up(ctx context.Context) error {
+	return migrator.executeWithLock(ctx, func(ctx context.Context, session *gocql.Session, migrations []Migration) error {
+		appliedMigrations, err := readAppliedMigrations(ctx, session)
+
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range migrations {
+			if _, ok := appliedMigrations[migration.Version]; ok {
+				continue
+			}
+
+			if err = executeStatements(ctx, session, migration.Version, migration.Up); err != nil {
+				return err
+			}
+
+			if migration.Backfill != nil {
+				if err = migration.Backfill(ctx, session); err != nil {
+					return fmt.Errorf("Migration backfill failed. Version: %d, Error: %s", migration.Version, err.Error())
+				}
+			}
+
+			err = session.Query(
+				"INSERT INTO schema_migrations"+
+					" (version, description, applied_at)"+
+					" VALUES(?, ?, ?)",
+				migration.Version,
+				migration.Description,
+				time.Now()).
+				WithContext(ctx).
+				Exec()
+
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// Down reverts the last applied migration.
+// ctx: Mandatory. The context the migration is reverted in, used to cancel reverting it.
+// Returns error if no migration is applied, the last applied migration is unknown, the lock cannot be acquired or is
+// lost, the migration fails or the context is canceled.
+func (migrator Migrator) Down(ctx context.Context) error {
+	return migrator.executeWithLock(ctx, func(ctx context.Context, session *gocql.Session, migrations []Migration) error {
+		appliedMigrations, err := readAppliedMigrations(ctx, session)
+
+		if err != nil {
+			return err
+		}
+
+		if len(appliedMigrations) == 0 {
+			return errors.New("No migration is applied.")
+		}
+
+		lastVersion := 0
+
+		for version := range appliedMigrations {
+			if version > lastVersion {
+				lastVersion = version
+			}
+		}
+
+		for _, migration := range migrations {
+			if migration.Version != lastVersion {
+				continue
+			}
+
+			if err = executeStatements(ctx, session, migration.Version, migration.Down); err != nil {
+				return err
+			}
+
+			return session.Query("DELETE FROM schema_migrations WHERE version = ?", migration.Version).WithContext(ctx).Exec()
+		}
+
+		return fmt.Errorf("Migration is applied but unknown to this version of the service. Version: %d", lastVersion)
+	})
+}
+
+// Status returns all the known migrations in version order along with the time they were applied at.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// Returns either the migrations status or error if something goes wrong.
+func (migrator Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	statuses := []MigrationStatus{}
+
+	err := migrator.executeWithSession(ctx, func(session *gocql.Session, migrations []Migration) error {
+		appliedMigrations, err := readAppliedMigrations(ctx, session)
+
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range migrations {
+			statuses = append(statuses, MigrationStatus{Migration: migration, AppliedAt: appliedMigrations[migration.Version]})
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return statuses, nil
+}
+
+// executeWithSession creates a session, makes sure the migration tables exist and runs the provided function with the
+// migrations sorted by version.
+func (migrator Migrator) executeWithSession(ctx context.Context, function func(session *gocql.Session, migrations []Migration) error) error {
+	diagnostics.IsNotNil(migrator.ClusterConfig, "migrator.ClusterConfig", "ClusterConfig must be provided.")
+
+	migrations := migrator.Migrations
+
+	if migrations == nil {
+		migrations = Migrations
+	}
+
+	migrations = append([]Migration{}, migrations...)
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	session, err := migrator.ClusterConfig.CreateSession()
+
+	if err != nil {
+		return err
+	}
+
+	defer session.Close()
+
+	for _, statement := range []string{createSchemaMigrationsTableStatement, createSchemaMigrationsLockTableStatement} {
+		if err = session.Query(statement).WithContext(ctx).Exec(); err != nil {
+			return err
+		}
+	}
+
+	return function(session, migrations)
+}
+
+// executeWithLock runs the provided function while holding the lock, waiting up to LockWaitTimeout for another run
+// holding the lock to finish. The lock is renewed every LockRenewInterval while the function runs, and the context the
+// function is given is canceled if the lock cannot be renewed, e.g. it expired and was acquired by another run. The lock
+// is released even if the provided context is canceled.
+func (migrator Migrator) executeWithLock(
+	ctx context.Context,
+	function func(ctx context.Context, session *gocql.Session, migrations []Migration) error) error {
+	return migrator.executeWithSession(ctx, func(session *gocql.Session, migrations []Migration) error {
+		owner := gocql.TimeUUID()
+		deadline := time.Now().Add(migrator.LockWaitTimeout)
+
+		for {
+			lockedAt, acquired, err := acquireLock(ctx, session, owner)
+
+			if err != nil {
+				return err
+			}
+
+			if acquired {
+				break
+			}
+
+			if !time.Now().Before(deadline) {
+				return fmt.Errorf("Schema migrations are locked by another run. Locked at: %s", lockedAt.String())
+			}
+
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(lockRetryInterval):
+			}
+		}
+
+		lockCtx, cancel := context.WithCancel(ctx)
+		renewErrs := make(chan error, 1)
+
+		go func() {
+			renewErrs <- migrator.renewLock(lockCtx, session, owner)
+			cancel()
+		}()
+
+		err := function(lockCtx, session, migrations)
+
+		cancel()
+
+		if renewErr := <-renewErrs; renewErr != nil {
+			return renewErr
+		}
+
+		if releaseErr := releaseLock(context.Background(), session, owner); err == nil {
+			err = releaseErr
+		}
+
+		return err
+	})
+}
+
+// renewLock renews the lock every LockRenewInterval while the provided owner still holds it, until the context is
+// canceled.
+// Returns nil once the context is canceled, or error if the lock is no longer held by the owner or cannot be renewed.
+func (migrator Migrator) renewLock(ctx context.Context, session *gocql.Session, owner gocql.UUID) error {
+	renewInterval := migrator.LockRenewInterval
+
+	if renewInterval <= 0 {
+		renewInterval = defaultLockRenewInterval
+	}
+
+	ticker := time.NewTicker(renewInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		currentLock := make(map[string]interface{})
+
+		renewed, err := session.Query(
+			"UPDATE schema_migrations_lock"+
+				" USING TTL ?"+
+				" SET owner = ?, locked_at = ?"+
+				" WHERE name = ?"+
+				" IF owner = ?",
+			int(lockTTL/time.Second),
+			owner,
+			time.Now(),
+			lockName,
+			owner).
+			WithContext(ctx).
+			MapScanCAS(currentLock)
+
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		if err != nil {
+			return fmt.Errorf("Schema migrations lock cannot be renewed. Error: %s", err.Error())
+		}
+
+		if !renewed {
+			return errors.New("Schema migrations lock is lost to another run.")
+		}
+	}
+}
+
+// acquireLock tries to acquire the lock for the provided owner.
+// Returns whether the lock is acquired, along with the time the current owner locked it at if it is not acquired.
+func acquireLock(ctx context.Context, session *gocql.Session, owner gocql.UUID) (time.Time, bool, error) {
+	existingLock := make(map[string]interface{})
+
+	acquired, err := session.Query(
+		"INSERT INTO schema_migrations_lock"+
+			" (name, owner, locked_at)"+
+			" VALUES(?, ?, ?)"+
+			" IF NOT EXISTS"+
+			" USING TTL ?",
+		lockName,
+		owner,
+		time.Now(),
+		int(lockTTL/time.Second)).
+		WithContext(ctx).
+		MapScanCAS(existingLock)
+
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	lockedAt, _ := existingLock["locked_at"].(time.Time)
+
+	return lockedAt, acquired, nil
+}
+
+// releaseLock releases the lock if it is still held by the provided owner.
+func releaseLock(ctx context.Context, session *gocql.Session, owner gocql.UUID) error {
+	_, err := session.Query(
+		"DELETE FROM schema_migrations_lock"+
+			" WHERE name = ?"+
+			" IF owner = ?",
+		lockName,
+		owner).
+		WithContext(ctx).
+		MapScanCAS(make(map[string]interface{}))
+
+	return err
+}
+
+// readAppliedMigrations returns the applied migrations versions along with the time they were applied at.
+func readAppliedMigrations(ctx context.Context, session *gocql.Session) (map[int]time.Time, error) {
+	iter := session.Query("SELECT version, applied_at FROM schema_migrations").WithContext(ctx).Iter()
+
+	var version int
+	var appliedAt time.Time
+
+	appliedMigrations := make(map[int]time.Time)
+
+	for iter.Scan(&version, &appliedAt) {
+		appliedMigrations[version] = appliedAt
+	}
+
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+
+	return appliedMigrations, nil
+}
+
+// executeStatements runs the provided statements of a migration in order.
+func executeStatements(ctx context.Context, session *gocql.Session, version int, statements []string) error {
+	for _, statement := range statements {
+		if err := session.Query(statement).WithContext(ctx).Exec(); err != nil {
+			return fmt.Errorf("Migration failed. Version: %d, Error: %s", version, err.Error())
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,336 @@
+// +build integration
+
+package migration_test
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/micro-business/AddressService/data/migration"
+	"github.com/micro-business/Micro-Business-Core/system"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+const databasePreparationMaxTimeout = time.Minute
+
+var _ = Describe("Migrator behaviour", func() {
+	var (
+		ctx           context.Context
+		keyspace      string
+		clusterConfig *gocql.ClusterConfig
+		migrator      migration.Migrator
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		keyspace = createRandomKeyspace()
+		executeStatement("CREATE KEYSPACE " + keyspace + " with replication = { 'class' : 'SimpleStrategy', 'replication_factor' : 1 };")
+
+		clusterConfig = getClusterConfig()
+		clusterConfig.Keyspace = keyspace
+		clusterConfig.Timeout = databasePreparationMaxTimeout
+
+		migrator = migration.Migrator{ClusterConfig: clusterConfig}
+	})
+
+	AfterEach(func() {
+		executeStatement("DROP KEYSPACE " + keyspace + " ;")
+	})
+
+	It("should report all the migrations as pending before applying them", func() {
+		statuses, err := migrator.Status(ctx)
+
+		Expect(err).To(BeNil())
+		Expect(statuses).To(HaveLen(len(migration.Migrations)))
+
+		for _, status := range statuses {
+			Expect(status.AppliedAt.IsZero()).To(BeTrue())
+		}
+	})
+
+	It("should apply all the pending migrations and create the address tables", func() {
+		Expect(migrator.Up(ctx)).To(BeNil())
+
+		statuses, err := migrator.Status(ctx)
+
+		Expect(err).To(BeNil())
+
+		for _, status := range statuses {
+			Expect(status.AppliedAt.IsZero()).To(BeFalse())
+		}
+
+		session, err := clusterConfig.CreateSession()
+
+		Expect(err).To(BeNil())
+
+		defer session.Close()
+
+		for _, table := range []string{"address", "address_indexed_by_address_key", "address_metadata", "address_history"} {
+			Expect(session.Query("SELECT * FROM " + table + " LIMIT 1").Exec()).To(BeNil())
+		}
+	})
+
+	It("should not fail when there is no pending migration", func() {
+		Expect(migrator.Up(ctx)).To(BeNil())
+		Expect(migrator.Up(ctx)).To(BeNil())
+	})
+
+	It("should revert the last applied migration only", func() {
+		Expect(migrator.Up(ctx)).To(BeNil())
+		Expect(migrator.Down(ctx)).To(BeNil())
+
+		statuses, err := migrator.Status(ctx)
+
+		Expect(err).To(BeNil())
+
+		for index, status := range statuses {
+			Expect(status.AppliedAt.IsZero()).To(Equal(index == len(statuses)-1))
+		}
+	})
+
+	It("should return error when reverting and no migration is applied", func() {
+		Expect(migrator.Down(ctx)).NotTo(BeNil())
+	})
+
+	It("should backfill the metadata of the addresses created before the versions were introduced", func() {
+		migrationsBeforeBackfill := []migration.Migration{}
+
+		for _, knownMigration := range migration.Migrations {
+			if knownMigration.Backfill == nil {
+				migrationsBeforeBackfill = append(migrationsBeforeBackfill, knownMigration)
+			}
+		}
+
+		Expect(migration.Migrator{ClusterConfig: clusterConfig, Migrations: migrationsBeforeBackfill}.Up(ctx)).To(BeNil())
+
+		tenantID := gocql.TimeUUID()
+		applicationID := gocql.TimeUUID()
+		addressIDs := []gocql.UUID{gocql.TimeUUID(), gocql.TimeUUID()}
+
+		for _, addressID := range addressIDs {
+			for _, key := range []string{"City", "Street"} {
+				executeStatement(
+					"INSERT INTO " + keyspace + ".address (tenant_id, application_id, address_id, address_key, address_value)" +
+						" VALUES(" + tenantID.String() + ", " + applicationID.String() + ", " + addressID.String() + ", '" + key + "', 'value')")
+			}
+		}
+
+		Expect(migrator.Up(ctx)).To(BeNil())
+
+		session, err := clusterConfig.CreateSession()
+
+		Expect(err).To(BeNil())
+
+		defer session.Close()
+
+		for _, addressID := range addressIDs {
+			var version int64
+
+			Expect(session.Query(
+				"SELECT version FROM address_metadata WHERE tenant_id = ? AND application_id = ? AND address_id = ?",
+				tenantID,
+				applicationID,
+				addressID).Scan(&version)).To(BeNil())
+			Expect(version).To(Equal(int64(1)))
+		}
+	})
+
+	It("should index the existing address details by their value hashes", func() {
+		migrationsBeforeValueIndex := []migration.Migration{}
+
+		for _, knownMigration := range migration.Migrations {
+			if knownMigration.Version < 11 {
+				migrationsBeforeValueIndex = append(migrationsBeforeValueIndex, knownMigration)
+			}
+		}
+
+		Expect(migration.Migrator{ClusterConfig: clusterConfig, Migrations: migrationsBeforeValueIndex}.Up(ctx)).To(BeNil())
+
+		tenantID := gocql.TimeUUID()
+		applicationID := gocql.TimeUUID()
+		plaintextAddressID := gocql.TimeUUID()
+		hashedAddressID := gocql.TimeUUID()
+
+		executeStatement(
+			"INSERT INTO " + keyspace + ".address_indexed_by_address_key (tenant_id, application_id, address_id, address_key, address_value)" +
+				" VALUES(" + tenantID.String() + ", " + applicationID.String() + ", " + plaintextAddressID.String() + ", 'City', 'Christchurch')")
+		executeStatement(
+			"INSERT INTO " + keyspace + ".address_indexed_by_address_key" +
+				" (tenant_id, application_id, address_id, address_key, address_value, address_value_hash)" +
+				" VALUES(" + tenantID.String() + ", " + applicationID.String() + ", " + hashedAddressID.String() + ", 'City', 'encrypted', 'hash')")
+
+		Expect(migrator.Up(ctx)).To(BeNil())
+
+		session, err := clusterConfig.CreateSession()
+
+		Expect(err).To(BeNil())
+
+		defer session.Close()
+
+		for valueHash, addressID := range map[string]gocql.UUID{"Christchurch": plaintextAddressID, "hash": hashedAddressID} {
+			var indexedAddressID gocql.UUID
+
+			Expect(session.Query(
+				"SELECT address_id FROM address_indexed_by_address_value"+
+					" WHERE tenant_id = ? AND application_id = ? AND address_key = 'City' AND address_value_hash = ?",
+				tenantID,
+				applicationID,
+				valueHash).Scan(&indexedAddressID)).To(BeNil())
+			Expect(indexedAddressID).To(Equal(addressID))
+		}
+	})
+
+	It("should move the events waiting in the outbox to the hourly partitions and record the cursors", func() {
+		migrationsBeforeHourlyOutbox := []migration.Migration{}
+
+		for _, knownMigration := range migration.Migrations {
+			if knownMigration.Version < 13 {
+				migrationsBeforeHourlyOutbox = append(migrationsBeforeHourlyOutbox, knownMigration)
+			}
+		}
+
+		Expect(migration.Migrator{ClusterConfig: clusterConfig, Migrations: migrationsBeforeHourlyOutbox}.Up(ctx)).To(BeNil())
+
+		eventID := gocql.UUIDFromTime(time.Now().Add(-2 * time.Hour))
+
+		executeStatement(
+			"INSERT INTO " + keyspace + ".address_event_outbox (shard, event_id, event_type, tenant_id, application_id, address_id, version)" +
+				" VALUES(3, " + eventID.String() + ", 'AddressCreated', " + gocql.TimeUUID().String() + ", " + gocql.TimeUUID().String() + ", " +
+				gocql.TimeUUID().String() + ", 1)")
+
+		Expect(migrator.Up(ctx)).To(BeNil())
+
+		session, err := clusterConfig.CreateSession()
+
+		Expect(err).To(BeNil())
+
+		defer session.Close()
+
+		var movedEventID gocql.UUID
+
+		Expect(session.Query(
+			"SELECT event_id FROM address_event_outbox_by_hour WHERE shard = 3 AND event_hour = ?",
+			eventID.Time().Truncate(time.Hour)).Scan(&movedEventID)).To(BeNil())
+		Expect(movedEventID).To(Equal(eventID))
+		Expect(session.Query("SELECT event_id FROM address_event_outbox WHERE shard = 3").Scan(&movedEventID)).To(Equal(gocql.ErrNotFound))
+
+		var cursorHour time.Time
+
+		for shard := 0; shard < 16; shard++ {
+			Expect(session.Query("SELECT event_hour FROM address_event_outbox_cursor WHERE shard = ?", shard).Scan(&cursorHour)).To(BeNil())
+
+			if shard == 3 {
+				Expect(cursorHour.Equal(eventID.Time().Truncate(time.Hour))).To(BeTrue())
+			} else {
+				Expect(cursorHour.Equal(time.Now().Truncate(time.Hour))).To(BeTrue())
+			}
+		}
+	})
+
+	It("should stop the backfill and return error when the lock is lost to another run", func() {
+		migrator.LockRenewInterval = 100 * time.Millisecond
+		migrator.Migrations = []migration.Migration{{
+			Version:     1,
+			Description: "Lose the lock",
+			Backfill: func(ctx context.Context, session *gocql.Session) error {
+				if err := session.Query("UPDATE schema_migrations_lock SET owner = now() WHERE name = 'migrate'").Exec(); err != nil {
+					return err
+				}
+
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-time.After(time.Minute):
+					return nil
+				}
+			},
+		}}
+
+		Expect(migrator.Up(ctx)).NotTo(BeNil())
+
+		statuses, err := migrator.Status(ctx)
+
+		Expect(err).To(BeNil())
+		Expect(statuses[0].AppliedAt.IsZero()).To(BeTrue())
+	})
+
+	It("should stop the backfill and release the lock when the context is canceled", func() {
+		canceledCtx, cancel := context.WithCancel(ctx)
+
+		migrator.Migrations = []migration.Migration{{
+			Version:     1,
+			Description: "Cancel the run",
+			Backfill: func(ctx context.Context, session *gocql.Session) error {
+				cancel()
+				<-ctx.Done()
+
+				return ctx.Err()
+			},
+		}}
+
+		Expect(migrator.Up(canceledCtx)).NotTo(BeNil())
+		Expect(migration.Migrator{ClusterConfig: clusterConfig}.Up(ctx)).To(BeNil())
+	})
+
+	It("should return error when another run holds the lock", func() {
+		_, err := migrator.Status(ctx)
+
+		Expect(err).To(BeNil())
+
+		executeStatement(
+			"INSERT INTO " + keyspace + ".schema_migrations_lock (name, owner, locked_at) VALUES('migrate', now(), dateof(now()))")
+
+		Expect(migrator.Up(ctx)).NotTo(BeNil())
+	})
+})
+
+func TestMigratorBehaviour(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Migrator behaviour")
+}
+
+func getClusterConfig() *gocql.ClusterConfig {
+	cassandraIPAddress := os.Getenv("CASSANDRA_ADDRESS")
+
+	if len(cassandraIPAddress) == 0 {
+		cassandraIPAddress = "127.0.0.1"
+	}
+
+	config := gocql.NewCluster(cassandraIPAddress)
+
+	cassandraProtocolVersion := os.Getenv("CASSANDRA_PROTOCOL_VERSION")
+
+	if len(cassandraProtocolVersion) != 0 {
+		if protocolVersion, err := strconv.Atoi(cassandraProtocolVersion); err == nil {
+			config.ProtoVersion = protocolVersion
+		}
+	}
+
+	config.Consistency = gocql.Quorum
+
+	return config
+}
+
+func createRandomKeyspace() string {
+	keyspaceRandomValue, _ := system.RandomUUID()
+
+	return strings.ToLower("a" + strings.Replace(keyspaceRandomValue.String(), "-", "", -1))
+}
+
+func executeStatement(statement string) {
+	config := getClusterConfig()
+	config.Timeout = databasePreparationMaxTimeout
+	session, err := config.CreateSession()
+
+	Expect(err).To(BeNil())
+
+	defer session.Close()
+
+	Expect(session.Query(statement).Exec()).To(BeNil())
+}
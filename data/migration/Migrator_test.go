@@ -0,0 +1,37 @@
+package migration_test
+
+import (
+	"testing"
+
+	"github.com/micro-business/AddressService/data/migration"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("Migrator input parameters and dependency test", func() {
+	var migrator migration.Migrator
+
+	BeforeEach(func() {
+		migrator = migration.Migrator{}
+	})
+
+	Context("when cluster configuration not provided", func() {
+		It("should panic when applying migrations", func() {
+			Ω(func() { migrator.Up(context.Background()) }).Should(Panic())
+		})
+
+		It("should panic when reverting migrations", func() {
+			Ω(func() { migrator.Down(context.Background()) }).Should(Panic())
+		})
+
+		It("should panic when reading migrations status", func() {
+			Ω(func() { migrator.Status(context.Background()) }).Should(Panic())
+		})
+	})
+})
+
+func TestMigrator(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Migrator input parameters and dependency test")
+}
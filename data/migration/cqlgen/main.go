@@ -0,0 +1,71 @@
+// Command cqlgen writes the CQL files of cql directory to MigrationFiles.go, so the migrations are built into the binary.
+// It is run by go generate in data/migration directory.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// migrationsDirectory is the directory holding the CQL files, relative to data/migration directory.
+const migrationsDirectory = "cql"
+
+// outputFileName is the name of the generated file, relative to data/migration directory.
+const outputFileName = "MigrationFiles.go"
+
+func main() {
+	fileInfos, err := ioutil.ReadDir(migrationsDirectory)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	names := []string{}
+
+	for _, fileInfo := range fileInfos {
+		if !fileInfo.IsDir() && strings.HasSuffix(fileInfo.Name(), ".cql") {
+			names = append(names, fileInfo.Name())
+		}
+	}
+
+	sort.Strings(names)
+
+	var output bytes.Buffer
+
+	output.WriteString("// Code generated by cqlgen from the CQL files of cql directory. DO NOT EDIT.\n\n")
+	output.WriteString("package migration\n\n")
+	output.WriteString("// migrationFiles holds the contents of the CQL files of cql directory keyed by file name.\n")
+	output.WriteString("var migrationFiles = map[string]string{\n")
+
+	for _, name := range names {
+		content, err := ioutil.ReadFile(filepath.Join(migrationsDirectory, name))
+
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if bytes.ContainsRune(content, '`') {
+			log.Fatalf("Migration file cannot contain backquotes. Name: %s", name)
+		}
+
+		fmt.Fprintf(&output, "%q: `%s`,\n", name, content)
+	}
+
+	output.WriteString("}\n")
+
+	formattedOutput, err := format.Source(output.Bytes())
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err = ioutil.WriteFile(outputFileName, formattedOutput, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
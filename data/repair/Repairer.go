@@ -0,0 +1,406 @@
+package repair
+
+import (
+	"sort"
+
+	"github.com/gocql/gocql"
+	"github.com/micro-business/AddressService/data/encryption"
+	"github.com/micro-business/Micro-Business-Core/common/diagnostics"
+	"github.com/micro-business/Micro-Business-Core/system"
+	"golang.org/x/net/context"
+)
+
+// InconsistencyKind describes how a row of address_indexed_by_address_key table differs from address table.
+type InconsistencyKind string
+
+const (
+	// MissingIndexRow is reported when an address key exists in address table but not in the index table.
+	MissingIndexRow InconsistencyKind = "missing_index_row"
+
+	// OrphanedIndexRow is reported when an address key exists in the index table but not in address table.
+	OrphanedIndexRow InconsistencyKind = "orphaned_index_row"
+
+	// MismatchedIndexRow is reported when an address key exists in both tables but either its value differs or only one
+	// of the rows expires.
+	MismatchedIndexRow InconsistencyKind = "mismatched_index_row"
+)
+
+// AddressRow is an address key/value row of either address or address_indexed_by_address_key table.
+type AddressRow struct {
+	ApplicationID string
+	AddressID     string
+	AddressKey    string
+	AddressValue  string
+
+	// TTL is the number of seconds left until the row expires, or zero if it never expires.
+	TTL int
+
+	// AddressValueHash is the keyed hash of the value stored in address_indexed_by_address_key table, or empty for the
+	// rows of address table and the index rows without a hash.
+	AddressValueHash string
+}
+
+// Inconsistency is a row of address_indexed_by_address_key table which does not match address table.
+type Inconsistency struct {
+	Kind          InconsistencyKind `json:"kind"`
+	ApplicationID string            `json:"applicationId"`
+	AddressID     string            `json:"addressId"`
+	AddressKey    string            `json:"addressKey"`
+
+	// AddressValue is the value stored in address table, or empty if the key does not exist in address table.
+	AddressValue string `json:"addressValue,omitempty"`
+
+	// IndexValue is the value stored in the index table, or empty if the key does not exist in the index table.
+	IndexValue string `json:"indexValue,omitempty"`
+
+	// Repaired is true once the index table row is fixed.
+	Repaired bool `json:"repaired"`
+}
+
+// Report is the result of checking and repairing the addresses of a tenant.
+type Report struct {
+	TenantID        string          `json:"tenantId"`
+	DryRun          bool            `json:"dryRun"`
+	AddressRows     int             `json:"addressRows"`
+	IndexRows       int             `json:"indexRows"`
+	Inconsistencies []Inconsistency `json:"inconsistencies"`
+}
+
+// Repairer checks address_indexed_by_address_key table of the keyspace the cluster configuration points to against
+// address table and fixes the differences from address table, which is the source of truth. The addresses changed while
+// the tables are scanned may be reported by mistake, so the repair should be run when the tenant's addresses are not
+// being changed, or be run again with DryRun to confirm the reported rows before fixing them.
+type Repairer struct {
+	ClusterConfig *gocql.ClusterConfig
+
+	// KeyProvider unwraps the data keys of the tenant, so the keyed hashes of the encrypted values are computed for the
+	// fixed index rows. It must be provided if the tenant's address values are encrypted.
+	KeyProvider encryption.KeyProvider
+
+	// DryRun reports the inconsistencies without fixing them.
+	DryRun bool
+}
+
+// Repair scans both tables for all the addresses of a tenant, reports the inconsistencies and fixes them unless DryRun is
+// set. The missing and mismatched index rows are written again from address table along with their remaining TTL and value
+// hashes, and the orphaned index rows are removed. The rows of address_indexed_by_address_value table are fixed along with
+// them.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant whose addresses to check.
+// Returns either the report or error if something goes wrong. The report holds the inconsistencies found so far and
+// whether they are repaired if fixing a row fails.
+func (repairer Repairer) Repair(ctx context.Context, tenantID system.UUID) (Report, error) {
+	diagnostics.IsNotNil(repairer.ClusterConfig, "repairer.ClusterConfig", "ClusterConfig must be provided.")
+
+	report := Report{TenantID: tenantID.String(), DryRun: repairer.DryRun, Inconsistencies: []Inconsistency{}}
+
+	session, err := repairer.ClusterConfig.CreateSession()
+
+	if err != nil {
+		return report, err
+	}
+
+	defer session.Close()
+
+	mappedTenantID := mapSystemUUIDToGocqlUUID(tenantID)
+
+	addressRows, err := readRows(
+		ctx,
+		session,
+		false,
+		"SELECT application_id, address_id, address_key, address_value, TTL(address_value)"+
+			" FROM address"+
+			" WHERE tenant_id = ?",
+		mappedTenantID)
+
+	if err != nil {
+		return report, err
+	}
+
+	indexRows, err := readRows(
+		ctx,
+		session,
+		true,
+		"SELECT application_id, address_id, address_key, address_value, TTL(address_value), address_value_hash"+
+			" FROM address_indexed_by_address_key"+
+			" WHERE tenant_id = ?",
+		mappedTenantID)
+
+	if err != nil {
+		return report, err
+	}
+
+	report.AddressRows = len(addressRows)
+	report.IndexRows = len(indexRows)
+	report.Inconsistencies = FindInconsistencies(addressRows, indexRows)
+
+	if repairer.DryRun {
+		return report, nil
+	}
+
+	keyring, err := repairer.readKeyring(ctx, tenantID, session)
+
+	if err != nil {
+		return report, err
+	}
+
+	addressRowsByKey := mapRowsByKey(addressRows)
+	indexRowsByKey := mapRowsByKey(indexRows)
+
+	for index, inconsistency := range report.Inconsistencies {
+		key := getRowKey(inconsistency.ApplicationID, inconsistency.AddressID, inconsistency.AddressKey)
+
+		if err = fixIndexRow(ctx, session, keyring, mappedTenantID, inconsistency, addressRowsByKey[key], indexRowsByKey[key]); err != nil {
+			return report, err
+		}
+
+		report.Inconsistencies[index].Repaired = true
+	}
+
+	return report, nil
+}
+
+// FindInconsistencies compares the rows of address table with the rows of address_indexed_by_address_key table.
+// addressRows: Mandatory. The rows of address table.
+// indexRows: Mandatory. The rows of address_indexed_by_address_key table.
+// Returns the index table rows not matching address table, ordered by application, address and address key.
+func FindInconsistencies(addressRows, indexRows []AddressRow) []Inconsistency {
+	addressRowsByKey := mapRowsByKey(addressRows)
+	indexRowsByKey := mapRowsByKey(indexRows)
+	inconsistencies := []Inconsistency{}
+
+	for key, addressRow := range addressRowsByKey {
+		inconsistency := Inconsistency{
+			ApplicationID: addressRow.ApplicationID,
+			AddressID:     addressRow.AddressID,
+			AddressKey:    addressRow.AddressKey,
+			AddressValue:  addressRow.AddressValue,
+		}
+
+		indexRow, ok := indexRowsByKey[key]
+
+		if !ok {
+			inconsistency.Kind = MissingIndexRow
+			inconsistencies = append(inconsistencies, inconsistency)
+
+			continue
+		}
+
+		if indexRow.AddressValue != addressRow.AddressValue || (indexRow.TTL == 0) != (addressRow.TTL == 0) {
+			inconsistency.Kind = MismatchedIndexRow
+			inconsistency.IndexValue = indexRow.AddressValue
+			inconsistencies = append(inconsistencies, inconsistency)
+		}
+	}
+
+	for key, indexRow := range indexRowsByKey {
+		if _, ok := addressRowsByKey[key]; !ok {
+			inconsistencies = append(
+				inconsistencies,
+				Inconsistency{
+					Kind:          OrphanedIndexRow,
+					ApplicationID: indexRow.ApplicationID,
+					AddressID:     indexRow.AddressID,
+					AddressKey:    indexRow.AddressKey,
+					IndexValue:    indexRow.AddressValue,
+				})
+		}
+	}
+
+	sort.Slice(inconsistencies, func(i, j int) bool {
+		return getRowKey(inconsistencies[i].ApplicationID, inconsistencies[i].AddressID, inconsistencies[i].AddressKey) <
+			getRowKey(inconsistencies[j].ApplicationID, inconsistencies[j].AddressID, inconsistencies[j].AddressKey)
+	})
+
+	return inconsistencies
+}
+
+// readRows returns all the rows the provided query selects. The query selects the value hash after the TTL if withHash is
+// set.
+func readRows(ctx context.Context, session *gocql.Session, withHash bool, statement string, values ...interface{}) ([]AddressRow, error) {
+	iter := session.Query(statement, values...).WithContext(ctx).Iter()
+
+	rows := []AddressRow{}
+
+	var applicationID, addressID gocql.UUID
+	var row AddressRow
+
+	columns := []interface{}{&applicationID, &addressID, &row.AddressKey, &row.AddressValue, &row.TTL}
+
+	if withHash {
+		columns = append(columns, &row.AddressValueHash)
+	}
+
+	for iter.Scan(columns...) {
+		row.ApplicationID = applicationID.String()
+		row.AddressID = addressID.String()
+		rows = append(rows, row)
+		row = AddressRow{}
+	}
+
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+
+	return rows, nil
+}
+
+// fixIndexRow writes the address table row to the index tables along with the keyed hash of its value, or removes the
+// index table rows if the address key does not exist in address table. The address_indexed_by_address_value table row of
+// the former index row is removed if its value hash differs. The rows are written in a single logged batch.
+func fixIndexRow(
+	ctx context.Context,
+	session *gocql.Session,
+	keyring *encryption.Keyring,
+	tenantID gocql.UUID,
+	inconsistency Inconsistency,
+	addressRow AddressRow,
+	indexRow AddressRow) error {
+	applicationID, err := gocql.ParseUUID(inconsistency.ApplicationID)
+
+	if err != nil {
+		return err
+	}
+
+	addressID, err := gocql.ParseUUID(inconsistency.AddressID)
+
+	if err != nil {
+		return err
+	}
+
+	batch := session.NewBatch(gocql.LoggedBatch)
+
+	if inconsistency.Kind == OrphanedIndexRow {
+		batch.Query(
+			"DELETE FROM address_indexed_by_address_key"+
+				" WHERE"+
+				" tenant_id = ?"+
+				" AND application_id = ?"+
+				" AND address_id = ?"+
+				" AND address_key = ?",
+			tenantID,
+			applicationID,
+			addressID,
+			inconsistency.AddressKey)
+		removeFromIndexByAddressValueTable(batch, tenantID, applicationID, addressID, indexRow)
+
+		return session.ExecuteBatch(batch.WithContext(ctx))
+	}
+
+	value, err := keyring.Decrypt(addressRow.AddressValue)
+
+	if err != nil {
+		return err
+	}
+
+	hash, err := keyring.Hash(addressRow.AddressKey, value)
+
+	if err != nil {
+		return err
+	}
+
+	// A removal written in the same batch as an insert of the same row wins, so the former row is only removed if it
+	// differs.
+	if inconsistency.Kind == MismatchedIndexRow &&
+		getIndexedValueHash(indexRow.AddressValue, indexRow.AddressValueHash) != getIndexedValueHash(addressRow.AddressValue, hash) {
+		removeFromIndexByAddressValueTable(batch, tenantID, applicationID, addressID, indexRow)
+	}
+
+	batch.Query(
+		"INSERT INTO address_indexed_by_address_key"+
+			" (tenant_id, application_id, address_id, address_key, address_value, address_value_hash)"+
+			" VALUES(?, ?, ?, ?, ?, ?)"+
+			" USING TTL ?",
+		tenantID,
+		applicationID,
+		addressID,
+		addressRow.AddressKey,
+		addressRow.AddressValue,
+		hash,
+		addressRow.TTL)
+	batch.Query(
+		"INSERT INTO address_indexed_by_address_value"+
+			" (tenant_id, application_id, address_key, address_value_hash, address_id)"+
+			" VALUES(?, ?, ?, ?, ?)"+
+			" USING TTL ?",
+		tenantID,
+		applicationID,
+		addressRow.AddressKey,
+		getIndexedValueHash(addressRow.AddressValue, hash),
+		addressID,
+		addressRow.TTL)
+
+	return session.ExecuteBatch(batch.WithContext(ctx))
+}
+
+// removeFromIndexByAddressValueTable adds the statement removing the address_indexed_by_address_value table row of the
+// provided index row to the provided batch.
+func removeFromIndexByAddressValueTable(batch *gocql.Batch, tenantID, applicationID, addressID gocql.UUID, indexRow AddressRow) {
+	batch.Query(
+		"DELETE FROM address_indexed_by_address_value"+
+			" WHERE"+
+			" tenant_id = ?"+
+			" AND application_id = ?"+
+			" AND address_key = ?"+
+			" AND address_value_hash = ?"+
+			" AND address_id = ?",
+		tenantID,
+		applicationID,
+		indexRow.AddressKey,
+		getIndexedValueHash(indexRow.AddressValue, indexRow.AddressValueHash),
+		addressID)
+}
+
+// getIndexedValueHash returns the hash an address detail is indexed by in address_indexed_by_address_value table, i.e.
+// the keyed hash of its value, or the stored value itself if it is in plaintext and has no hash.
+func getIndexedValueHash(storedValue, storedHash string) string {
+	if storedHash != "" {
+		return storedHash
+	}
+
+	return storedValue
+}
+
+// readKeyring reads and unwraps all the data key versions of the tenant. The latest version is the current one.
+// Returns nil if no key provider is provided or the tenant has no data key, as the tenant's values are then stored in
+// plaintext.
+func (repairer Repairer) readKeyring(ctx context.Context, tenantID system.UUID, session *gocql.Session) (*encryption.Keyring, error) {
+	if repairer.KeyProvider == nil {
+		return nil, nil
+	}
+
+	keyring, err := encryption.ReadKeyring(ctx, session, tenantID, repairer.KeyProvider)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(keyring.DataKeys) == 0 {
+		return nil, nil
+	}
+
+	return keyring, nil
+}
+
+// mapRowsByKey returns the provided rows keyed by getRowKey.
+func mapRowsByKey(rows []AddressRow) map[string]AddressRow {
+	rowsByKey := make(map[string]AddressRow, len(rows))
+
+	for _, row := range rows {
+		rowsByKey[getRowKey(row.ApplicationID, row.AddressID, row.AddressKey)] = row
+	}
+
+	return rowsByKey
+}
+
+// getRowKey returns the key identifying an address key row of an application's address.
+func getRowKey(applicationID, addressID, addressKey string) string {
+	return applicationID + "/" + addressID + "/" + addressKey
+}
+
+// mapSystemUUIDToGocqlUUID maps the system type UUID to gocql UUID type
+func mapSystemUUIDToGocqlUUID(uuid system.UUID) gocql.UUID {
+	mappedUUID, _ := gocql.UUIDFromBytes(uuid.Bytes())
+
+	return mappedUUID
+}
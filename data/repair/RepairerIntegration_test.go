@@ -0,0 +1,267 @@
+// +build integration
+
+package repair_test
+
+import (
+	"encoding/hex"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/micro-business/AddressService/data/encryption"
+	"github.com/micro-business/AddressService/data/migration"
+	"github.com/micro-business/AddressService/data/repair"
+	"github.com/micro-business/Micro-Business-Core/system"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+const databasePreparationMaxTimeout = time.Minute
+
+var _ = Describe("Repairer behaviour", func() {
+	var (
+		ctx           context.Context
+		keyspace      string
+		clusterConfig *gocql.ClusterConfig
+		repairer      repair.Repairer
+		tenantID      system.UUID
+		applicationID system.UUID
+		addressID     system.UUID
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		keyspace = createRandomKeyspace()
+		executeStatement("CREATE KEYSPACE " + keyspace + " with replication = { 'class' : 'SimpleStrategy', 'replication_factor' : 1 };")
+
+		clusterConfig = getClusterConfig()
+		clusterConfig.Keyspace = keyspace
+		clusterConfig.Timeout = databasePreparationMaxTimeout
+
+		Expect(migration.Migrator{ClusterConfig: clusterConfig}.Up(ctx)).To(BeNil())
+
+		repairer = repair.Repairer{ClusterConfig: clusterConfig}
+
+		tenantID, _ = system.RandomUUID()
+		applicationID, _ = system.RandomUUID()
+		addressID, _ = system.RandomUUID()
+
+		insertRow(clusterConfig, "address", tenantID, applicationID, addressID, "City", "Christchurch")
+		insertRow(clusterConfig, "address", tenantID, applicationID, addressID, "Postcode", "8011")
+		insertRow(clusterConfig, "address_indexed_by_address_key", tenantID, applicationID, addressID, "City", "Wellington")
+		insertRow(clusterConfig, "address_indexed_by_address_key", tenantID, applicationID, addressID, "Country", "New Zealand")
+	})
+
+	AfterEach(func() {
+		executeStatement("DROP KEYSPACE " + keyspace + " ;")
+	})
+
+	It("should report the inconsistencies without fixing them on dry run", func() {
+		repairer.DryRun = true
+
+		report, err := repairer.Repair(ctx, tenantID)
+
+		Expect(err).To(BeNil())
+		Expect(report.AddressRows).To(Equal(2))
+		Expect(report.IndexRows).To(Equal(2))
+		Expect(report.Inconsistencies).To(HaveLen(3))
+
+		for _, inconsistency := range report.Inconsistencies {
+			Expect(inconsistency.Repaired).To(BeFalse())
+		}
+
+		report, err = repairer.Repair(ctx, tenantID)
+
+		Expect(err).To(BeNil())
+		Expect(report.Inconsistencies).To(HaveLen(3))
+	})
+
+	It("should fix the index table from address table", func() {
+		report, err := repairer.Repair(ctx, tenantID)
+
+		Expect(err).To(BeNil())
+		Expect(report.Inconsistencies).To(HaveLen(3))
+
+		for _, inconsistency := range report.Inconsistencies {
+			Expect(inconsistency.Repaired).To(BeTrue())
+		}
+
+		repairer.DryRun = true
+		report, err = repairer.Repair(ctx, tenantID)
+
+		Expect(err).To(BeNil())
+		Expect(report.IndexRows).To(Equal(2))
+		Expect(report.Inconsistencies).To(BeEmpty())
+	})
+
+	It("should fix address_indexed_by_address_value table along with the index table", func() {
+		insertValueIndexRow(clusterConfig, tenantID, applicationID, addressID, "City", "Wellington")
+		insertValueIndexRow(clusterConfig, tenantID, applicationID, addressID, "Country", "New Zealand")
+
+		_, err := repairer.Repair(ctx, tenantID)
+
+		Expect(err).To(BeNil())
+		Expect(readValueIndexRows(clusterConfig, tenantID, applicationID)).To(Equal(map[string]string{"City": "Christchurch", "Postcode": "8011"}))
+	})
+
+	It("should index the encrypted values by their keyed hashes", func() {
+		dataKey, err := encryption.NewDataKey(1)
+
+		Expect(err).To(BeNil())
+
+		keyring := &encryption.Keyring{TenantID: tenantID, DataKeys: map[int]encryption.DataKey{1: dataKey}, CurrentVersion: 1}
+		encryptedValue, err := keyring.Encrypt("Riccarton")
+
+		Expect(err).To(BeNil())
+
+		hash, err := keyring.Hash("Suburb", "Riccarton")
+
+		Expect(err).To(BeNil())
+
+		executeStatement(
+			"INSERT INTO " + keyspace + ".tenant_data_key (tenant_id, version, master_key_id, wrapped_key)" +
+				" VALUES(" + tenantID.String() + ", 1, 'plain', 0x" + hex.EncodeToString(dataKey.Key) + ");")
+		insertRow(clusterConfig, "address", tenantID, applicationID, addressID, "Suburb", encryptedValue)
+
+		repairer.KeyProvider = plainKeyProvider{}
+		_, err = repairer.Repair(ctx, tenantID)
+
+		Expect(err).To(BeNil())
+		Expect(readValueIndexRows(clusterConfig, tenantID, applicationID)).To(HaveKeyWithValue("Suburb", hash))
+	})
+
+	It("should not report the addresses of other tenants", func() {
+		otherTenantID, _ := system.RandomUUID()
+
+		report, err := repairer.Repair(ctx, otherTenantID)
+
+		Expect(err).To(BeNil())
+		Expect(report.AddressRows).To(Equal(0))
+		Expect(report.Inconsistencies).To(BeEmpty())
+	})
+})
+
+func TestRepairerBehaviour(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Repairer behaviour")
+}
+
+func insertRow(clusterConfig *gocql.ClusterConfig, table string, tenantID, applicationID, addressID system.UUID, key, value string) {
+	session, err := clusterConfig.CreateSession()
+
+	Expect(err).To(BeNil())
+
+	defer session.Close()
+
+	Expect(session.Query(
+		"INSERT INTO "+table+
+			" (tenant_id, application_id, address_id, address_key, address_value)"+
+			" VALUES(?, ?, ?, ?, ?)",
+		tenantID.String(),
+		applicationID.String(),
+		addressID.String(),
+		key,
+		value).Exec()).To(BeNil())
+}
+
+// plainKeyProvider keeps the data keys unwrapped.
+type plainKeyProvider struct{}
+
+func (plainKeyProvider) WrapKey(dataKey []byte) (string, []byte, error) {
+	return "plain", dataKey, nil
+}
+
+func (plainKeyProvider) UnwrapKey(masterKeyID string, wrappedKey []byte) ([]byte, error) {
+	return wrappedKey, nil
+}
+
+func insertValueIndexRow(clusterConfig *gocql.ClusterConfig, tenantID, applicationID, addressID system.UUID, key, valueHash string) {
+	session, err := clusterConfig.CreateSession()
+
+	Expect(err).To(BeNil())
+
+	defer session.Close()
+
+	Expect(session.Query(
+		"INSERT INTO address_indexed_by_address_value"+
+			" (tenant_id, application_id, address_key, address_value_hash, address_id)"+
+			" VALUES(?, ?, ?, ?, ?)",
+		tenantID.String(),
+		applicationID.String(),
+		key,
+		valueHash,
+		addressID.String()).Exec()).To(BeNil())
+}
+
+func readValueIndexRows(clusterConfig *gocql.ClusterConfig, tenantID, applicationID system.UUID) map[string]string {
+	session, err := clusterConfig.CreateSession()
+
+	Expect(err).To(BeNil())
+
+	defer session.Close()
+
+	iter := session.Query(
+		"SELECT address_key, address_value_hash"+
+			" FROM address_indexed_by_address_value"+
+			" WHERE"+
+			" tenant_id = ?"+
+			" AND application_id = ?",
+		tenantID.String(),
+		applicationID.String()).Iter()
+
+	var key, valueHash string
+
+	valueHashes := make(map[string]string)
+
+	for iter.Scan(&key, &valueHash) {
+		valueHashes[key] = valueHash
+	}
+
+	Expect(iter.Close()).To(BeNil())
+
+	return valueHashes
+}
+
+func getClusterConfig() *gocql.ClusterConfig {
+	cassandraIPAddress := os.Getenv("CASSANDRA_ADDRESS")
+
+	if len(cassandraIPAddress) == 0 {
+		cassandraIPAddress = "127.0.0.1"
+	}
+
+	config := gocql.NewCluster(cassandraIPAddress)
+
+	cassandraProtocolVersion := os.Getenv("CASSANDRA_PROTOCOL_VERSION")
+
+	if len(cassandraProtocolVersion) != 0 {
+		if protocolVersion, err := strconv.Atoi(cassandraProtocolVersion); err == nil {
+			config.ProtoVersion = protocolVersion
+		}
+	}
+
+	config.Consistency = gocql.Quorum
+
+	return config
+}
+
+func createRandomKeyspace() string {
+	keyspaceRandomValue, _ := system.RandomUUID()
+
+	return strings.ToLower("a" + strings.Replace(keyspaceRandomValue.String(), "-", "", -1))
+}
+
+func executeStatement(statement string) {
+	config := getClusterConfig()
+	config.Timeout = databasePreparationMaxTimeout
+	session, err := config.CreateSession()
+
+	Expect(err).To(BeNil())
+
+	defer session.Close()
+
+	Expect(session.Query(statement).Exec()).To(BeNil())
+}
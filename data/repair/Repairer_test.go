@@ -0,0 +1,103 @@
+package repair_test
+
+import (
+	"testing"
+
+	"github.com/micro-business/AddressService/data/repair"
+	"github.com/micro-business/Micro-Business-Core/system"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("Repairer input parameters and dependency test", func() {
+	Context("when cluster configuration not provided", func() {
+		It("should panic", func() {
+			repairer := repair.Repairer{}
+			tenantID, _ := system.RandomUUID()
+
+			Ω(func() { repairer.Repair(context.Background(), tenantID) }).Should(Panic())
+		})
+	})
+})
+
+var _ = Describe("Finding inconsistencies", func() {
+	var (
+		applicationID string
+		addressID     string
+		addressRows   []repair.AddressRow
+	)
+
+	BeforeEach(func() {
+		randomApplicationID, _ := system.RandomUUID()
+		randomAddressID, _ := system.RandomUUID()
+		applicationID = randomApplicationID.String()
+		addressID = randomAddressID.String()
+
+		addressRows = []repair.AddressRow{
+			{ApplicationID: applicationID, AddressID: addressID, AddressKey: "City", AddressValue: "Christchurch"},
+			{ApplicationID: applicationID, AddressID: addressID, AddressKey: "Postcode", AddressValue: "8011"},
+		}
+	})
+
+	It("should not report anything when both tables match", func() {
+		Expect(repair.FindInconsistencies(addressRows, addressRows)).To(BeEmpty())
+	})
+
+	It("should report the index rows missing from the index table", func() {
+		inconsistencies := repair.FindInconsistencies(addressRows, addressRows[:1])
+
+		Expect(inconsistencies).To(Equal([]repair.Inconsistency{
+			{Kind: repair.MissingIndexRow, ApplicationID: applicationID, AddressID: addressID, AddressKey: "Postcode", AddressValue: "8011"},
+		}))
+	})
+
+	It("should report the index rows whose address key does not exist in address table", func() {
+		inconsistencies := repair.FindInconsistencies(addressRows[1:], addressRows)
+
+		Expect(inconsistencies).To(Equal([]repair.Inconsistency{
+			{Kind: repair.OrphanedIndexRow, ApplicationID: applicationID, AddressID: addressID, AddressKey: "City", IndexValue: "Christchurch"},
+		}))
+	})
+
+	It("should report the index rows whose value differs", func() {
+		indexRows := []repair.AddressRow{addressRows[0], addressRows[1]}
+		indexRows[0].AddressValue = "Wellington"
+
+		inconsistencies := repair.FindInconsistencies(addressRows, indexRows)
+
+		Expect(inconsistencies).To(Equal([]repair.Inconsistency{
+			{
+				Kind:          repair.MismatchedIndexRow,
+				ApplicationID: applicationID,
+				AddressID:     addressID,
+				AddressKey:    "City",
+				AddressValue:  "Christchurch",
+				IndexValue:    "Wellington",
+			},
+		}))
+	})
+
+	It("should report the index rows that do not expire along with the address", func() {
+		expiringAddressRows := []repair.AddressRow{addressRows[0]}
+		expiringAddressRows[0].TTL = 3600
+
+		inconsistencies := repair.FindInconsistencies(expiringAddressRows, addressRows[:1])
+
+		Expect(inconsistencies).To(HaveLen(1))
+		Expect(inconsistencies[0].Kind).To(Equal(repair.MismatchedIndexRow))
+	})
+
+	It("should not report the index rows whose remaining TTL differs slightly", func() {
+		indexRows := []repair.AddressRow{addressRows[0]}
+		addressRows[0].TTL = 3600
+		indexRows[0].TTL = 3599
+
+		Expect(repair.FindInconsistencies(addressRows[:1], indexRows)).To(BeEmpty())
+	})
+})
+
+func TestRepairer(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Repairer")
+}
@@ -1,29 +1,101 @@
 package service
 
 import (
-	"errors"
+	"encoding/base64"
 	"fmt"
-	"strings"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/gocql/gocql"
 	"github.com/micro-business/AddressService/data/contract"
+	"github.com/micro-business/AddressService/data/encryption"
 	"github.com/micro-business/Micro-Business-Core/common/diagnostics"
 	"github.com/micro-business/Micro-Business-Core/system"
+	"golang.org/x/net/context"
 )
 
+// maxVersionChangeAttempts is the number of times changing an address version is attempted when the version check is
+// skipped and other callers keep changing the address at the same time.
+const maxVersionChangeAttempts = 3
+
+// deletedAddressPurgeDelay is how long after an hour is over its bucket of deleted_address table is kept being purged,
+// so the addresses deleted in the hour by the instances whose clocks are behind are purged too.
+const deletedAddressPurgeDelay = time.Hour
+
+// deletedAddress is an address read from deleted_address table along with the time it was deleted at.
+type deletedAddress struct {
+	tenantID      system.UUID
+	applicationID system.UUID
+	addressID     system.UUID
+	deletedAt     time.Time
+}
+
 // AddressDataService provides access to add new address and update/retrieve/remove an existing address.
+// The service owns a long-lived session to the Cassandra cluster which is created on first use and shared by all the
+// calls, so the service must not be copied after first use. Close must be called to release the session.
 type AddressDataService struct {
 	UUIDGeneratorService system.UUIDGeneratorService
 	ClusterConfig        *gocql.ClusterConfig
+
+	// KeyProvider wraps the tenants' data keys used to encrypt the address values. The address values are stored in
+	// plaintext if it is not provided.
+	KeyProvider encryption.KeyProvider
+
+	// RecordEvents is true to write an event to address_event_outbox_by_hour table in the same batch as every address
+	// creation, update and deletion, so the events can be published by the event relay.
+	RecordEvents bool
+
+	// EventSettleDelay is how long an event is left in the outbox before ReadEvents reads it, so an event written with a
+	// clock behind by less than the delay is not skipped. The events are read after 10 seconds if it is not provided.
+	EventSettleDelay time.Duration
+
+	sessionLock sync.Mutex
+	session     *gocql.Session
+
+	keyringLock sync.Mutex
+	keyrings    map[system.UUID]*encryption.Keyring
+}
+
+// NewAddressDataService creates a new address data service and opens its session to the Cassandra cluster.
+// uuidGeneratorService: Mandatory. The service used to generate the new address unique identifiers.
+// clusterConfig: Mandatory. The Cassandra cluster configuration used to create the session.
+// Returns either the new address data service or error if the session cannot be created.
+func NewAddressDataService(uuidGeneratorService system.UUIDGeneratorService, clusterConfig *gocql.ClusterConfig) (*AddressDataService, error) {
+	diagnostics.IsNotNil(uuidGeneratorService, "uuidGeneratorService", "uuidGeneratorService must be provided.")
+	diagnostics.IsNotNil(clusterConfig, "clusterConfig", "clusterConfig must be provided.")
+
+	addressDataService := &AddressDataService{UUIDGeneratorService: uuidGeneratorService, ClusterConfig: clusterConfig}
+
+	if _, err := addressDataService.getSession(); err != nil {
+		return nil, err
+	}
+
+	return addressDataService, nil
+}
+
+// Close closes the session to the Cassandra cluster. The service creates a new session if it is used after being closed.
+// Returns error if something goes wrong.
+func (addressDataService *AddressDataService) Close() error {
+	addressDataService.sessionLock.Lock()
+	defer addressDataService.sessionLock.Unlock()
+
+	if addressDataService.session != nil {
+		addressDataService.session.Close()
+		addressDataService.session = nil
+	}
+
+	return nil
 }
 
 // Create creates a new address.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
 // tenantID: Mandatory. The unique identifier of the tenant owning the address.
 // applicationID: Mandatory. The unique identifier of the tenant's application will be owning the address.
 // address: Mandatory. The reference to the new address information.
+// changedBy: Optional. Identifies who makes the change, recorded in the address history.
 // Returns either the unique identifier of the new address or error if something goes wrong.
-func (addressDataService AddressDataService) Create(tenantID, applicationID system.UUID, address contract.Address) (system.UUID, error) {
+func (addressDataService *AddressDataService) Create(ctx context.Context, tenantID, applicationID system.UUID, address contract.Address, changedBy string) (system.UUID, error) {
 	diagnostics.IsNotNil(addressDataService.UUIDGeneratorService, "addressDataService.UUIDGeneratorService", "UUIDGeneratorService must be provided.")
 	diagnostics.IsNotNil(addressDataService.ClusterConfig, "addressDataService.ClusterConfig", "ClusterConfig must be provided.")
 
@@ -33,413 +105,1906 @@ func (addressDataService AddressDataService) Create(tenantID, applicationID syst
 		return system.EmptyUUID, err
 	}
 
-	session, err := addressDataService.ClusterConfig.CreateSession()
+	err = addressDataService.executeWithKeyring(ctx, tenantID, func(session *gocql.Session, keyring *encryption.Keyring) error {
+		return addNewAddress(ctx, tenantID, applicationID, address, addressID, changedBy, addressDataService.RecordEvents, keyring, session)
+	})
 
 	if err != nil {
 		return system.EmptyUUID, err
 	}
 
-	defer session.Close()
-
-	if err = addNewAddress(tenantID, applicationID, address, addressID, session); err != nil {
-		return system.EmptyUUID, err
-	}
-
 	return addressID, nil
 }
 
+// CreateMany creates several new addresses. Every address is created on its own, so failing to create an address does
+// not stop creating the rest of them.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the addresses.
+// applicationID: Mandatory. The unique identifier of the tenant's application will be owning the addresses.
+// addresses: Mandatory. The new addresses information.
+// changedBy: Optional. Identifies who makes the change, recorded in the address history.
+// Returns the result of creating every address in the same order as addresses.
+func (addressDataService *AddressDataService) CreateMany(
+	ctx context.Context,
+	tenantID, applicationID system.UUID,
+	addresses []contract.Address,
+	changedBy string) []contract.AddressResult {
+	return createAddresses(ctx, addressDataService, tenantID, applicationID, addresses, changedBy)
+}
+
 // Update updates an existing address.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
 // tenantID: Mandatory. The unique identifier of the tenant owning the address.
 // applicationID: Mandatory. The unique identifier of the tenant's application will be owning the address.
 // addressID: Mandatory. The unique identifier of the existing address.
 // address: Mandatory. The reeference to the updated address information.
-// Returns error if something goes wrong.
-func (addressDataService AddressDataService) Update(tenantID, applicationID, addressID system.UUID, address contract.Address) error {
+// expectedVersion: Optional. The version the address is expected to have, or AnyVersion to skip the version check.
+// changedBy: Optional. Identifies who makes the change, recorded in the address history.
+// Returns ConflictError if the address version does not match the expected version, or error if something goes wrong.
+func (addressDataService *AddressDataService) Update(
+	ctx context.Context,
+	tenantID, applicationID, addressID system.UUID,
+	address contract.Address,
+	expectedVersion int64,
+	changedBy string) error {
 	diagnostics.IsNotNil(addressDataService.ClusterConfig, "addressDataService.ClusterConfig", "ClusterConfig must be provided.")
 
-	session, err := addressDataService.ClusterConfig.CreateSession()
+	return addressDataService.executeWithKeyring(ctx, tenantID, func(session *gocql.Session, keyring *encryption.Keyring) error {
+		existingAddress, err := readAllAddressDetails(ctx, tenantID, applicationID, addressID, keyring, session)
 
-	if err != nil {
-		return err
-	}
+		if err != nil {
+			return err
+		}
 
-	defer session.Close()
+		if existingAddress.Fingerprint, err = readAddressFingerprint(ctx, tenantID, applicationID, addressID, session); err != nil {
+			return err
+		}
 
-	if !doesAddressExist(tenantID, applicationID, addressID, session) {
-		return fmt.Errorf("Address not found. Address ID: %s", addressID.String())
-	}
+		if address.Fingerprint, err = hashAddressFingerprint(keyring, address.Fingerprint); err != nil {
+			return err
+		}
 
-	if err := deleteExistingAddress(tenantID, applicationID, addressID, session); err != nil {
-		return err
-	}
+		return updateExistingAddress(
+			ctx,
+			tenantID,
+			applicationID,
+			existingAddress,
+			address,
+			addressID,
+			expectedVersion,
+			changedBy,
+			addressDataService.RecordEvents,
+			keyring,
+			session)
+	})
+}
+
+// Patch changes only the provided details of an existing address and leaves the rest of them unchanged.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+// applicationID: Mandatory. The unique identifier of the tenant's application will be owning the address.
+// addressID: Mandatory. The unique identifier of the existing address.
+// set: Optional. The address details keys to add or change along with their new values.
+// remove: Optional. The address details keys to remove.
+// expectedVersion: Optional. The version the address is expected to have, or AnyVersion to skip the version check.
+// changedBy: Optional. Identifies who makes the change, recorded in the address history.
+// Returns ConflictError if the address version does not match the expected version, or error if something goes wrong.
+func (addressDataService *AddressDataService) Patch(
+	ctx context.Context,
+	tenantID, applicationID, addressID system.UUID,
+	set map[string]string,
+	remove []string,
+	expectedVersion int64,
+	changedBy string) error {
+	diagnostics.IsNotNil(addressDataService.ClusterConfig, "addressDataService.ClusterConfig", "ClusterConfig must be provided.")
+
+	return addressDataService.executeWithKeyring(ctx, tenantID, func(session *gocql.Session, keyring *encryption.Keyring) error {
+		existingAddress, err := readAllAddressDetails(ctx, tenantID, applicationID, addressID, keyring, session)
 
-	return addNewAddress(tenantID, applicationID, address, addressID, session)
+		if err != nil {
+			return err
+		}
+
+		if existingAddress.Fingerprint, err = readAddressFingerprint(ctx, tenantID, applicationID, addressID, session); err != nil {
+			return err
+		}
+
+		patchedAddress, err := patchAddressDetails(existingAddress, set, remove, addressID)
+
+		if err != nil {
+			return err
+		}
+
+		patchedAddress.Fingerprint = existingAddress.Fingerprint
+
+		return updateExistingAddress(
+			ctx,
+			tenantID,
+			applicationID,
+			existingAddress,
+			patchedAddress,
+			addressID,
+			expectedVersion,
+			changedBy,
+			addressDataService.RecordEvents,
+			keyring,
+			session)
+	})
 }
 
 // Read retrieves an existing address information and returns only the detail which the keys provided by the keys.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
 // tenantID: Mandatory. The unique identifier of the tenant owning the address.
 // applicationID: Mandatory. The unique identifier of the tenant's application will be owning the address.
 // addressID: Mandatory. The unique identifier of the existing address.
 // keys: Mandatory. The interested address details keys to return.
 // Returns either the address information or error if something goes wrong.
-func (addressDataService AddressDataService) Read(tenantID, applicationID, addressID system.UUID, keys []string) (contract.Address, error) {
+func (addressDataService *AddressDataService) Read(ctx context.Context, tenantID, applicationID, addressID system.UUID, keys []string) (contract.Address, error) {
 	diagnostics.IsNotNil(addressDataService.ClusterConfig, "addressDataService.ClusterConfig", "ClusterConfig must be provided.")
 
-	session, err := addressDataService.ClusterConfig.CreateSession()
-
-	if err != nil {
-		return contract.Address{}, err
-	}
-
-	defer session.Close()
-
-	iter := session.Query(
-		"SELECT address_key, address_value"+
-			" FROM address"+
-			" WHERE"+
-			" tenant_id = ?"+
-			" AND application_id = ?"+
-			" AND address_id = ?"+
-			" AND address_key IN "+
-			" ('"+strings.Join(keys, "','")+"')",
-		tenantID.String(),
-		applicationID.String(),
-		addressID.String()).Iter()
+	var address contract.Address
+
+	err := addressDataService.executeWithKeyring(ctx, tenantID, func(session *gocql.Session, keyring *encryption.Keyring) error {
+		iter := session.Query(
+			"SELECT address_key, address_value, TTL(address_value)"+
+				" FROM address"+
+				" WHERE"+
+				" tenant_id = ?"+
+				" AND application_id = ?"+
+				" AND address_id = ?"+
+				" AND address_key IN ?",
+			tenantID.String(),
+			applicationID.String(),
+			addressID.String(),
+			keys).WithContext(ctx).Iter()
+
+		var err error
+
+		if address, err = scanAddressDetails(iter, addressID, keyring); err != nil {
+			return err
+		}
 
-	defer iter.Close()
+		var deletedAt time.Time
 
-	var key string
-	var value string
+		if address.Version, deletedAt, err = readAddressMetadata(ctx, tenantID, applicationID, addressID, session); err != nil {
+			return err
+		}
 
-	address := contract.Address{AddressDetails: make(map[string]string)}
+		if !deletedAt.IsZero() {
+			return contract.NotFoundError{AddressID: addressID}
+		}
 
-	for iter.Scan(&key, &value) {
-		address.AddressDetails[key] = value
-	}
+		return nil
+	})
 
-	if len(address.AddressDetails) == 0 {
-		return contract.Address{}, fmt.Errorf("Address not found. Address ID: %s", addressID.String())
+	if err != nil {
+		return contract.Address{}, err
 	}
 
 	return address, nil
 }
 
 // ReadAll retrieves an existing address information and returns all the detail of it.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
 // tenantID: Mandatory. The unique identifier of the tenant owning the address.
 // applicationID: Mandatory. The unique identifier of the tenant's application will be owning the address.
 // addressID: Mandatory. The unique identifier of the existing address.
 // Returns either the address information or error if something goes wrong.
-func (addressDataService AddressDataService) ReadAll(tenantID, applicationID, addressID system.UUID) (contract.Address, error) {
+func (addressDataService *AddressDataService) ReadAll(ctx context.Context, tenantID, applicationID, addressID system.UUID) (contract.Address, error) {
 	diagnostics.IsNotNil(addressDataService.ClusterConfig, "addressDataService.ClusterConfig", "ClusterConfig must be provided.")
 
-	session, err := addressDataService.ClusterConfig.CreateSession()
+	var address contract.Address
+
+	err := addressDataService.executeWithKeyring(ctx, tenantID, func(session *gocql.Session, keyring *encryption.Keyring) error {
+		var err error
+
+		address, err = readAllAddressDetails(ctx, tenantID, applicationID, addressID, keyring, session)
+
+		return err
+	})
 
 	if err != nil {
 		return contract.Address{}, err
 	}
 
-	defer session.Close()
+	return address, nil
+}
 
-	return readAllAddressDetails(tenantID, applicationID, addressID, session)
+// ReadMany retrieves several existing addresses information. Every address is read on its own, so failing to read an
+// address does not stop reading the rest of them.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the addresses.
+// applicationID: Mandatory. The unique identifier of the tenant's application owning the addresses.
+// addressIDs: Mandatory. The unique identifiers of the existing addresses.
+// keys: Optional. The interested address details keys to return, or empty to return all the details.
+// Returns the result of reading every address in the same order as addressIDs.
+func (addressDataService *AddressDataService) ReadMany(
+	ctx context.Context,
+	tenantID, applicationID system.UUID,
+	addressIDs []system.UUID,
+	keys []string) []contract.AddressResult {
+	return readAddresses(ctx, addressDataService, tenantID, applicationID, addressIDs, keys)
 }
 
-// Delete deletes an existing address information.
-// tenantID: Mandatory. The unique identifier of the tenant owning the address.
-// applicationID: Mandatory. The unique identifier of the tenant's application will be owning the address.
-// addressID: Mandatory. The unique identifier of the existing address to remove.
-// Returns error if something goes wrong.
-func (addressDataService AddressDataService) Delete(tenantID, applicationID, addressID system.UUID) error {
+// List retrieves a page of the addresses owned by a tenant's application. The deleted addresses are not returned.
+// The addresses are paged through address_metadata table using Cassandra paging state, which is returned as the cursor.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the addresses.
+// applicationID: Mandatory. The unique identifier of the tenant's application owning the addresses.
+// pageSize: Mandatory. The maximum number of addresses to return.
+// cursor: Optional. The cursor returned with the previous page, or empty to return the first page.
+// Returns either the page of addresses or error if the cursor is invalid or something goes wrong.
+func (addressDataService *AddressDataService) List(ctx context.Context, tenantID, applicationID system.UUID, pageSize int, cursor string) (contract.AddressPage, error) {
 	diagnostics.IsNotNil(addressDataService.ClusterConfig, "addressDataService.ClusterConfig", "ClusterConfig must be provided.")
 
-	session, err := addressDataService.ClusterConfig.CreateSession()
+	pageState, err := base64.RawURLEncoding.DecodeString(cursor)
 
 	if err != nil {
-		return err
+		return contract.AddressPage{}, contract.InvalidArgumentError{Message: fmt.Sprintf("Invalid cursor. Cursor: %s", cursor)}
 	}
 
-	defer session.Close()
+	var page contract.AddressPage
 
-	if !doesAddressExist(tenantID, applicationID, addressID, session) {
-		return fmt.Errorf("Address not found. Address ID: %s", addressID.String())
-	}
+	err = addressDataService.executeWithKeyring(ctx, tenantID, func(session *gocql.Session, keyring *encryption.Keyring) error {
+		var err error
 
-	return deleteExistingAddress(tenantID, applicationID, addressID, session)
-}
+		page, err = listAddresses(ctx, tenantID, applicationID, pageSize, pageState, keyring, session)
 
-// mapSystemUUIDToGocqlUUID maps the system type UUID to gocql UUID type
-func mapSystemUUIDToGocqlUUID(uuid system.UUID) gocql.UUID {
-	mappedUUID, _ := gocql.UUIDFromBytes(uuid.Bytes())
+		return err
+	})
 
-	return mappedUUID
+	if err != nil {
+		return contract.AddressPage{}, err
+	}
+
+	return page, nil
 }
 
-// addNewAddress adds new address to address table
-func addNewAddress(
+// FindByDetail retrieves the addresses owned by a tenant's application whose details match all the provided criteria.
+// The deleted addresses are not returned, and no more than MaxFoundAddresses addresses are returned. The candidate
+// addresses are read from address_indexed_by_address_value table using one of the criteria, and the rest of the
+// criteria are checked against their details.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the addresses.
+// applicationID: Mandatory. The unique identifier of the tenant's application owning the addresses.
+// criteria: Mandatory. The address details keys along with the values the returned addresses must have.
+// Returns either the matching addresses or error if something goes wrong.
+func (addressDataService *AddressDataService) FindByDetail(
+	ctx context.Context,
 	tenantID, applicationID system.UUID,
-	address contract.Address,
-	addressID system.UUID,
-	session *gocql.Session) error {
-	addressDetailsCount := len(address.AddressDetails)
+	criteria map[string]string) ([]contract.ListedAddress, error) {
+	diagnostics.IsNotNil(addressDataService.ClusterConfig, "addressDataService.ClusterConfig", "ClusterConfig must be provided.")
 
-	errorChannel := make(chan error, addressDetailsCount*2)
+	var listedAddresses []contract.ListedAddress
 
-	mappedTenantID := mapSystemUUIDToGocqlUUID(tenantID)
-	mappedApplicationID := mapSystemUUIDToGocqlUUID(applicationID)
-	mappedAddressID := mapSystemUUIDToGocqlUUID(addressID)
+	err := addressDataService.executeWithKeyring(ctx, tenantID, func(session *gocql.Session, keyring *encryption.Keyring) error {
+		var err error
 
-	var waitGroup sync.WaitGroup
+		listedAddresses, err = findAddressesByDetail(ctx, tenantID, applicationID, criteria, keyring, session)
 
-	for key, value := range address.AddressDetails {
-		waitGroup.Add(1)
+		return err
+	})
 
-		go addToAddressTable(
-			session,
-			errorChannel,
-			&waitGroup,
-			mappedTenantID,
-			mappedApplicationID,
-			mappedAddressID,
-			key,
-			value)
+	if err != nil {
+		return nil, err
+	}
 
-		waitGroup.Add(1)
+	return listedAddresses, nil
+}
 
-		go addToAddressIndexByAddressKeyTable(
-			session,
-			errorChannel,
-			&waitGroup,
-			mappedTenantID,
-			mappedApplicationID,
-			mappedAddressID,
-			key,
-			value)
+// History retrieves all the recorded versions of an address, including the versions recorded when it was deleted and
+// restored. The history is kept after the address is purged.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+// applicationID: Mandatory. The unique identifier of the tenant's application will be owning the address.
+// addressID: Mandatory. The unique identifier of the address.
+// Returns either the recorded versions ordered from the oldest to the newest or error if no version is recorded or
+// something goes wrong.
+func (addressDataService *AddressDataService) History(ctx context.Context, tenantID, applicationID, addressID system.UUID) ([]contract.AddressHistoryEntry, error) {
+	diagnostics.IsNotNil(addressDataService.ClusterConfig, "addressDataService.ClusterConfig", "ClusterConfig must be provided.")
+
+	var history []contract.AddressHistoryEntry
+
+	err := addressDataService.executeWithKeyring(ctx, tenantID, func(session *gocql.Session, keyring *encryption.Keyring) error {
+		var err error
+
+		history, err = readAddressHistory(ctx, tenantID, applicationID, addressID, keyring, session)
+
+		return err
+	})
+
+	if err != nil {
+		return nil, err
 	}
 
-	go func() {
-		waitGroup.Wait()
-		close(errorChannel)
-	}()
+	return history, nil
+}
+
+// ReadAt retrieves the address information as it was at the provided time.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+// applicationID: Mandatory. The unique identifier of the tenant's application will be owning the address.
+// addressID: Mandatory. The unique identifier of the address.
+// timestamp: Mandatory. The time to return the address information at.
+// Returns either the address information or error if the address did not exist or was deleted at the provided time, or
+// if something goes wrong.
+func (addressDataService *AddressDataService) ReadAt(ctx context.Context, tenantID, applicationID, addressID system.UUID, timestamp time.Time) (contract.Address, error) {
+	diagnostics.IsNotNil(addressDataService.ClusterConfig, "addressDataService.ClusterConfig", "ClusterConfig must be provided.")
+
+	var address contract.Address
 
-	errorMessage := ""
-	errorFound := false
+	err := addressDataService.executeWithKeyring(ctx, tenantID, func(session *gocql.Session, keyring *encryption.Keyring) error {
+		history, err := readAddressHistory(ctx, tenantID, applicationID, addressID, keyring, session)
 
-	for err := range errorChannel {
 		if err != nil {
-			errorMessage += err.Error()
-			errorMessage += "\n"
-			errorFound = true
+			return err
 		}
-	}
 
-	if errorFound {
-		return errors.New(errorMessage)
+		address, err = findAddressAt(history, timestamp, addressID)
+
+		return err
+	})
+
+	if err != nil {
+		return contract.Address{}, err
 	}
 
-	return nil
+	return address, nil
+}
+
+// Delete marks an existing address as deleted. The deleted address is not returned anymore but it can be restored
+// until it is purged.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+// applicationID: Mandatory. The unique identifier of the tenant's application will be owning the address.
+// addressID: Mandatory. The unique identifier of the existing address to remove.
+// expectedVersion: Optional. The version the address is expected to have, or AnyVersion to skip the version check.
+// changedBy: Optional. Identifies who makes the change, recorded in the address history.
+// Returns ConflictError if the address version does not match the expected version, or error if something goes wrong.
+func (addressDataService *AddressDataService) Delete(ctx context.Context, tenantID, applicationID, addressID system.UUID, expectedVersion int64, changedBy string) error {
+	diagnostics.IsNotNil(addressDataService.ClusterConfig, "addressDataService.ClusterConfig", "ClusterConfig must be provided.")
 
+	return addressDataService.executeWithKeyring(ctx, tenantID, func(session *gocql.Session, keyring *encryption.Keyring) error {
+		return deleteExistingAddress(ctx, tenantID, applicationID, addressID, expectedVersion, changedBy, addressDataService.RecordEvents, keyring, session)
+	})
 }
 
-// removeExistingAddress adds new address to address table
-func removeExistingAddress(
+// DeleteMany marks several existing addresses as deleted, skipping the version check. Every address is deleted on its
+// own, so failing to delete an address does not stop deleting the rest of them.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the addresses.
+// applicationID: Mandatory. The unique identifier of the tenant's application owning the addresses.
+// addressIDs: Mandatory. The unique identifiers of the existing addresses to remove.
+// changedBy: Optional. Identifies who makes the change, recorded in the address history.
+// Returns the result of deleting every address in the same order as addressIDs.
+func (addressDataService *AddressDataService) DeleteMany(
+	ctx context.Context,
 	tenantID, applicationID system.UUID,
-	address contract.Address,
-	addressID system.UUID,
-	session *gocql.Session) error {
-	addressDetailsCount := len(address.AddressDetails)
+	addressIDs []system.UUID,
+	changedBy string) []contract.AddressResult {
+	return deleteAddresses(ctx, addressDataService, tenantID, applicationID, addressIDs, changedBy)
+}
 
-	errorChannel := make(chan error, addressDetailsCount+1)
+// Restore restores a deleted address.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+// applicationID: Mandatory. The unique identifier of the tenant's application will be owning the address.
+// addressID: Mandatory. The unique identifier of the deleted address to restore.
+// deletedSince: Mandatory. The address is restored only if it was deleted at or after this time.
+// changedBy: Optional. Identifies who makes the change, recorded in the address history.
+// Returns error if the deleted address does not exist or was deleted before deletedSince, or if something goes wrong.
+func (addressDataService *AddressDataService) Restore(
+	ctx context.Context,
+	tenantID, applicationID, addressID system.UUID,
+	deletedSince time.Time,
+	changedBy string) error {
+	diagnostics.IsNotNil(addressDataService.ClusterConfig, "addressDataService.ClusterConfig", "ClusterConfig must be provided.")
 
-	mappedTenantID := mapSystemUUIDToGocqlUUID(tenantID)
-	mappedApplicationID := mapSystemUUIDToGocqlUUID(applicationID)
-	mappedAddressID := mapSystemUUIDToGocqlUUID(addressID)
+	return addressDataService.executeWithKeyring(ctx, tenantID, func(session *gocql.Session, keyring *encryption.Keyring) error {
+		version, deletedAt, err := readAddressMetadata(ctx, tenantID, applicationID, addressID, session)
 
-	var waitGroup sync.WaitGroup
+		if err != nil {
+			return err
+		}
 
-	waitGroup.Add(1)
+		if deletedAt.IsZero() || deletedAt.Before(deletedSince) {
+			return contract.NotFoundError{AddressID: addressID, Deleted: true}
+		}
 
-	go removeFromAddressTable(
-		session,
-		errorChannel,
-		&waitGroup,
-		mappedTenantID,
-		mappedApplicationID,
-		mappedAddressID)
+		return restoreDeletedAddress(ctx, tenantID, applicationID, addressID, version, deletedAt, changedBy, keyring, session)
+	})
+}
 
-	for key := range address.AddressDetails {
-		waitGroup.Add(1)
+// Purge removes a deleted address for good.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+// applicationID: Mandatory. The unique identifier of the tenant's application will be owning the address.
+// addressID: Mandatory. The unique identifier of the deleted address to purge.
+// Returns error if the deleted address does not exist, or if something goes wrong.
+func (addressDataService *AddressDataService) Purge(ctx context.Context, tenantID, applicationID, addressID system.UUID) error {
+	diagnostics.IsNotNil(addressDataService.ClusterConfig, "addressDataService.ClusterConfig", "ClusterConfig must be provided.")
 
-		go removeFromIndexByAddressKeyTable(
-			session,
-			errorChannel,
-			&waitGroup,
-			mappedTenantID,
-			mappedApplicationID,
-			mappedAddressID,
-			key)
-	}
+	return addressDataService.executeWithSession(func(session *gocql.Session) error {
+		_, deletedAt, err := readAddressMetadata(ctx, tenantID, applicationID, addressID, session)
+
+		if err != nil {
+			return err
+		}
+
+		if deletedAt.IsZero() {
+			return contract.NotFoundError{AddressID: addressID, Deleted: true}
+		}
+
+		return purgeDeletedAddress(ctx, tenantID, applicationID, addressID, deletedAt, session)
+	})
+}
 
-	go func() {
-		waitGroup.Wait()
-		close(errorChannel)
-	}()
+// PurgeDeleted removes for good all the addresses of all tenants deleted before the provided time. The deleted
+// addresses are read from the hourly buckets of deleted_address table, starting from the first bucket not purged yet as
+// recorded in deleted_address_purge table, so only the addresses due to be purged are read. The first bucket is moved
+// forward once all the addresses of a bucket are purged and deletedAddressPurgeDelay has passed since its hour was
+// over. Every instance may run it at the same time, as purging an address again does nothing.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// deletedBefore: Mandatory. The addresses deleted before this time are purged.
+// Returns error if something goes wrong.
+func (addressDataService *AddressDataService) PurgeDeleted(ctx context.Context, deletedBefore time.Time) error {
+	diagnostics.IsNotNil(addressDataService.ClusterConfig, "addressDataService.ClusterConfig", "ClusterConfig must be provided.")
 
-	errorMessage := ""
-	errorFound := false
+	return addressDataService.executeWithSession(func(session *gocql.Session) error {
+		nextHour, err := readNextDeletedAddressPurgeHour(ctx, deletedBefore, session)
 
-	for err := range errorChannel {
 		if err != nil {
-			errorMessage += err.Error()
-			errorMessage += "\n"
-			errorFound = true
+			return err
 		}
-	}
 
-	if errorFound {
-		return errors.New(errorMessage)
-	}
+		for deletedHour := nextHour; deletedHour.Before(deletedBefore); deletedHour = deletedHour.Add(time.Hour) {
+			if err = purgeDeletedAddressBucket(ctx, deletedHour, deletedBefore, session); err != nil {
+				return err
+			}
+
+			hourOver := deletedHour.Add(time.Hour)
+
+			if hourOver.After(deletedBefore) || hourOver.Add(deletedAddressPurgeDelay).After(time.Now()) {
+				return nil
+			}
+
+			// The progress is only moved forward from the purged hour, so an instance that purged an earlier hour cannot move
+			// it back.
+			applied, err := session.Query(
+				"UPDATE deleted_address_purge"+
+					" SET next_hour = ?"+
+					" WHERE name = 'deleted_address'"+
+					" IF next_hour = ?",
+				hourOver,
+				deletedHour).WithContext(ctx).MapScanCAS(make(map[string]interface{}))
+
+			if err != nil || !applied {
+				return err
+			}
+		}
 
-	return nil
+		return nil
+	})
 }
 
-// addToAddressTable adds new address key/value to address table using provided address unique identifier.
-func addToAddressTable(
-	session *gocql.Session,
-	errorChannel chan<- error,
-	waitGroup *sync.WaitGroup,
-	tenantID, applicationID, addressID gocql.UUID,
-	key, value string) {
+// getSession returns the shared session, creating it if it does not exist yet.
+func (addressDataService *AddressDataService) getSession() (*gocql.Session, error) {
+	addressDataService.sessionLock.Lock()
+	defer addressDataService.sessionLock.Unlock()
 
-	defer waitGroup.Done()
+	if addressDataService.session == nil || addressDataService.session.Closed() {
+		session, err := addressDataService.ClusterConfig.CreateSession()
 
-	if err := session.Query(
-		"INSERT INTO address"+
-			" (tenant_id, application_id, address_id, address_key, address_value)"+
-			" VALUES(?, ?, ?, ?, ?)",
-		tenantID,
-		applicationID,
-		addressID,
-		key,
-		value).
-		Exec(); err != nil {
-		errorChannel <- err
-	} else {
-		errorChannel <- nil
+		if err != nil {
+			return nil, err
+		}
+
+		addressDataService.session = session
 	}
-}
 
-// addToAddressIndexByAddressKeyTable adds address key/value to index table, so running query on address key will be faster.
-func addToAddressIndexByAddressKeyTable(
-	session *gocql.Session,
-	errorChannel chan<- error,
-	waitGroup *sync.WaitGroup,
-	tenantID, applicationID, addressID gocql.UUID,
-	key, value string) {
+	return addressDataService.session, nil
+}
 
-	defer waitGroup.Done()
+// discardSession closes the provided session and removes it from the service if it is still the shared session, so the
+// next call creates a new one.
+func (addressDataService *AddressDataService) discardSession(session *gocql.Session) {
+	addressDataService.sessionLock.Lock()
+	defer addressDataService.sessionLock.Unlock()
 
-	if err := session.Query(
-		"INSERT INTO address_indexed_by_address_key"+
-			" (tenant_id, application_id, address_id, address_key, address_value)"+
-			" VALUES(?, ?, ?, ?, ?)",
-		tenantID,
-		applicationID,
-		addressID,
-		key,
-		value).
-		Exec(); err != nil {
-		errorChannel <- err
-	} else {
-		errorChannel <- nil
+	if addressDataService.session == session {
+		addressDataService.session.Close()
+		addressDataService.session = nil
 	}
 }
 
-// removeFromAddressTable removes an existing address from address table using provided address unique identifier.
-func removeFromAddressTable(
-	session *gocql.Session,
-	errorChannel chan<- error,
-	waitGroup *sync.WaitGroup,
-	tenantID, applicationID, addressID gocql.UUID) {
+// executeWithSession runs the provided function using the shared session. If the function fails because the session lost
+// all its connections to the cluster, the session is recreated and the function is retried once. The errors returned
+// when the cluster is unavailable are returned as UnavailableError.
+func (addressDataService *AddressDataService) executeWithSession(function func(session *gocql.Session) error) error {
+	session, err := addressDataService.getSession()
+
+	if err != nil {
+		return contract.UnavailableError{Err: err}
+	}
 
-	defer waitGroup.Done()
+	if err = function(session); !isTopologyFailure(err) {
+		return mapCassandraError(err)
+	}
 
-	if err := session.Query(
-		"DELETE FROM address"+
-			" WHERE"+
-			" tenant_id = ?"+
-			" AND application_id = ?"+
-			" AND address_id = ?",
-		tenantID,
-		applicationID,
-		addressID).
-		Exec(); err != nil {
-		errorChannel <- err
-	} else {
-		errorChannel <- nil
+	addressDataService.discardSession(session)
+
+	if session, err = addressDataService.getSession(); err != nil {
+		return contract.UnavailableError{Err: err}
 	}
+
+	return mapCassandraError(function(session))
 }
 
-// removeFromIndexByAddressKeyTable removes an address key from index table.
-func removeFromIndexByAddressKeyTable(
-	session *gocql.Session,
-	errorChannel chan<- error,
-	waitGroup *sync.WaitGroup,
-	tenantID, applicationID, addressID gocql.UUID,
-	key string) {
+// isTopologyFailure returns true if the provided error indicates the session cannot reach the cluster anymore.
+func isTopologyFailure(err error) bool {
+	return err == gocql.ErrNoConnections ||
+		err == gocql.ErrSessionClosed ||
+		err == gocql.ErrConnectionClosed
+}
 
-	defer waitGroup.Done()
+// mapCassandraError maps the errors returned when the cluster cannot be reached or does not respond in time to
+// UnavailableError, and returns the rest of the errors as they are.
+func mapCassandraError(err error) error {
+	switch err.(type) {
+	case *gocql.RequestErrUnavailable, *gocql.RequestErrReadTimeout, *gocql.RequestErrWriteTimeout:
+		return contract.UnavailableError{Err: err}
+	}
 
-	if err := session.Query(
-		"DELETE FROM address_indexed_by_address_key"+
-			" WHERE"+
-			" tenant_id = ? "+
-			" AND application_id = ?"+
-			" AND address_id = ?"+
-			" AND address_key = ?",
-		tenantID,
-		applicationID,
-		addressID,
-		key).
-		Exec(); err != nil {
-		errorChannel <- err
-	} else {
-		errorChannel <- nil
+	if isTopologyFailure(err) || err == gocql.ErrTimeoutNoResponse || err == context.DeadlineExceeded {
+		return contract.UnavailableError{Err: err}
 	}
+
+	return err
 }
 
-// doesAddressExist checks whether the provided addressID exists in database
-func doesAddressExist(tenantID, applicationID, addressID system.UUID, session *gocql.Session) bool {
-	iter := session.Query(
-		"SELECT address_key"+
-			" FROM address"+
+// mapSystemUUIDToGocqlUUID maps the system type UUID to gocql UUID type
+func mapSystemUUIDToGocqlUUID(uuid system.UUID) gocql.UUID {
+	mappedUUID, _ := gocql.UUIDFromBytes(uuid.Bytes())
+
+	return mappedUUID
+}
+
+// mapGocqlUUIDToSystemUUID maps the gocql UUID type to system type UUID
+func mapGocqlUUIDToSystemUUID(uuid gocql.UUID) system.UUID {
+	mappedUUID, _ := system.UUIDFromBytes(uuid.Bytes())
+
+	return mappedUUID
+}
+
+// mapDurationToCassandraTTL maps the time to live of an address to the TTL in seconds used by Cassandra, rounding it up to
+// the next second. Zero means the rows never expire.
+func mapDurationToCassandraTTL(ttl time.Duration) int {
+	return int((ttl + time.Second - 1) / time.Second)
+}
+
+// mapTimeToCassandraTimestamp maps the zero time to null, so it is not stored as 1970-01-01 in Cassandra.
+func mapTimeToCassandraTimestamp(value time.Time) interface{} {
+	if value.IsZero() {
+		return nil
+	}
+
+	return value
+}
+
+// addNewAddress adds new address to address, address_indexed_by_address_key and address_indexed_by_address_value
+// tables, its first version to address_history table and its fingerprint to address_indexed_by_fingerprint table in one
+// logged batch, so either all the address details are written to all tables or none of them. The first version and the
+// fingerprint are written to address_metadata table by a lightweight transaction first, which is changed back if the
+// batch is known not to be applied. The address values are encrypted once and the same encrypted values are written to
+// all tables.
+func addNewAddress(
+	ctx context.Context,
+	tenantID, applicationID system.UUID,
+	address contract.Address,
+	addressID system.UUID,
+	changedBy string,
+	recordEvents bool,
+	keyring *encryption.Keyring,
+	session *gocql.Session) error {
+	mappedTenantID := mapSystemUUIDToGocqlUUID(tenantID)
+	mappedApplicationID := mapSystemUUIDToGocqlUUID(applicationID)
+	mappedAddressID := mapSystemUUIDToGocqlUUID(addressID)
+
+	encryptedAddressDetails, err := encryptAddressDetails(keyring, address.AddressDetails)
+
+	if err != nil {
+		return err
+	}
+
+	fingerprintHash, err := hashAddressFingerprint(keyring, address.Fingerprint)
+
+	if err != nil {
+		return err
+	}
+
+	hashes := make(map[string]string, len(address.AddressDetails))
+
+	for key, value := range address.AddressDetails {
+		if hashes[key], err = keyring.Hash(key, value); err != nil {
+			return err
+		}
+	}
+
+	existingMetadata, err := changeAddressVersion(
+		ctx,
+		tenantID,
+		applicationID,
+		addressID,
+		0,
+		addressMetadataChange{fingerprint: &fingerprintHash},
+		address.TTL,
+		session)
+
+	if err != nil {
+		return err
+	}
+
+	ttl := mapDurationToCassandraTTL(address.TTL)
+	batch := session.NewBatch(gocql.LoggedBatch)
+
+	for key := range address.AddressDetails {
+		hash := hashes[key]
+
+		addToAddressTable(batch, mappedTenantID, mappedApplicationID, mappedAddressID, key, encryptedAddressDetails[key], ttl)
+		addToAddressIndexByAddressKeyTable(batch, mappedTenantID, mappedApplicationID, mappedAddressID, key, encryptedAddressDetails[key], hash, ttl)
+	}
+
+	if fingerprintHash != "" {
+		changeAddressFingerprint(batch, mappedTenantID, mappedApplicationID, mappedAddressID, "", fingerprintHash, ttl)
+	}
+
+	addToAddressHistoryTable(batch, mappedTenantID, mappedApplicationID, mappedAddressID, encryptedAddressDetails, 1, changedBy, false)
+
+	if recordEvents {
+		addToAddressEventOutboxTable(
+			batch,
+			contract.AddressCreated,
+			mappedTenantID,
+			mappedApplicationID,
+			mappedAddressID,
+			1,
+			nil,
+			encryptedAddressDetails,
+			changedBy)
+	}
+
+	return executeVersionedBatch(ctx, tenantID, applicationID, addressID, existingMetadata, address.TTL, batch, session)
+}
+
+// updateExistingAddress compares the stored details of an existing address with the new ones and writes only the added,
+// changed and removed keys to address, address_indexed_by_address_key and address_indexed_by_address_value tables along
+// with the new version to address_history table and the changed fingerprint to address_indexed_by_fingerprint table in
+// one logged batch, once the new version and the fingerprint are written to address_metadata table by
+// changeAddressVersion. If either the existing or the new address expires, all the
+// keys, the fingerprint and the owners are written so they expire together. The address rows live in a single
+// partition, so concurrent readers see either the old or the new details and never a missing address. Everything that
+// can fail is prepared before the version is changed, so the version is not changed unless the batch is executed.
+func updateExistingAddress(
+	ctx context.Context,
+	tenantID, applicationID system.UUID,
+	existingAddress contract.Address,
+	address contract.Address,
+	addressID system.UUID,
+	expectedVersion int64,
+	changedBy string,
+	recordEvents bool,
+	keyring *encryption.Keyring,
+	session *gocql.Session) error {
+	changedAddressDetails, removedAddressKeys := diffAddressDetails(existingAddress.AddressDetails, address.AddressDetails)
+	expires := existingAddress.TTL != 0 || address.TTL != 0
+
+	if expires {
+		changedAddressDetails = address.AddressDetails
+	}
+
+	encryptedAddressDetails, err := encryptAddressDetails(keyring, address.AddressDetails)
+
+	if err != nil {
+		return err
+	}
+
+	hashes := make(map[string]string, len(changedAddressDetails))
+
+	for key, value := range changedAddressDetails {
+		if hashes[key], err = keyring.Hash(key, value); err != nil {
+			return err
+		}
+	}
+
+	var encryptedExistingAddressDetails map[string]string
+
+	if recordEvents {
+		if encryptedExistingAddressDetails, err = encryptAddressDetails(keyring, existingAddress.AddressDetails); err != nil {
+			return err
+		}
+	}
+
+	var owners []contract.AddressOwner
+
+	if expires {
+		if owners, err = readAddressOwners(ctx, tenantID, applicationID, addressID, session); err != nil {
+			return err
+		}
+	}
+
+	existingKeys := make([]string, 0, len(existingAddress.AddressDetails))
+
+	for key := range existingAddress.AddressDetails {
+		existingKeys = append(existingKeys, key)
+	}
+
+	existingValueHashes, err := readAddressValueHashes(ctx, tenantID, applicationID, addressID, existingKeys, session)
+
+	if err != nil {
+		return err
+	}
+
+	existingMetadata, err := changeAddressVersion(
+		ctx,
+		tenantID,
+		applicationID,
+		addressID,
+		expectedVersion,
+		addressMetadataChange{fingerprint: &address.Fingerprint},
+		address.TTL,
+		session)
+
+	if err != nil {
+		return err
+	}
+
+	newVersion := existingMetadata.version + 1
+	ttl := mapDurationToCassandraTTL(address.TTL)
+
+	mappedTenantID := mapSystemUUIDToGocqlUUID(tenantID)
+	mappedApplicationID := mapSystemUUIDToGocqlUUID(applicationID)
+	mappedAddressID := mapSystemUUIDToGocqlUUID(addressID)
+
+	batch := session.NewBatch(gocql.LoggedBatch)
+
+	for _, key := range removedAddressKeys {
+		removeFromAddressTable(batch, mappedTenantID, mappedApplicationID, mappedAddressID, key)
+		removeFromIndexByAddressKeyTable(batch, mappedTenantID, mappedApplicationID, mappedAddressID, key)
+
+		if valueHash, ok := existingValueHashes[key]; ok {
+			removeFromIndexByAddressValueTable(batch, mappedTenantID, mappedApplicationID, mappedAddressID, key, valueHash)
+		}
+	}
+
+	for key := range changedAddressDetails {
+		// The row of the unchanged value hash is written again instead, as a removal written in the same batch wins.
+		if valueHash, ok := existingValueHashes[key]; ok && valueHash != indexedAddressValueHash(encryptedAddressDetails[key], hashes[key]) {
+			removeFromIndexByAddressValueTable(batch, mappedTenantID, mappedApplicationID, mappedAddressID, key, valueHash)
+		}
+
+		addToAddressTable(batch, mappedTenantID, mappedApplicationID, mappedAddressID, key, encryptedAddressDetails[key], ttl)
+		addToAddressIndexByAddressKeyTable(batch, mappedTenantID, mappedApplicationID, mappedAddressID, key, encryptedAddressDetails[key], hashes[key], ttl)
+	}
+
+	if existingMetadata.fingerprint != address.Fingerprint || expires {
+		changeAddressFingerprint(batch, mappedTenantID, mappedApplicationID, mappedAddressID, existingMetadata.fingerprint, address.Fingerprint, ttl)
+	}
+
+	for _, owner := range owners {
+		addToAddressOwnerTables(batch, mappedTenantID, mappedApplicationID, mappedAddressID, owner, ttl)
+	}
+
+	addToAddressHistoryTable(batch, mappedTenantID, mappedApplicationID, mappedAddressID, encryptedAddressDetails, newVersion, changedBy, false)
+
+	if recordEvents {
+		addToAddressEventOutboxTable(
+			batch,
+			contract.AddressUpdated,
+			mappedTenantID,
+			mappedApplicationID,
+			mappedAddressID,
+			newVersion,
+			encryptedExistingAddressDetails,
+			encryptedAddressDetails,
+			changedBy)
+	}
+
+	return executeVersionedBatch(ctx, tenantID, applicationID, addressID, existingMetadata, existingAddress.TTL, batch, session)
+}
+
+// diffAddressDetails compares the existing address details with the new ones.
+// Returns the new or changed key/value pairs and the keys that no longer exist.
+func diffAddressDetails(existingAddressDetails, newAddressDetails map[string]string) (map[string]string, []string) {
+	changedAddressDetails := make(map[string]string)
+	removedAddressKeys := []string{}
+
+	for key, value := range newAddressDetails {
+		if existingValue, ok := existingAddressDetails[key]; !ok || existingValue != value {
+			changedAddressDetails[key] = value
+		}
+	}
+
+	for key := range existingAddressDetails {
+		if _, ok := newAddressDetails[key]; !ok {
+			removedAddressKeys = append(removedAddressKeys, key)
+		}
+	}
+
+	return changedAddressDetails, removedAddressKeys
+}
+
+// patchAddressDetails applies the keys to set and remove to a copy of the existing address details.
+// Returns either the patched address or error if no address detail would be left after removing the keys.
+func patchAddressDetails(existingAddress contract.Address, set map[string]string, remove []string, addressID system.UUID) (contract.Address, error) {
+	patchedAddress := contract.Address{AddressDetails: make(map[string]string), Version: existingAddress.Version, TTL: existingAddress.TTL}
+
+	for key, value := range existingAddress.AddressDetails {
+		patchedAddress.AddressDetails[key] = value
+	}
+
+	for key, value := range set {
+		patchedAddress.AddressDetails[key] = value
+	}
+
+	for _, key := range remove {
+		delete(patchedAddress.AddressDetails, key)
+	}
+
+	if len(patchedAddress.AddressDetails) == 0 {
+		return contract.Address{}, contract.InvalidArgumentError{
+			Message: fmt.Sprintf("Address cannot be left without any address detail. Address ID: %s", addressID.String()),
+		}
+	}
+
+	return patchedAddress, nil
+}
+
+// findAddressAt returns the address as it was at the provided time from its history ordered from the oldest to the newest
+// version. Returns not found error if the address did not exist or was deleted at the provided time.
+func findAddressAt(history []contract.AddressHistoryEntry, timestamp time.Time, addressID system.UUID) (contract.Address, error) {
+	var foundHistoryEntry *contract.AddressHistoryEntry
+
+	for index := range history {
+		if history[index].ChangedAt.After(timestamp) {
+			break
+		}
+
+		foundHistoryEntry = &history[index]
+	}
+
+	if foundHistoryEntry == nil || foundHistoryEntry.Deleted {
+		return contract.Address{}, contract.NotFoundError{AddressID: addressID}
+	}
+
+	return contract.Address{
+		AddressDetails: copyAddressDetails(foundHistoryEntry.Address.AddressDetails),
+		Version:        foundHistoryEntry.Address.Version,
+	}, nil
+}
+
+// createAddresses creates the provided addresses one by one using the provided address data service.
+// Returns the result of creating every address in the same order as addresses.
+func createAddresses(
+	ctx context.Context,
+	addressDataService contract.AddressDataService,
+	tenantID, applicationID system.UUID,
+	addresses []contract.Address,
+	changedBy string) []contract.AddressResult {
+	results := make([]contract.AddressResult, 0, len(addresses))
+
+	for _, address := range addresses {
+		addressID, err := addressDataService.Create(ctx, tenantID, applicationID, address, changedBy)
+		results = append(results, contract.AddressResult{AddressID: addressID, Err: err})
+	}
+
+	return results
+}
+
+// readAddresses reads the provided addresses one by one using the provided address data service, reading all the details
+// if no key is provided.
+// Returns the result of reading every address in the same order as addressIDs.
+func readAddresses(
+	ctx context.Context,
+	addressDataService contract.AddressDataService,
+	tenantID, applicationID system.UUID,
+	addressIDs []system.UUID,
+	keys []string) []contract.AddressResult {
+	results := make([]contract.AddressResult, 0, len(addressIDs))
+
+	for _, addressID := range addressIDs {
+		var address contract.Address
+		var err error
+
+		if len(keys) == 0 {
+			address, err = addressDataService.ReadAll(ctx, tenantID, applicationID, addressID)
+		} else {
+			address, err = addressDataService.Read(ctx, tenantID, applicationID, addressID, keys)
+		}
+
+		results = append(results, contract.AddressResult{AddressID: addressID, Address: address, Err: err})
+	}
+
+	return results
+}
+
+// deleteAddresses deletes the provided addresses one by one using the provided address data service, skipping the version
+// check.
+// Returns the result of deleting every address in the same order as addressIDs.
+func deleteAddresses(
+	ctx context.Context,
+	addressDataService contract.AddressDataService,
+	tenantID, applicationID system.UUID,
+	addressIDs []system.UUID,
+	changedBy string) []contract.AddressResult {
+	results := make([]contract.AddressResult, 0, len(addressIDs))
+
+	for _, addressID := range addressIDs {
+		err := addressDataService.Delete(ctx, tenantID, applicationID, addressID, contract.AnyVersion, changedBy)
+		results = append(results, contract.AddressResult{AddressID: addressID, Err: err})
+	}
+
+	return results
+}
+
+// matchAddressDetails returns true if the provided address details have all the keys of the provided criteria with the
+// same values.
+func matchAddressDetails(addressDetails map[string]string, criteria map[string]string) bool {
+	for key, value := range criteria {
+		if addressValue, ok := addressDetails[key]; !ok || addressValue != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+// encodeAddressIDCursor returns the cursor pointing after the provided address, used by the data services paging through
+// the addresses ordered by their unique identifiers.
+func encodeAddressIDCursor(addressID system.UUID) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(addressID.String()))
+}
+
+// decodeAddressIDCursor returns the unique identifier of the address the provided cursor points after, or empty string if
+// the cursor is empty. Returns error if the cursor is invalid.
+func decodeAddressIDCursor(cursor string) (string, error) {
+	if cursor == "" {
+		return "", nil
+	}
+
+	decodedCursor, err := base64.RawURLEncoding.DecodeString(cursor)
+
+	if err == nil {
+		_, err = system.ParseUUID(string(decodedCursor))
+	}
+
+	if err != nil {
+		return "", contract.InvalidArgumentError{Message: fmt.Sprintf("Invalid cursor. Cursor: %s", cursor)}
+	}
+
+	return string(decodedCursor), nil
+}
+
+// purgeDeletedAddress removes a deleted address from address, address_indexed_by_address_key,
+// address_indexed_by_address_value, address_indexed_by_fingerprint, address_owner, address_indexed_by_owner,
+// deleted_address and address_metadata tables in one logged batch, so either the address is removed from all the tables
+// or none of them. deletedAt is the time the address was deleted at, which its deleted_address row is keyed by.
+func purgeDeletedAddress(ctx context.Context, tenantID, applicationID, addressID system.UUID, deletedAt time.Time, session *gocql.Session) error {
+	fingerprint, err := readAddressFingerprint(ctx, tenantID, applicationID, addressID, session)
+
+	if err != nil {
+		return err
+	}
+
+	owners, err := readAddressOwners(ctx, tenantID, applicationID, addressID, session)
+
+	if err != nil {
+		return err
+	}
+
+	iter := session.Query(
+		"SELECT address_key"+
+			" FROM address"+
+			" WHERE"+
+			" tenant_id = ?"+
+			" AND application_id = ?"+
+			" AND address_id = ?",
+		tenantID.String(),
+		applicationID.String(),
+		addressID.String()).WithContext(ctx).Iter()
+
+	mappedTenantID := mapSystemUUIDToGocqlUUID(tenantID)
+	mappedApplicationID := mapSystemUUIDToGocqlUUID(applicationID)
+	mappedAddressID := mapSystemUUIDToGocqlUUID(addressID)
+
+	batch := session.NewBatch(gocql.LoggedBatch)
+
+	var key string
+
+	keys := []string{}
+
+	for iter.Scan(&key) {
+		keys = append(keys, key)
+	}
+
+	if err := iter.Close(); err != nil {
+		return err
+	}
+
+	valueHashes, err := readAddressValueHashes(ctx, tenantID, applicationID, addressID, keys, session)
+
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		removeFromAddressTable(batch, mappedTenantID, mappedApplicationID, mappedAddressID, key)
+		removeFromIndexByAddressKeyTable(batch, mappedTenantID, mappedApplicationID, mappedAddressID, key)
+
+		if valueHash, ok := valueHashes[key]; ok {
+			removeFromIndexByAddressValueTable(batch, mappedTenantID, mappedApplicationID, mappedAddressID, key, valueHash)
+		}
+	}
+
+	if fingerprint != "" {
+		removeFromIndexByFingerprintTable(batch, mappedTenantID, mappedApplicationID, mappedAddressID, fingerprint)
+	}
+
+	for _, owner := range owners {
+		removeFromAddressOwnerTables(batch, mappedTenantID, mappedApplicationID, mappedAddressID, owner)
+	}
+
+	removeFromDeletedAddressTable(batch, mappedTenantID, mappedApplicationID, mappedAddressID, deletedAt)
+
+	batch.Query(
+		"DELETE FROM address_metadata"+
+			" WHERE"+
+			" tenant_id = ?"+
+			" AND application_id = ?"+
+			" AND address_id = ?",
+		mappedTenantID,
+		mappedApplicationID,
+		mappedAddressID)
+
+	return session.ExecuteBatch(batch.WithContext(ctx))
+}
+
+// addToAddressTable adds the statement inserting address key/value to address table to the provided batch. The row expires
+// after the provided TTL in seconds, or never if it is zero.
+func addToAddressTable(
+	batch *gocql.Batch,
+	tenantID, applicationID, addressID gocql.UUID,
+	key, value string,
+	ttl int) {
+	batch.Query(
+		"INSERT INTO address"+
+			" (tenant_id, application_id, address_id, address_key, address_value)"+
+			" VALUES(?, ?, ?, ?, ?)"+
+			" USING TTL ?",
+		tenantID,
+		applicationID,
+		addressID,
+		key,
+		value,
+		ttl)
+}
+
+// addToAddressIndexByAddressKeyTable adds the statements inserting address key/value along with the value hash to
+// address_indexed_by_address_key table and the value hash to address_indexed_by_address_value table to the provided
+// batch, so running query on address key or value will be faster. The rows expire after the provided TTL in seconds, or
+// never if it is zero.
+func addToAddressIndexByAddressKeyTable(
+	batch *gocql.Batch,
+	tenantID, applicationID, addressID gocql.UUID,
+	key, value, hash string,
+	ttl int) {
+	batch.Query(
+		"INSERT INTO address_indexed_by_address_key"+
+			" (tenant_id, application_id, address_id, address_key, address_value, address_value_hash)"+
+			" VALUES(?, ?, ?, ?, ?, ?)"+
+			" USING TTL ?",
+		tenantID,
+		applicationID,
+		addressID,
+		key,
+		value,
+		hash,
+		ttl)
+	batch.Query(
+		"INSERT INTO address_indexed_by_address_value"+
+			" (tenant_id, application_id, address_key, address_value_hash, address_id)"+
+			" VALUES(?, ?, ?, ?, ?)"+
+			" USING TTL ?",
+		tenantID,
+		applicationID,
+		key,
+		indexedAddressValueHash(value, hash),
+		addressID,
+		ttl)
+}
+
+// removeFromAddressTable adds the statement removing an address key from address table to the provided batch.
+func removeFromAddressTable(
+	batch *gocql.Batch,
+	tenantID, applicationID, addressID gocql.UUID,
+	key string) {
+	batch.Query(
+		"DELETE FROM address"+
+			" WHERE"+
+			" tenant_id = ?"+
+			" AND application_id = ?"+
+			" AND address_id = ?"+
+			" AND address_key = ?",
+		tenantID,
+		applicationID,
+		addressID,
+		key)
+}
+
+// removeFromIndexByAddressKeyTable adds the statement removing an address key from index table to the provided batch.
+func removeFromIndexByAddressKeyTable(
+	batch *gocql.Batch,
+	tenantID, applicationID, addressID gocql.UUID,
+	key string) {
+	batch.Query(
+		"DELETE FROM address_indexed_by_address_key"+
+			" WHERE"+
+			" tenant_id = ? "+
+			" AND application_id = ?"+
+			" AND address_id = ?"+
+			" AND address_key = ?",
+		tenantID,
+		applicationID,
+		addressID,
+		key)
+}
+
+// removeFromIndexByAddressValueTable adds the statement removing an address key from address_indexed_by_address_value
+// table to the provided batch. valueHash is the hash the address key is indexed by.
+func removeFromIndexByAddressValueTable(
+	batch *gocql.Batch,
+	tenantID, applicationID, addressID gocql.UUID,
+	key, valueHash string) {
+	batch.Query(
+		"DELETE FROM address_indexed_by_address_value"+
+			" WHERE"+
+			" tenant_id = ?"+
+			" AND application_id = ?"+
+			" AND address_key = ?"+
+			" AND address_value_hash = ?"+
+			" AND address_id = ?",
+		tenantID,
+		applicationID,
+		key,
+		valueHash,
+		addressID)
+}
+
+// indexedAddressValueHash returns the hash an address detail is indexed by in address_indexed_by_address_value table,
+// i.e. the keyed hash of its value, or the stored value itself if it is in plaintext and has no hash.
+func indexedAddressValueHash(storedValue, storedHash string) string {
+	if storedHash != "" {
+		return storedHash
+	}
+
+	return storedValue
+}
+
+// readAddressValueHashes returns the hashes the provided keys of an existing address are indexed by in
+// address_indexed_by_address_value table, read from address_indexed_by_address_key table. The keys without an index row
+// are left out. The keys are read one by one, as Cassandra 2.0 allows IN only on the last restricted clustering column.
+func readAddressValueHashes(
+	ctx context.Context,
+	tenantID, applicationID, addressID system.UUID,
+	keys []string,
+	session *gocql.Session) (map[string]string, error) {
+	valueHashes := make(map[string]string, len(keys))
+
+	for _, key := range keys {
+		var value, hash string
+
+		err := session.Query(
+			"SELECT address_value, address_value_hash"+
+				" FROM address_indexed_by_address_key"+
+				" WHERE"+
+				" tenant_id = ?"+
+				" AND application_id = ?"+
+				" AND address_key = ?"+
+				" AND address_id = ?",
+			mapSystemUUIDToGocqlUUID(tenantID),
+			mapSystemUUIDToGocqlUUID(applicationID),
+			key,
+			mapSystemUUIDToGocqlUUID(addressID)).WithContext(ctx).Scan(&value, &hash)
+
+		if err == gocql.ErrNotFound {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		valueHashes[key] = indexedAddressValueHash(value, hash)
+	}
+
+	return valueHashes, nil
+}
+
+// addToAddressHistoryTable adds the statement inserting a version of an address to address_history table to the provided
+// batch. The address details values must be already encrypted.
+func addToAddressHistoryTable(
+	batch *gocql.Batch,
+	tenantID, applicationID, addressID gocql.UUID,
+	addressDetails map[string]string,
+	version int64,
+	changedBy string,
+	deleted bool) {
+	batch.Query(
+		"INSERT INTO address_history"+
+			" (tenant_id, application_id, address_id, version, address_details, changed_at, changed_by, deleted)"+
+			" VALUES(?, ?, ?, ?, ?, ?, ?, ?)",
+		tenantID,
+		applicationID,
+		addressID,
+		version,
+		addressDetails,
+		time.Now(),
+		changedBy,
+		deleted)
+}
+
+// readAddressHistory returns all the recorded versions of an address ordered from the oldest to the newest, decrypting the
+// address details values. Returns not found error if no version is recorded.
+func readAddressHistory(
+	ctx context.Context,
+	tenantID, applicationID, addressID system.UUID,
+	keyring *encryption.Keyring,
+	session *gocql.Session) ([]contract.AddressHistoryEntry, error) {
+	iter := session.Query(
+		"SELECT version, address_details, changed_at, changed_by, deleted"+
+			" FROM address_history"+
+			" WHERE"+
+			" tenant_id = ?"+
+			" AND application_id = ?"+
+			" AND address_id = ?",
+		tenantID.String(),
+		applicationID.String(),
+		addressID.String()).WithContext(ctx).Iter()
+
+	history := []contract.AddressHistoryEntry{}
+
+	var historyEntry contract.AddressHistoryEntry
+
+	for iter.Scan(
+		&historyEntry.Address.Version,
+		&historyEntry.Address.AddressDetails,
+		&historyEntry.ChangedAt,
+		&historyEntry.ChangedBy,
+		&historyEntry.Deleted) {
+		addressDetails, err := decryptAddressDetails(keyring, historyEntry.Address.AddressDetails)
+
+		if err != nil {
+			iter.Close()
+
+			return nil, err
+		}
+
+		historyEntry.Address.AddressDetails = addressDetails
+		history = append(history, historyEntry)
+		historyEntry = contract.AddressHistoryEntry{}
+	}
+
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+
+	if len(history) == 0 {
+		return nil, contract.NotFoundError{AddressID: addressID}
+	}
+
+	return history, nil
+}
+
+// deleteExistingAddress marks an existing address as deleted by storing the time it was deleted at. The address details are
+// kept in address and address_indexed_by_address_key tables until the address is purged. The new version is claimed by
+// a lightweight transaction first along with the deletion time, then the address is written to the bucket of the hour it
+// was deleted in of deleted_address table in one logged batch along with the history entry and the event recording the
+// deletion, so the address is never deleted without its event.
+func deleteExistingAddress(
+	ctx context.Context,
+	tenantID, applicationID, addressID system.UUID,
+	expectedVersion int64,
+	changedBy string,
+	recordEvents bool,
+	keyring *encryption.Keyring,
+	session *gocql.Session) error {
+	existingAddress, err := readAllAddressDetails(ctx, tenantID, applicationID, addressID, keyring, session)
+
+	if err != nil {
+		return err
+	}
+
+	encryptedAddressDetails, err := encryptAddressDetails(keyring, existingAddress.AddressDetails)
+
+	if err != nil {
+		return err
+	}
+
+	deletedAt := time.Now()
+
+	existingMetadata, err := changeAddressVersion(
+		ctx,
+		tenantID,
+		applicationID,
+		addressID,
+		expectedVersion,
+		addressMetadataChange{deletedAt: &deletedAt},
+		existingAddress.TTL,
+		session)
+
+	if err != nil {
+		return err
+	}
+
+	newVersion := existingMetadata.version + 1
+
+	mappedTenantID := mapSystemUUIDToGocqlUUID(tenantID)
+	mappedApplicationID := mapSystemUUIDToGocqlUUID(applicationID)
+	mappedAddressID := mapSystemUUIDToGocqlUUID(addressID)
+
+	batch := session.NewBatch(gocql.LoggedBatch)
+
+	addToDeletedAddressTable(batch, mappedTenantID, mappedApplicationID, mappedAddressID, deletedAt, mapDurationToCassandraTTL(existingAddress.TTL))
+	addToAddressHistoryTable(batch, mappedTenantID, mappedApplicationID, mappedAddressID, encryptedAddressDetails, newVersion, changedBy, true)
+
+	if recordEvents {
+		addToAddressEventOutboxTable(
+			batch,
+			contract.AddressDeleted,
+			mappedTenantID,
+			mappedApplicationID,
+			mappedAddressID,
+			newVersion,
+			encryptedAddressDetails,
+			nil,
+			changedBy)
+	}
+
+	return executeVersionedBatch(ctx, tenantID, applicationID, addressID, existingMetadata, existingAddress.TTL, batch, session)
+}
+
+// restoreDeletedAddress restores a deleted address by removing the time it was deleted at. The new version is claimed
+// by a lightweight transaction first along with removing the deletion time, then the address is removed from its
+// deleted_address bucket in one logged batch along with the history entry recording the restoration, so the address is
+// never restored without its history entry. deletedAt is the time the address was deleted at.
+func restoreDeletedAddress(
+	ctx context.Context,
+	tenantID, applicationID, addressID system.UUID,
+	expectedVersion int64,
+	deletedAt time.Time,
+	changedBy string,
+	keyring *encryption.Keyring,
+	session *gocql.Session) error {
+	deletedAddress, err := selectAllAddressDetails(ctx, tenantID, applicationID, addressID, keyring, session)
+
+	if err != nil {
+		return err
+	}
+
+	encryptedAddressDetails, err := encryptAddressDetails(keyring, deletedAddress.AddressDetails)
+
+	if err != nil {
+		return err
+	}
+
+	notDeleted := time.Time{}
+
+	existingMetadata, err := changeAddressVersion(
+		ctx,
+		tenantID,
+		applicationID,
+		addressID,
+		expectedVersion,
+		addressMetadataChange{deletedAt: &notDeleted},
+		deletedAddress.TTL,
+		session)
+
+	if err != nil {
+		return err
+	}
+
+	newVersion := existingMetadata.version + 1
+
+	mappedTenantID := mapSystemUUIDToGocqlUUID(tenantID)
+	mappedApplicationID := mapSystemUUIDToGocqlUUID(applicationID)
+	mappedAddressID := mapSystemUUIDToGocqlUUID(addressID)
+
+	batch := session.NewBatch(gocql.LoggedBatch)
+
+	removeFromDeletedAddressTable(batch, mappedTenantID, mappedApplicationID, mappedAddressID, deletedAt)
+	addToAddressHistoryTable(batch, mappedTenantID, mappedApplicationID, mappedAddressID, encryptedAddressDetails, newVersion, changedBy, false)
+
+	return executeVersionedBatch(ctx, tenantID, applicationID, addressID, existingMetadata, deletedAddress.TTL, batch, session)
+}
+
+// addToDeletedAddressTable adds the statement inserting an address deleted at the provided time to the bucket of the
+// hour it was deleted in of deleted_address table to the provided batch. The row expires after the provided TTL in
+// seconds, or never if it is zero.
+func addToDeletedAddressTable(
+	batch *gocql.Batch,
+	tenantID, applicationID, addressID gocql.UUID,
+	deletedAt time.Time,
+	ttl int) {
+	batch.Query(
+		"INSERT INTO deleted_address"+
+			" (deleted_hour, deleted_at, tenant_id, application_id, address_id)"+
+			" VALUES(?, ?, ?, ?, ?)"+
+			" USING TTL ?",
+		deletedAt.Truncate(time.Hour),
+		deletedAt,
+		tenantID,
+		applicationID,
+		addressID,
+		ttl)
+}
+
+// removeFromDeletedAddressTable adds the statement removing an address deleted at the provided time from
+// deleted_address table to the provided batch.
+func removeFromDeletedAddressTable(
+	batch *gocql.Batch,
+	tenantID, applicationID, addressID gocql.UUID,
+	deletedAt time.Time) {
+	batch.Query(
+		"DELETE FROM deleted_address"+
+			" WHERE"+
+			" deleted_hour = ?"+
+			" AND deleted_at = ?"+
+			" AND tenant_id = ?"+
+			" AND application_id = ?"+
+			" AND address_id = ?",
+		deletedAt.Truncate(time.Hour),
+		deletedAt,
+		tenantID,
+		applicationID,
+		addressID)
+}
+
+// readNextDeletedAddressPurgeHour returns the hour of the first bucket of deleted_address table not purged yet. If it is
+// not recorded, the hour of the provided time is recorded and returned, unless another instance records an hour first.
+func readNextDeletedAddressPurgeHour(ctx context.Context, deletedBefore time.Time, session *gocql.Session) (time.Time, error) {
+	var nextHour time.Time
+
+	err := session.Query(
+		"SELECT next_hour" +
+			" FROM deleted_address_purge" +
+			" WHERE name = 'deleted_address'").WithContext(ctx).Scan(&nextHour)
+
+	if err != nil && err != gocql.ErrNotFound {
+		return time.Time{}, err
+	}
+
+	if err == nil && !nextHour.IsZero() {
+		return nextHour, nil
+	}
+
+	var query *gocql.Query
+
+	if err == gocql.ErrNotFound {
+		query = session.Query(
+			"INSERT INTO deleted_address_purge"+
+				" (name, next_hour)"+
+				" VALUES('deleted_address', ?)"+
+				" IF NOT EXISTS",
+			deletedBefore.Truncate(time.Hour))
+	} else {
+		query = session.Query(
+			"UPDATE deleted_address_purge"+
+				" SET next_hour = ?"+
+				" WHERE name = 'deleted_address'"+
+				" IF next_hour = null",
+			deletedBefore.Truncate(time.Hour))
+	}
+
+	existingPurge := make(map[string]interface{})
+	applied, err := query.WithContext(ctx).MapScanCAS(existingPurge)
+
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if existingNextHour, ok := existingPurge["next_hour"].(time.Time); !applied && ok && !existingNextHour.IsZero() {
+		return existingNextHour, nil
+	}
+
+	return deletedBefore.Truncate(time.Hour), nil
+}
+
+// purgeDeletedAddressBucket purges the addresses of the bucket of deleted_address table of the provided hour deleted
+// before the provided time. An address restored or deleted again since has its metadata deletion time changed, so only
+// its row of the bucket is removed.
+func purgeDeletedAddressBucket(ctx context.Context, deletedHour, deletedBefore time.Time, session *gocql.Session) error {
+	iter := session.Query(
+		"SELECT deleted_at, tenant_id, application_id, address_id"+
+			" FROM deleted_address"+
+			" WHERE"+
+			" deleted_hour = ?"+
+			" AND deleted_at < ?",
+		deletedHour,
+		deletedBefore).WithContext(ctx).Iter()
+
+	var deletedAt time.Time
+	var tenantID, applicationID, addressID gocql.UUID
+
+	deletedAddresses := []deletedAddress{}
+
+	for iter.Scan(&deletedAt, &tenantID, &applicationID, &addressID) {
+		deletedAddresses = append(deletedAddresses, deletedAddress{
+			tenantID:      mapGocqlUUIDToSystemUUID(tenantID),
+			applicationID: mapGocqlUUIDToSystemUUID(applicationID),
+			addressID:     mapGocqlUUIDToSystemUUID(addressID),
+			deletedAt:     deletedAt,
+		})
+	}
+
+	if err := iter.Close(); err != nil {
+		return err
+	}
+
+	for _, address := range deletedAddresses {
+		_, currentDeletedAt, err := readAddressMetadata(ctx, address.tenantID, address.applicationID, address.addressID, session)
+
+		if err != nil {
+			return err
+		}
+
+		if currentDeletedAt.Equal(address.deletedAt) {
+			err = purgeDeletedAddress(ctx, address.tenantID, address.applicationID, address.addressID, address.deletedAt, session)
+		} else {
+			batch := session.NewBatch(gocql.LoggedBatch)
+
+			removeFromDeletedAddressTable(
+				batch,
+				mapSystemUUIDToGocqlUUID(address.tenantID),
+				mapSystemUUIDToGocqlUUID(address.applicationID),
+				mapSystemUUIDToGocqlUUID(address.addressID),
+				address.deletedAt)
+
+			err = session.ExecuteBatch(batch.WithContext(ctx))
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// listAddresses returns up to pageSize addresses of a tenant's application starting from the provided paging state, or
+// from the first address if the paging state is empty. The deleted addresses and the ones which cannot be read, e.g. they
+// expired or their values cannot be decrypted, are skipped and the following rows are fetched instead, so the page is
+// filled unless there is no more address.
+func listAddresses(
+	ctx context.Context,
+	tenantID, applicationID system.UUID,
+	pageSize int,
+	pageState []byte,
+	keyring *encryption.Keyring,
+	session *gocql.Session) (contract.AddressPage, error) {
+	page := contract.AddressPage{Addresses: []contract.ListedAddress{}}
+
+	for {
+		iter := session.Query(
+			"SELECT address_id, version, deleted_at"+
+				" FROM address_metadata"+
+				" WHERE"+
+				" tenant_id = ?"+
+				" AND application_id = ?",
+			mapSystemUUIDToGocqlUUID(tenantID),
+			mapSystemUUIDToGocqlUUID(applicationID)).
+			PageSize(pageSize - len(page.Addresses)).
+			PageState(pageState).
+			WithContext(ctx).
+			Iter()
+
+		var addressID gocql.UUID
+		var version int64
+		var deletedAt time.Time
+
+		listedAddresses := []contract.ListedAddress{}
+
+		for iter.Scan(&addressID, &version, &deletedAt) {
+			// The version is empty if the address has expired but its metadata row is not removed yet.
+			if deletedAt.IsZero() && version != 0 {
+				listedAddresses = append(
+					listedAddresses,
+					contract.ListedAddress{AddressID: mapGocqlUUIDToSystemUUID(addressID), Address: contract.Address{Version: version}})
+			}
+
+			version = 0
+			deletedAt = time.Time{}
+		}
+
+		pageState = iter.PageState()
+
+		if err := iter.Close(); err != nil {
+			return contract.AddressPage{}, err
+		}
+
+		for _, listedAddress := range listedAddresses {
+			address, err := selectAllAddressDetails(ctx, tenantID, applicationID, listedAddress.AddressID, keyring, session)
+
+			if isUnreadableAddress(err) {
+				continue
+			}
+
+			if err != nil {
+				return contract.AddressPage{}, err
+			}
+
+			listedAddress.Address.AddressDetails = address.AddressDetails
+			listedAddress.Address.TTL = address.TTL
+			page.Addresses = append(page.Addresses, listedAddress)
+		}
+
+		if len(page.Addresses) == pageSize || len(pageState) == 0 {
+			page.NextCursor = base64.RawURLEncoding.EncodeToString(pageState)
+
+			return page, nil
+		}
+	}
+}
+
+// isUnreadableAddress returns true if the provided error returned by reading an address means the address itself cannot
+// be read, i.e. it expired or its values cannot be decrypted, so it can be skipped when listing the addresses.
+func isUnreadableAddress(err error) bool {
+	switch err.(type) {
+	case contract.NotFoundError, encryption.DecryptionError:
+		return true
+	}
+
+	return false
+}
+
+// findAddressesByDetail returns no more than MaxFoundAddresses addresses of a tenant's application whose details match
+// all the provided criteria. The addresses having the value of the first criterion key in alphabetical order are read
+// from address_indexed_by_address_value table by the value hashes, as the values are encrypted, a page at a time. The
+// metadata and the details of every page of addresses are read at once, then the deleted addresses and the ones not
+// matching the rest of the criteria are skipped.
+func findAddressesByDetail(
+	ctx context.Context,
+	tenantID, applicationID system.UUID,
+	criteria map[string]string,
+	keyring *encryption.Keyring,
+	session *gocql.Session) ([]contract.ListedAddress, error) {
+	keys := make([]string, 0, len(criteria))
+
+	for key := range criteria {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	// The value itself is looked for too, as the values stored in plaintext are indexed by themselves.
+	valueHashes, err := keyring.Hashes(keys[0], criteria[keys[0]])
+
+	if err != nil {
+		return nil, err
+	}
+
+	valueHashes = append(valueHashes, criteria[keys[0]])
+
+	listedAddresses := []contract.ListedAddress{}
+	candidateAddressIDs := make(map[gocql.UUID]bool)
+
+	var pageState []byte
+
+	for {
+		iter := session.Query(
+			"SELECT address_id"+
+				" FROM address_indexed_by_address_value"+
+				" WHERE"+
+				" tenant_id = ?"+
+				" AND application_id = ?"+
+				" AND address_key = ?"+
+				" AND address_value_hash IN ?",
+			mapSystemUUIDToGocqlUUID(tenantID),
+			mapSystemUUIDToGocqlUUID(applicationID),
+			keys[0],
+			valueHashes).
+			PageSize(contract.MaxFoundAddresses).
+			PageState(pageState).
+			WithContext(ctx).
+			Iter()
+
+		var addressID gocql.UUID
+
+		pageAddressIDs := []gocql.UUID{}
+
+		for iter.Scan(&addressID) {
+			if !candidateAddressIDs[addressID] {
+				candidateAddressIDs[addressID] = true
+				pageAddressIDs = append(pageAddressIDs, addressID)
+			}
+		}
+
+		pageState = iter.PageState()
+
+		if err := iter.Close(); err != nil {
+			return nil, err
+		}
+
+		candidateAddresses, err := readListedAddresses(ctx, tenantID, applicationID, pageAddressIDs, keyring, session)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, candidateAddress := range candidateAddresses {
+			if !matchAddressDetails(candidateAddress.Address.AddressDetails, criteria) {
+				continue
+			}
+
+			listedAddresses = append(listedAddresses, candidateAddress)
+
+			if len(listedAddresses) == contract.MaxFoundAddresses {
+				return listedAddresses, nil
+			}
+		}
+
+		if len(pageState) == 0 {
+			return listedAddresses, nil
+		}
+	}
+}
+
+// readListedAddresses returns the existing addresses among the provided ones in the same order, reading the metadata
+// and the details of all of them at once. The deleted and expired addresses and the ones whose values cannot be
+// decrypted are skipped.
+func readListedAddresses(
+	ctx context.Context,
+	tenantID, applicationID system.UUID,
+	addressIDs []gocql.UUID,
+	keyring *encryption.Keyring,
+	session *gocql.Session) ([]contract.ListedAddress, error) {
+	if len(addressIDs) == 0 {
+		return nil, nil
+	}
+
+	mappedTenantID := mapSystemUUIDToGocqlUUID(tenantID)
+	mappedApplicationID := mapSystemUUIDToGocqlUUID(applicationID)
+
+	iter := session.Query(
+		"SELECT address_id, version, deleted_at"+
+			" FROM address_metadata"+
 			" WHERE"+
 			" tenant_id = ?"+
 			" AND application_id = ?"+
-			" AND address_id = ?"+
-			" LIMIT 1",
-		tenantID.String(),
-		applicationID.String(),
-		addressID.String()).Iter()
+			" AND address_id IN ?",
+		mappedTenantID,
+		mappedApplicationID,
+		addressIDs).WithContext(ctx).Iter()
+
+	var addressID gocql.UUID
+	var version int64
+	var deletedAt time.Time
+
+	addresses := make(map[gocql.UUID]*contract.Address, len(addressIDs))
+
+	for iter.Scan(&addressID, &version, &deletedAt) {
+		// The version is empty if the address has expired but its metadata row is not removed yet.
+		if deletedAt.IsZero() && version != 0 {
+			addresses[addressID] = &contract.Address{Version: version, AddressDetails: make(map[string]string)}
+		}
+
+		version = 0
+		deletedAt = time.Time{}
+	}
+
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+
+	iter = session.Query(
+		"SELECT address_id, address_key, address_value, TTL(address_value)"+
+			" FROM address"+
+			" WHERE"+
+			" tenant_id = ?"+
+			" AND application_id = ?"+
+			" AND address_id IN ?",
+		mappedTenantID,
+		mappedApplicationID,
+		addressIDs).WithContext(ctx).Iter()
+
+	var key, value string
+	var ttl int
+
+	unreadableAddressIDs := make(map[gocql.UUID]bool)
+
+	for iter.Scan(&addressID, &key, &value, &ttl) {
+		address, ok := addresses[addressID]
+
+		if !ok {
+			continue
+		}
+
+		decryptedValue, err := keyring.Decrypt(value)
+
+		if isUnreadableAddress(err) {
+			unreadableAddressIDs[addressID] = true
+			ttl = 0
+
+			continue
+		}
+
+		if err != nil {
+			iter.Close()
+
+			return nil, err
+		}
+
+		address.AddressDetails[key] = decryptedValue
+
+		if remainingTTL := time.Duration(ttl) * time.Second; remainingTTL > 0 && (address.TTL == 0 || remainingTTL < address.TTL) {
+			address.TTL = remainingTTL
+		}
+
+		ttl = 0
+	}
+
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
 
-	defer iter.Close()
+	listedAddresses := make([]contract.ListedAddress, 0, len(addresses))
 
-	var addressKey string
+	for _, addressID := range addressIDs {
+		address, ok := addresses[addressID]
 
-	return iter.Scan(&addressKey)
+		if !ok || unreadableAddressIDs[addressID] || len(address.AddressDetails) == 0 {
+			continue
+		}
+
+		listedAddresses = append(listedAddresses, contract.ListedAddress{AddressID: mapGocqlUUIDToSystemUUID(addressID), Address: *address})
+	}
+
+	return listedAddresses, nil
 }
 
-func deleteExistingAddress(tenantID, applicationID, addressID system.UUID, session *gocql.Session) error {
-	address, err := readAllAddressDetails(tenantID, applicationID, addressID, session)
+// readAllAddressDetails returns all the details and the version of an existing address.
+// Returns not found error if the address does not exist or is deleted.
+func readAllAddressDetails(
+	ctx context.Context,
+	tenantID, applicationID, addressID system.UUID,
+	keyring *encryption.Keyring,
+	session *gocql.Session) (contract.Address, error) {
+	address, err := selectAllAddressDetails(ctx, tenantID, applicationID, addressID, keyring, session)
 
 	if err != nil {
-		return err
+		return contract.Address{}, err
+	}
+
+	var deletedAt time.Time
+
+	if address.Version, deletedAt, err = readAddressMetadata(ctx, tenantID, applicationID, addressID, session); err != nil {
+		return contract.Address{}, err
+	}
+
+	if !deletedAt.IsZero() {
+		return contract.Address{}, contract.NotFoundError{AddressID: addressID}
 	}
 
-	return removeExistingAddress(tenantID, applicationID, address, addressID, session)
+	return address, nil
 }
 
-func readAllAddressDetails(tenantID, applicationID, addressID system.UUID, session *gocql.Session) (contract.Address, error) {
+// selectAllAddressDetails returns all the details stored for an address in address table, whether it is deleted or not.
+// Returns not found error if no detail is stored.
+func selectAllAddressDetails(
+	ctx context.Context,
+	tenantID, applicationID, addressID system.UUID,
+	keyring *encryption.Keyring,
+	session *gocql.Session) (contract.Address, error) {
 	iter := session.Query(
-		"SELECT address_key, address_value"+
+		"SELECT address_key, address_value, TTL(address_value)"+
 			" FROM address"+
 			" WHERE"+
 			" tenant_id = ?"+
@@ -447,21 +2012,306 @@ func readAllAddressDetails(tenantID, applicationID, addressID system.UUID, sessi
 			" AND address_id = ?",
 		tenantID.String(),
 		applicationID.String(),
-		addressID.String()).Iter()
+		addressID.String()).WithContext(ctx).Iter()
+
+	return scanAddressDetails(iter, addressID, keyring)
+}
+
+// readAddressMetadata returns the current version of an existing address and the time it was deleted at, or zero time if
+// it is not deleted. Addresses created before versioning was introduced have no metadata stored and 0 version is
+// returned for them.
+func readAddressMetadata(ctx context.Context, tenantID, applicationID, addressID system.UUID, session *gocql.Session) (int64, time.Time, error) {
+	var version int64
+	var deletedAt time.Time
+
+	err := session.Query(
+		"SELECT version, deleted_at"+
+			" FROM address_metadata"+
+			" WHERE"+
+			" tenant_id = ?"+
+			" AND application_id = ?"+
+			" AND address_id = ?",
+		tenantID.String(),
+		applicationID.String(),
+		addressID.String()).WithContext(ctx).Scan(&version, &deletedAt)
+
+	if err == gocql.ErrNotFound {
+		return 0, time.Time{}, nil
+	}
+
+	return version, deletedAt, err
+}
+
+// addressMetadata holds the version, the deletion time and the fingerprint hash stored in the address_metadata row of an
+// address.
+type addressMetadata struct {
+	version     int64
+	deletedAt   time.Time
+	fingerprint string
+}
+
+// addressMetadataChange holds the changes of the address_metadata columns written along with a new address version.
+// address_metadata rows are only written by lightweight transactions, as normal writes mixed with them may be ordered
+// wrongly, so the columns are changed by the lightweight transaction changing the version.
+type addressMetadataChange struct {
+	// deletedAt is the new deletion time, zero to remove it, or nil to keep it unchanged.
+	deletedAt *time.Time
+
+	// fingerprint is the new fingerprint hash, empty to remove it, or nil to keep it unchanged.
+	fingerprint *string
+}
+
+// readAddressMetadataRow returns the address_metadata row of an address, or the zero value if it has none.
+func readAddressMetadataRow(ctx context.Context, tenantID, applicationID, addressID system.UUID, session *gocql.Session) (addressMetadata, error) {
+	var metadata addressMetadata
+
+	err := session.Query(
+		"SELECT version, deleted_at, fingerprint"+
+			" FROM address_metadata"+
+			" WHERE"+
+			" tenant_id = ?"+
+			" AND application_id = ?"+
+			" AND address_id = ?",
+		tenantID.String(),
+		applicationID.String(),
+		addressID.String()).WithContext(ctx).Scan(&metadata.version, &metadata.deletedAt, &metadata.fingerprint)
+
+	if err == gocql.ErrNotFound {
+		return addressMetadata{}, nil
+	}
+
+	return metadata, err
+}
+
+// changeAddressVersion increases the version of an existing address along with the remaining time to live of the
+// address and applies the provided change of the deletion time and the fingerprint, using a lightweight transaction so
+// the change is applied only if nobody else changed the address version in between. A lightweight transaction cannot be
+// batched with the statements of other tables, so the callers write the rest of the change, including the history
+// entry, in one logged batch once the version is changed. If the version check is skipped and the version keeps
+// changing, the change is attempted maxVersionChangeAttempts times. Returns either the metadata of the address before
+// the change, whose version increased by one is the new version, or ConflictError if the address version does not
+// match the expected version, or error if something goes wrong.
+func changeAddressVersion(
+	ctx context.Context,
+	tenantID, applicationID, addressID system.UUID,
+	expectedVersion int64,
+	change addressMetadataChange,
+	ttl time.Duration,
+	session *gocql.Session) (addressMetadata, error) {
+	mappedTenantID := mapSystemUUIDToGocqlUUID(tenantID)
+	mappedApplicationID := mapSystemUUIDToGocqlUUID(applicationID)
+	mappedAddressID := mapSystemUUIDToGocqlUUID(addressID)
+
+	var existingMetadata addressMetadata
+
+	for attempt := 0; attempt < maxVersionChangeAttempts; attempt++ {
+		var err error
+
+		if existingMetadata, err = readAddressMetadataRow(ctx, tenantID, applicationID, addressID, session); err != nil {
+			return addressMetadata{}, err
+		}
+
+		if expectedVersion != contract.AnyVersion && expectedVersion != existingMetadata.version {
+			return addressMetadata{}, contract.ConflictError{
+				AddressID:       addressID,
+				ExpectedVersion: expectedVersion,
+				ActualVersion:   existingMetadata.version}
+		}
+
+		changedMetadata := existingMetadata
+		changedMetadata.version++
+
+		if change.deletedAt != nil {
+			changedMetadata.deletedAt = *change.deletedAt
+		}
+
+		if change.fingerprint != nil {
+			changedMetadata.fingerprint = *change.fingerprint
+		}
+
+		var query *gocql.Query
+
+		if existingMetadata.version == 0 {
+			query = session.Query(
+				"INSERT INTO address_metadata"+
+					" (tenant_id, application_id, address_id, version, deleted_at, fingerprint)"+
+					" VALUES(?, ?, ?, 1, ?, ?)"+
+					" IF NOT EXISTS"+
+					" USING TTL ?",
+				mappedTenantID,
+				mappedApplicationID,
+				mappedAddressID,
+				mapTimeToCassandraTimestamp(changedMetadata.deletedAt),
+				mapFingerprintToCassandra(changedMetadata.fingerprint),
+				mapDurationToCassandraTTL(ttl))
+		} else {
+			query = updateAddressMetadata(
+				session,
+				mappedTenantID,
+				mappedApplicationID,
+				mappedAddressID,
+				existingMetadata.version,
+				changedMetadata,
+				mapDurationToCassandraTTL(ttl))
+		}
+
+		applied, err := query.WithContext(ctx).MapScanCAS(make(map[string]interface{}))
+
+		if err != nil {
+			return addressMetadata{}, err
+		}
+
+		if applied {
+			return existingMetadata, nil
+		}
+	}
+
+	return addressMetadata{}, contract.ConflictError{
+		AddressID:       addressID,
+		ExpectedVersion: expectedVersion,
+		ActualVersion:   existingMetadata.version}
+}
+
+// updateAddressMetadata returns the lightweight transaction replacing the address_metadata row of an address with the
+// provided metadata if the row still has the expected version. The row expires after the provided TTL in seconds, or
+// never if it is zero.
+func updateAddressMetadata(
+	session *gocql.Session,
+	tenantID, applicationID, addressID gocql.UUID,
+	expectedVersion int64,
+	metadata addressMetadata,
+	ttl int) *gocql.Query {
+	return session.Query(
+		"UPDATE address_metadata"+
+			" USING TTL ?"+
+			" SET version = ?, deleted_at = ?, fingerprint = ?"+
+			" WHERE"+
+			" tenant_id = ?"+
+			" AND application_id = ?"+
+			" AND address_id = ?"+
+			" IF version = ?",
+		ttl,
+		metadata.version,
+		mapTimeToCassandraTimestamp(metadata.deletedAt),
+		mapFingerprintToCassandra(metadata.fingerprint),
+		tenantID,
+		applicationID,
+		addressID,
+		expectedVersion)
+}
+
+// executeVersionedBatch executes the logged batch writing the change of an address whose metadata was changed by
+// changeAddressVersion from existingMetadata. If the batch is known not to be applied, the metadata is changed back by a
+// lightweight transaction, so a failed change does not leave the version increased without the change and its history
+// entry. The metadata is left as it is if the batch might still be applied, e.g. the batch log is written but the write
+// timed out. Returns the error returned by executing the batch, or the error changing the metadata back if that fails,
+// or ConflictError if the version was changed again in between and cannot be changed back.
+func executeVersionedBatch(
+	ctx context.Context,
+	tenantID, applicationID, addressID system.UUID,
+	existingMetadata addressMetadata,
+	ttl time.Duration,
+	batch *gocql.Batch,
+	session *gocql.Session) error {
+	err := session.ExecuteBatch(batch.WithContext(ctx))
+
+	if err == nil || !isBatchNotApplied(err) {
+		return err
+	}
+
+	mappedTenantID := mapSystemUUIDToGocqlUUID(tenantID)
+	mappedApplicationID := mapSystemUUIDToGocqlUUID(applicationID)
+	mappedAddressID := mapSystemUUIDToGocqlUUID(addressID)
+	newVersion := existingMetadata.version + 1
+
+	var query *gocql.Query
+
+	if existingMetadata.version == 0 {
+		query = session.Query(
+			"DELETE FROM address_metadata"+
+				" WHERE"+
+				" tenant_id = ?"+
+				" AND application_id = ?"+
+				" AND address_id = ?"+
+				" IF version = 1",
+			mappedTenantID,
+			mappedApplicationID,
+			mappedAddressID)
+	} else {
+		query = updateAddressMetadata(
+			session,
+			mappedTenantID,
+			mappedApplicationID,
+			mappedAddressID,
+			newVersion,
+			existingMetadata,
+			mapDurationToCassandraTTL(ttl))
+	}
+
+	// The metadata is changed back even if the call is canceled, as the batch may have failed because of the cancellation.
+	currentMetadata := make(map[string]interface{})
+	applied, rollbackErr := query.WithContext(context.Background()).MapScanCAS(currentMetadata)
+
+	if rollbackErr != nil {
+		return rollbackErr
+	}
+
+	if !applied {
+		actualVersion, _ := currentMetadata["version"].(int64)
+
+		return contract.ConflictError{AddressID: addressID, ExpectedVersion: newVersion, ActualVersion: actualVersion}
+	}
 
-	defer iter.Close()
+	return err
+}
+
+// isBatchNotApplied returns true if the provided error returned by executing a logged batch means none of the batch
+// statements is applied, i.e. the batch is rejected before its batch log is written.
+func isBatchNotApplied(err error) bool {
+	switch typedErr := err.(type) {
+	case *gocql.RequestErrUnavailable:
+		return true
+	case *gocql.RequestErrWriteTimeout:
+		return typedErr.WriteType == "BATCH_LOG"
+	}
+
+	return err == gocql.ErrNoConnections || err == gocql.ErrSessionClosed
+}
 
+// scanAddressDetails reads the address_key, address_value and address_value TTL columns returned by the provided iterator
+// as address details, decrypting the values, and closes the iterator. The remaining time to live of the address is the
+// shortest TTL of its details. Returns not found error if the iterator returns no row.
+func scanAddressDetails(iter *gocql.Iter, addressID system.UUID, keyring *encryption.Keyring) (contract.Address, error) {
 	var key string
 	var value string
+	var ttl int
 
 	address := contract.Address{AddressDetails: make(map[string]string)}
 
-	for iter.Scan(&key, &value) {
-		address.AddressDetails[key] = value
+	for iter.Scan(&key, &value, &ttl) {
+		decryptedValue, err := keyring.Decrypt(value)
+
+		if err != nil {
+			iter.Close()
+
+			return contract.Address{}, err
+		}
+
+		address.AddressDetails[key] = decryptedValue
+
+		if remainingTTL := time.Duration(ttl) * time.Second; remainingTTL > 0 && (address.TTL == 0 || remainingTTL < address.TTL) {
+			address.TTL = remainingTTL
+		}
+
+		ttl = 0
+	}
+
+	if err := iter.Close(); err != nil {
+		return contract.Address{}, err
 	}
 
 	if len(address.AddressDetails) == 0 {
-		return contract.Address{}, fmt.Errorf("Address not found. Address ID: %s", addressID.String())
+		return contract.Address{}, contract.NotFoundError{AddressID: addressID}
 	}
 
 	return address, nil
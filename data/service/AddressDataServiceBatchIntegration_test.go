@@ -0,0 +1,165 @@
+// +build integration
+
+package service_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/golang/mock/gomock"
+	"github.com/micro-business/AddressService/data/contract"
+	"github.com/micro-business/AddressService/data/service"
+	"github.com/micro-business/Micro-Business-Core/system"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("Logged batch behaviour", func() {
+	var (
+		ctx                      context.Context
+		mockCtrl                 *gomock.Controller
+		addressDataService       *service.AddressDataService
+		mockUUIDGeneratorService *MockUUIDGeneratorService
+		tenantID                 system.UUID
+		applicationID            system.UUID
+		addressID                system.UUID
+		brokenKeyspace           string
+		session                  *gocql.Session
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		// The keyspace has no address_indexed_by_address_key table, so every batch touching it fails.
+		brokenKeyspace = createRandomKeyspace()
+		createKeyspaceWithoutAddressIndexTable(brokenKeyspace)
+
+		clusterConfig := getClusterConfig()
+		clusterConfig.Keyspace = brokenKeyspace
+
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockUUIDGeneratorService = NewMockUUIDGeneratorService(mockCtrl)
+
+		addressDataService = &service.AddressDataService{UUIDGeneratorService: mockUUIDGeneratorService, ClusterConfig: clusterConfig}
+
+		tenantID, _ = system.RandomUUID()
+		applicationID, _ = system.RandomUUID()
+		addressID, _ = system.RandomUUID()
+
+		var err error
+
+		session, err = clusterConfig.CreateSession()
+
+		Expect(err).To(BeNil())
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+		addressDataService.Close()
+		session.Close()
+		dropKeyspace(brokenKeyspace)
+	})
+
+	insertIntoAddressTable := func(addressDetails map[string]string) {
+		for key, value := range addressDetails {
+			Expect(session.Query(
+				"INSERT INTO address"+
+					" (tenant_id, application_id, address_id, address_key, address_value)"+
+					" VALUES(?, ?, ?, ?, ?)",
+				mapSystemUUIDToGocqlUUID(tenantID),
+				mapSystemUUIDToGocqlUUID(applicationID),
+				mapSystemUUIDToGocqlUUID(addressID),
+				key,
+				value).Exec()).To(BeNil())
+		}
+	}
+
+	readAddressTable := func() map[string]string {
+		iter := session.Query(
+			"SELECT address_key, address_value"+
+				" FROM address"+
+				" WHERE"+
+				" tenant_id = ?"+
+				" AND application_id = ?"+
+				" AND address_id = ?",
+			tenantID.String(),
+			applicationID.String(),
+			addressID.String()).Iter()
+
+		defer iter.Close()
+
+		var key string
+		var value string
+
+		addressDetails := make(map[string]string)
+
+		for iter.Scan(&key, &value) {
+			addressDetails[key] = value
+		}
+
+		return addressDetails
+	}
+
+	Context("when writing to address_indexed_by_address_key table fails", func() {
+		It("should not leave any record in address table on create", func() {
+			mockUUIDGeneratorService.
+				EXPECT().
+				GenerateRandomUUID().
+				Return(addressID, nil)
+
+			_, err := addressDataService.Create(ctx, tenantID, applicationID, contract.Address{AddressDetails: createRandomAddressDetails()}, "")
+
+			Expect(err).NotTo(BeNil())
+			Expect(readAddressTable()).To(BeEmpty())
+		})
+
+		It("should leave the records in address table unchanged on update", func() {
+			expectedAddressDetails := createRandomAddressDetails()
+			insertIntoAddressTable(expectedAddressDetails)
+
+			err := addressDataService.Update(ctx, tenantID, applicationID, addressID, contract.Address{AddressDetails: createRandomAddressDetails()}, contract.AnyVersion, "")
+
+			Expect(err).NotTo(BeNil())
+			Expect(readAddressTable()).To(Equal(expectedAddressDetails))
+		})
+
+		It("should leave the records in address table unchanged on purge", func() {
+			expectedAddressDetails := createRandomAddressDetails()
+			insertIntoAddressTable(expectedAddressDetails)
+
+			Expect(session.Query(
+				"INSERT INTO address_metadata"+
+					" (tenant_id, application_id, address_id, version, deleted_at)"+
+					" VALUES(?, ?, ?, 2, ?)",
+				mapSystemUUIDToGocqlUUID(tenantID),
+				mapSystemUUIDToGocqlUUID(applicationID),
+				mapSystemUUIDToGocqlUUID(addressID),
+				time.Now()).Exec()).To(BeNil())
+
+			err := addressDataService.Purge(ctx, tenantID, applicationID, addressID)
+
+			Expect(err).NotTo(BeNil())
+			Expect(readAddressTable()).To(Equal(expectedAddressDetails))
+		})
+	})
+})
+
+func createKeyspaceWithoutAddressIndexTable(keyspace string) {
+	createAddressKeyspaceAndAllRequiredTables(keyspace)
+
+	config := getClusterConfig()
+	config.Timeout = databasePreparationMaxTimeout
+	session, err := config.CreateSession()
+
+	Expect(err).To(BeNil())
+
+	defer session.Close()
+
+	Expect(session.Query("DROP TABLE " + keyspace + ".address_indexed_by_address_key;").Exec()).To(BeNil())
+}
+
+func TestBatchBehaviour(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Logged batch behaviour")
+}
@@ -3,16 +3,17 @@
 package service_test
 
 import (
-	"math/rand"
 	"os"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gocql/gocql"
+	"github.com/micro-business/AddressService/data/migration"
 	"github.com/micro-business/Micro-Business-Core/system"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
 )
 
 const databasePreparationMaxTimeout = time.Minute
@@ -71,19 +72,11 @@ func createAddressKeyspaceAndAllRequiredTables(keyspace string) {
 			" with replication = { 'class' : 'SimpleStrategy', 'replication_factor' : 1 };").
 		Exec()).To(BeNil())
 
-	Expect(session.Query(
-		"CREATE TABLE " +
-			keyspace +
-			".address(tenant_id UUID, application_id UUID, address_id UUID, address_key text, address_value text," +
-			" PRIMARY KEY(tenant_id, application_id, address_id, address_key));").
-		Exec()).To(BeNil())
+	clusterConfig := getClusterConfig()
+	clusterConfig.Keyspace = keyspace
+	clusterConfig.Timeout = databasePreparationMaxTimeout
 
-	Expect(session.Query(
-		"CREATE TABLE " +
-			keyspace +
-			".address_indexed_by_address_key(tenant_id UUID, application_id UUID, address_id UUID, address_key text, address_value text," +
-			" PRIMARY KEY(tenant_id, application_id, address_key, address_id));").
-		Exec()).To(BeNil())
+	Expect(migration.Migrator{ClusterConfig: clusterConfig}.Up(context.Background())).To(BeNil())
 }
 
 func dropKeyspace(keyspace string) {
@@ -111,16 +104,3 @@ func mapGocqlUUIDToSystemUUID(uuid gocql.UUID) system.UUID {
 
 	return mappedUUID
 }
-
-func createRandomAddressDetails() map[string]string {
-	details := make(map[string]string)
-
-	for idx := 0; idx < rand.Intn(10)+1; idx++ {
-		key, _ := system.RandomUUID()
-		value, _ := system.RandomUUID()
-
-		details[key.String()] = value.String()
-	}
-
-	return details
-}
@@ -13,10 +13,12 @@ import (
 	"github.com/micro-business/Micro-Business-Core/system"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
 )
 
 var _ = Describe("Create method behaviour", func() {
 	var (
+		ctx                      context.Context
 		mockCtrl                 *gomock.Controller
 		addressDataService       *service.AddressDataService
 		mockUUIDGeneratorService *MockUUIDGeneratorService
@@ -28,6 +30,7 @@ var _ = Describe("Create method behaviour", func() {
 	)
 
 	BeforeEach(func() {
+		ctx = context.Background()
 		clusterConfig = getClusterConfig()
 		clusterConfig.Keyspace = keyspace
 
@@ -54,7 +57,7 @@ var _ = Describe("Create method behaviour", func() {
 				GenerateRandomUUID().
 				Return(expectedAddressID, nil)
 
-			newAddressID, err := addressDataService.Create(tenantID, applicationID, validAddress)
+			newAddressID, err := addressDataService.Create(ctx, tenantID, applicationID, validAddress, "")
 
 			Expect(expectedAddressID).To(Equal(newAddressID))
 			Expect(err).To(BeNil())
@@ -70,7 +73,7 @@ var _ = Describe("Create method behaviour", func() {
 				GenerateRandomUUID().
 				Return(system.EmptyUUID, expectedError)
 
-			newAddressID, err := addressDataService.Create(tenantID, applicationID, validAddress)
+			newAddressID, err := addressDataService.Create(ctx, tenantID, applicationID, validAddress, "")
 
 			Expect(newAddressID).To(Equal(system.EmptyUUID))
 			Expect(err).To(Equal(expectedError))
@@ -87,9 +90,10 @@ var _ = Describe("Create method behaviour", func() {
 			expectedAddressDetails := createRandomAddressDetails()
 
 			returnedAddressID, err := addressDataService.Create(
+				ctx,
 				tenantID,
 				applicationID,
-				contract.Address{AddressDetails: expectedAddressDetails})
+				contract.Address{AddressDetails: expectedAddressDetails}, "")
 
 			Expect(addressID).To(Equal(returnedAddressID))
 			Expect(err).To(BeNil())
@@ -136,7 +140,7 @@ var _ = Describe("Create method behaviour", func() {
 
 			expectedAddressDetails := createRandomAddressDetails()
 
-			addressDataService.Create(tenantID, applicationID, contract.Address{AddressDetails: expectedAddressDetails})
+			addressDataService.Create(ctx, tenantID, applicationID, contract.Address{AddressDetails: expectedAddressDetails}, "")
 
 			config := getClusterConfig()
 			config.Keyspace = keyspace
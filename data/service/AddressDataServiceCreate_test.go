@@ -10,10 +10,12 @@ import (
 	"github.com/micro-business/Micro-Business-Core/system"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
 )
 
 var _ = Describe("Create method input parameters and dependency test", func() {
 	var (
+		ctx                      context.Context
 		mockCtrl                 *gomock.Controller
 		addressDataService       *service.AddressDataService
 		mockUUIDGeneratorService *MockUUIDGeneratorService
@@ -23,6 +25,7 @@ var _ = Describe("Create method input parameters and dependency test", func() {
 	)
 
 	BeforeEach(func() {
+		ctx = context.Background()
 		mockCtrl = gomock.NewController(GinkgoT())
 		mockUUIDGeneratorService = NewMockUUIDGeneratorService(mockCtrl)
 
@@ -41,7 +44,7 @@ var _ = Describe("Create method input parameters and dependency test", func() {
 		It("should panic", func() {
 			addressDataService.UUIDGeneratorService = nil
 
-			Ω(func() { addressDataService.Create(tenantID, applicationID, validAddress) }).Should(Panic())
+			Ω(func() { addressDataService.Create(ctx, tenantID, applicationID, validAddress, "") }).Should(Panic())
 		})
 	})
 
@@ -49,7 +52,7 @@ var _ = Describe("Create method input parameters and dependency test", func() {
 		It("should panic", func() {
 			addressDataService.ClusterConfig = nil
 
-			Ω(func() { addressDataService.Create(tenantID, applicationID, validAddress) }).Should(Panic())
+			Ω(func() { addressDataService.Create(ctx, tenantID, applicationID, validAddress, "") }).Should(Panic())
 		})
 	})
 })
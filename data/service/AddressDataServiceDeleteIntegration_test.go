@@ -3,8 +3,8 @@
 package service_test
 
 import (
-	"fmt"
 	"testing"
+	"time"
 
 	"github.com/gocql/gocql"
 	"github.com/golang/mock/gomock"
@@ -13,10 +13,12 @@ import (
 	"github.com/micro-business/Micro-Business-Core/system"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
 )
 
 var _ = Describe("Delete method behaviour", func() {
 	var (
+		ctx                      context.Context
 		mockCtrl                 *gomock.Controller
 		addressDataService       *service.AddressDataService
 		mockUUIDGeneratorService *MockUUIDGeneratorService
@@ -27,6 +29,7 @@ var _ = Describe("Delete method behaviour", func() {
 	)
 
 	BeforeEach(func() {
+		ctx = context.Background()
 		clusterConfig = getClusterConfig()
 		clusterConfig.Keyspace = keyspace
 
@@ -46,12 +49,36 @@ var _ = Describe("Delete method behaviour", func() {
 
 	Context("when deleting existing address", func() {
 		It("should return error if address does not exist", func() {
-			err := addressDataService.Delete(tenantID, applicationID, addressID)
+			err := addressDataService.Delete(ctx, tenantID, applicationID, addressID, contract.AnyVersion, "")
 
-			Expect(err).To(Equal(fmt.Errorf("Address not found. Address ID: %s", addressID.String())))
+			Expect(err).To(Equal(contract.NotFoundError{AddressID: addressID}))
 		})
 
-		It("should remove the records from address table", func() {
+		It("should hide the deleted address from readers", func() {
+			mockUUIDGeneratorService.
+				EXPECT().
+				GenerateRandomUUID().
+				Return(addressID, nil)
+
+			returnedAddressID, err := addressDataService.Create(
+				ctx,
+				tenantID,
+				applicationID,
+				contract.Address{AddressDetails: createRandomAddressDetails()}, "")
+
+			Expect(err).To(BeNil())
+			Expect(addressDataService.Delete(ctx, tenantID, applicationID, returnedAddressID, 1, "")).To(BeNil())
+
+			_, err = addressDataService.ReadAll(ctx, tenantID, applicationID, returnedAddressID)
+
+			Expect(err).To(Equal(contract.NotFoundError{AddressID: returnedAddressID}))
+
+			err = addressDataService.Delete(ctx, tenantID, applicationID, returnedAddressID, contract.AnyVersion, "")
+
+			Expect(err).To(Equal(contract.NotFoundError{AddressID: returnedAddressID}))
+		})
+
+		It("should remove the records from address table once purged", func() {
 			mockUUIDGeneratorService.
 				EXPECT().
 				GenerateRandomUUID().
@@ -60,18 +87,22 @@ var _ = Describe("Delete method behaviour", func() {
 			expectedAddressDetails := createRandomAddressDetails()
 
 			returnedAddressID, err := addressDataService.Create(
+				ctx,
 				tenantID,
 				applicationID,
-				contract.Address{AddressDetails: expectedAddressDetails})
+				contract.Address{AddressDetails: expectedAddressDetails}, "")
 
 			Expect(err).To(BeNil())
 
 			err = addressDataService.Delete(
+				ctx,
 				tenantID,
 				applicationID,
-				returnedAddressID)
+				returnedAddressID,
+				contract.AnyVersion, "")
 
 			Expect(err).To(BeNil())
+			Expect(addressDataService.Purge(ctx, tenantID, applicationID, returnedAddressID)).To(BeNil())
 
 			config := getClusterConfig()
 			config.Keyspace = keyspace
@@ -101,7 +132,7 @@ var _ = Describe("Delete method behaviour", func() {
 			Expect(iter.Scan(&key, &value)).To(BeFalse())
 		})
 
-		It("should remove all the index records from address_indexed_by_address_key table", func() {
+		It("should remove all the index records from address_indexed_by_address_key table once purged", func() {
 			mockUUIDGeneratorService.
 				EXPECT().
 				GenerateRandomUUID().
@@ -110,18 +141,22 @@ var _ = Describe("Delete method behaviour", func() {
 			expectedAddressDetails := createRandomAddressDetails()
 
 			returnedAddressID, err := addressDataService.Create(
+				ctx,
 				tenantID,
 				applicationID,
-				contract.Address{AddressDetails: expectedAddressDetails})
+				contract.Address{AddressDetails: expectedAddressDetails}, "")
 
 			Expect(err).To(BeNil())
 
 			err = addressDataService.Delete(
+				ctx,
 				tenantID,
 				applicationID,
-				returnedAddressID)
+				returnedAddressID,
+				contract.AnyVersion, "")
 
 			Expect(err).To(BeNil())
+			Expect(addressDataService.Purge(ctx, tenantID, applicationID, returnedAddressID)).To(BeNil())
 
 			config := getClusterConfig()
 			config.Keyspace = keyspace
@@ -153,6 +188,83 @@ var _ = Describe("Delete method behaviour", func() {
 
 			}
 		})
+
+		It("should write the deletion time along with the new version", func() {
+			mockUUIDGeneratorService.
+				EXPECT().
+				GenerateRandomUUID().
+				Return(addressID, nil)
+
+			returnedAddressID, err := addressDataService.Create(
+				ctx,
+				tenantID,
+				applicationID,
+				contract.Address{AddressDetails: createRandomAddressDetails()}, "")
+
+			Expect(err).To(BeNil())
+			Expect(addressDataService.Delete(ctx, tenantID, applicationID, returnedAddressID, 1, "")).To(BeNil())
+
+			config := getClusterConfig()
+			config.Keyspace = keyspace
+
+			session, err := config.CreateSession()
+
+			Expect(err).To(BeNil())
+
+			defer session.Close()
+
+			var version int64
+			var deletedAt time.Time
+			var versionWriteTime int64
+			var deletedAtWriteTime int64
+
+			err = session.Query(
+				"SELECT version, deleted_at, WRITETIME(version), WRITETIME(deleted_at)"+
+					" FROM address_metadata"+
+					" WHERE"+
+					" tenant_id = ?"+
+					" AND application_id = ?"+
+					" AND address_id = ?",
+				tenantID.String(),
+				applicationID.String(),
+				returnedAddressID.String()).Scan(&version, &deletedAt, &versionWriteTime, &deletedAtWriteTime)
+
+			Expect(err).To(BeNil())
+			Expect(version).To(Equal(int64(2)))
+			Expect(deletedAt.IsZero()).To(BeFalse())
+			Expect(deletedAtWriteTime).To(Equal(versionWriteTime))
+		})
+
+		It("should return conflict error and leave the address unchanged when the expected version does not match", func() {
+			mockUUIDGeneratorService.
+				EXPECT().
+				GenerateRandomUUID().
+				Return(addressID, nil)
+
+			expectedAddressDetails := createRandomAddressDetails()
+
+			returnedAddressID, err := addressDataService.Create(
+				ctx,
+				tenantID,
+				applicationID,
+				contract.Address{AddressDetails: expectedAddressDetails}, "")
+
+			Expect(err).To(BeNil())
+
+			err = addressDataService.Delete(
+				ctx,
+				tenantID,
+				applicationID,
+				returnedAddressID,
+				2, "")
+
+			Expect(err).To(Equal(contract.ConflictError{AddressID: returnedAddressID, ExpectedVersion: 2, ActualVersion: 1}))
+
+			address, err := addressDataService.ReadAll(ctx, tenantID, applicationID, returnedAddressID)
+
+			Expect(err).To(BeNil())
+			Expect(address).To(Equal(contract.Address{AddressDetails: expectedAddressDetails, Version: 1}))
+		})
 	})
 })
 
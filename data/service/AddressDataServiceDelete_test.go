@@ -4,14 +4,17 @@ import (
 	"testing"
 
 	"github.com/gocql/gocql"
+	"github.com/micro-business/AddressService/data/contract"
 	"github.com/micro-business/AddressService/data/service"
 	"github.com/micro-business/Micro-Business-Core/system"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
 )
 
 var _ = Describe("Delete method input parameters and dependency test", func() {
 	var (
+		ctx                context.Context
 		addressDataService *service.AddressDataService
 		tenantID           system.UUID
 		applicationID      system.UUID
@@ -19,6 +22,7 @@ var _ = Describe("Delete method input parameters and dependency test", func() {
 	)
 
 	BeforeEach(func() {
+		ctx = context.Background()
 		addressDataService = &service.AddressDataService{ClusterConfig: &gocql.ClusterConfig{}}
 		tenantID, _ = system.RandomUUID()
 		applicationID, _ = system.RandomUUID()
@@ -29,7 +33,7 @@ var _ = Describe("Delete method input parameters and dependency test", func() {
 		It("should panic", func() {
 			addressDataService.ClusterConfig = nil
 
-			Ω(func() { addressDataService.Delete(tenantID, applicationID, addressID) }).Should(Panic())
+			Ω(func() { addressDataService.Delete(ctx, tenantID, applicationID, addressID, contract.AnyVersion, "") }).Should(Panic())
 		})
 	})
 })
@@ -0,0 +1,524 @@
+package service
+
+import (
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/micro-business/AddressService/data/encryption"
+	"github.com/micro-business/Micro-Business-Core/common/diagnostics"
+	"github.com/micro-business/Micro-Business-Core/system"
+	"golang.org/x/net/context"
+)
+
+// ReEncryptReport is the result of re-encrypting the addresses of a tenant.
+type ReEncryptReport struct {
+	TenantID string `json:"tenantId"`
+
+	// DataKeyVersion is the version of the new data key the addresses are encrypted with.
+	DataKeyVersion int `json:"dataKeyVersion"`
+
+	AddressRows int `json:"addressRows"`
+	HistoryRows int `json:"historyRows"`
+
+	// SkippedRows is the number of rows of the addresses changed while they were re-encrypted, which are left as they are.
+	SkippedRows int `json:"skippedRows"`
+}
+
+// ReEncrypt rotates the data key of a tenant and encrypts all the address values of the tenant again with the new data
+// key. All the data key versions of the tenant are wrapped again with the current master key first, so the former
+// master keys are no longer needed once ReEncrypt completes. The values stored in plaintext before the encryption was
+// enabled are encrypted too. Every instance checks the latest data key version of the tenant before using its cached
+// keyring, so the other instances encrypt with the new data key as soon as it is added. The former data key versions are
+// kept to read the values written before. The writes to the tenant must be stopped while ReEncrypt runs, as the index
+// rows of an address are written after its re-encrypted values and may otherwise be left stale.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant whose addresses to re-encrypt.
+// Returns either the report or error if something goes wrong.
+func (addressDataService *AddressDataService) ReEncrypt(ctx context.Context, tenantID system.UUID) (ReEncryptReport, error) {
+	diagnostics.IsNotNil(addressDataService.ClusterConfig, "addressDataService.ClusterConfig", "ClusterConfig must be provided.")
+	diagnostics.IsNotNil(addressDataService.KeyProvider, "addressDataService.KeyProvider", "KeyProvider must be provided.")
+
+	report := ReEncryptReport{TenantID: tenantID.String()}
+
+	err := addressDataService.executeWithSession(func(session *gocql.Session) error {
+		keyring, err := addressDataService.rotateDataKey(ctx, tenantID, session)
+
+		if err != nil {
+			return err
+		}
+
+		report.DataKeyVersion = keyring.CurrentVersion
+
+		if err = reEncryptAddressRows(ctx, tenantID, keyring, &report, session); err != nil {
+			return err
+		}
+
+		return reEncryptAddressHistory(ctx, tenantID, keyring, &report, session)
+	})
+
+	if err != nil {
+		return report, err
+	}
+
+	return report, nil
+}
+
+// executeWithKeyring runs the provided function with the shared session and the keyring of the tenant, or a nil keyring
+// if no key provider is provided. If the function fails because a value is encrypted with a data key version added after
+// the keyring was cached, the keyring is read again and the function is run once more. The functions read the stored
+// values before writing any, so running them again is safe.
+func (addressDataService *AddressDataService) executeWithKeyring(
+	ctx context.Context,
+	tenantID system.UUID,
+	function func(session *gocql.Session, keyring *encryption.Keyring) error) error {
+	return addressDataService.executeWithSession(func(session *gocql.Session) error {
+		keyring, err := addressDataService.getKeyring(ctx, tenantID, session)
+
+		if err != nil {
+			return err
+		}
+
+		if err = function(session, keyring); !isUnknownDataKey(err) {
+			return err
+		}
+
+		addressDataService.uncacheKeyring(tenantID)
+
+		if keyring, err = addressDataService.getKeyring(ctx, tenantID, session); err != nil {
+			return err
+		}
+
+		return function(session, keyring)
+	})
+}
+
+// getKeyring returns the keyring of the tenant, reading it if it is not cached or a data key version was added since it
+// was cached, e.g. by ReEncrypt on another instance. Only the latest data key version is read to check the cached one.
+// The first data key of the tenant is created if it has none.
+// Returns nil if no key provider is provided.
+func (addressDataService *AddressDataService) getKeyring(ctx context.Context, tenantID system.UUID, session *gocql.Session) (*encryption.Keyring, error) {
+	if addressDataService.KeyProvider == nil {
+		return nil, nil
+	}
+
+	latestVersion, err := readLatestDataKeyVersion(ctx, tenantID, session)
+
+	if err != nil {
+		return nil, err
+	}
+
+	addressDataService.keyringLock.Lock()
+	cached, ok := addressDataService.keyrings[tenantID]
+	addressDataService.keyringLock.Unlock()
+
+	if ok && cached.CurrentVersion == latestVersion {
+		return cached, nil
+	}
+
+	keyring, err := addressDataService.readKeyring(ctx, tenantID, session)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(keyring.DataKeys) == 0 {
+		if err = addressDataService.addDataKey(ctx, tenantID, 1, session); err != nil {
+			return nil, err
+		}
+
+		if keyring, err = addressDataService.readKeyring(ctx, tenantID, session); err != nil {
+			return nil, err
+		}
+	}
+
+	addressDataService.cacheKeyring(tenantID, keyring)
+
+	return keyring, nil
+}
+
+// rotateDataKey wraps all the data key versions of the tenant with the current master key and adds a new data key
+// version.
+// Returns the keyring of the tenant holding the new data key version as the current one.
+func (addressDataService *AddressDataService) rotateDataKey(ctx context.Context, tenantID system.UUID, session *gocql.Session) (*encryption.Keyring, error) {
+	keyring, err := addressDataService.readKeyring(ctx, tenantID, session)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, dataKey := range keyring.DataKeys {
+		masterKeyID, wrappedKey, err := addressDataService.KeyProvider.WrapKey(dataKey.Key)
+
+		if err != nil {
+			return nil, err
+		}
+
+		err = session.Query(
+			"UPDATE tenant_data_key"+
+				" SET master_key_id = ?, wrapped_key = ?"+
+				" WHERE"+
+				" tenant_id = ?"+
+				" AND version = ?",
+			masterKeyID,
+			wrappedKey,
+			mapSystemUUIDToGocqlUUID(tenantID),
+			dataKey.Version).WithContext(ctx).Exec()
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err = addressDataService.addDataKey(ctx, tenantID, keyring.CurrentVersion+1, session); err != nil {
+		return nil, err
+	}
+
+	if keyring, err = addressDataService.readKeyring(ctx, tenantID, session); err != nil {
+		return nil, err
+	}
+
+	addressDataService.cacheKeyring(tenantID, keyring)
+
+	return keyring, nil
+}
+
+// readKeyring reads and unwraps all the data key versions of the tenant. The latest version is the current one.
+func (addressDataService *AddressDataService) readKeyring(ctx context.Context, tenantID system.UUID, session *gocql.Session) (*encryption.Keyring, error) {
+	return encryption.ReadKeyring(ctx, session, tenantID, addressDataService.KeyProvider)
+}
+
+// readLatestDataKeyVersion reads the latest data key version of the tenant.
+// Returns either the latest data key version or 0 if the tenant has no data key, or error if something goes wrong.
+func readLatestDataKeyVersion(ctx context.Context, tenantID system.UUID, session *gocql.Session) (int, error) {
+	var version int
+
+	err := session.Query(
+		"SELECT version"+
+			" FROM tenant_data_key"+
+			" WHERE"+
+			" tenant_id = ?"+
+			" ORDER BY version DESC"+
+			" LIMIT 1",
+		mapSystemUUIDToGocqlUUID(tenantID)).WithContext(ctx).Scan(&version)
+
+	if err == gocql.ErrNotFound {
+		return 0, nil
+	}
+
+	return version, err
+}
+
+// addDataKey generates a new data key version for the tenant, wraps it with the current master key and stores it unless
+// the version already exists, e.g. it was added by another instance at the same time.
+func (addressDataService *AddressDataService) addDataKey(ctx context.Context, tenantID system.UUID, version int, session *gocql.Session) error {
+	dataKey, err := encryption.NewDataKey(version)
+
+	if err != nil {
+		return err
+	}
+
+	masterKeyID, wrappedKey, err := addressDataService.KeyProvider.WrapKey(dataKey.Key)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = session.Query(
+		"INSERT INTO tenant_data_key"+
+			" (tenant_id, version, master_key_id, wrapped_key, created_at)"+
+			" VALUES(?, ?, ?, ?, ?)"+
+			" IF NOT EXISTS",
+		mapSystemUUIDToGocqlUUID(tenantID),
+		version,
+		masterKeyID,
+		wrappedKey,
+		time.Now()).WithContext(ctx).MapScanCAS(make(map[string]interface{}))
+
+	return err
+}
+
+// cacheKeyring keeps the keyring of the tenant in memory.
+func (addressDataService *AddressDataService) cacheKeyring(tenantID system.UUID, keyring *encryption.Keyring) {
+	addressDataService.keyringLock.Lock()
+	defer addressDataService.keyringLock.Unlock()
+
+	if addressDataService.keyrings == nil {
+		addressDataService.keyrings = make(map[system.UUID]*encryption.Keyring)
+	}
+
+	addressDataService.keyrings[tenantID] = keyring
+}
+
+// uncacheKeyring removes the keyring of the tenant from memory, so it is read again on next use.
+func (addressDataService *AddressDataService) uncacheKeyring(tenantID system.UUID) {
+	addressDataService.keyringLock.Lock()
+	defer addressDataService.keyringLock.Unlock()
+
+	delete(addressDataService.keyrings, tenantID)
+}
+
+// isUnknownDataKey returns true if the error is returned because the keyring does not hold a data key version.
+func isUnknownDataKey(err error) bool {
+	_, ok := err.(encryption.UnknownDataKeyError)
+
+	return ok
+}
+
+// reEncryptedAddressRow is an address row read by reEncryptAddressRows along with its value encrypted again.
+type reEncryptedAddressRow struct {
+	key            string
+	value          string
+	encryptedValue string
+	hash           string
+	ttl            int
+}
+
+// reEncryptAddressRows encrypts the values of address table again with the current data key and writes them to address,
+// address_indexed_by_address_key and address_indexed_by_address_value tables along with their hashes, keeping their
+// remaining TTL. The rows of an address are written only if none of them has changed since they were read, otherwise
+// they are all counted as skipped.
+func reEncryptAddressRows(ctx context.Context, tenantID system.UUID, keyring *encryption.Keyring, report *ReEncryptReport, session *gocql.Session) error {
+	mappedTenantID := mapSystemUUIDToGocqlUUID(tenantID)
+
+	iter := session.Query(
+		"SELECT application_id, address_id, address_key, address_value, TTL(address_value)"+
+			" FROM address"+
+			" WHERE tenant_id = ?",
+		mappedTenantID).WithContext(ctx).Iter()
+
+	var applicationID, addressID, rowApplicationID, rowAddressID gocql.UUID
+	var key, value string
+	var ttl int
+
+	rows := []reEncryptedAddressRow{}
+
+	for iter.Scan(&rowApplicationID, &rowAddressID, &key, &value, &ttl) {
+		report.AddressRows++
+
+		// The rows are read in clustering order, so the rows of an address are next to each other.
+		if len(rows) != 0 && (rowApplicationID != applicationID || rowAddressID != addressID) {
+			if err := reEncryptAddress(ctx, mappedTenantID, applicationID, addressID, rows, report, session); err != nil {
+				iter.Close()
+
+				return err
+			}
+
+			rows = []reEncryptedAddressRow{}
+		}
+
+		applicationID = rowApplicationID
+		addressID = rowAddressID
+
+		decryptedValue, err := keyring.Decrypt(value)
+
+		if err != nil {
+			iter.Close()
+
+			return err
+		}
+
+		encryptedValue, err := keyring.Encrypt(decryptedValue)
+
+		if err != nil {
+			iter.Close()
+
+			return err
+		}
+
+		hash, err := keyring.Hash(key, decryptedValue)
+
+		if err != nil {
+			iter.Close()
+
+			return err
+		}
+
+		rows = append(rows, reEncryptedAddressRow{key: key, value: value, encryptedValue: encryptedValue, hash: hash, ttl: ttl})
+		ttl = 0
+	}
+
+	if err := iter.Close(); err != nil {
+		return err
+	}
+
+	if len(rows) == 0 {
+		return nil
+	}
+
+	return reEncryptAddress(ctx, mappedTenantID, applicationID, addressID, rows, report, session)
+}
+
+// reEncryptAddress writes the re-encrypted rows of an address to address table in a conditional batch on the address
+// partition, applied only if none of the values changed since they were read. Once the batch is applied, the index rows
+// of the address are written in a logged batch. Cassandra does not allow a conditional batch to span tables, so the
+// index rows are written after the address rows, and an update written without condition in between is not seen by the
+// conditional batch. The writes to the tenant must therefore be stopped while it is re-encrypted.
+func reEncryptAddress(
+	ctx context.Context,
+	tenantID gocql.UUID,
+	applicationID gocql.UUID,
+	addressID gocql.UUID,
+	rows []reEncryptedAddressRow,
+	report *ReEncryptReport,
+	session *gocql.Session) error {
+	keys := make([]string, 0, len(rows))
+
+	for _, row := range rows {
+		keys = append(keys, row.key)
+	}
+
+	existingValueHashes, err := readAddressValueHashes(
+		ctx,
+		mapGocqlUUIDToSystemUUID(tenantID),
+		mapGocqlUUIDToSystemUUID(applicationID),
+		mapGocqlUUIDToSystemUUID(addressID),
+		keys,
+		session)
+
+	if err != nil {
+		return err
+	}
+
+	conditionalBatch := session.NewBatch(gocql.LoggedBatch)
+
+	for _, row := range rows {
+		conditionalBatch.Query(
+			"UPDATE address"+
+				" USING TTL ?"+
+				" SET address_value = ?"+
+				" WHERE"+
+				" tenant_id = ?"+
+				" AND application_id = ?"+
+				" AND address_id = ?"+
+				" AND address_key = ?"+
+				" IF address_value = ?",
+			row.ttl,
+			row.encryptedValue,
+			tenantID,
+			applicationID,
+			addressID,
+			row.key,
+			row.value)
+	}
+
+	applied, iter, err := session.MapExecuteBatchCAS(conditionalBatch.WithContext(ctx), make(map[string]interface{}))
+
+	if err != nil {
+		return err
+	}
+
+	if err = iter.Close(); err != nil {
+		return err
+	}
+
+	if !applied {
+		report.SkippedRows += len(rows)
+
+		return nil
+	}
+
+	batch := session.NewBatch(gocql.LoggedBatch)
+
+	for _, row := range rows {
+		if valueHash, ok := existingValueHashes[row.key]; ok && valueHash != row.hash {
+			removeFromIndexByAddressValueTable(batch, tenantID, applicationID, addressID, row.key, valueHash)
+		}
+
+		addToAddressIndexByAddressKeyTable(batch, tenantID, applicationID, addressID, row.key, row.encryptedValue, row.hash, row.ttl)
+	}
+
+	return session.ExecuteBatch(batch.WithContext(ctx))
+}
+
+// reEncryptAddressHistory encrypts the address details recorded in address_history table again with the current data key.
+func reEncryptAddressHistory(ctx context.Context, tenantID system.UUID, keyring *encryption.Keyring, report *ReEncryptReport, session *gocql.Session) error {
+	mappedTenantID := mapSystemUUIDToGocqlUUID(tenantID)
+
+	iter := session.Query(
+		"SELECT application_id, address_id, version, address_details"+
+			" FROM address_history"+
+			" WHERE tenant_id = ?",
+		mappedTenantID).WithContext(ctx).Iter()
+
+	var applicationID, addressID gocql.UUID
+	var version int64
+	var addressDetails map[string]string
+
+	for iter.Scan(&applicationID, &addressID, &version, &addressDetails) {
+		report.HistoryRows++
+
+		decryptedAddressDetails, err := decryptAddressDetails(keyring, addressDetails)
+
+		if err != nil {
+			iter.Close()
+
+			return err
+		}
+
+		encryptedAddressDetails, err := encryptAddressDetails(keyring, decryptedAddressDetails)
+
+		if err != nil {
+			iter.Close()
+
+			return err
+		}
+
+		err = session.Query(
+			"UPDATE address_history"+
+				" SET address_details = ?"+
+				" WHERE"+
+				" tenant_id = ?"+
+				" AND application_id = ?"+
+				" AND address_id = ?"+
+				" AND version = ?",
+			encryptedAddressDetails,
+			mappedTenantID,
+			applicationID,
+			addressID,
+			version).WithContext(ctx).Exec()
+
+		if err != nil {
+			iter.Close()
+
+			return err
+		}
+
+		addressDetails = nil
+	}
+
+	return iter.Close()
+}
+
+// encryptAddressDetails returns the address details with their values encrypted.
+func encryptAddressDetails(keyring *encryption.Keyring, addressDetails map[string]string) (map[string]string, error) {
+	encryptedAddressDetails := make(map[string]string, len(addressDetails))
+
+	for key, value := range addressDetails {
+		encryptedValue, err := keyring.Encrypt(value)
+
+		if err != nil {
+			return nil, err
+		}
+
+		encryptedAddressDetails[key] = encryptedValue
+	}
+
+	return encryptedAddressDetails, nil
+}
+
+// decryptAddressDetails returns the address details with their values decrypted.
+func decryptAddressDetails(keyring *encryption.Keyring, addressDetails map[string]string) (map[string]string, error) {
+	decryptedAddressDetails := make(map[string]string, len(addressDetails))
+
+	for key, value := range addressDetails {
+		decryptedValue, err := keyring.Decrypt(value)
+
+		if err != nil {
+			return nil, err
+		}
+
+		decryptedAddressDetails[key] = decryptedValue
+	}
+
+	return decryptedAddressDetails, nil
+}
@@ -0,0 +1,261 @@
+// +build integration
+
+package service_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/golang/mock/gomock"
+	"github.com/micro-business/AddressService/data/contract"
+	"github.com/micro-business/AddressService/data/encryption"
+	"github.com/micro-business/AddressService/data/service"
+	"github.com/micro-business/Micro-Business-Core/system"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("Address value encryption behaviour", func() {
+	var (
+		ctx                      context.Context
+		mockCtrl                 *gomock.Controller
+		addressDataService       *service.AddressDataService
+		mockUUIDGeneratorService *MockUUIDGeneratorService
+		tenantID                 system.UUID
+		applicationID            system.UUID
+		addressID                system.UUID
+		clusterConfig            *gocql.ClusterConfig
+		addressDetails           map[string]string
+		directory                string
+	)
+
+	writeMasterKeys := func(currentKeyID string, keyIDs ...string) {
+		keys := make(map[string]string)
+
+		for _, keyID := range keyIDs {
+			key := make([]byte, 32)
+			copy(key, keyID)
+			keys[keyID] = base64.StdEncoding.EncodeToString(key)
+		}
+
+		content, _ := json.Marshal(map[string]interface{}{"currentKeyId": currentKeyID, "keys": keys})
+
+		Expect(ioutil.WriteFile(filepath.Join(directory, "master-keys.json"), content, 0600)).To(BeNil())
+	}
+
+	readStoredValues := func(table string) []string {
+		session, err := clusterConfig.CreateSession()
+
+		Expect(err).To(BeNil())
+
+		defer session.Close()
+
+		iter := session.Query(
+			"SELECT address_value"+
+				" FROM "+table+
+				" WHERE tenant_id = ?",
+			mapSystemUUIDToGocqlUUID(tenantID)).Iter()
+
+		values := []string{}
+
+		var value string
+
+		for iter.Scan(&value) {
+			values = append(values, value)
+		}
+
+		Expect(iter.Close()).To(BeNil())
+
+		return values
+	}
+
+	createAddress := func(addressDataService *service.AddressDataService) {
+		mockUUIDGeneratorService.
+			EXPECT().
+			GenerateRandomUUID().
+			Return(addressID, nil)
+
+		_, err := addressDataService.Create(ctx, tenantID, applicationID, contract.Address{AddressDetails: addressDetails}, "")
+
+		Expect(err).To(BeNil())
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		clusterConfig = getClusterConfig()
+		clusterConfig.Keyspace = keyspace
+
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockUUIDGeneratorService = NewMockUUIDGeneratorService(mockCtrl)
+
+		var err error
+
+		directory, err = ioutil.TempDir("", "master-keys")
+
+		Expect(err).To(BeNil())
+
+		writeMasterKeys("2024-01", "2024-01")
+
+		addressDataService = &service.AddressDataService{
+			UUIDGeneratorService: mockUUIDGeneratorService,
+			ClusterConfig:        clusterConfig,
+			KeyProvider:          encryption.LocalFileKeyProvider{Path: filepath.Join(directory, "master-keys.json")},
+		}
+
+		tenantID, _ = system.RandomUUID()
+		applicationID, _ = system.RandomUUID()
+		addressID, _ = system.RandomUUID()
+		addressDetails = createRandomAddressDetails()
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+		addressDataService.Close()
+		os.RemoveAll(directory)
+	})
+
+	Context("when creating an address", func() {
+		It("should store the encrypted values in both address tables and return the decrypted values", func() {
+			createAddress(addressDataService)
+
+			for _, table := range []string{"address", "address_indexed_by_address_key"} {
+				storedValues := readStoredValues(table)
+
+				Expect(storedValues).To(HaveLen(len(addressDetails)))
+
+				for _, storedValue := range storedValues {
+					Expect(encryption.IsEncrypted(storedValue)).To(BeTrue())
+				}
+			}
+
+			address, err := addressDataService.ReadAll(ctx, tenantID, applicationID, addressID)
+
+			Expect(err).To(BeNil())
+			Expect(address.AddressDetails).To(Equal(addressDetails))
+
+			history, err := addressDataService.History(ctx, tenantID, applicationID, addressID)
+
+			Expect(err).To(BeNil())
+			Expect(history[0].Address.AddressDetails).To(Equal(addressDetails))
+		})
+
+		It("should find the address by its encrypted detail", func() {
+			createAddress(addressDataService)
+
+			for key, value := range addressDetails {
+				listedAddresses, err := addressDataService.FindByDetail(ctx, tenantID, applicationID, map[string]string{key: value})
+
+				Expect(err).To(BeNil())
+				Expect(listedAddresses).To(HaveLen(1))
+				Expect(listedAddresses[0].AddressID).To(Equal(addressID))
+
+				break
+			}
+		})
+	})
+
+	Context("when re-encrypting the addresses of a tenant", func() {
+		It("should encrypt the values with a new data key wrapped with the current master key", func() {
+			createAddress(addressDataService)
+
+			writeMasterKeys("2024-02", "2024-01", "2024-02")
+
+			report, err := addressDataService.ReEncrypt(ctx, tenantID)
+
+			Expect(err).To(BeNil())
+			Expect(report.DataKeyVersion).To(Equal(2))
+			Expect(report.AddressRows).To(Equal(len(addressDetails)))
+			Expect(report.HistoryRows).To(Equal(1))
+			Expect(report.SkippedRows).To(Equal(0))
+
+			for _, storedValue := range readStoredValues("address") {
+				Expect(strings.HasPrefix(storedValue, "enc:2:")).To(BeTrue())
+			}
+
+			writeMasterKeys("2024-02", "2024-02")
+
+			reopenedAddressDataService := &service.AddressDataService{
+				ClusterConfig: clusterConfig,
+				KeyProvider:   addressDataService.KeyProvider,
+			}
+
+			defer reopenedAddressDataService.Close()
+
+			address, err := reopenedAddressDataService.ReadAll(ctx, tenantID, applicationID, addressID)
+
+			Expect(err).To(BeNil())
+			Expect(address.AddressDetails).To(Equal(addressDetails))
+
+			for key, value := range addressDetails {
+				listedAddresses, err := reopenedAddressDataService.FindByDetail(ctx, tenantID, applicationID, map[string]string{key: value})
+
+				Expect(err).To(BeNil())
+				Expect(listedAddresses).To(HaveLen(1))
+
+				break
+			}
+		})
+
+		It("should make the other instances encrypt with the new data key on their next write", func() {
+			createAddress(addressDataService)
+
+			otherAddressDataService := &service.AddressDataService{
+				UUIDGeneratorService: mockUUIDGeneratorService,
+				ClusterConfig:        clusterConfig,
+				KeyProvider:          addressDataService.KeyProvider,
+			}
+
+			defer otherAddressDataService.Close()
+
+			_, err := otherAddressDataService.ReEncrypt(ctx, tenantID)
+
+			Expect(err).To(BeNil())
+
+			err = addressDataService.Update(ctx, tenantID, applicationID, addressID, contract.Address{AddressDetails: createRandomAddressDetails()}, contract.AnyVersion, "")
+
+			Expect(err).To(BeNil())
+
+			for _, storedValue := range readStoredValues("address") {
+				Expect(strings.HasPrefix(storedValue, "enc:2:")).To(BeTrue())
+			}
+		})
+
+		It("should encrypt the values stored in plaintext before the encryption was enabled", func() {
+			plaintextAddressDataService := &service.AddressDataService{UUIDGeneratorService: mockUUIDGeneratorService, ClusterConfig: clusterConfig}
+			defer plaintextAddressDataService.Close()
+
+			createAddress(plaintextAddressDataService)
+
+			for _, storedValue := range readStoredValues("address") {
+				Expect(encryption.IsEncrypted(storedValue)).To(BeFalse())
+			}
+
+			_, err := addressDataService.ReEncrypt(ctx, tenantID)
+
+			Expect(err).To(BeNil())
+
+			for _, table := range []string{"address", "address_indexed_by_address_key"} {
+				for _, storedValue := range readStoredValues(table) {
+					Expect(encryption.IsEncrypted(storedValue)).To(BeTrue())
+				}
+			}
+
+			address, err := addressDataService.ReadAll(ctx, tenantID, applicationID, addressID)
+
+			Expect(err).To(BeNil())
+			Expect(address.AddressDetails).To(Equal(addressDetails))
+		})
+	})
+})
+
+func TestAddressValueEncryption(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Address value encryption behaviour")
+}
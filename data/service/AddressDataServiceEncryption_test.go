@@ -0,0 +1,42 @@
+package service_test
+
+import (
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/micro-business/AddressService/data/encryption"
+	"github.com/micro-business/AddressService/data/service"
+	"github.com/micro-business/Micro-Business-Core/system"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("ReEncrypt method input parameters and dependency test", func() {
+	var tenantID system.UUID
+
+	BeforeEach(func() {
+		tenantID, _ = system.RandomUUID()
+	})
+
+	Context("when cluster configuration not provided", func() {
+		It("should panic", func() {
+			addressDataService := &service.AddressDataService{KeyProvider: encryption.LocalFileKeyProvider{Path: "master-keys.json"}}
+
+			Ω(func() { addressDataService.ReEncrypt(context.Background(), tenantID) }).Should(Panic())
+		})
+	})
+
+	Context("when key provider not provided", func() {
+		It("should panic", func() {
+			addressDataService := &service.AddressDataService{ClusterConfig: &gocql.ClusterConfig{}}
+
+			Ω(func() { addressDataService.ReEncrypt(context.Background(), tenantID) }).Should(Panic())
+		})
+	})
+})
+
+func TestReEncrypt(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "ReEncrypt method input parameters and dependency test")
+}
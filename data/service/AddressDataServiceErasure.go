@@ -0,0 +1,398 @@
+package service
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/micro-business/AddressService/data/contract"
+	"github.com/micro-business/Micro-Business-Core/common/diagnostics"
+	"github.com/micro-business/Micro-Business-Core/system"
+	"golang.org/x/net/context"
+)
+
+// erasureChunkSize is the number of rows read and deleted at a time when erasing a tenant or an application. The rows of a
+// chunk are deleted in one batch, so it is kept small enough not to exceed the batch size limit of the cluster.
+const erasureChunkSize = 100
+
+// erasedTable defines a table the rows of a tenant or an application are erased from.
+type erasedTable struct {
+	name string
+
+	// keyColumns are the primary key columns following tenant_id, which identify the rows to delete.
+	keyColumns []string
+
+	// tenantOnly is true if the table is not keyed by application, so it is erased only when the whole tenant is erased.
+	tenantOnly bool
+}
+
+// erasedTables are the tables erased in order. tenant_data_key is erased last, so the values left behind by an
+// interrupted erasure can still be decrypted until the erasure is resumed.
+var erasedTables = []erasedTable{
+	{name: "address", keyColumns: []string{"application_id", "address_id", "address_key"}},
+	{name: "address_indexed_by_address_key", keyColumns: []string{"application_id", "address_key", "address_id"}},
+	{name: "address_indexed_by_address_value", keyColumns: []string{"application_id", "address_key", "address_value_hash", "address_id"}},
+	{name: "address_indexed_by_fingerprint", keyColumns: []string{"application_id", "fingerprint", "address_id"}},
+	{name: "address_owner", keyColumns: []string{"application_id", "address_id", "owner_type", "owner_id"}},
+	{name: "address_indexed_by_owner", keyColumns: []string{"application_id", "owner_type", "owner_id", "address_id"}},
+	{name: "address_metadata", keyColumns: []string{"application_id", "address_id"}},
+	{name: "address_history", keyColumns: []string{"application_id", "address_id", "version"}},
+	{name: "address_event_dead_letter", keyColumns: []string{"application_id", "event_id"}},
+	{name: "tenant_data_key", keyColumns: []string{"version"}, tenantOnly: true},
+}
+
+// erasureProgress is an erasure certificate along with where the erasure stopped, so an interrupted erasure can be resumed.
+type erasureProgress struct {
+	certificate contract.ErasureCertificate
+
+	// currentTable is the name of the table being erased, or empty if the erasure has not erased any chunk yet.
+	currentTable string
+
+	// pageState is the paging state of the next chunk to read from currentTable.
+	pageState []byte
+}
+
+// PurgeTenant removes for good all the addresses of all the applications of a tenant along with their history and data
+// keys, whether they are deleted or not, and records an erasure certificate. The rows are removed in chunks and the
+// progress is recorded after every chunk, so an interrupted erasure continues where it stopped when it is run again.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant to erase.
+// requestedBy: Optional. Identifies who requests the erasure, recorded in the erasure certificate.
+// Returns either the erasure certificate or error if something goes wrong.
+func (addressDataService *AddressDataService) PurgeTenant(ctx context.Context, tenantID system.UUID, requestedBy string) (contract.ErasureCertificate, error) {
+	diagnostics.IsNotNil(addressDataService.UUIDGeneratorService, "addressDataService.UUIDGeneratorService", "UUIDGeneratorService must be provided.")
+	diagnostics.IsNotNil(addressDataService.ClusterConfig, "addressDataService.ClusterConfig", "ClusterConfig must be provided.")
+
+	defer addressDataService.uncacheKeyring(tenantID)
+
+	return addressDataService.erase(ctx, tenantID, system.EmptyUUID, requestedBy)
+}
+
+// PurgeApplication removes for good all the addresses of a tenant's application along with their history, whether they
+// are deleted or not, and records an erasure certificate. The rows are removed in chunks and the progress is recorded
+// after every chunk, so an interrupted erasure continues where it stopped when it is run again.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the application.
+// applicationID: Mandatory. The unique identifier of the tenant's application to erase.
+// requestedBy: Optional. Identifies who requests the erasure, recorded in the erasure certificate.
+// Returns either the erasure certificate or error if something goes wrong.
+func (addressDataService *AddressDataService) PurgeApplication(
+	ctx context.Context,
+	tenantID, applicationID system.UUID,
+	requestedBy string) (contract.ErasureCertificate, error) {
+	diagnostics.IsNotNil(addressDataService.UUIDGeneratorService, "addressDataService.UUIDGeneratorService", "UUIDGeneratorService must be provided.")
+	diagnostics.IsNotNil(addressDataService.ClusterConfig, "addressDataService.ClusterConfig", "ClusterConfig must be provided.")
+
+	return addressDataService.erase(ctx, tenantID, applicationID, requestedBy)
+}
+
+// erase removes the rows of the tenant, or only the rows of the application if applicationID is not empty, from all the
+// erased tables, resuming the incomplete erasure of the same tenant or application if there is one.
+func (addressDataService *AddressDataService) erase(
+	ctx context.Context,
+	tenantID, applicationID system.UUID,
+	requestedBy string) (contract.ErasureCertificate, error) {
+	var progress erasureProgress
+
+	err := addressDataService.executeWithSession(func(session *gocql.Session) error {
+		var err error
+
+		if progress, err = addressDataService.startErasure(ctx, tenantID, applicationID, requestedBy, session); err != nil {
+			return err
+		}
+
+		// The events are erased before the address rows, so the event relay does not publish the details of the addresses
+		// being erased. Erasing them again when the erasure is resumed is harmless.
+		if err = eraseAddressEvents(ctx, &progress, session); err != nil {
+			return err
+		}
+
+		// The deleted addresses are found through address_metadata table, so they are erased before it. Erasing them
+		// again when the erasure is resumed is harmless.
+		if err = eraseDeletedAddresses(ctx, &progress, session); err != nil {
+			return err
+		}
+
+		started := len(progress.currentTable) == 0
+
+		for _, table := range erasedTables {
+			if table.tenantOnly && applicationID != system.EmptyUUID {
+				continue
+			}
+
+			if !started {
+				if table.name != progress.currentTable {
+					continue
+				}
+
+				started = true
+			} else {
+				progress.currentTable = table.name
+				progress.pageState = nil
+			}
+
+			if err = eraseTable(ctx, table, &progress, session); err != nil {
+				return err
+			}
+		}
+
+		progress.certificate.CompletedAt = time.Now()
+
+		return session.Query(
+			"UPDATE erasure_certificate"+
+				" SET completed_at = ?, current_table = null, page_state = null"+
+				" WHERE"+
+				" tenant_id = ?"+
+				" AND application_id = ?"+
+				" AND certificate_id = ?",
+			progress.certificate.CompletedAt,
+			mapSystemUUIDToGocqlUUID(tenantID),
+			mapSystemUUIDToGocqlUUID(applicationID),
+			mapSystemUUIDToGocqlUUID(progress.certificate.CertificateID)).WithContext(ctx).Exec()
+	})
+
+	if err != nil {
+		return contract.ErasureCertificate{}, err
+	}
+
+	return progress.certificate, nil
+}
+
+// eraseDeletedAddresses deletes the rows of the deleted addresses of the tenant or the application from the hourly buckets
+// of deleted_address table and records their number in the erasure certificate. The buckets are not partitioned by
+// tenant, so the rows are found through the deletion times recorded in address_metadata table.
+func eraseDeletedAddresses(ctx context.Context, progress *erasureProgress, session *gocql.Session) error {
+	mappedTenantID := mapSystemUUIDToGocqlUUID(progress.certificate.TenantID)
+	mappedApplicationID := mapSystemUUIDToGocqlUUID(progress.certificate.ApplicationID)
+	statement := "SELECT application_id, address_id, deleted_at FROM address_metadata WHERE tenant_id = ?"
+	values := []interface{}{mappedTenantID}
+
+	if progress.certificate.ApplicationID != system.EmptyUUID {
+		statement += " AND application_id = ?"
+		values = append(values, mappedApplicationID)
+	}
+
+	iter := session.Query(statement, values...).PageSize(erasureChunkSize).WithContext(ctx).Iter()
+
+	var applicationID, addressID gocql.UUID
+	var deletedAt time.Time
+
+	erasedRows := int64(0)
+	batch := session.NewBatch(gocql.UnloggedBatch)
+
+	for iter.Scan(&applicationID, &addressID, &deletedAt) {
+		if !deletedAt.IsZero() {
+			removeFromDeletedAddressTable(batch, mappedTenantID, applicationID, addressID, deletedAt)
+		}
+
+		deletedAt = time.Time{}
+
+		if batch.Size() == erasureChunkSize {
+			if err := session.ExecuteBatch(batch.WithContext(ctx)); err != nil {
+				iter.Close()
+
+				return err
+			}
+
+			erasedRows += int64(batch.Size())
+			batch = session.NewBatch(gocql.UnloggedBatch)
+		}
+	}
+
+	if err := iter.Close(); err != nil {
+		return err
+	}
+
+	if batch.Size() != 0 {
+		if err := session.ExecuteBatch(batch.WithContext(ctx)); err != nil {
+			return err
+		}
+
+		erasedRows += int64(batch.Size())
+	}
+
+	progress.certificate.ErasedRows["deleted_address"] += erasedRows
+
+	return session.Query(
+		"UPDATE erasure_certificate"+
+			" SET erased_rows = erased_rows + ?"+
+			" WHERE"+
+			" tenant_id = ?"+
+			" AND application_id = ?"+
+			" AND certificate_id = ?",
+		map[string]int64{"deleted_address": progress.certificate.ErasedRows["deleted_address"]},
+		mappedTenantID,
+		mappedApplicationID,
+		mapSystemUUIDToGocqlUUID(progress.certificate.CertificateID)).WithContext(ctx).Exec()
+}
+
+// startErasure returns the progress of the incomplete erasure of the tenant or the application, or records a new erasure
+// certificate if there is none.
+func (addressDataService *AddressDataService) startErasure(
+	ctx context.Context,
+	tenantID, applicationID system.UUID,
+	requestedBy string,
+	session *gocql.Session) (erasureProgress, error) {
+	mappedTenantID := mapSystemUUIDToGocqlUUID(tenantID)
+	mappedApplicationID := mapSystemUUIDToGocqlUUID(applicationID)
+
+	iter := session.Query(
+		"SELECT certificate_id, requested_by, started_at, completed_at, erased_rows, current_table, page_state"+
+			" FROM erasure_certificate"+
+			" WHERE"+
+			" tenant_id = ?"+
+			" AND application_id = ?",
+		mappedTenantID,
+		mappedApplicationID).WithContext(ctx).Iter()
+
+	var certificateID gocql.UUID
+	var recordedRequestedBy, currentTable string
+	var startedAt, completedAt time.Time
+	var erasedRows map[string]int64
+	var pageState []byte
+
+	for iter.Scan(&certificateID, &recordedRequestedBy, &startedAt, &completedAt, &erasedRows, &currentTable, &pageState) {
+		if completedAt.IsZero() {
+			iter.Close()
+
+			if erasedRows == nil {
+				erasedRows = make(map[string]int64)
+			}
+
+			return erasureProgress{
+				certificate: contract.ErasureCertificate{
+					CertificateID: mapGocqlUUIDToSystemUUID(certificateID),
+					TenantID:      tenantID,
+					ApplicationID: applicationID,
+					RequestedBy:   recordedRequestedBy,
+					StartedAt:     startedAt,
+					ErasedRows:    erasedRows,
+				},
+				currentTable: currentTable,
+				pageState:    pageState,
+			}, nil
+		}
+
+		completedAt = time.Time{}
+		erasedRows = nil
+		currentTable = ""
+		pageState = nil
+	}
+
+	if err := iter.Close(); err != nil {
+		return erasureProgress{}, err
+	}
+
+	newCertificateID, err := addressDataService.UUIDGeneratorService.GenerateRandomUUID()
+
+	if err != nil {
+		return erasureProgress{}, err
+	}
+
+	certificate := contract.ErasureCertificate{
+		CertificateID: newCertificateID,
+		TenantID:      tenantID,
+		ApplicationID: applicationID,
+		RequestedBy:   requestedBy,
+		StartedAt:     time.Now(),
+		ErasedRows:    make(map[string]int64),
+	}
+
+	err = session.Query(
+		"INSERT INTO erasure_certificate"+
+			" (tenant_id, application_id, certificate_id, requested_by, started_at)"+
+			" VALUES(?, ?, ?, ?, ?)",
+		mappedTenantID,
+		mappedApplicationID,
+		mapSystemUUIDToGocqlUUID(newCertificateID),
+		requestedBy,
+		certificate.StartedAt).WithContext(ctx).Exec()
+
+	if err != nil {
+		return erasureProgress{}, err
+	}
+
+	return erasureProgress{certificate: certificate}, nil
+}
+
+// eraseTable deletes the rows of the tenant or the application from the table in chunks, starting from the paging state
+// recorded in the progress. Every chunk is deleted in one unlogged batch, as all its rows belong to the same partition, and
+// the progress is recorded once the chunk is deleted. Reading the next chunk from the recorded paging state rather than
+// from the beginning of the partition saves reading the tombstones of the rows already deleted.
+func eraseTable(ctx context.Context, table erasedTable, progress *erasureProgress, session *gocql.Session) error {
+	mappedTenantID := mapSystemUUIDToGocqlUUID(progress.certificate.TenantID)
+	selectStatement := "SELECT " + strings.Join(table.keyColumns, ", ") + " FROM " + table.name + " WHERE tenant_id = ?"
+	deleteStatement := "DELETE FROM " + table.name + " WHERE tenant_id = ?"
+	values := []interface{}{mappedTenantID}
+
+	for _, column := range table.keyColumns {
+		deleteStatement += " AND " + column + " = ?"
+	}
+
+	if progress.certificate.ApplicationID != system.EmptyUUID {
+		selectStatement += " AND application_id = ?"
+		values = append(values, mapSystemUUIDToGocqlUUID(progress.certificate.ApplicationID))
+	}
+
+	for {
+		iter := session.Query(selectStatement, values...).
+			PageSize(erasureChunkSize).
+			PageState(progress.pageState).
+			WithContext(ctx).
+			Iter()
+
+		nextPageState := iter.PageState()
+		batch := session.NewBatch(gocql.UnloggedBatch)
+
+		for {
+			row := make(map[string]interface{})
+
+			if !iter.MapScan(row) {
+				break
+			}
+
+			keyValues := []interface{}{mappedTenantID}
+
+			for _, column := range table.keyColumns {
+				keyValues = append(keyValues, row[column])
+			}
+
+			batch.Query(deleteStatement, keyValues...)
+		}
+
+		if err := iter.Close(); err != nil {
+			return err
+		}
+
+		if batch.Size() != 0 {
+			if err := session.ExecuteBatch(batch.WithContext(ctx)); err != nil {
+				return err
+			}
+		}
+
+		progress.certificate.ErasedRows[table.name] += int64(batch.Size())
+		progress.pageState = nextPageState
+
+		err := session.Query(
+			"UPDATE erasure_certificate"+
+				" SET erased_rows = erased_rows + ?, current_table = ?, page_state = ?"+
+				" WHERE"+
+				" tenant_id = ?"+
+				" AND application_id = ?"+
+				" AND certificate_id = ?",
+			map[string]int64{table.name: progress.certificate.ErasedRows[table.name]},
+			table.name,
+			nextPageState,
+			mappedTenantID,
+			mapSystemUUIDToGocqlUUID(progress.certificate.ApplicationID),
+			mapSystemUUIDToGocqlUUID(progress.certificate.CertificateID)).WithContext(ctx).Exec()
+
+		if err != nil {
+			return err
+		}
+
+		if len(nextPageState) == 0 {
+			return nil
+		}
+	}
+}
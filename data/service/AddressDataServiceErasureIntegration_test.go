@@ -0,0 +1,178 @@
+// +build integration
+
+package service_test
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/golang/mock/gomock"
+	"github.com/micro-business/AddressService/data/contract"
+	"github.com/micro-business/AddressService/data/service"
+	"github.com/micro-business/Micro-Business-Core/system"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("PurgeTenant and PurgeApplication methods behaviour", func() {
+	var (
+		ctx                      context.Context
+		mockCtrl                 *gomock.Controller
+		addressDataService       *service.AddressDataService
+		mockUUIDGeneratorService *MockUUIDGeneratorService
+		tenantID                 system.UUID
+		applicationID            system.UUID
+		otherApplicationID       system.UUID
+		addressID                system.UUID
+		otherAddressID           system.UUID
+		certificateID            system.UUID
+		clusterConfig            *gocql.ClusterConfig
+		addressDetails           map[string]string
+	)
+
+	countRows := func(table string) int {
+		session, err := clusterConfig.CreateSession()
+
+		Expect(err).To(BeNil())
+
+		defer session.Close()
+
+		var count int
+
+		Expect(session.Query(
+			"SELECT COUNT(*) FROM "+table+" WHERE tenant_id = ?",
+			mapSystemUUIDToGocqlUUID(tenantID)).Scan(&count)).To(BeNil())
+
+		return count
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		clusterConfig = getClusterConfig()
+		clusterConfig.Keyspace = keyspace
+
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockUUIDGeneratorService = NewMockUUIDGeneratorService(mockCtrl)
+
+		addressDataService = &service.AddressDataService{UUIDGeneratorService: mockUUIDGeneratorService, ClusterConfig: clusterConfig}
+
+		tenantID, _ = system.RandomUUID()
+		applicationID, _ = system.RandomUUID()
+		otherApplicationID, _ = system.RandomUUID()
+		addressID, _ = system.RandomUUID()
+		otherAddressID, _ = system.RandomUUID()
+		certificateID, _ = system.RandomUUID()
+
+		addressDetails = make(map[string]string)
+
+		for idx := 0; idx < 150; idx++ {
+			addressDetails["Line"+strconv.Itoa(idx)] = strconv.Itoa(idx)
+		}
+
+		gomock.InOrder(
+			mockUUIDGeneratorService.EXPECT().GenerateRandomUUID().Return(addressID, nil),
+			mockUUIDGeneratorService.EXPECT().GenerateRandomUUID().Return(otherAddressID, nil))
+
+		_, err := addressDataService.Create(ctx, tenantID, applicationID, contract.Address{AddressDetails: addressDetails}, "")
+
+		Expect(err).To(BeNil())
+		Expect(addressDataService.Delete(ctx, tenantID, applicationID, addressID, contract.AnyVersion, "")).To(BeNil())
+
+		_, err = addressDataService.Create(ctx, tenantID, otherApplicationID, contract.Address{AddressDetails: map[string]string{"City": "Wellington"}}, "")
+
+		Expect(err).To(BeNil())
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+		addressDataService.Close()
+	})
+
+	Context("when erasing a tenant", func() {
+		It("should remove all the rows of the tenant in chunks and record the erasure certificate", func() {
+			mockUUIDGeneratorService.
+				EXPECT().
+				GenerateRandomUUID().
+				Return(certificateID, nil)
+
+			certificate, err := addressDataService.PurgeTenant(ctx, tenantID, "privacy-officer")
+
+			Expect(err).To(BeNil())
+			Expect(certificate.CertificateID).To(Equal(certificateID))
+			Expect(certificate.RequestedBy).To(Equal("privacy-officer"))
+			Expect(certificate.CompletedAt.IsZero()).To(BeFalse())
+			Expect(certificate.ErasedRows).To(Equal(map[string]int64{
+				"address":                          151,
+				"address_indexed_by_address_key":   151,
+				"address_indexed_by_address_value": 151,
+				"address_indexed_by_fingerprint":   0,
+				"address_owner":                    0,
+				"address_indexed_by_owner":         0,
+				"address_metadata":                 2,
+				"address_history":                  3,
+				"tenant_data_key":                  0,
+				"address_event_outbox":             0,
+				"deleted_address":                  1,
+			}))
+
+			for _, table := range []string{"address", "address_indexed_by_address_key", "address_metadata", "address_history"} {
+				Expect(countRows(table)).To(Equal(0))
+			}
+		})
+	})
+
+	Context("when erasing an application", func() {
+		It("should remove only the rows of the application", func() {
+			mockUUIDGeneratorService.
+				EXPECT().
+				GenerateRandomUUID().
+				Return(certificateID, nil)
+
+			certificate, err := addressDataService.PurgeApplication(ctx, tenantID, otherApplicationID, "")
+
+			Expect(err).To(BeNil())
+			Expect(certificate.ApplicationID).To(Equal(otherApplicationID))
+			Expect(certificate.ErasedRows["address"]).To(Equal(int64(1)))
+
+			_, err = addressDataService.ReadAll(ctx, tenantID, otherApplicationID, otherAddressID)
+
+			Expect(err).To(Equal(contract.NotFoundError{AddressID: otherAddressID}))
+			Expect(countRows("address")).To(Equal(150))
+		})
+
+		It("should resume the interrupted erasure of the same application and keep its erasure certificate", func() {
+			session, err := clusterConfig.CreateSession()
+
+			Expect(err).To(BeNil())
+
+			defer session.Close()
+
+			Expect(session.Query(
+				"INSERT INTO erasure_certificate"+
+					" (tenant_id, application_id, certificate_id, requested_by, started_at, erased_rows)"+
+					" VALUES(?, ?, ?, ?, ?, ?)",
+				mapSystemUUIDToGocqlUUID(tenantID),
+				mapSystemUUIDToGocqlUUID(applicationID),
+				mapSystemUUIDToGocqlUUID(certificateID),
+				"privacy-officer",
+				time.Now().Add(-time.Hour),
+				map[string]int64{"address": 7}).Exec()).To(BeNil())
+
+			certificate, err := addressDataService.PurgeApplication(ctx, tenantID, applicationID, "someone-else")
+
+			Expect(err).To(BeNil())
+			Expect(certificate.CertificateID).To(Equal(certificateID))
+			Expect(certificate.RequestedBy).To(Equal("privacy-officer"))
+			Expect(certificate.ErasedRows["address"]).To(Equal(int64(157)))
+			Expect(certificate.ErasedRows["address_history"]).To(Equal(int64(2)))
+		})
+	})
+})
+
+func TestErasureIntegration(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "PurgeTenant and PurgeApplication methods behaviour")
+}
@@ -0,0 +1,47 @@
+package service_test
+
+import (
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/micro-business/AddressService/data/service"
+	"github.com/micro-business/Micro-Business-Core/system"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("PurgeTenant and PurgeApplication methods input parameters and dependency test", func() {
+	var (
+		tenantID      system.UUID
+		applicationID system.UUID
+	)
+
+	BeforeEach(func() {
+		tenantID, _ = system.RandomUUID()
+		applicationID, _ = system.RandomUUID()
+	})
+
+	Context("when UUID generator service not provided", func() {
+		It("should panic", func() {
+			addressDataService := &service.AddressDataService{ClusterConfig: &gocql.ClusterConfig{}}
+
+			Ω(func() { addressDataService.PurgeTenant(context.Background(), tenantID, "") }).Should(Panic())
+			Ω(func() { addressDataService.PurgeApplication(context.Background(), tenantID, applicationID, "") }).Should(Panic())
+		})
+	})
+
+	Context("when cluster configuration not provided", func() {
+		It("should panic", func() {
+			addressDataService := &service.AddressDataService{UUIDGeneratorService: system.UUIDGeneratorServiceImpl{}}
+
+			Ω(func() { addressDataService.PurgeTenant(context.Background(), tenantID, "") }).Should(Panic())
+			Ω(func() { addressDataService.PurgeApplication(context.Background(), tenantID, applicationID, "") }).Should(Panic())
+		})
+	})
+})
+
+func TestErasure(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "PurgeTenant and PurgeApplication methods input parameters and dependency test")
+}
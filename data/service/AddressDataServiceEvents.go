@@ -0,0 +1,558 @@
+package service
+
+import (
+	"bytes"
+	"math/rand"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/micro-business/AddressService/data/contract"
+	"github.com/micro-business/AddressService/data/encryption"
+	"github.com/micro-business/Micro-Business-Core/common/diagnostics"
+	"github.com/micro-business/Micro-Business-Core/system"
+	"golang.org/x/net/context"
+)
+
+// addressEventOutboxShards is the number of shards address_event_outbox_by_hour table is split into, so the events are
+// not all written to the partitions of a single shard. All the events of an address are written to the same shard.
+const addressEventOutboxShards = 16
+
+// defaultEventSettleDelay is how long an event is left in the outbox before it is read when
+// AddressDataService.EventSettleDelay is not provided.
+const defaultEventSettleDelay = 10 * time.Second
+
+// addressEventOutboxCursor is a position in a shard of the outbox: the hour whose partition is read and the last event
+// of the hour read before the position, or the zero UUID if the position is at the start of the hour.
+type addressEventOutboxCursor struct {
+	shard       int
+	hour        time.Time
+	lastEventID gocql.UUID
+}
+
+// ReadEvents retrieves the events waiting in the outbox to be published, decrypting the address details values. Every
+// shard is read forward from its cursor, the position of the last published event recorded in
+// address_event_outbox_cursor table, one hourly partition after another. The shards are read in turn starting from a
+// random one, so a busy shard does not hold back the rest of them, and the events of every shard are returned in the
+// order they were written in. The events written less than EventSettleDelay ago are not read yet, so an event written
+// with a clock slightly behind or delayed by a slow write is not skipped. The events which cannot be decrypted are
+// moved to address_event_dead_letter table along with the reason instead of being returned, so they do not block the
+// rest of the events.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// limit: Mandatory. The maximum number of events to return.
+// Returns either the events or error if something goes wrong.
+func (addressDataService *AddressDataService) ReadEvents(ctx context.Context, limit int) ([]contract.AddressEvent, error) {
+	diagnostics.IsNotNil(addressDataService.ClusterConfig, "addressDataService.ClusterConfig", "ClusterConfig must be provided.")
+
+	settleDelay := addressDataService.EventSettleDelay
+
+	if settleDelay <= 0 {
+		settleDelay = defaultEventSettleDelay
+	}
+
+	settledBefore := time.Now().Add(-settleDelay)
+	events := []contract.AddressEvent{}
+
+	err := addressDataService.executeWithSession(func(session *gocql.Session) error {
+		firstShard := rand.Intn(addressEventOutboxShards)
+
+		for index := 0; index < addressEventOutboxShards && len(events) < limit; index++ {
+			shardEvents, err := readAddressEventOutboxShard(ctx, (firstShard+index)%addressEventOutboxShards, settledBefore, limit-len(events), session)
+
+			if err != nil {
+				return err
+			}
+
+			events = append(events, shardEvents...)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	decryptedEvents := make([]contract.AddressEvent, 0, len(events))
+	returnedShards := make(map[int]bool)
+
+	for _, event := range events {
+		var decryptedEvent contract.AddressEvent
+		var decryptionErr error
+
+		position := getAddressEventOutboxPosition(event)
+
+		err = addressDataService.executeWithKeyring(ctx, event.TenantID, func(session *gocql.Session, keyring *encryption.Keyring) error {
+			decryptedEvent = event
+			decryptionErr = decryptAddressEventDetails(keyring, &decryptedEvent)
+
+			return decryptionErr
+		})
+
+		if err == nil {
+			decryptedEvents = append(decryptedEvents, decryptedEvent)
+			returnedShards[position.shard] = true
+
+			continue
+		}
+
+		// Only the events which cannot be decrypted with the tenant's keyring are dead-lettered, failing to read the keyring
+		// fails the whole read so the events are read again once the keyring can be read.
+		if err != decryptionErr {
+			return nil, err
+		}
+
+		err = addressDataService.executeWithSession(func(session *gocql.Session) error {
+			if err := addToAddressEventDeadLetterTable(ctx, event, decryptionErr.Error(), session); err != nil {
+				return err
+			}
+
+			// The cursor is moved past the dead letter only if no event read before it from the shard is returned, as the
+			// returned events are not published yet. Otherwise it is moved past once they are removed.
+			if returnedShards[position.shard] {
+				return nil
+			}
+
+			return advanceAddressEventOutboxCursor(ctx, position, session)
+		})
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return decryptedEvents, nil
+}
+
+// RemoveEvents removes the published events from the outbox by moving the cursor of every shard past the latest of the
+// provided events of the shard, so all the events ReadEvents returned must be published before they are removed. The
+// partitions of the hours the cursors are moved past are deleted. A cursor already moved further, e.g. by the relay
+// of another instance, is left as it is.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// events: Mandatory. The events returned by ReadEvents, all of which are published.
+// Returns error if something goes wrong.
+func (addressDataService *AddressDataService) RemoveEvents(ctx context.Context, events []contract.AddressEvent) error {
+	diagnostics.IsNotNil(addressDataService.ClusterConfig, "addressDataService.ClusterConfig", "ClusterConfig must be provided.")
+
+	positions := make(map[int]addressEventOutboxCursor)
+
+	for _, event := range events {
+		position := getAddressEventOutboxPosition(event)
+
+		if latestPosition, ok := positions[position.shard]; !ok || isAddressEventOutboxCursorAfter(position, latestPosition) {
+			positions[position.shard] = position
+		}
+	}
+
+	return addressDataService.executeWithSession(func(session *gocql.Session) error {
+		for _, position := range positions {
+			if err := advanceAddressEventOutboxCursor(ctx, position, session); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// addToAddressEventOutboxTable adds the statement inserting an event recording the change of an address to
+// address_event_outbox_by_hour table to the provided batch. The address details values must be already encrypted. The
+// event unique identifier is a time based UUID, so the events of a shard are ordered by the time they were written at,
+// and the event is written to the partition of the hour of its unique identifier.
+func addToAddressEventOutboxTable(
+	batch *gocql.Batch,
+	eventType contract.AddressEventType,
+	tenantID, applicationID, addressID gocql.UUID,
+	version int64,
+	oldAddressDetails, newAddressDetails map[string]string,
+	changedBy string) {
+	eventID := gocql.TimeUUID()
+
+	batch.Query(
+		"INSERT INTO address_event_outbox_by_hour"+
+			" (shard, event_hour, event_id, event_type, tenant_id, application_id, address_id, version,"+
+			" old_address_details, new_address_details, changed_by)"+
+			" VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		getAddressEventOutboxShard(addressID),
+		eventID.Time().Truncate(time.Hour),
+		eventID,
+		string(eventType),
+		tenantID,
+		applicationID,
+		addressID,
+		version,
+		oldAddressDetails,
+		newAddressDetails,
+		changedBy)
+}
+
+// addToAddressEventDeadLetterTable writes an event which cannot be published to address_event_dead_letter table,
+// keeping its address details values encrypted. The event is left in the outbox until the cursor of its shard is moved
+// past it, so writing it again when it is read again is harmless.
+func addToAddressEventDeadLetterTable(ctx context.Context, event contract.AddressEvent, reason string, session *gocql.Session) error {
+	return session.Query(
+		"INSERT INTO address_event_dead_letter"+
+			" (tenant_id, application_id, event_id, event_type, address_id, version, old_address_details,"+
+			" new_address_details, changed_by, reason)"+
+			" VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		mapSystemUUIDToGocqlUUID(event.TenantID),
+		mapSystemUUIDToGocqlUUID(event.ApplicationID),
+		mapSystemUUIDToGocqlUUID(event.EventID),
+		string(event.EventType),
+		mapSystemUUIDToGocqlUUID(event.AddressID),
+		event.Version,
+		event.OldAddressDetails,
+		event.NewAddressDetails,
+		event.ChangedBy,
+		reason).WithContext(ctx).Exec()
+}
+
+// readAddressEventOutboxShard reads the events of a shard written before settledBefore forward from the cursor of the
+// shard, one hourly partition after another until limit events are read or the hour of settledBefore is read. If the
+// partitions the cursor is moved past hold no event, the cursor is moved to the first partition holding an event, or
+// to the hour of settledBefore.
+// Returns either the events in the order they were written in or error if something goes wrong.
+func readAddressEventOutboxShard(ctx context.Context, shard int, settledBefore time.Time, limit int, session *gocql.Session) ([]contract.AddressEvent, error) {
+	cursor, err := readAddressEventOutboxCursor(ctx, shard, settledBefore, session)
+
+	if err != nil {
+		return nil, err
+	}
+
+	settledHour := settledBefore.Truncate(time.Hour)
+	position := cursor
+	emptyUntil := cursor
+	events := []contract.AddressEvent{}
+
+	for {
+		hourEvents, err := readAddressEventOutboxPartition(ctx, position, settledBefore, limit-len(events), session)
+
+		if err != nil {
+			return nil, err
+		}
+
+		events = append(events, hourEvents...)
+
+		if len(events) >= limit || !position.hour.Before(settledHour) {
+			break
+		}
+
+		position = addressEventOutboxCursor{shard: shard, hour: position.hour.Add(time.Hour)}
+
+		if len(events) == 0 {
+			emptyUntil = position
+		}
+	}
+
+	if emptyUntil.hour.Equal(cursor.hour) {
+		return events, nil
+	}
+
+	return events, advanceAddressEventOutboxCursor(ctx, emptyUntil, session)
+}
+
+// readAddressEventOutboxPartition reads the events of the hourly partition of the provided position written after the
+// position and before settledBefore.
+// Returns either the events in the order they were written in or error if something goes wrong.
+func readAddressEventOutboxPartition(
+	ctx context.Context,
+	position addressEventOutboxCursor,
+	settledBefore time.Time,
+	limit int,
+	session *gocql.Session) ([]contract.AddressEvent, error) {
+	afterCondition := " AND event_id >= minTimeuuid(?)"
+	var after interface{} = position.hour
+
+	if position.lastEventID != (gocql.UUID{}) {
+		afterCondition = " AND event_id > ?"
+		after = position.lastEventID
+	}
+
+	iter := session.Query(
+		"SELECT event_id, event_type, tenant_id, application_id, address_id, version, old_address_details,"+
+			" new_address_details, changed_by"+
+			" FROM address_event_outbox_by_hour"+
+			" WHERE"+
+			" shard = ?"+
+			" AND event_hour = ?"+
+			afterCondition+
+			" AND event_id < maxTimeuuid(?)"+
+			" LIMIT ?",
+		position.shard,
+		position.hour,
+		after,
+		settledBefore,
+		limit).WithContext(ctx).Iter()
+
+	var eventID, tenantID, applicationID, addressID gocql.UUID
+	var eventType, changedBy string
+	var version int64
+	var oldAddressDetails, newAddressDetails map[string]string
+
+	events := []contract.AddressEvent{}
+
+	for iter.Scan(&eventID, &eventType, &tenantID, &applicationID, &addressID, &version, &oldAddressDetails, &newAddressDetails, &changedBy) {
+		events = append(events, contract.AddressEvent{
+			EventID:           mapGocqlUUIDToSystemUUID(eventID),
+			EventType:         contract.AddressEventType(eventType),
+			TenantID:          mapGocqlUUIDToSystemUUID(tenantID),
+			ApplicationID:     mapGocqlUUIDToSystemUUID(applicationID),
+			AddressID:         mapGocqlUUIDToSystemUUID(addressID),
+			Version:           version,
+			OldAddressDetails: oldAddressDetails,
+			NewAddressDetails: newAddressDetails,
+			ChangedAt:         eventID.Time(),
+			ChangedBy:         changedBy,
+		})
+
+		oldAddressDetails = nil
+		newAddressDetails = nil
+	}
+
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// readAddressEventOutboxCursor reads the cursor of a shard. The cursors of all the shards are written by the migration
+// creating the outbox, so a missing cursor is only recovered from by recording it at the hour of the provided time,
+// unless another instance records it first.
+// Returns either the cursor or error if something goes wrong.
+func readAddressEventOutboxCursor(ctx context.Context, shard int, now time.Time, session *gocql.Session) (addressEventOutboxCursor, error) {
+	cursor := addressEventOutboxCursor{shard: shard}
+
+	err := session.Query(
+		"SELECT event_hour, last_event_id"+
+			" FROM address_event_outbox_cursor"+
+			" WHERE shard = ?",
+		shard).WithContext(ctx).Scan(&cursor.hour, &cursor.lastEventID)
+
+	if err != gocql.ErrNotFound {
+		return cursor, err
+	}
+
+	existingCursor := make(map[string]interface{})
+
+	applied, err := session.Query(
+		"INSERT INTO address_event_outbox_cursor"+
+			" (shard, event_hour)"+
+			" VALUES(?, ?)"+
+			" IF NOT EXISTS",
+		shard,
+		now.Truncate(time.Hour)).WithContext(ctx).MapScanCAS(existingCursor)
+
+	if err != nil {
+		return cursor, err
+	}
+
+	if applied {
+		cursor.hour = now.Truncate(time.Hour)
+
+		return cursor, nil
+	}
+
+	cursor.hour, _ = existingCursor["event_hour"].(time.Time)
+	cursor.lastEventID, _ = existingCursor["last_event_id"].(gocql.UUID)
+
+	return cursor, nil
+}
+
+// advanceAddressEventOutboxCursor moves the cursor of the shard of the provided position to the position unless it is
+// already at or after it, and deletes the partitions of the hours the cursor is moved past. The cursor is only written
+// by lightweight transactions conditional on its current position, so the cursor moved by another instance at the same
+// time is read again.
+func advanceAddressEventOutboxCursor(ctx context.Context, position addressEventOutboxCursor, session *gocql.Session) error {
+	for {
+		cursor, err := readAddressEventOutboxCursor(ctx, position.shard, time.Now(), session)
+
+		if err != nil {
+			return err
+		}
+
+		if !isAddressEventOutboxCursorAfter(position, cursor) {
+			return nil
+		}
+
+		applied, err := session.Query(
+			"UPDATE address_event_outbox_cursor"+
+				" SET event_hour = ?, last_event_id = ?"+
+				" WHERE shard = ?"+
+				" IF event_hour = ?"+
+				" AND last_event_id = ?",
+			position.hour,
+			mapTimeUUIDToNullable(position.lastEventID),
+			position.shard,
+			cursor.hour,
+			mapTimeUUIDToNullable(cursor.lastEventID)).WithContext(ctx).MapScanCAS(make(map[string]interface{}))
+
+		if err != nil {
+			return err
+		}
+
+		if !applied {
+			continue
+		}
+
+		for hour := cursor.hour; hour.Before(position.hour); hour = hour.Add(time.Hour) {
+			err = session.Query(
+				"DELETE FROM address_event_outbox_by_hour"+
+					" WHERE"+
+					" shard = ?"+
+					" AND event_hour = ?",
+				position.shard,
+				hour).WithContext(ctx).Exec()
+
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+// isAddressEventOutboxCursorAfter returns true if the first position is after the second one in the same shard.
+func isAddressEventOutboxCursorAfter(first, second addressEventOutboxCursor) bool {
+	if !first.hour.Equal(second.hour) {
+		return first.hour.After(second.hour)
+	}
+
+	if first.lastEventID == second.lastEventID || first.lastEventID == (gocql.UUID{}) {
+		return false
+	}
+
+	if second.lastEventID == (gocql.UUID{}) {
+		return true
+	}
+
+	if firstTime, secondTime := first.lastEventID.Time(), second.lastEventID.Time(); !firstTime.Equal(secondTime) {
+		return firstTime.After(secondTime)
+	}
+
+	return bytes.Compare(first.lastEventID[:], second.lastEventID[:]) > 0
+}
+
+// getAddressEventOutboxPosition returns the position in the outbox right after the provided event.
+func getAddressEventOutboxPosition(event contract.AddressEvent) addressEventOutboxCursor {
+	eventID := mapSystemUUIDToGocqlUUID(event.EventID)
+
+	return addressEventOutboxCursor{
+		shard:       getAddressEventOutboxShard(mapSystemUUIDToGocqlUUID(event.AddressID)),
+		hour:        eventID.Time().Truncate(time.Hour),
+		lastEventID: eventID,
+	}
+}
+
+// mapTimeUUIDToNullable returns the provided time based UUID, or nil if it is the zero UUID, so it is written as null.
+func mapTimeUUIDToNullable(id gocql.UUID) interface{} {
+	if id == (gocql.UUID{}) {
+		return nil
+	}
+
+	return id
+}
+
+// getAddressEventOutboxShard returns the address_event_outbox partition the events of an address are written to.
+func getAddressEventOutboxShard(addressID gocql.UUID) int {
+	return int(addressID[len(addressID)-1]) % addressEventOutboxShards
+}
+
+// decryptAddressEventDetails decrypts the old and new address details values of an event, keeping the nil ones nil.
+func decryptAddressEventDetails(keyring *encryption.Keyring, event *contract.AddressEvent) error {
+	var err error
+
+	if event.OldAddressDetails != nil {
+		if event.OldAddressDetails, err = decryptAddressDetails(keyring, event.OldAddressDetails); err != nil {
+			return err
+		}
+	}
+
+	if event.NewAddressDetails != nil {
+		if event.NewAddressDetails, err = decryptAddressDetails(keyring, event.NewAddressDetails); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// eraseAddressEvents deletes the events of the tenant or the application waiting in the outbox and records their number
+// in the erasure certificate. The outbox is not partitioned by tenant, so the partitions of every shard from the hour of
+// its cursor to the current hour are read, which stays cheap as long as the event relay keeps the outbox drained. The
+// partitions of the earlier hours are deleted once the cursors are moved past them.
+func eraseAddressEvents(ctx context.Context, progress *erasureProgress, session *gocql.Session) error {
+	mappedTenantID := mapSystemUUIDToGocqlUUID(progress.certificate.TenantID)
+	mappedApplicationID := mapSystemUUIDToGocqlUUID(progress.certificate.ApplicationID)
+	eraseApplicationOnly := progress.certificate.ApplicationID != system.EmptyUUID
+	now := time.Now()
+
+	for shard := 0; shard < addressEventOutboxShards; shard++ {
+		cursor, err := readAddressEventOutboxCursor(ctx, shard, now, session)
+
+		if err != nil {
+			return err
+		}
+
+		for hour := cursor.hour; !hour.After(now.Truncate(time.Hour)); hour = hour.Add(time.Hour) {
+			iter := session.Query(
+				"SELECT event_id, tenant_id, application_id"+
+					" FROM address_event_outbox_by_hour"+
+					" WHERE"+
+					" shard = ?"+
+					" AND event_hour = ?",
+				shard,
+				hour).PageSize(erasureChunkSize).WithContext(ctx).Iter()
+
+			var eventID, tenantID, applicationID gocql.UUID
+
+			eventIDs := []gocql.UUID{}
+
+			for iter.Scan(&eventID, &tenantID, &applicationID) {
+				if tenantID == mappedTenantID && (!eraseApplicationOnly || applicationID == mappedApplicationID) {
+					eventIDs = append(eventIDs, eventID)
+				}
+			}
+
+			if err := iter.Close(); err != nil {
+				return err
+			}
+
+			for start := 0; start < len(eventIDs); start += erasureChunkSize {
+				end := start + erasureChunkSize
+
+				if end > len(eventIDs) {
+					end = len(eventIDs)
+				}
+
+				batch := session.NewBatch(gocql.UnloggedBatch)
+
+				for _, id := range eventIDs[start:end] {
+					batch.Query(
+						"DELETE FROM address_event_outbox_by_hour WHERE shard = ? AND event_hour = ? AND event_id = ?",
+						shard,
+						hour,
+						id)
+				}
+
+				if err := session.ExecuteBatch(batch.WithContext(ctx)); err != nil {
+					return err
+				}
+			}
+
+			progress.certificate.ErasedRows["address_event_outbox"] += int64(len(eventIDs))
+		}
+	}
+
+	return session.Query(
+		"UPDATE erasure_certificate"+
+			" SET erased_rows = erased_rows + ?"+
+			" WHERE"+
+			" tenant_id = ?"+
+			" AND application_id = ?"+
+			" AND certificate_id = ?",
+		map[string]int64{"address_event_outbox": progress.certificate.ErasedRows["address_event_outbox"]},
+		mappedTenantID,
+		mappedApplicationID,
+		mapSystemUUIDToGocqlUUID(progress.certificate.CertificateID)).WithContext(ctx).Exec()
+}
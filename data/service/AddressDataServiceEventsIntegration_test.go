@@ -0,0 +1,188 @@
+// +build integration
+
+package service_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/golang/mock/gomock"
+	"github.com/micro-business/AddressService/data/contract"
+	"github.com/micro-business/AddressService/data/service"
+	"github.com/micro-business/Micro-Business-Core/system"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("ReadEvents and RemoveEvents methods behaviour", func() {
+	var (
+		ctx                      context.Context
+		mockCtrl                 *gomock.Controller
+		addressDataService       *service.AddressDataService
+		mockUUIDGeneratorService *MockUUIDGeneratorService
+		tenantID                 system.UUID
+		applicationID            system.UUID
+		addressID                system.UUID
+	)
+
+	// readTenantEvents returns the events of the tenant, as the outbox is shared by all the tenants created by the tests.
+	readTenantEvents := func() []contract.AddressEvent {
+		events, err := addressDataService.ReadEvents(ctx, 1000)
+
+		Expect(err).To(BeNil())
+
+		tenantEvents := []contract.AddressEvent{}
+
+		for _, event := range events {
+			if event.TenantID == tenantID {
+				tenantEvents = append(tenantEvents, event)
+			}
+		}
+
+		return tenantEvents
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		clusterConfig := getClusterConfig()
+		clusterConfig.Keyspace = keyspace
+
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockUUIDGeneratorService = NewMockUUIDGeneratorService(mockCtrl)
+
+		addressDataService = &service.AddressDataService{
+			UUIDGeneratorService: mockUUIDGeneratorService,
+			ClusterConfig:        clusterConfig,
+			RecordEvents:         true,
+			EventSettleDelay:     time.Millisecond,
+		}
+
+		tenantID, _ = system.RandomUUID()
+		applicationID, _ = system.RandomUUID()
+		addressID, _ = system.RandomUUID()
+
+		mockUUIDGeneratorService.
+			EXPECT().
+			GenerateRandomUUID().
+			Return(addressID, nil)
+
+		_, err := addressDataService.Create(ctx, tenantID, applicationID, contract.Address{AddressDetails: map[string]string{"City": "Christchurch"}}, "crm")
+
+		Expect(err).To(BeNil())
+		Expect(addressDataService.Update(ctx, tenantID, applicationID, addressID, contract.Address{AddressDetails: map[string]string{"City": "Wellington"}}, 1, "crm")).To(BeNil())
+		Expect(addressDataService.Delete(ctx, tenantID, applicationID, addressID, 2, "")).To(BeNil())
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+		addressDataService.Close()
+	})
+
+	Context("when the address is created, updated and deleted", func() {
+		It("should write an event along with every change in the order of the changes", func() {
+			events := readTenantEvents()
+
+			Expect(events).To(HaveLen(3))
+
+			Expect(events[0].EventType).To(Equal(contract.AddressCreated))
+			Expect(events[0].AddressID).To(Equal(addressID))
+			Expect(events[0].Version).To(Equal(int64(1)))
+			Expect(events[0].OldAddressDetails).To(BeNil())
+			Expect(events[0].NewAddressDetails).To(Equal(map[string]string{"City": "Christchurch"}))
+			Expect(events[0].ChangedBy).To(Equal("crm"))
+
+			Expect(events[1].EventType).To(Equal(contract.AddressUpdated))
+			Expect(events[1].Version).To(Equal(int64(2)))
+			Expect(events[1].OldAddressDetails).To(Equal(map[string]string{"City": "Christchurch"}))
+			Expect(events[1].NewAddressDetails).To(Equal(map[string]string{"City": "Wellington"}))
+
+			Expect(events[2].EventType).To(Equal(contract.AddressDeleted))
+			Expect(events[2].Version).To(Equal(int64(3)))
+			Expect(events[2].OldAddressDetails).To(Equal(map[string]string{"City": "Wellington"}))
+			Expect(events[2].NewAddressDetails).To(BeNil())
+			Expect(events[2].ChangedAt.IsZero()).To(BeFalse())
+		})
+
+		It("should not return the removed events again", func() {
+			Expect(addressDataService.RemoveEvents(ctx, readTenantEvents())).To(BeNil())
+			Expect(readTenantEvents()).To(BeEmpty())
+		})
+
+		It("should return the events written after the removed ones", func() {
+			Expect(addressDataService.RemoveEvents(ctx, readTenantEvents()[:2])).To(BeNil())
+
+			events := readTenantEvents()
+
+			Expect(events).To(HaveLen(1))
+			Expect(events[0].EventType).To(Equal(contract.AddressDeleted))
+		})
+
+		It("should not return the events written less than the settle delay ago", func() {
+			addressDataService.EventSettleDelay = time.Hour
+
+			Expect(readTenantEvents()).To(BeEmpty())
+		})
+
+		It("should remove the events of the erased tenant", func() {
+			certificateID, _ := system.RandomUUID()
+			mockUUIDGeneratorService.
+				EXPECT().
+				GenerateRandomUUID().
+				Return(certificateID, nil)
+
+			certificate, err := addressDataService.PurgeTenant(ctx, tenantID, "")
+
+			Expect(err).To(BeNil())
+			Expect(certificate.ErasedRows["address_event_outbox"]).To(Equal(int64(3)))
+			Expect(readTenantEvents()).To(BeEmpty())
+		})
+	})
+
+	Context("when an event cannot be decrypted", func() {
+		It("should move the event to the dead letter table and return the rest of the events", func() {
+			clusterConfig := getClusterConfig()
+			clusterConfig.Keyspace = keyspace
+			session, err := clusterConfig.CreateSession()
+
+			Expect(err).To(BeNil())
+
+			defer session.Close()
+
+			mappedAddressID := mapSystemUUIDToGocqlUUID(addressID)
+			eventID := gocql.TimeUUID()
+
+			Expect(session.Query(
+				"INSERT INTO address_event_outbox_by_hour"+
+					" (shard, event_hour, event_id, event_type, tenant_id, application_id, address_id, version, new_address_details)"+
+					" VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?)",
+				int(mappedAddressID[len(mappedAddressID)-1])%16,
+				eventID.Time().Truncate(time.Hour),
+				eventID,
+				string(contract.AddressCreated),
+				mapSystemUUIDToGocqlUUID(tenantID),
+				mapSystemUUIDToGocqlUUID(applicationID),
+				mappedAddressID,
+				4,
+				map[string]string{"City": "enc:1:AAAA"}).Exec()).To(BeNil())
+
+			Expect(readTenantEvents()).To(HaveLen(3))
+
+			var reason string
+
+			Expect(session.Query(
+				"SELECT reason FROM address_event_dead_letter WHERE tenant_id = ? AND application_id = ? AND event_id = ?",
+				mapSystemUUIDToGocqlUUID(tenantID),
+				mapSystemUUIDToGocqlUUID(applicationID),
+				eventID).Scan(&reason)).To(BeNil())
+			Expect(reason).NotTo(BeEmpty())
+			Expect(readTenantEvents()).To(HaveLen(3))
+		})
+	})
+})
+
+func TestEventsIntegration(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "ReadEvents and RemoveEvents methods behaviour")
+}
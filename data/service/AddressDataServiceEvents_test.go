@@ -0,0 +1,27 @@
+package service_test
+
+import (
+	"testing"
+
+	"github.com/micro-business/AddressService/data/contract"
+	"github.com/micro-business/AddressService/data/service"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("ReadEvents and RemoveEvents methods input parameters and dependency test", func() {
+	Context("when cluster configuration not provided", func() {
+		It("should panic", func() {
+			addressDataService := &service.AddressDataService{}
+
+			Ω(func() { addressDataService.ReadEvents(context.Background(), 10) }).Should(Panic())
+			Ω(func() { addressDataService.RemoveEvents(context.Background(), []contract.AddressEvent{}) }).Should(Panic())
+		})
+	})
+})
+
+func TestEvents(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "ReadEvents and RemoveEvents methods input parameters and dependency test")
+}
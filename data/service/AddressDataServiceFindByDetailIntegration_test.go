@@ -0,0 +1,106 @@
+// +build integration
+
+package service_test
+
+import (
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/golang/mock/gomock"
+	"github.com/micro-business/AddressService/data/contract"
+	"github.com/micro-business/AddressService/data/service"
+	"github.com/micro-business/Micro-Business-Core/system"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("FindByDetail method behaviour", func() {
+	var (
+		ctx                      context.Context
+		mockCtrl                 *gomock.Controller
+		addressDataService       *service.AddressDataService
+		mockUUIDGeneratorService *MockUUIDGeneratorService
+		tenantID                 system.UUID
+		applicationID            system.UUID
+		matchingAddressID        system.UUID
+		clusterConfig            *gocql.ClusterConfig
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		clusterConfig = getClusterConfig()
+		clusterConfig.Keyspace = keyspace
+
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockUUIDGeneratorService = NewMockUUIDGeneratorService(mockCtrl)
+
+		addressDataService = &service.AddressDataService{UUIDGeneratorService: mockUUIDGeneratorService, ClusterConfig: clusterConfig}
+
+		tenantID, _ = system.RandomUUID()
+		applicationID, _ = system.RandomUUID()
+
+		for _, addressDetails := range []map[string]string{
+			{"City": "Wellington", "Postcode": "6011"},
+			{"City": "Wellington", "Postcode": "6012"},
+			{"City": "Christchurch", "Postcode": "6011"},
+		} {
+			matchingAddressID, _ = system.RandomUUID()
+
+			mockUUIDGeneratorService.
+				EXPECT().
+				GenerateRandomUUID().
+				Return(matchingAddressID, nil)
+
+			_, err := addressDataService.Create(ctx, tenantID, applicationID, contract.Address{AddressDetails: addressDetails}, "")
+
+			Expect(err).To(BeNil())
+		}
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+		addressDataService.Close()
+	})
+
+	Context("when finding addresses by detail", func() {
+		It("should return the addresses having the provided detail", func() {
+			listedAddresses, err := addressDataService.FindByDetail(ctx, tenantID, applicationID, map[string]string{"Postcode": "6011"})
+
+			Expect(err).To(BeNil())
+			Expect(listedAddresses).To(HaveLen(2))
+		})
+
+		It("should return only the addresses matching all the provided details", func() {
+			listedAddresses, err := addressDataService.FindByDetail(ctx, tenantID, applicationID, map[string]string{"City": "Christchurch", "Postcode": "6011"})
+
+			Expect(err).To(BeNil())
+			Expect(listedAddresses).To(Equal([]contract.ListedAddress{
+				{AddressID: matchingAddressID, Address: contract.Address{AddressDetails: map[string]string{"City": "Christchurch", "Postcode": "6011"}, Version: 1}},
+			}))
+		})
+
+		It("should not return the deleted addresses", func() {
+			Expect(addressDataService.Delete(ctx, tenantID, applicationID, matchingAddressID, contract.AnyVersion, "")).To(BeNil())
+
+			listedAddresses, err := addressDataService.FindByDetail(ctx, tenantID, applicationID, map[string]string{"City": "Christchurch"})
+
+			Expect(err).To(BeNil())
+			Expect(listedAddresses).To(BeEmpty())
+		})
+
+		It("should not return the addresses of other applications", func() {
+			otherApplicationID, _ := system.RandomUUID()
+
+			listedAddresses, err := addressDataService.FindByDetail(ctx, tenantID, otherApplicationID, map[string]string{"Postcode": "6011"})
+
+			Expect(err).To(BeNil())
+			Expect(listedAddresses).To(BeEmpty())
+		})
+	})
+})
+
+func TestFindByDetailBehaviour(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "FindByDetail method behaviour")
+}
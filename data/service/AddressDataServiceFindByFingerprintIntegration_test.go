@@ -0,0 +1,125 @@
+// +build integration
+
+package service_test
+
+import (
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/golang/mock/gomock"
+	"github.com/micro-business/AddressService/data/contract"
+	"github.com/micro-business/AddressService/data/service"
+	"github.com/micro-business/Micro-Business-Core/system"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("FindByFingerprint method behaviour", func() {
+	var (
+		ctx                      context.Context
+		mockCtrl                 *gomock.Controller
+		addressDataService       *service.AddressDataService
+		mockUUIDGeneratorService *MockUUIDGeneratorService
+		tenantID                 system.UUID
+		applicationID            system.UUID
+		matchingAddressID        system.UUID
+		clusterConfig            *gocql.ClusterConfig
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		clusterConfig = getClusterConfig()
+		clusterConfig.Keyspace = keyspace
+
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockUUIDGeneratorService = NewMockUUIDGeneratorService(mockCtrl)
+
+		addressDataService = &service.AddressDataService{UUIDGeneratorService: mockUUIDGeneratorService, ClusterConfig: clusterConfig}
+
+		tenantID, _ = system.RandomUUID()
+		applicationID, _ = system.RandomUUID()
+
+		for _, address := range []contract.Address{
+			{AddressDetails: map[string]string{"City": "Wellington"}, Fingerprint: "wellington"},
+			{AddressDetails: map[string]string{"City": "Auckland"}},
+			{AddressDetails: map[string]string{"City": "Christchurch"}, Fingerprint: "christchurch"},
+		} {
+			matchingAddressID, _ = system.RandomUUID()
+
+			mockUUIDGeneratorService.
+				EXPECT().
+				GenerateRandomUUID().
+				Return(matchingAddressID, nil)
+
+			_, err := addressDataService.Create(ctx, tenantID, applicationID, address, "")
+
+			Expect(err).To(BeNil())
+		}
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+		addressDataService.Close()
+	})
+
+	Context("when finding addresses by fingerprint", func() {
+		It("should return the addresses created with the provided fingerprint", func() {
+			listedAddresses, err := addressDataService.FindByFingerprint(ctx, tenantID, applicationID, "christchurch")
+
+			Expect(err).To(BeNil())
+			Expect(listedAddresses).To(Equal([]contract.ListedAddress{
+				{AddressID: matchingAddressID, Address: contract.Address{AddressDetails: map[string]string{"City": "Christchurch"}, Version: 1}},
+			}))
+		})
+
+		It("should not return the deleted addresses", func() {
+			Expect(addressDataService.Delete(ctx, tenantID, applicationID, matchingAddressID, contract.AnyVersion, "")).To(BeNil())
+
+			listedAddresses, err := addressDataService.FindByFingerprint(ctx, tenantID, applicationID, "christchurch")
+
+			Expect(err).To(BeNil())
+			Expect(listedAddresses).To(BeEmpty())
+		})
+
+		It("should return the addresses by the fingerprint they were last updated with", func() {
+			updatedAddress := contract.Address{AddressDetails: map[string]string{"City": "Nelson"}, Fingerprint: "nelson"}
+
+			Expect(addressDataService.Update(ctx, tenantID, applicationID, matchingAddressID, updatedAddress, contract.AnyVersion, "")).To(BeNil())
+
+			listedAddresses, err := addressDataService.FindByFingerprint(ctx, tenantID, applicationID, "christchurch")
+
+			Expect(err).To(BeNil())
+			Expect(listedAddresses).To(BeEmpty())
+
+			listedAddresses, err = addressDataService.FindByFingerprint(ctx, tenantID, applicationID, "nelson")
+
+			Expect(err).To(BeNil())
+			Expect(listedAddresses).To(HaveLen(1))
+		})
+
+		It("should keep the fingerprint when the address is patched", func() {
+			Expect(addressDataService.Patch(ctx, tenantID, applicationID, matchingAddressID, map[string]string{"City": "Nelson"}, nil, contract.AnyVersion, "")).To(BeNil())
+
+			listedAddresses, err := addressDataService.FindByFingerprint(ctx, tenantID, applicationID, "christchurch")
+
+			Expect(err).To(BeNil())
+			Expect(listedAddresses).To(HaveLen(1))
+		})
+
+		It("should not return the purged addresses", func() {
+			Expect(addressDataService.Delete(ctx, tenantID, applicationID, matchingAddressID, contract.AnyVersion, "")).To(BeNil())
+			Expect(addressDataService.Purge(ctx, tenantID, applicationID, matchingAddressID)).To(BeNil())
+
+			listedAddresses, err := addressDataService.FindByFingerprint(ctx, tenantID, applicationID, "christchurch")
+
+			Expect(err).To(BeNil())
+			Expect(listedAddresses).To(BeEmpty())
+		})
+	})
+})
+
+func TestFindByFingerprintBehaviour(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "FindByFingerprint method behaviour")
+}
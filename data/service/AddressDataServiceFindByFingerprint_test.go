@@ -0,0 +1,43 @@
+package service_test
+
+import (
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/micro-business/AddressService/data/service"
+	"github.com/micro-business/Micro-Business-Core/system"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("FindByFingerprint method input parameters and dependency test", func() {
+	var (
+		ctx                context.Context
+		addressDataService *service.AddressDataService
+		tenantID           system.UUID
+		applicationID      system.UUID
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		addressDataService = &service.AddressDataService{ClusterConfig: &gocql.ClusterConfig{}}
+		tenantID, _ = system.RandomUUID()
+		applicationID, _ = system.RandomUUID()
+	})
+
+	Context("when cluster configuration not provided", func() {
+		It("should panic", func() {
+			addressDataService.ClusterConfig = nil
+
+			Ω(func() {
+				addressDataService.FindByFingerprint(ctx, tenantID, applicationID, "christchurch")
+			}).Should(Panic())
+		})
+	})
+})
+
+func TestFindByFingerprint(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "FindByFingerprint method input parameters and dependency test")
+}
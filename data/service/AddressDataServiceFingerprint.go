@@ -0,0 +1,228 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/gocql/gocql"
+	"github.com/micro-business/AddressService/data/contract"
+	"github.com/micro-business/AddressService/data/encryption"
+	"github.com/micro-business/Micro-Business-Core/common/diagnostics"
+	"github.com/micro-business/Micro-Business-Core/system"
+	"golang.org/x/net/context"
+)
+
+// addressFingerprintHashKey is the address key the fingerprints are hashed with. It is not a valid address key, so a
+// fingerprint never has the same hash as an address detail value.
+const addressFingerprintHashKey = "#fingerprint"
+
+// FindByFingerprint retrieves the addresses owned by a tenant's application which were created or last updated with the
+// provided fingerprint. The deleted addresses are not returned. The fingerprint is hashed with every data key version of
+// the tenant, the candidate addresses are read from address_indexed_by_fingerprint table by the hashes, and the ones
+// whose fingerprint hash stored in address_metadata table has changed since are skipped.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the addresses.
+// applicationID: Mandatory. The unique identifier of the tenant's application owning the addresses.
+// fingerprint: Mandatory. The fingerprint the returned addresses were created or last updated with.
+// Returns either the matching addresses or error if something goes wrong.
+func (addressDataService *AddressDataService) FindByFingerprint(
+	ctx context.Context,
+	tenantID, applicationID system.UUID,
+	fingerprint string) ([]contract.ListedAddress, error) {
+	diagnostics.IsNotNil(addressDataService.ClusterConfig, "addressDataService.ClusterConfig", "ClusterConfig must be provided.")
+
+	listedAddresses := []contract.ListedAddress{}
+
+	err := addressDataService.executeWithKeyring(ctx, tenantID, func(session *gocql.Session, keyring *encryption.Keyring) error {
+		hashes, err := hashAddressFingerprintWithAllDataKeys(keyring, fingerprint)
+
+		if err != nil {
+			return err
+		}
+
+		iter := session.Query(
+			"SELECT address_id"+
+				" FROM address_indexed_by_fingerprint"+
+				" WHERE"+
+				" tenant_id = ?"+
+				" AND application_id = ?"+
+				" AND fingerprint IN ?",
+			mapSystemUUIDToGocqlUUID(tenantID),
+			mapSystemUUIDToGocqlUUID(applicationID),
+			hashes).WithContext(ctx).Iter()
+
+		var addressID gocql.UUID
+
+		candidateAddressIDs := []system.UUID{}
+
+		for iter.Scan(&addressID) {
+			candidateAddressIDs = append(candidateAddressIDs, mapGocqlUUIDToSystemUUID(addressID))
+		}
+
+		if err := iter.Close(); err != nil {
+			return err
+		}
+
+		for _, candidateAddressID := range candidateAddressIDs {
+			currentFingerprint, err := readAddressFingerprint(ctx, tenantID, applicationID, candidateAddressID, session)
+
+			if err != nil {
+				return err
+			}
+
+			if !containsString(hashes, currentFingerprint) {
+				continue
+			}
+
+			address, err := readAllAddressDetails(ctx, tenantID, applicationID, candidateAddressID, keyring, session)
+
+			if _, ok := err.(contract.NotFoundError); ok {
+				continue
+			}
+
+			if err != nil {
+				return err
+			}
+
+			listedAddresses = append(listedAddresses, contract.ListedAddress{AddressID: candidateAddressID, Address: address})
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return listedAddresses, nil
+}
+
+// hashAddressFingerprint returns the hash of the provided fingerprint stored along with the address, or empty if the
+// fingerprint is empty. The fingerprint is hashed with the tenant's current data key like the address detail values, so
+// the normalized details cannot be recovered from it by hashing the likely addresses. The address values are stored in
+// plaintext when no keyring is provided, so the fingerprint is hashed with SHA-256 instead.
+func hashAddressFingerprint(keyring *encryption.Keyring, fingerprint string) (string, error) {
+	if fingerprint == "" {
+		return "", nil
+	}
+
+	if keyring == nil {
+		hash := sha256.Sum256([]byte(fingerprint))
+
+		return hex.EncodeToString(hash[:]), nil
+	}
+
+	return keyring.Hash(addressFingerprintHashKey, fingerprint)
+}
+
+// hashAddressFingerprintWithAllDataKeys returns the hashes the provided fingerprint may be stored with, one for every
+// data key version of the tenant, so the addresses fingerprinted before the current data key version was added are found
+// too.
+func hashAddressFingerprintWithAllDataKeys(keyring *encryption.Keyring, fingerprint string) ([]string, error) {
+	if keyring == nil {
+		hash, err := hashAddressFingerprint(nil, fingerprint)
+
+		return []string{hash}, err
+	}
+
+	return keyring.Hashes(addressFingerprintHashKey, fingerprint)
+}
+
+// containsString returns true if the provided values contain the provided value.
+func containsString(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// readAddressFingerprint returns the fingerprint hash an existing address was created or last updated with, or empty if it
+// has none.
+func readAddressFingerprint(ctx context.Context, tenantID, applicationID, addressID system.UUID, session *gocql.Session) (string, error) {
+	var fingerprint string
+
+	err := session.Query(
+		"SELECT fingerprint"+
+			" FROM address_metadata"+
+			" WHERE"+
+			" tenant_id = ?"+
+			" AND application_id = ?"+
+			" AND address_id = ?",
+		tenantID.String(),
+		applicationID.String(),
+		addressID.String()).WithContext(ctx).Scan(&fingerprint)
+
+	if err == gocql.ErrNotFound {
+		return "", nil
+	}
+
+	return fingerprint, err
+}
+
+// changeAddressFingerprint adds the statements moving the row of an address in address_indexed_by_fingerprint table from
+// the existing fingerprint to the new one to the provided batch. The new fingerprint itself is stored in
+// address_metadata table by changeAddressVersion. The row expires after the provided TTL in seconds, or never if it is
+// zero.
+func changeAddressFingerprint(
+	batch *gocql.Batch,
+	tenantID, applicationID, addressID gocql.UUID,
+	existingFingerprint, fingerprint string,
+	ttl int) {
+	if existingFingerprint != "" && existingFingerprint != fingerprint {
+		removeFromIndexByFingerprintTable(batch, tenantID, applicationID, addressID, existingFingerprint)
+	}
+
+	if fingerprint != "" {
+		addToAddressIndexByFingerprintTable(batch, tenantID, applicationID, addressID, fingerprint, ttl)
+	}
+}
+
+// addToAddressIndexByFingerprintTable adds the statement inserting an address fingerprint to address_indexed_by_fingerprint
+// table to the provided batch. The row expires after the provided TTL in seconds, or never if it is zero.
+func addToAddressIndexByFingerprintTable(
+	batch *gocql.Batch,
+	tenantID, applicationID, addressID gocql.UUID,
+	fingerprint string,
+	ttl int) {
+	batch.Query(
+		"INSERT INTO address_indexed_by_fingerprint"+
+			" (tenant_id, application_id, fingerprint, address_id)"+
+			" VALUES(?, ?, ?, ?)"+
+			" USING TTL ?",
+		tenantID,
+		applicationID,
+		fingerprint,
+		addressID,
+		ttl)
+}
+
+// removeFromIndexByFingerprintTable adds the statement removing an address fingerprint from
+// address_indexed_by_fingerprint table to the provided batch.
+func removeFromIndexByFingerprintTable(
+	batch *gocql.Batch,
+	tenantID, applicationID, addressID gocql.UUID,
+	fingerprint string) {
+	batch.Query(
+		"DELETE FROM address_indexed_by_fingerprint"+
+			" WHERE"+
+			" tenant_id = ?"+
+			" AND application_id = ?"+
+			" AND fingerprint = ?"+
+			" AND address_id = ?",
+		tenantID,
+		applicationID,
+		fingerprint,
+		addressID)
+}
+
+// mapFingerprintToCassandra maps the provided fingerprint to the stored value, nil if it is empty so no value is stored.
+func mapFingerprintToCassandra(fingerprint string) interface{} {
+	if fingerprint == "" {
+		return nil
+	}
+
+	return fingerprint
+}
@@ -0,0 +1,168 @@
+// +build integration
+
+package service_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/golang/mock/gomock"
+	"github.com/micro-business/AddressService/data/contract"
+	"github.com/micro-business/AddressService/data/service"
+	"github.com/micro-business/Micro-Business-Core/system"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("History and ReadAt methods behaviour", func() {
+	var (
+		ctx                      context.Context
+		mockCtrl                 *gomock.Controller
+		addressDataService       *service.AddressDataService
+		mockUUIDGeneratorService *MockUUIDGeneratorService
+		tenantID                 system.UUID
+		applicationID            system.UUID
+		addressID                system.UUID
+		clusterConfig            *gocql.ClusterConfig
+		createdAddressDetails    map[string]string
+		updatedAddressDetails    map[string]string
+		beforeCreate             time.Time
+		afterCreate              time.Time
+		afterUpdate              time.Time
+		afterDelete              time.Time
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		clusterConfig = getClusterConfig()
+		clusterConfig.Keyspace = keyspace
+
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockUUIDGeneratorService = NewMockUUIDGeneratorService(mockCtrl)
+
+		addressDataService = &service.AddressDataService{UUIDGeneratorService: mockUUIDGeneratorService, ClusterConfig: clusterConfig}
+
+		tenantID, _ = system.RandomUUID()
+		applicationID, _ = system.RandomUUID()
+		addressID, _ = system.RandomUUID()
+
+		mockUUIDGeneratorService.
+			EXPECT().
+			GenerateRandomUUID().
+			Return(addressID, nil)
+
+		createdAddressDetails = createRandomAddressDetails()
+		updatedAddressDetails = createRandomAddressDetails()
+
+		beforeCreate = time.Now()
+		time.Sleep(10 * time.Millisecond)
+
+		_, err := addressDataService.Create(ctx, tenantID, applicationID, contract.Address{AddressDetails: createdAddressDetails}, "creator")
+
+		Expect(err).To(BeNil())
+
+		time.Sleep(10 * time.Millisecond)
+		afterCreate = time.Now()
+		time.Sleep(10 * time.Millisecond)
+
+		Expect(addressDataService.Update(ctx, tenantID, applicationID, addressID, contract.Address{AddressDetails: updatedAddressDetails}, contract.AnyVersion, "updater")).To(BeNil())
+
+		time.Sleep(10 * time.Millisecond)
+		afterUpdate = time.Now()
+		time.Sleep(10 * time.Millisecond)
+
+		Expect(addressDataService.Delete(ctx, tenantID, applicationID, addressID, contract.AnyVersion, "deleter")).To(BeNil())
+
+		time.Sleep(10 * time.Millisecond)
+		afterDelete = time.Now()
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+		addressDataService.Close()
+	})
+
+	Context("when reading the address history", func() {
+		It("should return every version of the address with who changed it and when", func() {
+			history, err := addressDataService.History(ctx, tenantID, applicationID, addressID)
+
+			Expect(err).To(BeNil())
+			Expect(history).To(HaveLen(3))
+
+			Expect(history[0].Address).To(Equal(contract.Address{AddressDetails: createdAddressDetails, Version: 1}))
+			Expect(history[0].ChangedBy).To(Equal("creator"))
+			Expect(history[0].Deleted).To(BeFalse())
+			Expect(history[0].ChangedAt).To(BeTemporally("~", beforeCreate, time.Second))
+
+			Expect(history[1].Address).To(Equal(contract.Address{AddressDetails: updatedAddressDetails, Version: 2}))
+			Expect(history[1].ChangedBy).To(Equal("updater"))
+			Expect(history[1].Deleted).To(BeFalse())
+
+			Expect(history[2].Address).To(Equal(contract.Address{AddressDetails: updatedAddressDetails, Version: 3}))
+			Expect(history[2].ChangedBy).To(Equal("deleter"))
+			Expect(history[2].Deleted).To(BeTrue())
+		})
+
+		It("should record the restored version", func() {
+			Expect(addressDataService.Restore(ctx, tenantID, applicationID, addressID, time.Now().Add(-time.Hour), "restorer")).To(BeNil())
+
+			history, err := addressDataService.History(ctx, tenantID, applicationID, addressID)
+
+			Expect(err).To(BeNil())
+			Expect(history).To(HaveLen(4))
+			Expect(history[3].Address).To(Equal(contract.Address{AddressDetails: updatedAddressDetails, Version: 4}))
+			Expect(history[3].ChangedBy).To(Equal("restorer"))
+			Expect(history[3].Deleted).To(BeFalse())
+		})
+
+		It("should keep the history after the address is purged", func() {
+			Expect(addressDataService.Purge(ctx, tenantID, applicationID, addressID)).To(BeNil())
+
+			history, err := addressDataService.History(ctx, tenantID, applicationID, addressID)
+
+			Expect(err).To(BeNil())
+			Expect(history).To(HaveLen(3))
+		})
+
+		It("should return error if no version of the address is recorded", func() {
+			invalidAddressID, _ := system.RandomUUID()
+
+			_, err := addressDataService.History(ctx, tenantID, applicationID, invalidAddressID)
+
+			Expect(err).To(Equal(contract.NotFoundError{AddressID: invalidAddressID}))
+		})
+	})
+
+	Context("when reading the address at a point in time", func() {
+		It("should return error if the address did not exist at the provided time", func() {
+			_, err := addressDataService.ReadAt(ctx, tenantID, applicationID, addressID, beforeCreate)
+
+			Expect(err).To(Equal(contract.NotFoundError{AddressID: addressID}))
+		})
+
+		It("should return the address as it was at the provided time", func() {
+			address, err := addressDataService.ReadAt(ctx, tenantID, applicationID, addressID, afterCreate)
+
+			Expect(err).To(BeNil())
+			Expect(address).To(Equal(contract.Address{AddressDetails: createdAddressDetails, Version: 1}))
+
+			address, err = addressDataService.ReadAt(ctx, tenantID, applicationID, addressID, afterUpdate)
+
+			Expect(err).To(BeNil())
+			Expect(address).To(Equal(contract.Address{AddressDetails: updatedAddressDetails, Version: 2}))
+		})
+
+		It("should return error if the address was deleted at the provided time", func() {
+			_, err := addressDataService.ReadAt(ctx, tenantID, applicationID, addressID, afterDelete)
+
+			Expect(err).To(Equal(contract.NotFoundError{AddressID: addressID}))
+		})
+	})
+})
+
+func TestHistoryBehaviour(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "History and ReadAt methods behaviour")
+}
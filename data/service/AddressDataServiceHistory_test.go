@@ -0,0 +1,45 @@
+package service_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/micro-business/AddressService/data/service"
+	"github.com/micro-business/Micro-Business-Core/system"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("History and ReadAt methods input parameters and dependency test", func() {
+	var (
+		ctx                context.Context
+		addressDataService *service.AddressDataService
+		tenantID           system.UUID
+		applicationID      system.UUID
+		addressID          system.UUID
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		addressDataService = &service.AddressDataService{ClusterConfig: &gocql.ClusterConfig{}}
+		tenantID, _ = system.RandomUUID()
+		applicationID, _ = system.RandomUUID()
+		addressID, _ = system.RandomUUID()
+	})
+
+	Context("when cluster configuration not provided", func() {
+		It("should panic", func() {
+			addressDataService.ClusterConfig = nil
+
+			Ω(func() { addressDataService.History(ctx, tenantID, applicationID, addressID) }).Should(Panic())
+			Ω(func() { addressDataService.ReadAt(ctx, tenantID, applicationID, addressID, time.Now()) }).Should(Panic())
+		})
+	})
+})
+
+func TestHistory(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "History and ReadAt methods input parameters and dependency test")
+}
@@ -0,0 +1,122 @@
+// +build integration
+
+package service_test
+
+import (
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/golang/mock/gomock"
+	"github.com/micro-business/AddressService/data/contract"
+	"github.com/micro-business/AddressService/data/service"
+	"github.com/micro-business/Micro-Business-Core/system"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("List method behaviour", func() {
+	var (
+		ctx                      context.Context
+		mockCtrl                 *gomock.Controller
+		addressDataService       *service.AddressDataService
+		mockUUIDGeneratorService *MockUUIDGeneratorService
+		tenantID                 system.UUID
+		applicationID            system.UUID
+		clusterConfig            *gocql.ClusterConfig
+		expectedAddresses        map[system.UUID]contract.Address
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		clusterConfig = getClusterConfig()
+		clusterConfig.Keyspace = keyspace
+
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockUUIDGeneratorService = NewMockUUIDGeneratorService(mockCtrl)
+
+		addressDataService = &service.AddressDataService{UUIDGeneratorService: mockUUIDGeneratorService, ClusterConfig: clusterConfig}
+
+		tenantID, _ = system.RandomUUID()
+		applicationID, _ = system.RandomUUID()
+		expectedAddresses = make(map[system.UUID]contract.Address)
+
+		for i := 0; i < 3; i++ {
+			addressID, _ := system.RandomUUID()
+			addressDetails := createRandomAddressDetails()
+
+			mockUUIDGeneratorService.
+				EXPECT().
+				GenerateRandomUUID().
+				Return(addressID, nil)
+
+			_, err := addressDataService.Create(ctx, tenantID, applicationID, contract.Address{AddressDetails: addressDetails}, "")
+
+			Expect(err).To(BeNil())
+
+			expectedAddresses[addressID] = contract.Address{AddressDetails: addressDetails, Version: 1}
+		}
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+		addressDataService.Close()
+	})
+
+	Context("when listing addresses", func() {
+		It("should return all the addresses of the application page by page", func() {
+			listedAddresses := make(map[system.UUID]contract.Address)
+			cursor := ""
+
+			for {
+				page, err := addressDataService.List(ctx, tenantID, applicationID, 2, cursor)
+
+				Expect(err).To(BeNil())
+				Expect(len(page.Addresses)).To(BeNumerically("<=", 2))
+
+				for _, listedAddress := range page.Addresses {
+					listedAddresses[listedAddress.AddressID] = listedAddress.Address
+				}
+
+				if page.NextCursor == "" {
+					break
+				}
+
+				cursor = page.NextCursor
+			}
+
+			Expect(listedAddresses).To(Equal(expectedAddresses))
+		})
+
+		It("should not return the deleted addresses", func() {
+			var deletedAddressID system.UUID
+
+			for addressID := range expectedAddresses {
+				deletedAddressID = addressID
+			}
+
+			Expect(addressDataService.Delete(ctx, tenantID, applicationID, deletedAddressID, contract.AnyVersion, "")).To(BeNil())
+
+			page, err := addressDataService.List(ctx, tenantID, applicationID, 10, "")
+
+			Expect(err).To(BeNil())
+			Expect(page.Addresses).To(HaveLen(2))
+			Expect(page.NextCursor).To(BeEmpty())
+
+			for _, listedAddress := range page.Addresses {
+				Expect(listedAddress.AddressID).NotTo(Equal(deletedAddressID))
+			}
+		})
+
+		It("should return error if the cursor is invalid", func() {
+			_, err := addressDataService.List(ctx, tenantID, applicationID, 10, "!")
+
+			Expect(err).To(Equal(contract.InvalidArgumentError{Message: "Invalid cursor. Cursor: !"}))
+		})
+	})
+})
+
+func TestListBehaviour(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "List method behaviour")
+}
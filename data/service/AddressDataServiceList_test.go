@@ -0,0 +1,41 @@
+package service_test
+
+import (
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/micro-business/AddressService/data/service"
+	"github.com/micro-business/Micro-Business-Core/system"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("List method input parameters and dependency test", func() {
+	var (
+		ctx                context.Context
+		addressDataService *service.AddressDataService
+		tenantID           system.UUID
+		applicationID      system.UUID
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		addressDataService = &service.AddressDataService{ClusterConfig: &gocql.ClusterConfig{}}
+		tenantID, _ = system.RandomUUID()
+		applicationID, _ = system.RandomUUID()
+	})
+
+	Context("when cluster configuration not provided", func() {
+		It("should panic", func() {
+			addressDataService.ClusterConfig = nil
+
+			Ω(func() { addressDataService.List(ctx, tenantID, applicationID, 10, "") }).Should(Panic())
+		})
+	})
+})
+
+func TestList(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "List method input parameters and dependency test")
+}
@@ -0,0 +1,99 @@
+// +build integration
+
+package service_test
+
+import (
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/golang/mock/gomock"
+	"github.com/micro-business/AddressService/data/contract"
+	"github.com/micro-business/AddressService/data/service"
+	"github.com/micro-business/Micro-Business-Core/system"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("CreateMany, ReadMany and DeleteMany methods behaviour", func() {
+	var (
+		ctx                      context.Context
+		mockCtrl                 *gomock.Controller
+		addressDataService       *service.AddressDataService
+		mockUUIDGeneratorService *MockUUIDGeneratorService
+		tenantID                 system.UUID
+		applicationID            system.UUID
+		createdAddressIDs        []system.UUID
+		missingAddressID         system.UUID
+		createdAddresses         []contract.Address
+		clusterConfig            *gocql.ClusterConfig
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		clusterConfig = getClusterConfig()
+		clusterConfig.Keyspace = keyspace
+
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockUUIDGeneratorService = NewMockUUIDGeneratorService(mockCtrl)
+
+		addressDataService = &service.AddressDataService{UUIDGeneratorService: mockUUIDGeneratorService, ClusterConfig: clusterConfig}
+
+		tenantID, _ = system.RandomUUID()
+		applicationID, _ = system.RandomUUID()
+		missingAddressID, _ = system.RandomUUID()
+		createdAddressIDs = []system.UUID{}
+		createdAddresses = []contract.Address{}
+
+		for i := 0; i < 2; i++ {
+			createdAddressID, _ := system.RandomUUID()
+			createdAddressIDs = append(createdAddressIDs, createdAddressID)
+			createdAddresses = append(createdAddresses, contract.Address{AddressDetails: createRandomAddressDetails()})
+
+			mockUUIDGeneratorService.
+				EXPECT().
+				GenerateRandomUUID().
+				Return(createdAddressID, nil)
+		}
+
+		results := addressDataService.CreateMany(ctx, tenantID, applicationID, createdAddresses, "importer")
+
+		Expect(results).To(Equal([]contract.AddressResult{{AddressID: createdAddressIDs[0]}, {AddressID: createdAddressIDs[1]}}))
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+		addressDataService.Close()
+	})
+
+	Context("when reading several addresses", func() {
+		It("should read every address and report the ones not found", func() {
+			results := addressDataService.ReadMany(ctx, tenantID, applicationID, []system.UUID{createdAddressIDs[0], missingAddressID, createdAddressIDs[1]}, nil)
+
+			Expect(results).To(Equal([]contract.AddressResult{
+				{AddressID: createdAddressIDs[0], Address: contract.Address{AddressDetails: createdAddresses[0].AddressDetails, Version: 1}},
+				{AddressID: missingAddressID, Err: contract.NotFoundError{AddressID: missingAddressID}},
+				{AddressID: createdAddressIDs[1], Address: contract.Address{AddressDetails: createdAddresses[1].AddressDetails, Version: 1}},
+			}))
+		})
+	})
+
+	Context("when deleting several addresses", func() {
+		It("should delete every address", func() {
+			results := addressDataService.DeleteMany(ctx, tenantID, applicationID, createdAddressIDs, "")
+
+			Expect(results).To(Equal([]contract.AddressResult{{AddressID: createdAddressIDs[0]}, {AddressID: createdAddressIDs[1]}}))
+
+			for _, createdAddressID := range createdAddressIDs {
+				_, err := addressDataService.ReadAll(ctx, tenantID, applicationID, createdAddressID)
+
+				Expect(err).To(Equal(contract.NotFoundError{AddressID: createdAddressID}))
+			}
+		})
+	})
+})
+
+func TestManyBehaviour(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "CreateMany, ReadMany and DeleteMany methods behaviour")
+}
@@ -0,0 +1,55 @@
+package service_test
+
+import (
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/micro-business/AddressService/data/contract"
+	"github.com/micro-business/AddressService/data/service"
+	"github.com/micro-business/Micro-Business-Core/system"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("CreateMany, ReadMany and DeleteMany methods input parameters and dependency test", func() {
+	var (
+		ctx                context.Context
+		addressDataService *service.AddressDataService
+		tenantID           system.UUID
+		applicationID      system.UUID
+		addressID          system.UUID
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		addressDataService = &service.AddressDataService{ClusterConfig: &gocql.ClusterConfig{}}
+		tenantID, _ = system.RandomUUID()
+		applicationID, _ = system.RandomUUID()
+		addressID, _ = system.RandomUUID()
+	})
+
+	Context("when UUID generator service not provided", func() {
+		It("should panic", func() {
+			addressDataService.UUIDGeneratorService = nil
+
+			Ω(func() {
+				addressDataService.CreateMany(ctx, tenantID, applicationID, []contract.Address{{AddressDetails: createRandomAddressDetails()}}, "")
+			}).Should(Panic())
+		})
+	})
+
+	Context("when cluster configuration not provided", func() {
+		It("should panic", func() {
+			addressDataService.ClusterConfig = nil
+
+			Ω(func() { addressDataService.ReadMany(ctx, tenantID, applicationID, []system.UUID{addressID}, nil) }).Should(Panic())
+			Ω(func() { addressDataService.DeleteMany(ctx, tenantID, applicationID, []system.UUID{addressID}, "") }).Should(Panic())
+		})
+	})
+})
+
+func TestMany(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "CreateMany, ReadMany and DeleteMany methods input parameters and dependency test")
+}
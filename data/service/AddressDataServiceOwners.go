@@ -0,0 +1,271 @@
+package service
+
+import (
+	"github.com/gocql/gocql"
+	"github.com/micro-business/AddressService/data/contract"
+	"github.com/micro-business/AddressService/data/encryption"
+	"github.com/micro-business/Micro-Business-Core/common/diagnostics"
+	"github.com/micro-business/Micro-Business-Core/system"
+	"golang.org/x/net/context"
+)
+
+// AddOwners links an existing address to the provided owners. The owners already linked to the address are kept. Every
+// owner is written to both address_owner and address_indexed_by_owner tables in one logged batch, and the rows expire
+// along with the address.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+// applicationID: Mandatory. The unique identifier of the tenant's application owning the address.
+// addressID: Mandatory. The unique identifier of the existing address.
+// owners: Mandatory. The owners to link the address to.
+// Returns error if the address does not exist, or if something goes wrong.
+func (addressDataService *AddressDataService) AddOwners(
+	ctx context.Context,
+	tenantID, applicationID, addressID system.UUID,
+	owners []contract.AddressOwner) error {
+	diagnostics.IsNotNil(addressDataService.ClusterConfig, "addressDataService.ClusterConfig", "ClusterConfig must be provided.")
+
+	return addressDataService.executeWithKeyring(ctx, tenantID, func(session *gocql.Session, keyring *encryption.Keyring) error {
+		existingAddress, err := readAllAddressDetails(ctx, tenantID, applicationID, addressID, keyring, session)
+
+		if err != nil {
+			return err
+		}
+
+		mappedTenantID := mapSystemUUIDToGocqlUUID(tenantID)
+		mappedApplicationID := mapSystemUUIDToGocqlUUID(applicationID)
+		mappedAddressID := mapSystemUUIDToGocqlUUID(addressID)
+
+		ttl := mapDurationToCassandraTTL(existingAddress.TTL)
+		batch := session.NewBatch(gocql.LoggedBatch)
+
+		for _, owner := range owners {
+			addToAddressOwnerTables(batch, mappedTenantID, mappedApplicationID, mappedAddressID, owner, ttl)
+		}
+
+		return session.ExecuteBatch(batch.WithContext(ctx))
+	})
+}
+
+// RemoveOwners unlinks an existing address from the provided owners. The owners not linked to the address are skipped.
+// Every owner is removed from both address_owner and address_indexed_by_owner tables in one logged batch.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+// applicationID: Mandatory. The unique identifier of the tenant's application owning the address.
+// addressID: Mandatory. The unique identifier of the existing address.
+// owners: Mandatory. The owners to unlink the address from.
+// Returns error if the address does not exist, or if something goes wrong.
+func (addressDataService *AddressDataService) RemoveOwners(
+	ctx context.Context,
+	tenantID, applicationID, addressID system.UUID,
+	owners []contract.AddressOwner) error {
+	diagnostics.IsNotNil(addressDataService.ClusterConfig, "addressDataService.ClusterConfig", "ClusterConfig must be provided.")
+
+	return addressDataService.executeWithKeyring(ctx, tenantID, func(session *gocql.Session, keyring *encryption.Keyring) error {
+		if _, err := readAllAddressDetails(ctx, tenantID, applicationID, addressID, keyring, session); err != nil {
+			return err
+		}
+
+		mappedTenantID := mapSystemUUIDToGocqlUUID(tenantID)
+		mappedApplicationID := mapSystemUUIDToGocqlUUID(applicationID)
+		mappedAddressID := mapSystemUUIDToGocqlUUID(addressID)
+
+		batch := session.NewBatch(gocql.LoggedBatch)
+
+		for _, owner := range owners {
+			removeFromAddressOwnerTables(batch, mappedTenantID, mappedApplicationID, mappedAddressID, owner)
+		}
+
+		return session.ExecuteBatch(batch.WithContext(ctx))
+	})
+}
+
+// ReadOwners retrieves the owners an existing address is linked to from address_owner table.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+// applicationID: Mandatory. The unique identifier of the tenant's application owning the address.
+// addressID: Mandatory. The unique identifier of the existing address.
+// Returns either the owners ordered by their type and identifier or error if the address does not exist or something
+// goes wrong.
+func (addressDataService *AddressDataService) ReadOwners(ctx context.Context, tenantID, applicationID, addressID system.UUID) ([]contract.AddressOwner, error) {
+	diagnostics.IsNotNil(addressDataService.ClusterConfig, "addressDataService.ClusterConfig", "ClusterConfig must be provided.")
+
+	var owners []contract.AddressOwner
+
+	err := addressDataService.executeWithKeyring(ctx, tenantID, func(session *gocql.Session, keyring *encryption.Keyring) error {
+		if _, err := readAllAddressDetails(ctx, tenantID, applicationID, addressID, keyring, session); err != nil {
+			return err
+		}
+
+		var err error
+
+		owners, err = readAddressOwners(ctx, tenantID, applicationID, addressID, session)
+
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return owners, nil
+}
+
+// FindByOwner retrieves the addresses owned by a tenant's application which are linked to the provided owner. The
+// deleted addresses are not returned. The linked addresses are read from address_indexed_by_owner table.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the addresses.
+// applicationID: Mandatory. The unique identifier of the tenant's application owning the addresses.
+// owner: Mandatory. The owner the returned addresses are linked to.
+// Returns either the linked addresses ordered by their unique identifier or error if something goes wrong.
+func (addressDataService *AddressDataService) FindByOwner(
+	ctx context.Context,
+	tenantID, applicationID system.UUID,
+	owner contract.AddressOwner) ([]contract.ListedAddress, error) {
+	diagnostics.IsNotNil(addressDataService.ClusterConfig, "addressDataService.ClusterConfig", "ClusterConfig must be provided.")
+
+	listedAddresses := []contract.ListedAddress{}
+
+	err := addressDataService.executeWithKeyring(ctx, tenantID, func(session *gocql.Session, keyring *encryption.Keyring) error {
+		iter := session.Query(
+			"SELECT address_id"+
+				" FROM address_indexed_by_owner"+
+				" WHERE"+
+				" tenant_id = ?"+
+				" AND application_id = ?"+
+				" AND owner_type = ?"+
+				" AND owner_id = ?",
+			mapSystemUUIDToGocqlUUID(tenantID),
+			mapSystemUUIDToGocqlUUID(applicationID),
+			owner.Type,
+			owner.ID).WithContext(ctx).Iter()
+
+		var addressID gocql.UUID
+
+		linkedAddressIDs := []system.UUID{}
+
+		for iter.Scan(&addressID) {
+			linkedAddressIDs = append(linkedAddressIDs, mapGocqlUUIDToSystemUUID(addressID))
+		}
+
+		if err := iter.Close(); err != nil {
+			return err
+		}
+
+		for _, linkedAddressID := range linkedAddressIDs {
+			address, err := readAllAddressDetails(ctx, tenantID, applicationID, linkedAddressID, keyring, session)
+
+			if _, ok := err.(contract.NotFoundError); ok {
+				continue
+			}
+
+			if err != nil {
+				return err
+			}
+
+			listedAddresses = append(listedAddresses, contract.ListedAddress{AddressID: linkedAddressID, Address: address})
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return listedAddresses, nil
+}
+
+// readAddressOwners returns the owners an address is linked to, whether it is deleted or not.
+func readAddressOwners(ctx context.Context, tenantID, applicationID, addressID system.UUID, session *gocql.Session) ([]contract.AddressOwner, error) {
+	iter := session.Query(
+		"SELECT owner_type, owner_id"+
+			" FROM address_owner"+
+			" WHERE"+
+			" tenant_id = ?"+
+			" AND application_id = ?"+
+			" AND address_id = ?",
+		tenantID.String(),
+		applicationID.String(),
+		addressID.String()).WithContext(ctx).Iter()
+
+	var owner contract.AddressOwner
+
+	owners := []contract.AddressOwner{}
+
+	for iter.Scan(&owner.Type, &owner.ID) {
+		owners = append(owners, owner)
+	}
+
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+
+	return owners, nil
+}
+
+// addToAddressOwnerTables adds the statements inserting an address owner to address_owner and address_indexed_by_owner
+// tables to the provided batch. The rows expire after the provided TTL in seconds, or never if it is zero.
+func addToAddressOwnerTables(
+	batch *gocql.Batch,
+	tenantID, applicationID, addressID gocql.UUID,
+	owner contract.AddressOwner,
+	ttl int) {
+	batch.Query(
+		"INSERT INTO address_owner"+
+			" (tenant_id, application_id, address_id, owner_type, owner_id)"+
+			" VALUES(?, ?, ?, ?, ?)"+
+			" USING TTL ?",
+		tenantID,
+		applicationID,
+		addressID,
+		owner.Type,
+		owner.ID,
+		ttl)
+
+	batch.Query(
+		"INSERT INTO address_indexed_by_owner"+
+			" (tenant_id, application_id, owner_type, owner_id, address_id)"+
+			" VALUES(?, ?, ?, ?, ?)"+
+			" USING TTL ?",
+		tenantID,
+		applicationID,
+		owner.Type,
+		owner.ID,
+		addressID,
+		ttl)
+}
+
+// removeFromAddressOwnerTables adds the statements removing an address owner from address_owner and
+// address_indexed_by_owner tables to the provided batch.
+func removeFromAddressOwnerTables(
+	batch *gocql.Batch,
+	tenantID, applicationID, addressID gocql.UUID,
+	owner contract.AddressOwner) {
+	batch.Query(
+		"DELETE FROM address_owner"+
+			" WHERE"+
+			" tenant_id = ?"+
+			" AND application_id = ?"+
+			" AND address_id = ?"+
+			" AND owner_type = ?"+
+			" AND owner_id = ?",
+		tenantID,
+		applicationID,
+		addressID,
+		owner.Type,
+		owner.ID)
+
+	batch.Query(
+		"DELETE FROM address_indexed_by_owner"+
+			" WHERE"+
+			" tenant_id = ?"+
+			" AND application_id = ?"+
+			" AND owner_type = ?"+
+			" AND owner_id = ?"+
+			" AND address_id = ?",
+		tenantID,
+		applicationID,
+		owner.Type,
+		owner.ID,
+		addressID)
+}
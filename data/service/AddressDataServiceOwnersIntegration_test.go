@@ -0,0 +1,178 @@
+// +build integration
+
+package service_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/golang/mock/gomock"
+	"github.com/micro-business/AddressService/data/contract"
+	"github.com/micro-business/AddressService/data/service"
+	"github.com/micro-business/Micro-Business-Core/system"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("Owners methods behaviour", func() {
+	var (
+		ctx                      context.Context
+		mockCtrl                 *gomock.Controller
+		addressDataService       *service.AddressDataService
+		mockUUIDGeneratorService *MockUUIDGeneratorService
+		tenantID                 system.UUID
+		applicationID            system.UUID
+		addressID                system.UUID
+		customer                 contract.AddressOwner
+		supplier                 contract.AddressOwner
+		clusterConfig            *gocql.ClusterConfig
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		clusterConfig = getClusterConfig()
+		clusterConfig.Keyspace = keyspace
+
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockUUIDGeneratorService = NewMockUUIDGeneratorService(mockCtrl)
+
+		addressDataService = &service.AddressDataService{UUIDGeneratorService: mockUUIDGeneratorService, ClusterConfig: clusterConfig}
+
+		tenantID, _ = system.RandomUUID()
+		applicationID, _ = system.RandomUUID()
+		addressID, _ = system.RandomUUID()
+		customer = contract.AddressOwner{Type: "customer", ID: "123"}
+		supplier = contract.AddressOwner{Type: "supplier", ID: "S-42"}
+
+		mockUUIDGeneratorService.
+			EXPECT().
+			GenerateRandomUUID().
+			Return(addressID, nil)
+
+		_, err := addressDataService.Create(ctx, tenantID, applicationID, contract.Address{AddressDetails: map[string]string{"City": "Christchurch"}}, "")
+
+		Expect(err).To(BeNil())
+		Expect(addressDataService.AddOwners(ctx, tenantID, applicationID, addressID, []contract.AddressOwner{supplier, customer})).To(BeNil())
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+		addressDataService.Close()
+	})
+
+	Context("when linking an address to owners", func() {
+		It("should return the address for every owner", func() {
+			for _, owner := range []contract.AddressOwner{customer, supplier} {
+				listedAddresses, err := addressDataService.FindByOwner(ctx, tenantID, applicationID, owner)
+
+				Expect(err).To(BeNil())
+				Expect(listedAddresses).To(Equal([]contract.ListedAddress{
+					{AddressID: addressID, Address: contract.Address{AddressDetails: map[string]string{"City": "Christchurch"}, Version: 1}},
+				}))
+			}
+		})
+
+		It("should return the owners ordered by their type and identifier", func() {
+			owners, err := addressDataService.ReadOwners(ctx, tenantID, applicationID, addressID)
+
+			Expect(err).To(BeNil())
+			Expect(owners).To(Equal([]contract.AddressOwner{customer, supplier}))
+		})
+
+		It("should return NotFoundError when the address does not exist", func() {
+			missingAddressID, _ := system.RandomUUID()
+
+			err := addressDataService.AddOwners(ctx, tenantID, applicationID, missingAddressID, []contract.AddressOwner{customer})
+
+			Expect(err).To(Equal(contract.NotFoundError{AddressID: missingAddressID}))
+		})
+	})
+
+	Context("when unlinking an address from owners", func() {
+		It("should remove the address from both owner tables", func() {
+			Expect(addressDataService.RemoveOwners(ctx, tenantID, applicationID, addressID, []contract.AddressOwner{customer})).To(BeNil())
+
+			owners, err := addressDataService.ReadOwners(ctx, tenantID, applicationID, addressID)
+
+			Expect(err).To(BeNil())
+			Expect(owners).To(Equal([]contract.AddressOwner{supplier}))
+
+			listedAddresses, err := addressDataService.FindByOwner(ctx, tenantID, applicationID, customer)
+
+			Expect(err).To(BeNil())
+			Expect(listedAddresses).To(BeEmpty())
+		})
+	})
+
+	Context("when the address is deleted", func() {
+		It("should not return the address until it is restored", func() {
+			Expect(addressDataService.Delete(ctx, tenantID, applicationID, addressID, contract.AnyVersion, "")).To(BeNil())
+
+			listedAddresses, err := addressDataService.FindByOwner(ctx, tenantID, applicationID, customer)
+
+			Expect(err).To(BeNil())
+			Expect(listedAddresses).To(BeEmpty())
+
+			Expect(addressDataService.Restore(ctx, tenantID, applicationID, addressID, time.Now().Add(-time.Hour), "")).To(BeNil())
+
+			listedAddresses, err = addressDataService.FindByOwner(ctx, tenantID, applicationID, customer)
+
+			Expect(err).To(BeNil())
+			Expect(listedAddresses).To(HaveLen(1))
+		})
+
+		It("should remove the owners when the address is purged", func() {
+			Expect(addressDataService.Delete(ctx, tenantID, applicationID, addressID, contract.AnyVersion, "")).To(BeNil())
+			Expect(addressDataService.Purge(ctx, tenantID, applicationID, addressID)).To(BeNil())
+
+			session, err := clusterConfig.CreateSession()
+
+			Expect(err).To(BeNil())
+
+			defer session.Close()
+
+			var count int
+
+			Expect(session.Query(
+				"SELECT COUNT(*) FROM address_indexed_by_owner WHERE tenant_id = ? AND application_id = ? AND owner_type = ? AND owner_id = ?",
+				tenantID.String(),
+				applicationID.String(),
+				customer.Type,
+				customer.ID).Scan(&count)).To(BeNil())
+			Expect(count).To(Equal(0))
+		})
+	})
+
+	Context("when the address is updated with a TTL", func() {
+		It("should expire the owners along with the address", func() {
+			updatedAddress := contract.Address{AddressDetails: map[string]string{"City": "Christchurch"}, TTL: 2 * time.Second}
+
+			Expect(addressDataService.Update(ctx, tenantID, applicationID, addressID, updatedAddress, contract.AnyVersion, "")).To(BeNil())
+
+			time.Sleep(3 * time.Second)
+
+			session, err := clusterConfig.CreateSession()
+
+			Expect(err).To(BeNil())
+
+			defer session.Close()
+
+			var count int
+
+			Expect(session.Query(
+				"SELECT COUNT(*) FROM address_indexed_by_owner WHERE tenant_id = ? AND application_id = ? AND owner_type = ? AND owner_id = ?",
+				tenantID.String(),
+				applicationID.String(),
+				customer.Type,
+				customer.ID).Scan(&count)).To(BeNil())
+			Expect(count).To(Equal(0))
+		})
+	})
+})
+
+func TestOwnersBehaviour(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Owners methods behaviour")
+}
@@ -0,0 +1,60 @@
+package service_test
+
+import (
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/micro-business/AddressService/data/contract"
+	"github.com/micro-business/AddressService/data/service"
+	"github.com/micro-business/Micro-Business-Core/system"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("Owners methods input parameters and dependency test", func() {
+	var (
+		ctx                context.Context
+		addressDataService *service.AddressDataService
+		tenantID           system.UUID
+		applicationID      system.UUID
+		addressID          system.UUID
+		owner              contract.AddressOwner
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		addressDataService = &service.AddressDataService{ClusterConfig: &gocql.ClusterConfig{}}
+		tenantID, _ = system.RandomUUID()
+		applicationID, _ = system.RandomUUID()
+		addressID, _ = system.RandomUUID()
+		owner = contract.AddressOwner{Type: "customer", ID: "123"}
+	})
+
+	Context("when cluster configuration not provided", func() {
+		It("should panic", func() {
+			addressDataService.ClusterConfig = nil
+
+			Ω(func() {
+				addressDataService.AddOwners(ctx, tenantID, applicationID, addressID, []contract.AddressOwner{owner})
+			}).Should(Panic())
+
+			Ω(func() {
+				addressDataService.RemoveOwners(ctx, tenantID, applicationID, addressID, []contract.AddressOwner{owner})
+			}).Should(Panic())
+
+			Ω(func() {
+				addressDataService.ReadOwners(ctx, tenantID, applicationID, addressID)
+			}).Should(Panic())
+
+			Ω(func() {
+				addressDataService.FindByOwner(ctx, tenantID, applicationID, owner)
+			}).Should(Panic())
+		})
+	})
+})
+
+func TestOwners(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Owners methods input parameters and dependency test")
+}
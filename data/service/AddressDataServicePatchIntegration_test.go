@@ -0,0 +1,123 @@
+// +build integration
+
+package service_test
+
+import (
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/golang/mock/gomock"
+	"github.com/micro-business/AddressService/data/contract"
+	"github.com/micro-business/AddressService/data/service"
+	"github.com/micro-business/Micro-Business-Core/system"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("Patch method behaviour", func() {
+	var (
+		ctx                      context.Context
+		mockCtrl                 *gomock.Controller
+		addressDataService       *service.AddressDataService
+		mockUUIDGeneratorService *MockUUIDGeneratorService
+		tenantID                 system.UUID
+		applicationID            system.UUID
+		addressID                system.UUID
+		clusterConfig            *gocql.ClusterConfig
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		clusterConfig = getClusterConfig()
+		clusterConfig.Keyspace = keyspace
+
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockUUIDGeneratorService = NewMockUUIDGeneratorService(mockCtrl)
+
+		addressDataService = &service.AddressDataService{UUIDGeneratorService: mockUUIDGeneratorService, ClusterConfig: clusterConfig}
+
+		tenantID, _ = system.RandomUUID()
+		applicationID, _ = system.RandomUUID()
+		addressID, _ = system.RandomUUID()
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+		addressDataService.Close()
+	})
+
+	createAddress := func(addressDetails map[string]string) {
+		mockUUIDGeneratorService.
+			EXPECT().
+			GenerateRandomUUID().
+			Return(addressID, nil)
+
+		_, err := addressDataService.Create(ctx, tenantID, applicationID, contract.Address{AddressDetails: addressDetails}, "")
+
+		Expect(err).To(BeNil())
+	}
+
+	Context("when patching existing address", func() {
+		It("should return error if address does not exist", func() {
+			err := addressDataService.Patch(ctx, tenantID, applicationID, addressID, map[string]string{"City": "Christchurch"}, nil, contract.AnyVersion, "")
+
+			Expect(err).To(Equal(contract.NotFoundError{AddressID: addressID}))
+		})
+
+		It("should set and remove only the provided keys and increase the address version", func() {
+			createAddress(map[string]string{"Line1": "1 Main Road", "City": "Christchurch", "Postcode": "8011"})
+
+			err := addressDataService.Patch(
+				ctx,
+				tenantID,
+				applicationID,
+				addressID,
+				map[string]string{"Postcode": "8013", "Country": "New Zealand"},
+				[]string{"Line1"},
+				1, "")
+
+			Expect(err).To(BeNil())
+
+			address, err := addressDataService.ReadAll(ctx, tenantID, applicationID, addressID)
+
+			Expect(err).To(BeNil())
+			Expect(address).To(Equal(contract.Address{
+				AddressDetails: map[string]string{"City": "Christchurch", "Postcode": "8013", "Country": "New Zealand"},
+				Version:        2}))
+		})
+
+		It("should return error and leave the address unchanged if all the address keys are removed", func() {
+			expectedAddressDetails := map[string]string{"City": "Christchurch"}
+			createAddress(expectedAddressDetails)
+
+			err := addressDataService.Patch(ctx, tenantID, applicationID, addressID, nil, []string{"City"}, contract.AnyVersion, "")
+
+			Expect(err).To(Equal(contract.InvalidArgumentError{Message: "Address cannot be left without any address detail. Address ID: " + addressID.String()}))
+
+			address, err := addressDataService.ReadAll(ctx, tenantID, applicationID, addressID)
+
+			Expect(err).To(BeNil())
+			Expect(address).To(Equal(contract.Address{AddressDetails: expectedAddressDetails, Version: 1}))
+		})
+
+		It("should return conflict error and leave the address unchanged when the expected version does not match", func() {
+			expectedAddressDetails := createRandomAddressDetails()
+			createAddress(expectedAddressDetails)
+
+			err := addressDataService.Patch(ctx, tenantID, applicationID, addressID, map[string]string{"City": "Christchurch"}, nil, 2, "")
+
+			Expect(err).To(Equal(contract.ConflictError{AddressID: addressID, ExpectedVersion: 2, ActualVersion: 1}))
+
+			address, err := addressDataService.ReadAll(ctx, tenantID, applicationID, addressID)
+
+			Expect(err).To(BeNil())
+			Expect(address).To(Equal(contract.Address{AddressDetails: expectedAddressDetails, Version: 1}))
+		})
+	})
+})
+
+func TestPatchBehaviour(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Patch method behaviour")
+}
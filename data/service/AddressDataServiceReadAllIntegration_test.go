@@ -3,7 +3,6 @@
 package service_test
 
 import (
-	"fmt"
 	"testing"
 
 	"github.com/gocql/gocql"
@@ -13,10 +12,12 @@ import (
 	"github.com/micro-business/Micro-Business-Core/system"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
 )
 
 var _ = Describe("ReadAll method behaviour", func() {
 	var (
+		ctx                      context.Context
 		mockCtrl                 *gomock.Controller
 		addressDataService       *service.AddressDataService
 		mockUUIDGeneratorService *MockUUIDGeneratorService
@@ -27,6 +28,7 @@ var _ = Describe("ReadAll method behaviour", func() {
 	)
 
 	BeforeEach(func() {
+		ctx = context.Background()
 		clusterConfig = getClusterConfig()
 		clusterConfig.Keyspace = keyspace
 
@@ -46,9 +48,9 @@ var _ = Describe("ReadAll method behaviour", func() {
 
 	Context("when reading existing address", func() {
 		It("should return error if address does not exist", func() {
-			address, err := addressDataService.ReadAll(tenantID, applicationID, addressID)
+			address, err := addressDataService.ReadAll(ctx, tenantID, applicationID, addressID)
 
-			Expect(err).To(Equal(fmt.Errorf("Address not found. Address ID: %s", addressID.String())))
+			Expect(err).To(Equal(contract.NotFoundError{AddressID: addressID}))
 			Expect(address).To(Equal(contract.Address{}))
 		})
 
@@ -62,19 +64,22 @@ var _ = Describe("ReadAll method behaviour", func() {
 
 			expectedAddress := contract.Address{AddressDetails: expectedAddressDetails}
 			returnedAddressID, err := addressDataService.Create(
+				ctx,
 				tenantID,
 				applicationID,
-				expectedAddress)
+				expectedAddress, "")
 
 			Expect(err).To(BeNil())
 
 			returnedAddress, err := addressDataService.ReadAll(
+				ctx,
 				tenantID,
 				applicationID,
 				returnedAddressID)
 
 			Expect(err).To(BeNil())
-			Expect(expectedAddress).To(Equal(returnedAddress))
+			Expect(expectedAddress.AddressDetails).To(Equal(returnedAddress.AddressDetails))
+			Expect(returnedAddress.Version).To(Equal(int64(1)))
 		})
 	})
 })
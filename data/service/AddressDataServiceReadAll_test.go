@@ -8,10 +8,12 @@ import (
 	"github.com/micro-business/Micro-Business-Core/system"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
 )
 
 var _ = Describe("ReadAll method input parameters and dependency test", func() {
 	var (
+		ctx                context.Context
 		addressDataService *service.AddressDataService
 		tenantID           system.UUID
 		applicationID      system.UUID
@@ -19,6 +21,7 @@ var _ = Describe("ReadAll method input parameters and dependency test", func() {
 	)
 
 	BeforeEach(func() {
+		ctx = context.Background()
 		addressDataService = &service.AddressDataService{ClusterConfig: &gocql.ClusterConfig{}}
 
 		addressDataService = &service.AddressDataService{}
@@ -31,7 +34,7 @@ var _ = Describe("ReadAll method input parameters and dependency test", func() {
 		It("should panic", func() {
 			addressDataService.ClusterConfig = nil
 
-			Ω(func() { addressDataService.ReadAll(tenantID, applicationID, addressID) }).Should(Panic())
+			Ω(func() { addressDataService.ReadAll(ctx, tenantID, applicationID, addressID) }).Should(Panic())
 		})
 	})
 })
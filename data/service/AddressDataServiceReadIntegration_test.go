@@ -3,7 +3,6 @@
 package service_test
 
 import (
-	"fmt"
 	"testing"
 
 	"github.com/gocql/gocql"
@@ -13,10 +12,12 @@ import (
 	"github.com/micro-business/Micro-Business-Core/system"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
 )
 
 var _ = Describe("Read method behaviour", func() {
 	var (
+		ctx                      context.Context
 		mockCtrl                 *gomock.Controller
 		addressDataService       *service.AddressDataService
 		mockUUIDGeneratorService *MockUUIDGeneratorService
@@ -27,6 +28,7 @@ var _ = Describe("Read method behaviour", func() {
 	)
 
 	BeforeEach(func() {
+		ctx = context.Background()
 		clusterConfig = getClusterConfig()
 		clusterConfig.Keyspace = keyspace
 
@@ -49,9 +51,9 @@ var _ = Describe("Read method behaviour", func() {
 			keys := make([]string, 1)
 			keys[0] = "Line1"
 
-			address, err := addressDataService.Read(tenantID, applicationID, addressID, keys)
+			address, err := addressDataService.Read(ctx, tenantID, applicationID, addressID, keys)
 
-			Expect(err).To(Equal(fmt.Errorf("Address not found. Address ID: %s", addressID.String())))
+			Expect(err).To(Equal(contract.NotFoundError{AddressID: addressID}))
 			Expect(address).To(Equal(contract.Address{}))
 		})
 
@@ -65,9 +67,10 @@ var _ = Describe("Read method behaviour", func() {
 
 			expectedAddress := contract.Address{AddressDetails: expectedAddressDetails}
 			returnedAddressID, err := addressDataService.Create(
+				ctx,
 				tenantID,
 				applicationID,
-				expectedAddress)
+				expectedAddress, "")
 
 			Expect(err).To(BeNil())
 
@@ -78,13 +81,40 @@ var _ = Describe("Read method behaviour", func() {
 			}
 
 			returnedAddress, err := addressDataService.Read(
+				ctx,
 				tenantID,
 				applicationID,
 				returnedAddressID,
 				keys)
 
 			Expect(err).To(BeNil())
-			Expect(expectedAddress).To(Equal(returnedAddress))
+			Expect(expectedAddress.AddressDetails).To(Equal(returnedAddress.AddressDetails))
+			Expect(returnedAddress.Version).To(Equal(int64(1)))
+		})
+
+		It("should bind the keys as parameters so keys containing quotes are handled safely", func() {
+			mockUUIDGeneratorService.
+				EXPECT().
+				GenerateRandomUUID().
+				Return(addressID, nil)
+
+			returnedAddressID, err := addressDataService.Create(
+				ctx,
+				tenantID,
+				applicationID,
+				contract.Address{AddressDetails: map[string]string{"Line1": "1 Main Road", "O'Connell": "Street", "City": "Christchurch"}}, "")
+
+			Expect(err).To(BeNil())
+
+			returnedAddress, err := addressDataService.Read(
+				ctx,
+				tenantID,
+				applicationID,
+				returnedAddressID,
+				[]string{"O'Connell", "Line1') OR address_key IN ('City"})
+
+			Expect(err).To(BeNil())
+			Expect(returnedAddress).To(Equal(contract.Address{AddressDetails: map[string]string{"O'Connell": "Street"}, Version: 1}))
 		})
 	})
 })
@@ -8,10 +8,12 @@ import (
 	"github.com/micro-business/Micro-Business-Core/system"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
 )
 
 var _ = Describe("Read method input parameters and dependency test", func() {
 	var (
+		ctx                context.Context
 		addressDataService *service.AddressDataService
 		tenantID           system.UUID
 		applicationID      system.UUID
@@ -20,6 +22,7 @@ var _ = Describe("Read method input parameters and dependency test", func() {
 	)
 
 	BeforeEach(func() {
+		ctx = context.Background()
 		addressDataService = &service.AddressDataService{ClusterConfig: &gocql.ClusterConfig{}}
 
 		addressDataService = &service.AddressDataService{}
@@ -34,7 +37,7 @@ var _ = Describe("Read method input parameters and dependency test", func() {
 		It("should panic", func() {
 			addressDataService.ClusterConfig = nil
 
-			Ω(func() { addressDataService.Read(tenantID, applicationID, addressID, validKeys) }).Should(Panic())
+			Ω(func() { addressDataService.Read(ctx, tenantID, applicationID, addressID, validKeys) }).Should(Panic())
 		})
 	})
 })
@@ -0,0 +1,168 @@
+// +build integration
+
+package service_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/golang/mock/gomock"
+	"github.com/micro-business/AddressService/data/contract"
+	"github.com/micro-business/AddressService/data/service"
+	"github.com/micro-business/Micro-Business-Core/system"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("Restore and purge methods behaviour", func() {
+	var (
+		ctx                      context.Context
+		mockCtrl                 *gomock.Controller
+		addressDataService       *service.AddressDataService
+		mockUUIDGeneratorService *MockUUIDGeneratorService
+		tenantID                 system.UUID
+		applicationID            system.UUID
+		addressID                system.UUID
+		clusterConfig            *gocql.ClusterConfig
+		expectedAddressDetails   map[string]string
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		clusterConfig = getClusterConfig()
+		clusterConfig.Keyspace = keyspace
+
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockUUIDGeneratorService = NewMockUUIDGeneratorService(mockCtrl)
+
+		addressDataService = &service.AddressDataService{UUIDGeneratorService: mockUUIDGeneratorService, ClusterConfig: clusterConfig}
+
+		tenantID, _ = system.RandomUUID()
+		applicationID, _ = system.RandomUUID()
+		addressID, _ = system.RandomUUID()
+
+		mockUUIDGeneratorService.
+			EXPECT().
+			GenerateRandomUUID().
+			Return(addressID, nil)
+
+		expectedAddressDetails = createRandomAddressDetails()
+
+		_, err := addressDataService.Create(ctx, tenantID, applicationID, contract.Address{AddressDetails: expectedAddressDetails}, "")
+
+		Expect(err).To(BeNil())
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+		addressDataService.Close()
+	})
+
+	Context("when restoring deleted address", func() {
+		It("should return error if address is not deleted", func() {
+			err := addressDataService.Restore(ctx, tenantID, applicationID, addressID, time.Now().Add(-time.Hour), "")
+
+			Expect(err).To(Equal(contract.NotFoundError{AddressID: addressID, Deleted: true}))
+		})
+
+		It("should return the address to readers with increased version", func() {
+			Expect(addressDataService.Delete(ctx, tenantID, applicationID, addressID, contract.AnyVersion, "")).To(BeNil())
+			Expect(addressDataService.Restore(ctx, tenantID, applicationID, addressID, time.Now().Add(-time.Hour), "")).To(BeNil())
+
+			address, err := addressDataService.ReadAll(ctx, tenantID, applicationID, addressID)
+
+			Expect(err).To(BeNil())
+			Expect(address).To(Equal(contract.Address{AddressDetails: expectedAddressDetails, Version: 3}))
+		})
+
+		It("should return error if address was deleted before the provided time", func() {
+			Expect(addressDataService.Delete(ctx, tenantID, applicationID, addressID, contract.AnyVersion, "")).To(BeNil())
+
+			err := addressDataService.Restore(ctx, tenantID, applicationID, addressID, time.Now().Add(time.Hour), "")
+
+			Expect(err).To(Equal(contract.NotFoundError{AddressID: addressID, Deleted: true}))
+		})
+	})
+
+	Context("when purging deleted address", func() {
+		It("should return error if address is not deleted", func() {
+			err := addressDataService.Purge(ctx, tenantID, applicationID, addressID)
+
+			Expect(err).To(Equal(contract.NotFoundError{AddressID: addressID, Deleted: true}))
+
+			_, err = addressDataService.ReadAll(ctx, tenantID, applicationID, addressID)
+
+			Expect(err).To(BeNil())
+		})
+
+		It("should not be possible to restore the purged address", func() {
+			Expect(addressDataService.Delete(ctx, tenantID, applicationID, addressID, contract.AnyVersion, "")).To(BeNil())
+			Expect(addressDataService.Purge(ctx, tenantID, applicationID, addressID)).To(BeNil())
+
+			err := addressDataService.Restore(ctx, tenantID, applicationID, addressID, time.Now().Add(-time.Hour), "")
+
+			Expect(err).To(Equal(contract.NotFoundError{AddressID: addressID, Deleted: true}))
+		})
+	})
+
+	Context("when purging all the addresses deleted before the provided time", func() {
+		It("should purge the address deleted before the provided time", func() {
+			Expect(addressDataService.Delete(ctx, tenantID, applicationID, addressID, contract.AnyVersion, "")).To(BeNil())
+			Expect(addressDataService.PurgeDeleted(ctx, time.Now().Add(time.Hour))).To(BeNil())
+
+			err := addressDataService.Restore(ctx, tenantID, applicationID, addressID, time.Now().Add(-time.Hour), "")
+
+			Expect(err).To(Equal(contract.NotFoundError{AddressID: addressID, Deleted: true}))
+		})
+
+		It("should keep the address deleted after the provided time", func() {
+			Expect(addressDataService.Delete(ctx, tenantID, applicationID, addressID, contract.AnyVersion, "")).To(BeNil())
+			Expect(addressDataService.PurgeDeleted(ctx, time.Now().Add(-time.Hour))).To(BeNil())
+			Expect(addressDataService.Restore(ctx, tenantID, applicationID, addressID, time.Now().Add(-time.Hour), "")).To(BeNil())
+		})
+
+		It("should keep the address restored since it was deleted", func() {
+			Expect(addressDataService.Delete(ctx, tenantID, applicationID, addressID, contract.AnyVersion, "")).To(BeNil())
+			Expect(addressDataService.Restore(ctx, tenantID, applicationID, addressID, time.Now().Add(-time.Hour), "")).To(BeNil())
+			Expect(addressDataService.PurgeDeleted(ctx, time.Now().Add(time.Hour))).To(BeNil())
+
+			_, err := addressDataService.ReadAll(ctx, tenantID, applicationID, addressID)
+
+			Expect(err).To(BeNil())
+		})
+
+		It("should record the hour the purge starts from if it is not recorded", func() {
+			session, err := clusterConfig.CreateSession()
+
+			Expect(err).To(BeNil())
+
+			defer session.Close()
+
+			Expect(session.Query("DELETE FROM deleted_address_purge WHERE name = 'deleted_address'").Exec()).To(BeNil())
+
+			deletedBefore := time.Now()
+
+			Expect(addressDataService.PurgeDeleted(ctx, deletedBefore)).To(BeNil())
+
+			var nextHour time.Time
+
+			Expect(session.Query("SELECT next_hour FROM deleted_address_purge WHERE name = 'deleted_address'").Scan(&nextHour)).To(BeNil())
+			Expect(nextHour).To(BeTemporally("==", deletedBefore.Truncate(time.Hour)))
+		})
+
+		It("should keep the address not deleted", func() {
+			Expect(addressDataService.PurgeDeleted(ctx, time.Now().Add(time.Hour))).To(BeNil())
+
+			_, err := addressDataService.ReadAll(ctx, tenantID, applicationID, addressID)
+
+			Expect(err).To(BeNil())
+		})
+	})
+})
+
+func TestRestoreAndPurgeBehaviour(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Restore and purge methods behaviour")
+}
@@ -0,0 +1,87 @@
+// +build integration
+
+package service_test
+
+import (
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/golang/mock/gomock"
+	"github.com/micro-business/AddressService/data/contract"
+	"github.com/micro-business/AddressService/data/service"
+	"github.com/micro-business/Micro-Business-Core/system"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("Session lifecycle behaviour", func() {
+	var (
+		ctx                      context.Context
+		mockCtrl                 *gomock.Controller
+		mockUUIDGeneratorService *MockUUIDGeneratorService
+		tenantID                 system.UUID
+		applicationID            system.UUID
+		addressID                system.UUID
+		clusterConfig            *gocql.ClusterConfig
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		clusterConfig = getClusterConfig()
+		clusterConfig.Keyspace = keyspace
+
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockUUIDGeneratorService = NewMockUUIDGeneratorService(mockCtrl)
+
+		tenantID, _ = system.RandomUUID()
+		applicationID, _ = system.RandomUUID()
+		addressID, _ = system.RandomUUID()
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	Context("when creating new address data service", func() {
+		It("should return error if the cluster is not reachable", func() {
+			unreachableClusterConfig := gocql.NewCluster("127.0.0.2")
+			unreachableClusterConfig.Port = 1
+
+			addressDataService, err := service.NewAddressDataService(mockUUIDGeneratorService, unreachableClusterConfig)
+
+			Expect(addressDataService).To(BeNil())
+			Expect(err).NotTo(BeNil())
+		})
+
+		It("should reuse the same session for all the calls and reopen it after being closed", func() {
+			addressDataService, err := service.NewAddressDataService(mockUUIDGeneratorService, clusterConfig)
+
+			Expect(err).To(BeNil())
+
+			defer addressDataService.Close()
+
+			mockUUIDGeneratorService.
+				EXPECT().
+				GenerateRandomUUID().
+				Return(addressID, nil)
+
+			expectedAddress := contract.Address{AddressDetails: createRandomAddressDetails()}
+
+			_, err = addressDataService.Create(ctx, tenantID, applicationID, expectedAddress, "")
+
+			Expect(err).To(BeNil())
+			Expect(addressDataService.Close()).To(BeNil())
+
+			returnedAddress, err := addressDataService.ReadAll(ctx, tenantID, applicationID, addressID)
+
+			Expect(err).To(BeNil())
+			Expect(returnedAddress.AddressDetails).To(Equal(expectedAddress.AddressDetails))
+		})
+	})
+})
+
+func TestSessionBehaviour(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Session lifecycle behaviour")
+}
@@ -0,0 +1,38 @@
+package service_test
+
+import (
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/micro-business/AddressService/data/service"
+	"github.com/micro-business/Micro-Business-Core/system"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Session lifecycle input parameters and dependency test", func() {
+	Context("when UUID generator service not provided", func() {
+		It("should panic", func() {
+			Ω(func() { service.NewAddressDataService(nil, &gocql.ClusterConfig{}) }).Should(Panic())
+		})
+	})
+
+	Context("when cluster configuration not provided", func() {
+		It("should panic", func() {
+			Ω(func() { service.NewAddressDataService(&system.UUIDGeneratorServiceImpl{}, nil) }).Should(Panic())
+		})
+	})
+
+	Context("when closing a service that never opened a session", func() {
+		It("should return no error", func() {
+			addressDataService := &service.AddressDataService{ClusterConfig: &gocql.ClusterConfig{}}
+
+			Expect(addressDataService.Close()).To(BeNil())
+		})
+	})
+})
+
+func TestSession(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Session lifecycle input parameters and dependency test")
+}
@@ -0,0 +1,107 @@
+// +build integration
+
+package service_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/golang/mock/gomock"
+	"github.com/micro-business/AddressService/data/contract"
+	"github.com/micro-business/AddressService/data/service"
+	"github.com/micro-business/Micro-Business-Core/system"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("Address TTL behaviour", func() {
+	var (
+		ctx                      context.Context
+		mockCtrl                 *gomock.Controller
+		addressDataService       *service.AddressDataService
+		mockUUIDGeneratorService *MockUUIDGeneratorService
+		tenantID                 system.UUID
+		applicationID            system.UUID
+		addressID                system.UUID
+		clusterConfig            *gocql.ClusterConfig
+		expectedAddressDetails   map[string]string
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		clusterConfig = getClusterConfig()
+		clusterConfig.Keyspace = keyspace
+
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockUUIDGeneratorService = NewMockUUIDGeneratorService(mockCtrl)
+
+		addressDataService = &service.AddressDataService{UUIDGeneratorService: mockUUIDGeneratorService, ClusterConfig: clusterConfig}
+
+		tenantID, _ = system.RandomUUID()
+		applicationID, _ = system.RandomUUID()
+		addressID, _ = system.RandomUUID()
+
+		mockUUIDGeneratorService.
+			EXPECT().
+			GenerateRandomUUID().
+			Return(addressID, nil)
+
+		expectedAddressDetails = createRandomAddressDetails()
+
+		_, err := addressDataService.Create(
+			ctx,
+			tenantID,
+			applicationID,
+			contract.Address{AddressDetails: expectedAddressDetails, TTL: 2 * time.Second},
+			"")
+
+		Expect(err).To(BeNil())
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+		addressDataService.Close()
+	})
+
+	Context("when address is created with a TTL", func() {
+		It("should return the remaining TTL when reading the address", func() {
+			address, err := addressDataService.ReadAll(ctx, tenantID, applicationID, addressID)
+
+			Expect(err).To(BeNil())
+			Expect(address.AddressDetails).To(Equal(expectedAddressDetails))
+			Expect(address.TTL).To(BeNumerically(">", 0))
+			Expect(address.TTL).To(BeNumerically("<=", 2*time.Second))
+		})
+
+		It("should not return the address once it has expired", func() {
+			time.Sleep(3 * time.Second)
+
+			_, err := addressDataService.ReadAll(ctx, tenantID, applicationID, addressID)
+
+			Expect(err).To(Equal(contract.NotFoundError{AddressID: addressID}))
+
+			page, err := addressDataService.List(ctx, tenantID, applicationID, 10, "")
+
+			Expect(err).To(BeNil())
+			Expect(page.Addresses).To(BeEmpty())
+		})
+
+		It("should never expire the address once it is updated without a TTL", func() {
+			Expect(addressDataService.Update(ctx, tenantID, applicationID, addressID, contract.Address{AddressDetails: expectedAddressDetails}, contract.AnyVersion, "")).To(BeNil())
+
+			time.Sleep(3 * time.Second)
+
+			address, err := addressDataService.ReadAll(ctx, tenantID, applicationID, addressID)
+
+			Expect(err).To(BeNil())
+			Expect(address).To(Equal(contract.Address{AddressDetails: expectedAddressDetails, Version: 2}))
+		})
+	})
+})
+
+func TestAddressTTL(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Address TTL behaviour")
+}
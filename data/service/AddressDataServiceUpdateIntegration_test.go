@@ -3,7 +3,6 @@
 package service_test
 
 import (
-	"fmt"
 	"testing"
 
 	"github.com/gocql/gocql"
@@ -13,10 +12,12 @@ import (
 	"github.com/micro-business/Micro-Business-Core/system"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
 )
 
 var _ = Describe("Update method behaviour", func() {
 	var (
+		ctx                      context.Context
 		mockCtrl                 *gomock.Controller
 		addressDataService       *service.AddressDataService
 		mockUUIDGeneratorService *MockUUIDGeneratorService
@@ -27,6 +28,7 @@ var _ = Describe("Update method behaviour", func() {
 	)
 
 	BeforeEach(func() {
+		ctx = context.Background()
 		clusterConfig = getClusterConfig()
 		clusterConfig.Keyspace = keyspace
 
@@ -46,9 +48,9 @@ var _ = Describe("Update method behaviour", func() {
 
 	Context("when updating existing address", func() {
 		It("should return error if address does not exist", func() {
-			err := addressDataService.Update(tenantID, applicationID, addressID, contract.Address{AddressDetails: createRandomAddressDetails()})
+			err := addressDataService.Update(ctx, tenantID, applicationID, addressID, contract.Address{AddressDetails: createRandomAddressDetails()}, contract.AnyVersion, "")
 
-			Expect(err).To(Equal(fmt.Errorf("Address not found. Address ID: %s", addressID.String())))
+			Expect(err).To(Equal(contract.NotFoundError{AddressID: addressID}))
 		})
 
 		It("should remove all old records from address table", func() {
@@ -60,17 +62,20 @@ var _ = Describe("Update method behaviour", func() {
 			addressDetailsToAdd := createRandomAddressDetails()
 
 			returnedAddressID, err := addressDataService.Create(
+				ctx,
 				tenantID,
 				applicationID,
-				contract.Address{AddressDetails: addressDetailsToAdd})
+				contract.Address{AddressDetails: addressDetailsToAdd}, "")
 
 			Expect(err).To(BeNil())
 
 			err = addressDataService.Update(
+				ctx,
 				tenantID,
 				applicationID,
 				returnedAddressID,
-				contract.Address{AddressDetails: createRandomAddressDetails()})
+				contract.Address{AddressDetails: createRandomAddressDetails()},
+				contract.AnyVersion, "")
 
 			config := getClusterConfig()
 			config.Keyspace = keyspace
@@ -113,17 +118,20 @@ var _ = Describe("Update method behaviour", func() {
 			addressDetailsToAdd := createRandomAddressDetails()
 
 			returnedAddressID, err := addressDataService.Create(
+				ctx,
 				tenantID,
 				applicationID,
-				contract.Address{AddressDetails: addressDetailsToAdd})
+				contract.Address{AddressDetails: addressDetailsToAdd}, "")
 
 			Expect(err).To(BeNil())
 
 			err = addressDataService.Update(
+				ctx,
 				tenantID,
 				applicationID,
 				returnedAddressID,
-				contract.Address{AddressDetails: createRandomAddressDetails()})
+				contract.Address{AddressDetails: createRandomAddressDetails()},
+				contract.AnyVersion, "")
 
 			config := getClusterConfig()
 			config.Keyspace = keyspace
@@ -163,19 +171,22 @@ var _ = Describe("Update method behaviour", func() {
 				Return(addressID, nil)
 
 			returnedAddressID, err := addressDataService.Create(
+				ctx,
 				tenantID,
 				applicationID,
-				contract.Address{AddressDetails: createRandomAddressDetails()})
+				contract.Address{AddressDetails: createRandomAddressDetails()}, "")
 
 			Expect(err).To(BeNil())
 
 			expectedAddressDetails := createRandomAddressDetails()
 
 			err = addressDataService.Update(
+				ctx,
 				tenantID,
 				applicationID,
 				returnedAddressID,
-				contract.Address{AddressDetails: expectedAddressDetails})
+				contract.Address{AddressDetails: expectedAddressDetails},
+				contract.AnyVersion, "")
 
 			Expect(err).To(BeNil())
 
@@ -220,19 +231,22 @@ var _ = Describe("Update method behaviour", func() {
 				Return(addressID, nil)
 
 			returnedAddressID, err := addressDataService.Create(
+				ctx,
 				tenantID,
 				applicationID,
-				contract.Address{AddressDetails: createRandomAddressDetails()})
+				contract.Address{AddressDetails: createRandomAddressDetails()}, "")
 
 			Expect(err).To(BeNil())
 
 			expectedAddressDetails := createRandomAddressDetails()
 
 			err = addressDataService.Update(
+				ctx,
 				tenantID,
 				applicationID,
 				returnedAddressID,
-				contract.Address{AddressDetails: expectedAddressDetails})
+				contract.Address{AddressDetails: expectedAddressDetails},
+				contract.AnyVersion, "")
 
 			Expect(err).To(BeNil())
 
@@ -266,6 +280,149 @@ var _ = Describe("Update method behaviour", func() {
 				Expect(value).To(Equal(addressValue))
 			}
 		})
+
+		It("should keep unchanged keys, update changed keys and remove missing keys", func() {
+			mockUUIDGeneratorService.
+				EXPECT().
+				GenerateRandomUUID().
+				Return(addressID, nil)
+
+			returnedAddressID, err := addressDataService.Create(
+				ctx,
+				tenantID,
+				applicationID,
+				contract.Address{AddressDetails: map[string]string{"Line1": "1 Main Road", "City": "Christchurch", "Postcode": "8011"}}, "")
+
+			Expect(err).To(BeNil())
+
+			expectedAddressDetails := map[string]string{"Line1": "1 Main Road", "City": "Auckland", "Country": "New Zealand"}
+
+			err = addressDataService.Update(
+				ctx,
+				tenantID,
+				applicationID,
+				returnedAddressID,
+				contract.Address{AddressDetails: expectedAddressDetails},
+				contract.AnyVersion, "")
+
+			Expect(err).To(BeNil())
+
+			address, err := addressDataService.ReadAll(ctx, tenantID, applicationID, returnedAddressID)
+
+			Expect(err).To(BeNil())
+			Expect(address.AddressDetails).To(Equal(expectedAddressDetails))
+		})
+
+		It("should increase the address version when the expected version matches", func() {
+			mockUUIDGeneratorService.
+				EXPECT().
+				GenerateRandomUUID().
+				Return(addressID, nil)
+
+			returnedAddressID, err := addressDataService.Create(
+				ctx,
+				tenantID,
+				applicationID,
+				contract.Address{AddressDetails: createRandomAddressDetails()}, "")
+
+			Expect(err).To(BeNil())
+
+			err = addressDataService.Update(
+				ctx,
+				tenantID,
+				applicationID,
+				returnedAddressID,
+				contract.Address{AddressDetails: createRandomAddressDetails()},
+				1, "")
+
+			Expect(err).To(BeNil())
+
+			address, err := addressDataService.ReadAll(ctx, tenantID, applicationID, returnedAddressID)
+
+			Expect(err).To(BeNil())
+			Expect(address.Version).To(Equal(int64(2)))
+		})
+
+		It("should return conflict error and leave the address unchanged when the expected version does not match", func() {
+			mockUUIDGeneratorService.
+				EXPECT().
+				GenerateRandomUUID().
+				Return(addressID, nil)
+
+			expectedAddressDetails := createRandomAddressDetails()
+
+			returnedAddressID, err := addressDataService.Create(
+				ctx,
+				tenantID,
+				applicationID,
+				contract.Address{AddressDetails: expectedAddressDetails}, "")
+
+			Expect(err).To(BeNil())
+
+			err = addressDataService.Update(
+				ctx,
+				tenantID,
+				applicationID,
+				returnedAddressID,
+				contract.Address{AddressDetails: createRandomAddressDetails()},
+				2, "")
+
+			Expect(err).To(Equal(contract.ConflictError{AddressID: returnedAddressID, ExpectedVersion: 2, ActualVersion: 1}))
+
+			address, err := addressDataService.ReadAll(ctx, tenantID, applicationID, returnedAddressID)
+
+			Expect(err).To(BeNil())
+			Expect(address).To(Equal(contract.Address{AddressDetails: expectedAddressDetails, Version: 1}))
+		})
+
+		It("should never make the address unavailable to concurrent readers", func() {
+			mockUUIDGeneratorService.
+				EXPECT().
+				GenerateRandomUUID().
+				Return(addressID, nil)
+
+			returnedAddressID, err := addressDataService.Create(
+				ctx,
+				tenantID,
+				applicationID,
+				contract.Address{AddressDetails: createRandomAddressDetails()}, "")
+
+			Expect(err).To(BeNil())
+
+			updatesDone := make(chan struct{})
+			readErrors := make(chan error, 1)
+
+			go func() {
+				defer close(readErrors)
+
+				for {
+					select {
+					case <-updatesDone:
+						return
+					default:
+						if _, err := addressDataService.ReadAll(ctx, tenantID, applicationID, returnedAddressID); err != nil {
+							readErrors <- err
+
+							return
+						}
+					}
+				}
+			}()
+
+			for idx := 0; idx < 20; idx++ {
+				Expect(addressDataService.Update(
+					ctx,
+					tenantID,
+					applicationID,
+					returnedAddressID,
+					contract.Address{AddressDetails: createRandomAddressDetails()},
+					contract.AnyVersion, "")).To(BeNil())
+			}
+
+			close(updatesDone)
+
+			Expect(<-readErrors).To(BeNil())
+		})
 	})
 })
 
@@ -9,10 +9,12 @@ import (
 	"github.com/micro-business/Micro-Business-Core/system"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
 )
 
 var _ = Describe("Update method input parameters and dependency test", func() {
 	var (
+		ctx                context.Context
 		addressDataService *service.AddressDataService
 		tenantID           system.UUID
 		applicationID      system.UUID
@@ -21,6 +23,7 @@ var _ = Describe("Update method input parameters and dependency test", func() {
 	)
 
 	BeforeEach(func() {
+		ctx = context.Background()
 		addressDataService = &service.AddressDataService{ClusterConfig: &gocql.ClusterConfig{}}
 		tenantID, _ = system.RandomUUID()
 		applicationID, _ = system.RandomUUID()
@@ -32,7 +35,9 @@ var _ = Describe("Update method input parameters and dependency test", func() {
 		It("should panic", func() {
 			addressDataService.ClusterConfig = nil
 
-			Ω(func() { addressDataService.Update(tenantID, applicationID, addressID, validAddress) }).Should(Panic())
+			Ω(func() {
+				addressDataService.Update(ctx, tenantID, applicationID, addressID, validAddress, contract.AnyVersion, "")
+			}).Should(Panic())
 		})
 	})
 })
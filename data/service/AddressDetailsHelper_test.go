@@ -0,0 +1,20 @@
+package service_test
+
+import (
+	"math/rand"
+
+	"github.com/micro-business/Micro-Business-Core/system"
+)
+
+func createRandomAddressDetails() map[string]string {
+	details := make(map[string]string)
+
+	for idx := 0; idx < rand.Intn(10)+1; idx++ {
+		key, _ := system.RandomUUID()
+		value, _ := system.RandomUUID()
+
+		details[key.String()] = value.String()
+	}
+
+	return details
+}
@@ -0,0 +1,616 @@
+package service
+
+import (
+	"container/list"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/micro-business/AddressService/data/contract"
+	"github.com/micro-business/Micro-Business-Core/common/diagnostics"
+	"github.com/micro-business/Micro-Business-Core/system"
+	"golang.org/x/net/context"
+)
+
+// CachingAddressDataService decorates another address data service with an in-process read-through cache of the
+// addresses. ReadAll results are cached and Read is answered from the cached ReadAll result. Update, Patch, Delete,
+// Restore and Purge remove the changed address from the cache, PurgeTenant and PurgeApplication remove all the addresses
+// of the erased tenant or application, and the rest of the calls are passed through.
+// The cache is only aware of the changes made through the same instance, so the changes made by other processes are
+// seen once the cached address expires. The service must not be copied after first use.
+type CachingAddressDataService struct {
+	AddressDataService contract.AddressDataService
+
+	// Capacity is the maximum number of addresses kept in the cache. The least recently used address is removed once the
+	// cache is full.
+	Capacity int
+
+	// TTL is how long an address is kept in the cache. An address which expires on its own sooner is removed when it
+	// expires.
+	TTL time.Duration
+
+	lock sync.Mutex
+
+	// entries holds the elements of recentlyUsed keyed by getAddressKey.
+	entries map[string]*list.Element
+
+	// recentlyUsed holds the cached addresses ordered from the most recently used to the least recently used.
+	recentlyUsed *list.List
+
+	// invalidations is increased every time an address is removed from the cache, so an address read before the removal
+	// is not added to the cache after it.
+	invalidations uint64
+
+	hits   uint64
+	misses uint64
+}
+
+// cachedAddress is an address kept by CachingAddressDataService along with the time it is removed from the cache at.
+type cachedAddress struct {
+	key       string
+	address   contract.Address
+	cachedAt  time.Time
+	expiresAt time.Time
+}
+
+// Create creates a new address.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+// applicationID: Mandatory. The unique identifier of the tenant's application will be owning the address.
+// address: Mandatory. The reference to the new address information.
+// changedBy: Optional. Identifies who makes the change, recorded in the address history.
+// Returns either the unique identifier of the new address or error if something goes wrong.
+func (addressDataService *CachingAddressDataService) Create(
+	ctx context.Context,
+	tenantID, applicationID system.UUID,
+	address contract.Address,
+	changedBy string) (system.UUID, error) {
+	diagnostics.IsNotNil(addressDataService.AddressDataService, "addressDataService.AddressDataService", "AddressDataService must be provided.")
+
+	return addressDataService.AddressDataService.Create(ctx, tenantID, applicationID, address, changedBy)
+}
+
+// CreateMany creates several new addresses. Every address is created on its own, so failing to create an address does
+// not stop creating the rest of them.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the addresses.
+// applicationID: Mandatory. The unique identifier of the tenant's application will be owning the addresses.
+// addresses: Mandatory. The new addresses information.
+// changedBy: Optional. Identifies who makes the change, recorded in the address history.
+// Returns the result of creating every address in the same order as addresses.
+func (addressDataService *CachingAddressDataService) CreateMany(
+	ctx context.Context,
+	tenantID, applicationID system.UUID,
+	addresses []contract.Address,
+	changedBy string) []contract.AddressResult {
+	diagnostics.IsNotNil(addressDataService.AddressDataService, "addressDataService.AddressDataService", "AddressDataService must be provided.")
+
+	return addressDataService.AddressDataService.CreateMany(ctx, tenantID, applicationID, addresses, changedBy)
+}
+
+// Update updates an existing address and removes it from the cache.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+// applicationID: Mandatory. The unique identifier of the tenant's application will be owning the address.
+// addressID: Mandatory. The unique identifier of the existing address.
+// address: Mandatory. The reeference to the updated address information.
+// expectedVersion: Optional. The version the address is expected to have, or AnyVersion to skip the version check.
+// changedBy: Optional. Identifies who makes the change, recorded in the address history.
+// Returns ConflictError if the address version does not match the expected version, or error if something goes wrong.
+func (addressDataService *CachingAddressDataService) Update(
+	ctx context.Context,
+	tenantID, applicationID, addressID system.UUID,
+	address contract.Address,
+	expectedVersion int64,
+	changedBy string) error {
+	diagnostics.IsNotNil(addressDataService.AddressDataService, "addressDataService.AddressDataService", "AddressDataService must be provided.")
+
+	defer addressDataService.invalidate(tenantID, applicationID, addressID)
+
+	return addressDataService.AddressDataService.Update(ctx, tenantID, applicationID, addressID, address, expectedVersion, changedBy)
+}
+
+// Patch changes only the provided details of an existing address and leaves the rest of them unchanged, and removes the
+// address from the cache.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+// applicationID: Mandatory. The unique identifier of the tenant's application will be owning the address.
+// addressID: Mandatory. The unique identifier of the existing address.
+// set: Optional. The address details keys to add or change along with their new values.
+// remove: Optional. The address details keys to remove.
+// expectedVersion: Optional. The version the address is expected to have, or AnyVersion to skip the version check.
+// changedBy: Optional. Identifies who makes the change, recorded in the address history.
+// Returns ConflictError if the address version does not match the expected version, or error if something goes wrong.
+func (addressDataService *CachingAddressDataService) Patch(
+	ctx context.Context,
+	tenantID, applicationID, addressID system.UUID,
+	set map[string]string,
+	remove []string,
+	expectedVersion int64,
+	changedBy string) error {
+	diagnostics.IsNotNil(addressDataService.AddressDataService, "addressDataService.AddressDataService", "AddressDataService must be provided.")
+
+	defer addressDataService.invalidate(tenantID, applicationID, addressID)
+
+	return addressDataService.AddressDataService.Patch(ctx, tenantID, applicationID, addressID, set, remove, expectedVersion, changedBy)
+}
+
+// Read retrieves an existing address information and returns only the detail which the keys provided by the keys. The
+// details are taken from the cached address, which is read using ReadAll if it is not cached.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+// applicationID: Mandatory. The unique identifier of the tenant's application will be owning the address.
+// addressID: Mandatory. The unique identifier of the existing address.
+// keys: Mandatory. The interested address details keys to return.
+// Returns either the address information or error if something goes wrong.
+func (addressDataService *CachingAddressDataService) Read(ctx context.Context, tenantID, applicationID, addressID system.UUID, keys []string) (contract.Address, error) {
+	existingAddress, err := addressDataService.ReadAll(ctx, tenantID, applicationID, addressID)
+
+	if err != nil {
+		return contract.Address{}, err
+	}
+
+	address := contract.Address{AddressDetails: make(map[string]string), Version: existingAddress.Version, TTL: existingAddress.TTL}
+
+	for _, key := range keys {
+		if value, ok := existingAddress.AddressDetails[key]; ok {
+			address.AddressDetails[key] = value
+		}
+	}
+
+	if len(address.AddressDetails) == 0 {
+		return contract.Address{}, contract.NotFoundError{AddressID: addressID}
+	}
+
+	return address, nil
+}
+
+// ReadAll retrieves an existing address information and returns all the detail of it. The address is returned from the
+// cache, or it is read from the decorated address data service and added to the cache if it is not cached.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+// applicationID: Mandatory. The unique identifier of the tenant's application will be owning the address.
+// addressID: Mandatory. The unique identifier of the existing address.
+// Returns either the address information or error if something goes wrong.
+func (addressDataService *CachingAddressDataService) ReadAll(ctx context.Context, tenantID, applicationID, addressID system.UUID) (contract.Address, error) {
+	diagnostics.IsNotNil(addressDataService.AddressDataService, "addressDataService.AddressDataService", "AddressDataService must be provided.")
+
+	key := getAddressKey(tenantID, applicationID, addressID)
+	address, invalidations, ok := addressDataService.get(key)
+
+	if ok {
+		return address, nil
+	}
+
+	address, err := addressDataService.AddressDataService.ReadAll(ctx, tenantID, applicationID, addressID)
+
+	if err != nil {
+		return contract.Address{}, err
+	}
+
+	addressDataService.add(key, address, invalidations)
+
+	return address, nil
+}
+
+// ReadMany retrieves several existing addresses information. Every address is read on its own using the cache, so failing
+// to read an address does not stop reading the rest of them.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the addresses.
+// applicationID: Mandatory. The unique identifier of the tenant's application owning the addresses.
+// addressIDs: Mandatory. The unique identifiers of the existing addresses.
+// keys: Optional. The interested address details keys to return, or empty to return all the details.
+// Returns the result of reading every address in the same order as addressIDs.
+func (addressDataService *CachingAddressDataService) ReadMany(
+	ctx context.Context,
+	tenantID, applicationID system.UUID,
+	addressIDs []system.UUID,
+	keys []string) []contract.AddressResult {
+	return readAddresses(ctx, addressDataService, tenantID, applicationID, addressIDs, keys)
+}
+
+// List retrieves a page of the addresses owned by a tenant's application. The deleted addresses are not returned.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the addresses.
+// applicationID: Mandatory. The unique identifier of the tenant's application owning the addresses.
+// pageSize: Mandatory. The maximum number of addresses to return.
+// cursor: Optional. The cursor returned with the previous page, or empty to return the first page.
+// Returns either the page of addresses or error if the cursor is invalid or something goes wrong.
+func (addressDataService *CachingAddressDataService) List(ctx context.Context, tenantID, applicationID system.UUID, pageSize int, cursor string) (contract.AddressPage, error) {
+	diagnostics.IsNotNil(addressDataService.AddressDataService, "addressDataService.AddressDataService", "AddressDataService must be provided.")
+
+	return addressDataService.AddressDataService.List(ctx, tenantID, applicationID, pageSize, cursor)
+}
+
+// FindByDetail retrieves the addresses owned by a tenant's application whose details match all the provided criteria.
+// The deleted addresses are not returned, and no more than MaxFoundAddresses addresses are returned.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the addresses.
+// applicationID: Mandatory. The unique identifier of the tenant's application owning the addresses.
+// criteria: Mandatory. The address details keys along with the values the returned addresses must have.
+// Returns either the matching addresses or error if something goes wrong.
+func (addressDataService *CachingAddressDataService) FindByDetail(
+	ctx context.Context,
+	tenantID, applicationID system.UUID,
+	criteria map[string]string) ([]contract.ListedAddress, error) {
+	diagnostics.IsNotNil(addressDataService.AddressDataService, "addressDataService.AddressDataService", "AddressDataService must be provided.")
+
+	return addressDataService.AddressDataService.FindByDetail(ctx, tenantID, applicationID, criteria)
+}
+
+// FindByFingerprint retrieves the addresses owned by a tenant's application which were created or last updated with the
+// provided fingerprint. The deleted addresses are not returned.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the addresses.
+// applicationID: Mandatory. The unique identifier of the tenant's application owning the addresses.
+// fingerprint: Mandatory. The fingerprint the returned addresses were created or last updated with.
+// Returns either the matching addresses or error if something goes wrong.
+func (addressDataService *CachingAddressDataService) FindByFingerprint(
+	ctx context.Context,
+	tenantID, applicationID system.UUID,
+	fingerprint string) ([]contract.ListedAddress, error) {
+	diagnostics.IsNotNil(addressDataService.AddressDataService, "addressDataService.AddressDataService", "AddressDataService must be provided.")
+
+	return addressDataService.AddressDataService.FindByFingerprint(ctx, tenantID, applicationID, fingerprint)
+}
+
+// AddOwners links an existing address to the provided owners. The owners are not cached, so the cached address is kept.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+// applicationID: Mandatory. The unique identifier of the tenant's application owning the address.
+// addressID: Mandatory. The unique identifier of the existing address.
+// owners: Mandatory. The owners to link the address to.
+// Returns error if the address does not exist, or if something goes wrong.
+func (addressDataService *CachingAddressDataService) AddOwners(
+	ctx context.Context,
+	tenantID, applicationID, addressID system.UUID,
+	owners []contract.AddressOwner) error {
+	diagnostics.IsNotNil(addressDataService.AddressDataService, "addressDataService.AddressDataService", "AddressDataService must be provided.")
+
+	return addressDataService.AddressDataService.AddOwners(ctx, tenantID, applicationID, addressID, owners)
+}
+
+// RemoveOwners unlinks an existing address from the provided owners. The owners are not cached, so the cached address is
+// kept.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+// applicationID: Mandatory. The unique identifier of the tenant's application owning the address.
+// addressID: Mandatory. The unique identifier of the existing address.
+// owners: Mandatory. The owners to unlink the address from.
+// Returns error if the address does not exist, or if something goes wrong.
+func (addressDataService *CachingAddressDataService) RemoveOwners(
+	ctx context.Context,
+	tenantID, applicationID, addressID system.UUID,
+	owners []contract.AddressOwner) error {
+	diagnostics.IsNotNil(addressDataService.AddressDataService, "addressDataService.AddressDataService", "AddressDataService must be provided.")
+
+	return addressDataService.AddressDataService.RemoveOwners(ctx, tenantID, applicationID, addressID, owners)
+}
+
+// ReadOwners retrieves the owners an existing address is linked to.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+// applicationID: Mandatory. The unique identifier of the tenant's application owning the address.
+// addressID: Mandatory. The unique identifier of the existing address.
+// Returns either the owners ordered by their type and identifier or error if the address does not exist or something
+// goes wrong.
+func (addressDataService *CachingAddressDataService) ReadOwners(ctx context.Context, tenantID, applicationID, addressID system.UUID) ([]contract.AddressOwner, error) {
+	diagnostics.IsNotNil(addressDataService.AddressDataService, "addressDataService.AddressDataService", "AddressDataService must be provided.")
+
+	return addressDataService.AddressDataService.ReadOwners(ctx, tenantID, applicationID, addressID)
+}
+
+// FindByOwner retrieves the addresses owned by a tenant's application which are linked to the provided owner. The
+// deleted addresses are not returned.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the addresses.
+// applicationID: Mandatory. The unique identifier of the tenant's application owning the addresses.
+// owner: Mandatory. The owner the returned addresses are linked to.
+// Returns either the linked addresses ordered by their unique identifier or error if something goes wrong.
+func (addressDataService *CachingAddressDataService) FindByOwner(
+	ctx context.Context,
+	tenantID, applicationID system.UUID,
+	owner contract.AddressOwner) ([]contract.ListedAddress, error) {
+	diagnostics.IsNotNil(addressDataService.AddressDataService, "addressDataService.AddressDataService", "AddressDataService must be provided.")
+
+	return addressDataService.AddressDataService.FindByOwner(ctx, tenantID, applicationID, owner)
+}
+
+// History retrieves all the recorded versions of an address, including the versions recorded when it was deleted and
+// restored.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+// applicationID: Mandatory. The unique identifier of the tenant's application will be owning the address.
+// addressID: Mandatory. The unique identifier of the address.
+// Returns either the recorded versions ordered from the oldest to the newest or error if no version is recorded or
+// something goes wrong.
+func (addressDataService *CachingAddressDataService) History(ctx context.Context, tenantID, applicationID, addressID system.UUID) ([]contract.AddressHistoryEntry, error) {
+	diagnostics.IsNotNil(addressDataService.AddressDataService, "addressDataService.AddressDataService", "AddressDataService must be provided.")
+
+	return addressDataService.AddressDataService.History(ctx, tenantID, applicationID, addressID)
+}
+
+// ReadAt retrieves the address information as it was at the provided time.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+// applicationID: Mandatory. The unique identifier of the tenant's application will be owning the address.
+// addressID: Mandatory. The unique identifier of the address.
+// timestamp: Mandatory. The time to return the address information at.
+// Returns either the address information or error if the address did not exist or was deleted at the provided time, or
+// if something goes wrong.
+func (addressDataService *CachingAddressDataService) ReadAt(ctx context.Context, tenantID, applicationID, addressID system.UUID, timestamp time.Time) (contract.Address, error) {
+	diagnostics.IsNotNil(addressDataService.AddressDataService, "addressDataService.AddressDataService", "AddressDataService must be provided.")
+
+	return addressDataService.AddressDataService.ReadAt(ctx, tenantID, applicationID, addressID, timestamp)
+}
+
+// Delete marks an existing address as deleted and removes it from the cache.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+// applicationID: Mandatory. The unique identifier of the tenant's application will be owning the address.
+// addressID: Mandatory. The unique identifier of the existing address to remove.
+// expectedVersion: Optional. The version the address is expected to have, or AnyVersion to skip the version check.
+// changedBy: Optional. Identifies who makes the change, recorded in the address history.
+// Returns ConflictError if the address version does not match the expected version, or error if something goes wrong.
+func (addressDataService *CachingAddressDataService) Delete(
+	ctx context.Context,
+	tenantID, applicationID, addressID system.UUID,
+	expectedVersion int64,
+	changedBy string) error {
+	diagnostics.IsNotNil(addressDataService.AddressDataService, "addressDataService.AddressDataService", "AddressDataService must be provided.")
+
+	defer addressDataService.invalidate(tenantID, applicationID, addressID)
+
+	return addressDataService.AddressDataService.Delete(ctx, tenantID, applicationID, addressID, expectedVersion, changedBy)
+}
+
+// DeleteMany marks several existing addresses as deleted, skipping the version check, and removes them from the cache.
+// Every address is deleted on its own, so failing to delete an address does not stop deleting the rest of them.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the addresses.
+// applicationID: Mandatory. The unique identifier of the tenant's application owning the addresses.
+// addressIDs: Mandatory. The unique identifiers of the existing addresses to remove.
+// changedBy: Optional. Identifies who makes the change, recorded in the address history.
+// Returns the result of deleting every address in the same order as addressIDs.
+func (addressDataService *CachingAddressDataService) DeleteMany(
+	ctx context.Context,
+	tenantID, applicationID system.UUID,
+	addressIDs []system.UUID,
+	changedBy string) []contract.AddressResult {
+	return deleteAddresses(ctx, addressDataService, tenantID, applicationID, addressIDs, changedBy)
+}
+
+// Restore restores an address deleted since the provided time and removes it from the cache.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+// applicationID: Mandatory. The unique identifier of the tenant's application will be owning the address.
+// addressID: Mandatory. The unique identifier of the deleted address to restore.
+// deletedSince: Mandatory. The address can only be restored if it was deleted at or after this time.
+// changedBy: Optional. Identifies who makes the change, recorded in the address history.
+// Returns error if the deleted address does not exist or was deleted before deletedSince, or if something goes wrong.
+func (addressDataService *CachingAddressDataService) Restore(
+	ctx context.Context,
+	tenantID, applicationID, addressID system.UUID,
+	deletedSince time.Time,
+	changedBy string) error {
+	diagnostics.IsNotNil(addressDataService.AddressDataService, "addressDataService.AddressDataService", "AddressDataService must be provided.")
+
+	defer addressDataService.invalidate(tenantID, applicationID, addressID)
+
+	return addressDataService.AddressDataService.Restore(ctx, tenantID, applicationID, addressID, deletedSince, changedBy)
+}
+
+// Purge removes a deleted address for good and removes it from the cache.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+// applicationID: Mandatory. The unique identifier of the tenant's application will be owning the address.
+// addressID: Mandatory. The unique identifier of the deleted address to purge.
+// Returns error if the deleted address does not exist, or if something goes wrong.
+func (addressDataService *CachingAddressDataService) Purge(ctx context.Context, tenantID, applicationID, addressID system.UUID) error {
+	diagnostics.IsNotNil(addressDataService.AddressDataService, "addressDataService.AddressDataService", "AddressDataService must be provided.")
+
+	defer addressDataService.invalidate(tenantID, applicationID, addressID)
+
+	return addressDataService.AddressDataService.Purge(ctx, tenantID, applicationID, addressID)
+}
+
+// PurgeDeleted removes for good all the addresses deleted before the provided time. The deleted addresses are not cached,
+// so the cache is left unchanged.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// deletedBefore: Mandatory. The addresses deleted before this time are purged.
+// Returns error if something goes wrong.
+func (addressDataService *CachingAddressDataService) PurgeDeleted(ctx context.Context, deletedBefore time.Time) error {
+	diagnostics.IsNotNil(addressDataService.AddressDataService, "addressDataService.AddressDataService", "AddressDataService must be provided.")
+
+	return addressDataService.AddressDataService.PurgeDeleted(ctx, deletedBefore)
+}
+
+// PurgeTenant removes for good all the addresses of all the applications of a tenant along with their history, whether
+// they are deleted or not, and records an erasure certificate. All the cached addresses of the tenant are removed from
+// the cache.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant to erase.
+// requestedBy: Optional. Identifies who requests the erasure, recorded in the erasure certificate.
+// Returns either the erasure certificate or error if something goes wrong.
+func (addressDataService *CachingAddressDataService) PurgeTenant(ctx context.Context, tenantID system.UUID, requestedBy string) (contract.ErasureCertificate, error) {
+	diagnostics.IsNotNil(addressDataService.AddressDataService, "addressDataService.AddressDataService", "AddressDataService must be provided.")
+
+	defer addressDataService.invalidatePrefix(tenantID.String() + "/")
+
+	return addressDataService.AddressDataService.PurgeTenant(ctx, tenantID, requestedBy)
+}
+
+// PurgeApplication removes for good all the addresses of a tenant's application along with their history, whether they
+// are deleted or not, and records an erasure certificate. All the cached addresses of the application are removed from
+// the cache.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the application.
+// applicationID: Mandatory. The unique identifier of the tenant's application to erase.
+// requestedBy: Optional. Identifies who requests the erasure, recorded in the erasure certificate.
+// Returns either the erasure certificate or error if something goes wrong.
+func (addressDataService *CachingAddressDataService) PurgeApplication(
+	ctx context.Context,
+	tenantID, applicationID system.UUID,
+	requestedBy string) (contract.ErasureCertificate, error) {
+	diagnostics.IsNotNil(addressDataService.AddressDataService, "addressDataService.AddressDataService", "AddressDataService must be provided.")
+
+	defer addressDataService.invalidatePrefix(tenantID.String() + "/" + applicationID.String() + "/")
+
+	return addressDataService.AddressDataService.PurgeApplication(ctx, tenantID, applicationID, requestedBy)
+}
+
+// ReadEvents retrieves the events waiting in the outbox to be published. The events are not cached.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// limit: Mandatory. The maximum number of events to return.
+// Returns either the events or error if something goes wrong.
+func (addressDataService *CachingAddressDataService) ReadEvents(ctx context.Context, limit int) ([]contract.AddressEvent, error) {
+	diagnostics.IsNotNil(addressDataService.AddressDataService, "addressDataService.AddressDataService", "AddressDataService must be provided.")
+
+	return addressDataService.AddressDataService.ReadEvents(ctx, limit)
+}
+
+// RemoveEvents removes the published events from the outbox.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// events: Mandatory. The events returned by ReadEvents which are published.
+// Returns error if something goes wrong.
+func (addressDataService *CachingAddressDataService) RemoveEvents(ctx context.Context, events []contract.AddressEvent) error {
+	diagnostics.IsNotNil(addressDataService.AddressDataService, "addressDataService.AddressDataService", "AddressDataService must be provided.")
+
+	return addressDataService.AddressDataService.RemoveEvents(ctx, events)
+}
+
+// Close closes the decorated address data service if it needs closing.
+// Returns error if closing the decorated address data service fails.
+func (addressDataService *CachingAddressDataService) Close() error {
+	if closer, ok := addressDataService.AddressDataService.(io.Closer); ok {
+		return closer.Close()
+	}
+
+	return nil
+}
+
+// Hits returns the number of addresses read from the cache.
+func (addressDataService *CachingAddressDataService) Hits() uint64 {
+	addressDataService.lock.Lock()
+	defer addressDataService.lock.Unlock()
+
+	return addressDataService.hits
+}
+
+// Misses returns the number of addresses read from the decorated address data service because they were not cached.
+func (addressDataService *CachingAddressDataService) Misses() uint64 {
+	addressDataService.lock.Lock()
+	defer addressDataService.lock.Unlock()
+
+	return addressDataService.misses
+}
+
+// get returns a copy of the cached address with its remaining TTL, marking it as the most recently used one. An expired
+// address is removed from the cache.
+// Returns the number of invalidations so far along with whether the address is cached.
+func (addressDataService *CachingAddressDataService) get(key string) (contract.Address, uint64, bool) {
+	addressDataService.lock.Lock()
+	defer addressDataService.lock.Unlock()
+
+	if element, ok := addressDataService.entries[key]; ok {
+		entry := element.Value.(*cachedAddress)
+		now := time.Now()
+
+		if now.Before(entry.expiresAt) {
+			addressDataService.recentlyUsed.MoveToFront(element)
+			addressDataService.hits++
+
+			address := contract.Address{
+				AddressDetails: copyAddressDetails(entry.address.AddressDetails),
+				Version:        entry.address.Version,
+			}
+
+			if entry.address.TTL != 0 {
+				address.TTL = entry.address.TTL - now.Sub(entry.cachedAt)
+			}
+
+			return address, addressDataService.invalidations, true
+		}
+
+		addressDataService.remove(element)
+	}
+
+	addressDataService.misses++
+
+	return contract.Address{}, addressDataService.invalidations, false
+}
+
+// add adds a copy of the provided address to the cache unless an address was removed from the cache since the provided
+// number of invalidations was returned by get, removing the least recently used address if the cache is full.
+func (addressDataService *CachingAddressDataService) add(key string, address contract.Address, invalidations uint64) {
+	if addressDataService.Capacity <= 0 || addressDataService.TTL <= 0 {
+		return
+	}
+
+	addressDataService.lock.Lock()
+	defer addressDataService.lock.Unlock()
+
+	if invalidations != addressDataService.invalidations {
+		return
+	}
+
+	if addressDataService.entries == nil {
+		addressDataService.entries = make(map[string]*list.Element)
+		addressDataService.recentlyUsed = list.New()
+	}
+
+	if element, ok := addressDataService.entries[key]; ok {
+		addressDataService.remove(element)
+	}
+
+	now := time.Now()
+	entry := &cachedAddress{
+		key:       key,
+		address:   contract.Address{AddressDetails: copyAddressDetails(address.AddressDetails), Version: address.Version, TTL: address.TTL},
+		cachedAt:  now,
+		expiresAt: now.Add(addressDataService.TTL),
+	}
+
+	if address.TTL != 0 && address.TTL < addressDataService.TTL {
+		entry.expiresAt = now.Add(address.TTL)
+	}
+
+	addressDataService.entries[key] = addressDataService.recentlyUsed.PushFront(entry)
+
+	for addressDataService.recentlyUsed.Len() > addressDataService.Capacity {
+		addressDataService.remove(addressDataService.recentlyUsed.Back())
+	}
+}
+
+// invalidate removes the provided address from the cache.
+func (addressDataService *CachingAddressDataService) invalidate(tenantID, applicationID, addressID system.UUID) {
+	addressDataService.lock.Lock()
+	defer addressDataService.lock.Unlock()
+
+	addressDataService.invalidations++
+
+	if element, ok := addressDataService.entries[getAddressKey(tenantID, applicationID, addressID)]; ok {
+		addressDataService.remove(element)
+	}
+}
+
+// invalidatePrefix removes all the addresses whose key starts with the provided prefix from the cache.
+func (addressDataService *CachingAddressDataService) invalidatePrefix(prefix string) {
+	addressDataService.lock.Lock()
+	defer addressDataService.lock.Unlock()
+
+	addressDataService.invalidations++
+
+	for key, element := range addressDataService.entries {
+		if strings.HasPrefix(key, prefix) {
+			addressDataService.remove(element)
+		}
+	}
+}
+
+// remove removes the provided element from the cache. The lock must be held by the caller.
+func (addressDataService *CachingAddressDataService) remove(element *list.Element) {
+	addressDataService.recentlyUsed.Remove(element)
+	delete(addressDataService.entries, element.Value.(*cachedAddress).key)
+}
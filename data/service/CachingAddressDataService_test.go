@@ -0,0 +1,226 @@
+package service_test
+
+import (
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/micro-business/AddressService/data/contract"
+	"github.com/micro-business/AddressService/data/service"
+	"github.com/micro-business/Micro-Business-Core/system"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("Caching address data service behaviour", func() {
+	var (
+		ctx                      context.Context
+		mockCtrl                 *gomock.Controller
+		addressDataService       *service.CachingAddressDataService
+		backendDataService       *service.InMemoryAddressDataService
+		mockUUIDGeneratorService *MockUUIDGeneratorService
+		tenantID                 system.UUID
+		applicationID            system.UUID
+		addressID                system.UUID
+		validAddress             contract.Address
+	)
+
+	createAddress := func(address contract.Address) system.UUID {
+		newAddressID, _ := system.RandomUUID()
+		mockUUIDGeneratorService.
+			EXPECT().
+			GenerateRandomUUID().
+			Return(newAddressID, nil)
+
+		_, err := addressDataService.Create(ctx, tenantID, applicationID, address, "")
+		Expect(err).To(BeNil())
+
+		return newAddressID
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockUUIDGeneratorService = NewMockUUIDGeneratorService(mockCtrl)
+
+		backendDataService = &service.InMemoryAddressDataService{UUIDGeneratorService: mockUUIDGeneratorService}
+		addressDataService = &service.CachingAddressDataService{AddressDataService: backendDataService, Capacity: 10, TTL: time.Minute}
+
+		tenantID, _ = system.RandomUUID()
+		applicationID, _ = system.RandomUUID()
+		validAddress = contract.Address{AddressDetails: map[string]string{"City": "Christchurch", "Postcode": "8011"}}
+		addressID = createAddress(validAddress)
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	Context("when address data service not provided", func() {
+		It("should panic", func() {
+			addressDataService.AddressDataService = nil
+
+			Ω(func() { addressDataService.ReadAll(ctx, tenantID, applicationID, addressID) }).Should(Panic())
+		})
+	})
+
+	Context("when reading the same address several times", func() {
+		It("should read the address from the address data service only once", func() {
+			for i := 0; i < 3; i++ {
+				address, err := addressDataService.ReadAll(ctx, tenantID, applicationID, addressID)
+
+				Expect(err).To(BeNil())
+				Expect(address.AddressDetails).To(Equal(validAddress.AddressDetails))
+			}
+
+			Expect(addressDataService.Misses()).To(Equal(uint64(1)))
+			Expect(addressDataService.Hits()).To(Equal(uint64(2)))
+		})
+
+		It("should not be affected by changing the returned address details", func() {
+			address, _ := addressDataService.ReadAll(ctx, tenantID, applicationID, addressID)
+			address.AddressDetails["City"] = "Auckland"
+
+			address, err := addressDataService.ReadAll(ctx, tenantID, applicationID, addressID)
+
+			Expect(err).To(BeNil())
+			Expect(address.AddressDetails["City"]).To(Equal("Christchurch"))
+		})
+	})
+
+	Context("when reading a subset of the address details", func() {
+		It("should answer from the cached address", func() {
+			addressDataService.ReadAll(ctx, tenantID, applicationID, addressID)
+
+			address, err := addressDataService.Read(ctx, tenantID, applicationID, addressID, []string{"City", "Unknown"})
+
+			Expect(err).To(BeNil())
+			Expect(address.AddressDetails).To(Equal(map[string]string{"City": "Christchurch"}))
+			Expect(addressDataService.Hits()).To(Equal(uint64(1)))
+		})
+
+		It("should return NotFoundError if none of the keys exist", func() {
+			_, err := addressDataService.Read(ctx, tenantID, applicationID, addressID, []string{"Unknown"})
+
+			Expect(err).To(Equal(contract.NotFoundError{AddressID: addressID}))
+		})
+	})
+
+	Context("when reading an address that does not exist", func() {
+		It("should return the error and not cache it", func() {
+			unknownAddressID, _ := system.RandomUUID()
+
+			_, err := addressDataService.ReadAll(ctx, tenantID, applicationID, unknownAddressID)
+			Expect(err).To(BeAssignableToTypeOf(contract.NotFoundError{}))
+
+			_, err = addressDataService.ReadAll(ctx, tenantID, applicationID, unknownAddressID)
+			Expect(err).To(BeAssignableToTypeOf(contract.NotFoundError{}))
+
+			Expect(addressDataService.Misses()).To(Equal(uint64(2)))
+		})
+	})
+
+	Context("when changing a cached address", func() {
+		BeforeEach(func() {
+			addressDataService.ReadAll(ctx, tenantID, applicationID, addressID)
+		})
+
+		It("should return the updated address", func() {
+			updatedAddress := contract.Address{AddressDetails: map[string]string{"City": "Wellington"}}
+
+			Expect(addressDataService.Update(ctx, tenantID, applicationID, addressID, updatedAddress, contract.AnyVersion, "")).To(BeNil())
+
+			address, err := addressDataService.ReadAll(ctx, tenantID, applicationID, addressID)
+
+			Expect(err).To(BeNil())
+			Expect(address.AddressDetails).To(Equal(updatedAddress.AddressDetails))
+		})
+
+		It("should return the patched address", func() {
+			Expect(addressDataService.Patch(ctx, tenantID, applicationID, addressID, map[string]string{"City": "Wellington"}, nil, contract.AnyVersion, "")).To(BeNil())
+
+			address, err := addressDataService.Read(ctx, tenantID, applicationID, addressID, []string{"City"})
+
+			Expect(err).To(BeNil())
+			Expect(address.AddressDetails).To(Equal(map[string]string{"City": "Wellington"}))
+		})
+
+		It("should not return the deleted address", func() {
+			Expect(addressDataService.Delete(ctx, tenantID, applicationID, addressID, contract.AnyVersion, "")).To(BeNil())
+
+			_, err := addressDataService.ReadAll(ctx, tenantID, applicationID, addressID)
+
+			Expect(err).To(BeAssignableToTypeOf(contract.NotFoundError{}))
+		})
+
+		It("should not return the addresses deleted together", func() {
+			results := addressDataService.DeleteMany(ctx, tenantID, applicationID, []system.UUID{addressID}, "")
+
+			Expect(results[0].Err).To(BeNil())
+
+			_, err := addressDataService.ReadAll(ctx, tenantID, applicationID, addressID)
+
+			Expect(err).To(BeAssignableToTypeOf(contract.NotFoundError{}))
+		})
+
+		It("should not return the addresses of the erased tenant or application", func() {
+			certificateID, _ := system.RandomUUID()
+			mockUUIDGeneratorService.
+				EXPECT().
+				GenerateRandomUUID().
+				Return(certificateID, nil).
+				Times(2)
+
+			_, err := addressDataService.PurgeApplication(ctx, tenantID, applicationID, "")
+
+			Expect(err).To(BeNil())
+
+			_, err = addressDataService.ReadAll(ctx, tenantID, applicationID, addressID)
+
+			Expect(err).To(BeAssignableToTypeOf(contract.NotFoundError{}))
+
+			addressID = createAddress(validAddress)
+			addressDataService.ReadAll(ctx, tenantID, applicationID, addressID)
+
+			_, err = addressDataService.PurgeTenant(ctx, tenantID, "")
+
+			Expect(err).To(BeNil())
+
+			_, err = addressDataService.ReadAll(ctx, tenantID, applicationID, addressID)
+
+			Expect(err).To(BeAssignableToTypeOf(contract.NotFoundError{}))
+		})
+	})
+
+	Context("when the cached address expires", func() {
+		It("should read the address from the address data service again", func() {
+			addressDataService.TTL = 10 * time.Millisecond
+
+			addressDataService.ReadAll(ctx, tenantID, applicationID, addressID)
+			time.Sleep(20 * time.Millisecond)
+			addressDataService.ReadAll(ctx, tenantID, applicationID, addressID)
+
+			Expect(addressDataService.Misses()).To(Equal(uint64(2)))
+			Expect(addressDataService.Hits()).To(Equal(uint64(0)))
+		})
+	})
+
+	Context("when the cache is full", func() {
+		It("should remove the least recently used address", func() {
+			addressDataService.Capacity = 2
+			secondAddressID := createAddress(validAddress)
+			thirdAddressID := createAddress(validAddress)
+
+			addressDataService.ReadAll(ctx, tenantID, applicationID, addressID)
+			addressDataService.ReadAll(ctx, tenantID, applicationID, secondAddressID)
+			addressDataService.ReadAll(ctx, tenantID, applicationID, addressID)
+			addressDataService.ReadAll(ctx, tenantID, applicationID, thirdAddressID)
+
+			addressDataService.ReadAll(ctx, tenantID, applicationID, addressID)
+			Expect(addressDataService.Hits()).To(Equal(uint64(2)))
+
+			addressDataService.ReadAll(ctx, tenantID, applicationID, secondAddressID)
+			Expect(addressDataService.Misses()).To(Equal(uint64(4)))
+		})
+	})
+})
@@ -0,0 +1,1153 @@
+package service
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/micro-business/AddressService/data/contract"
+	"github.com/micro-business/Micro-Business-Core/common/diagnostics"
+	"github.com/micro-business/Micro-Business-Core/system"
+	"golang.org/x/net/context"
+)
+
+// InMemoryAddressDataService provides access to add new address and update/retrieve/remove an existing address, keeping all
+// the addresses in the process memory. It is meant to be used for local development and tests where Cassandra is not available.
+type InMemoryAddressDataService struct {
+	UUIDGeneratorService system.UUIDGeneratorService
+
+	// RecordEvents is true to add an event to the outbox along with every address creation, update and deletion, so the
+	// events can be published by the event relay.
+	RecordEvents bool
+
+	lock sync.RWMutex
+
+	// addresses holds addresses keyed by tenant, application and address unique identifiers.
+	addresses map[string]map[string]map[string]contract.Address
+
+	// deletedAddresses holds the deleted addresses until they are purged, keyed by getAddressKey.
+	deletedAddresses map[string]inMemoryDeletedAddress
+
+	// history holds the recorded versions of the addresses ordered from the oldest to the newest, keyed by getAddressKey.
+	history map[string][]contract.AddressHistoryEntry
+
+	// expiresAt holds the time the addresses created or updated with a TTL expire at, keyed by getAddressKey.
+	expiresAt map[string]time.Time
+
+	// owners holds the owners the addresses are linked to ordered by their type and identifier, keyed by getAddressKey.
+	owners map[string][]contract.AddressOwner
+
+	// erasureCertificates holds the erasure certificates recorded by PurgeTenant and PurgeApplication.
+	erasureCertificates []contract.ErasureCertificate
+
+	// events holds the outbox, the events waiting to be published ordered from the oldest to the newest.
+	events []contract.AddressEvent
+}
+
+// inMemoryDeletedAddress is a deleted address kept by InMemoryAddressDataService along with the time it was deleted at.
+type inMemoryDeletedAddress struct {
+	address   contract.Address
+	deletedAt time.Time
+}
+
+// Create creates a new address.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+// applicationID: Mandatory. The unique identifier of the tenant's application will be owning the address.
+// address: Mandatory. The reference to the new address information.
+// changedBy: Optional. Identifies who makes the change, recorded in the address history.
+// Returns either the unique identifier of the new address or error if something goes wrong.
+func (addressDataService *InMemoryAddressDataService) Create(
+	ctx context.Context,
+	tenantID, applicationID system.UUID,
+	address contract.Address,
+	changedBy string) (system.UUID, error) {
+	diagnostics.IsNotNil(addressDataService.UUIDGeneratorService, "addressDataService.UUIDGeneratorService", "UUIDGeneratorService must be provided.")
+
+	addressID, err := addressDataService.UUIDGeneratorService.GenerateRandomUUID()
+
+	if err != nil {
+		return system.EmptyUUID, err
+	}
+
+	eventID, err := addressDataService.newEventID()
+
+	if err != nil {
+		return system.EmptyUUID, err
+	}
+
+	addressDataService.lock.Lock()
+	defer addressDataService.lock.Unlock()
+
+	newAddress := contract.Address{AddressDetails: copyAddressDetails(address.AddressDetails), Version: 1, Fingerprint: address.Fingerprint}
+
+	addressDataService.getApplicationAddresses(tenantID, applicationID, true)[addressID.String()] = newAddress
+	addressDataService.setExpiry(tenantID, applicationID, addressID, address.TTL)
+	addressDataService.recordHistory(tenantID, applicationID, addressID, newAddress, changedBy, false)
+	addressDataService.recordEvent(eventID, contract.AddressCreated, tenantID, applicationID, addressID, 1, nil, newAddress.AddressDetails, changedBy)
+
+	return addressID, nil
+}
+
+// CreateMany creates several new addresses. Every address is created on its own, so failing to create an address does
+// not stop creating the rest of them.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the addresses.
+// applicationID: Mandatory. The unique identifier of the tenant's application will be owning the addresses.
+// addresses: Mandatory. The new addresses information.
+// changedBy: Optional. Identifies who makes the change, recorded in the address history.
+// Returns the result of creating every address in the same order as addresses.
+func (addressDataService *InMemoryAddressDataService) CreateMany(
+	ctx context.Context,
+	tenantID, applicationID system.UUID,
+	addresses []contract.Address,
+	changedBy string) []contract.AddressResult {
+	return createAddresses(ctx, addressDataService, tenantID, applicationID, addresses, changedBy)
+}
+
+// Update updates an existing address.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+// applicationID: Mandatory. The unique identifier of the tenant's application will be owning the address.
+// addressID: Mandatory. The unique identifier of the existing address.
+// address: Mandatory. The reeference to the updated address information.
+// expectedVersion: Optional. The version the address is expected to have, or AnyVersion to skip the version check.
+// changedBy: Optional. Identifies who makes the change, recorded in the address history.
+// Returns ConflictError if the address version does not match the expected version, or error if something goes wrong.
+func (addressDataService *InMemoryAddressDataService) Update(
+	ctx context.Context,
+	tenantID, applicationID, addressID system.UUID,
+	address contract.Address,
+	expectedVersion int64,
+	changedBy string) error {
+	eventID, err := addressDataService.newEventID()
+
+	if err != nil {
+		return err
+	}
+
+	addressDataService.lock.Lock()
+	defer addressDataService.lock.Unlock()
+
+	applicationAddresses := addressDataService.getApplicationAddresses(tenantID, applicationID, false)
+	existingAddress, ok := addressDataService.getExistingAddress(tenantID, applicationID, addressID)
+
+	if !ok {
+		return contract.NotFoundError{AddressID: addressID}
+	}
+
+	if expectedVersion != contract.AnyVersion && expectedVersion != existingAddress.Version {
+		return contract.ConflictError{AddressID: addressID, ExpectedVersion: expectedVersion, ActualVersion: existingAddress.Version}
+	}
+
+	updatedAddress := contract.Address{
+		AddressDetails: copyAddressDetails(address.AddressDetails),
+		Version:        existingAddress.Version + 1,
+		Fingerprint:    address.Fingerprint,
+	}
+
+	applicationAddresses[addressID.String()] = updatedAddress
+	addressDataService.setExpiry(tenantID, applicationID, addressID, address.TTL)
+	addressDataService.recordHistory(tenantID, applicationID, addressID, updatedAddress, changedBy, false)
+	addressDataService.recordEvent(
+		eventID,
+		contract.AddressUpdated,
+		tenantID,
+		applicationID,
+		addressID,
+		updatedAddress.Version,
+		existingAddress.AddressDetails,
+		updatedAddress.AddressDetails,
+		changedBy)
+
+	return nil
+}
+
+// Patch changes only the provided details of an existing address and leaves the rest of them unchanged.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+// applicationID: Mandatory. The unique identifier of the tenant's application will be owning the address.
+// addressID: Mandatory. The unique identifier of the existing address.
+// set: Optional. The address details keys to add or change along with their new values.
+// remove: Optional. The address details keys to remove.
+// expectedVersion: Optional. The version the address is expected to have, or AnyVersion to skip the version check.
+// changedBy: Optional. Identifies who makes the change, recorded in the address history.
+// Returns ConflictError if the address version does not match the expected version, or error if something goes wrong.
+func (addressDataService *InMemoryAddressDataService) Patch(
+	ctx context.Context,
+	tenantID, applicationID, addressID system.UUID,
+	set map[string]string,
+	remove []string,
+	expectedVersion int64,
+	changedBy string) error {
+	eventID, err := addressDataService.newEventID()
+
+	if err != nil {
+		return err
+	}
+
+	addressDataService.lock.Lock()
+	defer addressDataService.lock.Unlock()
+
+	applicationAddresses := addressDataService.getApplicationAddresses(tenantID, applicationID, false)
+	existingAddress, ok := addressDataService.getExistingAddress(tenantID, applicationID, addressID)
+
+	if !ok {
+		return contract.NotFoundError{AddressID: addressID}
+	}
+
+	if expectedVersion != contract.AnyVersion && expectedVersion != existingAddress.Version {
+		return contract.ConflictError{AddressID: addressID, ExpectedVersion: expectedVersion, ActualVersion: existingAddress.Version}
+	}
+
+	patchedAddress, err := patchAddressDetails(existingAddress, set, remove, addressID)
+
+	if err != nil {
+		return err
+	}
+
+	patchedAddress.Version = existingAddress.Version + 1
+	patchedAddress.Fingerprint = existingAddress.Fingerprint
+	applicationAddresses[addressID.String()] = patchedAddress
+	addressDataService.recordHistory(tenantID, applicationID, addressID, patchedAddress, changedBy, false)
+	addressDataService.recordEvent(
+		eventID,
+		contract.AddressUpdated,
+		tenantID,
+		applicationID,
+		addressID,
+		patchedAddress.Version,
+		existingAddress.AddressDetails,
+		patchedAddress.AddressDetails,
+		changedBy)
+
+	return nil
+}
+
+// Read retrieves an existing address information and returns only the detail which the keys provided by the keys.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+// applicationID: Mandatory. The unique identifier of the tenant's application will be owning the address.
+// addressID: Mandatory. The unique identifier of the existing address.
+// keys: Mandatory. The interested address details keys to return.
+// Returns either the address information or error if something goes wrong.
+func (addressDataService *InMemoryAddressDataService) Read(ctx context.Context, tenantID, applicationID, addressID system.UUID, keys []string) (contract.Address, error) {
+	addressDataService.lock.RLock()
+	defer addressDataService.lock.RUnlock()
+
+	existingAddress, _ := addressDataService.getExistingAddress(tenantID, applicationID, addressID)
+	address := contract.Address{AddressDetails: make(map[string]string), Version: existingAddress.Version, TTL: existingAddress.TTL}
+
+	for _, key := range keys {
+		if value, ok := existingAddress.AddressDetails[key]; ok {
+			address.AddressDetails[key] = value
+		}
+	}
+
+	if len(address.AddressDetails) == 0 {
+		return contract.Address{}, contract.NotFoundError{AddressID: addressID}
+	}
+
+	return address, nil
+}
+
+// ReadAll retrieves an existing address information and returns all the detail of it.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+// applicationID: Mandatory. The unique identifier of the tenant's application will be owning the address.
+// addressID: Mandatory. The unique identifier of the existing address.
+// Returns either the address information or error if something goes wrong.
+func (addressDataService *InMemoryAddressDataService) ReadAll(ctx context.Context, tenantID, applicationID, addressID system.UUID) (contract.Address, error) {
+	addressDataService.lock.RLock()
+	defer addressDataService.lock.RUnlock()
+
+	existingAddress, ok := addressDataService.getExistingAddress(tenantID, applicationID, addressID)
+
+	if !ok {
+		return contract.Address{}, contract.NotFoundError{AddressID: addressID}
+	}
+
+	return contract.Address{
+		AddressDetails: copyAddressDetails(existingAddress.AddressDetails),
+		Version:        existingAddress.Version,
+		TTL:            existingAddress.TTL,
+	}, nil
+}
+
+// ReadMany retrieves several existing addresses information. Every address is read on its own, so failing to read an
+// address does not stop reading the rest of them.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the addresses.
+// applicationID: Mandatory. The unique identifier of the tenant's application owning the addresses.
+// addressIDs: Mandatory. The unique identifiers of the existing addresses.
+// keys: Optional. The interested address details keys to return, or empty to return all the details.
+// Returns the result of reading every address in the same order as addressIDs.
+func (addressDataService *InMemoryAddressDataService) ReadMany(
+	ctx context.Context,
+	tenantID, applicationID system.UUID,
+	addressIDs []system.UUID,
+	keys []string) []contract.AddressResult {
+	return readAddresses(ctx, addressDataService, tenantID, applicationID, addressIDs, keys)
+}
+
+// List retrieves a page of the addresses owned by a tenant's application. The deleted addresses are not returned.
+// The addresses are ordered by their unique identifiers.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the addresses.
+// applicationID: Mandatory. The unique identifier of the tenant's application owning the addresses.
+// pageSize: Mandatory. The maximum number of addresses to return.
+// cursor: Optional. The cursor returned with the previous page, or empty to return the first page.
+// Returns either the page of addresses or error if the cursor is invalid or something goes wrong.
+func (addressDataService *InMemoryAddressDataService) List(
+	ctx context.Context,
+	tenantID, applicationID system.UUID,
+	pageSize int,
+	cursor string) (contract.AddressPage, error) {
+	lastAddressID, err := decodeAddressIDCursor(cursor)
+
+	if err != nil {
+		return contract.AddressPage{}, err
+	}
+
+	addressDataService.lock.RLock()
+	defer addressDataService.lock.RUnlock()
+
+	applicationAddresses := addressDataService.getApplicationAddresses(tenantID, applicationID, false)
+	addressIDs := []string{}
+
+	for addressID := range applicationAddresses {
+		if addressID > lastAddressID {
+			addressIDs = append(addressIDs, addressID)
+		}
+	}
+
+	sort.Strings(addressIDs)
+
+	page := contract.AddressPage{Addresses: []contract.ListedAddress{}}
+
+	for _, addressID := range addressIDs {
+		listedAddressID, err := system.ParseUUID(addressID)
+
+		if err != nil {
+			return contract.AddressPage{}, err
+		}
+
+		existingAddress, ok := addressDataService.getExistingAddress(tenantID, applicationID, listedAddressID)
+
+		if !ok {
+			continue
+		}
+
+		if len(page.Addresses) == pageSize {
+			page.NextCursor = encodeAddressIDCursor(page.Addresses[pageSize-1].AddressID)
+
+			break
+		}
+
+		page.Addresses = append(page.Addresses, contract.ListedAddress{
+			AddressID: listedAddressID,
+			Address: contract.Address{
+				AddressDetails: copyAddressDetails(existingAddress.AddressDetails),
+				Version:        existingAddress.Version,
+				TTL:            existingAddress.TTL,
+			},
+		})
+	}
+
+	return page, nil
+}
+
+// FindByDetail retrieves the addresses owned by a tenant's application whose details match all the provided criteria.
+// The deleted addresses are not returned. The addresses are ordered by their unique identifiers, and no more than
+// MaxFoundAddresses addresses are returned.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the addresses.
+// applicationID: Mandatory. The unique identifier of the tenant's application owning the addresses.
+// criteria: Mandatory. The address details keys along with the values the returned addresses must have.
+// Returns either the matching addresses or error if something goes wrong.
+func (addressDataService *InMemoryAddressDataService) FindByDetail(
+	ctx context.Context,
+	tenantID, applicationID system.UUID,
+	criteria map[string]string) ([]contract.ListedAddress, error) {
+	addressDataService.lock.RLock()
+	defer addressDataService.lock.RUnlock()
+
+	applicationAddresses := addressDataService.getApplicationAddresses(tenantID, applicationID, false)
+	addressIDs := []string{}
+
+	for addressID, existingAddress := range applicationAddresses {
+		if matchAddressDetails(existingAddress.AddressDetails, criteria) {
+			addressIDs = append(addressIDs, addressID)
+		}
+	}
+
+	sort.Strings(addressIDs)
+
+	listedAddresses := []contract.ListedAddress{}
+
+	for _, addressID := range addressIDs {
+		listedAddressID, err := system.ParseUUID(addressID)
+
+		if err != nil {
+			return nil, err
+		}
+
+		existingAddress, ok := addressDataService.getExistingAddress(tenantID, applicationID, listedAddressID)
+
+		if !ok {
+			continue
+		}
+
+		listedAddresses = append(listedAddresses, contract.ListedAddress{
+			AddressID: listedAddressID,
+			Address: contract.Address{
+				AddressDetails: copyAddressDetails(existingAddress.AddressDetails),
+				Version:        existingAddress.Version,
+				TTL:            existingAddress.TTL,
+			},
+		})
+
+		if len(listedAddresses) == contract.MaxFoundAddresses {
+			break
+		}
+	}
+
+	return listedAddresses, nil
+}
+
+// FindByFingerprint retrieves the addresses owned by a tenant's application which were created or last updated with the
+// provided fingerprint. The deleted addresses are not returned. The addresses are ordered by their unique identifiers.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the addresses.
+// applicationID: Mandatory. The unique identifier of the tenant's application owning the addresses.
+// fingerprint: Mandatory. The fingerprint the returned addresses were created or last updated with.
+// Returns either the matching addresses or error if something goes wrong.
+func (addressDataService *InMemoryAddressDataService) FindByFingerprint(
+	ctx context.Context,
+	tenantID, applicationID system.UUID,
+	fingerprint string) ([]contract.ListedAddress, error) {
+	addressDataService.lock.RLock()
+	defer addressDataService.lock.RUnlock()
+
+	applicationAddresses := addressDataService.getApplicationAddresses(tenantID, applicationID, false)
+	addressIDs := []string{}
+
+	for addressID, existingAddress := range applicationAddresses {
+		if existingAddress.Fingerprint == fingerprint {
+			addressIDs = append(addressIDs, addressID)
+		}
+	}
+
+	sort.Strings(addressIDs)
+
+	listedAddresses := []contract.ListedAddress{}
+
+	for _, addressID := range addressIDs {
+		listedAddressID, err := system.ParseUUID(addressID)
+
+		if err != nil {
+			return nil, err
+		}
+
+		existingAddress, ok := addressDataService.getExistingAddress(tenantID, applicationID, listedAddressID)
+
+		if !ok {
+			continue
+		}
+
+		listedAddresses = append(listedAddresses, contract.ListedAddress{
+			AddressID: listedAddressID,
+			Address: contract.Address{
+				AddressDetails: copyAddressDetails(existingAddress.AddressDetails),
+				Version:        existingAddress.Version,
+				TTL:            existingAddress.TTL,
+			},
+		})
+	}
+
+	return listedAddresses, nil
+}
+
+// AddOwners links an existing address to the provided owners. The owners already linked to the address are kept.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+// applicationID: Mandatory. The unique identifier of the tenant's application owning the address.
+// addressID: Mandatory. The unique identifier of the existing address.
+// owners: Mandatory. The owners to link the address to.
+// Returns error if the address does not exist, or if something goes wrong.
+func (addressDataService *InMemoryAddressDataService) AddOwners(
+	ctx context.Context,
+	tenantID, applicationID, addressID system.UUID,
+	owners []contract.AddressOwner) error {
+	addressDataService.lock.Lock()
+	defer addressDataService.lock.Unlock()
+
+	if _, ok := addressDataService.getExistingAddress(tenantID, applicationID, addressID); !ok {
+		return contract.NotFoundError{AddressID: addressID}
+	}
+
+	if addressDataService.owners == nil {
+		addressDataService.owners = make(map[string][]contract.AddressOwner)
+	}
+
+	key := getAddressKey(tenantID, applicationID, addressID)
+	linkedOwners := addressDataService.owners[key]
+
+	for _, owner := range owners {
+		if indexOfAddressOwner(linkedOwners, owner) == -1 {
+			linkedOwners = append(linkedOwners, owner)
+		}
+	}
+
+	sort.Slice(linkedOwners, func(i, j int) bool {
+		if linkedOwners[i].Type != linkedOwners[j].Type {
+			return linkedOwners[i].Type < linkedOwners[j].Type
+		}
+
+		return linkedOwners[i].ID < linkedOwners[j].ID
+	})
+
+	addressDataService.owners[key] = linkedOwners
+
+	return nil
+}
+
+// RemoveOwners unlinks an existing address from the provided owners. The owners not linked to the address are skipped.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+// applicationID: Mandatory. The unique identifier of the tenant's application owning the address.
+// addressID: Mandatory. The unique identifier of the existing address.
+// owners: Mandatory. The owners to unlink the address from.
+// Returns error if the address does not exist, or if something goes wrong.
+func (addressDataService *InMemoryAddressDataService) RemoveOwners(
+	ctx context.Context,
+	tenantID, applicationID, addressID system.UUID,
+	owners []contract.AddressOwner) error {
+	addressDataService.lock.Lock()
+	defer addressDataService.lock.Unlock()
+
+	if _, ok := addressDataService.getExistingAddress(tenantID, applicationID, addressID); !ok {
+		return contract.NotFoundError{AddressID: addressID}
+	}
+
+	key := getAddressKey(tenantID, applicationID, addressID)
+	remainingOwners := []contract.AddressOwner{}
+
+	for _, linkedOwner := range addressDataService.owners[key] {
+		if indexOfAddressOwner(owners, linkedOwner) == -1 {
+			remainingOwners = append(remainingOwners, linkedOwner)
+		}
+	}
+
+	if len(remainingOwners) == 0 {
+		delete(addressDataService.owners, key)
+	} else {
+		addressDataService.owners[key] = remainingOwners
+	}
+
+	return nil
+}
+
+// ReadOwners retrieves the owners an existing address is linked to.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+// applicationID: Mandatory. The unique identifier of the tenant's application owning the address.
+// addressID: Mandatory. The unique identifier of the existing address.
+// Returns either the owners ordered by their type and identifier or error if the address does not exist or something
+// goes wrong.
+func (addressDataService *InMemoryAddressDataService) ReadOwners(ctx context.Context, tenantID, applicationID, addressID system.UUID) ([]contract.AddressOwner, error) {
+	addressDataService.lock.RLock()
+	defer addressDataService.lock.RUnlock()
+
+	if _, ok := addressDataService.getExistingAddress(tenantID, applicationID, addressID); !ok {
+		return nil, contract.NotFoundError{AddressID: addressID}
+	}
+
+	linkedOwners := addressDataService.owners[getAddressKey(tenantID, applicationID, addressID)]
+	owners := make([]contract.AddressOwner, len(linkedOwners))
+	copy(owners, linkedOwners)
+
+	return owners, nil
+}
+
+// FindByOwner retrieves the addresses owned by a tenant's application which are linked to the provided owner. The
+// deleted addresses are not returned.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the addresses.
+// applicationID: Mandatory. The unique identifier of the tenant's application owning the addresses.
+// owner: Mandatory. The owner the returned addresses are linked to.
+// Returns either the linked addresses ordered by their unique identifier or error if something goes wrong.
+func (addressDataService *InMemoryAddressDataService) FindByOwner(
+	ctx context.Context,
+	tenantID, applicationID system.UUID,
+	owner contract.AddressOwner) ([]contract.ListedAddress, error) {
+	addressDataService.lock.RLock()
+	defer addressDataService.lock.RUnlock()
+
+	applicationAddresses := addressDataService.getApplicationAddresses(tenantID, applicationID, false)
+	addressIDs := []string{}
+
+	for addressID := range applicationAddresses {
+		addressIDs = append(addressIDs, addressID)
+	}
+
+	sort.Strings(addressIDs)
+
+	listedAddresses := []contract.ListedAddress{}
+
+	for _, addressID := range addressIDs {
+		listedAddressID, err := system.ParseUUID(addressID)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if indexOfAddressOwner(addressDataService.owners[getAddressKey(tenantID, applicationID, listedAddressID)], owner) == -1 {
+			continue
+		}
+
+		existingAddress, ok := addressDataService.getExistingAddress(tenantID, applicationID, listedAddressID)
+
+		if !ok {
+			continue
+		}
+
+		listedAddresses = append(listedAddresses, contract.ListedAddress{
+			AddressID: listedAddressID,
+			Address: contract.Address{
+				AddressDetails: copyAddressDetails(existingAddress.AddressDetails),
+				Version:        existingAddress.Version,
+				TTL:            existingAddress.TTL,
+			},
+		})
+	}
+
+	return listedAddresses, nil
+}
+
+// History retrieves all the recorded versions of an address, including the versions recorded when it was deleted and
+// restored. The history is kept after the address is purged.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+// applicationID: Mandatory. The unique identifier of the tenant's application will be owning the address.
+// addressID: Mandatory. The unique identifier of the address.
+// Returns either the recorded versions ordered from the oldest to the newest or error if no version is recorded or
+// something goes wrong.
+func (addressDataService *InMemoryAddressDataService) History(ctx context.Context, tenantID, applicationID, addressID system.UUID) ([]contract.AddressHistoryEntry, error) {
+	addressDataService.lock.RLock()
+	defer addressDataService.lock.RUnlock()
+
+	history, ok := addressDataService.history[getAddressKey(tenantID, applicationID, addressID)]
+
+	if !ok {
+		return nil, contract.NotFoundError{AddressID: addressID}
+	}
+
+	copiedHistory := make([]contract.AddressHistoryEntry, 0, len(history))
+
+	for _, historyEntry := range history {
+		historyEntry.Address.AddressDetails = copyAddressDetails(historyEntry.Address.AddressDetails)
+		copiedHistory = append(copiedHistory, historyEntry)
+	}
+
+	return copiedHistory, nil
+}
+
+// ReadAt retrieves the address information as it was at the provided time.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+// applicationID: Mandatory. The unique identifier of the tenant's application will be owning the address.
+// addressID: Mandatory. The unique identifier of the address.
+// timestamp: Mandatory. The time to return the address information at.
+// Returns either the address information or error if the address did not exist or was deleted at the provided time, or
+// if something goes wrong.
+func (addressDataService *InMemoryAddressDataService) ReadAt(ctx context.Context, tenantID, applicationID, addressID system.UUID, timestamp time.Time) (contract.Address, error) {
+	addressDataService.lock.RLock()
+	defer addressDataService.lock.RUnlock()
+
+	return findAddressAt(addressDataService.history[getAddressKey(tenantID, applicationID, addressID)], timestamp, addressID)
+}
+
+// Delete marks an existing address as deleted. The deleted address is not returned anymore but it can be restored
+// until it is purged.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+// applicationID: Mandatory. The unique identifier of the tenant's application will be owning the address.
+// addressID: Mandatory. The unique identifier of the existing address to remove.
+// expectedVersion: Optional. The version the address is expected to have, or AnyVersion to skip the version check.
+// changedBy: Optional. Identifies who makes the change, recorded in the address history.
+// Returns ConflictError if the address version does not match the expected version, or error if something goes wrong.
+func (addressDataService *InMemoryAddressDataService) Delete(
+	ctx context.Context,
+	tenantID, applicationID, addressID system.UUID,
+	expectedVersion int64,
+	changedBy string) error {
+	eventID, err := addressDataService.newEventID()
+
+	if err != nil {
+		return err
+	}
+
+	addressDataService.lock.Lock()
+	defer addressDataService.lock.Unlock()
+
+	applicationAddresses := addressDataService.getApplicationAddresses(tenantID, applicationID, false)
+	existingAddress, ok := addressDataService.getExistingAddress(tenantID, applicationID, addressID)
+
+	if !ok {
+		return contract.NotFoundError{AddressID: addressID}
+	}
+
+	if expectedVersion != contract.AnyVersion && expectedVersion != existingAddress.Version {
+		return contract.ConflictError{AddressID: addressID, ExpectedVersion: expectedVersion, ActualVersion: existingAddress.Version}
+	}
+
+	delete(applicationAddresses, addressID.String())
+
+	if addressDataService.deletedAddresses == nil {
+		addressDataService.deletedAddresses = make(map[string]inMemoryDeletedAddress)
+	}
+
+	existingAddress.Version++
+	addressDataService.deletedAddresses[getAddressKey(tenantID, applicationID, addressID)] = inMemoryDeletedAddress{
+		address:   existingAddress,
+		deletedAt: time.Now(),
+	}
+
+	addressDataService.recordHistory(tenantID, applicationID, addressID, existingAddress, changedBy, true)
+	addressDataService.recordEvent(
+		eventID,
+		contract.AddressDeleted,
+		tenantID,
+		applicationID,
+		addressID,
+		existingAddress.Version,
+		existingAddress.AddressDetails,
+		nil,
+		changedBy)
+
+	return nil
+}
+
+// DeleteMany marks several existing addresses as deleted, skipping the version check. Every address is deleted on its
+// own, so failing to delete an address does not stop deleting the rest of them.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the addresses.
+// applicationID: Mandatory. The unique identifier of the tenant's application owning the addresses.
+// addressIDs: Mandatory. The unique identifiers of the existing addresses to remove.
+// changedBy: Optional. Identifies who makes the change, recorded in the address history.
+// Returns the result of deleting every address in the same order as addressIDs.
+func (addressDataService *InMemoryAddressDataService) DeleteMany(
+	ctx context.Context,
+	tenantID, applicationID system.UUID,
+	addressIDs []system.UUID,
+	changedBy string) []contract.AddressResult {
+	return deleteAddresses(ctx, addressDataService, tenantID, applicationID, addressIDs, changedBy)
+}
+
+// Restore restores a deleted address.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+// applicationID: Mandatory. The unique identifier of the tenant's application will be owning the address.
+// addressID: Mandatory. The unique identifier of the deleted address to restore.
+// deletedSince: Mandatory. The address is restored only if it was deleted at or after this time.
+// changedBy: Optional. Identifies who makes the change, recorded in the address history.
+// Returns error if the deleted address does not exist or was deleted before deletedSince, or if something goes wrong.
+func (addressDataService *InMemoryAddressDataService) Restore(
+	ctx context.Context,
+	tenantID, applicationID, addressID system.UUID,
+	deletedSince time.Time,
+	changedBy string) error {
+	addressDataService.lock.Lock()
+	defer addressDataService.lock.Unlock()
+
+	key := getAddressKey(tenantID, applicationID, addressID)
+	deletedAddress, ok := addressDataService.deletedAddresses[key]
+
+	if !ok || deletedAddress.deletedAt.Before(deletedSince) || addressDataService.hasExpired(key) {
+		return contract.NotFoundError{AddressID: addressID, Deleted: true}
+	}
+
+	delete(addressDataService.deletedAddresses, key)
+
+	deletedAddress.address.Version++
+	addressDataService.getApplicationAddresses(tenantID, applicationID, true)[addressID.String()] = deletedAddress.address
+	addressDataService.recordHistory(tenantID, applicationID, addressID, deletedAddress.address, changedBy, false)
+
+	return nil
+}
+
+// Purge removes a deleted address for good.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+// applicationID: Mandatory. The unique identifier of the tenant's application will be owning the address.
+// addressID: Mandatory. The unique identifier of the deleted address to purge.
+// Returns error if the deleted address does not exist, or if something goes wrong.
+func (addressDataService *InMemoryAddressDataService) Purge(ctx context.Context, tenantID, applicationID, addressID system.UUID) error {
+	addressDataService.lock.Lock()
+	defer addressDataService.lock.Unlock()
+
+	key := getAddressKey(tenantID, applicationID, addressID)
+
+	if _, ok := addressDataService.deletedAddresses[key]; !ok {
+		return contract.NotFoundError{AddressID: addressID, Deleted: true}
+	}
+
+	delete(addressDataService.deletedAddresses, key)
+	delete(addressDataService.expiresAt, key)
+	delete(addressDataService.owners, key)
+
+	return nil
+}
+
+// PurgeDeleted removes for good all the addresses of all tenants deleted before the provided time.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// deletedBefore: Mandatory. The addresses deleted before this time are purged.
+// Returns error if something goes wrong.
+func (addressDataService *InMemoryAddressDataService) PurgeDeleted(ctx context.Context, deletedBefore time.Time) error {
+	addressDataService.lock.Lock()
+	defer addressDataService.lock.Unlock()
+
+	for key, deletedAddress := range addressDataService.deletedAddresses {
+		if deletedAddress.deletedAt.Before(deletedBefore) {
+			delete(addressDataService.deletedAddresses, key)
+			delete(addressDataService.expiresAt, key)
+			delete(addressDataService.owners, key)
+		}
+	}
+
+	return nil
+}
+
+// PurgeTenant removes for good all the addresses of all the applications of a tenant along with their history, whether
+// they are deleted or not, and records an erasure certificate. All the addresses are removed at once, so there is never
+// an interrupted erasure to resume.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant to erase.
+// requestedBy: Optional. Identifies who requests the erasure, recorded in the erasure certificate.
+// Returns either the erasure certificate or error if something goes wrong.
+func (addressDataService *InMemoryAddressDataService) PurgeTenant(ctx context.Context, tenantID system.UUID, requestedBy string) (contract.ErasureCertificate, error) {
+	return addressDataService.erase(tenantID, system.EmptyUUID, requestedBy)
+}
+
+// PurgeApplication removes for good all the addresses of a tenant's application along with their history, whether they
+// are deleted or not, and records an erasure certificate. All the addresses are removed at once, so there is never an
+// interrupted erasure to resume.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the application.
+// applicationID: Mandatory. The unique identifier of the tenant's application to erase.
+// requestedBy: Optional. Identifies who requests the erasure, recorded in the erasure certificate.
+// Returns either the erasure certificate or error if something goes wrong.
+func (addressDataService *InMemoryAddressDataService) PurgeApplication(
+	ctx context.Context,
+	tenantID, applicationID system.UUID,
+	requestedBy string) (contract.ErasureCertificate, error) {
+	return addressDataService.erase(tenantID, applicationID, requestedBy)
+}
+
+// erase removes the addresses of the tenant, or only the addresses of the application if applicationID is not empty, and
+// records the erasure certificate. The addresses are counted as address rows and their recorded versions as
+// address_history rows.
+func (addressDataService *InMemoryAddressDataService) erase(tenantID, applicationID system.UUID, requestedBy string) (contract.ErasureCertificate, error) {
+	diagnostics.IsNotNil(addressDataService.UUIDGeneratorService, "addressDataService.UUIDGeneratorService", "UUIDGeneratorService must be provided.")
+
+	certificateID, err := addressDataService.UUIDGeneratorService.GenerateRandomUUID()
+
+	if err != nil {
+		return contract.ErasureCertificate{}, err
+	}
+
+	addressDataService.lock.Lock()
+	defer addressDataService.lock.Unlock()
+
+	certificate := contract.ErasureCertificate{
+		CertificateID: certificateID,
+		TenantID:      tenantID,
+		ApplicationID: applicationID,
+		RequestedBy:   requestedBy,
+		StartedAt:     time.Now(),
+		ErasedRows:    map[string]int64{"address": 0, "address_history": 0, "address_event_outbox": 0},
+	}
+
+	prefix := tenantID.String() + "/"
+
+	if applicationID != system.EmptyUUID {
+		prefix += applicationID.String() + "/"
+	}
+
+	for key, applicationAddresses := range addressDataService.addresses[tenantID.String()] {
+		if applicationID == system.EmptyUUID || key == applicationID.String() {
+			certificate.ErasedRows["address"] += int64(len(applicationAddresses))
+			delete(addressDataService.addresses[tenantID.String()], key)
+		}
+	}
+
+	for key := range addressDataService.deletedAddresses {
+		if strings.HasPrefix(key, prefix) {
+			certificate.ErasedRows["address"]++
+			delete(addressDataService.deletedAddresses, key)
+		}
+	}
+
+	for key, entries := range addressDataService.history {
+		if strings.HasPrefix(key, prefix) {
+			certificate.ErasedRows["address_history"] += int64(len(entries))
+			delete(addressDataService.history, key)
+		}
+	}
+
+	for key := range addressDataService.expiresAt {
+		if strings.HasPrefix(key, prefix) {
+			delete(addressDataService.expiresAt, key)
+		}
+	}
+
+	for key := range addressDataService.owners {
+		if strings.HasPrefix(key, prefix) {
+			delete(addressDataService.owners, key)
+		}
+	}
+
+	remainingEvents := []contract.AddressEvent{}
+
+	for _, event := range addressDataService.events {
+		if event.TenantID == tenantID && (applicationID == system.EmptyUUID || event.ApplicationID == applicationID) {
+			certificate.ErasedRows["address_event_outbox"]++
+		} else {
+			remainingEvents = append(remainingEvents, event)
+		}
+	}
+
+	addressDataService.events = remainingEvents
+
+	certificate.CompletedAt = time.Now()
+	addressDataService.erasureCertificates = append(addressDataService.erasureCertificates, certificate)
+
+	return certificate, nil
+}
+
+// ReadEvents retrieves the events waiting in the outbox to be published, ordered from the oldest to the newest.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// limit: Mandatory. The maximum number of events to return.
+// Returns either the events or error if something goes wrong.
+func (addressDataService *InMemoryAddressDataService) ReadEvents(ctx context.Context, limit int) ([]contract.AddressEvent, error) {
+	addressDataService.lock.RLock()
+	defer addressDataService.lock.RUnlock()
+
+	events := []contract.AddressEvent{}
+
+	for _, event := range addressDataService.events {
+		if len(events) == limit {
+			break
+		}
+
+		events = append(events, event)
+	}
+
+	return events, nil
+}
+
+// RemoveEvents removes the published events from the outbox.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// events: Mandatory. The events returned by ReadEvents which are published.
+// Returns error if something goes wrong.
+func (addressDataService *InMemoryAddressDataService) RemoveEvents(ctx context.Context, events []contract.AddressEvent) error {
+	addressDataService.lock.Lock()
+	defer addressDataService.lock.Unlock()
+
+	removedEventIDs := make(map[system.UUID]bool, len(events))
+
+	for _, event := range events {
+		removedEventIDs[event.EventID] = true
+	}
+
+	remainingEvents := []contract.AddressEvent{}
+
+	for _, event := range addressDataService.events {
+		if !removedEventIDs[event.EventID] {
+			remainingEvents = append(remainingEvents, event)
+		}
+	}
+
+	addressDataService.events = remainingEvents
+
+	return nil
+}
+
+// getApplicationAddresses returns the addresses owned by the provided tenant's application. When create is true, the missing
+// tenant and application entries are created, otherwise nil is returned if no address has been stored for the application yet.
+// The caller must hold the lock.
+func (addressDataService *InMemoryAddressDataService) getApplicationAddresses(
+	tenantID, applicationID system.UUID,
+	create bool) map[string]contract.Address {
+	if addressDataService.addresses == nil {
+		if !create {
+			return nil
+		}
+
+		addressDataService.addresses = make(map[string]map[string]map[string]contract.Address)
+	}
+
+	tenantAddresses, ok := addressDataService.addresses[tenantID.String()]
+
+	if !ok {
+		if !create {
+			return nil
+		}
+
+		tenantAddresses = make(map[string]map[string]contract.Address)
+		addressDataService.addresses[tenantID.String()] = tenantAddresses
+	}
+
+	applicationAddresses, ok := tenantAddresses[applicationID.String()]
+
+	if !ok && create {
+		applicationAddresses = make(map[string]contract.Address)
+		tenantAddresses[applicationID.String()] = applicationAddresses
+	}
+
+	return applicationAddresses
+}
+
+// getExistingAddress returns an address owned by the provided tenant's application along with its remaining TTL, or false
+// if the address does not exist or has expired. The caller must hold the lock.
+func (addressDataService *InMemoryAddressDataService) getExistingAddress(tenantID, applicationID, addressID system.UUID) (contract.Address, bool) {
+	existingAddress, ok := addressDataService.getApplicationAddresses(tenantID, applicationID, false)[addressID.String()]
+
+	if !ok {
+		return contract.Address{}, false
+	}
+
+	if expiresAt, ok := addressDataService.expiresAt[getAddressKey(tenantID, applicationID, addressID)]; ok {
+		existingAddress.TTL = time.Until(expiresAt)
+
+		if existingAddress.TTL <= 0 {
+			return contract.Address{}, false
+		}
+	}
+
+	return existingAddress, true
+}
+
+// hasExpired returns true if the address kept under the provided key was given a TTL that has passed. The caller must
+// hold the lock.
+func (addressDataService *InMemoryAddressDataService) hasExpired(key string) bool {
+	expiresAt, ok := addressDataService.expiresAt[key]
+
+	return ok && !time.Now().Before(expiresAt)
+}
+
+// setExpiry records the time an address expires at, or removes it when ttl is zero so the address never expires. The
+// caller must hold the lock.
+func (addressDataService *InMemoryAddressDataService) setExpiry(tenantID, applicationID, addressID system.UUID, ttl time.Duration) {
+	key := getAddressKey(tenantID, applicationID, addressID)
+
+	if ttl == 0 {
+		delete(addressDataService.expiresAt, key)
+
+		return
+	}
+
+	if addressDataService.expiresAt == nil {
+		addressDataService.expiresAt = make(map[string]time.Time)
+	}
+
+	addressDataService.expiresAt[key] = time.Now().Add(ttl)
+}
+
+// recordHistory appends a version of an address to its history. The caller must hold the lock.
+func (addressDataService *InMemoryAddressDataService) recordHistory(
+	tenantID, applicationID, addressID system.UUID,
+	address contract.Address,
+	changedBy string,
+	deleted bool) {
+	if addressDataService.history == nil {
+		addressDataService.history = make(map[string][]contract.AddressHistoryEntry)
+	}
+
+	key := getAddressKey(tenantID, applicationID, addressID)
+	addressDataService.history[key] = append(
+		addressDataService.history[key],
+		contract.AddressHistoryEntry{
+			Address:   contract.Address{AddressDetails: copyAddressDetails(address.AddressDetails), Version: address.Version},
+			ChangedAt: time.Now(),
+			ChangedBy: changedBy,
+			Deleted:   deleted,
+		})
+}
+
+// newEventID returns the unique identifier of the event recording the next change, or an empty unique identifier if
+// recording the events is disabled. It is generated before the change is made, so the change and its event are either
+// both made or neither of them.
+func (addressDataService *InMemoryAddressDataService) newEventID() (system.UUID, error) {
+	if !addressDataService.RecordEvents {
+		return system.EmptyUUID, nil
+	}
+
+	diagnostics.IsNotNil(addressDataService.UUIDGeneratorService, "addressDataService.UUIDGeneratorService", "UUIDGeneratorService must be provided.")
+
+	return addressDataService.UUIDGeneratorService.GenerateRandomUUID()
+}
+
+// recordEvent adds an event recording the change of an address to the outbox if recording the events is enabled. The
+// caller must hold the lock.
+func (addressDataService *InMemoryAddressDataService) recordEvent(
+	eventID system.UUID,
+	eventType contract.AddressEventType,
+	tenantID, applicationID, addressID system.UUID,
+	version int64,
+	oldAddressDetails, newAddressDetails map[string]string,
+	changedBy string) {
+	if !addressDataService.RecordEvents {
+		return
+	}
+
+	event := contract.AddressEvent{
+		EventID:       eventID,
+		EventType:     eventType,
+		TenantID:      tenantID,
+		ApplicationID: applicationID,
+		AddressID:     addressID,
+		Version:       version,
+		ChangedAt:     time.Now(),
+		ChangedBy:     changedBy,
+	}
+
+	if oldAddressDetails != nil {
+		event.OldAddressDetails = copyAddressDetails(oldAddressDetails)
+	}
+
+	if newAddressDetails != nil {
+		event.NewAddressDetails = copyAddressDetails(newAddressDetails)
+	}
+
+	addressDataService.events = append(addressDataService.events, event)
+}
+
+// getAddressKey returns the key the address is kept under in deletedAddresses, history, expiresAt and owners.
+func getAddressKey(tenantID, applicationID, addressID system.UUID) string {
+	return tenantID.String() + "/" + applicationID.String() + "/" + addressID.String()
+}
+
+// copyAddressDetails returns a copy of the provided address details, so the stored details cannot be changed by the caller.
+func copyAddressDetails(addressDetails map[string]string) map[string]string {
+	copiedAddressDetails := make(map[string]string, len(addressDetails))
+
+	for key, value := range addressDetails {
+		copiedAddressDetails[key] = value
+	}
+
+	return copiedAddressDetails
+}
+
+// indexOfAddressOwner returns the index of the provided owner in owners, or -1 if it is not there.
+func indexOfAddressOwner(owners []contract.AddressOwner, owner contract.AddressOwner) int {
+	for index, linkedOwner := range owners {
+		if linkedOwner == owner {
+			return index
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,1003 @@
+package service_test
+
+import (
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/micro-business/AddressService/data/contract"
+	"github.com/micro-business/AddressService/data/service"
+	"github.com/micro-business/Micro-Business-Core/system"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("In-memory address data service behaviour", func() {
+	var (
+		ctx                      context.Context
+		mockCtrl                 *gomock.Controller
+		addressDataService       *service.InMemoryAddressDataService
+		mockUUIDGeneratorService *MockUUIDGeneratorService
+		tenantID                 system.UUID
+		applicationID            system.UUID
+		addressID                system.UUID
+		validAddress             contract.Address
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockUUIDGeneratorService = NewMockUUIDGeneratorService(mockCtrl)
+
+		addressDataService = &service.InMemoryAddressDataService{UUIDGeneratorService: mockUUIDGeneratorService}
+
+		tenantID, _ = system.RandomUUID()
+		applicationID, _ = system.RandomUUID()
+		addressID, _ = system.RandomUUID()
+		validAddress = contract.Address{AddressDetails: map[string]string{"City": "Christchurch", "Postcode": "8011"}}
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	Context("when UUID generator service not provided", func() {
+		It("should panic", func() {
+			addressDataService.UUIDGeneratorService = nil
+
+			Ω(func() { addressDataService.Create(ctx, tenantID, applicationID, validAddress, "") }).Should(Panic())
+		})
+	})
+
+	Context("when creating new address", func() {
+		It("should return the error returned by UUID generator service", func() {
+			expectedError := errors.New("UUID generation failed")
+			mockUUIDGeneratorService.
+				EXPECT().
+				GenerateRandomUUID().
+				Return(system.EmptyUUID, expectedError)
+
+			newAddressID, err := addressDataService.Create(ctx, tenantID, applicationID, validAddress, "")
+
+			Expect(newAddressID).To(Equal(system.EmptyUUID))
+			Expect(err).To(Equal(expectedError))
+		})
+
+		It("should store a copy of the address details", func() {
+			mockUUIDGeneratorService.
+				EXPECT().
+				GenerateRandomUUID().
+				Return(addressID, nil)
+
+			newAddressID, err := addressDataService.Create(ctx, tenantID, applicationID, validAddress, "")
+
+			Expect(newAddressID).To(Equal(addressID))
+			Expect(err).To(BeNil())
+
+			validAddress.AddressDetails["City"] = "Auckland"
+
+			address, err := addressDataService.ReadAll(ctx, tenantID, applicationID, addressID)
+
+			Expect(err).To(BeNil())
+			Expect(address.AddressDetails["City"]).To(Equal("Christchurch"))
+		})
+	})
+
+	Context("when address does not exist", func() {
+		It("should return not found error from Update, Read, ReadAll and Delete", func() {
+			expectedError := contract.NotFoundError{AddressID: addressID}
+
+			Expect(addressDataService.Update(ctx, tenantID, applicationID, addressID, validAddress, contract.AnyVersion, "")).To(Equal(expectedError))
+
+			_, err := addressDataService.Read(ctx, tenantID, applicationID, addressID, []string{"City"})
+			Expect(err).To(Equal(expectedError))
+
+			_, err = addressDataService.ReadAll(ctx, tenantID, applicationID, addressID)
+			Expect(err).To(Equal(expectedError))
+
+			Expect(addressDataService.Delete(ctx, tenantID, applicationID, addressID, contract.AnyVersion, "")).To(Equal(expectedError))
+		})
+	})
+
+	Context("when address exists", func() {
+		BeforeEach(func() {
+			ctx = context.Background()
+			mockUUIDGeneratorService.
+				EXPECT().
+				GenerateRandomUUID().
+				Return(addressID, nil)
+
+			addressDataService.Create(ctx, tenantID, applicationID, validAddress, "")
+		})
+
+		It("should return only the requested keys", func() {
+			address, err := addressDataService.Read(ctx, tenantID, applicationID, addressID, []string{"City", "Country"})
+
+			Expect(err).To(BeNil())
+			Expect(address).To(Equal(contract.Address{AddressDetails: map[string]string{"City": "Christchurch"}, Version: 1}))
+		})
+
+		It("should not return the address to other tenants or applications", func() {
+			otherID, _ := system.RandomUUID()
+
+			_, err := addressDataService.ReadAll(ctx, otherID, applicationID, addressID)
+			Expect(err).NotTo(BeNil())
+
+			_, err = addressDataService.ReadAll(ctx, tenantID, otherID, addressID)
+			Expect(err).NotTo(BeNil())
+		})
+
+		It("should replace all the address details on update", func() {
+			expectedAddressDetails := map[string]string{"Line1": "1 Example Street", "Country": "New Zealand"}
+
+			err := addressDataService.Update(ctx, tenantID, applicationID, addressID, contract.Address{AddressDetails: expectedAddressDetails}, contract.AnyVersion, "")
+
+			Expect(err).To(BeNil())
+
+			address, err := addressDataService.ReadAll(ctx, tenantID, applicationID, addressID)
+
+			Expect(err).To(BeNil())
+			Expect(address.AddressDetails).To(Equal(expectedAddressDetails))
+			Expect(address.Version).To(Equal(int64(2)))
+		})
+
+		It("should update the address when the expected version matches", func() {
+			Expect(addressDataService.Update(ctx, tenantID, applicationID, addressID, validAddress, 1, "")).To(BeNil())
+
+			address, err := addressDataService.ReadAll(ctx, tenantID, applicationID, addressID)
+
+			Expect(err).To(BeNil())
+			Expect(address.Version).To(Equal(int64(2)))
+		})
+
+		It("should return conflict error when the expected version does not match on update", func() {
+			err := addressDataService.Update(ctx, tenantID, applicationID, addressID, contract.Address{AddressDetails: map[string]string{"City": "Wellington"}}, 2, "")
+
+			Expect(err).To(Equal(contract.ConflictError{AddressID: addressID, ExpectedVersion: 2, ActualVersion: 1}))
+
+			address, _ := addressDataService.ReadAll(ctx, tenantID, applicationID, addressID)
+
+			Expect(address).To(Equal(contract.Address{AddressDetails: validAddress.AddressDetails, Version: 1}))
+		})
+
+		It("should return conflict error when the expected version does not match on delete", func() {
+			err := addressDataService.Delete(ctx, tenantID, applicationID, addressID, 2, "")
+
+			Expect(err).To(Equal(contract.ConflictError{AddressID: addressID, ExpectedVersion: 2, ActualVersion: 1}))
+
+			_, err = addressDataService.ReadAll(ctx, tenantID, applicationID, addressID)
+
+			Expect(err).To(BeNil())
+		})
+
+		It("should set and remove only the provided keys on patch", func() {
+			addressDataService.Update(ctx, tenantID, applicationID, addressID, contract.Address{AddressDetails: map[string]string{"Line1": "1 Main Road", "City": "Christchurch"}}, contract.AnyVersion, "")
+
+			err := addressDataService.Patch(ctx, tenantID, applicationID, addressID, map[string]string{"Postcode": "8011"}, []string{"Line1"}, 2, "")
+
+			Expect(err).To(BeNil())
+
+			address, err := addressDataService.ReadAll(ctx, tenantID, applicationID, addressID)
+
+			Expect(err).To(BeNil())
+			Expect(address).To(Equal(contract.Address{AddressDetails: map[string]string{"City": "Christchurch", "Postcode": "8011"}, Version: 3}))
+		})
+
+		It("should return error on patch if all the address keys are removed", func() {
+			err := addressDataService.Patch(ctx, tenantID, applicationID, addressID, nil, []string{"City", "Postcode"}, contract.AnyVersion, "")
+
+			Expect(err).To(Equal(contract.InvalidArgumentError{Message: "Address cannot be left without any address detail. Address ID: " + addressID.String()}))
+		})
+
+		It("should return conflict error when the expected version does not match on patch", func() {
+			err := addressDataService.Patch(ctx, tenantID, applicationID, addressID, map[string]string{"City": "Wellington"}, nil, 2, "")
+
+			Expect(err).To(Equal(contract.ConflictError{AddressID: addressID, ExpectedVersion: 2, ActualVersion: 1}))
+		})
+
+		It("should remove the address on delete", func() {
+			Expect(addressDataService.Delete(ctx, tenantID, applicationID, addressID, contract.AnyVersion, "")).To(BeNil())
+
+			_, err := addressDataService.ReadAll(ctx, tenantID, applicationID, addressID)
+
+			Expect(err).To(Equal(contract.NotFoundError{AddressID: addressID}))
+		})
+
+		It("should restore the deleted address with increased version", func() {
+			Expect(addressDataService.Delete(ctx, tenantID, applicationID, addressID, contract.AnyVersion, "")).To(BeNil())
+			Expect(addressDataService.Restore(ctx, tenantID, applicationID, addressID, time.Now().Add(-time.Hour), "")).To(BeNil())
+
+			address, err := addressDataService.ReadAll(ctx, tenantID, applicationID, addressID)
+
+			Expect(err).To(BeNil())
+			Expect(address).To(Equal(contract.Address{AddressDetails: validAddress.AddressDetails, Version: 3}))
+		})
+
+		It("should not restore the address deleted before the provided time", func() {
+			Expect(addressDataService.Delete(ctx, tenantID, applicationID, addressID, contract.AnyVersion, "")).To(BeNil())
+
+			err := addressDataService.Restore(ctx, tenantID, applicationID, addressID, time.Now().Add(time.Hour), "")
+
+			Expect(err).To(Equal(contract.NotFoundError{AddressID: addressID, Deleted: true}))
+		})
+
+		It("should not restore the purged address", func() {
+			Expect(addressDataService.Purge(ctx, tenantID, applicationID, addressID)).To(Equal(contract.NotFoundError{AddressID: addressID, Deleted: true}))
+			Expect(addressDataService.Delete(ctx, tenantID, applicationID, addressID, contract.AnyVersion, "")).To(BeNil())
+			Expect(addressDataService.Purge(ctx, tenantID, applicationID, addressID)).To(BeNil())
+
+			err := addressDataService.Restore(ctx, tenantID, applicationID, addressID, time.Now().Add(-time.Hour), "")
+
+			Expect(err).To(Equal(contract.NotFoundError{AddressID: addressID, Deleted: true}))
+		})
+
+		It("should purge only the addresses deleted before the provided time", func() {
+			Expect(addressDataService.Delete(ctx, tenantID, applicationID, addressID, contract.AnyVersion, "")).To(BeNil())
+			Expect(addressDataService.PurgeDeleted(ctx, time.Now().Add(-time.Hour))).To(BeNil())
+			Expect(addressDataService.PurgeDeleted(ctx, time.Now().Add(time.Hour))).To(BeNil())
+
+			err := addressDataService.Restore(ctx, tenantID, applicationID, addressID, time.Now().Add(-time.Hour), "")
+
+			Expect(err).To(Equal(contract.NotFoundError{AddressID: addressID, Deleted: true}))
+		})
+
+		It("should record every version of the address with who changed it", func() {
+			updatedAddressDetails := map[string]string{"City": "Wellington"}
+
+			Expect(addressDataService.Update(ctx, tenantID, applicationID, addressID, contract.Address{AddressDetails: updatedAddressDetails}, contract.AnyVersion, "updater")).To(BeNil())
+			Expect(addressDataService.Delete(ctx, tenantID, applicationID, addressID, contract.AnyVersion, "deleter")).To(BeNil())
+			Expect(addressDataService.Purge(ctx, tenantID, applicationID, addressID)).To(BeNil())
+
+			history, err := addressDataService.History(ctx, tenantID, applicationID, addressID)
+
+			Expect(err).To(BeNil())
+			Expect(history).To(HaveLen(3))
+			Expect(history[0].Address).To(Equal(contract.Address{AddressDetails: validAddress.AddressDetails, Version: 1}))
+			Expect(history[1].Address).To(Equal(contract.Address{AddressDetails: updatedAddressDetails, Version: 2}))
+			Expect(history[1].ChangedBy).To(Equal("updater"))
+			Expect(history[1].Deleted).To(BeFalse())
+			Expect(history[2].Address).To(Equal(contract.Address{AddressDetails: updatedAddressDetails, Version: 3}))
+			Expect(history[2].ChangedBy).To(Equal("deleter"))
+			Expect(history[2].Deleted).To(BeTrue())
+		})
+
+		It("should return the address as it was at the provided time", func() {
+			afterCreate := time.Now()
+			time.Sleep(time.Millisecond)
+
+			Expect(addressDataService.Update(ctx, tenantID, applicationID, addressID, contract.Address{AddressDetails: map[string]string{"City": "Wellington"}}, contract.AnyVersion, "")).To(BeNil())
+
+			afterUpdate := time.Now()
+			time.Sleep(time.Millisecond)
+
+			Expect(addressDataService.Delete(ctx, tenantID, applicationID, addressID, contract.AnyVersion, "")).To(BeNil())
+
+			address, err := addressDataService.ReadAt(ctx, tenantID, applicationID, addressID, afterCreate)
+
+			Expect(err).To(BeNil())
+			Expect(address).To(Equal(contract.Address{AddressDetails: validAddress.AddressDetails, Version: 1}))
+
+			address, err = addressDataService.ReadAt(ctx, tenantID, applicationID, addressID, afterUpdate)
+
+			Expect(err).To(BeNil())
+			Expect(address).To(Equal(contract.Address{AddressDetails: map[string]string{"City": "Wellington"}, Version: 2}))
+
+			_, err = addressDataService.ReadAt(ctx, tenantID, applicationID, addressID, time.Now())
+
+			Expect(err).To(Equal(contract.NotFoundError{AddressID: addressID}))
+
+			_, err = addressDataService.ReadAt(ctx, tenantID, applicationID, addressID, afterCreate.Add(-time.Hour))
+
+			Expect(err).To(Equal(contract.NotFoundError{AddressID: addressID}))
+		})
+	})
+
+	Context("when listing addresses", func() {
+		var expectedAddresses map[system.UUID]contract.Address
+
+		BeforeEach(func() {
+			ctx = context.Background()
+			expectedAddresses = make(map[system.UUID]contract.Address)
+
+			for i := 0; i < 3; i++ {
+				addressID, _ = system.RandomUUID()
+				mockUUIDGeneratorService.
+					EXPECT().
+					GenerateRandomUUID().
+					Return(addressID, nil)
+
+				_, err := addressDataService.Create(ctx, tenantID, applicationID, validAddress, "")
+
+				Expect(err).To(BeNil())
+
+				expectedAddresses[addressID] = contract.Address{AddressDetails: validAddress.AddressDetails, Version: 1}
+			}
+		})
+
+		It("should return all the addresses of the application page by page", func() {
+			firstPage, err := addressDataService.List(ctx, tenantID, applicationID, 2, "")
+
+			Expect(err).To(BeNil())
+			Expect(firstPage.Addresses).To(HaveLen(2))
+			Expect(firstPage.NextCursor).NotTo(BeEmpty())
+
+			secondPage, err := addressDataService.List(ctx, tenantID, applicationID, 2, firstPage.NextCursor)
+
+			Expect(err).To(BeNil())
+			Expect(secondPage.Addresses).To(HaveLen(1))
+			Expect(secondPage.NextCursor).To(BeEmpty())
+
+			listedAddresses := make(map[system.UUID]contract.Address)
+
+			for _, listedAddress := range append(firstPage.Addresses, secondPage.Addresses...) {
+				listedAddresses[listedAddress.AddressID] = listedAddress.Address
+			}
+
+			Expect(listedAddresses).To(Equal(expectedAddresses))
+		})
+
+		It("should not return the deleted addresses and the addresses of other applications", func() {
+			Expect(addressDataService.Delete(ctx, tenantID, applicationID, addressID, contract.AnyVersion, "")).To(BeNil())
+
+			page, err := addressDataService.List(ctx, tenantID, applicationID, 10, "")
+
+			Expect(err).To(BeNil())
+			Expect(page.Addresses).To(HaveLen(2))
+
+			for _, listedAddress := range page.Addresses {
+				Expect(listedAddress.AddressID).NotTo(Equal(addressID))
+			}
+
+			otherApplicationID, _ := system.RandomUUID()
+			page, err = addressDataService.List(ctx, tenantID, otherApplicationID, 10, "")
+
+			Expect(err).To(BeNil())
+			Expect(page.Addresses).To(BeEmpty())
+		})
+
+		It("should return error if the cursor is invalid", func() {
+			_, err := addressDataService.List(ctx, tenantID, applicationID, 10, "invalid")
+
+			Expect(err).To(Equal(contract.InvalidArgumentError{Message: "Invalid cursor. Cursor: invalid"}))
+		})
+	})
+
+	Context("when finding addresses by detail", func() {
+		var matchingAddressID system.UUID
+
+		BeforeEach(func() {
+			ctx = context.Background()
+			for _, addressDetails := range []map[string]string{
+				{"City": "Wellington", "Postcode": "6011"},
+				{"City": "Wellington", "Postcode": "6012"},
+				{"City": "Christchurch", "Postcode": "6011"},
+			} {
+				addressID, _ = system.RandomUUID()
+				mockUUIDGeneratorService.
+					EXPECT().
+					GenerateRandomUUID().
+					Return(addressID, nil)
+
+				_, err := addressDataService.Create(ctx, tenantID, applicationID, contract.Address{AddressDetails: addressDetails}, "")
+
+				Expect(err).To(BeNil())
+			}
+
+			matchingAddressID = addressID
+		})
+
+		It("should return the addresses having the provided detail", func() {
+			listedAddresses, err := addressDataService.FindByDetail(ctx, tenantID, applicationID, map[string]string{"Postcode": "6011"})
+
+			Expect(err).To(BeNil())
+			Expect(listedAddresses).To(HaveLen(2))
+		})
+
+		It("should return only the addresses matching all the provided details", func() {
+			listedAddresses, err := addressDataService.FindByDetail(ctx, tenantID, applicationID, map[string]string{"City": "Christchurch", "Postcode": "6011"})
+
+			Expect(err).To(BeNil())
+			Expect(listedAddresses).To(Equal([]contract.ListedAddress{
+				{AddressID: matchingAddressID, Address: contract.Address{AddressDetails: map[string]string{"City": "Christchurch", "Postcode": "6011"}, Version: 1}},
+			}))
+		})
+
+		It("should not return the deleted addresses", func() {
+			Expect(addressDataService.Delete(ctx, tenantID, applicationID, matchingAddressID, contract.AnyVersion, "")).To(BeNil())
+
+			listedAddresses, err := addressDataService.FindByDetail(ctx, tenantID, applicationID, map[string]string{"City": "Christchurch"})
+
+			Expect(err).To(BeNil())
+			Expect(listedAddresses).To(BeEmpty())
+		})
+
+		It("should return the addresses matching the updated details only", func() {
+			Expect(addressDataService.Update(ctx, tenantID, applicationID, matchingAddressID, contract.Address{AddressDetails: map[string]string{"City": "Auckland"}}, contract.AnyVersion, "")).To(BeNil())
+
+			listedAddresses, err := addressDataService.FindByDetail(ctx, tenantID, applicationID, map[string]string{"City": "Christchurch"})
+
+			Expect(err).To(BeNil())
+			Expect(listedAddresses).To(BeEmpty())
+
+			listedAddresses, err = addressDataService.FindByDetail(ctx, tenantID, applicationID, map[string]string{"City": "Auckland"})
+
+			Expect(err).To(BeNil())
+			Expect(listedAddresses).To(HaveLen(1))
+		})
+
+		It("should return no more than the maximum number of found addresses", func() {
+			for index := 0; index < contract.MaxFoundAddresses; index++ {
+				addressID, _ = system.RandomUUID()
+				mockUUIDGeneratorService.
+					EXPECT().
+					GenerateRandomUUID().
+					Return(addressID, nil)
+
+				_, err := addressDataService.Create(ctx, tenantID, applicationID, contract.Address{AddressDetails: map[string]string{"Postcode": "6011"}}, "")
+
+				Expect(err).To(BeNil())
+			}
+
+			listedAddresses, err := addressDataService.FindByDetail(ctx, tenantID, applicationID, map[string]string{"Postcode": "6011"})
+
+			Expect(err).To(BeNil())
+			Expect(listedAddresses).To(HaveLen(contract.MaxFoundAddresses))
+		})
+	})
+
+	Context("when finding addresses by fingerprint", func() {
+		var matchingAddressID system.UUID
+
+		BeforeEach(func() {
+			ctx = context.Background()
+			for _, address := range []contract.Address{
+				{AddressDetails: map[string]string{"City": "Wellington"}, Fingerprint: "wellington"},
+				{AddressDetails: map[string]string{"City": "Auckland"}},
+				{AddressDetails: map[string]string{"City": "Christchurch"}, Fingerprint: "christchurch"},
+			} {
+				addressID, _ = system.RandomUUID()
+				mockUUIDGeneratorService.
+					EXPECT().
+					GenerateRandomUUID().
+					Return(addressID, nil)
+
+				_, err := addressDataService.Create(ctx, tenantID, applicationID, address, "")
+
+				Expect(err).To(BeNil())
+			}
+
+			matchingAddressID = addressID
+		})
+
+		It("should return the addresses created with the provided fingerprint", func() {
+			listedAddresses, err := addressDataService.FindByFingerprint(ctx, tenantID, applicationID, "christchurch")
+
+			Expect(err).To(BeNil())
+			Expect(listedAddresses).To(Equal([]contract.ListedAddress{
+				{AddressID: matchingAddressID, Address: contract.Address{AddressDetails: map[string]string{"City": "Christchurch"}, Version: 1}},
+			}))
+		})
+
+		It("should not return the addresses of other applications", func() {
+			otherApplicationID, _ := system.RandomUUID()
+
+			listedAddresses, err := addressDataService.FindByFingerprint(ctx, tenantID, otherApplicationID, "christchurch")
+
+			Expect(err).To(BeNil())
+			Expect(listedAddresses).To(BeEmpty())
+		})
+
+		It("should not return the deleted addresses", func() {
+			Expect(addressDataService.Delete(ctx, tenantID, applicationID, matchingAddressID, contract.AnyVersion, "")).To(BeNil())
+
+			listedAddresses, err := addressDataService.FindByFingerprint(ctx, tenantID, applicationID, "christchurch")
+
+			Expect(err).To(BeNil())
+			Expect(listedAddresses).To(BeEmpty())
+		})
+
+		It("should return the addresses by the fingerprint they were last updated with", func() {
+			updatedAddress := contract.Address{AddressDetails: map[string]string{"City": "Nelson"}, Fingerprint: "nelson"}
+
+			Expect(addressDataService.Update(ctx, tenantID, applicationID, matchingAddressID, updatedAddress, contract.AnyVersion, "")).To(BeNil())
+
+			listedAddresses, err := addressDataService.FindByFingerprint(ctx, tenantID, applicationID, "christchurch")
+
+			Expect(err).To(BeNil())
+			Expect(listedAddresses).To(BeEmpty())
+
+			listedAddresses, err = addressDataService.FindByFingerprint(ctx, tenantID, applicationID, "nelson")
+
+			Expect(err).To(BeNil())
+			Expect(listedAddresses).To(HaveLen(1))
+		})
+
+		It("should keep the fingerprint when the address is patched", func() {
+			Expect(addressDataService.Patch(ctx, tenantID, applicationID, matchingAddressID, map[string]string{"City": "Nelson"}, nil, contract.AnyVersion, "")).To(BeNil())
+
+			listedAddresses, err := addressDataService.FindByFingerprint(ctx, tenantID, applicationID, "christchurch")
+
+			Expect(err).To(BeNil())
+			Expect(listedAddresses).To(Equal([]contract.ListedAddress{
+				{AddressID: matchingAddressID, Address: contract.Address{AddressDetails: map[string]string{"City": "Nelson"}, Version: 2}},
+			}))
+		})
+	})
+
+	Context("when linking addresses to owners", func() {
+		var (
+			customer        contract.AddressOwner
+			supplier        contract.AddressOwner
+			linkedAddresses []contract.ListedAddress
+		)
+
+		BeforeEach(func() {
+			ctx = context.Background()
+			customer = contract.AddressOwner{Type: "customer", ID: "123"}
+			supplier = contract.AddressOwner{Type: "supplier", ID: "S-42"}
+			linkedAddresses = []contract.ListedAddress{}
+
+			for _, city := range []string{"Wellington", "Auckland", "Christchurch"} {
+				addressID, _ = system.RandomUUID()
+				mockUUIDGeneratorService.
+					EXPECT().
+					GenerateRandomUUID().
+					Return(addressID, nil)
+
+				_, err := addressDataService.Create(ctx, tenantID, applicationID, contract.Address{AddressDetails: map[string]string{"City": city}}, "")
+
+				Expect(err).To(BeNil())
+
+				if city != "Auckland" {
+					Expect(addressDataService.AddOwners(ctx, tenantID, applicationID, addressID, []contract.AddressOwner{customer})).To(BeNil())
+
+					linkedAddresses = append(linkedAddresses, contract.ListedAddress{
+						AddressID: addressID,
+						Address:   contract.Address{AddressDetails: map[string]string{"City": city}, Version: 1},
+					})
+				}
+			}
+
+			sort.Slice(linkedAddresses, func(i, j int) bool {
+				return linkedAddresses[i].AddressID.String() < linkedAddresses[j].AddressID.String()
+			})
+		})
+
+		It("should return the addresses linked to the owner ordered by their unique identifier", func() {
+			listedAddresses, err := addressDataService.FindByOwner(ctx, tenantID, applicationID, customer)
+
+			Expect(err).To(BeNil())
+			Expect(listedAddresses).To(Equal(linkedAddresses))
+		})
+
+		It("should not return the addresses of other owners or other applications", func() {
+			otherApplicationID, _ := system.RandomUUID()
+
+			listedAddresses, err := addressDataService.FindByOwner(ctx, tenantID, applicationID, supplier)
+
+			Expect(err).To(BeNil())
+			Expect(listedAddresses).To(BeEmpty())
+
+			listedAddresses, err = addressDataService.FindByOwner(ctx, tenantID, otherApplicationID, customer)
+
+			Expect(err).To(BeNil())
+			Expect(listedAddresses).To(BeEmpty())
+		})
+
+		It("should return the owners of the address ordered by their type and identifier once each", func() {
+			Expect(addressDataService.AddOwners(ctx, tenantID, applicationID, addressID, []contract.AddressOwner{supplier, customer})).To(BeNil())
+
+			owners, err := addressDataService.ReadOwners(ctx, tenantID, applicationID, addressID)
+
+			Expect(err).To(BeNil())
+			Expect(owners).To(Equal([]contract.AddressOwner{customer, supplier}))
+		})
+
+		It("should unlink the address from the removed owners only", func() {
+			Expect(addressDataService.AddOwners(ctx, tenantID, applicationID, addressID, []contract.AddressOwner{supplier})).To(BeNil())
+			Expect(addressDataService.RemoveOwners(ctx, tenantID, applicationID, addressID, []contract.AddressOwner{customer})).To(BeNil())
+
+			owners, err := addressDataService.ReadOwners(ctx, tenantID, applicationID, addressID)
+
+			Expect(err).To(BeNil())
+			Expect(owners).To(Equal([]contract.AddressOwner{supplier}))
+
+			listedAddresses, err := addressDataService.FindByOwner(ctx, tenantID, applicationID, customer)
+
+			Expect(err).To(BeNil())
+			Expect(listedAddresses).To(HaveLen(1))
+		})
+
+		It("should return NotFoundError when the address does not exist", func() {
+			missingAddressID, _ := system.RandomUUID()
+
+			Expect(addressDataService.AddOwners(ctx, tenantID, applicationID, missingAddressID, []contract.AddressOwner{customer})).To(Equal(contract.NotFoundError{AddressID: missingAddressID}))
+			Expect(addressDataService.RemoveOwners(ctx, tenantID, applicationID, missingAddressID, []contract.AddressOwner{customer})).To(Equal(contract.NotFoundError{AddressID: missingAddressID}))
+
+			_, err := addressDataService.ReadOwners(ctx, tenantID, applicationID, missingAddressID)
+
+			Expect(err).To(Equal(contract.NotFoundError{AddressID: missingAddressID}))
+		})
+
+		It("should not return the deleted addresses until they are restored", func() {
+			Expect(addressDataService.Delete(ctx, tenantID, applicationID, addressID, contract.AnyVersion, "")).To(BeNil())
+
+			listedAddresses, err := addressDataService.FindByOwner(ctx, tenantID, applicationID, customer)
+
+			Expect(err).To(BeNil())
+			Expect(listedAddresses).To(HaveLen(1))
+
+			Expect(addressDataService.Restore(ctx, tenantID, applicationID, addressID, time.Now().Add(-time.Hour), "")).To(BeNil())
+
+			listedAddresses, err = addressDataService.FindByOwner(ctx, tenantID, applicationID, customer)
+
+			Expect(err).To(BeNil())
+			Expect(listedAddresses).To(HaveLen(2))
+		})
+
+		It("should remove the owners when the address is purged", func() {
+			Expect(addressDataService.Delete(ctx, tenantID, applicationID, addressID, contract.AnyVersion, "")).To(BeNil())
+			Expect(addressDataService.Purge(ctx, tenantID, applicationID, addressID)).To(BeNil())
+
+			mockUUIDGeneratorService.
+				EXPECT().
+				GenerateRandomUUID().
+				Return(addressID, nil)
+
+			_, err := addressDataService.Create(ctx, tenantID, applicationID, contract.Address{AddressDetails: map[string]string{"City": "Nelson"}}, "")
+
+			Expect(err).To(BeNil())
+
+			owners, err := addressDataService.ReadOwners(ctx, tenantID, applicationID, addressID)
+
+			Expect(err).To(BeNil())
+			Expect(owners).To(BeEmpty())
+		})
+	})
+
+	Context("when creating, reading and deleting several addresses", func() {
+		var (
+			ctx               context.Context
+			createdAddressIDs []system.UUID
+			missingAddressID  system.UUID
+		)
+
+		BeforeEach(func() {
+			ctx = context.Background()
+			createdAddressIDs = []system.UUID{}
+
+			for i := 0; i < 2; i++ {
+				createdAddressID, _ := system.RandomUUID()
+				createdAddressIDs = append(createdAddressIDs, createdAddressID)
+				mockUUIDGeneratorService.
+					EXPECT().
+					GenerateRandomUUID().
+					Return(createdAddressID, nil)
+			}
+
+			missingAddressID, _ = system.RandomUUID()
+		})
+
+		It("should create every address and report its unique identifier", func() {
+			results := addressDataService.CreateMany(
+				ctx,
+				tenantID,
+				applicationID,
+				[]contract.Address{{AddressDetails: map[string]string{"City": "Wellington"}}, {AddressDetails: map[string]string{"City": "Auckland"}}},
+				"importer")
+
+			Expect(results).To(Equal([]contract.AddressResult{{AddressID: createdAddressIDs[0]}, {AddressID: createdAddressIDs[1]}}))
+
+			address, err := addressDataService.ReadAll(ctx, tenantID, applicationID, createdAddressIDs[1])
+
+			Expect(err).To(BeNil())
+			Expect(address).To(Equal(contract.Address{AddressDetails: map[string]string{"City": "Auckland"}, Version: 1}))
+		})
+
+		It("should read every address and report the ones not found", func() {
+			addressDataService.CreateMany(
+				ctx,
+				tenantID,
+				applicationID,
+				[]contract.Address{{AddressDetails: map[string]string{"City": "Wellington", "Postcode": "6011"}}, {AddressDetails: map[string]string{"City": "Auckland"}}},
+				"")
+
+			results := addressDataService.ReadMany(ctx, tenantID, applicationID, []system.UUID{createdAddressIDs[0], missingAddressID}, []string{"Postcode"})
+
+			Expect(results).To(Equal([]contract.AddressResult{
+				{AddressID: createdAddressIDs[0], Address: contract.Address{AddressDetails: map[string]string{"Postcode": "6011"}, Version: 1}},
+				{AddressID: missingAddressID, Err: contract.NotFoundError{AddressID: missingAddressID}},
+			}))
+
+			results = addressDataService.ReadMany(ctx, tenantID, applicationID, []system.UUID{createdAddressIDs[1]}, nil)
+
+			Expect(results).To(Equal([]contract.AddressResult{
+				{AddressID: createdAddressIDs[1], Address: contract.Address{AddressDetails: map[string]string{"City": "Auckland"}, Version: 1}},
+			}))
+		})
+
+		It("should delete every address and report the ones not found", func() {
+			addressDataService.CreateMany(
+				ctx,
+				tenantID,
+				applicationID,
+				[]contract.Address{{AddressDetails: map[string]string{"City": "Wellington"}}, {AddressDetails: map[string]string{"City": "Auckland"}}},
+				"")
+
+			results := addressDataService.DeleteMany(ctx, tenantID, applicationID, []system.UUID{createdAddressIDs[0], missingAddressID, createdAddressIDs[1]}, "")
+
+			Expect(results).To(HaveLen(3))
+			Expect(results[0]).To(Equal(contract.AddressResult{AddressID: createdAddressIDs[0]}))
+			Expect(results[1].AddressID).To(Equal(missingAddressID))
+			Expect(results[1].Err).NotTo(BeNil())
+			Expect(results[2]).To(Equal(contract.AddressResult{AddressID: createdAddressIDs[1]}))
+
+			_, err := addressDataService.ReadAll(ctx, tenantID, applicationID, createdAddressIDs[1])
+
+			Expect(err).To(Equal(contract.NotFoundError{AddressID: createdAddressIDs[1]}))
+		})
+	})
+
+	Context("when recording events", func() {
+		var (
+			createdEventID system.UUID
+			updatedEventID system.UUID
+			deletedEventID system.UUID
+		)
+
+		BeforeEach(func() {
+			addressDataService.RecordEvents = true
+			createdEventID, _ = system.RandomUUID()
+			updatedEventID, _ = system.RandomUUID()
+			deletedEventID, _ = system.RandomUUID()
+
+			gomock.InOrder(
+				mockUUIDGeneratorService.EXPECT().GenerateRandomUUID().Return(addressID, nil),
+				mockUUIDGeneratorService.EXPECT().GenerateRandomUUID().Return(createdEventID, nil),
+				mockUUIDGeneratorService.EXPECT().GenerateRandomUUID().Return(updatedEventID, nil),
+				mockUUIDGeneratorService.EXPECT().GenerateRandomUUID().Return(deletedEventID, nil))
+
+			_, err := addressDataService.Create(ctx, tenantID, applicationID, contract.Address{AddressDetails: map[string]string{"City": "Christchurch"}}, "crm")
+
+			Expect(err).To(BeNil())
+			Expect(addressDataService.Patch(ctx, tenantID, applicationID, addressID, map[string]string{"Postcode": "8011"}, nil, contract.AnyVersion, "crm")).To(BeNil())
+			Expect(addressDataService.Delete(ctx, tenantID, applicationID, addressID, contract.AnyVersion, "")).To(BeNil())
+		})
+
+		It("should write an event along with every change holding the address details before and after the change", func() {
+			events, err := addressDataService.ReadEvents(ctx, 10)
+
+			Expect(err).To(BeNil())
+			Expect(events).To(HaveLen(3))
+
+			for _, event := range events {
+				Expect(event.TenantID).To(Equal(tenantID))
+				Expect(event.ApplicationID).To(Equal(applicationID))
+				Expect(event.AddressID).To(Equal(addressID))
+				Expect(event.ChangedAt.IsZero()).To(BeFalse())
+			}
+
+			Expect(events[0].EventID).To(Equal(createdEventID))
+			Expect(events[0].EventType).To(Equal(contract.AddressCreated))
+			Expect(events[0].Version).To(Equal(int64(1)))
+			Expect(events[0].OldAddressDetails).To(BeNil())
+			Expect(events[0].NewAddressDetails).To(Equal(map[string]string{"City": "Christchurch"}))
+			Expect(events[0].ChangedBy).To(Equal("crm"))
+
+			Expect(events[1].EventID).To(Equal(updatedEventID))
+			Expect(events[1].EventType).To(Equal(contract.AddressUpdated))
+			Expect(events[1].Version).To(Equal(int64(2)))
+			Expect(events[1].OldAddressDetails).To(Equal(map[string]string{"City": "Christchurch"}))
+			Expect(events[1].NewAddressDetails).To(Equal(map[string]string{"City": "Christchurch", "Postcode": "8011"}))
+
+			Expect(events[2].EventID).To(Equal(deletedEventID))
+			Expect(events[2].EventType).To(Equal(contract.AddressDeleted))
+			Expect(events[2].Version).To(Equal(int64(3)))
+			Expect(events[2].OldAddressDetails).To(Equal(map[string]string{"City": "Christchurch", "Postcode": "8011"}))
+			Expect(events[2].NewAddressDetails).To(BeNil())
+		})
+
+		It("should return no more than the provided number of events", func() {
+			events, err := addressDataService.ReadEvents(ctx, 2)
+
+			Expect(err).To(BeNil())
+			Expect(events).To(HaveLen(2))
+			Expect(events[0].EventID).To(Equal(createdEventID))
+		})
+
+		It("should not return the removed events again", func() {
+			events, _ := addressDataService.ReadEvents(ctx, 2)
+
+			Expect(addressDataService.RemoveEvents(ctx, events)).To(BeNil())
+
+			events, err := addressDataService.ReadEvents(ctx, 10)
+
+			Expect(err).To(BeNil())
+			Expect(events).To(HaveLen(1))
+			Expect(events[0].EventID).To(Equal(deletedEventID))
+		})
+
+		It("should not write the event if the change fails", func() {
+			unusedEventID, _ := system.RandomUUID()
+			mockUUIDGeneratorService.
+				EXPECT().
+				GenerateRandomUUID().
+				Return(unusedEventID, nil)
+
+			Expect(addressDataService.Delete(ctx, tenantID, applicationID, addressID, contract.AnyVersion, "")).
+				To(Equal(contract.NotFoundError{AddressID: addressID}))
+
+			events, err := addressDataService.ReadEvents(ctx, 10)
+
+			Expect(err).To(BeNil())
+			Expect(events).To(HaveLen(3))
+		})
+
+		It("should remove the events of the erased application", func() {
+			certificateID, _ := system.RandomUUID()
+			mockUUIDGeneratorService.
+				EXPECT().
+				GenerateRandomUUID().
+				Return(certificateID, nil)
+
+			certificate, err := addressDataService.PurgeApplication(ctx, tenantID, applicationID, "")
+
+			Expect(err).To(BeNil())
+			Expect(certificate.ErasedRows["address_event_outbox"]).To(Equal(int64(3)))
+
+			events, err := addressDataService.ReadEvents(ctx, 10)
+
+			Expect(err).To(BeNil())
+			Expect(events).To(BeEmpty())
+		})
+	})
+
+	Context("when not recording events", func() {
+		It("should not write any event", func() {
+			mockUUIDGeneratorService.
+				EXPECT().
+				GenerateRandomUUID().
+				Return(addressID, nil)
+
+			_, err := addressDataService.Create(ctx, tenantID, applicationID, validAddress, "")
+
+			Expect(err).To(BeNil())
+
+			events, err := addressDataService.ReadEvents(ctx, 10)
+
+			Expect(err).To(BeNil())
+			Expect(events).To(BeEmpty())
+		})
+	})
+
+	Context("when erasing a tenant or an application", func() {
+		var (
+			otherApplicationID system.UUID
+			otherAddressID     system.UUID
+			certificateID      system.UUID
+		)
+
+		BeforeEach(func() {
+			ctx = context.Background()
+			otherApplicationID, _ = system.RandomUUID()
+			otherAddressID, _ = system.RandomUUID()
+			certificateID, _ = system.RandomUUID()
+
+			gomock.InOrder(
+				mockUUIDGeneratorService.EXPECT().GenerateRandomUUID().Return(addressID, nil),
+				mockUUIDGeneratorService.EXPECT().GenerateRandomUUID().Return(otherAddressID, nil),
+				mockUUIDGeneratorService.EXPECT().GenerateRandomUUID().Return(certificateID, nil))
+
+			addressDataService.Create(ctx, tenantID, applicationID, validAddress, "")
+			addressDataService.Delete(ctx, tenantID, applicationID, addressID, contract.AnyVersion, "")
+			addressDataService.Create(ctx, tenantID, otherApplicationID, validAddress, "")
+		})
+
+		It("should remove all the addresses of the tenant, deleted or not, and return the erasure certificate", func() {
+			certificate, err := addressDataService.PurgeTenant(ctx, tenantID, "privacy-officer")
+
+			Expect(err).To(BeNil())
+			Expect(certificate.CertificateID).To(Equal(certificateID))
+			Expect(certificate.TenantID).To(Equal(tenantID))
+			Expect(certificate.ApplicationID).To(Equal(system.EmptyUUID))
+			Expect(certificate.RequestedBy).To(Equal("privacy-officer"))
+			Expect(certificate.ErasedRows).To(Equal(map[string]int64{"address": 2, "address_history": 3, "address_event_outbox": 0}))
+
+			_, err = addressDataService.ReadAll(ctx, tenantID, otherApplicationID, otherAddressID)
+
+			Expect(err).To(Equal(contract.NotFoundError{AddressID: otherAddressID}))
+
+			_, err = addressDataService.History(ctx, tenantID, applicationID, addressID)
+
+			Expect(err).To(Equal(contract.NotFoundError{AddressID: addressID}))
+			Expect(addressDataService.Restore(ctx, tenantID, applicationID, addressID, time.Now().Add(-time.Hour), "")).
+				To(Equal(contract.NotFoundError{AddressID: addressID, Deleted: true}))
+		})
+
+		It("should remove only the addresses of the application", func() {
+			certificate, err := addressDataService.PurgeApplication(ctx, tenantID, applicationID, "")
+
+			Expect(err).To(BeNil())
+			Expect(certificate.ApplicationID).To(Equal(applicationID))
+			Expect(certificate.ErasedRows).To(Equal(map[string]int64{"address": 1, "address_history": 2, "address_event_outbox": 0}))
+
+			_, err = addressDataService.ReadAll(ctx, tenantID, otherApplicationID, otherAddressID)
+
+			Expect(err).To(BeNil())
+		})
+	})
+
+	Context("when address is created with a TTL", func() {
+		BeforeEach(func() {
+			ctx = context.Background()
+			mockUUIDGeneratorService.
+				EXPECT().
+				GenerateRandomUUID().
+				Return(addressID, nil)
+
+			_, err := addressDataService.Create(
+				ctx,
+				tenantID,
+				applicationID,
+				contract.Address{AddressDetails: validAddress.AddressDetails, TTL: 50 * time.Millisecond},
+				"")
+
+			Expect(err).To(BeNil())
+		})
+
+		It("should return the remaining TTL when reading the address", func() {
+			address, err := addressDataService.ReadAll(ctx, tenantID, applicationID, addressID)
+
+			Expect(err).To(BeNil())
+			Expect(address.TTL).To(BeNumerically(">", 0))
+			Expect(address.TTL).To(BeNumerically("<=", 50*time.Millisecond))
+		})
+
+		It("should not return the address once it has expired", func() {
+			time.Sleep(60 * time.Millisecond)
+
+			_, err := addressDataService.ReadAll(ctx, tenantID, applicationID, addressID)
+
+			Expect(err).To(Equal(contract.NotFoundError{AddressID: addressID}))
+
+			page, err := addressDataService.List(ctx, tenantID, applicationID, 10, "")
+
+			Expect(err).To(BeNil())
+			Expect(page.Addresses).To(BeEmpty())
+
+			listedAddresses, err := addressDataService.FindByDetail(ctx, tenantID, applicationID, map[string]string{"City": "Christchurch"})
+
+			Expect(err).To(BeNil())
+			Expect(listedAddresses).To(BeEmpty())
+		})
+
+		It("should keep the TTL when the address is patched", func() {
+			Expect(addressDataService.Patch(ctx, tenantID, applicationID, addressID, map[string]string{"Suburb": "Riccarton"}, nil, contract.AnyVersion, "")).To(BeNil())
+
+			time.Sleep(60 * time.Millisecond)
+
+			_, err := addressDataService.ReadAll(ctx, tenantID, applicationID, addressID)
+
+			Expect(err).To(Equal(contract.NotFoundError{AddressID: addressID}))
+		})
+
+		It("should never expire the address once it is updated without a TTL", func() {
+			Expect(addressDataService.Update(ctx, tenantID, applicationID, addressID, validAddress, contract.AnyVersion, "")).To(BeNil())
+
+			time.Sleep(60 * time.Millisecond)
+
+			address, err := addressDataService.ReadAll(ctx, tenantID, applicationID, addressID)
+
+			Expect(err).To(BeNil())
+			Expect(address).To(Equal(contract.Address{AddressDetails: validAddress.AddressDetails, Version: 2}))
+		})
+	})
+})
+
+func TestInMemoryAddressDataService(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "In-memory address data service behaviour")
+}
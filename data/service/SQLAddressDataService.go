@@ -0,0 +1,1356 @@
+package service
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/micro-business/AddressService/data/contract"
+	"github.com/micro-business/Micro-Business-Core/common/diagnostics"
+	"github.com/micro-business/Micro-Business-Core/system"
+	"golang.org/x/net/context"
+)
+
+// SQLAddressDataService provides access to add new address and update/retrieve/remove an existing address, keeping the
+// addresses in a relational database through database/sql. The tables are created by DatabaseScript.sql.
+type SQLAddressDataService struct {
+	UUIDGeneratorService system.UUIDGeneratorService
+	DB                   *sql.DB
+
+	// RecordEvents is true to write an event to address_event_outbox table in the same transaction as every address
+	// creation, update and deletion, so the events can be published by the event relay.
+	RecordEvents bool
+}
+
+// Close closes the underlying database.
+// Returns error if something goes wrong.
+func (addressDataService SQLAddressDataService) Close() error {
+	diagnostics.IsNotNil(addressDataService.DB, "addressDataService.DB", "DB must be provided.")
+
+	return addressDataService.DB.Close()
+}
+
+// Create creates a new address.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+// applicationID: Mandatory. The unique identifier of the tenant's application will be owning the address.
+// address: Mandatory. The reference to the new address information.
+// changedBy: Optional. Identifies who makes the change, recorded in the address history.
+// Returns either the unique identifier of the new address or error if something goes wrong.
+func (addressDataService SQLAddressDataService) Create(ctx context.Context, tenantID, applicationID system.UUID, address contract.Address, changedBy string) (system.UUID, error) {
+	diagnostics.IsNotNil(addressDataService.UUIDGeneratorService, "addressDataService.UUIDGeneratorService", "UUIDGeneratorService must be provided.")
+	diagnostics.IsNotNil(addressDataService.DB, "addressDataService.DB", "DB must be provided.")
+
+	addressID, err := addressDataService.UUIDGeneratorService.GenerateRandomUUID()
+
+	if err != nil {
+		return system.EmptyUUID, err
+	}
+
+	err = executeInTransaction(ctx, addressDataService.DB, func(transaction *sql.Tx) error {
+		if err := insertSQLAddress(ctx, transaction, tenantID, applicationID, addressID, address); err != nil {
+			return err
+		}
+
+		_, err := transaction.ExecContext(
+			ctx,
+			"INSERT INTO address_metadata"+
+				" (tenant_id, application_id, address_id, version, expires_at, fingerprint)"+
+				" VALUES($1, $2, $3, 1, $4, $5)",
+			tenantID.String(),
+			applicationID.String(),
+			addressID.String(),
+			mapDurationToSQLExpiry(address.TTL),
+			mapFingerprintToSQL(address.Fingerprint))
+
+		if err != nil {
+			return err
+		}
+
+		if err = insertSQLAddressHistory(ctx, transaction, tenantID, applicationID, addressID, address, 1, changedBy, false); err != nil {
+			return err
+		}
+
+		return addressDataService.recordSQLAddressEvent(
+			ctx,
+			transaction,
+			contract.AddressCreated,
+			tenantID,
+			applicationID,
+			addressID,
+			1,
+			nil,
+			address.AddressDetails,
+			changedBy)
+	})
+
+	if err != nil {
+		return system.EmptyUUID, err
+	}
+
+	return addressID, nil
+}
+
+// CreateMany creates several new addresses. Every address is created on its own, so failing to create an address does
+// not stop creating the rest of them.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the addresses.
+// applicationID: Mandatory. The unique identifier of the tenant's application will be owning the addresses.
+// addresses: Mandatory. The new addresses information.
+// changedBy: Optional. Identifies who makes the change, recorded in the address history.
+// Returns the result of creating every address in the same order as addresses.
+func (addressDataService SQLAddressDataService) CreateMany(
+	ctx context.Context,
+	tenantID, applicationID system.UUID,
+	addresses []contract.Address,
+	changedBy string) []contract.AddressResult {
+	return createAddresses(ctx, addressDataService, tenantID, applicationID, addresses, changedBy)
+}
+
+// Update updates an existing address.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+// applicationID: Mandatory. The unique identifier of the tenant's application will be owning the address.
+// addressID: Mandatory. The unique identifier of the existing address.
+// address: Mandatory. The reeference to the updated address information.
+// expectedVersion: Optional. The version the address is expected to have, or AnyVersion to skip the version check.
+// changedBy: Optional. Identifies who makes the change, recorded in the address history.
+// Returns ConflictError if the address version does not match the expected version, or error if something goes wrong.
+func (addressDataService SQLAddressDataService) Update(
+	ctx context.Context,
+	tenantID, applicationID, addressID system.UUID,
+	address contract.Address,
+	expectedVersion int64,
+	changedBy string) error {
+	diagnostics.IsNotNil(addressDataService.DB, "addressDataService.DB", "DB must be provided.")
+
+	return executeInTransaction(ctx, addressDataService.DB, func(transaction *sql.Tx) error {
+		existingAddress, err := readAllSQLAddressDetails(ctx, transaction, tenantID, applicationID, addressID)
+
+		if err != nil {
+			return err
+		}
+
+		if err = deleteSQLAddress(ctx, transaction, tenantID, applicationID, addressID); err != nil {
+			return err
+		}
+
+		newVersion, err := changeSQLAddressVersion(ctx, transaction, tenantID, applicationID, addressID, expectedVersion, time.Time{})
+
+		if err != nil {
+			return err
+		}
+
+		if err = insertSQLAddress(ctx, transaction, tenantID, applicationID, addressID, address); err != nil {
+			return err
+		}
+
+		if err = changeSQLAddressExpiry(ctx, transaction, tenantID, applicationID, addressID, address.TTL); err != nil {
+			return err
+		}
+
+		if err = changeSQLAddressFingerprint(ctx, transaction, tenantID, applicationID, addressID, address.Fingerprint); err != nil {
+			return err
+		}
+
+		if err = insertSQLAddressHistory(ctx, transaction, tenantID, applicationID, addressID, address, newVersion, changedBy, false); err != nil {
+			return err
+		}
+
+		return addressDataService.recordSQLAddressEvent(
+			ctx,
+			transaction,
+			contract.AddressUpdated,
+			tenantID,
+			applicationID,
+			addressID,
+			newVersion,
+			existingAddress.AddressDetails,
+			address.AddressDetails,
+			changedBy)
+	})
+}
+
+// Patch changes only the provided details of an existing address and leaves the rest of them unchanged.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+// applicationID: Mandatory. The unique identifier of the tenant's application will be owning the address.
+// addressID: Mandatory. The unique identifier of the existing address.
+// set: Optional. The address details keys to add or change along with their new values.
+// remove: Optional. The address details keys to remove.
+// expectedVersion: Optional. The version the address is expected to have, or AnyVersion to skip the version check.
+// changedBy: Optional. Identifies who makes the change, recorded in the address history.
+// Returns ConflictError if the address version does not match the expected version, or error if something goes wrong.
+func (addressDataService SQLAddressDataService) Patch(
+	ctx context.Context,
+	tenantID, applicationID, addressID system.UUID,
+	set map[string]string,
+	remove []string,
+	expectedVersion int64,
+	changedBy string) error {
+	diagnostics.IsNotNil(addressDataService.DB, "addressDataService.DB", "DB must be provided.")
+
+	return executeInTransaction(ctx, addressDataService.DB, func(transaction *sql.Tx) error {
+		existingAddress, err := readAllSQLAddressDetails(ctx, transaction, tenantID, applicationID, addressID)
+
+		if err != nil {
+			return err
+		}
+
+		patchedAddress, err := patchAddressDetails(existingAddress, set, remove, addressID)
+
+		if err != nil {
+			return err
+		}
+
+		if err = deleteSQLAddress(ctx, transaction, tenantID, applicationID, addressID); err != nil {
+			return err
+		}
+
+		newVersion, err := changeSQLAddressVersion(ctx, transaction, tenantID, applicationID, addressID, expectedVersion, time.Time{})
+
+		if err != nil {
+			return err
+		}
+
+		if err = insertSQLAddress(ctx, transaction, tenantID, applicationID, addressID, patchedAddress); err != nil {
+			return err
+		}
+
+		if err = insertSQLAddressHistory(ctx, transaction, tenantID, applicationID, addressID, patchedAddress, newVersion, changedBy, false); err != nil {
+			return err
+		}
+
+		return addressDataService.recordSQLAddressEvent(
+			ctx,
+			transaction,
+			contract.AddressUpdated,
+			tenantID,
+			applicationID,
+			addressID,
+			newVersion,
+			existingAddress.AddressDetails,
+			patchedAddress.AddressDetails,
+			changedBy)
+	})
+}
+
+// Read retrieves an existing address information and returns only the detail which the keys provided by the keys.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+// applicationID: Mandatory. The unique identifier of the tenant's application will be owning the address.
+// addressID: Mandatory. The unique identifier of the existing address.
+// keys: Mandatory. The interested address details keys to return.
+// Returns either the address information or error if something goes wrong.
+func (addressDataService SQLAddressDataService) Read(ctx context.Context, tenantID, applicationID, addressID system.UUID, keys []string) (contract.Address, error) {
+	diagnostics.IsNotNil(addressDataService.DB, "addressDataService.DB", "DB must be provided.")
+
+	placeholders := make([]string, 0, len(keys))
+	args := []interface{}{tenantID.String(), applicationID.String(), addressID.String()}
+
+	for _, key := range keys {
+		args = append(args, key)
+		placeholders = append(placeholders, "$"+strconv.Itoa(len(args)))
+	}
+
+	address, err := readSQLAddressDetails(
+		ctx,
+		addressDataService.DB,
+		tenantID,
+		applicationID,
+		addressID,
+		"SELECT address_key, address_value"+
+			" FROM address"+
+			" WHERE"+
+			" tenant_id = $1"+
+			" AND application_id = $2"+
+			" AND address_id = $3"+
+			" AND address_key IN ("+strings.Join(placeholders, ", ")+")",
+		args...)
+
+	return address, mapSQLError(err)
+}
+
+// ReadAll retrieves an existing address information and returns all the detail of it.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+// applicationID: Mandatory. The unique identifier of the tenant's application will be owning the address.
+// addressID: Mandatory. The unique identifier of the existing address.
+// Returns either the address information or error if something goes wrong.
+func (addressDataService SQLAddressDataService) ReadAll(ctx context.Context, tenantID, applicationID, addressID system.UUID) (contract.Address, error) {
+	diagnostics.IsNotNil(addressDataService.DB, "addressDataService.DB", "DB must be provided.")
+
+	address, err := readAllSQLAddressDetails(ctx, addressDataService.DB, tenantID, applicationID, addressID)
+
+	return address, mapSQLError(err)
+}
+
+// ReadMany retrieves several existing addresses information. Every address is read on its own, so failing to read an
+// address does not stop reading the rest of them.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the addresses.
+// applicationID: Mandatory. The unique identifier of the tenant's application owning the addresses.
+// addressIDs: Mandatory. The unique identifiers of the existing addresses.
+// keys: Optional. The interested address details keys to return, or empty to return all the details.
+// Returns the result of reading every address in the same order as addressIDs.
+func (addressDataService SQLAddressDataService) ReadMany(
+	ctx context.Context,
+	tenantID, applicationID system.UUID,
+	addressIDs []system.UUID,
+	keys []string) []contract.AddressResult {
+	return readAddresses(ctx, addressDataService, tenantID, applicationID, addressIDs, keys)
+}
+
+// List retrieves a page of the addresses owned by a tenant's application. The deleted addresses are not returned.
+// The addresses are ordered by their unique identifiers.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the addresses.
+// applicationID: Mandatory. The unique identifier of the tenant's application owning the addresses.
+// pageSize: Mandatory. The maximum number of addresses to return.
+// cursor: Optional. The cursor returned with the previous page, or empty to return the first page.
+// Returns either the page of addresses or error if the cursor is invalid or something goes wrong.
+func (addressDataService SQLAddressDataService) List(ctx context.Context, tenantID, applicationID system.UUID, pageSize int, cursor string) (contract.AddressPage, error) {
+	diagnostics.IsNotNil(addressDataService.DB, "addressDataService.DB", "DB must be provided.")
+
+	lastAddressID, err := decodeAddressIDCursor(cursor)
+
+	if err != nil {
+		return contract.AddressPage{}, mapSQLError(err)
+	}
+
+	// One more address than the page size is selected to find out whether there is a next page.
+	rows, err := addressDataService.DB.QueryContext(
+		ctx,
+		"SELECT address_id, version, expires_at"+
+			" FROM address_metadata"+
+			" WHERE"+
+			" tenant_id = $1"+
+			" AND application_id = $2"+
+			" AND address_id > $3"+
+			" AND deleted_at IS NULL"+
+			" AND (expires_at IS NULL OR expires_at > $4)"+
+			" ORDER BY address_id"+
+			" LIMIT $5",
+		tenantID.String(),
+		applicationID.String(),
+		lastAddressID,
+		time.Now().UTC(),
+		pageSize+1)
+
+	if err != nil {
+		return contract.AddressPage{}, mapSQLError(err)
+	}
+
+	defer rows.Close()
+
+	var addressID string
+	var version int64
+	var expiresAt *time.Time
+
+	page := contract.AddressPage{Addresses: []contract.ListedAddress{}}
+
+	for rows.Next() {
+		if err = rows.Scan(&addressID, &version, &expiresAt); err != nil {
+			return contract.AddressPage{}, mapSQLError(err)
+		}
+
+		if len(page.Addresses) == pageSize {
+			page.NextCursor = encodeAddressIDCursor(page.Addresses[pageSize-1].AddressID)
+
+			break
+		}
+
+		listedAddress := contract.ListedAddress{Address: contract.Address{Version: version, TTL: mapSQLExpiryToDuration(expiresAt)}}
+
+		if listedAddress.AddressID, err = system.ParseUUID(addressID); err != nil {
+			return contract.AddressPage{}, mapSQLError(err)
+		}
+
+		page.Addresses = append(page.Addresses, listedAddress)
+	}
+
+	if err = rows.Err(); err != nil {
+		return contract.AddressPage{}, mapSQLError(err)
+	}
+
+	rows.Close()
+
+	if err = selectSQLListedAddressDetails(ctx, addressDataService.DB, tenantID, applicationID, page.Addresses); err != nil {
+		return contract.AddressPage{}, mapSQLError(err)
+	}
+
+	return page, nil
+}
+
+// FindByDetail retrieves the addresses owned by a tenant's application whose details match all the provided criteria.
+// The deleted addresses are not returned. The addresses are ordered by their unique identifiers, and no more than
+// MaxFoundAddresses addresses are returned.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the addresses.
+// applicationID: Mandatory. The unique identifier of the tenant's application owning the addresses.
+// criteria: Mandatory. The address details keys along with the values the returned addresses must have.
+// Returns either the matching addresses or error if something goes wrong.
+func (addressDataService SQLAddressDataService) FindByDetail(
+	ctx context.Context,
+	tenantID, applicationID system.UUID,
+	criteria map[string]string) ([]contract.ListedAddress, error) {
+	diagnostics.IsNotNil(addressDataService.DB, "addressDataService.DB", "DB must be provided.")
+
+	conditions := make([]string, 0, len(criteria))
+	args := []interface{}{tenantID.String(), applicationID.String()}
+
+	for key, value := range criteria {
+		args = append(args, key, value)
+		conditions = append(
+			conditions,
+			"(detail.address_key = $"+strconv.Itoa(len(args)-1)+" AND detail.address_value = $"+strconv.Itoa(len(args))+")")
+	}
+
+	args = append(args, time.Now().UTC(), len(criteria), contract.MaxFoundAddresses)
+
+	// An address matches all the criteria when one index row is found for each of them.
+	rows, err := addressDataService.DB.QueryContext(
+		ctx,
+		"SELECT detail.address_id, metadata.version, metadata.expires_at"+
+			" FROM address_indexed_by_address_key detail"+
+			" INNER JOIN address_metadata metadata"+
+			" ON metadata.tenant_id = detail.tenant_id"+
+			" AND metadata.application_id = detail.application_id"+
+			" AND metadata.address_id = detail.address_id"+
+			" WHERE"+
+			" detail.tenant_id = $1"+
+			" AND detail.application_id = $2"+
+			" AND metadata.deleted_at IS NULL"+
+			" AND ("+strings.Join(conditions, " OR ")+")"+
+			" AND (metadata.expires_at IS NULL OR metadata.expires_at > $"+strconv.Itoa(len(args)-2)+")"+
+			" GROUP BY detail.address_id, metadata.version, metadata.expires_at"+
+			" HAVING COUNT(*) = $"+strconv.Itoa(len(args)-1)+
+			" ORDER BY detail.address_id"+
+			" LIMIT $"+strconv.Itoa(len(args)),
+		args...)
+
+	if err != nil {
+		return nil, mapSQLError(err)
+	}
+
+	defer rows.Close()
+
+	var addressID string
+	var version int64
+	var expiresAt *time.Time
+
+	listedAddresses := []contract.ListedAddress{}
+
+	for rows.Next() {
+		if err = rows.Scan(&addressID, &version, &expiresAt); err != nil {
+			return nil, mapSQLError(err)
+		}
+
+		listedAddress := contract.ListedAddress{Address: contract.Address{Version: version, TTL: mapSQLExpiryToDuration(expiresAt)}}
+
+		if listedAddress.AddressID, err = system.ParseUUID(addressID); err != nil {
+			return nil, mapSQLError(err)
+		}
+
+		listedAddresses = append(listedAddresses, listedAddress)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, mapSQLError(err)
+	}
+
+	rows.Close()
+
+	if err = selectSQLListedAddressDetails(ctx, addressDataService.DB, tenantID, applicationID, listedAddresses); err != nil {
+		return nil, mapSQLError(err)
+	}
+
+	return listedAddresses, nil
+}
+
+// FindByFingerprint retrieves the addresses owned by a tenant's application which were created or last updated with the
+// provided fingerprint. The deleted addresses are not returned. The addresses are ordered by their unique identifiers.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the addresses.
+// applicationID: Mandatory. The unique identifier of the tenant's application owning the addresses.
+// fingerprint: Mandatory. The fingerprint the returned addresses were created or last updated with.
+// Returns either the matching addresses or error if something goes wrong.
+func (addressDataService SQLAddressDataService) FindByFingerprint(
+	ctx context.Context,
+	tenantID, applicationID system.UUID,
+	fingerprint string) ([]contract.ListedAddress, error) {
+	diagnostics.IsNotNil(addressDataService.DB, "addressDataService.DB", "DB must be provided.")
+
+	rows, err := addressDataService.DB.QueryContext(
+		ctx,
+		"SELECT address_id, version, expires_at"+
+			" FROM address_metadata"+
+			" WHERE"+
+			" tenant_id = $1"+
+			" AND application_id = $2"+
+			" AND fingerprint = $3"+
+			" AND deleted_at IS NULL"+
+			" AND (expires_at IS NULL OR expires_at > $4)"+
+			" ORDER BY address_id",
+		tenantID.String(),
+		applicationID.String(),
+		mapFingerprintToSQL(fingerprint),
+		time.Now().UTC())
+
+	if err != nil {
+		return nil, mapSQLError(err)
+	}
+
+	defer rows.Close()
+
+	var addressID string
+	var version int64
+	var expiresAt *time.Time
+
+	listedAddresses := []contract.ListedAddress{}
+
+	for rows.Next() {
+		if err = rows.Scan(&addressID, &version, &expiresAt); err != nil {
+			return nil, mapSQLError(err)
+		}
+
+		listedAddress := contract.ListedAddress{Address: contract.Address{Version: version, TTL: mapSQLExpiryToDuration(expiresAt)}}
+
+		if listedAddress.AddressID, err = system.ParseUUID(addressID); err != nil {
+			return nil, mapSQLError(err)
+		}
+
+		listedAddresses = append(listedAddresses, listedAddress)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, mapSQLError(err)
+	}
+
+	rows.Close()
+
+	if err = selectSQLListedAddressDetails(ctx, addressDataService.DB, tenantID, applicationID, listedAddresses); err != nil {
+		return nil, mapSQLError(err)
+	}
+
+	return listedAddresses, nil
+}
+
+// History retrieves all the recorded versions of an address, including the versions recorded when it was deleted and
+// restored. The history is kept after the address is purged.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+// applicationID: Mandatory. The unique identifier of the tenant's application will be owning the address.
+// addressID: Mandatory. The unique identifier of the address.
+// Returns either the recorded versions ordered from the oldest to the newest or error if no version is recorded or
+// something goes wrong.
+func (addressDataService SQLAddressDataService) History(ctx context.Context, tenantID, applicationID, addressID system.UUID) ([]contract.AddressHistoryEntry, error) {
+	diagnostics.IsNotNil(addressDataService.DB, "addressDataService.DB", "DB must be provided.")
+
+	history, err := readSQLAddressHistory(ctx, addressDataService.DB, tenantID, applicationID, addressID)
+
+	return history, mapSQLError(err)
+}
+
+// ReadAt retrieves the address information as it was at the provided time.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+// applicationID: Mandatory. The unique identifier of the tenant's application will be owning the address.
+// addressID: Mandatory. The unique identifier of the address.
+// timestamp: Mandatory. The time to return the address information at.
+// Returns either the address information or error if the address did not exist or was deleted at the provided time, or
+// if something goes wrong.
+func (addressDataService SQLAddressDataService) ReadAt(ctx context.Context, tenantID, applicationID, addressID system.UUID, timestamp time.Time) (contract.Address, error) {
+	diagnostics.IsNotNil(addressDataService.DB, "addressDataService.DB", "DB must be provided.")
+
+	history, err := readSQLAddressHistory(ctx, addressDataService.DB, tenantID, applicationID, addressID)
+
+	if err != nil {
+		return contract.Address{}, mapSQLError(err)
+	}
+
+	return findAddressAt(history, timestamp, addressID)
+}
+
+// Delete marks an existing address as deleted. The deleted address is not returned anymore but it can be restored
+// until it is purged.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+// applicationID: Mandatory. The unique identifier of the tenant's application will be owning the address.
+// addressID: Mandatory. The unique identifier of the existing address to remove.
+// expectedVersion: Optional. The version the address is expected to have, or AnyVersion to skip the version check.
+// changedBy: Optional. Identifies who makes the change, recorded in the address history.
+// Returns ConflictError if the address version does not match the expected version, or error if something goes wrong.
+func (addressDataService SQLAddressDataService) Delete(ctx context.Context, tenantID, applicationID, addressID system.UUID, expectedVersion int64, changedBy string) error {
+	diagnostics.IsNotNil(addressDataService.DB, "addressDataService.DB", "DB must be provided.")
+
+	return executeInTransaction(ctx, addressDataService.DB, func(transaction *sql.Tx) error {
+		existingAddress, err := readAllSQLAddressDetails(ctx, transaction, tenantID, applicationID, addressID)
+
+		if err != nil {
+			return err
+		}
+
+		newVersion, err := changeSQLAddressVersion(ctx, transaction, tenantID, applicationID, addressID, expectedVersion, time.Now())
+
+		if err != nil {
+			return err
+		}
+
+		if err = insertSQLAddressHistory(ctx, transaction, tenantID, applicationID, addressID, existingAddress, newVersion, changedBy, true); err != nil {
+			return err
+		}
+
+		return addressDataService.recordSQLAddressEvent(
+			ctx,
+			transaction,
+			contract.AddressDeleted,
+			tenantID,
+			applicationID,
+			addressID,
+			newVersion,
+			existingAddress.AddressDetails,
+			nil,
+			changedBy)
+	})
+}
+
+// DeleteMany marks several existing addresses as deleted, skipping the version check. Every address is deleted on its
+// own, so failing to delete an address does not stop deleting the rest of them.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the addresses.
+// applicationID: Mandatory. The unique identifier of the tenant's application owning the addresses.
+// addressIDs: Mandatory. The unique identifiers of the existing addresses to remove.
+// changedBy: Optional. Identifies who makes the change, recorded in the address history.
+// Returns the result of deleting every address in the same order as addressIDs.
+func (addressDataService SQLAddressDataService) DeleteMany(
+	ctx context.Context,
+	tenantID, applicationID system.UUID,
+	addressIDs []system.UUID,
+	changedBy string) []contract.AddressResult {
+	return deleteAddresses(ctx, addressDataService, tenantID, applicationID, addressIDs, changedBy)
+}
+
+// Restore restores a deleted address.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+// applicationID: Mandatory. The unique identifier of the tenant's application will be owning the address.
+// addressID: Mandatory. The unique identifier of the deleted address to restore.
+// deletedSince: Mandatory. The address is restored only if it was deleted at or after this time.
+// changedBy: Optional. Identifies who makes the change, recorded in the address history.
+// Returns error if the deleted address does not exist or was deleted before deletedSince, or if something goes wrong.
+func (addressDataService SQLAddressDataService) Restore(
+	ctx context.Context,
+	tenantID, applicationID, addressID system.UUID,
+	deletedSince time.Time,
+	changedBy string) error {
+	diagnostics.IsNotNil(addressDataService.DB, "addressDataService.DB", "DB must be provided.")
+
+	return executeInTransaction(ctx, addressDataService.DB, func(transaction *sql.Tx) error {
+		version, deletedAt, err := readSQLAddressMetadata(ctx, transaction, tenantID, applicationID, addressID)
+
+		if err != nil {
+			return err
+		}
+
+		if deletedAt.IsZero() || deletedAt.Before(deletedSince) {
+			return contract.NotFoundError{AddressID: addressID, Deleted: true}
+		}
+
+		if _, err = readSQLAddressTTL(ctx, transaction, tenantID, applicationID, addressID); err != nil {
+			return contract.NotFoundError{AddressID: addressID, Deleted: true}
+		}
+
+		deletedAddress, err := selectSQLAddressDetails(ctx, transaction, addressID, selectAllSQLAddressDetailsQuery, tenantID.String(), applicationID.String(), addressID.String())
+
+		if err != nil {
+			return err
+		}
+
+		newVersion, err := changeSQLAddressVersion(ctx, transaction, tenantID, applicationID, addressID, version, time.Time{})
+
+		if err != nil {
+			return err
+		}
+
+		return insertSQLAddressHistory(ctx, transaction, tenantID, applicationID, addressID, deletedAddress, newVersion, changedBy, false)
+	})
+}
+
+// Purge removes a deleted address for good.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+// applicationID: Mandatory. The unique identifier of the tenant's application will be owning the address.
+// addressID: Mandatory. The unique identifier of the deleted address to purge.
+// Returns error if the deleted address does not exist, or if something goes wrong.
+func (addressDataService SQLAddressDataService) Purge(ctx context.Context, tenantID, applicationID, addressID system.UUID) error {
+	diagnostics.IsNotNil(addressDataService.DB, "addressDataService.DB", "DB must be provided.")
+
+	return executeInTransaction(ctx, addressDataService.DB, func(transaction *sql.Tx) error {
+		return purgeSQLAddress(ctx, transaction, tenantID, applicationID, addressID, time.Time{})
+	})
+}
+
+// PurgeDeleted removes for good all the addresses of all tenants deleted before the provided time.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// deletedBefore: Mandatory. The addresses deleted before this time are purged.
+// Returns error if something goes wrong.
+func (addressDataService SQLAddressDataService) PurgeDeleted(ctx context.Context, deletedBefore time.Time) error {
+	diagnostics.IsNotNil(addressDataService.DB, "addressDataService.DB", "DB must be provided.")
+
+	rows, err := addressDataService.DB.QueryContext(
+		ctx,
+		"SELECT tenant_id, application_id, address_id, deleted_at"+
+			" FROM address_metadata"+
+			" WHERE deleted_at IS NOT NULL")
+
+	if err != nil {
+		return mapSQLError(err)
+	}
+
+	defer rows.Close()
+
+	var tenantID, applicationID, addressID string
+	var deletedAt time.Time
+
+	expiredAddresses := [][]system.UUID{}
+
+	for rows.Next() {
+		if err = rows.Scan(&tenantID, &applicationID, &addressID, &deletedAt); err != nil {
+			return mapSQLError(err)
+		}
+
+		if deletedAt.Before(deletedBefore) {
+			expiredAddress := make([]system.UUID, 3)
+
+			for idx, id := range []string{tenantID, applicationID, addressID} {
+				if expiredAddress[idx], err = system.ParseUUID(id); err != nil {
+					return mapSQLError(err)
+				}
+			}
+
+			expiredAddresses = append(expiredAddresses, expiredAddress)
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return mapSQLError(err)
+	}
+
+	rows.Close()
+
+	for _, expiredAddress := range expiredAddresses {
+		err = executeInTransaction(ctx, addressDataService.DB, func(transaction *sql.Tx) error {
+			return purgeSQLAddress(ctx, transaction, expiredAddress[0], expiredAddress[1], expiredAddress[2], deletedBefore)
+		})
+
+		if err != nil {
+			return mapSQLError(err)
+		}
+	}
+
+	return nil
+}
+
+// executeInTransaction runs the provided function in a new transaction. The transaction is committed if the function
+// succeeds, otherwise it is rolled back and the error returned by the function is returned. The errors returned when the
+// database is unavailable are returned as UnavailableError.
+func executeInTransaction(ctx context.Context, db *sql.DB, function func(transaction *sql.Tx) error) error {
+	transaction, err := db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return mapSQLError(err)
+	}
+
+	if err = function(transaction); err != nil {
+		transaction.Rollback()
+
+		return mapSQLError(err)
+	}
+
+	return mapSQLError(transaction.Commit())
+}
+
+// mapSQLError maps the errors returned when the database cannot be reached or does not respond in time to
+// UnavailableError, and returns the rest of the errors as they are.
+func mapSQLError(err error) error {
+	if err == driver.ErrBadConn || err == sql.ErrConnDone || err == context.DeadlineExceeded {
+		return contract.UnavailableError{Err: err}
+	}
+
+	return err
+}
+
+// insertSQLAddress adds the address key/value pairs to both address and address_indexed_by_address_key tables.
+func insertSQLAddress(ctx context.Context, transaction *sql.Tx, tenantID, applicationID, addressID system.UUID, address contract.Address) error {
+	for _, table := range []string{"address", "address_indexed_by_address_key"} {
+		for key, value := range address.AddressDetails {
+			if _, err := transaction.ExecContext(
+				ctx,
+				"INSERT INTO "+table+
+					" (tenant_id, application_id, address_id, address_key, address_value)"+
+					" VALUES($1, $2, $3, $4, $5)",
+				tenantID.String(),
+				applicationID.String(),
+				addressID.String(),
+				key,
+				value); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// deleteSQLAddress removes an existing address from both address and address_indexed_by_address_key tables. Returns
+// not found error if the address does not exist.
+func deleteSQLAddress(ctx context.Context, transaction *sql.Tx, tenantID, applicationID, addressID system.UUID) error {
+	var deletedRowsCount int64
+
+	for _, table := range []string{"address", "address_indexed_by_address_key"} {
+		result, err := transaction.ExecContext(
+			ctx,
+			"DELETE FROM "+table+
+				" WHERE"+
+				" tenant_id = $1"+
+				" AND application_id = $2"+
+				" AND address_id = $3",
+			tenantID.String(),
+			applicationID.String(),
+			addressID.String())
+
+		if err != nil {
+			return err
+		}
+
+		rowsCount, err := result.RowsAffected()
+
+		if err != nil {
+			return err
+		}
+
+		deletedRowsCount += rowsCount
+	}
+
+	if deletedRowsCount == 0 {
+		return contract.NotFoundError{AddressID: addressID}
+	}
+
+	return nil
+}
+
+// purgeSQLAddress removes a deleted address from address, address_indexed_by_address_key, address_owner and
+// address_metadata tables.
+// If deletedBefore is not zero time, the address is skipped unless it is still deleted and was deleted before that time,
+// otherwise error is returned if the deleted address does not exist.
+func purgeSQLAddress(ctx context.Context, transaction *sql.Tx, tenantID, applicationID, addressID system.UUID, deletedBefore time.Time) error {
+	_, deletedAt, err := readSQLAddressMetadata(ctx, transaction, tenantID, applicationID, addressID)
+
+	if err != nil {
+		return err
+	}
+
+	if !deletedBefore.IsZero() && (deletedAt.IsZero() || !deletedAt.Before(deletedBefore)) {
+		return nil
+	}
+
+	if deletedAt.IsZero() {
+		return contract.NotFoundError{AddressID: addressID, Deleted: true}
+	}
+
+	if err = deleteSQLAddress(ctx, transaction, tenantID, applicationID, addressID); err != nil {
+		return err
+	}
+
+	_, err = transaction.ExecContext(
+		ctx,
+		"DELETE FROM address_owner"+
+			" WHERE"+
+			" tenant_id = $1"+
+			" AND application_id = $2"+
+			" AND address_id = $3",
+		tenantID.String(),
+		applicationID.String(),
+		addressID.String())
+
+	if err != nil {
+		return err
+	}
+
+	_, err = transaction.ExecContext(
+		ctx,
+		"DELETE FROM address_metadata"+
+			" WHERE"+
+			" tenant_id = $1"+
+			" AND application_id = $2"+
+			" AND address_id = $3",
+		tenantID.String(),
+		applicationID.String(),
+		addressID.String())
+
+	return err
+}
+
+// changeSQLAddressVersion increases the version of an existing address and stores the time it was deleted at, or zero
+// time if it is not deleted. The version is changed only if it still has the value read in the same transaction, so
+// concurrent changes to the same address are detected even if the database does not lock the read row.
+// Returns either the new version of the address, or ConflictError if the address version does not match the expected
+// version, or error if something goes wrong.
+func changeSQLAddressVersion(
+	ctx context.Context,
+	transaction *sql.Tx,
+	tenantID, applicationID, addressID system.UUID,
+	expectedVersion int64,
+	deletedAt time.Time) (int64, error) {
+	currentVersion, _, err := readSQLAddressMetadata(ctx, transaction, tenantID, applicationID, addressID)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if expectedVersion != contract.AnyVersion && expectedVersion != currentVersion {
+		return 0, contract.ConflictError{AddressID: addressID, ExpectedVersion: expectedVersion, ActualVersion: currentVersion}
+	}
+
+	var result sql.Result
+
+	var mappedDeletedAt *time.Time
+
+	if !deletedAt.IsZero() {
+		mappedDeletedAt = &deletedAt
+	}
+
+	if currentVersion == 0 {
+		result, err = transaction.ExecContext(
+			ctx,
+			"INSERT INTO address_metadata"+
+				" (tenant_id, application_id, address_id, version, deleted_at)"+
+				" VALUES($1, $2, $3, 1, $4)",
+			tenantID.String(),
+			applicationID.String(),
+			addressID.String(),
+			mappedDeletedAt)
+	} else {
+		result, err = transaction.ExecContext(
+			ctx,
+			"UPDATE address_metadata"+
+				" SET version = $1, deleted_at = $2"+
+				" WHERE"+
+				" tenant_id = $3"+
+				" AND application_id = $4"+
+				" AND address_id = $5"+
+				" AND version = $6",
+			currentVersion+1,
+			mappedDeletedAt,
+			tenantID.String(),
+			applicationID.String(),
+			addressID.String(),
+			currentVersion)
+	}
+
+	if err != nil {
+		return 0, err
+	}
+
+	changedRowsCount, err := result.RowsAffected()
+
+	if err != nil {
+		return 0, err
+	}
+
+	if changedRowsCount == 0 {
+		actualVersion, _, err := readSQLAddressMetadata(ctx, transaction, tenantID, applicationID, addressID)
+
+		if err != nil {
+			return 0, err
+		}
+
+		return 0, contract.ConflictError{AddressID: addressID, ExpectedVersion: expectedVersion, ActualVersion: actualVersion}
+	}
+
+	return currentVersion + 1, nil
+}
+
+// insertSQLAddressHistory adds a version of an address to address_history and address_history_detail tables.
+func insertSQLAddressHistory(
+	ctx context.Context,
+	transaction *sql.Tx,
+	tenantID, applicationID, addressID system.UUID,
+	address contract.Address,
+	version int64,
+	changedBy string,
+	deleted bool) error {
+	_, err := transaction.ExecContext(
+		ctx,
+		"INSERT INTO address_history"+
+			" (tenant_id, application_id, address_id, version, changed_at, changed_by, deleted)"+
+			" VALUES($1, $2, $3, $4, $5, $6, $7)",
+		tenantID.String(),
+		applicationID.String(),
+		addressID.String(),
+		version,
+		time.Now(),
+		changedBy,
+		deleted)
+
+	if err != nil {
+		return err
+	}
+
+	for key, value := range address.AddressDetails {
+		if _, err = transaction.ExecContext(
+			ctx,
+			"INSERT INTO address_history_detail"+
+				" (tenant_id, application_id, address_id, version, address_key, address_value)"+
+				" VALUES($1, $2, $3, $4, $5, $6)",
+			tenantID.String(),
+			applicationID.String(),
+			addressID.String(),
+			version,
+			key,
+			value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// readSQLAddressHistory returns all the recorded versions of an address ordered from the oldest to the newest.
+// Returns not found error if no version is recorded.
+func readSQLAddressHistory(ctx context.Context, queryer sqlQueryer, tenantID, applicationID, addressID system.UUID) ([]contract.AddressHistoryEntry, error) {
+	rows, err := queryer.QueryContext(
+		ctx,
+		"SELECT version, changed_at, changed_by, deleted"+
+			" FROM address_history"+
+			" WHERE"+
+			" tenant_id = $1"+
+			" AND application_id = $2"+
+			" AND address_id = $3"+
+			" ORDER BY version",
+		tenantID.String(),
+		applicationID.String(),
+		addressID.String())
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	history := []contract.AddressHistoryEntry{}
+	historyIndexesByVersion := make(map[int64]int)
+
+	for rows.Next() {
+		historyEntry := contract.AddressHistoryEntry{Address: contract.Address{AddressDetails: make(map[string]string)}}
+
+		if err = rows.Scan(&historyEntry.Address.Version, &historyEntry.ChangedAt, &historyEntry.ChangedBy, &historyEntry.Deleted); err != nil {
+			return nil, err
+		}
+
+		historyIndexesByVersion[historyEntry.Address.Version] = len(history)
+		history = append(history, historyEntry)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows.Close()
+
+	if len(history) == 0 {
+		return nil, contract.NotFoundError{AddressID: addressID}
+	}
+
+	detailRows, err := queryer.QueryContext(
+		ctx,
+		"SELECT version, address_key, address_value"+
+			" FROM address_history_detail"+
+			" WHERE"+
+			" tenant_id = $1"+
+			" AND application_id = $2"+
+			" AND address_id = $3",
+		tenantID.String(),
+		applicationID.String(),
+		addressID.String())
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer detailRows.Close()
+
+	var version int64
+	var key, value string
+
+	for detailRows.Next() {
+		if err = detailRows.Scan(&version, &key, &value); err != nil {
+			return nil, err
+		}
+
+		if index, ok := historyIndexesByVersion[version]; ok {
+			history[index].Address.AddressDetails[key] = value
+		}
+	}
+
+	if err = detailRows.Err(); err != nil {
+		return nil, err
+	}
+
+	return history, nil
+}
+
+// sqlQueryer is implemented by both sql.DB and sql.Tx, so the address can be read in and out of a transaction.
+type sqlQueryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// readSQLAddressMetadata returns the current version of an existing address and the time it was deleted at, or zero time
+// if it is not deleted. Addresses created before versioning was introduced have no metadata stored and 0 version is
+// returned for them.
+func readSQLAddressMetadata(ctx context.Context, queryer sqlQueryer, tenantID, applicationID, addressID system.UUID) (int64, time.Time, error) {
+	var version int64
+	var deletedAt *time.Time
+
+	err := queryer.QueryRowContext(
+		ctx,
+		"SELECT version, deleted_at"+
+			" FROM address_metadata"+
+			" WHERE"+
+			" tenant_id = $1"+
+			" AND application_id = $2"+
+			" AND address_id = $3",
+		tenantID.String(),
+		applicationID.String(),
+		addressID.String()).Scan(&version, &deletedAt)
+
+	if err == sql.ErrNoRows {
+		return 0, time.Time{}, nil
+	}
+
+	if err != nil || deletedAt == nil {
+		return version, time.Time{}, err
+	}
+
+	return version, *deletedAt, nil
+}
+
+// readSQLAddressTTL returns the remaining time an existing address expires after, or zero if it never expires. Returns
+// not found error if the address has expired.
+func readSQLAddressTTL(ctx context.Context, queryer sqlQueryer, tenantID, applicationID, addressID system.UUID) (time.Duration, error) {
+	var expiresAt *time.Time
+
+	err := queryer.QueryRowContext(
+		ctx,
+		"SELECT expires_at"+
+			" FROM address_metadata"+
+			" WHERE"+
+			" tenant_id = $1"+
+			" AND application_id = $2"+
+			" AND address_id = $3",
+		tenantID.String(),
+		applicationID.String(),
+		addressID.String()).Scan(&expiresAt)
+
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+
+	if err != nil {
+		return 0, err
+	}
+
+	if expiresAt != nil && !time.Now().Before(*expiresAt) {
+		return 0, contract.NotFoundError{AddressID: addressID}
+	}
+
+	return mapSQLExpiryToDuration(expiresAt), nil
+}
+
+// changeSQLAddressExpiry stores the time an existing address expires at, or removes it when ttl is zero so the address
+// never expires.
+func changeSQLAddressExpiry(ctx context.Context, transaction *sql.Tx, tenantID, applicationID, addressID system.UUID, ttl time.Duration) error {
+	_, err := transaction.ExecContext(
+		ctx,
+		"UPDATE address_metadata"+
+			" SET expires_at = $1"+
+			" WHERE"+
+			" tenant_id = $2"+
+			" AND application_id = $3"+
+			" AND address_id = $4",
+		mapDurationToSQLExpiry(ttl),
+		tenantID.String(),
+		applicationID.String(),
+		addressID.String())
+
+	return err
+}
+
+// changeSQLAddressFingerprint stores the fingerprint an existing address is updated with, or removes it when fingerprint
+// is empty.
+func changeSQLAddressFingerprint(ctx context.Context, transaction *sql.Tx, tenantID, applicationID, addressID system.UUID, fingerprint string) error {
+	_, err := transaction.ExecContext(
+		ctx,
+		"UPDATE address_metadata"+
+			" SET fingerprint = $1"+
+			" WHERE"+
+			" tenant_id = $2"+
+			" AND application_id = $3"+
+			" AND address_id = $4",
+		mapFingerprintToSQL(fingerprint),
+		tenantID.String(),
+		applicationID.String(),
+		addressID.String())
+
+	return err
+}
+
+// mapFingerprintToSQL maps the provided fingerprint to the stored value, its SHA-256 hash as the address values are stored
+// in plaintext, or nil if it is empty so the addresses created without a fingerprint are never found by it.
+func mapFingerprintToSQL(fingerprint string) *string {
+	if fingerprint == "" {
+		return nil
+	}
+
+	hash, _ := hashAddressFingerprint(nil, fingerprint)
+
+	return &hash
+}
+
+// mapDurationToSQLExpiry maps the provided TTL to the time the address expires at, stored in UTC so the stored times can
+// be compared with each other. Returns nil if ttl is zero as the address never expires.
+func mapDurationToSQLExpiry(ttl time.Duration) *time.Time {
+	if ttl == 0 {
+		return nil
+	}
+
+	expiresAt := time.Now().Add(ttl).UTC()
+
+	return &expiresAt
+}
+
+// mapSQLExpiryToDuration maps the stored time an address expires at to its remaining TTL. Returns zero if expiresAt is
+// nil as the address never expires.
+func mapSQLExpiryToDuration(expiresAt *time.Time) time.Duration {
+	if expiresAt == nil {
+		return 0
+	}
+
+	return time.Until(*expiresAt)
+}
+
+// selectAllSQLAddressDetailsQuery returns all the details stored for an address in address table.
+const selectAllSQLAddressDetailsQuery = "SELECT address_key, address_value" +
+	" FROM address" +
+	" WHERE" +
+	" tenant_id = $1" +
+	" AND application_id = $2" +
+	" AND address_id = $3"
+
+// readAllSQLAddressDetails returns all the details of an existing address along with its version. Returns not found error
+// if the address does not exist or is deleted.
+func readAllSQLAddressDetails(ctx context.Context, queryer sqlQueryer, tenantID, applicationID, addressID system.UUID) (contract.Address, error) {
+	return readSQLAddressDetails(
+		ctx,
+		queryer,
+		tenantID,
+		applicationID,
+		addressID,
+		selectAllSQLAddressDetailsQuery,
+		tenantID.String(),
+		applicationID.String(),
+		addressID.String())
+}
+
+// readSQLAddressDetails runs the provided query returning address_key and address_value columns and returns them as
+// address details along with the address version. Returns not found error if the query returns no row or the address is
+// deleted.
+func readSQLAddressDetails(
+	ctx context.Context,
+	queryer sqlQueryer,
+	tenantID, applicationID, addressID system.UUID,
+	query string,
+	args ...interface{}) (contract.Address, error) {
+	address, err := selectSQLAddressDetails(ctx, queryer, addressID, query, args...)
+
+	if err != nil {
+		return contract.Address{}, err
+	}
+
+	var deletedAt time.Time
+
+	if address.Version, deletedAt, err = readSQLAddressMetadata(ctx, queryer, tenantID, applicationID, addressID); err != nil {
+		return contract.Address{}, err
+	}
+
+	if !deletedAt.IsZero() {
+		return contract.Address{}, contract.NotFoundError{AddressID: addressID}
+	}
+
+	if address.TTL, err = readSQLAddressTTL(ctx, queryer, tenantID, applicationID, addressID); err != nil {
+		return contract.Address{}, err
+	}
+
+	return address, nil
+}
+
+// selectSQLListedAddressDetails reads all the details of the provided listed addresses into them.
+func selectSQLListedAddressDetails(ctx context.Context, queryer sqlQueryer, tenantID, applicationID system.UUID, listedAddresses []contract.ListedAddress) error {
+	for index, listedAddress := range listedAddresses {
+		address, err := selectSQLAddressDetails(
+			ctx,
+			queryer,
+			listedAddress.AddressID,
+			selectAllSQLAddressDetailsQuery,
+			tenantID.String(),
+			applicationID.String(),
+			listedAddress.AddressID.String())
+
+		if err != nil {
+			return err
+		}
+
+		listedAddresses[index].Address.AddressDetails = address.AddressDetails
+	}
+
+	return nil
+}
+
+// selectSQLAddressDetails runs the provided query returning address_key and address_value columns and returns them as
+// address details, whether the address is deleted or not. Returns not found error if the query returns no row.
+func selectSQLAddressDetails(ctx context.Context, queryer sqlQueryer, addressID system.UUID, query string, args ...interface{}) (contract.Address, error) {
+	rows, err := queryer.QueryContext(ctx, query, args...)
+
+	if err != nil {
+		return contract.Address{}, err
+	}
+
+	defer rows.Close()
+
+	var key string
+	var value string
+
+	address := contract.Address{AddressDetails: make(map[string]string)}
+
+	for rows.Next() {
+		if err = rows.Scan(&key, &value); err != nil {
+			return contract.Address{}, err
+		}
+
+		address.AddressDetails[key] = value
+	}
+
+	if err = rows.Err(); err != nil {
+		return contract.Address{}, err
+	}
+
+	if len(address.AddressDetails) == 0 {
+		return contract.Address{}, contract.NotFoundError{AddressID: addressID}
+	}
+
+	return address, nil
+}
@@ -0,0 +1,321 @@
+package service
+
+import (
+	"database/sql"
+	"strconv"
+	"time"
+
+	"github.com/micro-business/AddressService/data/contract"
+	"github.com/micro-business/Micro-Business-Core/common/diagnostics"
+	"github.com/micro-business/Micro-Business-Core/system"
+	"golang.org/x/net/context"
+)
+
+// sqlErasureChunkSize is the number of addresses whose rows are deleted from a table in one transaction when erasing a
+// tenant or an application.
+const sqlErasureChunkSize = 100
+
+// sqlErasedTables are the tables the rows of a tenant or an application are erased from.
+var sqlErasedTables = []string{
+	"address",
+	"address_indexed_by_address_key",
+	"address_owner",
+	"address_metadata",
+	"address_history",
+	"address_history_detail",
+	"address_event_outbox",
+}
+
+// PurgeTenant removes for good all the addresses of all the applications of a tenant along with their history, whether
+// they are deleted or not, and records an erasure certificate. The rows are removed in chunks, each in its own
+// transaction along with the erased rows count, so an interrupted erasure continues where it stopped when it is run again.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant to erase.
+// requestedBy: Optional. Identifies who requests the erasure, recorded in the erasure certificate.
+// Returns either the erasure certificate or error if something goes wrong.
+func (addressDataService SQLAddressDataService) PurgeTenant(ctx context.Context, tenantID system.UUID, requestedBy string) (contract.ErasureCertificate, error) {
+	diagnostics.IsNotNil(addressDataService.UUIDGeneratorService, "addressDataService.UUIDGeneratorService", "UUIDGeneratorService must be provided.")
+	diagnostics.IsNotNil(addressDataService.DB, "addressDataService.DB", "DB must be provided.")
+
+	return addressDataService.erase(ctx, tenantID, system.EmptyUUID, requestedBy)
+}
+
+// PurgeApplication removes for good all the addresses of a tenant's application along with their history, whether they
+// are deleted or not, and records an erasure certificate. The rows are removed in chunks, each in its own transaction
+// along with the erased rows count, so an interrupted erasure continues where it stopped when it is run again.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the application.
+// applicationID: Mandatory. The unique identifier of the tenant's application to erase.
+// requestedBy: Optional. Identifies who requests the erasure, recorded in the erasure certificate.
+// Returns either the erasure certificate or error if something goes wrong.
+func (addressDataService SQLAddressDataService) PurgeApplication(
+	ctx context.Context,
+	tenantID, applicationID system.UUID,
+	requestedBy string) (contract.ErasureCertificate, error) {
+	diagnostics.IsNotNil(addressDataService.UUIDGeneratorService, "addressDataService.UUIDGeneratorService", "UUIDGeneratorService must be provided.")
+	diagnostics.IsNotNil(addressDataService.DB, "addressDataService.DB", "DB must be provided.")
+
+	return addressDataService.erase(ctx, tenantID, applicationID, requestedBy)
+}
+
+// erase removes the rows of the tenant, or only the rows of the application if applicationID is not empty, from all the
+// erased tables, resuming the incomplete erasure of the same tenant or application if there is one.
+func (addressDataService SQLAddressDataService) erase(
+	ctx context.Context,
+	tenantID, applicationID system.UUID,
+	requestedBy string) (contract.ErasureCertificate, error) {
+	certificate, err := addressDataService.startSQLErasure(ctx, tenantID, applicationID, requestedBy)
+
+	if err != nil {
+		return contract.ErasureCertificate{}, err
+	}
+
+	for _, table := range sqlErasedTables {
+		for {
+			var erasedRows int64
+
+			err = executeInTransaction(ctx, addressDataService.DB, func(transaction *sql.Tx) (chunkErr error) {
+				erasedRows, chunkErr = eraseSQLChunk(ctx, transaction, table, certificate)
+
+				return chunkErr
+			})
+
+			if err != nil {
+				return contract.ErasureCertificate{}, err
+			}
+
+			if erasedRows == 0 {
+				break
+			}
+
+			certificate.ErasedRows[table] += erasedRows
+		}
+	}
+
+	certificate.CompletedAt = time.Now()
+
+	_, err = addressDataService.DB.ExecContext(
+		ctx,
+		"UPDATE erasure_certificate"+
+			" SET completed_at = $1"+
+			" WHERE certificate_id = $2",
+		certificate.CompletedAt,
+		certificate.CertificateID.String())
+
+	if err != nil {
+		return contract.ErasureCertificate{}, mapSQLError(err)
+	}
+
+	return certificate, nil
+}
+
+// startSQLErasure returns the erasure certificate of the incomplete erasure of the tenant or the application along with
+// the rows erased so far, or records a new erasure certificate if there is none.
+func (addressDataService SQLAddressDataService) startSQLErasure(
+	ctx context.Context,
+	tenantID, applicationID system.UUID,
+	requestedBy string) (contract.ErasureCertificate, error) {
+	certificate := contract.ErasureCertificate{
+		TenantID:      tenantID,
+		ApplicationID: applicationID,
+		ErasedRows:    make(map[string]int64),
+	}
+
+	err := executeInTransaction(ctx, addressDataService.DB, func(transaction *sql.Tx) error {
+		var certificateID string
+
+		err := transaction.QueryRowContext(
+			ctx,
+			"SELECT certificate_id, requested_by, started_at"+
+				" FROM erasure_certificate"+
+				" WHERE"+
+				" tenant_id = $1"+
+				" AND application_id = $2"+
+				" AND completed_at IS NULL",
+			tenantID.String(),
+			applicationID.String()).Scan(&certificateID, &certificate.RequestedBy, &certificate.StartedAt)
+
+		if err == sql.ErrNoRows {
+			return insertSQLErasureCertificate(ctx, transaction, addressDataService.UUIDGeneratorService, requestedBy, &certificate)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if certificate.CertificateID, err = system.ParseUUID(certificateID); err != nil {
+			return err
+		}
+
+		rows, err := transaction.QueryContext(
+			ctx,
+			"SELECT table_name, erased_rows"+
+				" FROM erasure_certificate_row"+
+				" WHERE certificate_id = $1",
+			certificateID)
+
+		if err != nil {
+			return err
+		}
+
+		defer rows.Close()
+
+		var table string
+		var erasedRows int64
+
+		for rows.Next() {
+			if err = rows.Scan(&table, &erasedRows); err != nil {
+				return err
+			}
+
+			certificate.ErasedRows[table] = erasedRows
+		}
+
+		return rows.Err()
+	})
+
+	if err != nil {
+		return contract.ErasureCertificate{}, err
+	}
+
+	return certificate, nil
+}
+
+// insertSQLErasureCertificate records a new erasure certificate with no erased rows for every erased table.
+func insertSQLErasureCertificate(
+	ctx context.Context,
+	transaction *sql.Tx,
+	uuidGeneratorService system.UUIDGeneratorService,
+	requestedBy string,
+	certificate *contract.ErasureCertificate) error {
+	certificateID, err := uuidGeneratorService.GenerateRandomUUID()
+
+	if err != nil {
+		return err
+	}
+
+	certificate.CertificateID = certificateID
+	certificate.RequestedBy = requestedBy
+	certificate.StartedAt = time.Now()
+
+	_, err = transaction.ExecContext(
+		ctx,
+		"INSERT INTO erasure_certificate"+
+			" (certificate_id, tenant_id, application_id, requested_by, started_at)"+
+			" VALUES($1, $2, $3, $4, $5)",
+		certificateID.String(),
+		certificate.TenantID.String(),
+		certificate.ApplicationID.String(),
+		requestedBy,
+		certificate.StartedAt)
+
+	if err != nil {
+		return err
+	}
+
+	for _, table := range sqlErasedTables {
+		_, err = transaction.ExecContext(
+			ctx,
+			"INSERT INTO erasure_certificate_row"+
+				" (certificate_id, table_name, erased_rows)"+
+				" VALUES($1, $2, 0)",
+			certificateID.String(),
+			table)
+
+		if err != nil {
+			return err
+		}
+
+		certificate.ErasedRows[table] = 0
+	}
+
+	return nil
+}
+
+// eraseSQLChunk deletes the rows of up to sqlErasureChunkSize addresses of the tenant or the application from the table
+// and adds the number of deleted rows to the erasure certificate record.
+// Returns either the number of deleted rows, which is zero once the table holds no more rows to erase, or error if
+// something goes wrong.
+func eraseSQLChunk(ctx context.Context, transaction *sql.Tx, table string, certificate contract.ErasureCertificate) (int64, error) {
+	query := "SELECT DISTINCT application_id, address_id FROM " + table + " WHERE tenant_id = $1"
+	args := []interface{}{certificate.TenantID.String()}
+
+	if certificate.ApplicationID != system.EmptyUUID {
+		query += " AND application_id = $2"
+		args = append(args, certificate.ApplicationID.String())
+	}
+
+	rows, err := transaction.QueryContext(ctx, query+" LIMIT "+strconv.Itoa(sqlErasureChunkSize), args...)
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer rows.Close()
+
+	var applicationID, addressID string
+
+	addresses := [][]string{}
+
+	for rows.Next() {
+		if err = rows.Scan(&applicationID, &addressID); err != nil {
+			return 0, err
+		}
+
+		addresses = append(addresses, []string{applicationID, addressID})
+	}
+
+	if err = rows.Err(); err != nil {
+		return 0, err
+	}
+
+	rows.Close()
+
+	var erasedRows int64
+
+	for _, address := range addresses {
+		result, err := transaction.ExecContext(
+			ctx,
+			"DELETE FROM "+table+
+				" WHERE"+
+				" tenant_id = $1"+
+				" AND application_id = $2"+
+				" AND address_id = $3",
+			certificate.TenantID.String(),
+			address[0],
+			address[1])
+
+		if err != nil {
+			return 0, err
+		}
+
+		rowsCount, err := result.RowsAffected()
+
+		if err != nil {
+			return 0, err
+		}
+
+		erasedRows += rowsCount
+	}
+
+	if erasedRows == 0 {
+		return 0, nil
+	}
+
+	_, err = transaction.ExecContext(
+		ctx,
+		"UPDATE erasure_certificate_row"+
+			" SET erased_rows = erased_rows + $1"+
+			" WHERE"+
+			" certificate_id = $2"+
+			" AND table_name = $3",
+		erasedRows,
+		certificate.CertificateID.String(),
+		table)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return erasedRows, nil
+}
@@ -0,0 +1,194 @@
+package service
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/micro-business/AddressService/data/contract"
+	"github.com/micro-business/Micro-Business-Core/common/diagnostics"
+	"github.com/micro-business/Micro-Business-Core/system"
+	"golang.org/x/net/context"
+)
+
+// ReadEvents retrieves the events waiting in the outbox to be published, ordered by the time the addresses were changed
+// at and their versions.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// limit: Mandatory. The maximum number of events to return.
+// Returns either the events or error if something goes wrong.
+func (addressDataService SQLAddressDataService) ReadEvents(ctx context.Context, limit int) ([]contract.AddressEvent, error) {
+	diagnostics.IsNotNil(addressDataService.DB, "addressDataService.DB", "DB must be provided.")
+
+	rows, err := addressDataService.DB.QueryContext(
+		ctx,
+		"SELECT event_id, event_type, tenant_id, application_id, address_id, version, old_address_details,"+
+			" new_address_details, changed_at, changed_by"+
+			" FROM address_event_outbox"+
+			" ORDER BY changed_at, version"+
+			" LIMIT $1",
+		limit)
+
+	if err != nil {
+		return nil, mapSQLError(err)
+	}
+
+	defer rows.Close()
+
+	events := []contract.AddressEvent{}
+
+	for rows.Next() {
+		var eventID, eventType, tenantID, applicationID, addressID string
+		var oldAddressDetails, newAddressDetails sql.NullString
+
+		event := contract.AddressEvent{}
+
+		err = rows.Scan(
+			&eventID,
+			&eventType,
+			&tenantID,
+			&applicationID,
+			&addressID,
+			&event.Version,
+			&oldAddressDetails,
+			&newAddressDetails,
+			&event.ChangedAt,
+			&event.ChangedBy)
+
+		if err != nil {
+			return nil, mapSQLError(err)
+		}
+
+		event.EventType = contract.AddressEventType(eventType)
+
+		ids := make([]system.UUID, 4)
+
+		for idx, id := range []string{eventID, tenantID, applicationID, addressID} {
+			if ids[idx], err = system.ParseUUID(id); err != nil {
+				return nil, mapSQLError(err)
+			}
+		}
+
+		event.EventID, event.TenantID, event.ApplicationID, event.AddressID = ids[0], ids[1], ids[2], ids[3]
+
+		if event.OldAddressDetails, err = decodeSQLAddressEventDetails(oldAddressDetails); err != nil {
+			return nil, err
+		}
+
+		if event.NewAddressDetails, err = decodeSQLAddressEventDetails(newAddressDetails); err != nil {
+			return nil, err
+		}
+
+		events = append(events, event)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, mapSQLError(err)
+	}
+
+	return events, nil
+}
+
+// RemoveEvents removes the published events from the outbox.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// events: Mandatory. The events returned by ReadEvents which are published.
+// Returns error if something goes wrong.
+func (addressDataService SQLAddressDataService) RemoveEvents(ctx context.Context, events []contract.AddressEvent) error {
+	diagnostics.IsNotNil(addressDataService.DB, "addressDataService.DB", "DB must be provided.")
+
+	return executeInTransaction(ctx, addressDataService.DB, func(transaction *sql.Tx) error {
+		for _, event := range events {
+			if _, err := transaction.ExecContext(
+				ctx,
+				"DELETE FROM address_event_outbox WHERE event_id = $1",
+				event.EventID.String()); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// recordSQLAddressEvent adds an event recording the change of an address to address_event_outbox table if recording the
+// events is enabled. The address details are stored as JSON objects.
+func (addressDataService SQLAddressDataService) recordSQLAddressEvent(
+	ctx context.Context,
+	transaction *sql.Tx,
+	eventType contract.AddressEventType,
+	tenantID, applicationID, addressID system.UUID,
+	version int64,
+	oldAddressDetails, newAddressDetails map[string]string,
+	changedBy string) error {
+	if !addressDataService.RecordEvents {
+		return nil
+	}
+
+	diagnostics.IsNotNil(addressDataService.UUIDGeneratorService, "addressDataService.UUIDGeneratorService", "UUIDGeneratorService must be provided.")
+
+	eventID, err := addressDataService.UUIDGeneratorService.GenerateRandomUUID()
+
+	if err != nil {
+		return err
+	}
+
+	encodedOldAddressDetails, err := encodeSQLAddressEventDetails(oldAddressDetails)
+
+	if err != nil {
+		return err
+	}
+
+	encodedNewAddressDetails, err := encodeSQLAddressEventDetails(newAddressDetails)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = transaction.ExecContext(
+		ctx,
+		"INSERT INTO address_event_outbox"+
+			" (event_id, event_type, tenant_id, application_id, address_id, version, old_address_details,"+
+			" new_address_details, changed_at, changed_by)"+
+			" VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)",
+		eventID.String(),
+		string(eventType),
+		tenantID.String(),
+		applicationID.String(),
+		addressID.String(),
+		version,
+		encodedOldAddressDetails,
+		encodedNewAddressDetails,
+		time.Now(),
+		changedBy)
+
+	return err
+}
+
+// encodeSQLAddressEventDetails encodes the address details of an event to a JSON object, or to NULL if they are nil.
+func encodeSQLAddressEventDetails(addressDetails map[string]string) (sql.NullString, error) {
+	if addressDetails == nil {
+		return sql.NullString{}, nil
+	}
+
+	encodedAddressDetails, err := json.Marshal(addressDetails)
+
+	if err != nil {
+		return sql.NullString{}, err
+	}
+
+	return sql.NullString{String: string(encodedAddressDetails), Valid: true}, nil
+}
+
+// decodeSQLAddressEventDetails decodes the address details of an event encoded by encodeSQLAddressEventDetails.
+func decodeSQLAddressEventDetails(encodedAddressDetails sql.NullString) (map[string]string, error) {
+	if !encodedAddressDetails.Valid {
+		return nil, nil
+	}
+
+	addressDetails := make(map[string]string)
+
+	if err := json.Unmarshal([]byte(encodedAddressDetails.String), &addressDetails); err != nil {
+		return nil, err
+	}
+
+	return addressDetails, nil
+}
@@ -0,0 +1,231 @@
+package service
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/micro-business/AddressService/data/contract"
+	"github.com/micro-business/Micro-Business-Core/common/diagnostics"
+	"github.com/micro-business/Micro-Business-Core/system"
+	"golang.org/x/net/context"
+)
+
+// AddOwners links an existing address to the provided owners. The owners already linked to the address are kept. Every
+// owner is removed before it is inserted to address_owner table, so linking an address to the same owner twice does not
+// depend on the database supporting upserts.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+// applicationID: Mandatory. The unique identifier of the tenant's application owning the address.
+// addressID: Mandatory. The unique identifier of the existing address.
+// owners: Mandatory. The owners to link the address to.
+// Returns error if the address does not exist, or if something goes wrong.
+func (addressDataService SQLAddressDataService) AddOwners(
+	ctx context.Context,
+	tenantID, applicationID, addressID system.UUID,
+	owners []contract.AddressOwner) error {
+	diagnostics.IsNotNil(addressDataService.DB, "addressDataService.DB", "DB must be provided.")
+
+	return executeInTransaction(ctx, addressDataService.DB, func(transaction *sql.Tx) error {
+		if _, err := readAllSQLAddressDetails(ctx, transaction, tenantID, applicationID, addressID); err != nil {
+			return err
+		}
+
+		for _, owner := range owners {
+			if err := deleteSQLAddressOwner(ctx, transaction, tenantID, applicationID, addressID, owner); err != nil {
+				return err
+			}
+
+			_, err := transaction.ExecContext(
+				ctx,
+				"INSERT INTO address_owner"+
+					" (tenant_id, application_id, address_id, owner_type, owner_id)"+
+					" VALUES($1, $2, $3, $4, $5)",
+				tenantID.String(),
+				applicationID.String(),
+				addressID.String(),
+				owner.Type,
+				owner.ID)
+
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// RemoveOwners unlinks an existing address from the provided owners. The owners not linked to the address are skipped.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+// applicationID: Mandatory. The unique identifier of the tenant's application owning the address.
+// addressID: Mandatory. The unique identifier of the existing address.
+// owners: Mandatory. The owners to unlink the address from.
+// Returns error if the address does not exist, or if something goes wrong.
+func (addressDataService SQLAddressDataService) RemoveOwners(
+	ctx context.Context,
+	tenantID, applicationID, addressID system.UUID,
+	owners []contract.AddressOwner) error {
+	diagnostics.IsNotNil(addressDataService.DB, "addressDataService.DB", "DB must be provided.")
+
+	return executeInTransaction(ctx, addressDataService.DB, func(transaction *sql.Tx) error {
+		if _, err := readAllSQLAddressDetails(ctx, transaction, tenantID, applicationID, addressID); err != nil {
+			return err
+		}
+
+		for _, owner := range owners {
+			if err := deleteSQLAddressOwner(ctx, transaction, tenantID, applicationID, addressID, owner); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// ReadOwners retrieves the owners an existing address is linked to.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the address.
+// applicationID: Mandatory. The unique identifier of the tenant's application owning the address.
+// addressID: Mandatory. The unique identifier of the existing address.
+// Returns either the owners ordered by their type and identifier or error if the address does not exist or something
+// goes wrong.
+func (addressDataService SQLAddressDataService) ReadOwners(ctx context.Context, tenantID, applicationID, addressID system.UUID) ([]contract.AddressOwner, error) {
+	diagnostics.IsNotNil(addressDataService.DB, "addressDataService.DB", "DB must be provided.")
+
+	if _, err := readAllSQLAddressDetails(ctx, addressDataService.DB, tenantID, applicationID, addressID); err != nil {
+		return nil, mapSQLError(err)
+	}
+
+	rows, err := addressDataService.DB.QueryContext(
+		ctx,
+		"SELECT owner_type, owner_id"+
+			" FROM address_owner"+
+			" WHERE"+
+			" tenant_id = $1"+
+			" AND application_id = $2"+
+			" AND address_id = $3"+
+			" ORDER BY owner_type, owner_id",
+		tenantID.String(),
+		applicationID.String(),
+		addressID.String())
+
+	if err != nil {
+		return nil, mapSQLError(err)
+	}
+
+	defer rows.Close()
+
+	var owner contract.AddressOwner
+
+	owners := []contract.AddressOwner{}
+
+	for rows.Next() {
+		if err = rows.Scan(&owner.Type, &owner.ID); err != nil {
+			return nil, mapSQLError(err)
+		}
+
+		owners = append(owners, owner)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, mapSQLError(err)
+	}
+
+	return owners, nil
+}
+
+// FindByOwner retrieves the addresses owned by a tenant's application which are linked to the provided owner. The
+// deleted addresses are not returned.
+// ctx: Mandatory. The context the call is made in, used to cancel the call or limit how long it may take.
+// tenantID: Mandatory. The unique identifier of the tenant owning the addresses.
+// applicationID: Mandatory. The unique identifier of the tenant's application owning the addresses.
+// owner: Mandatory. The owner the returned addresses are linked to.
+// Returns either the linked addresses ordered by their unique identifier or error if something goes wrong.
+func (addressDataService SQLAddressDataService) FindByOwner(
+	ctx context.Context,
+	tenantID, applicationID system.UUID,
+	owner contract.AddressOwner) ([]contract.ListedAddress, error) {
+	diagnostics.IsNotNil(addressDataService.DB, "addressDataService.DB", "DB must be provided.")
+
+	rows, err := addressDataService.DB.QueryContext(
+		ctx,
+		"SELECT address_metadata.address_id, address_metadata.version, address_metadata.expires_at"+
+			" FROM address_owner"+
+			" JOIN address_metadata"+
+			" ON address_metadata.tenant_id = address_owner.tenant_id"+
+			" AND address_metadata.application_id = address_owner.application_id"+
+			" AND address_metadata.address_id = address_owner.address_id"+
+			" WHERE"+
+			" address_owner.tenant_id = $1"+
+			" AND address_owner.application_id = $2"+
+			" AND address_owner.owner_type = $3"+
+			" AND address_owner.owner_id = $4"+
+			" AND address_metadata.deleted_at IS NULL"+
+			" AND (address_metadata.expires_at IS NULL OR address_metadata.expires_at > $5)"+
+			" ORDER BY address_metadata.address_id",
+		tenantID.String(),
+		applicationID.String(),
+		owner.Type,
+		owner.ID,
+		time.Now().UTC())
+
+	if err != nil {
+		return nil, mapSQLError(err)
+	}
+
+	defer rows.Close()
+
+	var addressID string
+	var version int64
+	var expiresAt *time.Time
+
+	listedAddresses := []contract.ListedAddress{}
+
+	for rows.Next() {
+		if err = rows.Scan(&addressID, &version, &expiresAt); err != nil {
+			return nil, mapSQLError(err)
+		}
+
+		listedAddress := contract.ListedAddress{Address: contract.Address{Version: version, TTL: mapSQLExpiryToDuration(expiresAt)}}
+
+		if listedAddress.AddressID, err = system.ParseUUID(addressID); err != nil {
+			return nil, mapSQLError(err)
+		}
+
+		listedAddresses = append(listedAddresses, listedAddress)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, mapSQLError(err)
+	}
+
+	rows.Close()
+
+	if err = selectSQLListedAddressDetails(ctx, addressDataService.DB, tenantID, applicationID, listedAddresses); err != nil {
+		return nil, mapSQLError(err)
+	}
+
+	return listedAddresses, nil
+}
+
+// deleteSQLAddressOwner removes an address owner from address_owner table, or does nothing if the address is not linked
+// to the owner.
+func deleteSQLAddressOwner(ctx context.Context, transaction *sql.Tx, tenantID, applicationID, addressID system.UUID, owner contract.AddressOwner) error {
+	_, err := transaction.ExecContext(
+		ctx,
+		"DELETE FROM address_owner"+
+			" WHERE"+
+			" tenant_id = $1"+
+			" AND application_id = $2"+
+			" AND address_id = $3"+
+			" AND owner_type = $4"+
+			" AND owner_id = $5",
+		tenantID.String(),
+		applicationID.String(),
+		addressID.String(),
+		owner.Type,
+		owner.ID)
+
+	return err
+}
@@ -0,0 +1,1173 @@
+package service_test
+
+import (
+	"database/sql"
+	"errors"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/micro-business/AddressService/data/contract"
+	"github.com/micro-business/AddressService/data/service"
+	"github.com/micro-business/Micro-Business-Core/system"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("SQL address data service behaviour", func() {
+	var (
+		ctx                      context.Context
+		mockCtrl                 *gomock.Controller
+		addressDataService       *service.SQLAddressDataService
+		mockUUIDGeneratorService *MockUUIDGeneratorService
+		db                       *sql.DB
+		tenantID                 system.UUID
+		applicationID            system.UUID
+		addressID                system.UUID
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		db = createSQLiteDatabase()
+
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockUUIDGeneratorService = NewMockUUIDGeneratorService(mockCtrl)
+
+		addressDataService = &service.SQLAddressDataService{UUIDGeneratorService: mockUUIDGeneratorService, DB: db}
+
+		tenantID, _ = system.RandomUUID()
+		applicationID, _ = system.RandomUUID()
+		addressID, _ = system.RandomUUID()
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+		db.Close()
+	})
+
+	createAddress := func(addressDetails map[string]string) {
+		mockUUIDGeneratorService.
+			EXPECT().
+			GenerateRandomUUID().
+			Return(addressID, nil)
+
+		returnedAddressID, err := addressDataService.Create(ctx, tenantID, applicationID, contract.Address{AddressDetails: addressDetails}, "")
+
+		Expect(err).To(BeNil())
+		Expect(returnedAddressID).To(Equal(addressID))
+	}
+
+	readTable := func(table string) map[string]string {
+		rows, err := db.Query(
+			"SELECT address_key, address_value FROM "+table+" WHERE tenant_id = $1 AND application_id = $2 AND address_id = $3",
+			tenantID.String(),
+			applicationID.String(),
+			addressID.String())
+
+		Expect(err).To(BeNil())
+
+		defer rows.Close()
+
+		var key string
+		var value string
+
+		addressDetails := make(map[string]string)
+
+		for rows.Next() {
+			Expect(rows.Scan(&key, &value)).To(BeNil())
+			addressDetails[key] = value
+		}
+
+		return addressDetails
+	}
+
+	Context("when dependencies not provided", func() {
+		It("should panic", func() {
+			addressDataService.DB = nil
+
+			Ω(func() { addressDataService.ReadAll(ctx, tenantID, applicationID, addressID) }).Should(Panic())
+
+			addressDataService.UUIDGeneratorService = nil
+
+			Ω(func() { addressDataService.Create(ctx, tenantID, applicationID, contract.Address{}, "") }).Should(Panic())
+		})
+	})
+
+	Context("when creating new address", func() {
+		It("should return address unique identifier as empty UUID and the returned error by UUID generator service", func() {
+			expectedError := errors.New("UUID generation failed")
+			mockUUIDGeneratorService.
+				EXPECT().
+				GenerateRandomUUID().
+				Return(system.EmptyUUID, expectedError)
+
+			newAddressID, err := addressDataService.Create(ctx, tenantID, applicationID, contract.Address{AddressDetails: createRandomAddressDetails()}, "")
+
+			Expect(newAddressID).To(Equal(system.EmptyUUID))
+			Expect(err).To(Equal(expectedError))
+		})
+
+		It("should insert the records into address and address_indexed_by_address_key tables", func() {
+			expectedAddressDetails := createRandomAddressDetails()
+
+			createAddress(expectedAddressDetails)
+
+			Expect(readTable("address")).To(Equal(expectedAddressDetails))
+			Expect(readTable("address_indexed_by_address_key")).To(Equal(expectedAddressDetails))
+		})
+	})
+
+	Context("when updating existing address", func() {
+		It("should return error if address does not exist", func() {
+			err := addressDataService.Update(ctx, tenantID, applicationID, addressID, contract.Address{AddressDetails: createRandomAddressDetails()}, contract.AnyVersion, "")
+
+			Expect(err).To(Equal(contract.NotFoundError{AddressID: addressID}))
+		})
+
+		It("should replace the records in address and address_indexed_by_address_key tables", func() {
+			createAddress(createRandomAddressDetails())
+
+			expectedAddressDetails := createRandomAddressDetails()
+
+			err := addressDataService.Update(ctx, tenantID, applicationID, addressID, contract.Address{AddressDetails: expectedAddressDetails}, contract.AnyVersion, "")
+
+			Expect(err).To(BeNil())
+			Expect(readTable("address")).To(Equal(expectedAddressDetails))
+			Expect(readTable("address_indexed_by_address_key")).To(Equal(expectedAddressDetails))
+		})
+
+		It("should increase the address version", func() {
+			createAddress(createRandomAddressDetails())
+
+			Expect(addressDataService.Update(ctx, tenantID, applicationID, addressID, contract.Address{AddressDetails: createRandomAddressDetails()}, 1, "")).To(BeNil())
+
+			address, err := addressDataService.ReadAll(ctx, tenantID, applicationID, addressID)
+
+			Expect(err).To(BeNil())
+			Expect(address.Version).To(Equal(int64(2)))
+		})
+
+		It("should return conflict error and leave the records unchanged if the expected version does not match", func() {
+			expectedAddressDetails := createRandomAddressDetails()
+
+			createAddress(expectedAddressDetails)
+
+			err := addressDataService.Update(ctx, tenantID, applicationID, addressID, contract.Address{AddressDetails: createRandomAddressDetails()}, 2, "")
+
+			Expect(err).To(Equal(contract.ConflictError{AddressID: addressID, ExpectedVersion: 2, ActualVersion: 1}))
+			Expect(readTable("address")).To(Equal(expectedAddressDetails))
+		})
+	})
+
+	Context("when patching existing address", func() {
+		It("should return error if address does not exist", func() {
+			err := addressDataService.Patch(ctx, tenantID, applicationID, addressID, map[string]string{"City": "Christchurch"}, nil, contract.AnyVersion, "")
+
+			Expect(err).To(Equal(contract.NotFoundError{AddressID: addressID}))
+		})
+
+		It("should set and remove only the provided keys in address and address_indexed_by_address_key tables", func() {
+			createAddress(map[string]string{"Line1": "1 Main Road", "City": "Christchurch"})
+
+			expectedAddressDetails := map[string]string{"City": "Christchurch", "Postcode": "8011"}
+
+			Expect(addressDataService.Patch(ctx, tenantID, applicationID, addressID, map[string]string{"Postcode": "8011"}, []string{"Line1"}, 1, "")).To(BeNil())
+			Expect(readTable("address")).To(Equal(expectedAddressDetails))
+			Expect(readTable("address_indexed_by_address_key")).To(Equal(expectedAddressDetails))
+
+			address, err := addressDataService.ReadAll(ctx, tenantID, applicationID, addressID)
+
+			Expect(err).To(BeNil())
+			Expect(address.Version).To(Equal(int64(2)))
+		})
+
+		It("should return error and leave the records unchanged if all the address keys are removed", func() {
+			expectedAddressDetails := map[string]string{"City": "Christchurch"}
+
+			createAddress(expectedAddressDetails)
+
+			err := addressDataService.Patch(ctx, tenantID, applicationID, addressID, nil, []string{"City"}, contract.AnyVersion, "")
+
+			Expect(err).To(Equal(contract.InvalidArgumentError{Message: "Address cannot be left without any address detail. Address ID: " + addressID.String()}))
+			Expect(readTable("address")).To(Equal(expectedAddressDetails))
+		})
+	})
+
+	Context("when reading existing address", func() {
+		It("should return error if address does not exist", func() {
+			address, err := addressDataService.Read(ctx, tenantID, applicationID, addressID, []string{"Line1"})
+
+			Expect(err).To(Equal(contract.NotFoundError{AddressID: addressID}))
+			Expect(address).To(Equal(contract.Address{}))
+		})
+
+		It("should return the requested address keys and values", func() {
+			addressDetails := createRandomAddressDetails()
+			addressDetails["City"] = "Christchurch"
+
+			createAddress(addressDetails)
+
+			address, err := addressDataService.Read(ctx, tenantID, applicationID, addressID, []string{"City", "Country"})
+
+			Expect(err).To(BeNil())
+			Expect(address).To(Equal(contract.Address{AddressDetails: map[string]string{"City": "Christchurch"}, Version: 1}))
+		})
+	})
+
+	Context("when reading all the existing address details", func() {
+		It("should return error if address does not exist", func() {
+			address, err := addressDataService.ReadAll(ctx, tenantID, applicationID, addressID)
+
+			Expect(err).To(Equal(contract.NotFoundError{AddressID: addressID}))
+			Expect(address).To(Equal(contract.Address{}))
+		})
+
+		It("should return all the existing address keys and values", func() {
+			expectedAddressDetails := createRandomAddressDetails()
+
+			createAddress(expectedAddressDetails)
+
+			address, err := addressDataService.ReadAll(ctx, tenantID, applicationID, addressID)
+
+			Expect(err).To(BeNil())
+			Expect(address).To(Equal(contract.Address{AddressDetails: expectedAddressDetails, Version: 1}))
+		})
+	})
+
+	Context("when deleting existing address", func() {
+		It("should return error if address does not exist", func() {
+			err := addressDataService.Delete(ctx, tenantID, applicationID, addressID, contract.AnyVersion, "")
+
+			Expect(err).To(Equal(contract.NotFoundError{AddressID: addressID}))
+		})
+
+		It("should hide the address from readers and keep the records until purged", func() {
+			expectedAddressDetails := createRandomAddressDetails()
+
+			createAddress(expectedAddressDetails)
+
+			Expect(addressDataService.Delete(ctx, tenantID, applicationID, addressID, contract.AnyVersion, "")).To(BeNil())
+
+			_, err := addressDataService.ReadAll(ctx, tenantID, applicationID, addressID)
+
+			Expect(err).To(Equal(contract.NotFoundError{AddressID: addressID}))
+			Expect(readTable("address")).To(Equal(expectedAddressDetails))
+		})
+
+		It("should return conflict error and leave the records unchanged if the expected version does not match", func() {
+			expectedAddressDetails := createRandomAddressDetails()
+
+			createAddress(expectedAddressDetails)
+
+			err := addressDataService.Delete(ctx, tenantID, applicationID, addressID, 2, "")
+
+			Expect(err).To(Equal(contract.ConflictError{AddressID: addressID, ExpectedVersion: 2, ActualVersion: 1}))
+			Expect(readTable("address")).To(Equal(expectedAddressDetails))
+		})
+	})
+
+	Context("when restoring deleted address", func() {
+		It("should return error if address is not deleted", func() {
+			createAddress(createRandomAddressDetails())
+
+			err := addressDataService.Restore(ctx, tenantID, applicationID, addressID, time.Now().Add(-time.Hour), "")
+
+			Expect(err).To(Equal(contract.NotFoundError{AddressID: addressID, Deleted: true}))
+		})
+
+		It("should return the address to readers with increased version", func() {
+			expectedAddressDetails := createRandomAddressDetails()
+
+			createAddress(expectedAddressDetails)
+
+			Expect(addressDataService.Delete(ctx, tenantID, applicationID, addressID, contract.AnyVersion, "")).To(BeNil())
+			Expect(addressDataService.Restore(ctx, tenantID, applicationID, addressID, time.Now().Add(-time.Hour), "")).To(BeNil())
+
+			address, err := addressDataService.ReadAll(ctx, tenantID, applicationID, addressID)
+
+			Expect(err).To(BeNil())
+			Expect(address).To(Equal(contract.Address{AddressDetails: expectedAddressDetails, Version: 3}))
+		})
+
+		It("should return error if address was deleted before the provided time", func() {
+			createAddress(createRandomAddressDetails())
+
+			Expect(addressDataService.Delete(ctx, tenantID, applicationID, addressID, contract.AnyVersion, "")).To(BeNil())
+
+			err := addressDataService.Restore(ctx, tenantID, applicationID, addressID, time.Now().Add(time.Hour), "")
+
+			Expect(err).To(Equal(contract.NotFoundError{AddressID: addressID, Deleted: true}))
+		})
+	})
+
+	Context("when purging deleted address", func() {
+		It("should return error if address is not deleted", func() {
+			expectedAddressDetails := createRandomAddressDetails()
+
+			createAddress(expectedAddressDetails)
+
+			err := addressDataService.Purge(ctx, tenantID, applicationID, addressID)
+
+			Expect(err).To(Equal(contract.NotFoundError{AddressID: addressID, Deleted: true}))
+			Expect(readTable("address")).To(Equal(expectedAddressDetails))
+		})
+
+		It("should remove the records from address and address_indexed_by_address_key tables", func() {
+			createAddress(createRandomAddressDetails())
+
+			Expect(addressDataService.Delete(ctx, tenantID, applicationID, addressID, contract.AnyVersion, "")).To(BeNil())
+			Expect(addressDataService.Purge(ctx, tenantID, applicationID, addressID)).To(BeNil())
+			Expect(readTable("address")).To(BeEmpty())
+			Expect(readTable("address_indexed_by_address_key")).To(BeEmpty())
+
+			err := addressDataService.Restore(ctx, tenantID, applicationID, addressID, time.Now().Add(-time.Hour), "")
+
+			Expect(err).To(Equal(contract.NotFoundError{AddressID: addressID, Deleted: true}))
+		})
+	})
+
+	Context("when purging all the addresses deleted before the provided time", func() {
+		It("should purge only the addresses deleted before the provided time", func() {
+			createAddress(createRandomAddressDetails())
+
+			Expect(addressDataService.Delete(ctx, tenantID, applicationID, addressID, contract.AnyVersion, "")).To(BeNil())
+			Expect(addressDataService.PurgeDeleted(ctx, time.Now().Add(-time.Hour))).To(BeNil())
+			Expect(readTable("address")).NotTo(BeEmpty())
+
+			Expect(addressDataService.PurgeDeleted(ctx, time.Now().Add(time.Hour))).To(BeNil())
+			Expect(readTable("address")).To(BeEmpty())
+		})
+
+		It("should keep the addresses not deleted", func() {
+			expectedAddressDetails := createRandomAddressDetails()
+
+			createAddress(expectedAddressDetails)
+
+			Expect(addressDataService.PurgeDeleted(ctx, time.Now().Add(time.Hour))).To(BeNil())
+			Expect(readTable("address")).To(Equal(expectedAddressDetails))
+		})
+	})
+
+	Context("when recording events", func() {
+		var (
+			createdEventID system.UUID
+			updatedEventID system.UUID
+			deletedEventID system.UUID
+		)
+
+		BeforeEach(func() {
+			addressDataService.RecordEvents = true
+			createdEventID, _ = system.RandomUUID()
+			updatedEventID, _ = system.RandomUUID()
+			deletedEventID, _ = system.RandomUUID()
+
+			gomock.InOrder(
+				mockUUIDGeneratorService.EXPECT().GenerateRandomUUID().Return(addressID, nil),
+				mockUUIDGeneratorService.EXPECT().GenerateRandomUUID().Return(createdEventID, nil),
+				mockUUIDGeneratorService.EXPECT().GenerateRandomUUID().Return(updatedEventID, nil),
+				mockUUIDGeneratorService.EXPECT().GenerateRandomUUID().Return(deletedEventID, nil))
+
+			_, err := addressDataService.Create(ctx, tenantID, applicationID, contract.Address{AddressDetails: map[string]string{"City": "Christchurch"}}, "crm")
+
+			Expect(err).To(BeNil())
+			Expect(addressDataService.Patch(ctx, tenantID, applicationID, addressID, map[string]string{"Postcode": "8011"}, nil, contract.AnyVersion, "crm")).To(BeNil())
+			Expect(addressDataService.Delete(ctx, tenantID, applicationID, addressID, contract.AnyVersion, "")).To(BeNil())
+		})
+
+		It("should write an event along with every change holding the address details before and after the change", func() {
+			events, err := addressDataService.ReadEvents(ctx, 10)
+
+			Expect(err).To(BeNil())
+			Expect(events).To(HaveLen(3))
+
+			for _, event := range events {
+				Expect(event.TenantID).To(Equal(tenantID))
+				Expect(event.ApplicationID).To(Equal(applicationID))
+				Expect(event.AddressID).To(Equal(addressID))
+				Expect(event.ChangedAt.IsZero()).To(BeFalse())
+			}
+
+			Expect(events[0].EventID).To(Equal(createdEventID))
+			Expect(events[0].EventType).To(Equal(contract.AddressCreated))
+			Expect(events[0].Version).To(Equal(int64(1)))
+			Expect(events[0].OldAddressDetails).To(BeNil())
+			Expect(events[0].NewAddressDetails).To(Equal(map[string]string{"City": "Christchurch"}))
+			Expect(events[0].ChangedBy).To(Equal("crm"))
+
+			Expect(events[1].EventID).To(Equal(updatedEventID))
+			Expect(events[1].EventType).To(Equal(contract.AddressUpdated))
+			Expect(events[1].Version).To(Equal(int64(2)))
+			Expect(events[1].OldAddressDetails).To(Equal(map[string]string{"City": "Christchurch"}))
+			Expect(events[1].NewAddressDetails).To(Equal(map[string]string{"City": "Christchurch", "Postcode": "8011"}))
+
+			Expect(events[2].EventID).To(Equal(deletedEventID))
+			Expect(events[2].EventType).To(Equal(contract.AddressDeleted))
+			Expect(events[2].Version).To(Equal(int64(3)))
+			Expect(events[2].OldAddressDetails).To(Equal(map[string]string{"City": "Christchurch", "Postcode": "8011"}))
+			Expect(events[2].NewAddressDetails).To(BeNil())
+		})
+
+		It("should return no more than the provided number of events", func() {
+			events, err := addressDataService.ReadEvents(ctx, 2)
+
+			Expect(err).To(BeNil())
+			Expect(events).To(HaveLen(2))
+			Expect(events[0].EventID).To(Equal(createdEventID))
+		})
+
+		It("should not return the removed events again", func() {
+			events, _ := addressDataService.ReadEvents(ctx, 2)
+
+			Expect(addressDataService.RemoveEvents(ctx, events)).To(BeNil())
+
+			events, err := addressDataService.ReadEvents(ctx, 10)
+
+			Expect(err).To(BeNil())
+			Expect(events).To(HaveLen(1))
+			Expect(events[0].EventID).To(Equal(deletedEventID))
+		})
+
+		It("should not write the event if the change fails", func() {
+			Expect(addressDataService.Delete(ctx, tenantID, applicationID, addressID, contract.AnyVersion, "")).
+				To(Equal(contract.NotFoundError{AddressID: addressID}))
+
+			events, err := addressDataService.ReadEvents(ctx, 10)
+
+			Expect(err).To(BeNil())
+			Expect(events).To(HaveLen(3))
+		})
+
+		It("should remove the events of the erased application", func() {
+			certificateID, _ := system.RandomUUID()
+			mockUUIDGeneratorService.
+				EXPECT().
+				GenerateRandomUUID().
+				Return(certificateID, nil)
+
+			certificate, err := addressDataService.PurgeApplication(ctx, tenantID, applicationID, "")
+
+			Expect(err).To(BeNil())
+			Expect(certificate.ErasedRows["address_event_outbox"]).To(Equal(int64(3)))
+
+			events, err := addressDataService.ReadEvents(ctx, 10)
+
+			Expect(err).To(BeNil())
+			Expect(events).To(BeEmpty())
+		})
+	})
+
+	Context("when not recording events", func() {
+		It("should not write any event", func() {
+			createAddress(map[string]string{"City": "Christchurch"})
+
+			events, err := addressDataService.ReadEvents(ctx, 10)
+
+			Expect(err).To(BeNil())
+			Expect(events).To(BeEmpty())
+		})
+	})
+
+	Context("when erasing a tenant or an application", func() {
+		var (
+			otherApplicationID system.UUID
+			otherAddressID     system.UUID
+			certificateID      system.UUID
+		)
+
+		countRows := func(table, tenantID string) int {
+			var count int
+
+			Expect(db.QueryRow("SELECT COUNT(*) FROM "+table+" WHERE tenant_id = $1", tenantID).Scan(&count)).To(BeNil())
+
+			return count
+		}
+
+		BeforeEach(func() {
+			otherApplicationID, _ = system.RandomUUID()
+			otherAddressID, _ = system.RandomUUID()
+			certificateID, _ = system.RandomUUID()
+
+			createAddress(map[string]string{"City": "Christchurch", "Postcode": "8011"})
+
+			Expect(addressDataService.Delete(ctx, tenantID, applicationID, addressID, contract.AnyVersion, "")).To(BeNil())
+
+			mockUUIDGeneratorService.
+				EXPECT().
+				GenerateRandomUUID().
+				Return(otherAddressID, nil)
+
+			_, err := addressDataService.Create(ctx, tenantID, otherApplicationID, contract.Address{AddressDetails: map[string]string{"City": "Wellington"}}, "")
+
+			Expect(err).To(BeNil())
+		})
+
+		It("should remove all the addresses of the tenant, deleted or not, and record the erasure certificate", func() {
+			mockUUIDGeneratorService.
+				EXPECT().
+				GenerateRandomUUID().
+				Return(certificateID, nil)
+
+			certificate, err := addressDataService.PurgeTenant(ctx, tenantID, "privacy-officer")
+
+			Expect(err).To(BeNil())
+			Expect(certificate.CertificateID).To(Equal(certificateID))
+			Expect(certificate.ApplicationID).To(Equal(system.EmptyUUID))
+			Expect(certificate.RequestedBy).To(Equal("privacy-officer"))
+			Expect(certificate.CompletedAt.IsZero()).To(BeFalse())
+			Expect(certificate.ErasedRows).To(Equal(map[string]int64{
+				"address":                        3,
+				"address_indexed_by_address_key": 3,
+				"address_owner":                  0,
+				"address_metadata":               2,
+				"address_history":                3,
+				"address_history_detail":         5,
+				"address_event_outbox":           0,
+			}))
+
+			for _, table := range []string{"address", "address_indexed_by_address_key", "address_metadata", "address_history", "address_history_detail"} {
+				Expect(countRows(table, tenantID.String())).To(Equal(0))
+			}
+
+			var completedAt *time.Time
+
+			Expect(db.QueryRow("SELECT completed_at FROM erasure_certificate WHERE certificate_id = $1", certificateID.String()).Scan(&completedAt)).To(BeNil())
+			Expect(completedAt).NotTo(BeNil())
+		})
+
+		It("should remove only the addresses of the application", func() {
+			mockUUIDGeneratorService.
+				EXPECT().
+				GenerateRandomUUID().
+				Return(certificateID, nil)
+
+			certificate, err := addressDataService.PurgeApplication(ctx, tenantID, otherApplicationID, "")
+
+			Expect(err).To(BeNil())
+			Expect(certificate.ApplicationID).To(Equal(otherApplicationID))
+			Expect(certificate.ErasedRows["address"]).To(Equal(int64(1)))
+
+			_, err = addressDataService.ReadAll(ctx, tenantID, otherApplicationID, otherAddressID)
+
+			Expect(err).To(Equal(contract.NotFoundError{AddressID: otherAddressID}))
+
+			history, err := addressDataService.History(ctx, tenantID, applicationID, addressID)
+
+			Expect(err).To(BeNil())
+			Expect(history).To(HaveLen(2))
+		})
+
+		It("should resume the interrupted erasure of the same application and keep its erasure certificate", func() {
+			_, err := db.Exec(
+				"INSERT INTO erasure_certificate (certificate_id, tenant_id, application_id, requested_by, started_at) VALUES($1, $2, $3, $4, $5)",
+				certificateID.String(),
+				tenantID.String(),
+				applicationID.String(),
+				"privacy-officer",
+				time.Now().Add(-time.Hour))
+
+			Expect(err).To(BeNil())
+
+			_, err = db.Exec(
+				"INSERT INTO erasure_certificate_row (certificate_id, table_name, erased_rows) VALUES($1, $2, 3)",
+				certificateID.String(),
+				"address")
+
+			Expect(err).To(BeNil())
+
+			certificate, err := addressDataService.PurgeApplication(ctx, tenantID, applicationID, "someone-else")
+
+			Expect(err).To(BeNil())
+			Expect(certificate.CertificateID).To(Equal(certificateID))
+			Expect(certificate.RequestedBy).To(Equal("privacy-officer"))
+			Expect(certificate.ErasedRows["address"]).To(Equal(int64(5)))
+			Expect(certificate.ErasedRows["address_history"]).To(Equal(int64(2)))
+		})
+	})
+	Context("when creating, reading and deleting several addresses", func() {
+		var (
+			ctx               context.Context
+			createdAddressIDs []system.UUID
+			missingAddressID  system.UUID
+		)
+
+		BeforeEach(func() {
+			ctx = context.Background()
+			createdAddressIDs = []system.UUID{}
+
+			for i := 0; i < 2; i++ {
+				createdAddressID, _ := system.RandomUUID()
+				createdAddressIDs = append(createdAddressIDs, createdAddressID)
+				mockUUIDGeneratorService.
+					EXPECT().
+					GenerateRandomUUID().
+					Return(createdAddressID, nil)
+			}
+
+			missingAddressID, _ = system.RandomUUID()
+		})
+
+		It("should create every address and report its unique identifier", func() {
+			results := addressDataService.CreateMany(
+				ctx,
+				tenantID,
+				applicationID,
+				[]contract.Address{{AddressDetails: map[string]string{"City": "Wellington"}}, {AddressDetails: map[string]string{"City": "Auckland"}}},
+				"importer")
+
+			Expect(results).To(Equal([]contract.AddressResult{{AddressID: createdAddressIDs[0]}, {AddressID: createdAddressIDs[1]}}))
+
+			address, err := addressDataService.ReadAll(ctx, tenantID, applicationID, createdAddressIDs[1])
+
+			Expect(err).To(BeNil())
+			Expect(address).To(Equal(contract.Address{AddressDetails: map[string]string{"City": "Auckland"}, Version: 1}))
+		})
+
+		It("should read every address and report the ones not found", func() {
+			addressDataService.CreateMany(
+				ctx,
+				tenantID,
+				applicationID,
+				[]contract.Address{{AddressDetails: map[string]string{"City": "Wellington", "Postcode": "6011"}}, {AddressDetails: map[string]string{"City": "Auckland"}}},
+				"")
+
+			results := addressDataService.ReadMany(ctx, tenantID, applicationID, []system.UUID{createdAddressIDs[0], missingAddressID}, []string{"Postcode"})
+
+			Expect(results).To(Equal([]contract.AddressResult{
+				{AddressID: createdAddressIDs[0], Address: contract.Address{AddressDetails: map[string]string{"Postcode": "6011"}, Version: 1}},
+				{AddressID: missingAddressID, Err: contract.NotFoundError{AddressID: missingAddressID}},
+			}))
+
+			results = addressDataService.ReadMany(ctx, tenantID, applicationID, []system.UUID{createdAddressIDs[1]}, nil)
+
+			Expect(results).To(Equal([]contract.AddressResult{
+				{AddressID: createdAddressIDs[1], Address: contract.Address{AddressDetails: map[string]string{"City": "Auckland"}, Version: 1}},
+			}))
+		})
+
+		It("should delete every address and report the ones not found", func() {
+			addressDataService.CreateMany(
+				ctx,
+				tenantID,
+				applicationID,
+				[]contract.Address{{AddressDetails: map[string]string{"City": "Wellington"}}, {AddressDetails: map[string]string{"City": "Auckland"}}},
+				"")
+
+			results := addressDataService.DeleteMany(ctx, tenantID, applicationID, []system.UUID{createdAddressIDs[0], missingAddressID, createdAddressIDs[1]}, "")
+
+			Expect(results).To(HaveLen(3))
+			Expect(results[0]).To(Equal(contract.AddressResult{AddressID: createdAddressIDs[0]}))
+			Expect(results[1].AddressID).To(Equal(missingAddressID))
+			Expect(results[1].Err).NotTo(BeNil())
+			Expect(results[2]).To(Equal(contract.AddressResult{AddressID: createdAddressIDs[1]}))
+
+			_, err := addressDataService.ReadAll(ctx, tenantID, applicationID, createdAddressIDs[1])
+
+			Expect(err).To(Equal(contract.NotFoundError{AddressID: createdAddressIDs[1]}))
+		})
+	})
+
+	Context("when listing addresses", func() {
+		var expectedAddresses map[system.UUID]contract.Address
+
+		BeforeEach(func() {
+			ctx = context.Background()
+			expectedAddresses = make(map[system.UUID]contract.Address)
+
+			for i := 0; i < 3; i++ {
+				addressID, _ = system.RandomUUID()
+				addressDetails := createRandomAddressDetails()
+
+				createAddress(addressDetails)
+
+				expectedAddresses[addressID] = contract.Address{AddressDetails: addressDetails, Version: 1}
+			}
+		})
+
+		It("should return all the addresses of the application page by page", func() {
+			firstPage, err := addressDataService.List(ctx, tenantID, applicationID, 2, "")
+
+			Expect(err).To(BeNil())
+			Expect(firstPage.Addresses).To(HaveLen(2))
+			Expect(firstPage.NextCursor).NotTo(BeEmpty())
+
+			secondPage, err := addressDataService.List(ctx, tenantID, applicationID, 2, firstPage.NextCursor)
+
+			Expect(err).To(BeNil())
+			Expect(secondPage.Addresses).To(HaveLen(1))
+			Expect(secondPage.NextCursor).To(BeEmpty())
+
+			listedAddresses := make(map[system.UUID]contract.Address)
+
+			for _, listedAddress := range append(firstPage.Addresses, secondPage.Addresses...) {
+				listedAddresses[listedAddress.AddressID] = listedAddress.Address
+			}
+
+			Expect(listedAddresses).To(Equal(expectedAddresses))
+		})
+
+		It("should not return the deleted addresses and the addresses of other applications", func() {
+			Expect(addressDataService.Delete(ctx, tenantID, applicationID, addressID, contract.AnyVersion, "")).To(BeNil())
+
+			page, err := addressDataService.List(ctx, tenantID, applicationID, 10, "")
+
+			Expect(err).To(BeNil())
+			Expect(page.Addresses).To(HaveLen(2))
+
+			for _, listedAddress := range page.Addresses {
+				Expect(listedAddress.AddressID).NotTo(Equal(addressID))
+			}
+
+			otherApplicationID, _ := system.RandomUUID()
+			page, err = addressDataService.List(ctx, tenantID, otherApplicationID, 10, "")
+
+			Expect(err).To(BeNil())
+			Expect(page.Addresses).To(BeEmpty())
+		})
+
+		It("should return error if the cursor is invalid", func() {
+			_, err := addressDataService.List(ctx, tenantID, applicationID, 10, "invalid")
+
+			Expect(err).To(Equal(contract.InvalidArgumentError{Message: "Invalid cursor. Cursor: invalid"}))
+		})
+	})
+
+	Context("when finding addresses by detail", func() {
+		var matchingAddressID system.UUID
+
+		BeforeEach(func() {
+			ctx = context.Background()
+			for _, addressDetails := range []map[string]string{
+				{"City": "Wellington", "Postcode": "6011"},
+				{"City": "Wellington", "Postcode": "6012"},
+				{"City": "Christchurch", "Postcode": "6011"},
+			} {
+				addressID, _ = system.RandomUUID()
+				createAddress(addressDetails)
+			}
+
+			matchingAddressID = addressID
+		})
+
+		It("should return the addresses having the provided detail", func() {
+			listedAddresses, err := addressDataService.FindByDetail(ctx, tenantID, applicationID, map[string]string{"Postcode": "6011"})
+
+			Expect(err).To(BeNil())
+			Expect(listedAddresses).To(HaveLen(2))
+		})
+
+		It("should return only the addresses matching all the provided details", func() {
+			listedAddresses, err := addressDataService.FindByDetail(ctx, tenantID, applicationID, map[string]string{"City": "Christchurch", "Postcode": "6011"})
+
+			Expect(err).To(BeNil())
+			Expect(listedAddresses).To(Equal([]contract.ListedAddress{
+				{AddressID: matchingAddressID, Address: contract.Address{AddressDetails: map[string]string{"City": "Christchurch", "Postcode": "6011"}, Version: 1}},
+			}))
+		})
+
+		It("should not return the deleted addresses", func() {
+			Expect(addressDataService.Delete(ctx, tenantID, applicationID, matchingAddressID, contract.AnyVersion, "")).To(BeNil())
+
+			listedAddresses, err := addressDataService.FindByDetail(ctx, tenantID, applicationID, map[string]string{"City": "Christchurch"})
+
+			Expect(err).To(BeNil())
+			Expect(listedAddresses).To(BeEmpty())
+		})
+
+		It("should return the addresses matching the updated details only", func() {
+			Expect(addressDataService.Update(ctx, tenantID, applicationID, matchingAddressID, contract.Address{AddressDetails: map[string]string{"City": "Auckland"}}, contract.AnyVersion, "")).To(BeNil())
+
+			listedAddresses, err := addressDataService.FindByDetail(ctx, tenantID, applicationID, map[string]string{"City": "Christchurch"})
+
+			Expect(err).To(BeNil())
+			Expect(listedAddresses).To(BeEmpty())
+
+			listedAddresses, err = addressDataService.FindByDetail(ctx, tenantID, applicationID, map[string]string{"City": "Auckland"})
+
+			Expect(err).To(BeNil())
+			Expect(listedAddresses).To(HaveLen(1))
+		})
+	})
+
+	Context("when finding addresses by fingerprint", func() {
+		var matchingAddressID system.UUID
+
+		BeforeEach(func() {
+			ctx = context.Background()
+			for _, address := range []contract.Address{
+				{AddressDetails: map[string]string{"City": "Wellington"}, Fingerprint: "wellington"},
+				{AddressDetails: map[string]string{"City": "Auckland"}},
+				{AddressDetails: map[string]string{"City": "Christchurch"}, Fingerprint: "christchurch"},
+			} {
+				addressID, _ = system.RandomUUID()
+				mockUUIDGeneratorService.
+					EXPECT().
+					GenerateRandomUUID().
+					Return(addressID, nil)
+
+				_, err := addressDataService.Create(ctx, tenantID, applicationID, address, "")
+
+				Expect(err).To(BeNil())
+			}
+
+			matchingAddressID = addressID
+		})
+
+		It("should return the addresses created with the provided fingerprint", func() {
+			listedAddresses, err := addressDataService.FindByFingerprint(ctx, tenantID, applicationID, "christchurch")
+
+			Expect(err).To(BeNil())
+			Expect(listedAddresses).To(Equal([]contract.ListedAddress{
+				{AddressID: matchingAddressID, Address: contract.Address{AddressDetails: map[string]string{"City": "Christchurch"}, Version: 1}},
+			}))
+		})
+
+		It("should not return the addresses of other applications", func() {
+			otherApplicationID, _ := system.RandomUUID()
+
+			listedAddresses, err := addressDataService.FindByFingerprint(ctx, tenantID, otherApplicationID, "christchurch")
+
+			Expect(err).To(BeNil())
+			Expect(listedAddresses).To(BeEmpty())
+		})
+
+		It("should not return the deleted addresses", func() {
+			Expect(addressDataService.Delete(ctx, tenantID, applicationID, matchingAddressID, contract.AnyVersion, "")).To(BeNil())
+
+			listedAddresses, err := addressDataService.FindByFingerprint(ctx, tenantID, applicationID, "christchurch")
+
+			Expect(err).To(BeNil())
+			Expect(listedAddresses).To(BeEmpty())
+		})
+
+		It("should return the addresses by the fingerprint they were last updated with", func() {
+			updatedAddress := contract.Address{AddressDetails: map[string]string{"City": "Nelson"}, Fingerprint: "nelson"}
+
+			Expect(addressDataService.Update(ctx, tenantID, applicationID, matchingAddressID, updatedAddress, contract.AnyVersion, "")).To(BeNil())
+
+			listedAddresses, err := addressDataService.FindByFingerprint(ctx, tenantID, applicationID, "christchurch")
+
+			Expect(err).To(BeNil())
+			Expect(listedAddresses).To(BeEmpty())
+
+			listedAddresses, err = addressDataService.FindByFingerprint(ctx, tenantID, applicationID, "nelson")
+
+			Expect(err).To(BeNil())
+			Expect(listedAddresses).To(HaveLen(1))
+		})
+
+		It("should keep the fingerprint when the address is patched", func() {
+			Expect(addressDataService.Patch(ctx, tenantID, applicationID, matchingAddressID, map[string]string{"City": "Nelson"}, nil, contract.AnyVersion, "")).To(BeNil())
+
+			listedAddresses, err := addressDataService.FindByFingerprint(ctx, tenantID, applicationID, "christchurch")
+
+			Expect(err).To(BeNil())
+			Expect(listedAddresses).To(Equal([]contract.ListedAddress{
+				{AddressID: matchingAddressID, Address: contract.Address{AddressDetails: map[string]string{"City": "Nelson"}, Version: 2}},
+			}))
+		})
+	})
+
+	Context("when linking addresses to owners", func() {
+		var (
+			customer        contract.AddressOwner
+			supplier        contract.AddressOwner
+			linkedAddresses []contract.ListedAddress
+		)
+
+		BeforeEach(func() {
+			ctx = context.Background()
+			customer = contract.AddressOwner{Type: "customer", ID: "123"}
+			supplier = contract.AddressOwner{Type: "supplier", ID: "S-42"}
+			linkedAddresses = []contract.ListedAddress{}
+
+			for _, city := range []string{"Wellington", "Auckland", "Christchurch"} {
+				addressID, _ = system.RandomUUID()
+				mockUUIDGeneratorService.
+					EXPECT().
+					GenerateRandomUUID().
+					Return(addressID, nil)
+
+				_, err := addressDataService.Create(ctx, tenantID, applicationID, contract.Address{AddressDetails: map[string]string{"City": city}}, "")
+
+				Expect(err).To(BeNil())
+
+				if city != "Auckland" {
+					Expect(addressDataService.AddOwners(ctx, tenantID, applicationID, addressID, []contract.AddressOwner{customer})).To(BeNil())
+
+					linkedAddresses = append(linkedAddresses, contract.ListedAddress{
+						AddressID: addressID,
+						Address:   contract.Address{AddressDetails: map[string]string{"City": city}, Version: 1},
+					})
+				}
+			}
+
+			sort.Slice(linkedAddresses, func(i, j int) bool {
+				return linkedAddresses[i].AddressID.String() < linkedAddresses[j].AddressID.String()
+			})
+		})
+
+		It("should return the addresses linked to the owner ordered by their unique identifier", func() {
+			listedAddresses, err := addressDataService.FindByOwner(ctx, tenantID, applicationID, customer)
+
+			Expect(err).To(BeNil())
+			Expect(listedAddresses).To(Equal(linkedAddresses))
+		})
+
+		It("should not return the addresses of other owners or other applications", func() {
+			otherApplicationID, _ := system.RandomUUID()
+
+			listedAddresses, err := addressDataService.FindByOwner(ctx, tenantID, applicationID, supplier)
+
+			Expect(err).To(BeNil())
+			Expect(listedAddresses).To(BeEmpty())
+
+			listedAddresses, err = addressDataService.FindByOwner(ctx, tenantID, otherApplicationID, customer)
+
+			Expect(err).To(BeNil())
+			Expect(listedAddresses).To(BeEmpty())
+		})
+
+		It("should return the owners of the address ordered by their type and identifier once each", func() {
+			Expect(addressDataService.AddOwners(ctx, tenantID, applicationID, addressID, []contract.AddressOwner{supplier, customer})).To(BeNil())
+
+			owners, err := addressDataService.ReadOwners(ctx, tenantID, applicationID, addressID)
+
+			Expect(err).To(BeNil())
+			Expect(owners).To(Equal([]contract.AddressOwner{customer, supplier}))
+		})
+
+		It("should unlink the address from the removed owners only", func() {
+			Expect(addressDataService.AddOwners(ctx, tenantID, applicationID, addressID, []contract.AddressOwner{supplier})).To(BeNil())
+			Expect(addressDataService.RemoveOwners(ctx, tenantID, applicationID, addressID, []contract.AddressOwner{customer})).To(BeNil())
+
+			owners, err := addressDataService.ReadOwners(ctx, tenantID, applicationID, addressID)
+
+			Expect(err).To(BeNil())
+			Expect(owners).To(Equal([]contract.AddressOwner{supplier}))
+
+			listedAddresses, err := addressDataService.FindByOwner(ctx, tenantID, applicationID, customer)
+
+			Expect(err).To(BeNil())
+			Expect(listedAddresses).To(HaveLen(1))
+		})
+
+		It("should return NotFoundError when the address does not exist", func() {
+			missingAddressID, _ := system.RandomUUID()
+
+			Expect(addressDataService.AddOwners(ctx, tenantID, applicationID, missingAddressID, []contract.AddressOwner{customer})).To(Equal(contract.NotFoundError{AddressID: missingAddressID}))
+			Expect(addressDataService.RemoveOwners(ctx, tenantID, applicationID, missingAddressID, []contract.AddressOwner{customer})).To(Equal(contract.NotFoundError{AddressID: missingAddressID}))
+
+			_, err := addressDataService.ReadOwners(ctx, tenantID, applicationID, missingAddressID)
+
+			Expect(err).To(Equal(contract.NotFoundError{AddressID: missingAddressID}))
+		})
+
+		It("should not return the deleted addresses until they are restored", func() {
+			Expect(addressDataService.Delete(ctx, tenantID, applicationID, addressID, contract.AnyVersion, "")).To(BeNil())
+
+			listedAddresses, err := addressDataService.FindByOwner(ctx, tenantID, applicationID, customer)
+
+			Expect(err).To(BeNil())
+			Expect(listedAddresses).To(HaveLen(1))
+
+			Expect(addressDataService.Restore(ctx, tenantID, applicationID, addressID, time.Now().Add(-time.Hour), "")).To(BeNil())
+
+			listedAddresses, err = addressDataService.FindByOwner(ctx, tenantID, applicationID, customer)
+
+			Expect(err).To(BeNil())
+			Expect(listedAddresses).To(HaveLen(2))
+		})
+
+		It("should remove the owners when the address is purged", func() {
+			Expect(addressDataService.Delete(ctx, tenantID, applicationID, addressID, contract.AnyVersion, "")).To(BeNil())
+			Expect(addressDataService.Purge(ctx, tenantID, applicationID, addressID)).To(BeNil())
+
+			var count int
+
+			Expect(db.QueryRow(
+				"SELECT COUNT(*) FROM address_owner WHERE tenant_id = $1 AND address_id = $2",
+				tenantID.String(),
+				addressID.String()).Scan(&count)).To(BeNil())
+			Expect(count).To(Equal(0))
+		})
+	})
+
+	Context("when reading the address history", func() {
+		It("should return every version of the address with who changed it, even after it is purged", func() {
+			createdAddressDetails := createRandomAddressDetails()
+			updatedAddressDetails := createRandomAddressDetails()
+
+			createAddress(createdAddressDetails)
+
+			Expect(addressDataService.Update(ctx, tenantID, applicationID, addressID, contract.Address{AddressDetails: updatedAddressDetails}, contract.AnyVersion, "updater")).To(BeNil())
+			Expect(addressDataService.Delete(ctx, tenantID, applicationID, addressID, contract.AnyVersion, "deleter")).To(BeNil())
+			Expect(addressDataService.Restore(ctx, tenantID, applicationID, addressID, time.Now().Add(-time.Hour), "restorer")).To(BeNil())
+			Expect(addressDataService.Delete(ctx, tenantID, applicationID, addressID, contract.AnyVersion, "")).To(BeNil())
+			Expect(addressDataService.Purge(ctx, tenantID, applicationID, addressID)).To(BeNil())
+
+			history, err := addressDataService.History(ctx, tenantID, applicationID, addressID)
+
+			Expect(err).To(BeNil())
+			Expect(history).To(HaveLen(5))
+			Expect(history[0].Address).To(Equal(contract.Address{AddressDetails: createdAddressDetails, Version: 1}))
+			Expect(history[1].Address).To(Equal(contract.Address{AddressDetails: updatedAddressDetails, Version: 2}))
+			Expect(history[1].ChangedBy).To(Equal("updater"))
+			Expect(history[2].Deleted).To(BeTrue())
+			Expect(history[2].ChangedBy).To(Equal("deleter"))
+			Expect(history[3].Address).To(Equal(contract.Address{AddressDetails: updatedAddressDetails, Version: 4}))
+			Expect(history[3].ChangedBy).To(Equal("restorer"))
+			Expect(history[3].Deleted).To(BeFalse())
+			Expect(history[4].Deleted).To(BeTrue())
+		})
+
+		It("should return error if no version of the address is recorded", func() {
+			_, err := addressDataService.History(ctx, tenantID, applicationID, addressID)
+
+			Expect(err).To(Equal(contract.NotFoundError{AddressID: addressID}))
+		})
+	})
+
+	Context("when reading the address at a point in time", func() {
+		It("should return the address as it was at the provided time", func() {
+			createdAddressDetails := createRandomAddressDetails()
+
+			createAddress(createdAddressDetails)
+
+			afterCreate := time.Now()
+			time.Sleep(time.Millisecond)
+
+			Expect(addressDataService.Update(ctx, tenantID, applicationID, addressID, contract.Address{AddressDetails: createRandomAddressDetails()}, contract.AnyVersion, "")).To(BeNil())
+			Expect(addressDataService.Delete(ctx, tenantID, applicationID, addressID, contract.AnyVersion, "")).To(BeNil())
+
+			address, err := addressDataService.ReadAt(ctx, tenantID, applicationID, addressID, afterCreate)
+
+			Expect(err).To(BeNil())
+			Expect(address).To(Equal(contract.Address{AddressDetails: createdAddressDetails, Version: 1}))
+
+			_, err = addressDataService.ReadAt(ctx, tenantID, applicationID, addressID, time.Now())
+
+			Expect(err).To(Equal(contract.NotFoundError{AddressID: addressID}))
+		})
+	})
+
+	Context("when address is created with a TTL", func() {
+		BeforeEach(func() {
+			ctx = context.Background()
+			mockUUIDGeneratorService.
+				EXPECT().
+				GenerateRandomUUID().
+				Return(addressID, nil)
+
+			_, err := addressDataService.Create(
+				ctx,
+				tenantID,
+				applicationID,
+				contract.Address{AddressDetails: map[string]string{"City": "Christchurch"}, TTL: 50 * time.Millisecond},
+				"")
+
+			Expect(err).To(BeNil())
+		})
+
+		It("should return the remaining TTL when reading the address", func() {
+			address, err := addressDataService.ReadAll(ctx, tenantID, applicationID, addressID)
+
+			Expect(err).To(BeNil())
+			Expect(address.TTL).To(BeNumerically(">", 0))
+			Expect(address.TTL).To(BeNumerically("<=", 50*time.Millisecond))
+
+			page, err := addressDataService.List(ctx, tenantID, applicationID, 10, "")
+
+			Expect(err).To(BeNil())
+			Expect(page.Addresses).To(HaveLen(1))
+			Expect(page.Addresses[0].Address.TTL).To(BeNumerically(">", 0))
+		})
+
+		It("should not return the address once it has expired", func() {
+			time.Sleep(60 * time.Millisecond)
+
+			_, err := addressDataService.ReadAll(ctx, tenantID, applicationID, addressID)
+
+			Expect(err).To(Equal(contract.NotFoundError{AddressID: addressID}))
+
+			page, err := addressDataService.List(ctx, tenantID, applicationID, 10, "")
+
+			Expect(err).To(BeNil())
+			Expect(page.Addresses).To(BeEmpty())
+
+			listedAddresses, err := addressDataService.FindByDetail(ctx, tenantID, applicationID, map[string]string{"City": "Christchurch"})
+
+			Expect(err).To(BeNil())
+			Expect(listedAddresses).To(BeEmpty())
+		})
+
+		It("should never expire the address once it is updated without a TTL", func() {
+			Expect(addressDataService.Update(ctx, tenantID, applicationID, addressID, contract.Address{AddressDetails: map[string]string{"City": "Wellington"}}, contract.AnyVersion, "")).To(BeNil())
+
+			time.Sleep(60 * time.Millisecond)
+
+			address, err := addressDataService.ReadAll(ctx, tenantID, applicationID, addressID)
+
+			Expect(err).To(BeNil())
+			Expect(address).To(Equal(contract.Address{AddressDetails: map[string]string{"City": "Wellington"}, Version: 2}))
+		})
+	})
+
+	Context("when the context is cancelled", func() {
+		It("should not create the address", func() {
+			mockUUIDGeneratorService.
+				EXPECT().
+				GenerateRandomUUID().
+				Return(addressID, nil)
+
+			cancelledCtx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			_, err := addressDataService.Create(
+				cancelledCtx,
+				tenantID,
+				applicationID,
+				contract.Address{AddressDetails: map[string]string{"City": "Christchurch"}},
+				"")
+
+			Expect(err).To(Equal(context.Canceled))
+
+			_, err = addressDataService.ReadAll(ctx, tenantID, applicationID, addressID)
+
+			Expect(err).To(Equal(contract.NotFoundError{AddressID: addressID}))
+		})
+	})
+})
+
+// createSQLiteDatabase creates a new in-memory SQLite database and runs DatabaseScript.sql against it.
+func createSQLiteDatabase() *sql.DB {
+	db, err := sql.Open("sqlite3", ":memory:")
+
+	Expect(err).To(BeNil())
+
+	// Every connection to an in-memory database gets its own empty database, so only one connection must be used.
+	db.SetMaxOpenConns(1)
+
+	script, err := ioutil.ReadFile("../../DatabaseScript.sql")
+
+	Expect(err).To(BeNil())
+
+	for _, statement := range strings.Split(string(script), ";") {
+		if len(strings.TrimSpace(statement)) != 0 {
+			_, err = db.Exec(statement)
+
+			Expect(err).To(BeNil())
+		}
+	}
+
+	return db
+}
+
+func TestSQLAddressDataService(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "SQL address data service behaviour")
+}
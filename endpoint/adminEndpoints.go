@@ -0,0 +1,171 @@
+package endpoint
+
+import (
+	"sort"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	"github.com/micro-business/AddressService/business/contract"
+	"github.com/micro-business/AddressService/business/domain"
+	"github.com/micro-business/Micro-Business-Core/system"
+)
+
+type erasedRows struct {
+	Table string `json:"table"`
+	Rows  int64  `json:"rows"`
+}
+
+type erasureCertificate struct {
+	ID            string       `json:"id"`
+	TenantID      string       `json:"tenantId"`
+	ApplicationID *string      `json:"applicationId"`
+	RequestedBy   string       `json:"requestedBy"`
+	StartedAt     time.Time    `json:"startedAt"`
+	CompletedAt   time.Time    `json:"completedAt"`
+	ErasedRows    []erasedRows `json:"erasedRows"`
+}
+
+var erasedRowsType = graphql.NewObject(
+	graphql.ObjectConfig{
+		Name: "ErasedRows",
+		Fields: graphql.Fields{
+			"table": &graphql.Field{Type: graphql.String},
+			"rows":  &graphql.Field{Type: graphql.Int},
+		},
+	},
+)
+
+var erasureCertificateType = graphql.NewObject(
+	graphql.ObjectConfig{
+		Name: "ErasureCertificate",
+		Fields: graphql.Fields{
+			"id":            &graphql.Field{Type: graphql.ID},
+			"tenantId":      &graphql.Field{Type: graphql.ID},
+			"applicationId": &graphql.Field{Type: graphql.ID},
+			"requestedBy":   &graphql.Field{Type: graphql.String},
+			"startedAt":     &graphql.Field{Type: graphql.DateTime},
+			"completedAt":   &graphql.Field{Type: graphql.DateTime},
+			"erasedRows":    &graphql.Field{Type: graphql.NewList(erasedRowsType)},
+		},
+	},
+)
+
+var adminMutationType = graphql.NewObject(
+	graphql.ObjectConfig{
+		Name: "AdminMutation",
+		Fields: graphql.Fields{
+			"purgeTenant": &graphql.Field{
+				Type:        erasureCertificateType,
+				Description: "Removes for good all the addresses of a tenant and returns the erasure certificate",
+				Args: graphql.FieldConfigArgument{
+					"tenantId": &graphql.ArgumentConfig{
+						Type: graphql.NewNonNull(graphql.ID),
+					},
+				},
+				Resolve: func(resolveParams graphql.ResolveParams) (interface{}, error) {
+					tenantID, err := parseUUIDArgument(resolveParams.Args, "tenantId")
+
+					if err != nil {
+						return nil, err
+					}
+
+					executionContext := resolveParams.Context.Value("ExecutionContext").(executionContext)
+
+					certificate, err := executionContext.addressService.PurgeTenant(resolveParams.Context, tenantID, executionContext.changedBy)
+
+					if err != nil {
+						return nil, err
+					}
+
+					return mapToErasureCertificate(certificate), nil
+				},
+			},
+
+			"purgeApplication": &graphql.Field{
+				Type:        erasureCertificateType,
+				Description: "Removes for good all the addresses of a tenant's application and returns the erasure certificate",
+				Args: graphql.FieldConfigArgument{
+					"tenantId": &graphql.ArgumentConfig{
+						Type: graphql.NewNonNull(graphql.ID),
+					},
+					"applicationId": &graphql.ArgumentConfig{
+						Type: graphql.NewNonNull(graphql.ID),
+					},
+				},
+				Resolve: func(resolveParams graphql.ResolveParams) (interface{}, error) {
+					tenantID, err := parseUUIDArgument(resolveParams.Args, "tenantId")
+
+					if err != nil {
+						return nil, err
+					}
+
+					applicationID, err := parseUUIDArgument(resolveParams.Args, "applicationId")
+
+					if err != nil {
+						return nil, err
+					}
+
+					executionContext := resolveParams.Context.Value("ExecutionContext").(executionContext)
+
+					certificate, err := executionContext.addressService.PurgeApplication(
+						resolveParams.Context,
+						tenantID,
+						applicationID,
+						executionContext.changedBy)
+
+					if err != nil {
+						return nil, err
+					}
+
+					return mapToErasureCertificate(certificate), nil
+				},
+			},
+		},
+	},
+)
+
+// newAdminSchema creates the schema served at /AdminApi. It is served only on the admin listening port, never along with
+// the address schema, as the service does not authenticate the requests itself. GraphQL requires a query type, so the
+// address queries are served too.
+func newAdminSchema() (graphql.Schema, error) {
+	return graphql.NewSchema(graphql.SchemaConfig{Query: rootQueryType, Mutation: adminMutationType})
+}
+
+// parseUUIDArgument parses the unique identifier provided to the named argument, returning InvalidArgumentError if it is
+// not a valid UUID.
+func parseUUIDArgument(args map[string]interface{}, name string) (system.UUID, error) {
+	id, err := system.ParseUUID(args[name].(string))
+
+	if err != nil {
+		return system.EmptyUUID, contract.InvalidArgumentError{Message: name + " is not valid. Error: " + err.Error()}
+	}
+
+	return id, nil
+}
+
+// mapToErasureCertificate maps the erasure certificate domain object to the erasure certificate returned to the client,
+// ordering the erased rows by table name.
+func mapToErasureCertificate(certificate domain.ErasureCertificate) erasureCertificate {
+	mappedCertificate := erasureCertificate{
+		ID:          certificate.CertificateID.String(),
+		TenantID:    certificate.TenantID.String(),
+		RequestedBy: certificate.RequestedBy,
+		StartedAt:   certificate.StartedAt,
+		CompletedAt: certificate.CompletedAt,
+		ErasedRows:  make([]erasedRows, 0, len(certificate.ErasedRows)),
+	}
+
+	if certificate.ApplicationID != system.EmptyUUID {
+		mappedCertificate.ApplicationID = stringPointer(certificate.ApplicationID.String())
+	}
+
+	for table, rows := range certificate.ErasedRows {
+		mappedCertificate.ErasedRows = append(mappedCertificate.ErasedRows, erasedRows{Table: table, Rows: rows})
+	}
+
+	sort.Slice(mappedCertificate.ErasedRows, func(i, j int) bool {
+		return mappedCertificate.ErasedRows[i].Table < mappedCertificate.ErasedRows[j].Table
+	})
+
+	return mappedCertificate
+}
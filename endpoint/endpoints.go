@@ -1,13 +1,17 @@
 package endpoint
 
 import (
-	"errors"
+	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-kit/kit/endpoint"
 	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
 	"github.com/micro-business/AddressService/business/contract"
 	"github.com/micro-business/AddressService/business/domain"
+	"github.com/micro-business/AddressService/endpoint/transport"
 	"github.com/micro-business/Micro-Business-Core/common/query"
 	"github.com/micro-business/Micro-Business-Core/system"
 	"golang.org/x/net/context"
@@ -26,6 +30,27 @@ const (
 	state          = "State"
 	postcode       = "Postcode"
 	country        = "Country"
+	version        = "Version"
+	changedAt      = "ChangedAt"
+	changedBy      = "ChangedBy"
+	deleted        = "Deleted"
+	history        = "history"
+	owners         = "owners"
+	identifier     = "id"
+	ttl            = "TTL"
+
+	// defaultPageSize is the number of addresses returned by addresses query when first argument is not provided.
+	defaultPageSize = 50
+)
+
+// The GraphQL error codes returned to the client in the extensions of the errors.
+const (
+	invalidArgumentErrorCode  = "INVALID_ARGUMENT"
+	notFoundErrorCode         = "NOT_FOUND"
+	conflictErrorCode         = "CONFLICT"
+	duplicateAddressErrorCode = "DUPLICATE_ADDRESS"
+	unavailableErrorCode      = "UNAVAILABLE"
+	internalErrorCode         = "INTERNAL"
 )
 
 type address struct {
@@ -41,12 +66,96 @@ type address struct {
 	State          string `json:"State"`
 	Postcode       string `json:"Postcode"`
 	Country        string `json:"Country"`
+	Version        int64  `json:"Version"`
+	TTL            int64  `json:"TTL"`
+
+	addressID system.UUID
+}
+
+type addressEdge struct {
+	Node  interface{} `json:"node"`
+	Error *string     `json:"error"`
+}
+
+type addressResult struct {
+	ID    *string `json:"id"`
+	Error *string `json:"error"`
+}
+
+type createdAddress struct {
+	ID          string  `json:"id"`
+	DuplicateOf *string `json:"duplicateOf"`
+}
+
+type addressOwner struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+type pageInfo struct {
+	HasNextPage bool   `json:"hasNextPage"`
+	EndCursor   string `json:"endCursor"`
+}
+
+type addressConnection struct {
+	Edges    []addressEdge `json:"edges"`
+	PageInfo pageInfo      `json:"pageInfo"`
+}
+
+type addressHistoryEntry struct {
+	address
+
+	ChangedAt time.Time `json:"ChangedAt"`
+	ChangedBy string    `json:"ChangedBy"`
+	Deleted   bool      `json:"Deleted"`
 }
 
+var addressHistoryEntryType = graphql.NewObject(
+	graphql.ObjectConfig{
+		Name: "AddressHistoryEntry",
+		Fields: graphql.Fields{
+			buildingNumber: &graphql.Field{Type: graphql.String},
+			streetNumber:   &graphql.Field{Type: graphql.String},
+			line1:          &graphql.Field{Type: graphql.String},
+			line2:          &graphql.Field{Type: graphql.String},
+			line3:          &graphql.Field{Type: graphql.String},
+			line4:          &graphql.Field{Type: graphql.String},
+			line5:          &graphql.Field{Type: graphql.String},
+			suburb:         &graphql.Field{Type: graphql.String},
+			city:           &graphql.Field{Type: graphql.String},
+			state:          &graphql.Field{Type: graphql.String},
+			postcode:       &graphql.Field{Type: graphql.String},
+			country:        &graphql.Field{Type: graphql.String},
+			version:        &graphql.Field{Type: graphql.Int},
+			changedAt:      &graphql.Field{Type: graphql.DateTime},
+			changedBy:      &graphql.Field{Type: graphql.String},
+			deleted:        &graphql.Field{Type: graphql.Boolean},
+		},
+	},
+)
+
+var addressOwnerType = graphql.NewObject(
+	graphql.ObjectConfig{
+		Name: "AddressOwner",
+		Fields: graphql.Fields{
+			"type":     &graphql.Field{Type: graphql.NewNonNull(graphql.String), Description: "The type of the owning entity, e.g. customer"},
+			identifier: &graphql.Field{Type: graphql.NewNonNull(graphql.String), Description: "The identifier of the owning entity"},
+		},
+	},
+)
+
 var addressType = graphql.NewObject(
 	graphql.ObjectConfig{
 		Name: "Address",
 		Fields: graphql.Fields{
+			identifier: &graphql.Field{
+				Type: graphql.ID,
+				Resolve: func(resolveParams graphql.ResolveParams) (interface{}, error) {
+					returnedAddress, _ := resolveParams.Source.(address)
+
+					return returnedAddress.addressID.String(), nil
+				},
+			},
 			buildingNumber: &graphql.Field{Type: graphql.String},
 			streetNumber:   &graphql.Field{Type: graphql.String},
 			line1:          &graphql.Field{Type: graphql.String},
@@ -59,6 +168,121 @@ var addressType = graphql.NewObject(
 			state:          &graphql.Field{Type: graphql.String},
 			postcode:       &graphql.Field{Type: graphql.String},
 			country:        &graphql.Field{Type: graphql.String},
+			version:        &graphql.Field{Type: graphql.Int},
+			ttl:            &graphql.Field{Type: graphql.Int, Description: "The remaining number of seconds before the address expires, or 0 if it never expires"},
+			history: &graphql.Field{
+				Type:        graphql.NewList(addressHistoryEntryType),
+				Description: "Returns all the recorded versions of the address ordered from the oldest to the newest",
+				Resolve: func(resolveParams graphql.ResolveParams) (interface{}, error) {
+					executionContext := resolveParams.Context.Value("ExecutionContext").(executionContext)
+					returnedAddress, _ := resolveParams.Source.(address)
+
+					returnedHistory, err := executionContext.addressService.History(
+						resolveParams.Context,
+						executionContext.tenantID,
+						executionContext.applicationID,
+						returnedAddress.addressID)
+
+					if err != nil {
+						return nil, err
+					}
+
+					historyEntries := make([]addressHistoryEntry, 0, len(returnedHistory))
+
+					for _, historyEntry := range returnedHistory {
+						historyEntries = append(historyEntries, addressHistoryEntry{
+							address:   mapToAddress(returnedAddress.addressID, historyEntry.Address),
+							ChangedAt: historyEntry.ChangedAt,
+							ChangedBy: historyEntry.ChangedBy,
+							Deleted:   historyEntry.Deleted,
+						})
+					}
+
+					return historyEntries, nil
+				},
+			},
+			owners: &graphql.Field{
+				Type:        graphql.NewList(addressOwnerType),
+				Description: "Returns the owners the address is linked to ordered by their type and identifier",
+				Resolve: func(resolveParams graphql.ResolveParams) (interface{}, error) {
+					executionContext := resolveParams.Context.Value("ExecutionContext").(executionContext)
+					returnedAddress, _ := resolveParams.Source.(address)
+
+					returnedOwners, err := executionContext.addressService.ReadOwners(
+						resolveParams.Context,
+						executionContext.tenantID,
+						executionContext.applicationID,
+						returnedAddress.addressID)
+
+					if err != nil {
+						return nil, err
+					}
+
+					mappedOwners := make([]addressOwner, 0, len(returnedOwners))
+
+					for _, owner := range returnedOwners {
+						mappedOwners = append(mappedOwners, addressOwner{Type: owner.Type, ID: owner.ID})
+					}
+
+					return mappedOwners, nil
+				},
+			},
+		},
+	},
+)
+
+var addressEdgeType = graphql.NewObject(
+	graphql.ObjectConfig{
+		Name: "AddressEdge",
+		Fields: graphql.Fields{
+			"node":  &graphql.Field{Type: addressType},
+			"error": &graphql.Field{Type: graphql.String, Description: "The reason the address could not be read"},
+		},
+	},
+)
+
+var pageInfoType = graphql.NewObject(
+	graphql.ObjectConfig{
+		Name: "PageInfo",
+		Fields: graphql.Fields{
+			"hasNextPage": &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+			"endCursor":   &graphql.Field{Type: graphql.String},
+		},
+	},
+)
+
+var addressResultType = graphql.NewObject(
+	graphql.ObjectConfig{
+		Name: "AddressResult",
+		Fields: graphql.Fields{
+			identifier: &graphql.Field{Type: graphql.ID},
+			"error":    &graphql.Field{Type: graphql.String, Description: "The reason the address could not be created or deleted"},
+		},
+	},
+)
+
+var createdAddressType = graphql.NewObject(
+	graphql.ObjectConfig{
+		Name: "CreatedAddress",
+		Fields: graphql.Fields{
+			identifier: &graphql.Field{
+				Type:        graphql.ID,
+				Description: "The unique identifier of the new address, or of the existing address if it is returned instead",
+			},
+			"duplicateOf": &graphql.Field{
+				Type:        graphql.ID,
+				Description: "The unique identifier of an existing address with the same normalized details, or null if there is none",
+			},
+		},
+	},
+)
+
+var addressConnectionType = graphql.NewObject(
+	graphql.ObjectConfig{
+		Name: "AddressConnection",
+		Fields: graphql.Fields{
+			"edges":    &graphql.Field{Type: graphql.NewList(addressEdgeType)},
+			"pageInfo": &graphql.Field{Type: graphql.NewNonNull(pageInfoType)},
 		},
 	},
 )
@@ -79,6 +303,37 @@ var inputAddressType = graphql.NewInputObject(
 			state:          &graphql.InputObjectFieldConfig{Type: graphql.String},
 			postcode:       &graphql.InputObjectFieldConfig{Type: graphql.String},
 			country:        &graphql.InputObjectFieldConfig{Type: graphql.String},
+			ttl:            &graphql.InputObjectFieldConfig{Type: graphql.Int, Description: "The number of seconds after which the address expires"},
+		},
+	},
+)
+
+var addressFilterType = graphql.NewInputObject(
+	graphql.InputObjectConfig{
+		Name: "AddressFilter",
+		Fields: graphql.InputObjectConfigFieldMap{
+			buildingNumber: &graphql.InputObjectFieldConfig{Type: graphql.String},
+			streetNumber:   &graphql.InputObjectFieldConfig{Type: graphql.String},
+			line1:          &graphql.InputObjectFieldConfig{Type: graphql.String},
+			line2:          &graphql.InputObjectFieldConfig{Type: graphql.String},
+			line3:          &graphql.InputObjectFieldConfig{Type: graphql.String},
+			line4:          &graphql.InputObjectFieldConfig{Type: graphql.String},
+			line5:          &graphql.InputObjectFieldConfig{Type: graphql.String},
+			suburb:         &graphql.InputObjectFieldConfig{Type: graphql.String},
+			city:           &graphql.InputObjectFieldConfig{Type: graphql.String},
+			state:          &graphql.InputObjectFieldConfig{Type: graphql.String},
+			postcode:       &graphql.InputObjectFieldConfig{Type: graphql.String},
+			country:        &graphql.InputObjectFieldConfig{Type: graphql.String},
+		},
+	},
+)
+
+var inputAddressOwnerType = graphql.NewInputObject(
+	graphql.InputObjectConfig{
+		Name: "AddressOwnerInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"type":     &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			identifier: &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
 		},
 	},
 )
@@ -94,43 +349,180 @@ var rootQueryType = graphql.NewObject(
 					"id": &graphql.ArgumentConfig{
 						Type: graphql.NewNonNull(graphql.String),
 					},
+					"asOf": &graphql.ArgumentConfig{
+						Type: graphql.DateTime,
+					},
 				},
 				Resolve: func(resolveParams graphql.ResolveParams) (interface{}, error) {
 					executionContext := resolveParams.Context.Value("ExecutionContext").(executionContext)
 					id, _ := resolveParams.Args["id"].(string)
 
-					addressID, err := system.ParseUUID(id)
+					addressID, err := parseAddressID(id)
 
 					if err != nil {
 						return nil, err
 					}
 
-					keys := query.GetSelectedFields([]string{"address"}, resolveParams)
+					keys := getAddressKeysFromSelectedFields(query.GetSelectedFields([]string{"address"}, resolveParams))
 
 					var returnedAddress domain.Address
 
-					if returnedAddress, err = executionContext.addressService.Read(
+					if asOf, ok := resolveParams.Args["asOf"].(time.Time); ok {
+						returnedAddress, err = executionContext.addressService.ReadAt(
+							resolveParams.Context,
+							executionContext.tenantID,
+							executionContext.applicationID,
+							addressID,
+							asOf)
+					} else if len(keys) == 0 {
+						returnedAddress, err = executionContext.addressService.ReadAll(
+							resolveParams.Context,
+							executionContext.tenantID,
+							executionContext.applicationID,
+							addressID)
+					} else {
+						returnedAddress, err = executionContext.addressService.Read(
+							resolveParams.Context,
+							executionContext.tenantID,
+							executionContext.applicationID,
+							addressID,
+							keys)
+					}
+
+					if err != nil {
+						return nil, err
+					}
+
+					return mapToAddress(addressID, returnedAddress), nil
+				},
+			},
+
+			"addresses": &graphql.Field{
+				Type:        addressConnectionType,
+				Description: "Returns a page of the existing addresses, or the addresses with the provided ids",
+				Args: graphql.FieldConfigArgument{
+					"ids": &graphql.ArgumentConfig{
+						Type: graphql.NewList(graphql.NewNonNull(graphql.ID)),
+					},
+					"first": &graphql.ArgumentConfig{
+						Type:         graphql.Int,
+						DefaultValue: defaultPageSize,
+					},
+					"after": &graphql.ArgumentConfig{
+						Type: graphql.String,
+					},
+				},
+				Resolve: func(resolveParams graphql.ResolveParams) (interface{}, error) {
+					executionContext := resolveParams.Context.Value("ExecutionContext").(executionContext)
+
+					if ids, ok := resolveParams.Args["ids"].([]interface{}); ok {
+						return readAddresses(resolveParams.Context, executionContext, ids)
+					}
+
+					first, _ := resolveParams.Args["first"].(int)
+					after, _ := resolveParams.Args["after"].(string)
+
+					if first <= 0 {
+						return nil, contract.InvalidArgumentError{Message: "first must be greater than zero."}
+					}
+
+					page, err := executionContext.addressService.List(
+						resolveParams.Context,
 						executionContext.tenantID,
 						executionContext.applicationID,
-						addressID,
-						keys); err != nil {
+						first,
+						after)
+
+					if err != nil {
 						return nil, err
 					}
 
-					return address{
-						BuildingNumber: returnedAddress.AddressDetails[buildingNumber],
-						StreetNumber:   returnedAddress.AddressDetails[streetNumber],
-						Line1:          returnedAddress.AddressDetails[line1],
-						Line2:          returnedAddress.AddressDetails[line2],
-						Line3:          returnedAddress.AddressDetails[line3],
-						Line4:          returnedAddress.AddressDetails[line4],
-						Line5:          returnedAddress.AddressDetails[line5],
-						Suburb:         returnedAddress.AddressDetails[suburb],
-						City:           returnedAddress.AddressDetails[city],
-						State:          returnedAddress.AddressDetails[state],
-						Postcode:       returnedAddress.AddressDetails[postcode],
-						Country:        returnedAddress.AddressDetails[country],
-					}, nil
+					connection := addressConnection{
+						Edges:    make([]addressEdge, 0, len(page.Addresses)),
+						PageInfo: pageInfo{HasNextPage: page.NextCursor != "", EndCursor: page.NextCursor},
+					}
+
+					for _, listedAddress := range page.Addresses {
+						connection.Edges = append(connection.Edges, addressEdge{Node: mapToAddress(listedAddress.AddressID, listedAddress.Address)})
+					}
+
+					return connection, nil
+				},
+			},
+
+			"findAddresses": &graphql.Field{
+				Type:        graphql.NewList(addressType),
+				Description: "Returns up to " + strconv.Itoa(contract.MaxFoundAddresses) + " existing addresses having all the address parts provided in the filter",
+				Args: graphql.FieldConfigArgument{
+					"filter": &graphql.ArgumentConfig{
+						Type: graphql.NewNonNull(addressFilterType),
+					},
+				},
+				Resolve: func(resolveParams graphql.ResolveParams) (interface{}, error) {
+					inputFilterArgument, _ := resolveParams.Args["filter"].(map[string]interface{})
+					var filter domain.Address
+					var err error
+
+					if filter, err = resolveAddressFromInputAddressArgument(inputFilterArgument); err != nil {
+						return nil, err
+					}
+
+					executionContext := resolveParams.Context.Value("ExecutionContext").(executionContext)
+
+					listedAddresses, err := executionContext.addressService.FindByDetail(
+						resolveParams.Context,
+						executionContext.tenantID,
+						executionContext.applicationID,
+						filter.AddressDetails)
+
+					if err != nil {
+						return nil, err
+					}
+
+					addresses := make([]address, 0, len(listedAddresses))
+
+					for _, listedAddress := range listedAddresses {
+						addresses = append(addresses, mapToAddress(listedAddress.AddressID, listedAddress.Address))
+					}
+
+					return addresses, nil
+				},
+			},
+
+			"addressesByOwner": &graphql.Field{
+				Type:        graphql.NewList(addressType),
+				Description: "Returns the existing addresses linked to the provided owner",
+				Args: graphql.FieldConfigArgument{
+					"type": &graphql.ArgumentConfig{
+						Type: graphql.NewNonNull(graphql.String),
+					},
+					"id": &graphql.ArgumentConfig{
+						Type: graphql.NewNonNull(graphql.String),
+					},
+				},
+				Resolve: func(resolveParams graphql.ResolveParams) (interface{}, error) {
+					ownerType, _ := resolveParams.Args["type"].(string)
+					ownerID, _ := resolveParams.Args["id"].(string)
+
+					executionContext := resolveParams.Context.Value("ExecutionContext").(executionContext)
+
+					listedAddresses, err := executionContext.addressService.FindByOwner(
+						resolveParams.Context,
+						executionContext.tenantID,
+						executionContext.applicationID,
+						domain.AddressOwner{Type: ownerType, ID: ownerID})
+
+					if err != nil {
+						return nil, err
+					}
+
+					addresses := make([]address, 0, len(listedAddresses))
+
+					for _, listedAddress := range listedAddresses {
+						addresses = append(addresses, mapToAddress(listedAddress.AddressID, listedAddress.Address))
+					}
+
+					return addresses, nil
 				},
 			},
 		},
@@ -142,8 +534,8 @@ var rootMutationType = graphql.NewObject(
 		Name: "RootMutation",
 		Fields: graphql.Fields{
 			"create": &graphql.Field{
-				Type:        graphql.ID,
-				Description: "Creates new address",
+				Type:        createdAddressType,
+				Description: "Creates new address, reporting an existing address with the same normalized details",
 				Args: graphql.FieldConfigArgument{
 					"address": &graphql.ArgumentConfig{
 						Type: graphql.NewNonNull(inputAddressType),
@@ -160,16 +552,74 @@ var rootMutationType = graphql.NewObject(
 
 					executionContext := resolveParams.Context.Value("ExecutionContext").(executionContext)
 
-					addressID, err := executionContext.addressService.Create(
+					created, err := executionContext.addressService.Create(
+						resolveParams.Context,
 						executionContext.tenantID,
 						executionContext.applicationID,
-						address)
+						address,
+						executionContext.changedBy)
 
 					if err != nil {
 						return nil, err
 					}
 
-					return addressID.String(), nil
+					result := createdAddress{ID: created.AddressID.String()}
+
+					if created.DuplicateOf != system.EmptyUUID {
+						result.DuplicateOf = stringPointer(created.DuplicateOf.String())
+					}
+
+					return result, nil
+				},
+			},
+
+			"createAddresses": &graphql.Field{
+				Type:        graphql.NewList(addressResultType),
+				Description: "Creates new addresses, reporting the result of creating every address in the same order",
+				Args: graphql.FieldConfigArgument{
+					"addresses": &graphql.ArgumentConfig{
+						Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(inputAddressType))),
+					},
+				},
+				Resolve: func(resolveParams graphql.ResolveParams) (interface{}, error) {
+					inputAddressesArgument, _ := resolveParams.Args["addresses"].([]interface{})
+					results := make([]addressResult, len(inputAddressesArgument))
+					addresses := []domain.Address{}
+					indexes := []int{}
+
+					for index, inputAddressArgument := range inputAddressesArgument {
+						address, err := resolveAddressFromInputAddressArgument(inputAddressArgument.(map[string]interface{}))
+
+						if err != nil {
+							results[index] = mapToAddressResult(system.EmptyUUID, err)
+						} else {
+							addresses = append(addresses, address)
+							indexes = append(indexes, index)
+						}
+					}
+
+					if len(addresses) == 0 {
+						return results, nil
+					}
+
+					executionContext := resolveParams.Context.Value("ExecutionContext").(executionContext)
+
+					createResults, err := executionContext.addressService.CreateMany(
+						resolveParams.Context,
+						executionContext.tenantID,
+						executionContext.applicationID,
+						addresses,
+						executionContext.changedBy)
+
+					if err != nil {
+						return nil, err
+					}
+
+					for index, createResult := range createResults {
+						results[indexes[index]] = mapToAddressResult(createResult.AddressID, createResult.Err)
+					}
+
+					return results, nil
 				},
 			},
 
@@ -183,6 +633,9 @@ var rootMutationType = graphql.NewObject(
 					"address": &graphql.ArgumentConfig{
 						Type: graphql.NewNonNull(inputAddressType),
 					},
+					"version": &graphql.ArgumentConfig{
+						Type: graphql.Int,
+					},
 				},
 				Resolve: func(resolveParams graphql.ResolveParams) (interface{}, error) {
 					id, _ := resolveParams.Args["id"].(string)
@@ -191,7 +644,7 @@ var rootMutationType = graphql.NewObject(
 					var addressID system.UUID
 					var err error
 
-					if addressID, err = system.ParseUUID(id); err != nil {
+					if addressID, err = parseAddressID(id); err != nil {
 						return nil, err
 					}
 
@@ -204,10 +657,85 @@ var rootMutationType = graphql.NewObject(
 					executionContext := resolveParams.Context.Value("ExecutionContext").(executionContext)
 
 					err = executionContext.addressService.Update(
+						resolveParams.Context,
+						executionContext.tenantID,
+						executionContext.applicationID,
+						addressID,
+						address,
+						resolveExpectedVersionArgument(resolveParams.Args),
+						executionContext.changedBy)
+
+					if err != nil {
+						return nil, err
+					}
+
+					return addressID.String(), nil
+				},
+			},
+
+			"patch": &graphql.Field{
+				Type:        graphql.ID,
+				Description: "Changes only the provided details of existing address",
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{
+						Type: graphql.NewNonNull(graphql.ID),
+					},
+					"set": &graphql.ArgumentConfig{
+						Type: inputAddressType,
+					},
+					"remove": &graphql.ArgumentConfig{
+						Type: graphql.NewList(graphql.NewNonNull(graphql.String)),
+					},
+					"version": &graphql.ArgumentConfig{
+						Type: graphql.Int,
+					},
+				},
+				Resolve: func(resolveParams graphql.ResolveParams) (interface{}, error) {
+					id, _ := resolveParams.Args["id"].(string)
+					inputSetArgument, _ := resolveParams.Args["set"].(map[string]interface{})
+					inputRemoveArgument, _ := resolveParams.Args["remove"].([]interface{})
+
+					var addressID system.UUID
+					var err error
+
+					if addressID, err = parseAddressID(id); err != nil {
+						return nil, err
+					}
+
+					set := make(map[string]string)
+
+					if len(inputSetArgument) != 0 {
+						var address domain.Address
+
+						if address, err = resolveAddressFromInputAddressArgument(inputSetArgument); err != nil {
+							return nil, err
+						}
+
+						set = address.AddressDetails
+					}
+
+					remove := []string{}
+
+					for _, key := range inputRemoveArgument {
+						remove = append(remove, key.(string))
+					}
+
+					if len(set) == 0 && len(remove) == 0 {
+						return nil, contract.InvalidArgumentError{Message: "At least one address part key must be set or removed."}
+					}
+
+					executionContext := resolveParams.Context.Value("ExecutionContext").(executionContext)
+
+					err = executionContext.addressService.Patch(
+						resolveParams.Context,
 						executionContext.tenantID,
 						executionContext.applicationID,
 						addressID,
-						address)
+						set,
+						remove,
+						resolveExpectedVersionArgument(resolveParams.Args),
+						executionContext.changedBy)
+
 					if err != nil {
 						return nil, err
 					}
@@ -223,6 +751,9 @@ var rootMutationType = graphql.NewObject(
 					"id": &graphql.ArgumentConfig{
 						Type: graphql.NewNonNull(graphql.ID),
 					},
+					"version": &graphql.ArgumentConfig{
+						Type: graphql.Int,
+					},
 				},
 				Resolve: func(resolveParams graphql.ResolveParams) (interface{}, error) {
 					id, _ := resolveParams.Args["id"].(string)
@@ -230,64 +761,290 @@ var rootMutationType = graphql.NewObject(
 					var addressID system.UUID
 					var err error
 
-					if addressID, err = system.ParseUUID(id); err != nil {
+					if addressID, err = parseAddressID(id); err != nil {
 						return nil, err
 					}
 
 					executionContext := resolveParams.Context.Value("ExecutionContext").(executionContext)
 
 					err = executionContext.addressService.Delete(
+						resolveParams.Context,
 						executionContext.tenantID,
 						executionContext.applicationID,
-						addressID)
+						addressID,
+						resolveExpectedVersionArgument(resolveParams.Args),
+						executionContext.changedBy)
+
+					if err != nil {
+						return nil, err
+					}
+
+					return addressID.String(), nil
+
+				},
+			},
+
+			"deleteAddresses": &graphql.Field{
+				Type:        graphql.NewList(addressResultType),
+				Description: "Delete existing addresses, reporting the result of deleting every address in the same order",
+				Args: graphql.FieldConfigArgument{
+					"ids": &graphql.ArgumentConfig{
+						Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.ID))),
+					},
+				},
+				Resolve: func(resolveParams graphql.ResolveParams) (interface{}, error) {
+					ids, _ := resolveParams.Args["ids"].([]interface{})
+					results := make([]addressResult, len(ids))
+					addressIDs := []system.UUID{}
+					indexes := []int{}
+
+					for index, id := range ids {
+						addressID, err := parseAddressID(id.(string))
+
+						if err != nil {
+							results[index] = addressResult{ID: stringPointer(id.(string)), Error: stringPointer(err.Error())}
+						} else {
+							addressIDs = append(addressIDs, addressID)
+							indexes = append(indexes, index)
+						}
+					}
+
+					if len(addressIDs) == 0 {
+						return results, nil
+					}
+
+					executionContext := resolveParams.Context.Value("ExecutionContext").(executionContext)
+
+					deleteResults, err := executionContext.addressService.DeleteMany(
+						resolveParams.Context,
+						executionContext.tenantID,
+						executionContext.applicationID,
+						addressIDs,
+						executionContext.changedBy)
+
+					if err != nil {
+						return nil, err
+					}
+
+					for index, deleteResult := range deleteResults {
+						results[indexes[index]] = mapToAddressResult(deleteResult.AddressID, deleteResult.Err)
+					}
+
+					return results, nil
+				},
+			},
+
+			"addOwners": &graphql.Field{
+				Type:        graphql.ID,
+				Description: "Links existing address to the provided owners, keeping the owners it is already linked to",
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{
+						Type: graphql.NewNonNull(graphql.ID),
+					},
+					owners: &graphql.ArgumentConfig{
+						Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(inputAddressOwnerType))),
+					},
+				},
+				Resolve: func(resolveParams graphql.ResolveParams) (interface{}, error) {
+					id, _ := resolveParams.Args["id"].(string)
+
+					var addressID system.UUID
+					var err error
+
+					if addressID, err = parseAddressID(id); err != nil {
+						return nil, err
+					}
+
+					executionContext := resolveParams.Context.Value("ExecutionContext").(executionContext)
+
+					err = executionContext.addressService.AddOwners(
+						resolveParams.Context,
+						executionContext.tenantID,
+						executionContext.applicationID,
+						addressID,
+						resolveAddressOwnersFromInputArgument(resolveParams.Args[owners]))
+
+					if err != nil {
+						return nil, err
+					}
+
+					return addressID.String(), nil
+				},
+			},
+
+			"removeOwners": &graphql.Field{
+				Type:        graphql.ID,
+				Description: "Unlinks existing address from the provided owners",
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{
+						Type: graphql.NewNonNull(graphql.ID),
+					},
+					owners: &graphql.ArgumentConfig{
+						Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(inputAddressOwnerType))),
+					},
+				},
+				Resolve: func(resolveParams graphql.ResolveParams) (interface{}, error) {
+					id, _ := resolveParams.Args["id"].(string)
+
+					var addressID system.UUID
+					var err error
+
+					if addressID, err = parseAddressID(id); err != nil {
+						return nil, err
+					}
+
+					executionContext := resolveParams.Context.Value("ExecutionContext").(executionContext)
+
+					err = executionContext.addressService.RemoveOwners(
+						resolveParams.Context,
+						executionContext.tenantID,
+						executionContext.applicationID,
+						addressID,
+						resolveAddressOwnersFromInputArgument(resolveParams.Args[owners]))
 
 					if err != nil {
 						return nil, err
 					}
 
 					return addressID.String(), nil
+				},
+			},
+
+			"restore": &graphql.Field{
+				Type:        graphql.ID,
+				Description: "Restore deleted address",
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{
+						Type: graphql.NewNonNull(graphql.ID),
+					},
+				},
+				Resolve: func(resolveParams graphql.ResolveParams) (interface{}, error) {
+					id, _ := resolveParams.Args["id"].(string)
+
+					var addressID system.UUID
+					var err error
+
+					if addressID, err = parseAddressID(id); err != nil {
+						return nil, err
+					}
+
+					executionContext := resolveParams.Context.Value("ExecutionContext").(executionContext)
+
+					err = executionContext.addressService.Restore(
+						resolveParams.Context,
+						executionContext.tenantID,
+						executionContext.applicationID,
+						addressID,
+						executionContext.changedBy)
+
+					if err != nil {
+						return nil, err
+					}
 
+					return addressID.String(), nil
+				},
+			},
+
+			"purge": &graphql.Field{
+				Type:        graphql.ID,
+				Description: "Purge deleted address",
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{
+						Type: graphql.NewNonNull(graphql.ID),
+					},
+				},
+				Resolve: func(resolveParams graphql.ResolveParams) (interface{}, error) {
+					id, _ := resolveParams.Args["id"].(string)
+
+					var addressID system.UUID
+					var err error
+
+					if addressID, err = parseAddressID(id); err != nil {
+						return nil, err
+					}
+
+					executionContext := resolveParams.Context.Value("ExecutionContext").(executionContext)
+
+					err = executionContext.addressService.Purge(
+						resolveParams.Context,
+						executionContext.tenantID,
+						executionContext.applicationID,
+						addressID)
+
+					if err != nil {
+						return nil, err
+					}
+
+					return addressID.String(), nil
 				},
 			},
 		},
 	},
 )
 
-var addressSchema, _ = graphql.NewSchema(graphql.SchemaConfig{Query: rootQueryType, Mutation: rootMutationType})
+// newAddressSchema creates the schema served at /Api.
+func newAddressSchema() (graphql.Schema, error) {
+	return graphql.NewSchema(graphql.SchemaConfig{Query: rootQueryType, Mutation: rootMutationType})
+}
 
 type executionContext struct {
 	addressService contract.AddressService
 	tenantID       system.UUID
 	applicationID  system.UUID
+	changedBy      string
 }
 
-func createAPIEndpoint(addressService contract.AddressService) endpoint.Endpoint {
+// createAPIEndpoint creates the endpoint executing the GraphQL queries against the provided schema. The query is canceled
+// when the client disconnects or when it takes longer than requestTimeout, unless requestTimeout is zero.
+func createAPIEndpoint(schema graphql.Schema, addressService contract.AddressService, requestTimeout time.Duration) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		if requestTimeout > 0 {
+			var cancel context.CancelFunc
+
+			ctx, cancel = context.WithTimeout(ctx, requestTimeout)
+			defer cancel()
+		}
+
 		tenantID, _ := system.ParseUUID("02365c33-43d5-4bf8-b220-25563443960b")
 		applicationID, _ := system.ParseUUID("02365c33-43d5-4bf8-b220-25563443960c")
 
-		result := executeQuery(request.(string), addressService, tenantID, applicationID)
+		result := executeQuery(ctx, schema, request.(string), addressService, tenantID, applicationID, transport.GetChangedBy(ctx))
 
-		if result.HasErrors() {
-			errorMessages := []string{}
+		if !result.HasErrors() {
+			return result, nil
+		}
 
-			for _, err := range result.Errors {
-				errorMessages = append(errorMessages, err.Error())
-			}
+		statusCode := 0
 
-			return nil, errors.New(strings.Join(errorMessages, "\n"))
+		for index, formattedError := range result.Errors {
+			errorCode, errorStatusCode := mapErrorToCode(getResolverError(formattedError))
+			result.Errors[index].Extensions = map[string]interface{}{"code": errorCode}
+
+			if statusCode == 0 {
+				statusCode = errorStatusCode
+			}
 		}
 
-		return result, nil
+		return nil, transport.APIError{StatusCode: statusCode, Response: result}
 	}
 }
 
-func executeQuery(query string, addressService contract.AddressService, tenantID system.UUID, applicationID system.UUID) *graphql.Result {
+func executeQuery(
+	ctx context.Context,
+	schema graphql.Schema,
+	query string,
+	addressService contract.AddressService,
+	tenantID system.UUID,
+	applicationID system.UUID,
+	changedBy string) *graphql.Result {
 	return graphql.Do(
 		graphql.Params{
-			Schema:        addressSchema,
+			Schema:        schema,
 			RequestString: query,
-			Context:       context.WithValue(context.Background(), "ExecutionContext", executionContext{addressService, tenantID, applicationID}),
+			Context: context.WithValue(
+				ctx,
+				"ExecutionContext",
+				executionContext{addressService, tenantID, applicationID, changedBy}),
 		})
 }
 
@@ -318,8 +1075,182 @@ func resolveAddressFromInputAddressArgument(inputAddressArgument map[string]inte
 	}
 
 	if len(address.AddressDetails) == 0 {
-		return domain.Address{}, errors.New("At least one address part key be provided.")
+		return domain.Address{}, contract.InvalidArgumentError{Message: "At least one address part key be provided."}
+	}
+
+	if ttlArg, ttlArgProvided := inputAddressArgument[ttl].(int); ttlArgProvided {
+		if ttlArg < 0 {
+			return domain.Address{}, contract.InvalidArgumentError{Message: "TTL cannot be negative."}
+		}
+
+		address.TTL = time.Duration(ttlArg) * time.Second
 	}
 
 	return address, nil
 }
+
+// readAddresses reads the addresses with the provided ids for addresses query, returning the reason an address could not
+// be read in its edge instead of failing the whole query.
+func readAddresses(ctx context.Context, executionContext executionContext, ids []interface{}) (interface{}, error) {
+	connection := addressConnection{Edges: make([]addressEdge, len(ids))}
+	addressIDs := []system.UUID{}
+	indexes := []int{}
+
+	for index, id := range ids {
+		addressID, err := parseAddressID(id.(string))
+
+		if err != nil {
+			connection.Edges[index] = addressEdge{Error: stringPointer(err.Error())}
+		} else {
+			addressIDs = append(addressIDs, addressID)
+			indexes = append(indexes, index)
+		}
+	}
+
+	if len(addressIDs) == 0 {
+		return connection, nil
+	}
+
+	readResults, err := executionContext.addressService.ReadMany(
+		ctx,
+		executionContext.tenantID,
+		executionContext.applicationID,
+		addressIDs,
+		nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for index, readResult := range readResults {
+		if readResult.Err != nil {
+			connection.Edges[indexes[index]] = addressEdge{Error: stringPointer(readResult.Err.Error())}
+		} else {
+			connection.Edges[indexes[index]] = addressEdge{Node: mapToAddress(readResult.AddressID, readResult.Address)}
+		}
+	}
+
+	return connection, nil
+}
+
+// parseAddressID parses the address id provided by the client, returning InvalidArgumentError if it is not a valid UUID.
+func parseAddressID(id string) (system.UUID, error) {
+	addressID, err := system.ParseUUID(id)
+
+	if err != nil {
+		return system.EmptyUUID, contract.InvalidArgumentError{Message: err.Error()}
+	}
+
+	return addressID, nil
+}
+
+// getResolverError returns the error a resolver failed with, or nil if the error is raised by GraphQL itself, e.g. the
+// query is not valid.
+func getResolverError(formattedError gqlerrors.FormattedError) error {
+	if locatedError, ok := formattedError.OriginalError().(*gqlerrors.Error); ok {
+		return locatedError.OriginalError
+	}
+
+	return formattedError.OriginalError()
+}
+
+// mapErrorToCode returns the GraphQL error code and the HTTP status code the provided error is reported to the client
+// with. The errors raised by GraphQL itself are reported as invalid argument, and the errors which are not returned by
+// the address service as internal error.
+func mapErrorToCode(err error) (string, int) {
+	switch err.(type) {
+	case nil, contract.InvalidArgumentError:
+		return invalidArgumentErrorCode, http.StatusBadRequest
+	case contract.NotFoundError:
+		return notFoundErrorCode, http.StatusNotFound
+	case contract.ConflictError:
+		return conflictErrorCode, http.StatusConflict
+	case contract.DuplicateAddressError:
+		return duplicateAddressErrorCode, http.StatusConflict
+	case contract.UnavailableError:
+		return unavailableErrorCode, http.StatusServiceUnavailable
+	}
+
+	return internalErrorCode, http.StatusInternalServerError
+}
+
+// resolveExpectedVersionArgument returns the version argument provided to update and delete mutations, or AnyVersion if
+// the argument is not provided so the version check is skipped.
+func resolveExpectedVersionArgument(args map[string]interface{}) int64 {
+	if expectedVersion, ok := args["version"].(int); ok {
+		return int64(expectedVersion)
+	}
+
+	return contract.AnyVersion
+}
+
+// resolveAddressOwnersFromInputArgument returns the owners provided to addOwners and removeOwners mutations.
+func resolveAddressOwnersFromInputArgument(inputOwnersArgument interface{}) []domain.AddressOwner {
+	inputOwners, _ := inputOwnersArgument.([]interface{})
+	resolvedOwners := make([]domain.AddressOwner, 0, len(inputOwners))
+
+	for _, inputOwner := range inputOwners {
+		inputOwnerFields, _ := inputOwner.(map[string]interface{})
+		ownerType, _ := inputOwnerFields["type"].(string)
+		ownerID, _ := inputOwnerFields[identifier].(string)
+
+		resolvedOwners = append(resolvedOwners, domain.AddressOwner{Type: ownerType, ID: ownerID})
+	}
+
+	return resolvedOwners
+}
+
+// getAddressKeysFromSelectedFields removes the id, version, TTL, history and owners from the selected address fields, as
+// they are not address detail keys.
+func getAddressKeysFromSelectedFields(selectedFields []string) []string {
+	keys := []string{}
+
+	for _, selectedField := range selectedFields {
+		if selectedField != identifier && selectedField != version && selectedField != ttl && selectedField != history && selectedField != owners {
+			keys = append(keys, selectedField)
+		}
+	}
+
+	return keys
+}
+
+// mapToAddress maps the address domain object to the address object returned to the client.
+func mapToAddress(addressID system.UUID, domainAddress domain.Address) address {
+	return address{
+		BuildingNumber: domainAddress.AddressDetails[buildingNumber],
+		StreetNumber:   domainAddress.AddressDetails[streetNumber],
+		Line1:          domainAddress.AddressDetails[line1],
+		Line2:          domainAddress.AddressDetails[line2],
+		Line3:          domainAddress.AddressDetails[line3],
+		Line4:          domainAddress.AddressDetails[line4],
+		Line5:          domainAddress.AddressDetails[line5],
+		Suburb:         domainAddress.AddressDetails[suburb],
+		City:           domainAddress.AddressDetails[city],
+		State:          domainAddress.AddressDetails[state],
+		Postcode:       domainAddress.AddressDetails[postcode],
+		Country:        domainAddress.AddressDetails[country],
+		Version:        domainAddress.Version,
+		TTL:            int64(domainAddress.TTL / time.Second),
+		addressID:      addressID,
+	}
+}
+
+// mapToAddressResult maps the result of creating or deleting an address to the address result returned to the client.
+func mapToAddressResult(addressID system.UUID, err error) addressResult {
+	result := addressResult{}
+
+	if addressID != system.EmptyUUID {
+		result.ID = stringPointer(addressID.String())
+	}
+
+	if err != nil {
+		result.Error = stringPointer(err.Error())
+	}
+
+	return result
+}
+
+// stringPointer returns a pointer to the provided string, so optional string fields can be returned as null.
+func stringPointer(value string) *string {
+	return &value
+}
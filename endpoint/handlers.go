@@ -4,9 +4,14 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
 	"strconv"
+	"syscall"
+	"time"
 
 	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/graphql-go/graphql"
 	"github.com/micro-business/AddressService/business/contract"
 	"github.com/micro-business/AddressService/config"
 	"github.com/micro-business/AddressService/endpoint/transport"
@@ -18,44 +23,100 @@ import (
 type Endpoint struct {
 	ConfigurationReader config.ConfigurationReader
 	AddressService      contract.AddressService
+
+	// AdminListeningPort is the port the administrative API is served on, separately from the address API. The service
+	// does not authenticate the requests itself, so the administrative API is not served unless the port is set, and the
+	// port must only be reachable by the operators.
+	AdminListeningPort int
 }
 
-// StartServer creates all the endpoints and starts the server.
+// shutdownTimeout is the maximum time the server waits for the in-flight requests to complete when shutting down.
+const shutdownTimeout = 30 * time.Second
+
+// StartServer creates all the endpoints and starts the server. The method blocks until the process receives an interrupt or
+// terminate signal, then stops accepting new requests and returns once the in-flight requests are completed, so the caller
+// can release the resources used by the AddressService.
 func (endpoint Endpoint) StartServer() {
 	diagnostics.IsNotNil(endpoint.AddressService, "endpoint.AddressService", "AddressService must be provided.")
 	diagnostics.IsNotNil(endpoint.ConfigurationReader, "endpoint.ConfigurationReader", "ConfigurationReader must be provided.")
 
 	ctx := context.Background()
 
-	handlers := getHandlers(endpoint, ctx)
-	http.HandleFunc("/CheckHealth", checkHealthHandleFunc)
+	requestTimeout, err := endpoint.ConfigurationReader.GetRequestTimeout()
 
-	for pattern, handler := range handlers {
-		http.Handle(pattern, handler)
+	if err != nil {
+		log.Fatal(err.Error())
 	}
 
-	if listeningPort, err := endpoint.ConfigurationReader.GetListeningPort(); err != nil {
+	addressSchema, err := newAddressSchema()
+
+	if err != nil {
 		log.Fatal(err.Error())
-	} else {
-		log.Fatal(http.ListenAndServe(":"+strconv.Itoa(listeningPort), nil))
 	}
-}
 
-func getHandlers(endpoint Endpoint, ctx context.Context) map[string]http.Handler {
-	handlers := make(map[string]http.Handler)
-	handlers["/Api"] = createAPIHandler(endpoint, ctx)
+	listeningPort, err := endpoint.ConfigurationReader.GetListeningPort()
+
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+
+	serveMux := http.NewServeMux()
+	serveMux.HandleFunc("/CheckHealth", checkHealthHandleFunc)
+	serveMux.Handle("/Api", createAPIHandler(endpoint, ctx, requestTimeout, addressSchema))
+
+	servers := []*http.Server{{Addr: ":" + strconv.Itoa(listeningPort), Handler: serveMux}}
+
+	if endpoint.AdminListeningPort != 0 {
+		adminSchema, err := newAdminSchema()
 
-	return handlers
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+
+		adminServeMux := http.NewServeMux()
+		adminServeMux.HandleFunc("/CheckHealth", checkHealthHandleFunc)
+		adminServeMux.Handle("/AdminApi", createAPIHandler(endpoint, ctx, requestTimeout, adminSchema))
+
+		servers = append(servers, &http.Server{Addr: ":" + strconv.Itoa(endpoint.AdminListeningPort), Handler: adminServeMux})
+	}
+
+	serverErrorChannel := make(chan error, len(servers))
+
+	for _, server := range servers {
+		go func(server *http.Server) {
+			serverErrorChannel <- server.ListenAndServe()
+		}(server)
+	}
+
+	signalChannel := make(chan os.Signal, 1)
+	signal.Notify(signalChannel, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signalChannel)
+
+	select {
+	case err := <-serverErrorChannel:
+		log.Fatal(err.Error())
+	case <-signalChannel:
+		shutdownContext, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+
+		for _, server := range servers {
+			if err := server.Shutdown(shutdownContext); err != nil {
+				log.Println(err.Error())
+			}
+		}
+	}
 }
 
 func checkHealthHandleFunc(writer http.ResponseWriter, request *http.Request) {
 	fmt.Fprintln(writer, "Alive")
 }
 
-func createAPIHandler(endpoint Endpoint, ctx context.Context) http.Handler {
+func createAPIHandler(endpoint Endpoint, ctx context.Context, requestTimeout time.Duration, schema graphql.Schema) http.Handler {
 	return httptransport.NewServer(
 		ctx,
-		createAPIEndpoint(endpoint.AddressService),
+		createAPIEndpoint(schema, endpoint.AddressService, requestTimeout),
 		transport.DecodeAPIRequest,
-		transport.EncodeAPIResponse)
+		transport.EncodeAPIResponse,
+		httptransport.ServerBefore(transport.PopulateRequestContext, transport.PopulateChangedBy),
+		httptransport.ServerErrorEncoder(transport.EncodeAPIError))
 }
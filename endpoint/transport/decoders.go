@@ -7,6 +7,32 @@ import (
 	"golang.org/x/net/context"
 )
 
+// ChangedByHeader is the HTTP header the client sets to identify who makes the change, recorded in the address history.
+const ChangedByHeader = "X-Changed-By"
+
+type contextKey string
+
+// changedByContextKey is the key the value of ChangedByHeader is stored under in the request context.
+const changedByContextKey contextKey = "ChangedBy"
+
+// PopulateRequestContext replaces the server context with the context of the HTTP request, so processing the request is
+// canceled when the client disconnects. It must be the first function run before decoding the request.
+func PopulateRequestContext(ctx context.Context, httpRequest *http.Request) context.Context {
+	return httpRequest.Context()
+}
+
+// PopulateChangedBy stores the value of ChangedByHeader sent by the client in the request context.
+func PopulateChangedBy(ctx context.Context, httpRequest *http.Request) context.Context {
+	return context.WithValue(ctx, changedByContextKey, httpRequest.Header.Get(ChangedByHeader))
+}
+
+// GetChangedBy returns the value of ChangedByHeader stored in the request context, or empty string if it was not sent.
+func GetChangedBy(ctx context.Context) string {
+	changedBy, _ := ctx.Value(changedByContextKey).(string)
+
+	return changedBy
+}
+
 // DecodeAPIRequest decodes the request message sent by the client. The request message can be sent using GET method as part of
 // URL or can be the payload of a POST HTTP message.
 func DecodeAPIRequest(context context.Context, httpRequest *http.Request) (interface{}, error) {
@@ -4,15 +4,56 @@ import (
 	"encoding/json"
 	"net/http"
 
+	httptransport "github.com/go-kit/kit/transport/http"
 	"golang.org/x/net/context"
 )
 
+// APIError is returned by the API endpoint when the request fails, holding the HTTP status code to respond with and the
+// response message to send back to the client.
+type APIError struct {
+	StatusCode int
+	Response   interface{}
+}
+
+// Error returns the error message.
+func (apiError APIError) Error() string {
+	return http.StatusText(apiError.StatusCode)
+}
+
 // EncodeAPIResponse encodes the response message before sending back to the client
 func EncodeAPIResponse(context context.Context, writer http.ResponseWriter, response interface{}) error {
+	setResponseHeaders(writer)
+
+	return json.NewEncoder(writer).Encode(response)
+}
+
+// EncodeAPIError encodes the error returned while processing the request before sending back to the client. APIError is
+// sent with its status code and response message, the request decoding errors are sent with 400 status code and the rest
+// of the errors with 500 status code.
+func EncodeAPIError(context context.Context, err error, writer http.ResponseWriter) {
+	if transportError, ok := err.(httptransport.Error); ok {
+		if apiError, ok := transportError.Err.(APIError); ok {
+			setResponseHeaders(writer)
+			writer.WriteHeader(apiError.StatusCode)
+			json.NewEncoder(writer).Encode(apiError.Response)
+
+			return
+		}
+
+		if transportError.Domain == httptransport.DomainDecode {
+			http.Error(writer, transportError.Err.Error(), http.StatusBadRequest)
+
+			return
+		}
+	}
+
+	http.Error(writer, err.Error(), http.StatusInternalServerError)
+}
+
+// setResponseHeaders sets the headers sent back to the client along with the response message.
+func setResponseHeaders(writer http.ResponseWriter) {
 	writer.Header().Set("Content-Type", "application/json; charset=utf-8")
 	writer.Header().Set("Access-Control-Allow-Origin", "*")
 	writer.Header().Set("Access-Control-Allow-Methods", "POST")
-	writer.Header().Set("Access-Control-Allow-Headers", "Origin, Content-Type")
-
-	return json.NewEncoder(writer).Encode(response)
+	writer.Header().Set("Access-Control-Allow-Headers", "Origin, Content-Type, "+ChangedByHeader)
 }
@@ -1,68 +1,514 @@
 package main
 
 import (
+	"database/sql"
+	"encoding/json"
 	"flag"
+	"fmt"
+	"io"
 	"log"
 	"os"
+	"os/signal"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gocql/gocql"
+	_ "github.com/lib/pq"
+	businessContract "github.com/micro-business/AddressService/business/contract"
+	"github.com/micro-business/AddressService/business/domain"
 	businessService "github.com/micro-business/AddressService/business/service"
 	"github.com/micro-business/AddressService/config"
+	"github.com/micro-business/AddressService/data/contract"
+	"github.com/micro-business/AddressService/data/encryption"
+	"github.com/micro-business/AddressService/data/migration"
+	"github.com/micro-business/AddressService/data/repair"
 	dataService "github.com/micro-business/AddressService/data/service"
 	"github.com/micro-business/AddressService/endpoint"
 	"github.com/micro-business/Micro-Business-Core/common/diagnostics"
 	"github.com/micro-business/Micro-Business-Core/system"
+	"golang.org/x/net/context"
 )
 
 var consulAddress string
 var consulScheme string
 var listeningPort int
+var adminListeningPort int
+var requestTimeout time.Duration
 var cassandraHosts string
 var cassandraKeyspace string
 var cassandraProtoclVersion int
+var sqlDriverName string
+var sqlDataSourceName string
+var dataStore string
+var deletedAddressGracePeriod time.Duration
+var deletedAddressPurgeInterval time.Duration
+var migrateOnStartup bool
+var cacheSize int
+var cacheTTL time.Duration
+var masterKeyFile string
+var eventPublisher string
+var eventFile string
+var eventRelayInterval time.Duration
+var duplicatePolicy string
+
+const (
+	cassandraDataStore = "cassandra"
+	inMemoryDataStore  = "in-memory"
+	sqlDataStore       = "sql"
+
+	migrateCommand   = "migrate"
+	repairCommand    = "repair"
+	exportCommand    = "export"
+	importCommand    = "import"
+	reEncryptCommand = "reencrypt"
+	purgeCommand     = "purge"
+
+	stdoutEventPublisher = "stdout"
+	fileEventPublisher   = "file"
+
+	// migrationLockWaitTimeout is how long to wait for another instance applying the migrations to finish.
+	migrationLockWaitTimeout = 5 * time.Minute
+)
 
 func main() {
 	flag.StringVar(&consulAddress, "consul-address", "", "The consul address in form of host:port. The default value is empty string.")
 	flag.StringVar(&consulScheme, "consul-scheme", "", "The consul scheme. The default value is empty string.")
 	flag.IntVar(&listeningPort, "listening-port", 0, "The port the application is serving HTTP request on. The default is zero.")
+	flag.IntVar(&adminListeningPort, "admin-listening-port", 0, "The port the administrative API is served on, or zero to not serve it. The default is zero.")
+	flag.DurationVar(&requestTimeout, "request-timeout", 0, "How long a request may take before it is canceled, e.g. 10s. The default is zero.")
 	flag.StringVar(&cassandraHosts, "cassandra-hosts", "", "The list of cassandra hosts to connect to. The default value is empty string.")
 	flag.StringVar(&cassandraKeyspace, "cassandra-keyspace", "", "The cassandra keyspace. The default value is empty string.")
 	flag.IntVar(&cassandraProtoclVersion, "cassandra-protocl-version", 0, "The cassandra protocl version. The default value is zero.")
+	flag.StringVar(&sqlDriverName, "sql-driver-name", "", "The database/sql driver name, e.g. postgres. The default value is empty string.")
+	flag.StringVar(&sqlDataSourceName, "sql-data-source-name", "", "The SQL database data source name. The default value is empty string.")
+	flag.StringVar(&dataStore, "data-store", cassandraDataStore, "The data store to keep the addresses in, either cassandra, sql or in-memory. The default value is cassandra.")
+	flag.DurationVar(&deletedAddressGracePeriod, "deleted-address-grace-period", 0, "How long a deleted address can be restored before it is purged, e.g. 24h. The default is 72h.")
+	flag.DurationVar(&deletedAddressPurgeInterval, "deleted-address-purge-interval", 0, "How often the deleted addresses whose grace period is over are purged, e.g. 1h, or zero to not purge them. The default is zero.")
+	flag.IntVar(&cacheSize, "cache-size", 0, "The maximum number of addresses cached in memory, or zero to disable the cache. The default is zero.")
+	flag.DurationVar(&cacheTTL, "cache-ttl", time.Minute, "How long an address is cached in memory, e.g. 30s. The default is one minute.")
+	flag.StringVar(&masterKeyFile, "master-key-file", "", "The JSON file holding the master keys used to encrypt the address values stored in Cassandra. The values are stored in plaintext if it is not provided.")
+	flag.StringVar(&eventPublisher, "event-publisher", "", "Where to publish the address change events, either stdout or file, or empty to record no event. The default value is empty string.")
+	flag.StringVar(&eventFile, "event-file", "", "The file the address change events are appended to when -event-publisher is file. The default value is empty string.")
+	flag.DurationVar(&eventRelayInterval, "event-relay-interval", time.Second, "How often the address change events waiting in the outbox are published, e.g. 5s. The default is one second.")
+	flag.StringVar(&duplicatePolicy, "duplicate-policy", "", "What to do when an address with the same normalized details already exists on create, either warn, reject or return-existing, or empty to disable duplicate detection. The default value is empty string.")
+	flag.BoolVar(&migrateOnStartup, "migrate-on-startup", false, "Whether to apply the pending Cassandra schema migrations on startup. The default value is false.")
+	flag.Usage = func() {
+		fmt.Fprintf(
+			os.Stderr,
+			"Usage: %s [flags]\n"+
+				"       %s [flags] %s up|down|status\n"+
+				"       %s [flags] %s --tenant <tenant ID> [--dry-run]\n"+
+				"       %s [flags] %s --tenant <tenant ID> --application <application ID> [--format jsonl|csv] [--output <file>] [--checkpoint <file>]\n"+
+				"       %s [flags] %s --tenant <tenant ID> --application <application ID> [--format jsonl|csv] [--input <file>] [--checkpoint <file>] [--changed-by <who>]\n"+
+				"       %s -master-key-file <file> [flags] %s --tenant <tenant ID> --writes-stopped\n"+
+				"       %s [flags] %s --tenant <tenant ID> [--application <application ID>] [--requested-by <who>]\n",
+			os.Args[0],
+			os.Args[0],
+			migrateCommand,
+			os.Args[0],
+			repairCommand,
+			os.Args[0],
+			exportCommand,
+			os.Args[0],
+			importCommand,
+			os.Args[0],
+			reEncryptCommand,
+			os.Args[0],
+			purgeCommand)
+		flag.PrintDefaults()
+	}
 	flag.Parse()
 
 	consulConfigurationReader := config.ConsulConfigurationReader{ConsulAddress: consulAddress, ConsulScheme: consulScheme}
 
 	setConsulConfigurationValuesRequireToBeOverriden(&consulConfigurationReader)
 
-	endpoint := endpoint.Endpoint{ConfigurationReader: consulConfigurationReader}
+	if flag.Arg(0) == migrateCommand {
+		runMigrateCommand(consulConfigurationReader, flag.Arg(1))
+
+		return
+	}
+
+	if flag.Arg(0) == repairCommand {
+		runRepairCommand(consulConfigurationReader, flag.Args()[1:])
+
+		return
+	}
+
+	uuidGeneratorService := system.UUIDGeneratorServiceImpl{}
+
+	if flag.Arg(0) == reEncryptCommand {
+		runReEncryptCommand(consulConfigurationReader, &uuidGeneratorService, flag.Args()[1:])
+
+		return
+	}
+
+	if flag.Arg(0) == exportCommand {
+		runExportCommand(consulConfigurationReader, &uuidGeneratorService, flag.Args()[1:])
+
+		return
+	}
 
-	cassandraHosts, err := consulConfigurationReader.GetCassandraHosts()
+	if flag.Arg(0) == importCommand {
+		runImportCommand(consulConfigurationReader, &uuidGeneratorService, flag.Args()[1:])
+
+		return
+	}
+
+	if flag.Arg(0) == purgeCommand {
+		runPurgeCommand(consulConfigurationReader, &uuidGeneratorService, flag.Args()[1:])
+
+		return
+	}
+
+	if migrateOnStartup && dataStore == cassandraDataStore {
+		runMigrateCommand(consulConfigurationReader, "up")
+	}
+
+	endpoint := endpoint.Endpoint{ConfigurationReader: consulConfigurationReader, AdminListeningPort: adminListeningPort}
+
+	addressDataService := createAddressDataService(consulConfigurationReader, &uuidGeneratorService)
+
+	if cacheSize > 0 {
+		addressDataService = &dataService.CachingAddressDataService{AddressDataService: addressDataService, Capacity: cacheSize, TTL: cacheTTL}
+	}
+
+	gracePeriod, err := consulConfigurationReader.GetDeletedAddressGracePeriod()
 
 	if err != nil {
 		log.Fatal(err.Error())
+	}
 
-		return
+	purgeInterval, err := consulConfigurationReader.GetDeletedAddressPurgeInterval()
+
+	if err != nil {
+		log.Fatal(err.Error())
 	}
 
-	cassandraKeyspace, err := consulConfigurationReader.GetCassandraKeyspace()
+	addressService := businessService.AddressService{
+		AddressDataService:        addressDataService,
+		DeletedAddressGracePeriod: gracePeriod,
+		DuplicatePolicy:           parseDuplicatePolicy(),
+	}
+
+	stopPurging := startPurgingExpiredAddresses(addressService, purgeInterval)
+	stopRelaying := startRelayingEvents(addressDataService)
+
+	endpoint.AddressService = addressService
+
+	endpoint.StartServer()
+
+	close(stopPurging)
+	close(stopRelaying)
+
+	closeAddressDataService(addressDataService)
+}
+
+// closeAddressDataService releases the resources held by the address data service if it needs closing.
+func closeAddressDataService(addressDataService contract.AddressDataService) {
+	if closer, ok := addressDataService.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			log.Println(err.Error())
+		}
+	}
+}
+
+// createAddressDataService creates the address data service keeping the addresses in the data store chosen by
+// -data-store flag.
+func createAddressDataService(
+	configurationReader config.ConfigurationReader,
+	uuidGeneratorService system.UUIDGeneratorService) contract.AddressDataService {
+	if len(masterKeyFile) != 0 && dataStore != cassandraDataStore {
+		log.Fatalf("-master-key-file is supported only by %s data store.", cassandraDataStore)
+	}
+
+	switch dataStore {
+	case cassandraDataStore:
+		return createCassandraAddressDataService(configurationReader, uuidGeneratorService)
+	case sqlDataStore:
+		return createSQLAddressDataService(configurationReader, uuidGeneratorService)
+	case inMemoryDataStore:
+		return &dataService.InMemoryAddressDataService{UUIDGeneratorService: uuidGeneratorService, RecordEvents: len(eventPublisher) != 0}
+	}
+
+	log.Fatalf(
+		"Unknown data store %s. Supported data stores are %s, %s and %s.",
+		dataStore,
+		cassandraDataStore,
+		sqlDataStore,
+		inMemoryDataStore)
+
+	return nil
+}
+
+func createCassandraAddressDataService(
+	configurationReader config.ConfigurationReader,
+	uuidGeneratorService system.UUIDGeneratorService) contract.AddressDataService {
+	addressDataService, err := dataService.NewAddressDataService(uuidGeneratorService, createCassandraClusterConfig(configurationReader))
 
 	if err != nil {
 		log.Fatal(err.Error())
+	}
 
-		return
+	if len(masterKeyFile) != 0 {
+		addressDataService.KeyProvider = encryption.LocalFileKeyProvider{Path: masterKeyFile}
+	}
+
+	addressDataService.RecordEvents = len(eventPublisher) != 0
+
+	return addressDataService
+}
+
+// runMigrateCommand applies, reverts or prints the status of the Cassandra schema migrations. An interrupt cancels the
+// run, which releases the lock before exiting.
+func runMigrateCommand(configurationReader config.ConfigurationReader, subcommand string) {
+	migrator := migration.Migrator{ClusterConfig: createCassandraClusterConfig(configurationReader), LockWaitTimeout: migrationLockWaitTimeout}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	defer signal.Stop(interrupts)
+
+	go func() {
+		select {
+		case <-interrupts:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	switch subcommand {
+	case "up":
+		if err := migrator.Up(ctx); err != nil {
+			log.Fatal(err.Error())
+		}
+	case "down":
+		if err := migrator.Down(ctx); err != nil {
+			log.Fatal(err.Error())
+		}
+	case "status":
+		statuses, err := migrator.Status(ctx)
+
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+
+		for _, status := range statuses {
+			appliedAt := "pending"
+
+			if !status.AppliedAt.IsZero() {
+				appliedAt = "applied at " + status.AppliedAt.Format(time.RFC3339)
+			}
+
+			fmt.Printf("%d\t%s\t%s\n", status.Version, status.Description, appliedAt)
+		}
+	default:
+		log.Fatalf("Unknown %s command %s. Supported commands are up, down and status.", migrateCommand, subcommand)
+	}
+}
+
+// runRepairCommand checks the address index table of a tenant against the address table, fixes the inconsistencies unless
+// it is a dry run and prints the report as JSON. The command exits with non-zero status if an inconsistency is left
+// unrepaired.
+func runRepairCommand(configurationReader config.ConfigurationReader, args []string) {
+	repairFlags := flag.NewFlagSet(repairCommand, flag.ExitOnError)
+	tenant := repairFlags.String("tenant", "", "The unique identifier of the tenant whose addresses to check.")
+	dryRun := repairFlags.Bool("dry-run", false, "Whether to only report the inconsistencies without fixing them. The default value is false.")
+	repairFlags.Parse(args)
+
+	tenantID, err := system.ParseUUID(*tenant)
+
+	if err != nil {
+		log.Fatalf("Invalid tenant ID %s. Error: %s", *tenant, err.Error())
+	}
+
+	repairer := repair.Repairer{ClusterConfig: createCassandraClusterConfig(configurationReader), DryRun: *dryRun}
+
+	if len(masterKeyFile) != 0 {
+		repairer.KeyProvider = encryption.LocalFileKeyProvider{Path: masterKeyFile}
 	}
 
-	cassandraProtocolVersion, err := consulConfigurationReader.GetCassandraProtocolVersion()
+	report, err := repairer.Repair(context.Background(), tenantID)
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+
+	if encodeErr := encoder.Encode(report); encodeErr != nil {
+		log.Fatal(encodeErr.Error())
+	}
 
 	if err != nil {
 		log.Fatal(err.Error())
+	}
 
-		return
+	for _, inconsistency := range report.Inconsistencies {
+		if !inconsistency.Repaired {
+			os.Exit(1)
+		}
 	}
+}
 
-	uuidGeneratorService := system.UUIDGeneratorServiceImpl{}
+// runReEncryptCommand rotates the data key of a tenant, encrypts all the address values of the tenant with the new data
+// key and prints the report as JSON.
+func runReEncryptCommand(configurationReader config.ConfigurationReader, uuidGeneratorService system.UUIDGeneratorService, args []string) {
+	reEncryptFlags := flag.NewFlagSet(reEncryptCommand, flag.ExitOnError)
+	tenant := reEncryptFlags.String("tenant", "", "The unique identifier of the tenant whose addresses to re-encrypt.")
+	writesStopped := reEncryptFlags.Bool("writes-stopped", false, "Acknowledges that the writes to the tenant are stopped while its addresses are re-encrypted.")
+	reEncryptFlags.Parse(args)
+
+	tenantID, err := system.ParseUUID(*tenant)
+
+	if err != nil {
+		log.Fatalf("Invalid tenant ID %s. Error: %s", *tenant, err.Error())
+	}
+
+	if len(masterKeyFile) == 0 {
+		log.Fatalf("%s command requires -master-key-file.", reEncryptCommand)
+	}
+
+	if !*writesStopped {
+		log.Fatalf("%s command requires --writes-stopped, as the index rows of the addresses changed while they are re-encrypted may be left stale.", reEncryptCommand)
+	}
+
+	if err = reEncryptTenant(configurationReader, uuidGeneratorService, tenantID); err != nil {
+		log.Fatal(err.Error())
+	}
+}
+
+// reEncryptTenant re-encrypts the addresses of a tenant and prints the report as JSON, even if the re-encryption fails
+// part way. The address data service is closed before returning.
+func reEncryptTenant(configurationReader config.ConfigurationReader, uuidGeneratorService system.UUIDGeneratorService, tenantID system.UUID) error {
+	addressDataService, err := dataService.NewAddressDataService(uuidGeneratorService, createCassandraClusterConfig(configurationReader))
+
+	if err != nil {
+		return err
+	}
+
+	defer closeAddressDataService(addressDataService)
+
+	addressDataService.KeyProvider = encryption.LocalFileKeyProvider{Path: masterKeyFile}
+
+	report, err := addressDataService.ReEncrypt(context.Background(), tenantID)
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+
+	if encodeErr := encoder.Encode(report); encodeErr != nil {
+		return encodeErr
+	}
+
+	return err
+}
+
+// erasureCertificateOutput is the erasure certificate printed by the purge command.
+type erasureCertificateOutput struct {
+	CertificateID string           `json:"certificateId"`
+	TenantID      string           `json:"tenantId"`
+	ApplicationID string           `json:"applicationId,omitempty"`
+	RequestedBy   string           `json:"requestedBy"`
+	StartedAt     time.Time        `json:"startedAt"`
+	CompletedAt   time.Time        `json:"completedAt"`
+	ErasedRows    map[string]int64 `json:"erasedRows"`
+}
+
+// runPurgeCommand removes for good all the addresses of a tenant, or of a tenant's application if --application is
+// provided, and prints the erasure certificate as JSON. Running the command again resumes an interrupted erasure.
+func runPurgeCommand(configurationReader config.ConfigurationReader, uuidGeneratorService system.UUIDGeneratorService, args []string) {
+	purgeFlags := flag.NewFlagSet(purgeCommand, flag.ExitOnError)
+	tenant := purgeFlags.String("tenant", "", "The unique identifier of the tenant to erase.")
+	application := purgeFlags.String("application", "", "The unique identifier of the tenant's application to erase. All the tenant's applications are erased if it is not provided.")
+	requestedBy := purgeFlags.String("requested-by", "", "Identifies who requests the erasure, recorded in the erasure certificate.")
+	purgeFlags.Parse(args)
+
+	tenantID, err := system.ParseUUID(*tenant)
+
+	if err != nil {
+		log.Fatalf("Invalid tenant ID %s. Error: %s", *tenant, err.Error())
+	}
+
+	applicationID := system.EmptyUUID
+
+	if len(*application) != 0 {
+		if applicationID, err = system.ParseUUID(*application); err != nil {
+			log.Fatalf("Invalid application ID %s. Error: %s", *application, err.Error())
+		}
+
+		// An empty application ID would otherwise erase all the tenant's applications.
+		if applicationID == system.EmptyUUID {
+			log.Fatalf("Invalid application ID %s.", *application)
+		}
+	}
+
+	if err = purgeAddresses(configurationReader, uuidGeneratorService, tenantID, applicationID, *requestedBy); err != nil {
+		log.Fatal(err.Error())
+	}
+}
+
+// purgeAddresses removes for good all the addresses of a tenant, or of a tenant's application if applicationID is not
+// empty, and prints the erasure certificate as JSON. The address data service is closed before returning.
+func purgeAddresses(
+	configurationReader config.ConfigurationReader,
+	uuidGeneratorService system.UUIDGeneratorService,
+	tenantID, applicationID system.UUID,
+	requestedBy string) error {
+	addressDataService := createAddressDataService(configurationReader, uuidGeneratorService)
+	defer closeAddressDataService(addressDataService)
+
+	addressService := businessService.AddressService{AddressDataService: addressDataService}
+
+	var certificate domain.ErasureCertificate
+	var err error
+
+	if applicationID == system.EmptyUUID {
+		certificate, err = addressService.PurgeTenant(context.Background(), tenantID, requestedBy)
+	} else {
+		certificate, err = addressService.PurgeApplication(context.Background(), tenantID, applicationID, requestedBy)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	output := erasureCertificateOutput{
+		CertificateID: certificate.CertificateID.String(),
+		TenantID:      certificate.TenantID.String(),
+		RequestedBy:   certificate.RequestedBy,
+		StartedAt:     certificate.StartedAt,
+		CompletedAt:   certificate.CompletedAt,
+		ErasedRows:    certificate.ErasedRows,
+	}
+
+	if certificate.ApplicationID != system.EmptyUUID {
+		output.ApplicationID = certificate.ApplicationID.String()
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(output)
+}
+
+func createCassandraClusterConfig(configurationReader config.ConfigurationReader) *gocql.ClusterConfig {
+	cassandraHosts, err := configurationReader.GetCassandraHosts()
+
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+
+	cassandraKeyspace, err := configurationReader.GetCassandraKeyspace()
+
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+
+	cassandraProtocolVersion, err := configurationReader.GetCassandraProtocolVersion()
+
+	if err != nil {
+		log.Fatal(err.Error())
+	}
 
 	cluster := gocql.NewCluster()
 	cluster.Hosts = cassandraHosts
@@ -70,12 +516,136 @@ func main() {
 	cluster.Keyspace = cassandraKeyspace
 	cluster.Consistency = gocql.Quorum
 
-	addressDataService := dataService.AddressDataService{UUIDGeneratorService: &uuidGeneratorService, ClusterConfig: cluster}
-	addressService := businessService.AddressService{AddressDataService: &addressDataService}
+	return cluster
+}
 
-	endpoint.AddressService = addressService
+func createSQLAddressDataService(
+	configurationReader config.ConfigurationReader,
+	uuidGeneratorService system.UUIDGeneratorService) contract.AddressDataService {
+	driverName, err := configurationReader.GetSQLDriverName()
 
-	endpoint.StartServer()
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+
+	dataSourceName, err := configurationReader.GetSQLDataSourceName()
+
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+
+	db, err := sql.Open(driverName, dataSourceName)
+
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+
+	return dataService.SQLAddressDataService{UUIDGeneratorService: uuidGeneratorService, DB: db, RecordEvents: len(eventPublisher) != 0}
+}
+
+// startPurgingExpiredAddresses purges the deleted addresses whose grace period is over every purge interval until the
+// returned channel is closed. Purging is disabled if the purge interval is not positive.
+func startPurgingExpiredAddresses(addressService businessService.AddressService, purgeInterval time.Duration) chan struct{} {
+	stop := make(chan struct{})
+
+	if purgeInterval <= 0 {
+		return stop
+	}
+
+	go func() {
+		ticker := time.NewTicker(purgeInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := addressService.PurgeExpired(context.Background()); err != nil {
+					log.Println(err.Error())
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	return stop
+}
+
+// parseDuplicatePolicy returns the duplicate address policy chosen by -duplicate-policy flag.
+func parseDuplicatePolicy() businessService.DuplicateAddressPolicy {
+	policy := businessService.DuplicateAddressPolicy(duplicatePolicy)
+
+	switch policy {
+	case businessService.IgnoreDuplicateAddress,
+		businessService.WarnOnDuplicateAddress,
+		businessService.RejectDuplicateAddress,
+		businessService.ReturnExistingAddress:
+		return policy
+	}
+
+	log.Fatalf(
+		"Unknown duplicate policy %s. Supported duplicate policies are %s, %s and %s.",
+		duplicatePolicy,
+		businessService.WarnOnDuplicateAddress,
+		businessService.RejectDuplicateAddress,
+		businessService.ReturnExistingAddress)
+
+	return businessService.IgnoreDuplicateAddress
+}
+
+// createEventPublisher creates the event publisher chosen by -event-publisher flag, or returns nil if no event is recorded.
+func createEventPublisher() businessContract.EventPublisher {
+	switch eventPublisher {
+	case "":
+		return nil
+	case stdoutEventPublisher:
+		return businessService.StdoutEventPublisher{}
+	case fileEventPublisher:
+		if len(eventFile) == 0 {
+			log.Fatalf("%s event publisher requires -event-file.", fileEventPublisher)
+		}
+
+		return businessService.FileEventPublisher{Path: eventFile}
+	}
+
+	log.Fatalf("Unknown event publisher %s. Supported event publishers are %s and %s.", eventPublisher, stdoutEventPublisher, fileEventPublisher)
+
+	return nil
+}
+
+// startRelayingEvents publishes the events waiting in the outbox every event relay interval until the returned channel is
+// closed. Relaying is disabled if no event publisher is chosen.
+func startRelayingEvents(addressDataService contract.AddressDataService) chan struct{} {
+	stop := make(chan struct{})
+	publisher := createEventPublisher()
+
+	if publisher == nil {
+		return stop
+	}
+
+	if eventRelayInterval <= 0 {
+		log.Fatal("-event-relay-interval must be positive.")
+	}
+
+	eventRelay := businessService.EventRelay{AddressDataService: addressDataService, EventPublisher: publisher}
+
+	go func() {
+		ticker := time.NewTicker(eventRelayInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := eventRelay.Relay(context.Background()); err != nil {
+					log.Println(err.Error())
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	return stop
 }
 
 func setConsulConfigurationValuesRequireToBeOverriden(consulConfigurationReader *config.ConsulConfigurationReader) {
@@ -87,6 +657,10 @@ func setConsulConfigurationValuesRequireToBeOverriden(consulConfigurationReader
 		consulConfigurationReader.ListeningPortToOverride = port
 	}
 
+	if requestTimeout != 0 {
+		consulConfigurationReader.RequestTimeoutToOverride = requestTimeout
+	}
+
 	if len(cassandraHosts) != 0 {
 		consulConfigurationReader.CassandraHostsToOverride = strings.Split(cassandraHosts, ",")
 	}
@@ -98,4 +672,20 @@ func setConsulConfigurationValuesRequireToBeOverriden(consulConfigurationReader
 	if cassandraProtoclVersion != 0 {
 		consulConfigurationReader.CassandraProtocolVersionToOverride = cassandraProtoclVersion
 	}
+
+	if len(sqlDriverName) != 0 {
+		consulConfigurationReader.SQLDriverNameToOverride = sqlDriverName
+	}
+
+	if len(sqlDataSourceName) != 0 {
+		consulConfigurationReader.SQLDataSourceNameToOverride = sqlDataSourceName
+	}
+
+	if deletedAddressGracePeriod != 0 {
+		consulConfigurationReader.DeletedAddressGracePeriodToOverride = deletedAddressGracePeriod
+	}
+
+	if deletedAddressPurgeInterval != 0 {
+		consulConfigurationReader.DeletedAddressPurgeIntervalToOverride = deletedAddressPurgeInterval
+	}
 }
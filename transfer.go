@@ -0,0 +1,278 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+
+	businessService "github.com/micro-business/AddressService/business/service"
+	"github.com/micro-business/AddressService/config"
+	"github.com/micro-business/Micro-Business-Core/system"
+	"golang.org/x/net/context"
+)
+
+// exportCheckpoint is saved by export command after every page of addresses is written to the output file.
+type exportCheckpoint struct {
+	// Cursor is the cursor to resume the export from.
+	Cursor string `json:"cursor"`
+
+	// Offset is the size of the output file when the checkpoint was saved. The output file is truncated to it when the
+	// export is resumed, so the addresses written after the checkpoint are not written twice.
+	Offset int64 `json:"offset"`
+
+	// Completed is true once all the addresses are written.
+	Completed bool `json:"completed"`
+}
+
+// importCheckpoint is saved by import command after every address is imported.
+type importCheckpoint struct {
+	// Records is the number of addresses read so far, which are skipped when the import is resumed.
+	Records int `json:"records"`
+}
+
+// runExportCommand writes the addresses of a tenant's application to the output file, or to the standard output if no
+// file is provided. If a checkpoint file is provided, the export is resumed from it and it is updated after every page.
+func runExportCommand(configurationReader config.ConfigurationReader, uuidGeneratorService system.UUIDGeneratorService, args []string) {
+	exportFlags := flag.NewFlagSet(exportCommand, flag.ExitOnError)
+	tenant := exportFlags.String("tenant", "", "The unique identifier of the tenant owning the addresses.")
+	application := exportFlags.String("application", "", "The unique identifier of the tenant's application owning the addresses.")
+	format := exportFlags.String("format", businessService.JSONLinesFormat, "The format to write the addresses in, either jsonl or csv. The default value is jsonl.")
+	output := exportFlags.String("output", "", "The file to write the addresses to. The default is the standard output.")
+	checkpointPath := exportFlags.String("checkpoint", "", "The file to save the export progress to and resume the export from. It requires --output.")
+	exportFlags.Parse(args)
+
+	tenantID, applicationID := parseOwner(*tenant, *application)
+
+	if len(*checkpointPath) != 0 && len(*output) == 0 {
+		log.Fatal("--checkpoint requires --output.")
+	}
+
+	checkpoint := exportCheckpoint{}
+
+	if err := readCheckpoint(*checkpointPath, &checkpoint); err != nil {
+		log.Fatal(err.Error())
+	}
+
+	if checkpoint.Completed {
+		log.Println("Export is already completed.")
+
+		return
+	}
+
+	file := os.Stdout
+
+	if len(*output) != 0 {
+		var err error
+
+		if file, err = openExportOutput(*output, checkpoint.Offset); err != nil {
+			log.Fatal(err.Error())
+		}
+
+		defer file.Close()
+	}
+
+	writer := bufio.NewWriter(file)
+
+	addressDataService := createAddressDataService(configurationReader, uuidGeneratorService)
+	defer closeAddressDataService(addressDataService)
+
+	addressExporter := businessService.AddressExporter{AddressDataService: addressDataService}
+
+	err := addressExporter.Export(context.Background(), tenantID, applicationID, *format, checkpoint.Cursor, writer, func(cursor string) error {
+		if err := writer.Flush(); err != nil {
+			return err
+		}
+
+		if len(*checkpointPath) == 0 {
+			return nil
+		}
+
+		offset, err := file.Seek(0, io.SeekCurrent)
+
+		if err != nil {
+			return err
+		}
+
+		return saveCheckpoint(*checkpointPath, exportCheckpoint{Cursor: cursor, Offset: offset, Completed: len(cursor) == 0})
+	})
+
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+}
+
+// runImportCommand creates the addresses read from the input file, or from the standard input if no file is provided, for
+// a tenant's application and prints the result of importing every address as JSON Lines. If a checkpoint file is
+// provided, the addresses it records as imported are skipped and it is updated after every address. The command exits
+// with non-zero status if an address is not imported.
+func runImportCommand(configurationReader config.ConfigurationReader, uuidGeneratorService system.UUIDGeneratorService, args []string) {
+	importFlags := flag.NewFlagSet(importCommand, flag.ExitOnError)
+	tenant := importFlags.String("tenant", "", "The unique identifier of the tenant will be owning the addresses.")
+	application := importFlags.String("application", "", "The unique identifier of the tenant's application will be owning the addresses.")
+	format := importFlags.String("format", businessService.JSONLinesFormat, "The format to read the addresses in, either jsonl or csv. The default value is jsonl.")
+	input := importFlags.String("input", "", "The file to read the addresses from. The default is the standard input.")
+	checkpointPath := importFlags.String("checkpoint", "", "The file to save the import progress to and resume the import from.")
+	changedBy := importFlags.String("changed-by", "", "Identifies who imports the addresses, recorded in the address history.")
+	importFlags.Parse(args)
+
+	tenantID, applicationID := parseOwner(*tenant, *application)
+
+	checkpoint := importCheckpoint{}
+
+	if err := readCheckpoint(*checkpointPath, &checkpoint); err != nil {
+		log.Fatal(err.Error())
+	}
+
+	reader := io.Reader(os.Stdin)
+
+	if len(*input) != 0 {
+		file, err := os.Open(*input)
+
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+
+		defer file.Close()
+
+		reader = file
+	}
+
+	addressDataService := createAddressDataService(configurationReader, uuidGeneratorService)
+	defer closeAddressDataService(addressDataService)
+
+	addressImporter := businessService.AddressImporter{AddressDataService: addressDataService, DuplicatePolicy: parseDuplicatePolicy()}
+	encoder := json.NewEncoder(os.Stdout)
+	failed := false
+
+	err := addressImporter.Import(
+		context.Background(),
+		tenantID,
+		applicationID,
+		*format,
+		bufio.NewReader(reader),
+		checkpoint.Records,
+		*changedBy,
+		func(result businessService.ImportResult) error {
+			failed = failed || len(result.Error) != 0
+
+			if err := encoder.Encode(result); err != nil {
+				return err
+			}
+
+			if len(*checkpointPath) == 0 {
+				return nil
+			}
+
+			return saveCheckpoint(*checkpointPath, importCheckpoint{Records: result.Record})
+		})
+
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+
+	if failed {
+		closeAddressDataService(addressDataService)
+		os.Exit(1)
+	}
+}
+
+// parseOwner parses the unique identifiers of the tenant and the application provided to export and import commands.
+func parseOwner(tenant, application string) (system.UUID, system.UUID) {
+	tenantID, err := system.ParseUUID(tenant)
+
+	if err != nil {
+		log.Fatalf("Invalid tenant ID %s. Error: %s", tenant, err.Error())
+	}
+
+	applicationID, err := system.ParseUUID(application)
+
+	if err != nil {
+		log.Fatalf("Invalid application ID %s. Error: %s", application, err.Error())
+	}
+
+	return tenantID, applicationID
+}
+
+// openExportOutput opens the export output file. A new export truncates the file, and a resumed export truncates it to the
+// size it had at the checkpoint and appends to it.
+func openExportOutput(path string, offset int64) (*os.File, error) {
+	if offset == 0 {
+		return os.Create(path)
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY, 0)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = file.Truncate(offset); err != nil {
+		file.Close()
+
+		return nil, err
+	}
+
+	if _, err = file.Seek(offset, io.SeekStart); err != nil {
+		file.Close()
+
+		return nil, err
+	}
+
+	return file, nil
+}
+
+// readCheckpoint reads the checkpoint saved to the provided file. The checkpoint is left unchanged if no file is provided
+// or the file does not exist yet.
+func readCheckpoint(path string, checkpoint interface{}) error {
+	if len(path) == 0 {
+		return nil
+	}
+
+	content, err := ioutil.ReadFile(path)
+
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(content, checkpoint)
+}
+
+// saveCheckpoint writes the checkpoint to a temporary file and renames it to the provided file, so the checkpoint is never
+// left half written.
+func saveCheckpoint(path string, checkpoint interface{}) error {
+	content, err := json.Marshal(checkpoint)
+
+	if err != nil {
+		return err
+	}
+
+	temporaryFile, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path))
+
+	if err != nil {
+		return err
+	}
+
+	if _, err = temporaryFile.Write(content); err != nil {
+		temporaryFile.Close()
+		os.Remove(temporaryFile.Name())
+
+		return err
+	}
+
+	if err = temporaryFile.Close(); err != nil {
+		os.Remove(temporaryFile.Name())
+
+		return err
+	}
+
+	return os.Rename(temporaryFile.Name(), path)
+}